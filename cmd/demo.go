@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"time"
@@ -12,6 +13,13 @@ import (
 
 // Demo entrypoint for running the SDK in API mode.
 func main() {
+	trace := flag.String("trace", "", "print an execution trace after the run: mermaid or dot")
+	flag.Parse()
+	if *trace != "" && *trace != string(agent.TraceMermaid) && *trace != string(agent.TraceDOT) {
+		fmt.Println("-trace must be mermaid or dot")
+		return
+	}
+
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
 		fmt.Println("OPENAI_API_KEY is empty; set it to run cmd/demo.go")
@@ -48,4 +56,12 @@ func main() {
 	}
 
 	fmt.Printf("Summary: %s\n", result.Summary)
+
+	if *trace != "" {
+		diagram, err := result.Trace(agent.TraceFormat(*trace))
+		if err != nil {
+			panic(err)
+		}
+		fmt.Print(diagram)
+	}
 }
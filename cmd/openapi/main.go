@@ -0,0 +1,23 @@
+// Command openapi writes the chat server's OpenAPI document.
+//
+// Usage:
+//
+//	go run ./cmd/openapi -o openapi.yaml
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/MimeLyc/agent-core-go/pkg/controller"
+)
+
+func main() {
+	out := flag.String("o", "openapi.yaml", "output file")
+	flag.Parse()
+
+	if err := os.WriteFile(*out, controller.OpenAPIYAML(), 0o644); err != nil {
+		log.Fatalf("write %s: %v", *out, err)
+	}
+}
@@ -0,0 +1,76 @@
+// Command rundiff compares two agent runs and prints where they diverge:
+// differing iterations and tool calls, tool call counts, and token deltas.
+// Each file holds either an AgentResult marshaled as JSON or a transcript,
+// a JSON array of messages such as AgentResult.RawOutput.
+//
+// Usage:
+//
+//	go run ./cmd/rundiff [-json] a.json b.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the diff as JSON")
+	flag.Parse()
+	if flag.NArg() != 2 {
+		log.Fatalf("usage: rundiff [-json] a.json b.json")
+	}
+
+	a, aIsResult, err := load(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, bIsResult, err := load(flag.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var diff agent.RunDiff
+	if aIsResult && bIsResult {
+		diff = agent.CompareResults(a, b)
+	} else {
+		diff = agent.CompareTranscripts(a.RawOutput, b.RawOutput)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diff); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	fmt.Print(diff.Summary())
+}
+
+// load reads an AgentResult or a transcript. A transcript is returned as
+// the RawOutput of an otherwise empty result.
+func load(path string) (agent.AgentResult, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return agent.AgentResult{}, false, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var messages []agenttypes.Message
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return agent.AgentResult{}, false, fmt.Errorf("parse transcript %s: %w", path, err)
+		}
+		return agent.AgentResult{RawOutput: messages}, false, nil
+	}
+	var result agent.AgentResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return agent.AgentResult{}, false, fmt.Errorf("parse result %s: %w", path, err)
+	}
+	return result, true, nil
+}
@@ -2,35 +2,146 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"google.golang.org/grpc"
+
 	"github.com/MimeLyc/agent-core-go/pkg/agent"
 	"github.com/MimeLyc/agent-core-go/pkg/controller"
+	"github.com/MimeLyc/agent-core-go/pkg/guard"
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
 	"github.com/MimeLyc/agent-core-go/pkg/tools/builtin"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
 )
 
 func main() {
 	cfg := loadConfig()
+	if err := cfg.streamBufferPolicy.Validate(); err != nil {
+		log.Fatalf("invalid STREAM_BUFFER_POLICY: %v", err)
+	}
+
+	var redactor *redact.Redactor
+	if cfg.redactionEnabled {
+		redactor = redact.New(redact.Config{Allowlist: cfg.redactionAllowlist})
+		log.SetOutput(redact.NewWriter(os.Stderr, redactor))
+	}
 
-	a, err := createAgent(cfg)
+	agentCfg, err := agentConfig(cfg, redactor)
+	if err != nil {
+		log.Fatalf("failed to create agent: %v", err)
+	}
+	if cfg.usageWebhookURL != "" {
+		reporter, err := agent.NewWebhookUsageReporter(agent.WebhookUsageConfig{
+			URL:          cfg.usageWebhookURL,
+			PerIteration: cfg.usageWebhookPerIteration,
+		})
+		if err != nil {
+			log.Fatalf("failed to create usage webhook: %v", err)
+		}
+		defer reporter.Close()
+		agentCfg.API.UsageReporter = reporter
+	}
+	a, err := agent.NewAgent(agentCfg)
 	if err != nil {
 		log.Fatalf("failed to create agent: %v", err)
 	}
 	defer a.Close()
 
-	chatCtrl := controller.NewChatController(a, controller.ChatConfig{
+	var profiles map[string]agent.Agent
+	if cfg.profilesFile != "" {
+		specs, err := agent.LoadProfiles(cfg.profilesFile)
+		if err != nil {
+			log.Fatalf("failed to load profiles: %v", err)
+		}
+		profiles, err = agent.NewProfileAgents(agentCfg, specs)
+		if err != nil {
+			log.Fatalf("failed to create profile agents: %v", err)
+		}
+		for name, p := range profiles {
+			log.Printf("loaded agent profile %s", name)
+			defer p.Close()
+		}
+	}
+
+	var workspaces *workspace.Manager
+	if cfg.workspaceCacheDir != "" {
+		workspaces, err = workspace.NewManager(workspace.ManagerConfig{
+			Root:         cfg.workspaceCacheDir,
+			AllowedHosts: cfg.workspaceAllowedHosts,
+		})
+		if err != nil {
+			log.Fatalf("failed to create workspace manager: %v", err)
+		}
+	}
+
+	chatCfg := controller.ChatConfig{
 		SystemPrompt:    cfg.systemPrompt,
 		SoulFile:        cfg.soulFile,
 		DefaultDir:      cfg.workDir,
 		EnableStreaming: cfg.streamingEnabled,
-	})
+		SessionLimits: controller.SessionLimits{
+			MaxRuns:          cfg.sessionMaxRuns,
+			MaxTotalTokens:   cfg.sessionMaxTokens,
+			MaxCost:          cfg.sessionMaxCost,
+			IdleTTL:          time.Duration(cfg.sessionIdleTTLSeconds) * time.Second,
+			MaxArtifacts:     cfg.sessionMaxArtifacts,
+			MaxArtifactBytes: cfg.sessionMaxArtifactBytes,
+		},
+		Pricing: controller.TokenPricing{
+			InputPerMillion:  cfg.priceInputPerMillion,
+			OutputPerMillion: cfg.priceOutputPerMillion,
+		},
+		Model:      cfg.model,
+		AdminToken: cfg.adminToken,
+		StreamReplay: controller.StreamReplayConfig{
+			BufferSize: cfg.streamReplayBufferSize,
+			RetainFor:  time.Duration(cfg.streamReplayRetainSeconds) * time.Second,
+		},
+		SSE: controller.SSEConfig{
+			KeepAlive:     time.Duration(cfg.sseKeepAliveSeconds) * time.Second,
+			MaxEventBytes: cfg.sseMaxEventBytes,
+			Gzip:          cfg.sseGzip,
+		},
+		Readiness: controller.ReadinessConfig{
+			Interval: time.Duration(cfg.readinessIntervalSeconds) * time.Second,
+		},
+		StreamBuffer: &agent.StreamBufferConfig{
+			Size:   cfg.streamBufferSize,
+			Policy: cfg.streamBufferPolicy,
+		},
+		Limits: controller.RequestLimits{
+			MaxBodyBytes:    int64(cfg.maxBodyBytes),
+			MaxMessageBytes: cfg.maxMessageBytes,
+			MaxReplyBytes:   cfg.maxReplyBytes,
+			AllowedWorkDirs: cfg.allowedWorkDirs,
+		},
+		Workspaces:      workspaces,
+		Profiles:        profiles,
+		BuiltinCommands: cfg.chatCommands,
+	}
+	chatCtrl := controller.NewChatController(a, chatCfg)
+
+	probeCtx, stopProbe := context.WithCancel(context.Background())
+	defer stopProbe()
+	if cfg.readinessIntervalSeconds >= 0 {
+		if err := chatCtrl.StartReadinessProbe(probeCtx); err != nil {
+			if errors.Is(err, agent.ErrUnauthorized) {
+				log.Fatalf("provider health check failed: %v", err)
+			}
+			log.Printf("WARNING: provider health check failed: %v", err)
+		}
+	}
 
 	mux := http.NewServeMux()
 	chatCtrl.RegisterRoutes(mux)
@@ -54,11 +165,39 @@ func main() {
 		}
 	}()
 
+	var grpcSrv *grpc.Server
+	if cfg.grpcPort > 0 {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.grpcPort))
+		if err != nil {
+			log.Fatalf("grpc listen error: %v", err)
+		}
+		grpcSrv = grpc.NewServer()
+		controller.NewAgentService(a, chatCfg).Register(grpcSrv)
+		go func() {
+			log.Printf("grpc server listening on %s", lis.Addr())
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Fatalf("grpc server error: %v", err)
+			}
+		}()
+	}
+
 	<-done
 	log.Println("shutting down...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	if grpcSrv != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcSrv.Stop()
+		}
+	}
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("shutdown error: %v", err)
 	}
@@ -67,56 +206,218 @@ func main() {
 
 type serverConfig struct {
 	// LLM
-	providerType   agent.ProviderType
-	baseURL        string
-	apiKey         string
-	model          string
-	maxTokens      int
-	timeoutSeconds int
-	maxAttempts    int
+	providerType    agent.ProviderType
+	baseURL         string
+	apiKey          string
+	model           string
+	maxTokens       int
+	timeoutSeconds  int
+	maxAttempts     int
+	thinkingBudget  int
+	promptCaching   bool
+	httpProxy       string
+	caFile          string
+	disableHTTP2    bool
+	maxConnsPerHost int
+	extraHeaders    map[string]string
+	extraBody       map[string]any
+	validation      agent.ResponseValidation
+	instrRoles      agent.InstructionRoles
+	maxReqMessages  int
+	toolPairRepair  agent.ToolPairRepair
+
+	// OpenRouter (LLM_PROVIDER_TYPE=openrouter)
+	openRouterFallbackModels []string
+	openRouterProviderOrder  []string
+	openRouterTransforms     []string
 
 	// Agent
 	maxIterations    int
 	maxMessages      int
+	maxContextTokens int
 	systemPrompt     string
 	soulFile         string
 	workDir          string
 	streamingEnabled bool
+	rateLimitNotes   bool
+	trackFileReads   bool
+	skillTools       agent.SkillToolsMode
+	skillEnvVars     []string
+	askUser          bool
+	profilesFile     string
+	chatCommands     bool
 
 	// Compaction
 	compactEnabled    bool
 	compactThreshold  int
 	compactKeepRecent int
 
+	// Tool result caching
+	toolCacheEnabled bool
+	toolCacheTools   []string
+
+	// Tool retries
+	toolRetries int
+
+	// JSON output compression
+	jsonCompression         bool
+	jsonCompressionMinBytes int
+
+	// Loop detection
+	loopDetection       bool
+	loopDetectionAction string
+
+	// Context pruning
+	pruneToolResults bool
+	pruneThreshold   int
+	pruneKeepRecent  int
+
+	// Workdir watching
+	watchWorkDir bool
+
+	// Usage reporting
+	usageWebhookURL          string
+	usageWebhookPerIteration bool
+
+	// Redaction
+	redactionEnabled   bool
+	redactionAllowlist []string
+
+	// Output guard
+	outputGuardEnabled bool
+
+	// Sessions
+	sessionMaxRuns          int
+	sessionMaxTokens        int
+	sessionMaxCost          float64
+	sessionMaxArtifacts     int
+	sessionMaxArtifactBytes int
+	sessionIdleTTLSeconds   int
+	priceInputPerMillion    float64
+	priceOutputPerMillion   float64
+	adminToken              string
+
+	// Stream resume
+	streamReplayBufferSize    int
+	streamReplayRetainSeconds int
+	sseKeepAliveSeconds       int
+	sseMaxEventBytes          int
+	sseGzip                   bool
+	streamBufferSize          int
+	streamBufferPolicy        agent.StreamBufferPolicy
+
+	// Readiness
+	readinessIntervalSeconds int
+
+	// Request limits
+	maxBodyBytes    int
+	maxMessageBytes int
+	maxReplyBytes   int
+	allowedWorkDirs []string
+
+	// Repo checkouts
+	workspaceCacheDir     string
+	workspaceAllowedHosts []string
+
 	// Server
 	serverPort int
+	grpcPort   int
 }
 
 func loadConfig() serverConfig {
-	return serverConfig{
-		providerType:      agent.ProviderType(envOrDefault("LLM_PROVIDER_TYPE", "openai")),
-		baseURL:           envOrDefault("LLM_BASE_URL", "https://api.openai.com"),
-		apiKey:            os.Getenv("LLM_API_KEY"),
-		model:             envOrDefault("LLM_MODEL", "gpt-4.1"),
-		maxTokens:         envIntOrDefault("LLM_MAX_TOKENS", 4096),
-		timeoutSeconds:    envIntOrDefault("LLM_TIMEOUT_SECONDS", 300),
-		maxAttempts:       envIntOrDefault("LLM_MAX_ATTEMPTS", 5),
-		maxIterations:     envIntOrDefault("AGENT_MAX_ITERATIONS", 0),
-		maxMessages:       envIntOrDefault("AGENT_MAX_MESSAGES", 50),
-		systemPrompt:      os.Getenv("AGENT_SYSTEM_PROMPT"),
-		soulFile:          os.Getenv("AGENT_SOUL_FILE"),
-		workDir:           envOrDefault("AGENT_WORK_DIR", "."),
-		streamingEnabled:  envBoolOrDefault("AGENT_ENABLE_STREAMING", false),
-		compactEnabled:    envBoolOrDefault("COMPACT_ENABLED", false),
-		compactThreshold:  envIntOrDefault("COMPACT_THRESHOLD", 30),
-		compactKeepRecent: envIntOrDefault("COMPACT_KEEP_RECENT", 10),
-		serverPort:        envIntOrDefault("SERVER_PORT", 8080),
+	cfg := serverConfig{
+		providerType:              agent.ProviderType(envOrDefault("LLM_PROVIDER_TYPE", "openai")),
+		baseURL:                   envOrDefault("LLM_BASE_URL", "https://api.openai.com"),
+		apiKey:                    os.Getenv("LLM_API_KEY"),
+		model:                     envOrDefault("LLM_MODEL", "gpt-4.1"),
+		maxTokens:                 envIntOrDefault("LLM_MAX_TOKENS", 4096),
+		timeoutSeconds:            envIntOrDefault("LLM_TIMEOUT_SECONDS", 300),
+		maxAttempts:               envIntOrDefault("LLM_MAX_ATTEMPTS", 5),
+		thinkingBudget:            envIntOrDefault("LLM_THINKING_BUDGET_TOKENS", 0),
+		promptCaching:             envBoolOrDefault("LLM_PROMPT_CACHING", false),
+		httpProxy:                 os.Getenv("LLM_HTTP_PROXY"),
+		caFile:                    os.Getenv("LLM_CA_FILE"),
+		disableHTTP2:              envBoolOrDefault("LLM_DISABLE_HTTP2", false),
+		maxConnsPerHost:           envIntOrDefault("LLM_MAX_CONNS_PER_HOST", 0),
+		extraHeaders:              envHeaders("LLM_EXTRA_HEADERS"),
+		extraBody:                 envJSONObject("LLM_EXTRA_BODY"),
+		validation:                agent.ResponseValidation(envOrDefault("LLM_RESPONSE_VALIDATION", "")),
+		instrRoles:                agent.InstructionRoles(envOrDefault("LLM_INSTRUCTION_ROLES", "")),
+		maxReqMessages:            envIntOrDefault("LLM_MAX_REQUEST_MESSAGES", 0),
+		toolPairRepair:            agent.ToolPairRepair(envOrDefault("TOOL_PAIR_REPAIR", "")),
+		openRouterFallbackModels:  envListOrDefault("OPENROUTER_FALLBACK_MODELS", nil),
+		openRouterProviderOrder:   envListOrDefault("OPENROUTER_PROVIDER_ORDER", nil),
+		openRouterTransforms:      envListOrDefault("OPENROUTER_TRANSFORMS", nil),
+		maxIterations:             envIntOrDefault("AGENT_MAX_ITERATIONS", 0),
+		maxMessages:               envIntOrDefault("AGENT_MAX_MESSAGES", 50),
+		maxContextTokens:          envIntOrDefault("AGENT_MAX_CONTEXT_TOKENS", 0),
+		systemPrompt:              os.Getenv("AGENT_SYSTEM_PROMPT"),
+		soulFile:                  os.Getenv("AGENT_SOUL_FILE"),
+		workDir:                   envOrDefault("AGENT_WORK_DIR", "."),
+		streamingEnabled:          envBoolOrDefault("AGENT_ENABLE_STREAMING", false),
+		rateLimitNotes:            envBoolOrDefault("AGENT_RATE_LIMIT_NOTES", false),
+		trackFileReads:            envBoolOrDefault("TRACK_FILE_READS", false),
+		skillTools:                agent.SkillToolsMode(envOrDefault("SKILL_TOOLS", "")),
+		skillEnvVars:              envListOrDefault("SKILL_ENV_VARS", nil),
+		askUser:                   envBoolOrDefault("AGENT_ASK_USER", false),
+		profilesFile:              envOrDefault("AGENT_PROFILES_FILE", ""),
+		chatCommands:              envBoolOrDefault("CHAT_COMMANDS_ENABLED", true),
+		compactEnabled:            envBoolOrDefault("COMPACT_ENABLED", false),
+		compactThreshold:          envIntOrDefault("COMPACT_THRESHOLD", 30),
+		compactKeepRecent:         envIntOrDefault("COMPACT_KEEP_RECENT", 10),
+		toolCacheEnabled:          envBoolOrDefault("TOOL_CACHE_ENABLED", false),
+		toolCacheTools:            envListOrDefault("TOOL_CACHE_TOOLS", nil),
+		toolRetries:               envIntOrDefault("TOOL_MAX_RETRIES", 0),
+		jsonCompression:           envBoolOrDefault("JSON_COMPRESSION", false),
+		jsonCompressionMinBytes:   envIntOrDefault("JSON_COMPRESSION_MIN_BYTES", 0),
+		loopDetection:             envBoolOrDefault("LOOP_DETECTION", false),
+		loopDetectionAction:       envOrDefault("LOOP_DETECTION_ACTION", string(agent.LoopActionNudge)),
+		pruneToolResults:          envBoolOrDefault("PRUNE_TOOL_RESULTS", false),
+		pruneThreshold:            envIntOrDefault("PRUNE_THRESHOLD", 0),
+		pruneKeepRecent:           envIntOrDefault("PRUNE_KEEP_RECENT", 10),
+		watchWorkDir:              envBoolOrDefault("WATCH_WORKDIR", false),
+		usageWebhookURL:           envOrDefault("USAGE_WEBHOOK_URL", ""),
+		usageWebhookPerIteration:  envBoolOrDefault("USAGE_WEBHOOK_PER_ITERATION", false),
+		redactionEnabled:          envBoolOrDefault("REDACTION_ENABLED", true),
+		redactionAllowlist:        envListOrDefault("REDACTION_ALLOWLIST", nil),
+		outputGuardEnabled:        envBoolOrDefault("OUTPUT_GUARD_ENABLED", false),
+		sessionMaxRuns:            envIntOrDefault("SESSION_MAX_RUNS", 0),
+		sessionMaxTokens:          envIntOrDefault("SESSION_MAX_TOTAL_TOKENS", 0),
+		sessionMaxCost:            envFloatOrDefault("SESSION_MAX_COST", 0),
+		sessionIdleTTLSeconds:     envIntOrDefault("SESSION_IDLE_TTL_SECONDS", 3600),
+		sessionMaxArtifacts:       envIntOrDefault("SESSION_MAX_ARTIFACTS", 100),
+		sessionMaxArtifactBytes:   envIntOrDefault("SESSION_MAX_ARTIFACT_BYTES", 64<<20),
+		priceInputPerMillion:      envFloatOrDefault("PRICE_INPUT_PER_MTOK", 0),
+		priceOutputPerMillion:     envFloatOrDefault("PRICE_OUTPUT_PER_MTOK", 0),
+		adminToken:                os.Getenv("ADMIN_TOKEN"),
+		streamReplayBufferSize:    envIntOrDefault("STREAM_REPLAY_BUFFER_SIZE", 1024),
+		streamReplayRetainSeconds: envIntOrDefault("STREAM_REPLAY_RETAIN_SECONDS", 300),
+		sseKeepAliveSeconds:       envIntOrDefault("SSE_KEEPALIVE_SECONDS", 0),
+		sseMaxEventBytes:          envIntOrDefault("SSE_MAX_EVENT_BYTES", 0),
+		sseGzip:                   envBoolOrDefault("SSE_GZIP", false),
+		streamBufferSize:          envIntOrDefault("STREAM_BUFFER_SIZE", agent.DefaultStreamBufferSize),
+		streamBufferPolicy:        agent.StreamBufferPolicy(envOrDefault("STREAM_BUFFER_POLICY", string(agent.StreamBlock))),
+		readinessIntervalSeconds:  envIntOrDefault("READINESS_INTERVAL_SECONDS", 60),
+		maxBodyBytes:              envIntOrDefault("CHAT_MAX_BODY_BYTES", 1<<20),
+		maxMessageBytes:           envIntOrDefault("CHAT_MAX_MESSAGE_BYTES", 256<<10),
+		maxReplyBytes:             envIntOrDefault("CHAT_MAX_REPLY_BYTES", 0),
+		allowedWorkDirs:           envListOrDefault("CHAT_ALLOWED_WORK_DIRS", nil),
+		workspaceCacheDir:         os.Getenv("WORKSPACE_CACHE_DIR"),
+		workspaceAllowedHosts:     envListOrDefault("WORKSPACE_ALLOWED_HOSTS", []string{"github.com", "gitlab.com", "bitbucket.org"}),
+		serverPort:                envIntOrDefault("SERVER_PORT", 8080),
+		grpcPort:                  envIntOrDefault("GRPC_PORT", 0),
+	}
+	// Requests may only pick work_dir values under the default directory
+	// unless other roots are listed explicitly.
+	if len(cfg.allowedWorkDirs) == 0 {
+		cfg.allowedWorkDirs = []string{cfg.workDir}
 	}
+	return cfg
 }
 
-func createAgent(cfg serverConfig) (agent.Agent, error) {
+func agentConfig(cfg serverConfig, redactor *redact.Redactor) (agent.AgentConfig, error) {
 	if cfg.apiKey == "" {
-		return nil, fmt.Errorf("LLM_API_KEY is required")
+		return agent.AgentConfig{}, fmt.Errorf("LLM_API_KEY is required")
 	}
 
 	var compactCfg *agent.CompactConfig
@@ -128,24 +429,122 @@ func createAgent(cfg serverConfig) (agent.Agent, error) {
 		}
 	}
 
-	return agent.NewAgent(agent.AgentConfig{
+	var toolCache *agent.ToolCacheConfig
+	if cfg.toolCacheEnabled {
+		toolCache = &agent.ToolCacheConfig{
+			Enabled: true,
+			Tools:   cfg.toolCacheTools,
+		}
+	}
+
+	var toolRetry *agent.ToolRetryConfig
+	if cfg.toolRetries > 0 {
+		toolRetry = &agent.ToolRetryConfig{MaxRetries: cfg.toolRetries}
+	}
+
+	var jsonCompression *agent.JSONCompressionConfig
+	if cfg.jsonCompression {
+		jsonCompression = &agent.JSONCompressionConfig{
+			Enabled:  true,
+			MinBytes: cfg.jsonCompressionMinBytes,
+		}
+	}
+
+	var loopDetection *agent.LoopDetectionConfig
+	if cfg.loopDetection {
+		loopDetection = &agent.LoopDetectionConfig{
+			Enabled: true,
+			Action:  agent.LoopAction(cfg.loopDetectionAction),
+		}
+	}
+
+	var prune *agent.PruneConfig
+	if cfg.pruneToolResults {
+		prune = &agent.PruneConfig{
+			ToolResults: true,
+			Threshold:   cfg.pruneThreshold,
+			KeepRecent:  cfg.pruneKeepRecent,
+		}
+	}
+
+	var watchWorkDir *workspace.WatchConfig
+	if cfg.watchWorkDir {
+		watchWorkDir = &workspace.WatchConfig{}
+	}
+
+	var outputGuard guard.OutputGuard
+	if cfg.outputGuardEnabled {
+		outputGuard = guard.NewRegex(guard.RegexConfig{Allowlist: cfg.redactionAllowlist})
+	}
+
+	httpCfg := &agent.HTTPConfig{
+		MaxConnsPerHost: cfg.maxConnsPerHost,
+		DisableHTTP2:    cfg.disableHTTP2,
+		ProxyURL:        cfg.httpProxy,
+		CAFile:          cfg.caFile,
+	}
+
+	// ask_user answers arrive through the stream reply endpoint; artifacts
+	// are downloaded from the session's artifact endpoint.
+	registry := builtin.NewRegistryWithBuiltins()
+	builtin.RegisterArtifactTools(registry)
+	if cfg.askUser && cfg.streamingEnabled {
+		builtin.RegisterUserTools(registry)
+	}
+
+	var openRouter *agent.OpenRouterConfig
+	if cfg.providerType == agent.ProviderTypeOpenRouter {
+		openRouter = &agent.OpenRouterConfig{
+			FallbackModels: cfg.openRouterFallbackModels,
+			Transforms:     cfg.openRouterTransforms,
+		}
+		if len(cfg.openRouterProviderOrder) > 0 {
+			openRouter.Provider = &agent.OpenRouterProviderPreferences{Order: cfg.openRouterProviderOrder}
+		}
+	}
+
+	return agent.AgentConfig{
 		Type: agent.AgentTypeAPI,
 		API: &agent.APIConfig{
-			ProviderType:    cfg.providerType,
-			BaseURL:         cfg.baseURL,
-			APIKey:          cfg.apiKey,
-			Model:           cfg.model,
-			MaxTokens:       cfg.maxTokens,
-			Timeout:         time.Duration(cfg.timeoutSeconds) * time.Second,
-			MaxAttempts:     cfg.maxAttempts,
-			MaxIterations:   cfg.maxIterations,
-			MaxMessages:     cfg.maxMessages,
-			SystemPrompt:    cfg.systemPrompt,
-			CompactConfig:   compactCfg,
-			EnableStreaming: cfg.streamingEnabled,
+			ProviderType:     cfg.providerType,
+			BaseURL:          cfg.baseURL,
+			APIKey:           cfg.apiKey,
+			Model:            cfg.model,
+			MaxTokens:        cfg.maxTokens,
+			Timeout:          time.Duration(cfg.timeoutSeconds) * time.Second,
+			HTTP:             httpCfg,
+			ExtraHeaders:     cfg.extraHeaders,
+			ExtraBody:        cfg.extraBody,
+			OpenRouter:       openRouter,
+			MaxAttempts:      cfg.maxAttempts,
+			MaxIterations:    cfg.maxIterations,
+			MaxMessages:      cfg.maxMessages,
+			MaxContextTokens: cfg.maxContextTokens,
+			SystemPrompt:     cfg.systemPrompt,
+			CompactConfig:    compactCfg,
+			ToolCache:        toolCache,
+			ToolRetry:        toolRetry,
+			JSONCompression:  jsonCompression,
+			LoopDetection:    loopDetection,
+			Prune:            prune,
+			WatchWorkDir:     watchWorkDir,
+			EnableStreaming:  cfg.streamingEnabled,
+			Redactor:         redactor,
+			OutputGuard:      outputGuard,
+
+			ThinkingBudgetTokens: cfg.thinkingBudget,
+			PromptCaching:        cfg.promptCaching,
+			RateLimitNotes:       cfg.rateLimitNotes,
+			TrackFileReads:       cfg.trackFileReads,
+			ResponseValidation:   cfg.validation,
+			InstructionRoles:     cfg.instrRoles,
+			MaxRequestMessages:   cfg.maxReqMessages,
+			ToolPairRepair:       cfg.toolPairRepair,
+			SkillTools:           cfg.skillTools,
+			SkillEnvVars:         cfg.skillEnvVars,
 		},
-		Registry: builtin.NewRegistryWithBuiltins(),
-	})
+		Registry: registry,
+	}, nil
 }
 
 func envOrDefault(key, def string) string {
@@ -155,6 +554,52 @@ func envOrDefault(key, def string) string {
 	return def
 }
 
+func envListOrDefault(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// envHeaders parses a comma-separated list of "Name: value" headers.
+func envHeaders(key string) map[string]string {
+	items := envListOrDefault(key, nil)
+	if len(items) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(items))
+	for _, item := range items {
+		name, value, ok := strings.Cut(item, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			log.Printf("ignoring malformed %s entry %q", key, item)
+			continue
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
+// envJSONObject parses a JSON object, e.g. {"top_k": 40}.
+func envJSONObject(key string) map[string]any {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(v), &obj); err != nil {
+		log.Printf("ignoring malformed %s: %v", key, err)
+		return nil
+	}
+	return obj
+}
+
 func envIntOrDefault(key string, def int) int {
 	v := os.Getenv(key)
 	if v == "" {
@@ -168,6 +613,19 @@ func envIntOrDefault(key string, def int) int {
 	return n
 }
 
+func envFloatOrDefault(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("warning: invalid number for %s=%q, using default %v", key, v, def)
+		return def
+	}
+	return f
+}
+
 func envBoolOrDefault(key string, def bool) bool {
 	v := os.Getenv(key)
 	if v == "" {
@@ -1,3 +1,19 @@
 module github.com/MimeLyc/agent-core-go
 
 go 1.24.0
+
+require (
+	github.com/fsnotify/fsnotify v1.8.0
+	github.com/itchyny/gojq v0.12.17
+	google.golang.org/grpc v1.72.2
+	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/itchyny/timefmt-go v0.1.6 // indirect
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250218202821-56aae31c358a // indirect
+)
@@ -21,6 +21,9 @@ const (
 	defaultClaudeMaxAttempts = 5
 	defaultClaudeBackoffSec  = 2
 	defaultClaudeMaxTokens   = 4096
+
+	// minClaudeThinkingBudget is the smallest budget_tokens the API accepts.
+	minClaudeThinkingBudget = 1024
 )
 
 // ClaudeProvider implements LLMProvider for the Claude API.
@@ -35,6 +38,31 @@ type ClaudeProvider struct {
 	HTTPClient  *http.Client
 	Backoff     func(int) time.Duration
 	Sleep       func(time.Duration)
+
+	// ThinkingBudgetTokens enables extended thinking for requests that do
+	// not set Thinking themselves. Zero disables it.
+	ThinkingBudgetTokens int
+
+	// PromptCaching marks the stable part of the system prompt cacheable
+	// (see LLMProviderConfig.PromptCaching).
+	PromptCaching bool
+
+	// ExtraHeaders and RequestMutator customize each API request (see
+	// LLMProviderConfig).
+	ExtraHeaders   map[string]string
+	RequestMutator func(*http.Request)
+
+	// ExtraBody is merged into every request body (see
+	// LLMProviderConfig.ExtraBody).
+	ExtraBody map[string]any
+
+	// MessageLimit caps the messages per request (see
+	// LLMProviderConfig.MaxRequestMessages).
+	MessageLimit int
+
+	// InstructionRoles selects how system and developer messages are sent
+	// (see LLMProviderConfig.InstructionRoles).
+	InstructionRoles InstructionRoles
 }
 
 // NewClaudeProvider creates a new Claude API provider.
@@ -61,6 +89,15 @@ func NewClaudeProvider(cfg LLMProviderConfig) *ClaudeProvider {
 		MaxTokens:   maxTokens,
 		Timeout:     timeout,
 		MaxAttempts: maxAttempts,
+		HTTPClient:  newProviderHTTPClient(cfg.HTTP, timeout),
+
+		ThinkingBudgetTokens: cfg.ThinkingBudgetTokens,
+		PromptCaching:        cfg.PromptCaching,
+		ExtraHeaders:         cfg.ExtraHeaders,
+		RequestMutator:       cfg.RequestMutator,
+		ExtraBody:            cfg.ExtraBody,
+		MessageLimit:         cfg.MaxRequestMessages,
+		InstructionRoles:     cfg.InstructionRoles,
 	}
 }
 
@@ -94,6 +131,28 @@ func (p *ClaudeProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 		}
 	}
 
+	p.applyThinking(&req)
+	if len(req.Tools) == 0 && len(req.ServerTools) == 0 {
+		req.ToolChoice = nil
+	}
+	if req.ToolChoice != nil && req.ToolChoice.Forced() && req.Thinking != nil {
+		log.Printf("[claude-provider] using tool_choice auto: forced tool use is not supported with extended thinking")
+		choice := *req.ToolChoice
+		choice.Type, choice.Name = ToolChoiceAuto, ""
+		req.ToolChoice = &choice
+	}
+	if req.Seed != nil {
+		log.Printf("[claude-provider] ignoring seed: not supported by the Claude API")
+	}
+	prefill := prefillText(req)
+	if prefill != "" && req.Thinking != nil {
+		log.Printf("[claude-provider] ignoring assistant prefill: not supported with extended thinking")
+		prefill = ""
+	}
+	if prefill != "" {
+		req.Messages = withPrefillMessage(req.Messages, prefill)
+	}
+
 	// Debug: log tool_use and tool_result blocks for debugging
 	var toolUseCount, toolResultCount int
 	var toolUseIDs, toolResultIDs []string
@@ -124,10 +183,13 @@ func (p *ClaudeProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 		log.Printf("[claude-provider] tool_result IDs: %v", toolResultIDs)
 	}
 
-	log.Printf("[claude-provider] calling API: model=%s max_tokens=%d messages=%d tools=%d",
-		req.Model, req.MaxTokens, len(req.Messages), len(req.Tools))
+	log.Printf("[claude-provider] calling API: model=%s max_tokens=%d messages=%d tools=%d server_tools=%d",
+		req.Model, req.MaxTokens, len(req.Messages), len(req.Tools), len(req.ServerTools))
+	if req.Thinking != nil {
+		log.Printf("[claude-provider] extended thinking: type=%s budget_tokens=%d", req.Thinking.Type, req.Thinking.BudgetTokens)
+	}
 
-	payload, err := json.Marshal(req)
+	payload, err := marshalWithExtraBody(newClaudeRequest(req, p.PromptCaching, p.InstructionRoles), p.ExtraBody, req.ExtraBody)
 	if err != nil {
 		return AgentResponse{}, fmt.Errorf("marshal request: %w", err)
 	}
@@ -155,7 +217,6 @@ func (p *ClaudeProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 	if timeout == 0 {
 		timeout = 5 * time.Minute
 	}
-
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -172,8 +233,7 @@ func (p *ClaudeProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 				log.Printf("[claude-provider] response body: %s", string(respBody))
 				// Treat empty/unparseable response with 2xx status as retriable
 				lastErr = parseErr
-				if attempt < maxAttempts {
-					backoffDuration := backoff(attempt)
+				if backoffDuration := backoff(attempt); attempt < maxAttempts && retryFits(ctx, backoffDuration) {
 					log.Printf("[claude-provider] retrying after parse error in %v", backoffDuration)
 					sleep(backoffDuration)
 					continue
@@ -182,6 +242,7 @@ func (p *ClaudeProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 			}
 			log.Printf("[claude-provider] parsed response: id=%s stop_reason=%s content_blocks=%d",
 				resp.ID, resp.StopReason, len(resp.Content))
+			applyPrefill(&resp, prefill)
 			return resp, nil
 		}
 		lastErr = wrapClaudeAPIError(respBody, status, err)
@@ -191,12 +252,110 @@ func (p *ClaudeProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 			return AgentResponse{}, lastErr
 		}
 		backoffDuration := backoff(attempt)
+		if !retryFits(ctx, backoffDuration) {
+			log.Printf("[claude-provider] giving up after %d attempts: retrying in %v would pass the deadline", attempt, backoffDuration)
+			return AgentResponse{}, lastErr
+		}
 		log.Printf("[claude-provider] retrying in %v", backoffDuration)
+		notifyRetry(ctx, RetryNotice{Provider: p.Name(), Attempt: attempt, MaxAttempts: maxAttempts, Status: status, Wait: backoffDuration, Err: lastErr})
 		sleep(backoffDuration)
 	}
 	return AgentResponse{}, lastErr
 }
 
+// claudeRequest is the wire request: AgentRequest with server tools
+// appended to the local tool definitions, and with the system prompt split
+// into text blocks when its stable part is cached.
+type claudeRequest struct {
+	AgentRequest
+	System any   `json:"system,omitempty"`
+	Tools  []any `json:"tools,omitempty"`
+}
+
+// claudeSystemBlock is a text block of the system prompt.
+type claudeSystemBlock struct {
+	Type         string              `json:"type"`
+	Text         string              `json:"text"`
+	CacheControl *claudeCacheControl `json:"cache_control,omitempty"`
+}
+
+type claudeCacheControl struct {
+	Type string `json:"type"`
+}
+
+func newClaudeRequest(req AgentRequest, promptCaching bool, roles InstructionRoles) claudeRequest {
+	// The API takes instructions only in the system prompt: leading system
+	// and developer messages become system blocks, later ones user turns.
+	var instructions []string
+	if roles != InstructionRolesUser {
+		instructions, req.Messages = splitLeadingInstructions(req.Messages)
+	}
+	req.Messages = downgradeInstructionRoles(req.Messages, InstructionRolesUser)
+
+	tools := make([]any, 0, len(req.Tools)+len(req.ServerTools))
+	for _, tool := range req.Tools {
+		tools = append(tools, tool)
+	}
+	for _, tool := range req.ServerTools {
+		tools = append(tools, tool)
+	}
+	wire := claudeRequest{AgentRequest: req, Tools: tools}
+	if req.System != "" {
+		wire.System = req.System
+	}
+	cached := promptCaching && req.StableSystemBytes > 0 && req.StableSystemBytes <= len(req.System)
+	if !cached && len(instructions) == 0 {
+		return wire
+	}
+	var blocks []claudeSystemBlock
+	rest := req.System
+	if cached {
+		// The cache covers the prefix up to the marked block, so the
+		// per-iteration rest goes into a block after it.
+		blocks = append(blocks, claudeSystemBlock{
+			Type:         "text",
+			Text:         req.System[:req.StableSystemBytes],
+			CacheControl: &claudeCacheControl{Type: "ephemeral"},
+		})
+		rest = req.System[req.StableSystemBytes:]
+	}
+	if rest = strings.TrimSpace(rest); rest != "" {
+		blocks = append(blocks, claudeSystemBlock{Type: "text", Text: rest})
+	}
+	for _, text := range instructions {
+		blocks = append(blocks, claudeSystemBlock{Type: "text", Text: text})
+	}
+	wire.System = blocks
+	return wire
+}
+
+// applyThinking fills in the default thinking config and adjusts the
+// request to satisfy the API's extended thinking constraints.
+func (p *ClaudeProvider) applyThinking(req *AgentRequest) {
+	if req.Thinking == nil && p.ThinkingBudgetTokens > 0 {
+		req.Thinking = NewThinkingConfig(p.ThinkingBudgetTokens)
+	}
+	if req.Thinking == nil || req.Thinking.Type != "enabled" {
+		return
+	}
+	if req.Thinking.BudgetTokens < minClaudeThinkingBudget {
+		log.Printf("[claude-provider] raising thinking budget %d to minimum %d",
+			req.Thinking.BudgetTokens, minClaudeThinkingBudget)
+		thinking := *req.Thinking
+		thinking.BudgetTokens = minClaudeThinkingBudget
+		req.Thinking = &thinking
+	}
+	// budget_tokens must be below max_tokens; keep the configured response budget on top.
+	if req.MaxTokens <= req.Thinking.BudgetTokens {
+		req.MaxTokens += req.Thinking.BudgetTokens
+	}
+	// Thinking is incompatible with a custom temperature.
+	if req.Temperature != nil {
+		log.Printf("[claude-provider] dropping temperature: not supported with extended thinking")
+		req.Temperature = nil
+	}
+}
+
 func (p *ClaudeProvider) doRequest(ctx context.Context, client *http.Client, payload []byte) ([]byte, int, error) {
 	endpoint, err := buildClaudeEndpoint(p.BaseURL)
 	if err != nil {
@@ -213,6 +372,7 @@ func (p *ClaudeProvider) doRequest(ctx context.Context, client *http.Client, pay
 	req.Header.Set("x-api-key", p.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("anthropic-version", "2023-06-01")
+	customizeRequest(req, p.ExtraHeaders, p.RequestMutator)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -268,6 +428,9 @@ func wrapClaudeAPIError(body []byte, status int, err error) error {
 		} `json:"error"`
 	}
 	if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+		if isContextOverflow(status, errResp.Error.Type, "", errResp.Error.Message) {
+			return &ContextOverflowError{Provider: "Claude", Status: status, Message: errResp.Error.Message}
+		}
 		return fmt.Errorf("Claude API error %d: %s - %s", status, errResp.Error.Type, errResp.Error.Message)
 	}
 
@@ -275,6 +438,9 @@ func wrapClaudeAPIError(body []byte, status int, err error) error {
 	if msg == "" {
 		msg = http.StatusText(status)
 	}
+	if isContextOverflow(status, "", "", msg) {
+		return &ContextOverflowError{Provider: "Claude", Status: status, Message: msg}
+	}
 	return fmt.Errorf("Claude API error: %d %s", status, msg)
 }
 
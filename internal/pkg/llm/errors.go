@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrContextOverflow is matched (via errors.Is) by errors returned when a
+// provider rejects a request because it exceeds the model context window.
+var ErrContextOverflow = errors.New("context window exceeded")
+
+// ContextOverflowError is returned when a provider rejects a request as too large.
+type ContextOverflowError struct {
+	Provider string
+	Status   int
+	Message  string
+}
+
+func (e *ContextOverflowError) Error() string {
+	return fmt.Sprintf("%s API error %d: context window exceeded - %s", e.Provider, e.Status, e.Message)
+}
+
+// Unwrap lets errors.Is(err, ErrContextOverflow) match.
+func (e *ContextOverflowError) Unwrap() error {
+	return ErrContextOverflow
+}
+
+// IsContextOverflow reports whether err indicates a context window overflow.
+func IsContextOverflow(err error) bool {
+	return errors.Is(err, ErrContextOverflow)
+}
+
+// contextOverflowMarkers are lower-cased fragments providers use in
+// "request too large" error messages.
+var contextOverflowMarkers = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"context length",
+	"context window",
+	"prompt is too long",
+	"too many tokens",
+	"request_too_large",
+	"input is too long",
+}
+
+// CheckContextOverflow returns a *ContextOverflowError when an HTTP error
+// response with the given status, error type, code, and message reports
+// that the request exceeded the context window, and nil otherwise.
+func CheckContextOverflow(provider string, status int, errType, code, message string) error {
+	if !isContextOverflow(status, errType, code, message) {
+		return nil
+	}
+	return &ContextOverflowError{Provider: provider, Status: status, Message: message}
+}
+
+// isContextOverflow classifies an HTTP error response as a context overflow.
+func isContextOverflow(status int, errType, code, message string) bool {
+	if status == http.StatusRequestEntityTooLarge {
+		return true
+	}
+	if status != http.StatusBadRequest {
+		return false
+	}
+	haystack := strings.ToLower(errType + " " + code + " " + message)
+	for _, marker := range contextOverflowMarkers {
+		if strings.Contains(haystack, marker) {
+			return true
+		}
+	}
+	return false
+}
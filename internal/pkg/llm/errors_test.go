@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestWrapAPIErrorDetectsContextOverflow(t *testing.T) {
+	tests := []struct {
+		name         string
+		wrap         func([]byte, int, error) error
+		status       int
+		body         string
+		wantOverflow bool
+	}{
+		{
+			name:         "claude prompt too long",
+			wrap:         wrapClaudeAPIError,
+			status:       http.StatusBadRequest,
+			body:         `{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`,
+			wantOverflow: true,
+		},
+		{
+			name:         "claude request too large",
+			wrap:         wrapClaudeAPIError,
+			status:       http.StatusRequestEntityTooLarge,
+			body:         `{"type":"error","error":{"type":"request_too_large","message":"Request exceeds the maximum allowed number of bytes."}}`,
+			wantOverflow: true,
+		},
+		{
+			name:         "claude other bad request",
+			wrap:         wrapClaudeAPIError,
+			status:       http.StatusBadRequest,
+			body:         `{"type":"error","error":{"type":"invalid_request_error","message":"messages: field required"}}`,
+			wantOverflow: false,
+		},
+		{
+			name:         "openai context_length_exceeded",
+			wrap:         wrapOpenAIAPIError,
+			status:       http.StatusBadRequest,
+			body:         `{"error":{"type":"invalid_request_error","code":"context_length_exceeded","message":"This model's maximum context length is 128000 tokens."}}`,
+			wantOverflow: true,
+		},
+		{
+			name:         "openai-compatible plain text",
+			wrap:         wrapOpenAIAPIError,
+			status:       http.StatusBadRequest,
+			body:         `input exceeds the context window of this model`,
+			wantOverflow: true,
+		},
+		{
+			name:         "openai rate limit",
+			wrap:         wrapOpenAIAPIError,
+			status:       http.StatusTooManyRequests,
+			body:         `{"error":{"type":"rate_limit","message":"too many tokens per minute"}}`,
+			wantOverflow: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.wrap([]byte(tt.body), tt.status, nil)
+			if got := IsContextOverflow(err); got != tt.wantOverflow {
+				t.Fatalf("IsContextOverflow(%v) = %v, want %v", err, got, tt.wantOverflow)
+			}
+			if tt.wantOverflow {
+				var overflowErr *ContextOverflowError
+				if !errors.As(err, &overflowErr) || overflowErr.Status != tt.status {
+					t.Fatalf("expected ContextOverflowError with status %d, got %#v", tt.status, err)
+				}
+			}
+		})
+	}
+}
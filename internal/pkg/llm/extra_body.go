@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// marshalWithExtraBody marshals a provider request and merges extra fields
+// into its top-level JSON object. Layers apply in order, so later ones win,
+// and all of them override the provider's own fields of the same name. A
+// nil value removes the field.
+func marshalWithExtraBody(v any, layers ...map[string]any) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	empty := true
+	for _, layer := range layers {
+		empty = empty && len(layer) == 0
+	}
+	if empty {
+		return payload, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	for _, layer := range layers {
+		for key, value := range layer {
+			if value == nil {
+				delete(fields, key)
+				continue
+			}
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("extra body field %q: %w", key, err)
+			}
+			fields[key] = raw
+		}
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// HealthChecker is an optional extension for providers that can cheaply
+// check that their API is reachable and accepts their credentials, e.g. for
+// a readiness probe at startup.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ErrUnauthorized is matched by errors.Is when a health check was rejected
+// for its credentials (HTTP 401 or 403), which retrying will not fix.
+var ErrUnauthorized = errors.New("provider rejected credentials")
+
+const (
+	claudeModelsPath     = "/v1/models"
+	openaiModelsPath     = "/v1/models"
+	openrouterKeyPath    = "/v1/key"
+	maxHealthBodyToParse = 64 << 10
+)
+
+// HealthCheck lists one model, which needs a valid API key but no tokens.
+func (p *ClaudeProvider) HealthCheck(ctx context.Context) error {
+	if strings.TrimSpace(p.BaseURL) == "" {
+		return errors.New("Claude API base URL is empty")
+	}
+	if strings.TrimSpace(p.APIKey) == "" {
+		return errors.New("Claude API key is empty")
+	}
+	endpoint, err := buildClaudeEndpoint(p.BaseURL)
+	if err != nil {
+		return err
+	}
+	endpoint = strings.TrimSuffix(endpoint, claudeAPIPath) + claudeModelsPath + "?limit=1"
+
+	status, body, err := probe(ctx, p.HTTPClient, http.MethodGet, endpoint, nil, func(req *http.Request) {
+		req.Header.Set("x-api-key", p.APIKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+		customizeRequest(req, p.ExtraHeaders, p.RequestMutator)
+	})
+	return healthError(status, wrapClaudeAPIError(body, status, err))
+}
+
+// HealthCheck lists the models (OpenRouter: reads the key's limits), which
+// needs a valid API key but no tokens. Compatible servers without a models
+// endpoint get a one-token completion instead.
+func (p *OpenAIProvider) HealthCheck(ctx context.Context) error {
+	if strings.TrimSpace(p.BaseURL) == "" {
+		return errors.New("OpenAI API base URL is empty")
+	}
+	if strings.TrimSpace(p.APIKey) == "" {
+		return errors.New("OpenAI API key is empty")
+	}
+	base := strings.TrimSuffix(strings.TrimRight(p.BaseURL, "/"), openaiAPIPath)
+	endpoint := base + openaiModelsPath
+	if p.OpenRouter != nil {
+		endpoint = base + openrouterKeyPath
+	}
+	authorize := func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+		customizeRequest(req, p.ExtraHeaders, p.RequestMutator)
+	}
+
+	status, body, err := probe(ctx, p.HTTPClient, http.MethodGet, endpoint, nil, authorize)
+	if err == nil && (status == http.StatusNotFound || status == http.StatusMethodNotAllowed) {
+		if strings.TrimSpace(p.Model) == "" {
+			return errors.New("OpenAI API model is empty")
+		}
+		ping := fmt.Sprintf(`{"model":%q,"max_tokens":1,"messages":[{"role":"user","content":"ping"}]}`, p.Model)
+		status, body, err = probe(ctx, p.HTTPClient, http.MethodPost, base+openaiAPIPath, strings.NewReader(ping), func(req *http.Request) {
+			req.Header.Set("Content-Type", "application/json")
+			authorize(req)
+		})
+	}
+	return healthError(status, wrapOpenAIAPIError(body, status, err))
+}
+
+// probe sends one request and returns the status and the start of the body.
+func probe(ctx context.Context, client *http.Client, method, endpoint string, body io.Reader, prepare func(*http.Request)) (int, []byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	prepare(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxHealthBodyToParse))
+	return resp.StatusCode, data, nil
+}
+
+// healthError returns nil for a successful probe and marks rejected
+// credentials with ErrUnauthorized. apiErr is the provider's error for the
+// response.
+func healthError(status int, apiErr error) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return fmt.Errorf("%w: %v", ErrUnauthorized, apiErr)
+	case status == 0 || status >= 400:
+		return apiErr
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClaudeProviderHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/models" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"claude-test"}]}`))
+	}))
+	defer server.Close()
+
+	good := NewClaudeProvider(LLMProviderConfig{BaseURL: server.URL, APIKey: "good-key", Model: "claude-test"})
+	if err := good.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() = %v, want nil", err)
+	}
+
+	bad := NewClaudeProvider(LLMProviderConfig{BaseURL: server.URL, APIKey: "bad-key", Model: "claude-test"})
+	err := bad.HealthCheck(context.Background())
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("HealthCheck() = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestOpenAIProviderHealthCheckFallsBackToPing(t *testing.T) {
+	var pinged bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			http.NotFound(w, r)
+		case "/v1/chat/completions":
+			pinged = true
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"p"},"finish_reason":"length"}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider(LLMProviderConfig{BaseURL: server.URL + "/v1/chat/completions", APIKey: "key", Model: "local"})
+	if err := p.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() = %v, want nil", err)
+	}
+	if !pinged {
+		t.Fatal("expected a completion ping when the models endpoint is missing")
+	}
+}
+
+func TestOpenRouterProviderHealthCheckReadsKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/key" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":{"message":"upstream down"}}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenRouterProvider(LLMProviderConfig{BaseURL: server.URL + "/api", APIKey: "key", Model: "m"})
+	err := p.HealthCheck(context.Background())
+	if err == nil || errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("HealthCheck() = %v, want a non-credential error", err)
+	}
+}
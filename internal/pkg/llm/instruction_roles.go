@@ -0,0 +1,77 @@
+package llm
+
+import "strings"
+
+// InstructionRoles selects how a provider sends RoleSystem and
+// RoleDeveloper messages.
+type InstructionRoles string
+
+const (
+	// InstructionRolesNative sends them with the provider's own mechanism:
+	// the system and developer roles for OpenAI, and system prompt blocks
+	// for Claude, which accepts them only before the conversation starts.
+	// Later ones are downgraded to user messages.
+	InstructionRolesNative InstructionRoles = "native"
+
+	// InstructionRolesSystem sends developer messages with the system role,
+	// for OpenAI-compatible backends that do not know the developer role.
+	InstructionRolesSystem InstructionRoles = "system"
+
+	// InstructionRolesUser downgrades both to user messages whose text is
+	// marked with the original role, for backends that accept only user
+	// and assistant messages.
+	InstructionRolesUser InstructionRoles = "user"
+)
+
+// IsInstruction reports whether r is RoleSystem or RoleDeveloper.
+func (r Role) IsInstruction() bool {
+	return r == RoleSystem || r == RoleDeveloper
+}
+
+// downgradeInstructionRoles rewrites the system and developer messages of
+// messages that mode does not send as they are. messages is not modified.
+func downgradeInstructionRoles(messages []Message, mode InstructionRoles) []Message {
+	var out []Message
+	for i, msg := range messages {
+		var downgraded Message
+		switch {
+		case mode == InstructionRolesSystem && msg.Role == RoleDeveloper:
+			downgraded = msg
+			downgraded.Role = RoleSystem
+		case mode == InstructionRolesUser && msg.Role.IsInstruction():
+			downgraded = downgradeToUser(msg)
+		default:
+			if out != nil {
+				out = append(out, msg)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]Message, 0, len(messages)), messages[:i]...)
+		}
+		out = append(out, downgraded)
+	}
+	if out == nil {
+		return messages
+	}
+	return out
+}
+
+// downgradeToUser turns an instruction message into a user message whose
+// text starts with the original role, e.g. "[developer]".
+func downgradeToUser(msg Message) Message {
+	return NewTextMessage(RoleUser, "["+string(msg.Role)+"]\n"+msg.GetText())
+}
+
+// splitLeadingInstructions returns the text of the system and developer
+// messages at the start of messages, and the messages after them.
+func splitLeadingInstructions(messages []Message) ([]string, []Message) {
+	var texts []string
+	i := 0
+	for ; i < len(messages) && messages[i].Role.IsInstruction(); i++ {
+		if text := strings.TrimSpace(messages[i].GetText()); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts, messages[i:]
+}
@@ -0,0 +1,18 @@
+package llm
+
+// MessageLimiter is an optional extension for providers whose API caps the
+// number of messages in a request, e.g. a gateway that rejects more than
+// 100 turns. The agent loop keeps every request within the limit.
+type MessageLimiter interface {
+	MaxRequestMessages() int
+}
+
+// MaxRequestMessages returns the per-request message limit (0 for none).
+func (p *ClaudeProvider) MaxRequestMessages() int {
+	return p.MessageLimit
+}
+
+// MaxRequestMessages returns the per-request message limit (0 for none).
+func (p *OpenAIProvider) MaxRequestMessages() int {
+	return p.MessageLimit
+}
@@ -36,6 +36,31 @@ type OpenAIProvider struct {
 	HTTPClient  *http.Client
 	Backoff     func(int) time.Duration
 	Sleep       func(time.Duration)
+
+	// ExtraHeaders and RequestMutator customize each API request (see
+	// LLMProviderConfig).
+	ExtraHeaders   map[string]string
+	RequestMutator func(*http.Request)
+
+	// ExtraBody is merged into every request body (see
+	// LLMProviderConfig.ExtraBody).
+	ExtraBody map[string]any
+
+	// OpenRouter, if set, adds OpenRouter's routing fields to each request
+	// and names the provider "openrouter".
+	OpenRouter *OpenRouterOptions
+
+	// ResponseValidation selects how malformed responses are handled (see
+	// LLMProviderConfig.ResponseValidation).
+	ResponseValidation ResponseValidation
+
+	// MessageLimit caps the messages per request (see
+	// LLMProviderConfig.MaxRequestMessages).
+	MessageLimit int
+
+	// InstructionRoles selects how system and developer messages are sent
+	// (see LLMProviderConfig.InstructionRoles).
+	InstructionRoles InstructionRoles
 }
 
 // NewOpenAIProvider creates a new OpenAI-compatible API provider.
@@ -62,11 +87,23 @@ func NewOpenAIProvider(cfg LLMProviderConfig) *OpenAIProvider {
 		MaxTokens:   maxTokens,
 		Timeout:     timeout,
 		MaxAttempts: maxAttempts,
+		HTTPClient:  newProviderHTTPClient(cfg.HTTP, timeout),
+
+		ExtraHeaders:   cfg.ExtraHeaders,
+		RequestMutator: cfg.RequestMutator,
+		ExtraBody:      cfg.ExtraBody,
+
+		ResponseValidation: cfg.ResponseValidation,
+		MessageLimit:       cfg.MaxRequestMessages,
+		InstructionRoles:   cfg.InstructionRoles,
 	}
 }
 
 // Name returns the provider name.
 func (p *OpenAIProvider) Name() string {
+	if p.OpenRouter != nil {
+		return "openrouter"
+	}
 	return "openai"
 }
 
@@ -89,7 +126,7 @@ func (p *OpenAIProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 	log.Printf("[openai-provider] calling API: model=%s max_tokens=%d messages=%d tools=%d",
 		openaiReq.Model, openaiReq.MaxTokens, len(openaiReq.Messages), len(openaiReq.Tools))
 
-	payload, err := json.Marshal(openaiReq)
+	payload, err := marshalWithExtraBody(openaiReq, p.ExtraBody, req.ExtraBody)
 	if err != nil {
 		return AgentResponse{}, fmt.Errorf("marshal request: %w", err)
 	}
@@ -117,7 +154,6 @@ func (p *OpenAIProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 	if timeout == 0 {
 		timeout = 5 * time.Minute
 	}
-
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -136,6 +172,7 @@ func (p *OpenAIProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 			}
 			log.Printf("[openai-provider] parsed response: stop_reason=%s content_blocks=%d",
 				resp.StopReason, len(resp.Content))
+			applyPrefill(&resp, prefillText(req))
 			return resp, nil
 		}
 		lastErr = wrapOpenAIAPIError(respBody, status, err)
@@ -145,7 +182,12 @@ func (p *OpenAIProvider) Call(ctx context.Context, req AgentRequest) (AgentRespo
 			return AgentResponse{}, lastErr
 		}
 		backoffDuration := backoff(attempt)
+		if !retryFits(ctx, backoffDuration) {
+			log.Printf("[openai-provider] giving up after %d attempts: retrying in %v would pass the deadline", attempt, backoffDuration)
+			return AgentResponse{}, lastErr
+		}
 		log.Printf("[openai-provider] retrying in %v", backoffDuration)
+		notifyRetry(ctx, RetryNotice{Provider: p.Name(), Attempt: attempt, MaxAttempts: maxAttempts, Status: status, Wait: backoffDuration, Err: lastErr})
 		sleep(backoffDuration)
 	}
 	return AgentResponse{}, lastErr
@@ -167,7 +209,7 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req AgentRequest, onDelta f
 	openaiReq := p.convertToOpenAIRequest(req)
 	openaiReq.Stream = true
 
-	payload, err := json.Marshal(openaiReq)
+	payload, err := marshalWithExtraBody(openaiReq, p.ExtraBody, req.ExtraBody)
 	if err != nil {
 		return AgentResponse{}, fmt.Errorf("marshal request: %w", err)
 	}
@@ -194,7 +236,6 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req AgentRequest, onDelta f
 	if timeout == 0 {
 		timeout = 5 * time.Minute
 	}
-
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -206,9 +247,14 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req AgentRequest, onDelta f
 		if err != nil {
 			lastErr = err
 		} else if status < 400 {
-			resp, streamErr := parseOpenAIStream(streamBody, onDelta)
+			prefill := prefillText(req)
+			if prefill != "" {
+				emitDelta(onDelta, ContentBlockDelta{Type: ContentTypeText, Text: prefill})
+			}
+			resp, streamErr := parseOpenAIStream(streamBody, onDelta, p.ResponseValidation)
 			closeErr := streamBody.Close()
 			if streamErr == nil && closeErr == nil {
+				applyPrefill(&resp, prefill)
 				return resp, nil
 			}
 			if streamErr != nil {
@@ -233,7 +279,12 @@ func (p *OpenAIProvider) Stream(ctx context.Context, req AgentRequest, onDelta f
 			return AgentResponse{}, lastErr
 		}
 		delay := backoff(attempt)
+		if !retryFits(ctx, delay) {
+			log.Printf("[openai-provider] giving up after %d stream attempts: retrying in %v would pass the deadline", attempt, delay)
+			return AgentResponse{}, lastErr
+		}
 		log.Printf("[openai-provider] retrying stream in %v", delay)
+		notifyRetry(ctx, RetryNotice{Provider: p.Name(), Attempt: attempt, MaxAttempts: maxAttempts, Status: status, Wait: delay, Err: lastErr})
 		sleep(delay)
 	}
 
@@ -247,9 +298,16 @@ type openaiRequest struct {
 	Messages    []openaiMessage `json:"messages"`
 	MaxTokens   int             `json:"max_tokens,omitempty"`
 	Temperature *float64        `json:"temperature,omitempty"`
+	Seed        *int64          `json:"seed,omitempty"`
 	Tools       []openaiTool    `json:"tools,omitempty"`
-	ToolChoice  string          `json:"tool_choice,omitempty"`
+	ToolChoice  any             `json:"tool_choice,omitempty"`
+	Parallel    *bool           `json:"parallel_tool_calls,omitempty"`
 	Stream      bool            `json:"stream,omitempty"`
+
+	// OpenRouter routing fields.
+	Models     []string                       `json:"models,omitempty"`
+	Provider   *OpenRouterProviderPreferences `json:"provider,omitempty"`
+	Transforms []string                       `json:"transforms,omitempty"`
 }
 
 type openaiMessage struct {
@@ -295,11 +353,24 @@ type openaiResponse struct {
 		Message      openaiMessage `json:"message"`
 		FinishReason string        `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage openaiUsage `json:"usage"`
+}
+
+type openaiUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+func (u openaiUsage) toUsage() Usage {
+	return Usage{
+		InputTokens:          u.PromptTokens,
+		OutputTokens:         u.CompletionTokens,
+		CacheReadInputTokens: u.PromptTokensDetails.CachedTokens,
+	}
 }
 
 type openaiStreamResponse struct {
@@ -322,11 +393,7 @@ type openaiStreamResponse struct {
 		} `json:"delta"`
 		FinishReason *string `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage openaiUsage `json:"usage"`
 }
 
 // convertToOpenAIRequest converts a Claude AgentRequest to OpenAI format.
@@ -353,11 +420,21 @@ func (p *OpenAIProvider) convertToOpenAIRequest(req AgentRequest) openaiRequest
 	}
 
 	// Convert each message
-	for _, msg := range req.Messages {
+	for _, msg := range downgradeInstructionRoles(req.Messages, p.InstructionRoles) {
 		openaiMsg := p.convertMessage(msg)
 		messages = append(messages, openaiMsg...)
 	}
 
+	// Backends that support prefix completion continue a trailing
+	// assistant message instead of starting a new one.
+	if prefill := prefillText(req); prefill != "" {
+		messages = append(messages, openaiMessage{Role: "assistant", Content: prefill})
+	}
+
+	if len(req.ServerTools) > 0 {
+		log.Printf("[openai-provider] ignoring %d server tool(s): not supported by the OpenAI API", len(req.ServerTools))
+	}
+
 	// Convert tools
 	var tools []openaiTool
 	for _, t := range req.Tools {
@@ -376,11 +453,33 @@ func (p *OpenAIProvider) convertToOpenAIRequest(req AgentRequest) openaiRequest
 		Messages:    messages,
 		MaxTokens:   maxTokens,
 		Temperature: req.Temperature,
+		Seed:        req.Seed,
 	}
 
 	if len(tools) > 0 {
 		openaiReq.Tools = tools
 		openaiReq.ToolChoice = "auto"
+		if req.ToolChoice != nil {
+			switch req.ToolChoice.Type {
+			case ToolChoiceAny:
+				openaiReq.ToolChoice = "required"
+			case ToolChoiceNone:
+				openaiReq.ToolChoice = "none"
+			case ToolChoiceTool:
+				openaiReq.ToolChoice = map[string]any{
+					"type":     "function",
+					"function": map[string]any{"name": req.ToolChoice.Name},
+				}
+			}
+			if req.ToolChoice.DisableParallelToolUse {
+				parallel := false
+				openaiReq.Parallel = &parallel
+			}
+		}
+	}
+
+	if p.OpenRouter != nil {
+		p.OpenRouter.apply(&openaiReq)
 	}
 
 	return openaiReq
@@ -428,6 +527,14 @@ func (p *OpenAIProvider) convertMessage(msg Message) []openaiMessage {
 			}
 		}
 
+	case RoleSystem, RoleDeveloper:
+		if text := msg.GetText(); text != "" {
+			result = append(result, openaiMessage{
+				Role:    string(msg.Role),
+				Content: text,
+			})
+		}
+
 	case RoleAssistant:
 		// Check for tool calls
 		var toolCalls []openaiToolCall
@@ -500,52 +607,39 @@ func (p *OpenAIProvider) parseOpenAIResponse(body []byte) (AgentResponse, error)
 	}
 
 	// Add tool calls
+	var sanitizer responseSanitizer
 	hasToolCalls := false
 	for _, tc := range msg.ToolCalls {
-		var input map[string]any
-		if tc.Function.Arguments != "" {
-			json.Unmarshal([]byte(tc.Function.Arguments), &input)
+		if !sanitizer.toolCall(tc.ID, tc.Function.Name) {
+			continue
 		}
 		hasToolCalls = true
+		var args argumentScanner
+		args.WriteString(tc.Function.Arguments)
+		input, inputErr := sanitizer.toolInput(tc.ID, tc.Function.Name, &args)
 		content = append(content, ContentBlock{
-			Type:  ContentTypeToolUse,
-			ID:    tc.ID,
-			Name:  tc.Function.Name,
-			Input: input,
+			Type:       ContentTypeToolUse,
+			ID:         tc.ID,
+			Name:       tc.Function.Name,
+			Input:      input,
+			InputError: inputErr,
 		})
 	}
 
-	// Map finish reason to stop reason
-	var stopReason StopReason
-	switch choice.FinishReason {
-	case "stop":
-		stopReason = StopReasonEndTurn
-	case "tool_calls":
-		stopReason = StopReasonToolUse
-	case "length":
-		stopReason = StopReasonMaxTokens
-	default:
-		stopReason = StopReasonEndTurn
-	}
-	// Some OpenAI-compatible providers return finish_reason="stop" while still
-	// populating tool_calls. Presence of tool_calls should take precedence.
-	if hasToolCalls {
-		stopReason = StopReasonToolUse
-	}
-
-	return AgentResponse{
+	resp := AgentResponse{
 		ID:               openaiResp.ID,
 		Type:             "message",
 		Role:             RoleAssistant,
 		Content:          content,
 		ReasoningContent: reasoningContent,
 		Model:            openaiResp.Model,
-		StopReason:       stopReason,
-		Usage: Usage{
-			InputTokens:  openaiResp.Usage.PromptTokens,
-			OutputTokens: openaiResp.Usage.CompletionTokens,
-		},
-	}, nil
+		StopReason:       sanitizer.stopReason(choice.FinishReason, hasToolCalls),
+		Usage:            openaiResp.Usage.toUsage(),
+	}
+	if err := sanitizer.finish(&resp, p.ResponseValidation); err != nil {
+		return AgentResponse{}, err
+	}
+	return resp, nil
 }
 
 func normalizeReasoningContent(value any) string {
@@ -582,6 +676,7 @@ func (p *OpenAIProvider) doRequest(ctx context.Context, client *http.Client, pay
 	// OpenAI uses Bearer token authentication
 	req.Header.Set("Authorization", "Bearer "+p.APIKey)
 	req.Header.Set("Content-Type", "application/json")
+	customizeRequest(req, p.ExtraHeaders, p.RequestMutator)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -606,6 +701,7 @@ func (p *OpenAIProvider) doStreamRequest(ctx context.Context, client *http.Clien
 	req.Header.Set("Authorization", "Bearer "+p.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "text/event-stream")
+	customizeRequest(req, p.ExtraHeaders, p.RequestMutator)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -617,14 +713,15 @@ func (p *OpenAIProvider) doStreamRequest(ctx context.Context, client *http.Clien
 	return resp.Body, resp.StatusCode, nil
 }
 
-func parseOpenAIStream(body io.Reader, onDelta func(ContentBlockDelta)) (AgentResponse, error) {
+func parseOpenAIStream(body io.Reader, onDelta func(ContentBlockDelta), validation ResponseValidation) (AgentResponse, error) {
 	scanner := bufio.NewScanner(body)
 	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
 
 	type toolCallAcc struct {
 		ID        string
 		Name      string
-		Arguments strings.Builder
+		Arguments argumentScanner
+		started   bool
 	}
 
 	var responseID string
@@ -658,8 +755,7 @@ func parseOpenAIStream(body io.Reader, onDelta func(ContentBlockDelta)) (AgentRe
 			model = chunk.Model
 		}
 		if chunk.Usage.PromptTokens > 0 || chunk.Usage.CompletionTokens > 0 {
-			usage.InputTokens = chunk.Usage.PromptTokens
-			usage.OutputTokens = chunk.Usage.CompletionTokens
+			usage = chunk.Usage.toUsage()
 		}
 
 		for _, choice := range chunk.Choices {
@@ -685,8 +781,26 @@ func parseOpenAIStream(body io.Reader, onDelta func(ContentBlockDelta)) (AgentRe
 				if tc.Function.Name != "" {
 					acc.Name = tc.Function.Name
 				}
+				if !acc.started && acc.Name != "" {
+					acc.started = true
+					emitDelta(onDelta, ContentBlockDelta{
+						Type:  ContentTypeToolUse,
+						Phase: ToolCallPhaseStart,
+						Index: tc.Index,
+						ID:    acc.ID,
+						Name:  acc.Name,
+					})
+				}
 				if tc.Function.Arguments != "" {
 					acc.Arguments.WriteString(tc.Function.Arguments)
+					emitDelta(onDelta, ContentBlockDelta{
+						Type:         ContentTypeToolUse,
+						Phase:        ToolCallPhaseDelta,
+						Index:        tc.Index,
+						ID:           acc.ID,
+						Name:         acc.Name,
+						PartialInput: tc.Function.Arguments,
+					})
 				}
 			}
 
@@ -708,6 +822,8 @@ func parseOpenAIStream(body io.Reader, onDelta func(ContentBlockDelta)) (AgentRe
 		})
 	}
 
+	var sanitizer responseSanitizer
+	hasToolCalls := false
 	if len(toolCalls) > 0 {
 		indices := make([]int, 0, len(toolCalls))
 		for idx := range toolCalls {
@@ -717,13 +833,22 @@ func parseOpenAIStream(body io.Reader, onDelta func(ContentBlockDelta)) (AgentRe
 
 		for _, idx := range indices {
 			acc := toolCalls[idx]
-			var input map[string]any
-			args := strings.TrimSpace(acc.Arguments.String())
-			if args != "" {
-				_ = json.Unmarshal([]byte(args), &input)
+			if !sanitizer.toolCall(acc.ID, acc.Name) {
+				continue
 			}
+			hasToolCalls = true
+			input, inputErr := sanitizer.toolInput(acc.ID, acc.Name, &acc.Arguments)
 			content = append(content, ContentBlock{
+				Type:       ContentTypeToolUse,
+				ID:         acc.ID,
+				Name:       acc.Name,
+				Input:      input,
+				InputError: inputErr,
+			})
+			emitDelta(onDelta, ContentBlockDelta{
 				Type:  ContentTypeToolUse,
+				Phase: ToolCallPhaseReady,
+				Index: idx,
 				ID:    acc.ID,
 				Name:  acc.Name,
 				Input: input,
@@ -731,30 +856,25 @@ func parseOpenAIStream(body io.Reader, onDelta func(ContentBlockDelta)) (AgentRe
 		}
 	}
 
-	stopReason := StopReasonEndTurn
-	switch finishReason {
-	case "tool_calls":
-		stopReason = StopReasonToolUse
-	case "length":
-		stopReason = StopReasonMaxTokens
-	case "stop", "":
-		stopReason = StopReasonEndTurn
-	default:
-		stopReason = StopReasonEndTurn
-	}
-	if len(toolCalls) > 0 {
-		stopReason = StopReasonToolUse
-	}
-
-	return AgentResponse{
+	resp := AgentResponse{
 		ID:         responseID,
 		Type:       "message",
 		Role:       RoleAssistant,
 		Content:    content,
 		Model:      model,
-		StopReason: stopReason,
+		StopReason: sanitizer.stopReason(finishReason, hasToolCalls),
 		Usage:      usage,
-	}, nil
+	}
+	if err := sanitizer.finish(&resp, validation); err != nil {
+		return AgentResponse{}, err
+	}
+	return resp, nil
+}
+
+func emitDelta(onDelta func(ContentBlockDelta), delta ContentBlockDelta) {
+	if onDelta != nil {
+		onDelta(delta)
+	}
 }
 
 func wrapOpenAIAPIError(body []byte, status int, err error) error {
@@ -774,6 +894,9 @@ func wrapOpenAIAPIError(body []byte, status int, err error) error {
 		} `json:"error"`
 	}
 	if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+		if isContextOverflow(status, errResp.Error.Type, errResp.Error.Code, errResp.Error.Message) {
+			return &ContextOverflowError{Provider: "OpenAI", Status: status, Message: errResp.Error.Message}
+		}
 		return fmt.Errorf("OpenAI API error %d: %s - %s", status, errResp.Error.Type, errResp.Error.Message)
 	}
 
@@ -781,6 +904,9 @@ func wrapOpenAIAPIError(body []byte, status int, err error) error {
 	if msg == "" {
 		msg = http.StatusText(status)
 	}
+	if isContextOverflow(status, "", "", msg) {
+		return &ContextOverflowError{Provider: "OpenAI", Status: status, Message: msg}
+	}
 	return fmt.Errorf("OpenAI API error: %d %s", status, msg)
 }
 
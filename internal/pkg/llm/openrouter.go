@@ -0,0 +1,78 @@
+package llm
+
+// OpenRouterOptions adds OpenRouter's routing fields to the OpenAI-compatible
+// request payload.
+type OpenRouterOptions struct {
+	// FallbackModels are tried in order when the primary model is down,
+	// rate limited, or refuses the request. They are sent with the primary
+	// model as the "models" array.
+	FallbackModels []string
+
+	// Provider sets the upstream provider preferences ("provider").
+	Provider *OpenRouterProviderPreferences
+
+	// Transforms are prompt transforms such as "middle-out" ("transforms").
+	Transforms []string
+}
+
+// OpenRouterProviderPreferences controls which upstream providers OpenRouter
+// routes a request to.
+type OpenRouterProviderPreferences struct {
+	// Order lists providers to try first, e.g. ["anthropic", "openai"].
+	Order []string `json:"order,omitempty"`
+
+	// AllowFallbacks set to false restricts routing to Order (and Only).
+	AllowFallbacks *bool `json:"allow_fallbacks,omitempty"`
+
+	// RequireParameters only routes to providers that support every
+	// parameter in the request, such as tools.
+	RequireParameters bool `json:"require_parameters,omitempty"`
+
+	// DataCollection is "allow" or "deny".
+	DataCollection string `json:"data_collection,omitempty"`
+
+	// Only and Ignore allow or exclude providers by name.
+	Only   []string `json:"only,omitempty"`
+	Ignore []string `json:"ignore,omitempty"`
+
+	// Sort ranks providers by "price", "throughput", or "latency".
+	Sort string `json:"sort,omitempty"`
+}
+
+// NewOpenRouterProvider creates an OpenAI-compatible provider that adds
+// cfg.OpenRouter's routing fields to each request.
+func NewOpenRouterProvider(cfg LLMProviderConfig) *OpenAIProvider {
+	p := NewOpenAIProvider(cfg)
+	p.OpenRouter = cfg.OpenRouter
+	if p.OpenRouter == nil {
+		p.OpenRouter = &OpenRouterOptions{}
+	}
+	// Not every upstream provider knows the developer role.
+	if p.InstructionRoles == "" {
+		p.InstructionRoles = InstructionRolesSystem
+	}
+	return p
+}
+
+// apply sets the routing fields of an OpenRouter request.
+func (o *OpenRouterOptions) apply(req *openaiRequest) {
+	if len(o.FallbackModels) > 0 {
+		req.Models = mergeStringsUnique([]string{req.Model}, o.FallbackModels)
+	}
+	req.Provider = o.Provider
+	req.Transforms = o.Transforms
+}
+
+// mergeStringsUnique concatenates base and add, dropping empty and repeated
+// entries.
+func mergeStringsUnique(base, add []string) []string {
+	seen := make(map[string]bool, len(base)+len(add))
+	out := make([]string, 0, len(base)+len(add))
+	for _, s := range append(append([]string{}, base...), add...) {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
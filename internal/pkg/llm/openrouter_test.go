@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenRouterProviderSendsRoutingFields(t *testing.T) {
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"id":"c","model":"openai/gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	allowFallbacks := false
+	provider, err := NewLLMProvider(LLMProviderConfig{
+		Type:    ProviderOpenRouter,
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Model:   "anthropic/claude-sonnet-4",
+		OpenRouter: &OpenRouterOptions{
+			FallbackModels: []string{"openai/gpt-4o", "anthropic/claude-sonnet-4"},
+			Provider:       &OpenRouterProviderPreferences{Order: []string{"anthropic"}, AllowFallbacks: &allowFallbacks},
+			Transforms:     []string{"middle-out"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLLMProvider() error = %v", err)
+	}
+	if provider.Name() != "openrouter" {
+		t.Fatalf("Name() = %q, want openrouter", provider.Name())
+	}
+
+	resp, err := provider.Call(context.Background(), AgentRequest{Messages: []Message{NewTextMessage(RoleUser, "hi")}})
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if resp.Model != "openai/gpt-4o" {
+		t.Fatalf("expected the serving fallback model, got %q", resp.Model)
+	}
+
+	models, _ := payload["models"].([]any)
+	if len(models) != 2 || models[0] != "anthropic/claude-sonnet-4" || models[1] != "openai/gpt-4o" {
+		t.Fatalf("unexpected models: %v", payload["models"])
+	}
+	prefs, _ := payload["provider"].(map[string]any)
+	if order, _ := prefs["order"].([]any); len(order) != 1 || order[0] != "anthropic" || prefs["allow_fallbacks"] != false {
+		t.Fatalf("unexpected provider preferences: %v", payload["provider"])
+	}
+	if transforms, _ := payload["transforms"].([]any); len(transforms) != 1 || transforms[0] != "middle-out" {
+		t.Fatalf("unexpected transforms: %v", payload["transforms"])
+	}
+}
+
+func TestOpenAIProviderOmitsRoutingFields(t *testing.T) {
+	p := NewOpenAIProvider(LLMProviderConfig{Model: "gpt-4o", OpenRouter: &OpenRouterOptions{FallbackModels: []string{"x"}}})
+	data, err := json.Marshal(p.convertToOpenAIRequest(AgentRequest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]any
+	_ = json.Unmarshal(data, &payload)
+	for _, key := range []string{"models", "provider", "transforms"} {
+		if _, ok := payload[key]; ok {
+			t.Fatalf("plain OpenAI request should not set %q: %s", key, data)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package llm
+
+import "strings"
+
+// prefillText returns the request's assistant prefill as sent to the API.
+// Claude rejects a final assistant turn ending in whitespace, so trailing
+// whitespace is dropped for every provider to keep them consistent.
+func prefillText(req AgentRequest) string {
+	return strings.TrimRight(req.AssistantPrefill, " \t\r\n")
+}
+
+// withPrefillMessage returns messages with a trailing assistant message
+// holding prefill. messages is not modified.
+func withPrefillMessage(messages []Message, prefill string) []Message {
+	out := make([]Message, 0, len(messages)+1)
+	out = append(out, messages...)
+	return append(out, NewTextMessage(RoleAssistant, prefill))
+}
+
+// applyPrefill prepends prefill to the response text, since the API only
+// returns the continuation.
+func applyPrefill(resp *AgentResponse, prefill string) {
+	if prefill == "" {
+		return
+	}
+	for i := range resp.Content {
+		if resp.Content[i].Type == ContentTypeText {
+			resp.Content[i].Text = prefill + resp.Content[i].Text
+			return
+		}
+	}
+	resp.Content = append([]ContentBlock{{Type: ContentTypeText, Text: prefill}}, resp.Content...)
+}
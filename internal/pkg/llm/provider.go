@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"fmt"
+	"net/http"
 )
 
 // LLMProvider is the unified interface for LLM API calls.
@@ -31,6 +32,10 @@ const (
 
 	// ProviderOpenAI uses OpenAI-compatible APIs (OpenAI, OpenRouter, DeepSeek, etc.).
 	ProviderOpenAI LLMProviderType = "openai"
+
+	// ProviderOpenRouter uses the OpenAI-compatible OpenRouter API with its
+	// model fallback and provider routing fields (see OpenRouterOptions).
+	ProviderOpenRouter LLMProviderType = "openrouter"
 )
 
 // LLMProviderConfig contains configuration for creating an LLM provider.
@@ -55,15 +60,69 @@ type LLMProviderConfig struct {
 
 	// MaxAttempts is the maximum retry count.
 	MaxAttempts int
+
+	// ThinkingBudgetTokens enables Claude extended thinking with this token
+	// budget. Zero disables it. Ignored by other providers.
+	ThinkingBudgetTokens int
+
+	// PromptCaching marks the stable part of the system prompt (see
+	// AgentRequest.StableSystemBytes) for Claude prompt caching, so later
+	// turns of a session read it from the cache instead of processing it
+	// again. Ignored by other providers.
+	PromptCaching bool
+
+	// HTTP tunes the shared HTTP transport (connection pool, HTTP/2,
+	// proxy, TLS). Zero values use pooled defaults.
+	HTTP HTTPConfig
+
+	// ExtraHeaders are added to every API request, e.g. HTTP-Referer and
+	// X-Title for OpenRouter or a tenant ID for a corporate gateway. They
+	// override the provider's own headers of the same name.
+	ExtraHeaders map[string]string
+
+	// RequestMutator, if set, is called on every API request after all
+	// headers are set and before it is sent.
+	RequestMutator func(*http.Request)
+
+	// ExtraBody is merged into the JSON body of every API request, for
+	// fields the provider does not model, e.g. "top_k", "reasoning_effort",
+	// or vendor extensions. It overrides the provider's own fields of the
+	// same name; a nil value removes a field. AgentRequest.ExtraBody is
+	// applied on top.
+	ExtraBody map[string]any
+
+	// OpenRouter sets model fallbacks, provider preferences, and transforms
+	// for ProviderOpenRouter. Ignored by other providers.
+	OpenRouter *OpenRouterOptions
+
+	// ResponseValidation selects lenient (default) or strict handling of
+	// malformed responses from OpenAI-compatible backends. Ignored by the
+	// Claude provider.
+	ResponseValidation ResponseValidation
+
+	// MaxRequestMessages caps the messages per request for APIs or gateways
+	// that reject longer histories (see MessageLimiter). Zero is unlimited.
+	MaxRequestMessages int
+
+	// InstructionRoles selects how messages with RoleSystem or
+	// RoleDeveloper are sent. Empty uses InstructionRolesNative, except for
+	// ProviderOpenRouter, which defaults to InstructionRolesSystem.
+	InstructionRoles InstructionRoles
 }
 
 // NewLLMProvider creates an LLM provider based on the configuration.
 func NewLLMProvider(cfg LLMProviderConfig) (LLMProvider, error) {
+	if _, err := SharedTransport(cfg.HTTP); err != nil {
+		return nil, fmt.Errorf("http config: %w", err)
+	}
+
 	switch cfg.Type {
 	case ProviderClaude:
 		return NewClaudeProvider(cfg), nil
 	case ProviderOpenAI:
 		return NewOpenAIProvider(cfg), nil
+	case ProviderOpenRouter:
+		return NewOpenRouterProvider(cfg), nil
 	case "":
 		// Default to Claude if not specified
 		return NewClaudeProvider(cfg), nil
@@ -1,10 +1,12 @@
 package llm
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -469,6 +471,58 @@ func TestOpenAIProviderStream(t *testing.T) {
 	}
 }
 
+func TestOpenAIProviderStreamEmitsToolCallDeltas(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"id\":\"chatcmpl-2\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"read_file\",\"arguments\":\"\"}}]},\"finish_reason\":null}]}\n\n"))
+		_, _ = w.Write([]byte("data: {\"id\":\"chatcmpl-2\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"{\\\"path\\\":\"}}]},\"finish_reason\":null}]}\n\n"))
+		_, _ = w.Write([]byte("data: {\"id\":\"chatcmpl-2\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"a.txt\\\"}\"}}]},\"finish_reason\":\"tool_calls\"}]}\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(LLMProviderConfig{
+		Type:    ProviderOpenAI,
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Model:   "gpt-4",
+	})
+
+	var deltas []ContentBlockDelta
+	resp, err := provider.Stream(context.Background(), AgentRequest{
+		Messages: []Message{NewTextMessage(RoleUser, "read it")},
+	}, func(delta ContentBlockDelta) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	wantPhases := []ToolCallPhase{ToolCallPhaseStart, ToolCallPhaseDelta, ToolCallPhaseDelta, ToolCallPhaseReady}
+	if len(deltas) != len(wantPhases) {
+		t.Fatalf("expected %d deltas, got %#v", len(wantPhases), deltas)
+	}
+	var partial string
+	for i, delta := range deltas {
+		if delta.Type != ContentTypeToolUse || delta.Phase != wantPhases[i] {
+			t.Fatalf("delta %d: unexpected type/phase %s/%s", i, delta.Type, delta.Phase)
+		}
+		if delta.ID != "call_1" || delta.Name != "read_file" {
+			t.Fatalf("delta %d: unexpected id/name %q/%q", i, delta.ID, delta.Name)
+		}
+		partial += delta.PartialInput
+	}
+	if partial != `{"path":"a.txt"}` {
+		t.Fatalf("unexpected accumulated arguments: %q", partial)
+	}
+	if got := deltas[3].Input["path"]; got != "a.txt" {
+		t.Fatalf("expected ready delta input path a.txt, got %#v", got)
+	}
+	if resp.StopReason != StopReasonToolUse {
+		t.Fatalf("expected stop reason tool_use, got %s", resp.StopReason)
+	}
+}
+
 func TestAgentRunnerBackwardCompatibility(t *testing.T) {
 	// Create a mock server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -647,3 +701,526 @@ func TestOpenAIProviderReasoningContentRoundTrip(t *testing.T) {
 		t.Fatalf("ToMessage().ReasoningContent = %q, want %q", msg.ReasoningContent, "followed explicit chain")
 	}
 }
+
+func TestClaudeProviderThinkingRoundTrip(t *testing.T) {
+	var payloads []map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+
+		resp := map[string]any{
+			"id":          "msg_thinking",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       "claude-sonnet",
+			"stop_reason": "tool_use",
+			"content": []map[string]any{
+				{"type": "thinking", "thinking": "need to look it up", "signature": "sig_1"},
+				{"type": "redacted_thinking", "data": "opaque"},
+				{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": map[string]any{"term": "Neo"}},
+			},
+			"usage": map[string]int{"input_tokens": 10, "output_tokens": 5},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	provider := NewClaudeProvider(LLMProviderConfig{
+		Type:                 ProviderClaude,
+		BaseURL:              server.URL,
+		APIKey:               "test-key",
+		Model:                "claude-sonnet",
+		MaxTokens:            2048,
+		TimeoutSeconds:       30,
+		ThinkingBudgetTokens: 4000,
+	})
+
+	temperature := 0.2
+	req := AgentRequest{
+		Messages:    []Message{NewTextMessage(RoleUser, "Use lookup")},
+		Temperature: &temperature,
+	}
+	resp, err := provider.Call(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+
+	msg := resp.ToMessage()
+	if got := msg.GetThinking(); got != "need to look it up" {
+		t.Fatalf("GetThinking() = %q", got)
+	}
+	if msg.Content[0].Signature != "sig_1" || msg.Content[1].Type != ContentTypeRedactedThinking || msg.Content[1].Data != "opaque" {
+		t.Fatalf("thinking blocks not parsed: %#v", msg.Content)
+	}
+
+	first := payloads[0]
+	thinking, ok := first["thinking"].(map[string]any)
+	if !ok || thinking["type"] != "enabled" || thinking["budget_tokens"] != float64(4000) {
+		t.Fatalf("thinking config = %#v", first["thinking"])
+	}
+	if first["max_tokens"] != float64(6048) {
+		t.Fatalf("max_tokens = %v, want budget plus response tokens", first["max_tokens"])
+	}
+	if _, ok := first["temperature"]; ok {
+		t.Fatal("expected temperature to be dropped with thinking enabled")
+	}
+
+	// Send the assistant turn back; thinking blocks must round-trip unchanged.
+	req.Messages = append(req.Messages, msg, NewToolResultMessage("toolu_1", "ok", false))
+	if _, err := provider.Call(context.Background(), req); err != nil {
+		t.Fatalf("second Call() error = %v", err)
+	}
+	messages := payloads[1]["messages"].([]any)
+	content := messages[1].(map[string]any)["content"].([]any)
+	block := content[0].(map[string]any)
+	if block["type"] != "thinking" || block["thinking"] != "need to look it up" || block["signature"] != "sig_1" {
+		t.Fatalf("thinking block not round-tripped: %#v", block)
+	}
+	redacted := content[1].(map[string]any)
+	if redacted["type"] != "redacted_thinking" || redacted["data"] != "opaque" {
+		t.Fatalf("redacted_thinking block not round-tripped: %#v", redacted)
+	}
+}
+
+func TestOpenAIProviderSamplingParams(t *testing.T) {
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-seed",
+			"choices": []map[string]any{{"index": 0, "message": map[string]any{"role": "assistant", "content": "ok"}, "finish_reason": "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(LLMProviderConfig{
+		Type:           ProviderOpenAI,
+		BaseURL:        server.URL,
+		APIKey:         "test-key",
+		Model:          "gpt-4",
+		TimeoutSeconds: 30,
+	})
+
+	temperature := 0.0
+	seed := int64(42)
+	_, err := provider.Call(context.Background(), AgentRequest{
+		Messages:    []Message{NewTextMessage(RoleUser, "Hello")},
+		Tools:       []ToolDefinition{{Name: "lookup", InputSchema: map[string]any{"type": "object"}}},
+		Temperature: &temperature,
+		Seed:        &seed,
+		ToolChoice:  &ToolChoice{Type: "auto", DisableParallelToolUse: true},
+	})
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+
+	if payload["temperature"] != float64(0) || payload["seed"] != float64(42) {
+		t.Fatalf("sampling params = temperature:%v seed:%v", payload["temperature"], payload["seed"])
+	}
+	if payload["parallel_tool_calls"] != false || payload["tool_choice"] != "auto" {
+		t.Fatalf("tool params = parallel_tool_calls:%v tool_choice:%v", payload["parallel_tool_calls"], payload["tool_choice"])
+	}
+}
+
+func TestClaudeProviderServerToolsRoundTrip(t *testing.T) {
+	var payloads []map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+
+		resp := map[string]any{
+			"id":          "msg_search",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       "claude-sonnet",
+			"stop_reason": "end_turn",
+			"content": []map[string]any{
+				{"type": "server_tool_use", "id": "srvtoolu_1", "name": "web_search", "input": map[string]any{"query": "go release"}},
+				{"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1", "content": []map[string]any{
+					{"type": "web_search_result", "url": "https://go.dev", "title": "Go", "encrypted_content": "abc"},
+				}},
+				{"type": "text", "text": "Go 1.24 is out."},
+			},
+			"usage": map[string]int{"input_tokens": 10, "output_tokens": 5},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	provider := NewClaudeProvider(LLMProviderConfig{
+		Type:           ProviderClaude,
+		BaseURL:        server.URL,
+		APIKey:         "test-key",
+		Model:          "claude-sonnet",
+		TimeoutSeconds: 30,
+	})
+
+	req := AgentRequest{
+		Messages: []Message{NewTextMessage(RoleUser, "Latest Go?")},
+		Tools:    []ToolDefinition{{Name: "lookup", InputSchema: map[string]any{"type": "object"}}},
+		ServerTools: []ServerTool{
+			{Type: "web_search_20250305", Name: "web_search", Config: map[string]any{"max_uses": 3}},
+		},
+	}
+	resp, err := provider.Call(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if resp.HasToolUse() {
+		t.Fatal("server tool calls must not be reported as local tool use")
+	}
+	if resp.GetText() != "Go 1.24 is out." || resp.Content[0].Type != ContentTypeServerToolUse {
+		t.Fatalf("unexpected content: %#v", resp.Content)
+	}
+	if !IsServerToolResult(resp.Content[1].Type) || len(resp.Content[1].ServerContent) == 0 {
+		t.Fatalf("server tool result content not kept: %#v", resp.Content[1])
+	}
+
+	tools := payloads[0]["tools"].([]any)
+	if len(tools) != 2 {
+		t.Fatalf("expected local and server tools, got %#v", tools)
+	}
+	webSearch := tools[1].(map[string]any)
+	if webSearch["type"] != "web_search_20250305" || webSearch["name"] != "web_search" || webSearch["max_uses"] != float64(3) {
+		t.Fatalf("server tool definition = %#v", webSearch)
+	}
+
+	// Send the assistant turn back; server tool blocks must round-trip unchanged.
+	req.Messages = append(req.Messages, resp.ToMessage(), NewTextMessage(RoleUser, "Thanks"))
+	if _, err := provider.Call(context.Background(), req); err != nil {
+		t.Fatalf("second Call() error = %v", err)
+	}
+	content := payloads[1]["messages"].([]any)[1].(map[string]any)["content"].([]any)
+	result := content[1].(map[string]any)
+	results, ok := result["content"].([]any)
+	if result["type"] != "web_search_tool_result" || result["tool_use_id"] != "srvtoolu_1" || !ok || len(results) != 1 {
+		t.Fatalf("web_search_tool_result not round-tripped: %#v", result)
+	}
+	if results[0].(map[string]any)["encrypted_content"] != "abc" {
+		t.Fatalf("search result payload changed: %#v", results[0])
+	}
+}
+
+func TestProvidersReportCachedInputTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "" {
+			_, _ = w.Write([]byte(`{"id":"m","type":"message","role":"assistant","stop_reason":"end_turn","content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":10,"output_tokens":2,"cache_read_input_tokens":90}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"c","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":100,"completion_tokens":2,"prompt_tokens_details":{"cached_tokens":64}}}`))
+	}))
+	defer server.Close()
+
+	cfg := LLMProviderConfig{BaseURL: server.URL, APIKey: "test-key", Model: "m"}
+	req := AgentRequest{Messages: []Message{NewTextMessage(RoleUser, "hi")}}
+
+	resp, err := NewClaudeProvider(cfg).Call(context.Background(), req)
+	if err != nil {
+		t.Fatalf("claude Call() error = %v", err)
+	}
+	if resp.Usage != (Usage{InputTokens: 10, OutputTokens: 2, CacheReadInputTokens: 90}) {
+		t.Fatalf("unexpected claude usage: %+v", resp.Usage)
+	}
+
+	resp, err = NewOpenAIProvider(cfg).Call(context.Background(), req)
+	if err != nil {
+		t.Fatalf("openai Call() error = %v", err)
+	}
+	if resp.Usage != (Usage{InputTokens: 100, OutputTokens: 2, CacheReadInputTokens: 64}) {
+		t.Fatalf("unexpected openai usage: %+v", resp.Usage)
+	}
+}
+
+func TestProvidersSendAssistantPrefill(t *testing.T) {
+	var payload struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if r.Header.Get("x-api-key") != "" {
+			_, _ = w.Write([]byte(`{"id":"m","type":"message","role":"assistant","stop_reason":"end_turn","content":[{"type":"text","text":"\"ok\": true}"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"c","choices":[{"index":0,"message":{"role":"assistant","content":"\"ok\": true}"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := LLMProviderConfig{BaseURL: server.URL, APIKey: "test-key", Model: "m"}
+	req := AgentRequest{
+		Messages:         []Message{NewTextMessage(RoleUser, "answer in JSON")},
+		AssistantPrefill: "{\n",
+	}
+
+	for name, provider := range map[string]LLMProvider{
+		"claude": NewClaudeProvider(cfg),
+		"openai": NewOpenAIProvider(cfg),
+	} {
+		resp, err := provider.Call(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s Call() error = %v", name, err)
+		}
+		last := payload.Messages[len(payload.Messages)-1]
+		if last.Role != "assistant" || !bytes.Contains(last.Content, []byte(`"{"`)) {
+			t.Fatalf("%s: expected a trailing assistant prefill without trailing whitespace, got %+v", name, last)
+		}
+		if got := resp.GetText(); got != `{"ok": true}` {
+			t.Fatalf("%s: expected the prefill prepended to the reply, got %q", name, got)
+		}
+	}
+	if len(req.Messages) != 1 {
+		t.Fatalf("expected the caller's messages to be left alone, got %d", len(req.Messages))
+	}
+}
+
+func TestProvidersSendSpecificToolChoice(t *testing.T) {
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		payload = nil
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if r.URL.Path == "/v1/chat/completions" {
+			json.NewEncoder(w).Encode(map[string]any{
+				"id":      "chatcmpl-choice",
+				"choices": []map[string]any{{"index": 0, "message": map[string]any{"role": "assistant", "content": "ok"}, "finish_reason": "stop"}},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"id": "msg_choice", "type": "message", "role": "assistant", "model": "claude-sonnet", "stop_reason": "end_turn",
+			"content": []map[string]any{{"type": "text", "text": "ok"}},
+			"usage":   map[string]int{"input_tokens": 1, "output_tokens": 1},
+		})
+	}))
+	defer server.Close()
+
+	req := AgentRequest{
+		Messages:   []Message{NewTextMessage(RoleUser, "Hello")},
+		Tools:      []ToolDefinition{{Name: "final_answer", InputSchema: map[string]any{"type": "object"}}},
+		ToolChoice: &ToolChoice{Type: ToolChoiceTool, Name: "final_answer", DisableParallelToolUse: true},
+	}
+	cfg := LLMProviderConfig{BaseURL: server.URL, APIKey: "test-key", Model: "model", TimeoutSeconds: 30}
+
+	if _, err := NewOpenAIProvider(cfg).Call(context.Background(), req); err != nil {
+		t.Fatalf("OpenAI Call() error = %v", err)
+	}
+	choice, _ := payload["tool_choice"].(map[string]any)
+	function, _ := choice["function"].(map[string]any)
+	if choice["type"] != "function" || function["name"] != "final_answer" || payload["parallel_tool_calls"] != false {
+		t.Fatalf("OpenAI tool params = tool_choice:%v parallel_tool_calls:%v", payload["tool_choice"], payload["parallel_tool_calls"])
+	}
+
+	if _, err := NewClaudeProvider(cfg).Call(context.Background(), req); err != nil {
+		t.Fatalf("Claude Call() error = %v", err)
+	}
+	choice, _ = payload["tool_choice"].(map[string]any)
+	if choice["type"] != "tool" || choice["name"] != "final_answer" || choice["disable_parallel_tool_use"] != true {
+		t.Fatalf("Claude tool_choice = %v", payload["tool_choice"])
+	}
+
+	cfg.ThinkingBudgetTokens = 2000
+	if _, err := NewClaudeProvider(cfg).Call(context.Background(), req); err != nil {
+		t.Fatalf("Claude Call() error = %v", err)
+	}
+	choice, _ = payload["tool_choice"].(map[string]any)
+	if choice["type"] != "auto" || choice["name"] != nil {
+		t.Fatalf("expected auto tool_choice with thinking, got %v", payload["tool_choice"])
+	}
+	if req.ToolChoice.Type != ToolChoiceTool {
+		t.Fatal("the caller's tool choice must not be modified")
+	}
+}
+
+func TestProvidersMergeExtraBody(t *testing.T) {
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload = nil
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		if r.Header.Get("x-api-key") != "" {
+			_, _ = w.Write([]byte(`{"id":"m","type":"message","role":"assistant","stop_reason":"end_turn","content":[{"type":"text","text":"ok"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"c","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := LLMProviderConfig{
+		BaseURL:   server.URL,
+		APIKey:    "test-key",
+		Model:     "m",
+		ExtraBody: map[string]any{"top_k": 40, "reasoning_effort": "low", "max_tokens": nil},
+	}
+	req := AgentRequest{
+		Messages:  []Message{NewTextMessage(RoleUser, "hi")},
+		ExtraBody: map[string]any{"reasoning_effort": "high", "vendor": map[string]any{"cache": true}},
+	}
+
+	for name, provider := range map[string]LLMProvider{
+		"claude": NewClaudeProvider(cfg),
+		"openai": NewOpenAIProvider(cfg),
+	} {
+		if _, err := provider.Call(context.Background(), req); err != nil {
+			t.Fatalf("%s Call() error = %v", name, err)
+		}
+		if payload["top_k"] != float64(40) || payload["reasoning_effort"] != "high" {
+			t.Fatalf("%s: expected merged extra fields with request params winning, got %v", name, payload)
+		}
+		if vendor, _ := payload["vendor"].(map[string]any); vendor["cache"] != true {
+			t.Fatalf("%s: expected nested extra field, got %v", name, payload["vendor"])
+		}
+		if _, ok := payload["max_tokens"]; ok {
+			t.Fatalf("%s: expected a nil extra field to remove max_tokens", name)
+		}
+		if payload["model"] != "m" || payload["messages"] == nil {
+			t.Fatalf("%s: expected the provider's own fields to be kept, got %v", name, payload)
+		}
+	}
+}
+
+func TestClaudeProviderPromptCaching(t *testing.T) {
+	var payloads []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode request payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":          "msg_1",
+			"type":        "message",
+			"role":        "assistant",
+			"stop_reason": "end_turn",
+			"content":     []map[string]any{{"type": "text", "text": "ok"}},
+		})
+	}))
+	defer server.Close()
+
+	req := AgentRequest{
+		System:            "base\n\nrules\n\n## CI Status\n\ngreen",
+		StableSystemBytes: len("base\n\nrules"),
+		Messages:          []Message{NewTextMessage(RoleUser, "hi")},
+	}
+	for _, caching := range []bool{false, true} {
+		provider := NewClaudeProvider(LLMProviderConfig{
+			Type:          ProviderClaude,
+			BaseURL:       server.URL,
+			APIKey:        "test-key",
+			Model:         "claude-sonnet",
+			PromptCaching: caching,
+		})
+		if _, err := provider.Call(context.Background(), req); err != nil {
+			t.Fatalf("Call() error = %v", err)
+		}
+	}
+
+	if payloads[0]["system"] != req.System {
+		t.Fatalf("expected plain system prompt without caching, got %#v", payloads[0]["system"])
+	}
+	blocks, ok := payloads[1]["system"].([]any)
+	if !ok || len(blocks) != 2 {
+		t.Fatalf("expected stable and dynamic system blocks, got %#v", payloads[1]["system"])
+	}
+	stable := blocks[0].(map[string]any)
+	if stable["text"] != "base\n\nrules" || stable["cache_control"].(map[string]any)["type"] != "ephemeral" {
+		t.Fatalf("unexpected stable block: %#v", stable)
+	}
+	rest := blocks[1].(map[string]any)
+	if rest["text"] != "## CI Status\n\ngreen" || rest["cache_control"] != nil {
+		t.Fatalf("unexpected dynamic block: %#v", rest)
+	}
+}
+
+func TestClaudeProviderInstructionMessages(t *testing.T) {
+	req := AgentRequest{
+		System:            "base\n\nrules",
+		StableSystemBytes: len("base"),
+		Messages: []Message{
+			NewTextMessage(RoleSystem, "be brief"),
+			NewTextMessage(RoleDeveloper, "answer in JSON"),
+			NewTextMessage(RoleUser, "hi"),
+			NewTextMessage(RoleAssistant, "hello"),
+			NewTextMessage(RoleDeveloper, "now in YAML"),
+		},
+	}
+
+	wire := newClaudeRequest(req, true, InstructionRolesNative)
+	blocks, ok := wire.System.([]claudeSystemBlock)
+	if !ok || len(blocks) != 4 {
+		t.Fatalf("expected cached, rest and two instruction blocks, got %#v", wire.System)
+	}
+	if blocks[0].CacheControl == nil || blocks[1].Text != "rules" || blocks[2].Text != "be brief" || blocks[3].Text != "answer in JSON" {
+		t.Fatalf("unexpected system blocks: %#v", blocks)
+	}
+	if len(wire.Messages) != 3 || wire.Messages[0].Role != RoleUser || wire.Messages[2].Role != RoleUser || wire.Messages[2].GetText() != "[developer]\nnow in YAML" {
+		t.Fatalf("unexpected messages: %#v", wire.Messages)
+	}
+	if req.Messages[4].Role != RoleDeveloper {
+		t.Fatal("the request messages must not be modified")
+	}
+
+	wire = newClaudeRequest(AgentRequest{Messages: req.Messages[:3]}, false, InstructionRolesNative)
+	if blocks, ok := wire.System.([]claudeSystemBlock); !ok || len(blocks) != 2 {
+		t.Fatalf("expected instruction blocks without a system prompt, got %#v", wire.System)
+	}
+
+	wire = newClaudeRequest(req, false, InstructionRolesUser)
+	if wire.System != req.System || len(wire.Messages) != 5 || wire.Messages[0].GetText() != "[system]\nbe brief" {
+		t.Fatalf("expected instructions downgraded to user messages, got %#v / %#v", wire.System, wire.Messages)
+	}
+}
+
+func TestOpenAIProviderInstructionRoles(t *testing.T) {
+	messages := []Message{
+		NewTextMessage(RoleDeveloper, "answer in JSON"),
+		NewTextMessage(RoleUser, "hi"),
+		NewTextMessage(RoleSystem, "be brief"),
+	}
+	roles := func(mode InstructionRoles) []string {
+		p := NewOpenAIProvider(LLMProviderConfig{Type: ProviderOpenAI, InstructionRoles: mode})
+		var out []string
+		for _, msg := range p.convertToOpenAIRequest(AgentRequest{System: "base", Messages: messages}).Messages {
+			out = append(out, msg.Role+":"+msg.Content.(string))
+		}
+		return out
+	}
+
+	tests := []struct {
+		mode InstructionRoles
+		want []string
+	}{
+		{InstructionRolesNative, []string{"system:base", "developer:answer in JSON", "user:hi", "system:be brief"}},
+		{InstructionRolesSystem, []string{"system:base", "system:answer in JSON", "user:hi", "system:be brief"}},
+		{InstructionRolesUser, []string{"system:base", "user:[developer]\nanswer in JSON", "user:hi", "user:[system]\nbe brief"}},
+	}
+	for _, tt := range tests {
+		if got := roles(tt.mode); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+
+	if p := NewOpenRouterProvider(LLMProviderConfig{Type: ProviderOpenRouter}); p.InstructionRoles != InstructionRolesSystem {
+		t.Fatalf("expected OpenRouter to default to system roles, got %q", p.InstructionRoles)
+	}
+}
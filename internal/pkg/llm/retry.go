@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RetryNotice describes a failed provider attempt that is about to be
+// retried after Wait.
+type RetryNotice struct {
+	Provider    string
+	Attempt     int
+	MaxAttempts int
+
+	// Status is the HTTP status of the failed attempt, 0 for transport errors.
+	Status int
+
+	// RateLimited is set when the provider throttled the request (HTTP 429,
+	// or 529 for an overloaded Claude API).
+	RateLimited bool
+
+	Wait time.Duration
+	Err  error
+}
+
+type retryObserverKey struct{}
+
+// WithRetryObserver returns a context whose provider calls report each retry
+// to fn before waiting. fn runs on the calling goroutine.
+func WithRetryObserver(ctx context.Context, fn func(RetryNotice)) context.Context {
+	return context.WithValue(ctx, retryObserverKey{}, fn)
+}
+
+// NotifyRetry reports a retry to the context's observer, if any. Providers
+// outside this package call it before waiting between attempts so rate
+// limit notes and retry callbacks cover them too.
+func NotifyRetry(ctx context.Context, notice RetryNotice) {
+	notifyRetry(ctx, notice)
+}
+
+// notifyRetry reports a retry to the context's observer, if any.
+func notifyRetry(ctx context.Context, notice RetryNotice) {
+	notice.RateLimited = notice.Status == http.StatusTooManyRequests || notice.Status == 529
+	if fn, ok := ctx.Value(retryObserverKey{}).(func(RetryNotice)); ok && fn != nil {
+		fn(notice)
+	}
+}
+
+// retryFits reports whether waiting wait leaves time for another attempt
+// before ctx's deadline. Providers give up instead of sleeping past it.
+func retryFits(ctx context.Context, wait time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > wait
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClaudeProviderReportsRateLimitRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			http.Error(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`, http.StatusTooManyRequests)
+		case 2:
+			http.Error(w, "upstream failed", http.StatusBadGateway)
+		default:
+			json.NewEncoder(w).Encode(AgentResponse{
+				Role:       RoleAssistant,
+				StopReason: StopReasonEndTurn,
+				Content:    []ContentBlock{{Type: ContentTypeText, Text: "ok"}},
+			})
+		}
+	}))
+	defer server.Close()
+
+	provider := NewClaudeProvider(LLMProviderConfig{
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		Model:       "claude-test",
+		MaxAttempts: 3,
+	})
+	provider.Backoff = func(attempt int) time.Duration { return time.Duration(attempt) * time.Second }
+	provider.Sleep = func(time.Duration) {}
+
+	var notices []RetryNotice
+	ctx := WithRetryObserver(context.Background(), func(n RetryNotice) {
+		notices = append(notices, n)
+	})
+	if _, err := provider.Call(ctx, AgentRequest{Messages: []Message{NewTextMessage(RoleUser, "hi")}}); err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+
+	if len(notices) != 2 {
+		t.Fatalf("expected 2 retry notices, got %+v", notices)
+	}
+	first := notices[0]
+	if !first.RateLimited || first.Status != http.StatusTooManyRequests || first.Wait != time.Second ||
+		first.Attempt != 1 || first.MaxAttempts != 3 || first.Provider != "claude" {
+		t.Fatalf("unexpected rate limit notice: %+v", first)
+	}
+	if notices[1].RateLimited || notices[1].Status != http.StatusBadGateway {
+		t.Fatalf("expected a plain retry notice for 502, got %+v", notices[1])
+	}
+}
+
+func TestOpenAIProviderStopsRetryingAtDeadline(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "upstream failed", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(LLMProviderConfig{
+		BaseURL:     server.URL,
+		APIKey:      "test-key",
+		Model:       "gpt-test",
+		MaxAttempts: 5,
+	})
+	provider.Backoff = func(int) time.Duration { return time.Hour }
+	slept := false
+	provider.Sleep = func(time.Duration) { slept = true }
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := provider.Call(ctx, AgentRequest{Messages: []Message{NewTextMessage(RoleUser, "hi")}}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 || slept {
+		t.Fatalf("expected one attempt without waiting past the deadline, got calls=%d slept=%v", calls, slept)
+	}
+}
@@ -0,0 +1,258 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ResponseValidation selects how the OpenAI-compatible provider handles
+// malformed responses, which some gateways return: missing tool call IDs,
+// a finish_reason that contradicts the content, or tool arguments that are
+// stringified twice or followed by garbage.
+type ResponseValidation string
+
+const (
+	// ResponseValidationLenient repairs what it can and reports every
+	// repair in AgentResponse.Repairs. It is the default.
+	ResponseValidationLenient ResponseValidation = "lenient"
+
+	// ResponseValidationStrict fails the call with a
+	// *ResponseValidationError instead, e.g. in CI to catch gateway
+	// regressions early.
+	ResponseValidationStrict ResponseValidation = "strict"
+)
+
+// RepairKind classifies a ResponseRepair.
+type RepairKind string
+
+const (
+	// RepairToolArguments means tool arguments were not a plain JSON
+	// object but could be recovered, e.g. by removing trailing data.
+	RepairToolArguments RepairKind = "tool_arguments"
+
+	// RepairTruncatedToolArguments means tool arguments were cut off,
+	// e.g. by a stream that ended early, after a complete member. They are
+	// closed so the tool_use block shows what arrived, but ContentBlock.
+	// InputError is set and the tool is not run: the missing members could
+	// have changed what the call does. Detail names the closers appended.
+	RepairTruncatedToolArguments RepairKind = "truncated_tool_arguments"
+
+	// RepairInvalidToolArguments means tool arguments could not be
+	// parsed. The tool call is kept with empty input and
+	// ContentBlock.InputError set; the agent loop answers it with an
+	// error result instead of running the tool.
+	RepairInvalidToolArguments RepairKind = "invalid_tool_arguments"
+
+	// RepairMissingToolCallID means a tool call had no ID. The agent
+	// loop assigns one.
+	RepairMissingToolCallID RepairKind = "missing_tool_call_id"
+
+	// RepairMissingToolName means a tool call had no function name. The
+	// call is dropped.
+	RepairMissingToolName RepairKind = "missing_tool_name"
+
+	// RepairFinishReason means finish_reason was missing, unknown, or
+	// contradicted the content, and the stop reason was derived from the
+	// content instead.
+	RepairFinishReason RepairKind = "finish_reason"
+)
+
+// ResponseRepair describes one problem found in a provider response and
+// how it was handled.
+type ResponseRepair struct {
+	Kind RepairKind
+
+	// ToolUseID and ToolName identify the affected tool call, if any.
+	ToolUseID string
+	ToolName  string
+
+	// Detail describes the problem, e.g. the trailing data removed from
+	// the arguments.
+	Detail string
+}
+
+func (r ResponseRepair) String() string {
+	var b strings.Builder
+	b.WriteString(string(r.Kind))
+	if r.ToolName != "" || r.ToolUseID != "" {
+		fmt.Fprintf(&b, " (tool %s %s)", r.ToolName, r.ToolUseID)
+	}
+	if r.Detail != "" {
+		b.WriteString(": ")
+		b.WriteString(r.Detail)
+	}
+	return b.String()
+}
+
+// ResponseValidationError is returned in ResponseValidationStrict mode for
+// a response that would have needed repairs.
+type ResponseValidationError struct {
+	Repairs []ResponseRepair
+}
+
+func (e *ResponseValidationError) Error() string {
+	problems := make([]string, len(e.Repairs))
+	for i, r := range e.Repairs {
+		problems[i] = r.String()
+	}
+	return "malformed provider response: " + strings.Join(problems, "; ")
+}
+
+// responseSanitizer collects the repairs made while converting one
+// OpenAI-compatible response.
+type responseSanitizer struct {
+	repairs []ResponseRepair
+}
+
+// toolCall reports whether a tool call can be kept.
+func (s *responseSanitizer) toolCall(id, name string) bool {
+	if strings.TrimSpace(name) == "" {
+		s.add(ResponseRepair{Kind: RepairMissingToolName, ToolUseID: id, Detail: "tool call dropped"})
+		return false
+	}
+	if id == "" {
+		s.add(ResponseRepair{Kind: RepairMissingToolCallID, ToolName: name})
+	}
+	return true
+}
+
+// toolInput parses a tool call's arguments, recovering from the common
+// gateway defects. Arguments that cannot be recovered yield nil input and an
+// error describing why, for the tool_use block's InputError. Arguments cut
+// off after a complete member yield the closed input and an error as well,
+// so the call is answered instead of run.
+func (s *responseSanitizer) toolInput(id, name string, args *argumentScanner) (map[string]any, string) {
+	raw := args.String()
+	input, detail, err := parseToolArguments(raw)
+	if err != nil {
+		if repaired, closers, ok := args.repair(); ok {
+			if input, _, err := parseToolArguments(repaired); err == nil {
+				s.add(ResponseRepair{Kind: RepairTruncatedToolArguments, ToolUseID: id, ToolName: name,
+					Detail: fmt.Sprintf("appended %q", closers)})
+				return input, "arguments were cut off after a complete member and may be missing the rest (truncated)"
+			}
+		}
+		if reason := args.truncation(); reason != "" {
+			err = fmt.Errorf("%s (truncated)", reason)
+		}
+		s.add(ResponseRepair{Kind: RepairInvalidToolArguments, ToolUseID: id, ToolName: name,
+			Detail: fmt.Sprintf("%v: %s", err, truncateForLog(raw, 200))})
+		return nil, err.Error()
+	}
+	if detail != "" {
+		s.add(ResponseRepair{Kind: RepairToolArguments, ToolUseID: id, ToolName: name, Detail: detail})
+	}
+	return input, ""
+}
+
+// stopReason maps an OpenAI finish_reason to a StopReason. Tool calls take
+// precedence, since some gateways report "stop" while returning them.
+func (s *responseSanitizer) stopReason(finishReason string, hasToolCalls bool) StopReason {
+	var reason StopReason
+	switch finishReason {
+	case "stop", "content_filter":
+		reason = StopReasonEndTurn
+	case "tool_calls", "function_call":
+		reason = StopReasonToolUse
+	case "length":
+		reason = StopReasonMaxTokens
+	case "":
+		s.add(ResponseRepair{Kind: RepairFinishReason, Detail: "finish_reason missing"})
+		reason = StopReasonEndTurn
+	default:
+		s.add(ResponseRepair{Kind: RepairFinishReason, Detail: fmt.Sprintf("unknown finish_reason %q", finishReason)})
+		reason = StopReasonEndTurn
+	}
+	switch {
+	case hasToolCalls && reason != StopReasonToolUse:
+		if finishReason != "" {
+			s.add(ResponseRepair{Kind: RepairFinishReason, Detail: fmt.Sprintf("finish_reason %q with tool calls", finishReason)})
+		}
+		return StopReasonToolUse
+	case !hasToolCalls && reason == StopReasonToolUse:
+		s.add(ResponseRepair{Kind: RepairFinishReason, Detail: fmt.Sprintf("finish_reason %q without tool calls", finishReason)})
+		return StopReasonEndTurn
+	}
+	return reason
+}
+
+func (s *responseSanitizer) add(r ResponseRepair) {
+	s.repairs = append(s.repairs, r)
+}
+
+// finish records the repairs on resp, or returns them as an error in
+// strict mode.
+func (s *responseSanitizer) finish(resp *AgentResponse, mode ResponseValidation) error {
+	if len(s.repairs) == 0 {
+		return nil
+	}
+	if mode == ResponseValidationStrict {
+		return &ResponseValidationError{Repairs: s.repairs}
+	}
+	resp.Repairs = s.repairs
+	return nil
+}
+
+// parseToolArguments parses tool call arguments as a JSON object. It
+// recovers from a code fence around the object, an object encoded as a
+// JSON string, and data after the object, and describes what it removed.
+func parseToolArguments(raw string) (map[string]any, string, error) {
+	args := strings.TrimSpace(raw)
+	if args == "" {
+		return nil, "", nil
+	}
+	var input map[string]any
+	if err := json.Unmarshal([]byte(args), &input); err == nil {
+		return input, "", nil
+	}
+
+	var fixes []string
+	if unfenced, ok := stripCodeFence(args); ok {
+		args = unfenced
+		fixes = append(fixes, "removed code fence")
+	}
+	for range 2 {
+		dec := json.NewDecoder(strings.NewReader(args))
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			return nil, "", errors.New("arguments are not valid JSON")
+		}
+		if rest := bytes.TrimSpace([]byte(args[dec.InputOffset():])); len(rest) > 0 {
+			fixes = append(fixes, fmt.Sprintf("removed trailing data %q", truncateForLog(string(rest), 50)))
+			args = args[:dec.InputOffset()]
+		}
+		switch v := value.(type) {
+		case string:
+			// The object was encoded as a JSON string; decode it again.
+			args = strings.TrimSpace(v)
+			fixes = append(fixes, "decoded stringified arguments")
+			continue
+		case map[string]any, nil:
+			if err := json.Unmarshal([]byte(args), &input); err != nil {
+				return nil, "", errors.New("arguments are not valid JSON")
+			}
+			return input, strings.Join(fixes, ", "), nil
+		}
+		break
+	}
+	return nil, "", errors.New("arguments are not a JSON object")
+}
+
+// stripCodeFence removes a Markdown code fence around s.
+func stripCodeFence(s string) (string, bool) {
+	if !strings.HasPrefix(s, "```") {
+		return s, false
+	}
+	body := strings.TrimPrefix(s, "```")
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+		body = body[nl+1:]
+	} else {
+		return s, false
+	}
+	body = strings.TrimSpace(body)
+	body = strings.TrimSuffix(body, "```")
+	return strings.TrimSpace(body), true
+}
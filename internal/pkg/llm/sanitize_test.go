@@ -0,0 +1,190 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseToolArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]any
+		fix     string
+		wantErr bool
+	}{
+		{name: "clean", raw: `{"path":"a.go"}`, want: map[string]any{"path": "a.go"}},
+		{name: "empty", raw: "  "},
+		{name: "trailing garbage", raw: `{"path":"a.go"}}<|end|>`, want: map[string]any{"path": "a.go"}, fix: "removed trailing data"},
+		{name: "stringified", raw: `"{\"path\":\"a.go\"}"`, want: map[string]any{"path": "a.go"}, fix: "decoded stringified arguments"},
+		{name: "code fence", raw: "```json\n{\"path\":\"a.go\"}\n```", want: map[string]any{"path": "a.go"}, fix: "removed code fence"},
+		{name: "array", raw: `["a.go"]`, wantErr: true},
+		{name: "broken", raw: `{"path":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fix, err := parseToolArguments(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("input = %#v, want %#v", got, tt.want)
+			}
+			if !strings.Contains(fix, tt.fix) || (tt.fix == "") != (fix == "") {
+				t.Fatalf("fix = %q, want %q", fix, tt.fix)
+			}
+		})
+	}
+}
+
+func malformedOpenAIServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":    "resp_1",
+			"model": "gw-model",
+			"choices": []map[string]any{{
+				"message": map[string]any{
+					"role": "assistant",
+					"tool_calls": []map[string]any{
+						{"id": "", "type": "function", "function": map[string]any{"name": "read_file", "arguments": `{"path":"a.go"} trailing`}},
+						{"id": "call_2", "type": "function", "function": map[string]any{"name": "", "arguments": `{}`}},
+					},
+				},
+				"finish_reason": "eos",
+			}},
+		})
+	}))
+}
+
+func TestOpenAIProviderRepairsMalformedResponse(t *testing.T) {
+	server := malformedOpenAIServer(t)
+	defer server.Close()
+
+	provider := NewOpenAIProvider(LLMProviderConfig{BaseURL: server.URL, APIKey: "k", Model: "m"})
+	resp, err := provider.Call(context.Background(), AgentRequest{Messages: []Message{NewTextMessage(RoleUser, "hi")}})
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	toolUses := resp.GetToolUses()
+	if len(toolUses) != 1 || toolUses[0].Input["path"] != "a.go" || resp.StopReason != StopReasonToolUse {
+		t.Fatalf("unexpected repaired response: %+v", resp)
+	}
+
+	var kinds []RepairKind
+	for _, r := range resp.Repairs {
+		kinds = append(kinds, r.Kind)
+	}
+	want := []RepairKind{RepairMissingToolCallID, RepairToolArguments, RepairMissingToolName, RepairFinishReason, RepairFinishReason}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("repairs = %v, want %v", resp.Repairs, want)
+	}
+}
+
+func TestOpenAIProviderStrictValidation(t *testing.T) {
+	server := malformedOpenAIServer(t)
+	defer server.Close()
+
+	provider := NewOpenAIProvider(LLMProviderConfig{BaseURL: server.URL, APIKey: "k", Model: "m", ResponseValidation: ResponseValidationStrict})
+	_, err := provider.Call(context.Background(), AgentRequest{Messages: []Message{NewTextMessage(RoleUser, "hi")}})
+	var validationErr *ResponseValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Repairs) != 5 {
+		t.Fatalf("expected a ResponseValidationError with 5 problems, got %v", err)
+	}
+}
+
+func TestOpenAIProviderStreamRepairsArguments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"ping","arguments":"\"{\\\"n\\\":1}\""}}]},"finish_reason":"tool_calls"}]}` + "\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(LLMProviderConfig{BaseURL: server.URL, APIKey: "k", Model: "m"})
+	resp, err := provider.Stream(context.Background(), AgentRequest{Messages: []Message{NewTextMessage(RoleUser, "hi")}}, nil)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	toolUses := resp.GetToolUses()
+	if len(toolUses) != 1 || toolUses[0].Input["n"] != float64(1) {
+		t.Fatalf("expected stringified arguments decoded, got %+v", toolUses)
+	}
+	if len(resp.Repairs) != 1 || resp.Repairs[0].Kind != RepairToolArguments || resp.Repairs[0].ToolUseID != "call_1" {
+		t.Fatalf("unexpected repairs: %+v", resp.Repairs)
+	}
+}
+
+func TestArgumentScannerRepair(t *testing.T) {
+	tests := []struct {
+		name      string
+		fragments []string
+		want      string // repaired arguments; "" when not repairable
+		reason    string
+	}{
+		{name: "after member", fragments: []string{`{"path":`, `"a.go"`}, want: `{"path":"a.go"}`},
+		{name: "trailing comma", fragments: []string{`{"path":"a.go",`, ` `}, want: `{"path":"a.go"}`},
+		{name: "nested", fragments: []string{`{"edits":[{"old":"}"`, `,"new":"]"}`}, want: `{"edits":[{"old":"}","new":"]"}]}`},
+		{name: "number", fragments: []string{`{"n":12,`}, want: `{"n":12}`},
+		{name: "inside string", fragments: []string{`{"path":"a.`}, reason: "inside a string value"},
+		{name: "inside key", fragments: []string{`{"pa`}, reason: "inside a key"},
+		{name: "after colon", fragments: []string{`{"path":`}, reason: "before a value"},
+		{name: "inside number", fragments: []string{`{"n":12`}, reason: "inside a value"},
+		{name: "complete", fragments: []string{`{"n":1}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args argumentScanner
+			for _, f := range tt.fragments {
+				args.WriteString(f)
+			}
+			got, _, ok := args.repair()
+			if ok != (tt.want != "") || got != tt.want {
+				t.Fatalf("repair() = %q, %v, want %q", got, ok, tt.want)
+			}
+			if reason := args.truncation(); tt.want == "" && (!strings.Contains(reason, tt.reason) || (tt.reason == "") != (reason == "")) {
+				t.Fatalf("truncation() = %q, want %q", reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestOpenAIProviderStreamTruncatedArguments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		// The stream ends without [DONE] in the middle of both calls.
+		_, _ = w.Write([]byte(`data: {"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"read","arguments":"{\"path\":"}},{"index":1,"id":"call_2","function":{"name":"write","arguments":"{\"path\":\"b.go\","}}]}}]}` + "\n\n"))
+		_, _ = w.Write([]byte(`data: {"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"a.go\""}},{"index":1,"function":{"arguments":"\"content\":\"pack"}}]}}]}` + "\n\n"))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(LLMProviderConfig{BaseURL: server.URL, APIKey: "k", Model: "m"})
+	resp, err := provider.Stream(context.Background(), AgentRequest{Messages: []Message{NewTextMessage(RoleUser, "hi")}}, nil)
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	toolUses := resp.GetToolUses()
+	if len(toolUses) != 2 {
+		t.Fatalf("expected both calls kept, got %+v", toolUses)
+	}
+	if toolUses[0].Input["path"] != "a.go" || !strings.Contains(toolUses[0].InputError, "truncated") {
+		t.Fatalf("expected the first call closed but not runnable, got %+v", toolUses[0])
+	}
+	if toolUses[1].Input != nil || !strings.Contains(toolUses[1].InputError, "inside a string value") {
+		t.Fatalf("expected the second call unrecoverable, got %+v", toolUses[1])
+	}
+	var kinds []RepairKind
+	for _, r := range resp.Repairs {
+		kinds = append(kinds, r.Kind)
+	}
+	want := []RepairKind{RepairTruncatedToolArguments, RepairInvalidToolArguments, RepairFinishReason}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("repairs = %v, want %v", kinds, want)
+	}
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ServerTool is a provider-native tool that the provider executes itself,
+// such as Claude's web_search. Unlike ToolDefinition it has no local
+// implementation: its calls and results arrive in the response as
+// server_tool_use and *_tool_result blocks and are sent back unchanged.
+type ServerTool struct {
+	// Type is the versioned tool type, e.g. "web_search_20250305".
+	Type string
+
+	// Name is the tool name, e.g. "web_search".
+	Name string
+
+	// Config holds the tool's remaining fields (max_uses,
+	// allowed_domains, user_location, ...), sent as-is.
+	Config map[string]any
+}
+
+// MarshalJSON flattens Config next to type and name.
+func (t ServerTool) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]any, len(t.Config)+2)
+	for k, v := range t.Config {
+		fields[k] = v
+	}
+	fields["type"] = t.Type
+	fields["name"] = t.Name
+	return json.Marshal(fields)
+}
+
+// IsServerToolResult reports whether t is the result block of a server
+// tool, e.g. web_search_tool_result.
+func IsServerToolResult(t ContentType) bool {
+	return t != ContentTypeToolResult && strings.HasSuffix(string(t), "_tool_result")
+}
+
+// contentBlockJSON is ContentBlock without its JSON methods.
+type contentBlockJSON ContentBlock
+
+// MarshalJSON writes ServerContent as the content of server tool results.
+func (b ContentBlock) MarshalJSON() ([]byte, error) {
+	if !IsServerToolResult(b.Type) {
+		return json.Marshal(contentBlockJSON(b))
+	}
+	return json.Marshal(struct {
+		contentBlockJSON
+		Content json.RawMessage `json:"content,omitempty"`
+	}{contentBlockJSON(b), b.ServerContent})
+}
+
+// UnmarshalJSON keeps the structured content of server tool results in
+// ServerContent; other blocks carry string content.
+func (b *ContentBlock) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		contentBlockJSON
+		Content json.RawMessage `json:"content,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*b = ContentBlock(aux.contentBlockJSON)
+	if IsServerToolResult(b.Type) {
+		b.ServerContent = aux.Content
+		return nil
+	}
+	if len(aux.Content) > 0 && string(aux.Content) != "null" {
+		if err := json.Unmarshal(aux.Content, &b.Content); err != nil {
+			return fmt.Errorf("%s block content: %w", b.Type, err)
+		}
+	}
+	return nil
+}
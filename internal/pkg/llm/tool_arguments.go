@@ -0,0 +1,179 @@
+package llm
+
+import "strings"
+
+// argState is what an argumentScanner expects next.
+type argState int
+
+const (
+	argValue      argState = iota // a value: top level, after ':' or '[' or ',' in an array
+	argKey                        // a key or '}': after '{' or ',' in an object
+	argColon                      // ':' after a key
+	argAfterValue                 // ',' or a closing bracket
+)
+
+// argumentScanner accumulates streamed tool call arguments and follows
+// their JSON structure as the fragments arrive, so arguments cut off
+// mid-stream can be closed where they were cut.
+type argumentScanner struct {
+	buf strings.Builder
+
+	stack    []byte // open '{' and '['
+	state    argState
+	inString bool
+	isKey    bool // the open string is an object key
+	escaped  bool
+	literal  bool // inside a number, true, false, or null
+	done     bool // the top-level value is complete
+	invalid  bool // not JSON; left to parseToolArguments
+}
+
+// WriteString appends an argument fragment.
+func (a *argumentScanner) WriteString(s string) {
+	a.buf.WriteString(s)
+	for i := 0; i < len(s) && !a.done && !a.invalid; i++ {
+		a.scan(s[i])
+	}
+}
+
+func (a *argumentScanner) String() string {
+	return a.buf.String()
+}
+
+func (a *argumentScanner) scan(c byte) {
+	if a.inString {
+		switch {
+		case a.escaped:
+			a.escaped = false
+		case c == '\\':
+			a.escaped = true
+		case c == '"':
+			a.inString = false
+			if a.isKey {
+				a.state = argColon
+			} else {
+				a.valueDone()
+			}
+		}
+		return
+	}
+	if a.literal {
+		if !strings.ContainsRune(" \t\r\n,}]", rune(c)) {
+			return
+		}
+		a.literal = false
+		a.valueDone()
+		if a.done {
+			return
+		}
+	}
+
+	switch c {
+	case ' ', '\t', '\r', '\n':
+	case '{', '[':
+		if a.state != argValue {
+			a.invalid = true
+			return
+		}
+		a.stack = append(a.stack, c)
+		a.state = argValue
+		if c == '{' {
+			a.state = argKey
+		}
+	case '"':
+		if a.state != argValue && a.state != argKey {
+			a.invalid = true
+			return
+		}
+		a.inString, a.isKey = true, a.state == argKey
+	case ':':
+		if a.state != argColon {
+			a.invalid = true
+			return
+		}
+		a.state = argValue
+	case ',':
+		if a.state != argAfterValue || len(a.stack) == 0 {
+			a.invalid = true
+			return
+		}
+		a.state = argValue
+		if a.top() == '{' {
+			a.state = argKey
+		}
+	case '}', ']':
+		open := byte('{')
+		if c == ']' {
+			open = '['
+		}
+		// Empty containers and trailing commas are tolerated here, as in
+		// the repair.
+		closable := a.state == argAfterValue || (c == '}' && a.state == argKey) || (c == ']' && a.state == argValue)
+		if len(a.stack) == 0 || a.top() != open || !closable {
+			a.invalid = true
+			return
+		}
+		a.stack = a.stack[:len(a.stack)-1]
+		a.valueDone()
+	default:
+		if a.state != argValue {
+			a.invalid = true
+			return
+		}
+		a.literal = true
+	}
+}
+
+func (a *argumentScanner) top() byte {
+	return a.stack[len(a.stack)-1]
+}
+
+func (a *argumentScanner) valueDone() {
+	a.state = argAfterValue
+	if len(a.stack) == 0 {
+		a.done = true
+	}
+}
+
+// repair closes arguments that were cut off after a complete member or
+// element. It returns the repaired arguments and the appended closers.
+// Arguments cut inside a key, a value, or between a key and its value are
+// not repaired: guessing the rest could run a tool with altered input.
+func (a *argumentScanner) repair() (string, string, bool) {
+	if a.done || a.invalid || len(a.stack) == 0 || a.inString || a.literal {
+		return "", "", false
+	}
+	inner := a.state == argKey || (a.state == argValue && a.top() == '[')
+	if a.state != argAfterValue && !inner {
+		return "", "", false
+	}
+	repaired := strings.TrimRight(strings.TrimSpace(a.buf.String()), ",")
+	repaired = strings.TrimSpace(repaired)
+	var closers strings.Builder
+	for i := len(a.stack) - 1; i >= 0; i-- {
+		if a.stack[i] == '{' {
+			closers.WriteByte('}')
+		} else {
+			closers.WriteByte(']')
+		}
+	}
+	return repaired + closers.String(), closers.String(), true
+}
+
+// truncation describes where arguments that could not be repaired were
+// cut off, or returns "" when they were not cut off.
+func (a *argumentScanner) truncation() string {
+	switch {
+	case a.done || a.invalid || (len(a.stack) == 0 && !a.inString && !a.literal):
+		return ""
+	case a.inString && a.isKey:
+		return "arguments end inside a key"
+	case a.inString:
+		return "arguments end inside a string value"
+	case a.literal:
+		return "arguments end inside a value"
+	case a.state == argColon || a.state == argValue:
+		return "arguments end before a value"
+	}
+	return "arguments are incomplete"
+}
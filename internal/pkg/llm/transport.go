@@ -0,0 +1,201 @@
+package llm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// HTTPConfig tunes the HTTP transport used by providers. Providers created
+// with the same HTTPConfig share one transport and its connection pool.
+// Zero values use the defaults noted on each field.
+type HTTPConfig struct {
+	// MaxIdleConns caps idle connections across all hosts (default 100).
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost caps idle connections kept per host (default 32;
+	// net/http's default of 2 forces reconnects under concurrent load).
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost caps total connections per host. Zero is unlimited.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout closes idle connections after this long (default 90s).
+	IdleConnTimeout time.Duration
+
+	// KeepAlive is the TCP keep-alive period (default 30s; negative
+	// disables keep-alives).
+	KeepAlive time.Duration
+
+	// DialTimeout and TLSHandshakeTimeout bound connection setup
+	// (defaults 30s and 10s).
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+
+	// DisableHTTP2 keeps connections on HTTP/1.1. HTTP/2 is negotiated
+	// when the server supports it otherwise.
+	DisableHTTP2 bool
+
+	// ProxyURL routes requests through this proxy, e.g.
+	// "http://proxy.corp:3128". Empty uses HTTPS_PROXY/HTTP_PROXY/NO_PROXY.
+	ProxyURL string
+
+	// CAFile is a PEM bundle of extra root CAs trusted in addition to the
+	// system pool, e.g. a corporate TLS-inspecting proxy's CA.
+	CAFile string
+
+	// InsecureSkipVerify disables TLS certificate verification. For local
+	// testing only.
+	InsecureSkipVerify bool
+
+	// TLSConfig replaces the TLS settings derived from CAFile and
+	// InsecureSkipVerify.
+	TLSConfig *tls.Config
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = map[HTTPConfig]*http.Transport{}
+)
+
+// SharedTransport returns the transport for cfg, creating it on first use.
+func SharedTransport(cfg HTTPConfig) (*http.Transport, error) {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if t, ok := transports[cfg]; ok {
+		return t, nil
+	}
+	t, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transports[cfg] = t
+	return t, nil
+}
+
+// NewHTTPClient returns a client on the shared transport for cfg with the
+// given request timeout (zero means none).
+func NewHTTPClient(cfg HTTPConfig, timeout time.Duration) (*http.Client, error) {
+	t, err := SharedTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: t, Timeout: timeout}, nil
+}
+
+// newProviderHTTPClient returns a client on the shared transport for cfg.
+// It falls back to nil (a per-call default client) if the transport cannot
+// be built; NewLLMProvider reports that error up front.
+func newProviderHTTPClient(cfg HTTPConfig, timeout time.Duration) *http.Client {
+	client, err := NewHTTPClient(cfg, timeout)
+	if err != nil {
+		log.Printf("[llm] WARNING: invalid HTTP config, using default client: %v", err)
+		return nil
+	}
+	return client
+}
+
+func newTransport(cfg HTTPConfig) (*http.Transport, error) {
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy URL: %w", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   durationOrDefault(cfg.DialTimeout, 30*time.Second),
+		KeepAlive: durationOrDefault(cfg.KeepAlive, 30*time.Second),
+	}
+	t := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          intOrDefault(cfg.MaxIdleConns, 100),
+		MaxIdleConnsPerHost:   intOrDefault(cfg.MaxIdleConnsPerHost, 32),
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       durationOrDefault(cfg.IdleConnTimeout, 90*time.Second),
+		TLSHandshakeTimeout:   durationOrDefault(cfg.TLSHandshakeTimeout, 10*time.Second),
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+	}
+	if cfg.DisableHTTP2 {
+		// A non-nil empty map turns off the transport's HTTP/2 upgrade.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t, nil
+}
+
+func (cfg HTTPConfig) tlsConfig() (*tls.Config, error) {
+	if cfg.TLSConfig != nil {
+		return cfg.TLSConfig.Clone(), nil
+	}
+	if cfg.CAFile == "" && !cfg.InsecureSkipVerify {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA file %s contains no PEM certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+func intOrDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func durationOrDefault(v, def time.Duration) time.Duration {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// CustomizeRequest applies extra headers and a request mutator to req, the
+// way built-in providers apply ExtraHeaders and RequestMutator.
+func CustomizeRequest(req *http.Request, headers map[string]string, mutate func(*http.Request)) {
+	customizeRequest(req, headers, mutate)
+}
+
+// customizeRequest applies a provider's extra headers and request mutator
+// to req.
+func customizeRequest(req *http.Request, headers map[string]string, mutate func(*http.Request)) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if mutate != nil {
+		mutate(req)
+	}
+}
@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSharedTransportReusedForSameConfig(t *testing.T) {
+	cfg := HTTPConfig{MaxIdleConnsPerHost: 7}
+	first, err := SharedTransport(cfg)
+	if err != nil {
+		t.Fatalf("SharedTransport: %v", err)
+	}
+	second, err := SharedTransport(cfg)
+	if err != nil {
+		t.Fatalf("SharedTransport: %v", err)
+	}
+	if first != second {
+		t.Fatal("expected the same transport for equal configs")
+	}
+	if first.MaxIdleConnsPerHost != 7 || first.MaxIdleConns != 100 {
+		t.Fatalf("pool sizes = %d/%d, want 7/100", first.MaxIdleConnsPerHost, first.MaxIdleConns)
+	}
+
+	p := NewClaudeProvider(LLMProviderConfig{HTTP: cfg})
+	if p.HTTPClient == nil || p.HTTPClient.Transport != first {
+		t.Fatal("expected provider to use the shared transport")
+	}
+	if p.HTTPClient.Timeout != p.Timeout {
+		t.Fatalf("client timeout = %v, want %v", p.HTTPClient.Timeout, p.Timeout)
+	}
+}
+
+func TestSharedTransportHTTP2AndProxy(t *testing.T) {
+	tr, err := SharedTransport(HTTPConfig{DisableHTTP2: true, ProxyURL: "http://proxy.internal:3128"})
+	if err != nil {
+		t.Fatalf("SharedTransport: %v", err)
+	}
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil {
+		t.Fatal("expected HTTP/2 to be disabled")
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://api.anthropic.com/v1/messages", nil)
+	proxyURL, err := tr.Proxy(req)
+	if err != nil {
+		t.Fatalf("Proxy: %v", err)
+	}
+	if proxyURL == nil || proxyURL.Host != "proxy.internal:3128" {
+		t.Fatalf("proxy = %v, want proxy.internal:3128", proxyURL)
+	}
+
+	tr, err = SharedTransport(HTTPConfig{})
+	if err != nil {
+		t.Fatalf("SharedTransport: %v", err)
+	}
+	if !tr.ForceAttemptHTTP2 {
+		t.Fatal("expected HTTP/2 to be attempted by default")
+	}
+}
+
+func TestNewLLMProviderRejectsInvalidHTTPConfig(t *testing.T) {
+	dir := t.TempDir()
+	badPEM := filepath.Join(dir, "bad.pem")
+	if err := os.WriteFile(badPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cfg := range []HTTPConfig{
+		{CAFile: filepath.Join(dir, "missing.pem")},
+		{CAFile: badPEM},
+		{ProxyURL: "://bad"},
+	} {
+		_, err := NewLLMProvider(LLMProviderConfig{Type: ProviderClaude, HTTP: cfg})
+		if err == nil || !strings.Contains(err.Error(), "http config") {
+			t.Fatalf("NewLLMProvider(%+v) error = %v, want http config error", cfg, err)
+		}
+	}
+}
+
+func TestClaudeProviderTrustsCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewLLMProvider(LLMProviderConfig{
+		Type:    ProviderClaude,
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Model:   "claude-test",
+		HTTP:    HTTPConfig{CAFile: caFile},
+	})
+	if err != nil {
+		t.Fatalf("NewLLMProvider: %v", err)
+	}
+
+	resp, err := provider.Call(context.Background(), AgentRequest{
+		Messages: []Message{NewTextMessage(RoleUser, "hi")},
+	})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if resp.GetText() != "ok" {
+		t.Fatalf("text = %q, want ok", resp.GetText())
+	}
+}
+
+func TestProvidersApplyExtraHeadersAndRequestMutator(t *testing.T) {
+	var got []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Clone())
+		switch {
+		case r.Header.Get("Accept") == "text/event-stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"id\":\"c\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n"))
+		case r.Header.Get("x-api-key") != "":
+			_, _ = w.Write([]byte(`{"id":"m","type":"message","role":"assistant","stop_reason":"end_turn","content":[{"type":"text","text":"ok"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"id":"c","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := LLMProviderConfig{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Model:   "m",
+		ExtraHeaders: map[string]string{
+			"HTTP-Referer":  "https://example.com",
+			"Authorization": "Bearer gateway-key",
+		},
+		RequestMutator: func(req *http.Request) {
+			req.Header.Set("X-Tenant", "acme")
+		},
+	}
+	req := AgentRequest{Messages: []Message{NewTextMessage(RoleUser, "hi")}}
+
+	if _, err := NewClaudeProvider(cfg).Call(context.Background(), req); err != nil {
+		t.Fatalf("claude Call() error = %v", err)
+	}
+	openai := NewOpenAIProvider(cfg)
+	if _, err := openai.Call(context.Background(), req); err != nil {
+		t.Fatalf("openai Call() error = %v", err)
+	}
+	if _, err := openai.Stream(context.Background(), req, func(ContentBlockDelta) {}); err != nil {
+		t.Fatalf("openai Stream() error = %v", err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(got))
+	}
+	for i, h := range got {
+		if h.Get("HTTP-Referer") != "https://example.com" || h.Get("X-Tenant") != "acme" {
+			t.Fatalf("request %d missing custom headers: %v", i, h)
+		}
+		// Extra headers override the provider's own.
+		if h.Get("Authorization") != "Bearer gateway-key" {
+			t.Fatalf("request %d: Authorization = %q", i, h.Get("Authorization"))
+		}
+	}
+}
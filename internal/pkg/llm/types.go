@@ -1,11 +1,19 @@
 package llm
 
+import "encoding/json"
+
 // Role represents the role of a message sender.
 type Role string
 
 const (
 	RoleUser      Role = "user"
 	RoleAssistant Role = "assistant"
+
+	// RoleSystem and RoleDeveloper carry instructions from the application
+	// rather than the user, e.g. a mid-run policy change. Providers send
+	// them as configured by LLMProviderConfig.InstructionRoles.
+	RoleSystem    Role = "system"
+	RoleDeveloper Role = "developer"
 )
 
 // ContentType represents the type of content block.
@@ -15,6 +23,17 @@ const (
 	ContentTypeText       ContentType = "text"
 	ContentTypeToolUse    ContentType = "tool_use"
 	ContentTypeToolResult ContentType = "tool_result"
+
+	// ContentTypeThinking and ContentTypeRedactedThinking are Claude extended
+	// thinking blocks. They must be sent back unchanged with the assistant turn.
+	ContentTypeThinking         ContentType = "thinking"
+	ContentTypeRedactedThinking ContentType = "redacted_thinking"
+
+	// ContentTypeServerToolUse is a call of a ServerTool, executed by the
+	// provider. Its result follows in the same assistant message as a
+	// *_tool_result block such as ContentTypeWebSearchToolResult.
+	ContentTypeServerToolUse       ContentType = "server_tool_use"
+	ContentTypeWebSearchToolResult ContentType = "web_search_tool_result"
 )
 
 // StopReason represents why the model stopped generating.
@@ -25,6 +44,10 @@ const (
 	StopReasonToolUse   StopReason = "tool_use"
 	StopReasonMaxTokens StopReason = "max_tokens"
 	StopReasonStopSeq   StopReason = "stop_sequence"
+
+	// StopReasonPauseTurn means the provider paused a long server tool
+	// turn; sending the response back as-is lets the model continue.
+	StopReasonPauseTurn StopReason = "pause_turn"
 )
 
 // ContentBlock represents a content block in a message.
@@ -39,18 +62,57 @@ type ContentBlock struct {
 	Name  string                 `json:"name,omitempty"`
 	Input map[string]interface{} `json:"input,omitempty"`
 
+	// InputError is why the tool call's arguments could not be parsed,
+	// e.g. because the stream ended inside them. The call is answered with
+	// an error result instead of running the tool.
+	InputError string `json:"-"`
+
 	// For tool_result content
 	ToolUseID string `json:"tool_use_id,omitempty"`
 	Content   string `json:"content,omitempty"`
 	IsError   bool   `json:"is_error,omitempty"`
+
+	// For thinking content
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+
+	// For redacted_thinking content (opaque, encrypted)
+	Data string `json:"data,omitempty"`
+
+	// For server tool results (e.g. web_search_tool_result): the raw
+	// structured content, sent back unchanged.
+	ServerContent json.RawMessage `json:"-"`
 }
 
+// ToolCallPhase identifies which part of a streamed tool call a delta carries.
+type ToolCallPhase string
+
+const (
+	// ToolCallPhaseStart is emitted once the tool name is known.
+	ToolCallPhaseStart ToolCallPhase = "start"
+	// ToolCallPhaseDelta carries an incremental argument fragment.
+	ToolCallPhaseDelta ToolCallPhase = "delta"
+	// ToolCallPhaseReady is emitted once the arguments are complete.
+	ToolCallPhaseReady ToolCallPhase = "ready"
+)
+
 // ContentBlockDelta represents a streamed incremental content update.
 type ContentBlockDelta struct {
-	// Type is currently always text for stream deltas.
+	// Type is text for model output fragments and tool_use for tool call fragments.
 	Type ContentType `json:"type"`
 	// Text is the incremental token/text fragment.
 	Text string `json:"text,omitempty"`
+
+	// The fields below are only set for tool_use deltas.
+	Phase ToolCallPhase `json:"phase,omitempty"`
+	// Index is the position of the tool call within the response.
+	Index int    `json:"index,omitempty"`
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	// PartialInput is the raw JSON argument fragment (delta phase).
+	PartialInput string `json:"partial_input,omitempty"`
+	// Input is the parsed argument object (ready phase).
+	Input map[string]any `json:"input,omitempty"`
 }
 
 // Message represents a message in the conversation.
@@ -58,6 +120,21 @@ type Message struct {
 	Role             Role           `json:"role"`
 	Content          []ContentBlock `json:"content"`
 	ReasoningContent string         `json:"reasoning_content,omitempty"`
+
+	// Metadata carries embedder annotations (origin, visibility, pinning, ...).
+	// It travels with the message through the loop but is never sent to providers.
+	Metadata map[string]any `json:"-"`
+}
+
+// WithMetadata returns a copy of the message with key set in its metadata.
+func (m Message) WithMetadata(key string, value any) Message {
+	metadata := make(map[string]any, len(m.Metadata)+1)
+	for k, v := range m.Metadata {
+		metadata[k] = v
+	}
+	metadata[key] = value
+	m.Metadata = metadata
+	return m
 }
 
 // NewTextMessage creates a new text message.
@@ -99,6 +176,20 @@ func (m Message) GetText() string {
 	return result
 }
 
+// GetThinking extracts concatenated text from all thinking content blocks.
+func (m Message) GetThinking() string {
+	var result string
+	for _, block := range m.Content {
+		if block.Type == ContentTypeThinking {
+			if result != "" {
+				result += "\n"
+			}
+			result += block.Thinking
+		}
+	}
+	return result
+}
+
 // GetToolUses extracts all tool use blocks from the message.
 func (m Message) GetToolUses() []ContentBlock {
 	var uses []ContentBlock
@@ -117,6 +208,20 @@ type ToolDefinition struct {
 	InputSchema map[string]interface{} `json:"input_schema"`
 }
 
+// ThinkingConfig enables Claude extended thinking.
+type ThinkingConfig struct {
+	// Type is "enabled" or "disabled".
+	Type string `json:"type"`
+	// BudgetTokens bounds the tokens spent on thinking. It counts towards
+	// max_tokens and must be at least 1024.
+	BudgetTokens int `json:"budget_tokens,omitempty"`
+}
+
+// NewThinkingConfig returns an enabled ThinkingConfig with the given budget.
+func NewThinkingConfig(budgetTokens int) *ThinkingConfig {
+	return &ThinkingConfig{Type: "enabled", BudgetTokens: budgetTokens}
+}
+
 // AgentRequest represents a request to the agent API.
 type AgentRequest struct {
 	Model       string           `json:"model"`
@@ -126,6 +231,56 @@ type AgentRequest struct {
 	Tools       []ToolDefinition `json:"tools,omitempty"`
 	StopSeqs    []string         `json:"stop_sequences,omitempty"`
 	Temperature *float64         `json:"temperature,omitempty"`
+	Thinking    *ThinkingConfig  `json:"thinking,omitempty"`
+	ToolChoice  *ToolChoice      `json:"tool_choice,omitempty"`
+
+	// ServerTools are provider-native tools sent alongside Tools. Only the
+	// Claude provider supports them; others ignore them.
+	ServerTools []ServerTool `json:"-"`
+
+	// Seed requests reproducible sampling. Only OpenAI-compatible
+	// providers support it; the Claude provider ignores it.
+	Seed *int64 `json:"-"`
+
+	// AssistantPrefill starts the model's reply with this text, e.g. "{" to
+	// force a JSON object. Providers send it as a trailing assistant message
+	// and prepend it to the returned text.
+	AssistantPrefill string `json:"-"`
+
+	// ExtraBody is merged into the provider's JSON request body after
+	// LLMProviderConfig.ExtraBody (see there).
+	ExtraBody map[string]any `json:"-"`
+
+	// StableSystemBytes is the length of System's leading part that stays
+	// the same across the requests of a session, e.g. the SOUL file and
+	// repository instructions. With LLMProviderConfig.PromptCaching, the
+	// Claude provider marks it cacheable.
+	StableSystemBytes int `json:"-"`
+}
+
+// Tool choice types.
+const (
+	ToolChoiceAuto = "auto"
+	ToolChoiceAny  = "any"
+	ToolChoiceNone = "none"
+	ToolChoiceTool = "tool"
+)
+
+// ToolChoice controls how the model may use tools.
+type ToolChoice struct {
+	// Type is "auto", "any", "none", or "tool".
+	Type string `json:"type"`
+
+	// Name is the tool the model must call when Type is "tool".
+	Name string `json:"name,omitempty"`
+
+	// DisableParallelToolUse limits the model to one tool call per response.
+	DisableParallelToolUse bool `json:"disable_parallel_tool_use,omitempty"`
+}
+
+// Forced reports whether the choice requires a tool call.
+func (c ToolChoice) Forced() bool {
+	return c.Type == ToolChoiceAny || c.Type == ToolChoiceTool
 }
 
 // AgentResponse represents a response from the agent API.
@@ -139,12 +294,21 @@ type AgentResponse struct {
 	StopReason       StopReason     `json:"stop_reason"`
 	StopSequence     string         `json:"stop_sequence,omitempty"`
 	Usage            Usage          `json:"usage"`
+
+	// Repairs lists the defects the provider repaired in a malformed
+	// response (see ResponseValidation).
+	Repairs []ResponseRepair `json:"-"`
 }
 
 // Usage represents token usage information.
 type Usage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
+
+	// CacheReadInputTokens are input tokens served from the provider's
+	// prompt cache. Claude counts them separately from InputTokens; OpenAI
+	// includes them in it.
+	CacheReadInputTokens int `json:"cache_read_input_tokens,omitempty"`
 }
 
 // ToMessage converts the response to a Message for conversation history.
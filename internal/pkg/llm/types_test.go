@@ -1,6 +1,10 @@
 package llm
 
-import "testing"
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
 
 func TestNewTextMessage(t *testing.T) {
 	msg := NewTextMessage(RoleUser, "hello")
@@ -139,3 +143,27 @@ func TestAgentResponseToMessagePreservesReasoningContent(t *testing.T) {
 		t.Errorf("ReasoningContent = %q, want %q", msg.ReasoningContent, "thought summary")
 	}
 }
+
+func TestMessageWithMetadataCopiesMap(t *testing.T) {
+	original := NewTextMessage(RoleUser, "hello").WithMetadata("origin", "ui")
+	tagged := original.WithMetadata("pinned", true)
+
+	if _, ok := original.Metadata["pinned"]; ok {
+		t.Errorf("WithMetadata mutated the original message metadata")
+	}
+	if tagged.Metadata["origin"] != "ui" || tagged.Metadata["pinned"] != true {
+		t.Errorf("Metadata = %#v, want origin and pinned", tagged.Metadata)
+	}
+}
+
+func TestMessageMetadataNotSerialized(t *testing.T) {
+	msg := NewTextMessage(RoleUser, "hello").WithMetadata("origin", "ui")
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), "origin") {
+		t.Errorf("metadata leaked into provider payload: %s", data)
+	}
+}
@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Clock tells the loop the time for the durations it records: provider and
+// tool latency, tool call stats, and profiles. Inject a ManualClock to make
+// them deterministic in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator generates IDs for tool_use blocks the provider left empty or
+// duplicated.
+type IDGenerator interface {
+	NewID() string
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+type randomIDs struct{}
+
+func (randomIDs) NewID() string { return generateToolUseID() }
+
+// ManualClock is a Clock that only moves when told to. Each Now call
+// returns the current time and then advances it by the step, so that every
+// measured duration is a multiple of the step.
+type ManualClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewManualClock returns a clock at start that advances by step on each Now.
+func NewManualClock(start time.Time, step time.Duration) *ManualClock {
+	return &ManualClock{now: start, step: step}
+}
+
+// Now returns the clock's time and advances it by the step.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SequentialIDs is an IDGenerator that returns Prefix followed by 1, 2, 3,
+// and so on. The zero value uses the prefix "tool_seq_".
+type SequentialIDs struct {
+	Prefix string
+
+	mu sync.Mutex
+	n  int
+}
+
+// NewID returns the next ID.
+func (s *SequentialIDs) NewID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.n++
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "tool_seq_"
+	}
+	return fmt.Sprintf("%s%d", prefix, s.n)
+}
@@ -2,11 +2,13 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
 
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
 )
 
 // CompactConfig holds configuration for context compaction.
@@ -14,35 +16,32 @@ type CompactConfig struct {
 	Enabled    bool
 	Threshold  int // Trigger compact when messages exceed this
 	KeepRecent int // Keep this many recent messages after compact
+
+	// MaxErrors caps the failed tool calls the summary keeps verbatim,
+	// dropping the oldest first (0 uses DefaultMaxErrors, negative keeps
+	// none).
+	MaxErrors int
 }
 
+// DefaultMaxErrors is the default cap of failed tool calls kept in a
+// compaction summary.
+const DefaultMaxErrors = 20
+
 // DefaultCompactConfig returns sensible defaults for compaction.
 func DefaultCompactConfig() CompactConfig {
 	return CompactConfig{
 		Enabled:    true,
 		Threshold:  30,
 		KeepRecent: 10,
+		MaxErrors:  DefaultMaxErrors,
 	}
 }
 
-// compactSummaryPrompt is the system prompt for generating conversation summaries.
-const compactSummaryPrompt = `You are a conversation summarizer. Your task is to create a concise but comprehensive summary of the conversation history that preserves all important context for continuing the task.
-
-Your summary MUST include:
-1. **Original Task**: What was the user's initial request/goal?
-2. **Key Decisions**: Important decisions made during the conversation
-3. **Files Modified**: List of files that were read, created, or modified with brief descriptions of changes
-4. **Current State**: What has been accomplished so far?
-5. **Pending Work**: What still needs to be done?
-6. **Important Context**: Any critical information needed to continue (error messages, specific requirements, etc.)
-
-Format your summary as a structured document. Be concise but don't omit important details.
-Do NOT include tool call details or raw outputs - just summarize the key information.`
-
 // Compactor handles conversation context compaction.
 type Compactor struct {
 	provider llm.LLMProvider
 	config   CompactConfig
+	locale   string
 }
 
 // NewCompactor creates a new Compactor.
@@ -54,6 +53,13 @@ func NewCompactor(provider llm.LLMProvider, config CompactConfig) *Compactor {
 	}
 }
 
+// WithLocale sets the language of the summary prompt and the summary
+// message header. Empty uses English.
+func (c *Compactor) WithLocale(lang string) *Compactor {
+	c.locale = lang
+	return c
+}
+
 // ShouldCompact returns true if the conversation should be compacted.
 func (c *Compactor) ShouldCompact(messages []llm.Message) bool {
 	if !c.config.Enabled {
@@ -62,13 +68,41 @@ func (c *Compactor) ShouldCompact(messages []llm.Message) bool {
 	return len(messages) > c.config.Threshold
 }
 
+func (c *Compactor) maxErrors() int {
+	switch {
+	case c.config.MaxErrors < 0:
+		return 0
+	case c.config.MaxErrors == 0:
+		return DefaultMaxErrors
+	}
+	return c.config.MaxErrors
+}
+
 // Compact summarizes the conversation and returns a compacted message list.
 // It keeps the first message (initial prompt), generates a summary of the middle,
 // and keeps the most recent messages.
 func (c *Compactor) Compact(ctx context.Context, messages []llm.Message) ([]llm.Message, error) {
+	compacted, _, err := c.CompactWithSummary(ctx, messages)
+	if err != nil {
+		log.Printf("[compact] ERROR: failed to generate summary: %v", err)
+		// Fall back to simple truncation
+		return truncateMessages(messages, c.config.KeepRecent+1), nil
+	}
+	return compacted, nil
+}
+
+// CompactWithSummary is Compact without the truncation fallback: it returns
+// the summary text, or an error if the summary could not be generated. The
+// summary is empty when there is nothing to compact.
+//
+// The summary message carries a CompactSummary in its metadata (see
+// CompactSummaryMetadataKey). When the messages being summarized include an
+// earlier summary message, the model updates that summary with the newer
+// messages instead of summarizing it again.
+func (c *Compactor) CompactWithSummary(ctx context.Context, messages []llm.Message) ([]llm.Message, string, error) {
 	if len(messages) <= c.config.KeepRecent+1 {
 		// Not enough messages to compact
-		return messages, nil
+		return messages, "", nil
 	}
 
 	log.Printf("[compact] starting compaction: %d messages, threshold=%d, keep_recent=%d",
@@ -79,22 +113,43 @@ func (c *Compactor) Compact(ctx context.Context, messages []llm.Message) ([]llm.
 	summarizeEnd := len(messages) - c.config.KeepRecent
 	if summarizeEnd <= 1 {
 		// Nothing to summarize
-		return messages, nil
+		return messages, "", nil
 	}
 
-	// Build the conversation text to summarize
-	messagesToSummarize := messages[1:summarizeEnd]
+	// Split the earlier summary, if any, from the messages to summarize
+	var previous *CompactSummary
+	var messagesToSummarize []llm.Message
+	for _, msg := range messages[1:summarizeEnd] {
+		if s, ok := SummaryFromMessage(msg); ok {
+			previous = &s
+			continue
+		}
+		messagesToSummarize = append(messagesToSummarize, msg)
+	}
 	conversationText := formatMessagesForSummary(messagesToSummarize)
 
-	log.Printf("[compact] summarizing %d messages (%d chars)", len(messagesToSummarize), len(conversationText))
+	log.Printf("[compact] summarizing %d messages (%d chars), updating previous summary: %v",
+		len(messagesToSummarize), len(conversationText), previous != nil)
 
 	// Generate summary using the LLM
-	summary, err := c.generateSummary(ctx, conversationText)
+	summaryText, err := c.generateSummary(ctx, previous, conversationText)
 	if err != nil {
-		log.Printf("[compact] ERROR: failed to generate summary: %v", err)
-		// Fall back to simple truncation
-		return truncateMessages(messages, c.config.KeepRecent+1), nil
+		return nil, "", err
 	}
+	structured, ok := parseCompactSummary(summaryText)
+	if !ok {
+		log.Printf("[compact] WARNING: summary is not the requested JSON, keeping it as notes")
+		structured = CompactSummary{Notes: strings.TrimSpace(summaryText)}
+	}
+	structured.merge(previous, toolInventory(messagesToSummarize))
+	if max := c.maxErrors(); len(structured.Errors) > max {
+		structured.Errors = structured.Errors[len(structured.Errors)-max:]
+	}
+	structured.Messages = len(messagesToSummarize)
+	if previous != nil {
+		structured.Messages += previous.Messages
+	}
+	summary := structured.render(c.locale)
 
 	log.Printf("[compact] generated summary: %d chars", len(summary))
 
@@ -110,10 +165,10 @@ func (c *Compactor) Compact(ctx context.Context, messages []llm.Message) ([]llm.
 		Content: []llm.ContentBlock{
 			{
 				Type: llm.ContentTypeText,
-				Text: fmt.Sprintf("[Conversation Summary - %d messages compacted]\n\n%s", len(messagesToSummarize), summary),
+				Text: fmt.Sprintf(locale.Text(c.locale, locale.CompactSummaryHeader), structured.Messages) + "\n\n" + summary,
 			},
 		},
-	})
+	}.WithMetadata(CompactSummaryMetadataKey, structured))
 
 	// Recent messages (need to ensure tool pairs are intact)
 	recentMessages := messages[summarizeEnd:]
@@ -122,15 +177,25 @@ func (c *Compactor) Compact(ctx context.Context, messages []llm.Message) ([]llm.
 
 	log.Printf("[compact] compaction complete: %d -> %d messages", len(messages), len(result))
 
-	return result, nil
+	return result, summary, nil
 }
 
-// generateSummary calls the LLM to generate a conversation summary.
-func (c *Compactor) generateSummary(ctx context.Context, conversationText string) (string, error) {
+// generateSummary calls the LLM to generate a conversation summary, or to
+// update previous with the conversation when it is set.
+func (c *Compactor) generateSummary(ctx context.Context, previous *CompactSummary, conversationText string) (string, error) {
+	prompt := locale.Text(c.locale, locale.CompactSummaryRequest) + "\n\n" + conversationText
+	if previous != nil {
+		data, err := json.MarshalIndent(previous, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("marshal previous summary: %w", err)
+		}
+		prompt = locale.Text(c.locale, locale.CompactSummaryUpdate) + "\n\n" + string(data) + "\n\n" +
+			locale.Text(c.locale, locale.CompactNewMessages) + "\n\n" + conversationText
+	}
 	req := llm.AgentRequest{
-		System: compactSummaryPrompt,
+		System: locale.Text(c.locale, locale.CompactSummaryPrompt),
 		Messages: []llm.Message{
-			llm.NewTextMessage(llm.RoleUser, "Please summarize the following conversation:\n\n"+conversationText),
+			llm.NewTextMessage(llm.RoleUser, prompt),
 		},
 		// No tools for summary generation
 		Tools: nil,
@@ -170,7 +235,7 @@ func formatMessagesForSummary(messages []llm.Message) string {
 					sb.WriteString(block.Text)
 					sb.WriteString("\n")
 				}
-			case llm.ContentTypeToolUse:
+			case llm.ContentTypeToolUse, llm.ContentTypeServerToolUse:
 				sb.WriteString(fmt.Sprintf("[Tool Call: %s]\n", block.Name))
 				// Don't include full input to keep summary manageable
 			case llm.ContentTypeToolResult:
@@ -183,6 +248,14 @@ func formatMessagesForSummary(messages []llm.Message) string {
 				} else {
 					sb.WriteString(fmt.Sprintf("[Tool Result: %s]\n", content))
 				}
+			default:
+				if llm.IsServerToolResult(block.Type) {
+					content := string(block.ServerContent)
+					if len(content) > 500 {
+						content = content[:500] + "... (truncated)"
+					}
+					sb.WriteString(fmt.Sprintf("[Tool Result: %s]\n", content))
+				}
 			}
 		}
 		sb.WriteString("\n")
@@ -0,0 +1,356 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+)
+
+// CompactSummaryMetadataKey marks the message holding a compaction summary.
+// Its metadata value is the CompactSummary; later compactions update that
+// summary instead of summarizing its rendered text again.
+const CompactSummaryMetadataKey = "compact_summary"
+
+// maxInventoryCommandLen truncates long commands in the tool call inventory.
+const maxInventoryCommandLen = 200
+
+// maxFailureErrorLen truncates long error messages of failed tool calls.
+const maxFailureErrorLen = 1000
+
+// CompactSummary is the structured state a compaction keeps in place of the
+// summarized messages.
+type CompactSummary struct {
+	Task         string      `json:"task,omitempty"`
+	CurrentState string      `json:"current_state,omitempty"`
+	FilesTouched []FileTouch `json:"files_touched,omitempty"`
+	CommandsRun  []string    `json:"commands_run,omitempty"`
+	Decisions    []string    `json:"decisions,omitempty"`
+	OpenTODOs    []string    `json:"open_todos,omitempty"`
+	Notes        string      `json:"notes,omitempty"`
+
+	// ToolCalls counts the summarized tool calls by tool name.
+	ToolCalls map[string]int `json:"tool_calls,omitempty"`
+
+	// Errors are the failed tool calls of the summarized messages, oldest
+	// first. They are taken from the tool results rather than the model's
+	// summary and kept verbatim across compactions, so the agent does not
+	// retry approaches that are known to fail.
+	Errors []ToolFailure `json:"errors_encountered,omitempty"`
+
+	// Messages is the total number of messages summarized so far, across
+	// all compactions.
+	Messages int `json:"messages"`
+}
+
+// FileTouch is a file the summarized conversation worked with.
+type FileTouch struct {
+	Path string `json:"path"`
+
+	// Action is what happened to the file (read, created, modified,
+	// deleted), or the tool name when only the tool call inventory saw it.
+	Action string `json:"action,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// ToolFailure is a failed tool call kept in a compaction summary.
+type ToolFailure struct {
+	Tool string `json:"tool"`
+
+	// Input is the call's command or path, or its JSON input.
+	Input string `json:"input,omitempty"`
+	Error string `json:"error"`
+
+	// Count is how many times the same call failed with the same error.
+	Count int `json:"count"`
+}
+
+// SummaryFromMessage returns the structured summary stored on a compaction
+// summary message.
+func SummaryFromMessage(msg llm.Message) (CompactSummary, bool) {
+	value, ok := msg.Metadata[CompactSummaryMetadataKey]
+	if !ok {
+		return CompactSummary{}, false
+	}
+	switch v := value.(type) {
+	case CompactSummary:
+		return v, true
+	case *CompactSummary:
+		return *v, v != nil
+	}
+	// Metadata that went through JSON (e.g. a client round trip) is a map.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return CompactSummary{}, false
+	}
+	var summary CompactSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return CompactSummary{}, false
+	}
+	return summary, true
+}
+
+// parseCompactSummary reads the model's JSON summary, tolerating code fences
+// and surrounding prose.
+func parseCompactSummary(text string) (CompactSummary, bool) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return CompactSummary{}, false
+	}
+	var summary CompactSummary
+	if err := json.Unmarshal([]byte(text[start:end+1]), &summary); err != nil {
+		return CompactSummary{}, false
+	}
+	return summary, true
+}
+
+// toolInventory collects the files, commands, and tool call counts of the
+// tool_use blocks in messages, and the calls whose tool_result is an error.
+func toolInventory(messages []llm.Message) CompactSummary {
+	var inv CompactSummary
+	calls := map[string]llm.ContentBlock{}
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type == llm.ContentTypeToolResult && block.IsError {
+				call := calls[block.ToolUseID]
+				inv.Errors = mergeFailures(inv.Errors, []ToolFailure{{
+					Tool:  call.Name,
+					Input: describeToolInput(call.Input),
+					Error: truncateText(strings.TrimSpace(block.Content), maxFailureErrorLen),
+					Count: 1,
+				}})
+				continue
+			}
+			if block.Type != llm.ContentTypeToolUse && block.Type != llm.ContentTypeServerToolUse {
+				continue
+			}
+			if inv.ToolCalls == nil {
+				inv.ToolCalls = map[string]int{}
+			}
+			inv.ToolCalls[block.Name]++
+			calls[block.ID] = block
+			for _, key := range []string{"path", "file_path"} {
+				if path, ok := block.Input[key].(string); ok && path != "" {
+					inv.FilesTouched = mergeFiles(inv.FilesTouched, []FileTouch{{Path: path, Action: block.Name}})
+				}
+			}
+			if cmd, ok := block.Input["command"].(string); ok && cmd != "" {
+				inv.CommandsRun = mergeStrings(inv.CommandsRun, []string{truncateText(cmd, maxInventoryCommandLen)})
+			}
+		}
+	}
+	return inv
+}
+
+// describeToolInput returns the command or path of a tool call's input, or
+// the whole input as JSON.
+func describeToolInput(input map[string]any) string {
+	for _, key := range []string{"command", "path", "file_path"} {
+		if s, ok := input[key].(string); ok && s != "" {
+			return truncateText(s, maxInventoryCommandLen)
+		}
+	}
+	if len(input) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return ""
+	}
+	return truncateText(string(data), maxInventoryCommandLen)
+}
+
+func truncateText(s string, max int) string {
+	if len(s) > max {
+		return s[:max] + "..."
+	}
+	return s
+}
+
+// merge fills in what the model's summary left out from the previous
+// summary and the tool call inventory of the newly summarized messages.
+func (s *CompactSummary) merge(previous *CompactSummary, inventory CompactSummary) {
+	if previous != nil {
+		if s.Task == "" {
+			s.Task = previous.Task
+		}
+		if s.CurrentState == "" {
+			s.CurrentState = previous.CurrentState
+		}
+		s.FilesTouched = mergeFiles(s.FilesTouched, previous.FilesTouched)
+		s.CommandsRun = mergeStrings(previous.CommandsRun, s.CommandsRun)
+		s.Decisions = mergeStrings(previous.Decisions, s.Decisions)
+		if s.OpenTODOs == nil {
+			s.OpenTODOs = previous.OpenTODOs
+		}
+		if s.Notes == "" {
+			s.Notes = previous.Notes
+		}
+	}
+	// The model may echo or reword the previous errors; only the recorded
+	// ones are kept.
+	s.Errors = nil
+	if previous != nil {
+		s.Errors = mergeFailures(s.Errors, previous.Errors)
+	}
+	s.Errors = mergeFailures(s.Errors, inventory.Errors)
+	s.FilesTouched = mergeFiles(s.FilesTouched, inventory.FilesTouched)
+	s.CommandsRun = mergeStrings(s.CommandsRun, inventory.CommandsRun)
+
+	counts := map[string]int{}
+	if previous != nil {
+		for name, n := range previous.ToolCalls {
+			counts[name] += n
+		}
+	}
+	for name, n := range inventory.ToolCalls {
+		counts[name] += n
+	}
+	s.ToolCalls = nil
+	if len(counts) > 0 {
+		s.ToolCalls = counts
+	}
+}
+
+// mergeFiles appends the files of add not yet in base. An entry in base
+// keeps its action and note; a missing note is taken from add.
+func mergeFiles(base, add []FileTouch) []FileTouch {
+	out := append([]FileTouch(nil), base...)
+	index := make(map[string]int, len(out))
+	for i, f := range out {
+		index[f.Path] = i
+	}
+	for _, f := range add {
+		if f.Path == "" {
+			continue
+		}
+		if i, ok := index[f.Path]; ok {
+			if out[i].Note == "" {
+				out[i].Note = f.Note
+			}
+			if out[i].Action == "" {
+				out[i].Action = f.Action
+			}
+			continue
+		}
+		index[f.Path] = len(out)
+		out = append(out, f)
+	}
+	return out
+}
+
+// mergeFailures appends the failures of add not yet in base. A repeated
+// failure (same tool, input, and error) adds its count to the earlier one.
+func mergeFailures(base, add []ToolFailure) []ToolFailure {
+	out := append([]ToolFailure(nil), base...)
+	for _, f := range add {
+		if f.Count <= 0 {
+			f.Count = 1
+		}
+		found := false
+		for i := range out {
+			if out[i].Tool == f.Tool && out[i].Input == f.Input && out[i].Error == f.Error {
+				out[i].Count += f.Count
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func mergeStrings(base, add []string) []string {
+	out := append([]string(nil), base...)
+	seen := make(map[string]bool, len(out))
+	for _, s := range out {
+		seen[s] = true
+	}
+	for _, s := range add {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// render formats the summary as the text the model sees. Notes come last
+// without a heading, which is also how an unstructured summary is kept.
+func (s CompactSummary) render(lang string) string {
+	var b strings.Builder
+	section := func(key locale.Key, body string) {
+		if body == "" {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "## %s\n%s", locale.Text(lang, key), body)
+	}
+	list := func(items []string) string {
+		if len(items) == 0 {
+			return ""
+		}
+		return "- " + strings.Join(items, "\n- ")
+	}
+
+	section(locale.CompactSectionTask, s.Task)
+	section(locale.CompactSectionState, s.CurrentState)
+
+	files := make([]string, 0, len(s.FilesTouched))
+	for _, f := range s.FilesTouched {
+		line := f.Path
+		if f.Action != "" {
+			line += " (" + f.Action + ")"
+		}
+		if f.Note != "" {
+			line += ": " + f.Note
+		}
+		files = append(files, line)
+	}
+	section(locale.CompactSectionFiles, list(files))
+	section(locale.CompactSectionCommands, list(s.CommandsRun))
+	section(locale.CompactSectionDecisions, list(s.Decisions))
+	section(locale.CompactSectionTodos, list(s.OpenTODOs))
+
+	failures := make([]string, 0, len(s.Errors))
+	for _, f := range s.Errors {
+		line := f.Tool
+		if f.Input != "" {
+			line += ": " + f.Input
+		}
+		if f.Count > 1 {
+			line += fmt.Sprintf(" (×%d)", f.Count)
+		}
+		failures = append(failures, line+"\n  "+strings.ReplaceAll(f.Error, "\n", "\n  "))
+	}
+	section(locale.CompactSectionErrors, list(failures))
+
+	if len(s.ToolCalls) > 0 {
+		names := make([]string, 0, len(s.ToolCalls))
+		for name := range s.ToolCalls {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		counts := make([]string, len(names))
+		for i, name := range names {
+			counts[i] = fmt.Sprintf("%s ×%d", name, s.ToolCalls[name])
+		}
+		section(locale.CompactSectionToolCalls, strings.Join(counts, ", "))
+	}
+
+	if s.Notes != "" {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(s.Notes)
+	}
+	return b.String()
+}
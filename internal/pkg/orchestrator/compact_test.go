@@ -1,6 +1,8 @@
 package orchestrator
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
@@ -141,3 +143,136 @@ func containsMiddle(s, substr string) bool {
 	}
 	return false
 }
+
+func TestCompactorUsesLocale(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "摘要"}}},
+	}}
+	messages := make([]llm.Message, 6)
+	for i := range messages {
+		messages[i] = llm.NewTextMessage(llm.RoleUser, "msg")
+	}
+
+	compacted, _, err := NewCompactor(provider, CompactConfig{Enabled: true, KeepRecent: 2}).
+		WithLocale("zh").
+		CompactWithSummary(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("CompactWithSummary() error = %v", err)
+	}
+	if got := compacted[1].GetText(); got != "[对话摘要 - 已压缩 3 条消息]\n\n摘要" {
+		t.Fatalf("unexpected summary message %q", got)
+	}
+	req := provider.requests[0]
+	if !strings.Contains(req.System, "使用中文") || !strings.HasPrefix(req.Messages[0].GetText(), "请总结以下对话") {
+		t.Fatalf("expected localized summary request, got system=%q", req.System)
+	}
+}
+
+func TestCompactorKeepsStructuredSummaryAndUpdatesIt(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "```json\n" +
+			`{"task":"fix the build","files_touched":[{"path":"a.go","action":"modified","note":"fixed import"}],` +
+			`"decisions":["keep the old API"],"open_todos":["run tests"]}` + "\n```"}}},
+		{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: `{"current_state":"tests pass","open_todos":[]}`}}},
+	}}
+	toolTurn := func(id, name string, input map[string]any) []llm.Message {
+		return []llm.Message{
+			{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeToolUse, ID: id, Name: name, Input: input}}},
+			{Role: llm.RoleUser, Content: []llm.ContentBlock{{Type: llm.ContentTypeToolResult, ToolUseID: id, Content: "ok"}}},
+		}
+	}
+	messages := []llm.Message{llm.NewTextMessage(llm.RoleUser, "fix the build")}
+	messages = append(messages, toolTurn("t1", "write_file", map[string]any{"path": "a.go"})...)
+	messages = append(messages, toolTurn("t2", "bash", map[string]any{"command": "go build ./..."})...)
+	messages = append(messages, llm.NewTextMessage(llm.RoleAssistant, "built"))
+
+	compactor := NewCompactor(provider, CompactConfig{Enabled: true, KeepRecent: 1})
+	compacted, _, err := compactor.CompactWithSummary(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("CompactWithSummary() error = %v", err)
+	}
+	first, ok := SummaryFromMessage(compacted[1])
+	if !ok {
+		t.Fatal("expected the summary message to carry a structured summary")
+	}
+	if first.Messages != 4 || first.ToolCalls["bash"] != 1 || len(first.FilesTouched) != 1 ||
+		first.FilesTouched[0].Note != "fixed import" || len(first.CommandsRun) != 1 {
+		t.Fatalf("unexpected first summary: %+v", first)
+	}
+	text := compacted[1].GetText()
+	for _, want := range []string{"[Conversation Summary - 4 messages compacted]", "## Task\nfix the build", "- a.go (modified): fixed import", "- go build ./...", "bash ×1, write_file ×1"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("summary text missing %q:\n%s", want, text)
+		}
+	}
+
+	compacted = append(compacted, toolTurn("t3", "bash", map[string]any{"command": "go test ./..."})...)
+	compacted = append(compacted, llm.NewTextMessage(llm.RoleAssistant, "tested"))
+	compacted, _, err = compactor.CompactWithSummary(context.Background(), compacted)
+	if err != nil {
+		t.Fatalf("second CompactWithSummary() error = %v", err)
+	}
+
+	prompt := provider.requests[1].Messages[0].GetText()
+	if !strings.Contains(prompt, `"open_todos": [`) || strings.Contains(prompt, "[Conversation Summary") {
+		t.Fatalf("expected the previous summary as JSON rather than its rendered text, got:\n%s", prompt)
+	}
+	second, ok := SummaryFromMessage(compacted[1])
+	if !ok {
+		t.Fatal("expected an updated structured summary")
+	}
+	if second.Task != "fix the build" || second.CurrentState != "tests pass" || len(second.OpenTODOs) != 0 ||
+		len(second.Decisions) != 1 || second.ToolCalls["bash"] != 2 || second.Messages != 7 ||
+		len(second.CommandsRun) != 2 {
+		t.Fatalf("unexpected updated summary: %+v", second)
+	}
+}
+
+func TestCompactorKeepsToolFailuresVerbatim(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: `{"task":"release"}`}}},
+		{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: `{"errors_encountered":[{"tool":"bash","error":"reworded"}]}`}}},
+	}}
+	failedTurn := func(id, command, output string) []llm.Message {
+		return []llm.Message{
+			{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeToolUse, ID: id, Name: "bash", Input: map[string]any{"command": command}}}},
+			{Role: llm.RoleUser, Content: []llm.ContentBlock{{Type: llm.ContentTypeToolResult, ToolUseID: id, Content: output, IsError: true}}},
+		}
+	}
+	messages := []llm.Message{llm.NewTextMessage(llm.RoleUser, "release")}
+	messages = append(messages, failedTurn("t1", "make release", "make: *** No rule to make target 'release'.\nStop.")...)
+	messages = append(messages, failedTurn("t2", "git push --tags", "fatal: could not read Username")...)
+	messages = append(messages, llm.NewTextMessage(llm.RoleAssistant, "stuck"))
+
+	compactor := NewCompactor(provider, CompactConfig{Enabled: true, KeepRecent: 1, MaxErrors: 2})
+	compacted, _, err := compactor.CompactWithSummary(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("CompactWithSummary() error = %v", err)
+	}
+	text := compacted[1].GetText()
+	want := "## Errors encountered (do not retry unchanged)\n" +
+		"- bash: make release\n  make: *** No rule to make target 'release'.\n  Stop.\n" +
+		"- bash: git push --tags\n  fatal: could not read Username"
+	if !strings.Contains(text, want) {
+		t.Fatalf("summary text missing the errors section:\n%s", text)
+	}
+
+	compacted = append(compacted, failedTurn("t3", "git push --tags", "fatal: could not read Username")...)
+	compacted = append(compacted, failedTurn("t4", "goreleaser", "token missing")...)
+	compacted = append(compacted, llm.NewTextMessage(llm.RoleAssistant, "still stuck"))
+	compacted, _, err = compactor.CompactWithSummary(context.Background(), compacted)
+	if err != nil {
+		t.Fatalf("second CompactWithSummary() error = %v", err)
+	}
+	summary, _ := SummaryFromMessage(compacted[1])
+	want2 := []ToolFailure{
+		{Tool: "bash", Input: "git push --tags", Error: "fatal: could not read Username", Count: 2},
+		{Tool: "bash", Input: "goreleaser", Error: "token missing", Count: 1},
+	}
+	if len(summary.Errors) != len(want2) || summary.Errors[0] != want2[0] || summary.Errors[1] != want2[1] {
+		t.Fatalf("errors = %+v, want %+v (oldest dropped, repeats counted, model rewording ignored)", summary.Errors, want2)
+	}
+	if !strings.Contains(compacted[1].GetText(), "- bash: git push --tags (×2)\n") {
+		t.Fatalf("expected the repeat count in the summary text:\n%s", compacted[1].GetText())
+	}
+}
@@ -0,0 +1,220 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Built-in system prompt section names. A ContextSection with one of these
+// names replaces the built-in section.
+const (
+	SectionSystem           = "system"
+	SectionSoul             = "soul"
+	SectionRepoInstructions = "repository_instructions"
+)
+
+// Built-in section priorities. Sections render in descending priority, so
+// custom sections with the default priority (0) follow the built-ins.
+const (
+	PrioritySystem           = 300
+	PrioritySoul             = 200
+	PriorityRepoInstructions = 100
+)
+
+// ContextRefreshFunc produces a section's content for the coming iteration.
+type ContextRefreshFunc func(ctx context.Context, snapshot LoopInputSnapshot) (string, error)
+
+// ContextSection is one named part of the system prompt.
+type ContextSection struct {
+	// Name identifies the section; later sections replace earlier ones
+	// with the same name.
+	Name string
+
+	// Title, when set, is rendered as a "## Title" heading above the content.
+	Title string
+
+	// Priority orders sections (higher first) and decides which sections
+	// are cut first (lowest first) when the prompt exceeds its byte budget.
+	Priority int
+
+	// MaxBytes truncates the section's content. Zero means no limit.
+	MaxBytes int
+
+	// Content is the static section content.
+	Content string
+
+	// Refresh, when set, is called before every provider call and its
+	// result replaces Content. On error the previous content is kept.
+	Refresh ContextRefreshFunc
+}
+
+// ContextBuilder assembles the system prompt from sections.
+type ContextBuilder struct {
+	// MaxBytes is the total system prompt budget. Zero means no limit.
+	MaxBytes int
+
+	sections []ContextSection
+}
+
+// NewContextBuilder returns an empty builder with a total byte budget.
+func NewContextBuilder(maxBytes int) *ContextBuilder {
+	return &ContextBuilder{MaxBytes: maxBytes}
+}
+
+// Set adds section, replacing any section with the same name in place.
+func (b *ContextBuilder) Set(section ContextSection) {
+	for i, s := range b.sections {
+		if s.Name == section.Name {
+			b.sections[i] = section
+			return
+		}
+	}
+	b.sections = append(b.sections, section)
+}
+
+// Remove deletes the named section.
+func (b *ContextBuilder) Remove(name string) {
+	for i, s := range b.sections {
+		if s.Name == name {
+			b.sections = append(b.sections[:i], b.sections[i+1:]...)
+			return
+		}
+	}
+}
+
+// Sections returns the sections in render order.
+func (b *ContextBuilder) Sections() []ContextSection {
+	out := append([]ContextSection(nil), b.sections...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Priority > out[j].Priority })
+	return out
+}
+
+// Dynamic reports whether any section refreshes per iteration.
+func (b *ContextBuilder) Dynamic() bool {
+	for _, s := range b.sections {
+		if s.Refresh != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Build refreshes dynamic sections and renders the prompt. Sections are
+// joined by blank lines; empty sections are skipped. When the result
+// exceeds MaxBytes, the lowest-priority sections are truncated or dropped.
+func (b *ContextBuilder) Build(ctx context.Context, snapshot LoopInputSnapshot) string {
+	prompt, _ := b.build(ctx, snapshot)
+	return prompt
+}
+
+// build is Build that also returns the length of the prompt's stable
+// prefix: the sections rendered before the first dynamic one.
+func (b *ContextBuilder) build(ctx context.Context, snapshot LoopInputSnapshot) (string, int) {
+	for i, s := range b.sections {
+		if s.Refresh == nil {
+			continue
+		}
+		content, err := s.Refresh(ctx, snapshot)
+		if err != nil {
+			log.Printf("[orchestrator] WARNING: context section %s refresh failed, keeping previous content: %v", s.Name, err)
+			continue
+		}
+		b.sections[i].Content = content
+	}
+
+	var names, parts []string
+	stableParts := -1
+	for _, s := range b.Sections() {
+		rendered := renderSection(s)
+		if rendered == "" {
+			continue
+		}
+		if s.Refresh != nil && stableParts < 0 {
+			stableParts = len(parts)
+		}
+		names = append(names, s.Name)
+		parts = append(parts, rendered)
+	}
+	if b.MaxBytes > 0 {
+		parts = fitSections(names, parts, b.MaxBytes)
+	}
+	if stableParts < 0 || stableParts > len(parts) {
+		stableParts = len(parts)
+	}
+	return strings.Join(parts, "\n\n"), len(strings.Join(parts[:stableParts], "\n\n"))
+}
+
+func renderSection(s ContextSection) string {
+	content := strings.TrimSpace(s.Content)
+	if content == "" {
+		return ""
+	}
+	if s.MaxBytes > 0 {
+		content = truncateSection(content, s.MaxBytes)
+	}
+	if s.Title != "" {
+		return "## " + s.Title + "\n\n" + content
+	}
+	return content
+}
+
+// fitSections cuts parts from the end (lowest priority) until the joined
+// result fits maxBytes.
+func fitSections(names, parts []string, maxBytes int) []string {
+	total := len(strings.Join(parts, "\n\n"))
+	for i := len(parts) - 1; i >= 0 && total > maxBytes; i-- {
+		over := total - maxBytes
+		keep := len(parts[i]) - over - len(truncatedMarker)
+		if keep <= 0 {
+			log.Printf("[orchestrator] system prompt over budget: dropping section %s", names[i])
+			total -= len(parts[i])
+			if i > 0 {
+				total -= 2
+			}
+			parts = parts[:i]
+			continue
+		}
+		log.Printf("[orchestrator] system prompt over budget: truncating section %s", names[i])
+		parts[i] = truncateSection(parts[i], keep+len(truncatedMarker))
+		total = len(strings.Join(parts, "\n\n"))
+	}
+	return parts
+}
+
+const truncatedMarker = "\n[truncated]"
+
+// truncateSection cuts s to at most maxBytes, including the marker, on a
+// UTF-8 boundary.
+func truncateSection(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes - len(truncatedMarker)
+	if cut <= 0 {
+		return ""
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncatedMarker
+}
+
+// newSystemPromptBuilder returns a builder holding the built-in sections
+// followed by the request's sections.
+func newSystemPromptBuilder(req OrchestratorRequest, soulContent, repoInstructions string) *ContextBuilder {
+	b := NewContextBuilder(req.MaxSystemPromptBytes)
+	b.Set(ContextSection{Name: SectionSystem, Priority: PrioritySystem, Content: req.SystemPrompt})
+	b.Set(ContextSection{Name: SectionSoul, Priority: PrioritySoul, Content: soulSection(req.Locale, soulContent)})
+	b.Set(ContextSection{Name: SectionRepoInstructions, Priority: PriorityRepoInstructions, Content: repoInstructionsSection(req.Locale, repoInstructions)})
+	for _, s := range req.ContextSections {
+		if strings.TrimSpace(s.Name) == "" {
+			s.Name = fmt.Sprintf("section-%d", len(b.sections))
+		}
+		b.Set(s)
+	}
+	return b
+}
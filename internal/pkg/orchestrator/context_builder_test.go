@@ -0,0 +1,177 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestContextBuilderOrdersByPriority(t *testing.T) {
+	b := NewContextBuilder(0)
+	b.Set(ContextSection{Name: "low", Content: "low"})
+	b.Set(ContextSection{Name: "high", Priority: 10, Content: "high"})
+	b.Set(ContextSection{Name: "tie", Content: "tie"})
+	b.Set(ContextSection{Name: "empty", Priority: 5, Content: "  "})
+	b.Set(ContextSection{Name: "titled", Priority: 5, Title: "Ticket", Content: "ABC-1"})
+
+	got := b.Build(context.Background(), LoopInputSnapshot{})
+	want := "high\n\n## Ticket\n\nABC-1\n\nlow\n\ntie"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestContextBuilderSetReplacesAndRemove(t *testing.T) {
+	b := NewContextBuilder(0)
+	b.Set(ContextSection{Name: "a", Content: "first"})
+	b.Set(ContextSection{Name: "b", Content: "other"})
+	b.Set(ContextSection{Name: "a", Content: "second"})
+	if got := b.Build(context.Background(), LoopInputSnapshot{}); got != "second\n\nother" {
+		t.Fatalf("unexpected prompt after replace: %q", got)
+	}
+	b.Remove("a")
+	if got := b.Build(context.Background(), LoopInputSnapshot{}); got != "other" {
+		t.Fatalf("unexpected prompt after remove: %q", got)
+	}
+}
+
+func TestContextBuilderSectionBudget(t *testing.T) {
+	b := NewContextBuilder(0)
+	b.Set(ContextSection{Name: "ci", MaxBytes: 20, Content: strings.Repeat("é", 20)})
+
+	got := b.Build(context.Background(), LoopInputSnapshot{})
+	if len(got) > 20 {
+		t.Fatalf("expected at most 20 bytes, got %d: %q", len(got), got)
+	}
+	if !strings.HasSuffix(got, truncatedMarker) {
+		t.Fatalf("expected truncation marker, got %q", got)
+	}
+	if !strings.HasPrefix(got, "éééé") || strings.ContainsRune(got, '�') {
+		t.Fatalf("expected truncation on a rune boundary, got %q", got)
+	}
+}
+
+func TestContextBuilderTotalBudgetCutsLowestPriority(t *testing.T) {
+	b := NewContextBuilder(60)
+	b.Set(ContextSection{Name: "keep", Priority: 10, Content: strings.Repeat("k", 30)})
+	b.Set(ContextSection{Name: "trim", Priority: 5, Content: strings.Repeat("t", 40)})
+	b.Set(ContextSection{Name: "drop", Priority: 1, Content: "DROP"})
+
+	got := b.Build(context.Background(), LoopInputSnapshot{})
+	if len(got) > 60 {
+		t.Fatalf("expected at most 60 bytes, got %d: %q", len(got), got)
+	}
+	if strings.Contains(got, "DROP") {
+		t.Fatalf("expected lowest-priority section to be dropped, got %q", got)
+	}
+	if !strings.HasPrefix(got, strings.Repeat("k", 30)+"\n\nt") {
+		t.Fatalf("expected high-priority section intact, got %q", got)
+	}
+	if !strings.HasSuffix(got, truncatedMarker) {
+		t.Fatalf("expected middle section truncated, got %q", got)
+	}
+}
+
+func TestSystemPromptBuilderReplacesBuiltinSection(t *testing.T) {
+	req := OrchestratorRequest{
+		SystemPrompt: "base",
+		ContextSections: []ContextSection{
+			{Name: SectionRepoInstructions, Priority: PriorityRepoInstructions, Content: "custom rules"},
+			{Name: "ticket", Priority: 250, Title: "Ticket", Content: "ABC-1"},
+		},
+	}
+	got := newSystemPromptBuilder(req, "", "repo rules").Build(context.Background(), LoopInputSnapshot{})
+	if strings.Contains(got, "repo rules") || !strings.Contains(got, "custom rules") {
+		t.Fatalf("expected repository instructions to be replaced, got %q", got)
+	}
+	if want := "base\n\n## Ticket\n\nABC-1\n\ncustom rules"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSystemPromptBuilderLocalizesBuiltinSections(t *testing.T) {
+	req := OrchestratorRequest{SystemPrompt: "base", Locale: "es"}
+	got := newSystemPromptBuilder(req, "be kind", "repo rules").Build(context.Background(), LoopInputSnapshot{})
+	for _, want := range []string{"## Alma", "## Instrucciones del repositorio"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in prompt, got %q", want, got)
+		}
+	}
+	if strings.Contains(got, "## Soul") {
+		t.Fatalf("expected no English headers, got %q", got)
+	}
+}
+
+func TestRunRefreshesDynamicContextSections(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "count", map[string]any{"n": 1}),
+		toolUseResponse("tool-2", "count", map[string]any{"n": 1}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(&countTool{})
+
+	loop := NewAgentLoop(provider, registry)
+	_, err := loop.Run(context.Background(), OrchestratorRequest{
+		SystemPrompt:     "base",
+		RepoInstructions: "rules",
+		InitialMessages:  []llm.Message{llm.NewTextMessage(llm.RoleUser, "go")},
+		WorkDir:          t.TempDir(),
+		ContextSections: []ContextSection{{
+			Name:    "ci",
+			Title:   "CI Status",
+			Content: "unknown",
+			Refresh: func(_ context.Context, snapshot LoopInputSnapshot) (string, error) {
+				if snapshot.Iteration == 2 {
+					return "", errors.New("ci unavailable")
+				}
+				return fmt.Sprintf("iteration %d, %d tool calls", snapshot.Iteration, snapshot.ToolCallCount), nil
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(provider.requests) != 3 {
+		t.Fatalf("expected 3 provider calls, got %d", len(provider.requests))
+	}
+
+	want := []string{
+		"iteration 1, 0 tool calls",
+		"iteration 1, 0 tool calls", // refresh failed: previous content kept
+		"iteration 3, 2 tool calls",
+	}
+	for i, w := range want {
+		system := provider.requests[i].System
+		if !strings.HasPrefix(system, "base\n\n## Repository Instructions") {
+			t.Errorf("call %d: expected built-in sections first, got %q", i, system)
+		}
+		if !strings.HasSuffix(system, "## CI Status\n\n"+w) {
+			t.Errorf("call %d: expected CI section %q, got %q", i, w, system)
+		}
+	}
+}
+
+func TestContextBuilderStablePrefix(t *testing.T) {
+	b := NewContextBuilder(0)
+	b.Set(ContextSection{Name: "base", Priority: 10, Content: "base"})
+	b.Set(ContextSection{Name: "ci", Priority: 5, Title: "CI", Content: "green",
+		Refresh: func(context.Context, LoopInputSnapshot) (string, error) { return "red", nil }})
+	b.Set(ContextSection{Name: "notes", Content: "notes"})
+
+	got, stable := b.build(context.Background(), LoopInputSnapshot{})
+	if got[:stable] != "base" {
+		t.Fatalf("expected stable prefix %q, got %q", "base", got[:stable])
+	}
+
+	b.Remove("ci")
+	got, stable = b.build(context.Background(), LoopInputSnapshot{})
+	if stable != len(got) {
+		t.Fatalf("expected a prompt without dynamic sections to be stable, got %d of %d bytes", stable, len(got))
+	}
+}
@@ -0,0 +1,309 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+const (
+	// defaultContextMargin is the fraction of the context window kept free
+	// when ContextMargin is not set.
+	defaultContextMargin = 0.1
+
+	// charsPerToken is the rough character/token ratio used for estimation.
+	charsPerToken = 4
+
+	// messageOverheadTokens approximates per-message framing tokens.
+	messageOverheadTokens = 4
+
+	// minPreflightMessages is the smallest history pre-flight truncation will produce.
+	minPreflightMessages = 3
+)
+
+// ContextPressure describes a pre-flight context window check that exceeded
+// the configured threshold and the relief that was applied.
+type ContextPressure struct {
+	Iteration        int
+	MaxContextTokens int
+	ThresholdTokens  int
+	EstimatedTokens  int // Estimate before relief
+	ResultTokens     int // Estimate after relief
+	MessagesBefore   int
+	MessagesAfter    int
+	Compacted        bool
+	Truncated        bool
+}
+
+// EstimateRequestTokens returns a rough token estimate for req, covering the
+// system prompt, messages, and tool definitions. It is intentionally
+// provider-agnostic and errs on the side of overestimating.
+func EstimateRequestTokens(req llm.AgentRequest) int {
+	chars := len(req.System)
+	tokens := 0
+	for _, msg := range req.Messages {
+		tokens += messageOverheadTokens
+		chars += len(msg.ReasoningContent)
+		for _, block := range msg.Content {
+			chars += len(block.Text) + len(block.Name) + len(block.Content) + len(block.Thinking) + len(block.Data)
+			if len(block.Input) > 0 {
+				if data, err := json.Marshal(block.Input); err == nil {
+					chars += len(data)
+				}
+			}
+		}
+	}
+	for _, def := range req.Tools {
+		chars += len(def.Name) + len(def.Description)
+		if data, err := json.Marshal(def.InputSchema); err == nil {
+			chars += len(data)
+		}
+	}
+	return tokens + (chars+charsPerToken-1)/charsPerToken
+}
+
+// contextThreshold returns the token count above which pre-flight relief runs,
+// or 0 when no context window is configured.
+func contextThreshold(req OrchestratorRequest) int {
+	if req.MaxContextTokens <= 0 {
+		return 0
+	}
+	margin := req.ContextMargin
+	if margin <= 0 || margin >= 1 {
+		margin = defaultContextMargin
+	}
+	return int(float64(req.MaxContextTokens) * (1 - margin))
+}
+
+// relieveContextPressure estimates agentReq against the context window and,
+// when it is over the threshold, compacts and then truncates the history
+// until it fits (or cannot shrink further).
+func (l *AgentLoop) relieveContextPressure(
+	ctx context.Context,
+	req OrchestratorRequest,
+	state *State,
+	compactor *Compactor,
+	maxMessages int,
+	agentReq llm.AgentRequest,
+) (llm.AgentRequest, error) {
+	threshold := contextThreshold(req)
+	if threshold <= 0 || req.DisableDefaultContextRules {
+		return agentReq, nil
+	}
+
+	estimate := EstimateRequestTokens(agentReq)
+	if estimate <= threshold {
+		return agentReq, nil
+	}
+
+	pressure := ContextPressure{
+		Iteration:        state.Iterations,
+		MaxContextTokens: req.MaxContextTokens,
+		ThresholdTokens:  threshold,
+		EstimatedTokens:  estimate,
+		MessagesBefore:   len(agentReq.Messages),
+	}
+	log.Printf("[orchestrator] context pressure: estimated=%d threshold=%d max=%d messages=%d",
+		estimate, threshold, req.MaxContextTokens, len(agentReq.Messages))
+
+	if compactor != nil {
+		history := state.history(state.Messages)
+		compacted, err := compactor.Compact(ctx, history)
+		if err != nil {
+			log.Printf("[orchestrator] WARNING: pre-flight compaction failed: %v", err)
+		} else if len(compacted) < len(history) {
+			state.replaceMessages(compacted)
+			pressure.Compacted = true
+			messages, err := l.buildContextMessages(ctx, req, state, compactor, maxMessages)
+			if err != nil {
+				return agentReq, err
+			}
+			agentReq.Messages = messages
+			estimate = EstimateRequestTokens(agentReq)
+		}
+	}
+
+	budget := len(agentReq.Messages)
+	for estimate > threshold && budget > minPreflightMessages {
+		budget = budget * 3 / 4
+		if budget < minPreflightMessages {
+			budget = minPreflightMessages
+		}
+		messages, err := l.buildContextMessages(ctx, req, state, compactor, budget)
+		if err != nil {
+			return agentReq, err
+		}
+		agentReq.Messages = messages
+		estimate = EstimateRequestTokens(agentReq)
+		pressure.Truncated = true
+	}
+
+	pressure.ResultTokens = estimate
+	pressure.MessagesAfter = len(agentReq.Messages)
+	log.Printf("[orchestrator] context pressure relieved: estimated=%d messages=%d compacted=%v truncated=%v",
+		estimate, len(agentReq.Messages), pressure.Compacted, pressure.Truncated)
+	if estimate > threshold {
+		log.Printf("[orchestrator] WARNING: request still exceeds context threshold after relief")
+	}
+
+	if req.OnContextPressure != nil {
+		req.OnContextPressure(pressure)
+	}
+	return agentReq, nil
+}
+
+const (
+	// defaultMaxOverflowRetries bounds shrink-and-retry attempts after a
+	// provider rejects a request as exceeding the context window.
+	defaultMaxOverflowRetries = 3
+
+	// overflowKeepRecent is the number of trailing messages whose tool
+	// results are never elided.
+	overflowKeepRecent = 4
+
+	elidedToolResultText = "[tool result elided to fit the context window]"
+)
+
+// maxOverflowRetries resolves the configured retry bound.
+// Zero selects the default; negative disables overflow retries.
+func maxOverflowRetries(req OrchestratorRequest) int {
+	if req.MaxOverflowRetries < 0 {
+		return 0
+	}
+	if req.MaxOverflowRetries == 0 {
+		return defaultMaxOverflowRetries
+	}
+	return req.MaxOverflowRetries
+}
+
+// overflowShrinker applies progressively stronger reductions to a request
+// after a context overflow: elide old tool results, compact, then truncate.
+// Only the messages sent are shrunk; the stored history is never changed.
+// Once old tool results had to be elided, they are elided in every later
+// request of the run.
+type overflowShrinker struct {
+	loop      *AgentLoop
+	compactor *Compactor
+
+	// elide is set once old tool results had to be elided.
+	elide bool
+
+	// base is the compacted history the current call's window is built
+	// from instead of state.Messages, or nil.
+	base      []AgentMessage
+	compacted bool
+}
+
+// prepare starts a provider call: it drops the previous call's compaction
+// and returns messages with old tool results elided if elision is on.
+func (s *overflowShrinker) prepare(messages []llm.Message) []llm.Message {
+	s.base, s.compacted = nil, false
+	if s.elide {
+		messages, _ = elideOldToolResults(messages, overflowKeepRecent)
+	}
+	return messages
+}
+
+// shrink reduces agentReq.Messages (or maxMessages) once and reports
+// whether anything changed.
+func (s *overflowShrinker) shrink(
+	ctx context.Context,
+	req OrchestratorRequest,
+	state *State,
+	agentReq *llm.AgentRequest,
+	maxMessages *int,
+) (bool, error) {
+	if !s.elide {
+		if messages, n := elideOldToolResults(agentReq.Messages, overflowKeepRecent); n > 0 {
+			log.Printf("[orchestrator] context overflow: elided %d old tool result(s)", n)
+			s.elide = true
+			agentReq.Messages = messages
+			return true, nil
+		}
+	}
+
+	if s.compactor != nil && !s.compacted {
+		s.compacted = true
+		history := state.history(state.Messages)
+		compacted, err := s.compactor.Compact(ctx, history)
+		if err != nil {
+			log.Printf("[orchestrator] WARNING: overflow compaction failed: %v", err)
+		} else if len(compacted) < len(history) {
+			log.Printf("[orchestrator] context overflow: compacted %d -> %d messages for this request", len(history), len(compacted))
+			s.base = compacted
+			return true, s.rebuild(ctx, req, state, agentReq, *maxMessages)
+		}
+	}
+
+	base := s.base
+	if base == nil {
+		base = state.Messages
+	}
+	current := min(*maxMessages, len(base))
+	if current <= minPreflightMessages {
+		return false, nil
+	}
+	next := max(current/2, minPreflightMessages)
+	log.Printf("[orchestrator] context overflow: lowering max messages %d -> %d", *maxMessages, next)
+	*maxMessages = next
+	return true, s.rebuild(ctx, req, state, agentReq, next)
+}
+
+// rebuild builds agentReq.Messages from the current base. The pipeline runs
+// without the compactor, so that its compaction cannot replace the stored
+// history with a compaction of the base.
+func (s *overflowShrinker) rebuild(
+	ctx context.Context,
+	req OrchestratorRequest,
+	state *State,
+	agentReq *llm.AgentRequest,
+	maxMessages int,
+) error {
+	base := s.base
+	if base == nil {
+		base = state.Messages
+	}
+	messages, err := s.loop.buildContextMessagesFrom(ctx, req, state, nil, maxMessages, base)
+	if err != nil {
+		return err
+	}
+	if s.elide {
+		messages, _ = elideOldToolResults(messages, overflowKeepRecent)
+	}
+	agentReq.Messages = messages
+	return nil
+}
+
+// elideOldToolResults returns messages with the tool_result content outside
+// the most recent keepRecent messages replaced by a short placeholder,
+// keeping tool pairs intact, and the number of results elided. messages is
+// not modified.
+func elideOldToolResults(messages []llm.Message, keepRecent int) ([]llm.Message, int) {
+	var out []llm.Message
+	elided := 0
+	for i := 0; i < len(messages)-keepRecent; i++ {
+		var content []llm.ContentBlock
+		for j, block := range messages[i].Content {
+			if block.Type != llm.ContentTypeToolResult || block.Content == elidedToolResultText {
+				continue
+			}
+			if content == nil {
+				content = append([]llm.ContentBlock(nil), messages[i].Content...)
+			}
+			content[j].Content = elidedToolResultText
+			elided++
+		}
+		if content != nil {
+			if out == nil {
+				out = append([]llm.Message(nil), messages...)
+			}
+			out[i].Content = content
+		}
+	}
+	if out == nil {
+		return messages, 0
+	}
+	return out, elided
+}
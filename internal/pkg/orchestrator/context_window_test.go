@@ -0,0 +1,122 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+func TestEstimateRequestTokensCountsAllParts(t *testing.T) {
+	base := llm.AgentRequest{
+		Messages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "hello")},
+	}
+	baseEstimate := EstimateRequestTokens(base)
+	if baseEstimate <= 0 {
+		t.Fatalf("expected positive estimate, got %d", baseEstimate)
+	}
+
+	withSystem := base
+	withSystem.System = strings.Repeat("s", 400)
+	if got := EstimateRequestTokens(withSystem); got != baseEstimate+100 {
+		t.Errorf("expected system prompt to add 100 tokens, got %d -> %d", baseEstimate, got)
+	}
+
+	withTools := base
+	withTools.Tools = []llm.ToolDefinition{{
+		Name:        "read_file",
+		Description: strings.Repeat("d", 400),
+		InputSchema: map[string]any{"type": "object"},
+	}}
+	if got := EstimateRequestTokens(withTools); got <= baseEstimate+100 {
+		t.Errorf("expected tool definitions to be counted, got %d", got)
+	}
+}
+
+func TestContextThreshold(t *testing.T) {
+	tests := []struct {
+		name string
+		req  OrchestratorRequest
+		want int
+	}{
+		{name: "disabled", req: OrchestratorRequest{}, want: 0},
+		{name: "default margin", req: OrchestratorRequest{MaxContextTokens: 1000}, want: 900},
+		{name: "custom margin", req: OrchestratorRequest{MaxContextTokens: 1000, ContextMargin: 0.25}, want: 750},
+		{name: "invalid margin", req: OrchestratorRequest{MaxContextTokens: 1000, ContextMargin: 1.5}, want: 900},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contextThreshold(tt.req); got != tt.want {
+				t.Errorf("contextThreshold() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunPreflightTruncatesWhenOverContextWindow(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+
+	history := make([]llm.Message, 0, 20)
+	history = append(history, llm.NewTextMessage(llm.RoleUser, "task"))
+	for i := 0; i < 19; i++ {
+		role := llm.RoleAssistant
+		if i%2 == 1 {
+			role = llm.RoleUser
+		}
+		history = append(history, llm.NewTextMessage(role, strings.Repeat("x", 400)))
+	}
+
+	var pressures []ContextPressure
+	_, err := NewAgentLoop(provider, nil).Run(context.Background(), OrchestratorRequest{
+		InitialMessages:  history,
+		MaxMessages:      50,
+		MaxContextTokens: 1000,
+		OnContextPressure: func(p ContextPressure) {
+			pressures = append(pressures, p)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pressures) != 1 {
+		t.Fatalf("expected one context pressure event, got %d", len(pressures))
+	}
+	p := pressures[0]
+	if !p.Truncated || p.Compacted {
+		t.Errorf("expected truncation only, got %+v", p)
+	}
+	if p.ResultTokens > p.ThresholdTokens || p.EstimatedTokens <= p.ThresholdTokens {
+		t.Errorf("unexpected estimates: %+v", p)
+	}
+
+	sent := provider.requests[0]
+	if len(sent.Messages) != p.MessagesAfter || len(sent.Messages) >= len(history) {
+		t.Errorf("expected shrunk request, got %d messages (history %d)", len(sent.Messages), len(history))
+	}
+	if sent.Messages[0].GetText() != "task" {
+		t.Errorf("expected first message to be preserved")
+	}
+}
+
+func TestRunPreflightSkippedUnderThreshold(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+
+	called := false
+	_, err := NewAgentLoop(provider, nil).Run(context.Background(), OrchestratorRequest{
+		InitialMessages:   []llm.Message{llm.NewTextMessage(llm.RoleUser, "short task")},
+		MaxContextTokens:  100000,
+		OnContextPressure: func(ContextPressure) { called = true },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if called {
+		t.Fatalf("did not expect context pressure callback")
+	}
+}
@@ -0,0 +1,45 @@
+package orchestrator
+
+import (
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+// DeterministicSeed is the sampling seed used in deterministic mode when
+// OrchestratorRequest.Seed is not set.
+const DeterministicSeed int64 = 42
+
+// applySampling sets the temperature, seed, and tool choice of a provider
+// call from the request.
+func applySampling(req OrchestratorRequest, agentReq *llm.AgentRequest) {
+	agentReq.Temperature = req.Temperature
+	agentReq.Seed = req.Seed
+	if !req.Deterministic {
+		return
+	}
+
+	if agentReq.Temperature == nil {
+		zero := 0.0
+		agentReq.Temperature = &zero
+	}
+	if agentReq.Seed == nil {
+		seed := DeterministicSeed
+		agentReq.Seed = &seed
+	}
+	if len(agentReq.Tools) > 0 {
+		agentReq.ToolChoice = &llm.ToolChoice{Type: "auto", DisableParallelToolUse: true}
+	}
+}
+
+// toolUseIDGenerator returns the tool_use ID generator for one run: the
+// request's, then the loop's, then sequential IDs in deterministic mode.
+func toolUseIDGenerator(req OrchestratorRequest, ids IDGenerator) func() string {
+	switch {
+	case req.NewToolUseID != nil:
+		return req.NewToolUseID
+	case ids != nil:
+		return ids.NewID
+	case req.Deterministic:
+		return (&SequentialIDs{}).NewID
+	}
+	return generateToolUseID
+}
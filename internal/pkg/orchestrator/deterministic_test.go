@@ -0,0 +1,136 @@
+package orchestrator
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/skills"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestRunDeterministicMode(t *testing.T) {
+	run := func() ([]llm.AgentRequest, []string) {
+		provider := &scriptedProvider{responses: []llm.AgentResponse{
+			toolUseResponse("", "count", map[string]any{"n": 1}),
+			toolUseResponse("", "count", map[string]any{"n": 2}),
+			{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+		}}
+		registry := tools.NewRegistry()
+		registry.MustRegister(&countTool{})
+
+		result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+			InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+			MaxMessages:     50,
+			Deterministic:   true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var ids []string
+		for _, msg := range result.Messages {
+			for _, block := range msg.GetToolUses() {
+				ids = append(ids, block.ID)
+			}
+		}
+		return provider.requests, ids
+	}
+
+	requests, ids := run()
+	first := requests[0]
+	if first.Temperature == nil || *first.Temperature != 0 {
+		t.Fatalf("expected temperature 0, got %v", first.Temperature)
+	}
+	if first.Seed == nil || *first.Seed != DeterministicSeed {
+		t.Fatalf("expected seed %d, got %v", DeterministicSeed, first.Seed)
+	}
+	if first.ToolChoice == nil || !first.ToolChoice.DisableParallelToolUse {
+		t.Fatalf("expected parallel tool use disabled, got %#v", first.ToolChoice)
+	}
+
+	if strings.Join(ids, ",") != "tool_seq_1,tool_seq_2" {
+		t.Fatalf("expected sequential tool IDs, got %v", ids)
+	}
+	if _, again := run(); strings.Join(again, ",") != strings.Join(ids, ",") {
+		t.Fatalf("tool IDs differ between runs: %v vs %v", ids, again)
+	}
+}
+
+func TestApplySamplingExplicitValuesWin(t *testing.T) {
+	temperature := 0.7
+	seed := int64(7)
+	agentReq := llm.AgentRequest{}
+	applySampling(OrchestratorRequest{Temperature: &temperature, Seed: &seed, Deterministic: true}, &agentReq)
+
+	if *agentReq.Temperature != 0.7 || *agentReq.Seed != 7 {
+		t.Fatalf("expected explicit sampling values, got temperature=%v seed=%v", *agentReq.Temperature, *agentReq.Seed)
+	}
+	if agentReq.ToolChoice != nil {
+		t.Fatalf("expected no tool choice without tools, got %#v", agentReq.ToolChoice)
+	}
+}
+
+func TestRunUsesInjectedClockAndIDs(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("", "count", map[string]any{"n": 1}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(&countTool{})
+
+	loop := NewAgentLoop(provider, registry)
+	loop.Clock = NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Second)
+	loop.IDs = &SequentialIDs{Prefix: "call_"}
+	result, err := loop.Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+		Profile:         true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if uses := result.Messages[1].GetToolUses(); len(uses) != 1 || uses[0].ID != "call_1" {
+		t.Fatalf("expected the injected tool ID, got %#v", uses)
+	}
+	// Every clock read advances it by a second, so each measured call
+	// takes exactly one second.
+	if stats := result.ToolStats["count"]; stats.TotalDuration != time.Second {
+		t.Fatalf("expected a 1s tool call, got %#v", stats)
+	}
+	it1 := result.Profile.Iterations[0]
+	if it1.ProviderLatency != time.Second || it1.ToolLatency != time.Second {
+		t.Fatalf("expected 1s provider and tool latency, got %#v", it1)
+	}
+}
+
+func TestRunRendersSkillDatesFromInjectedClock(t *testing.T) {
+	root := t.TempDir()
+	skillsDir := filepath.Join(root, "skills")
+	mustMkdirAll(t, filepath.Join(skillsDir, "report"))
+	mustWriteText(t, filepath.Join(skillsDir, "report", "SKILL.md"), `---
+name: report
+description: daily report
+---
+Report for ${DATE}`)
+	t.Setenv(skills.SkillDirsEnv, skillsDir)
+
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	loop := NewAgentLoop(provider, tools.NewRegistry())
+	loop.Clock = NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Second)
+	_, err := loop.Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "/report")},
+		WorkDir:         root,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := provider.requests[0].Messages[0].GetText(); !strings.Contains(got, "Report for 2025-01-01") {
+		t.Fatalf("expected the injected clock's date, got: %q", got)
+	}
+}
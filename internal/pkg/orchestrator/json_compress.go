@@ -0,0 +1,240 @@
+package orchestrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+const (
+	defaultJSONCompressMinBytes       = 16 << 10
+	defaultJSONCompressMaxDepth       = 4
+	defaultJSONCompressMaxArrayItems  = 3
+	defaultJSONCompressMaxKeys        = 25
+	defaultJSONCompressMaxStringBytes = 200
+)
+
+// JSONCompressionConfig configures compression of large JSON tool outputs.
+// The model gets a shortened copy of the payload, with arrays cut to their
+// first items, objects to their first keys, long strings shortened, and
+// deep levels summarized; the full payload is attached to the run as an
+// artifact the model can query. Requires ToolContext.Artifacts; without
+// it outputs are left as they are.
+type JSONCompressionConfig struct {
+	Enabled bool
+
+	// MinBytes is the output size from which JSON is compressed
+	// (default 16 KiB).
+	MinBytes int
+
+	// MaxDepth is how many levels of nesting are kept; deeper objects and
+	// arrays are replaced by their size (default 4).
+	MaxDepth int
+
+	// MaxArrayItems is how many items of an array are kept (default 3).
+	MaxArrayItems int
+
+	// MaxKeys is how many keys of an object are kept (default 25).
+	MaxKeys int
+
+	// MaxStringBytes is how long a string value may be (default 200).
+	MaxStringBytes int
+
+	// Tools limits compression to the outputs of these tools. Empty
+	// compresses the output of every tool.
+	Tools []string
+}
+
+func (c JSONCompressionConfig) withDefaults() JSONCompressionConfig {
+	if c.MinBytes <= 0 {
+		c.MinBytes = defaultJSONCompressMinBytes
+	}
+	if c.MaxDepth <= 0 {
+		c.MaxDepth = defaultJSONCompressMaxDepth
+	}
+	if c.MaxArrayItems <= 0 {
+		c.MaxArrayItems = defaultJSONCompressMaxArrayItems
+	}
+	if c.MaxKeys <= 0 {
+		c.MaxKeys = defaultJSONCompressMaxKeys
+	}
+	if c.MaxStringBytes <= 0 {
+		c.MaxStringBytes = defaultJSONCompressMaxStringBytes
+	}
+	return c
+}
+
+// jsonQueryTool is the built-in tool that queries compressed payloads.
+const jsonQueryTool = "json_query"
+
+// compressJSONResult replaces a large JSON output with its compressed form
+// and a note naming the artifact holding the full payload; queryable adds a
+// hint to query it with json_query. Other results, and the output of
+// json_query itself, are returned unchanged.
+func compressJSONResult(toolCtx *tools.ToolContext, req OrchestratorRequest, name, id string, result tools.ToolResult, queryable bool) tools.ToolResult {
+	cfg := req.JSONCompression
+	if !cfg.Enabled || result.IsError || toolCtx.Artifacts == nil || name == jsonQueryTool {
+		return result
+	}
+	if len(cfg.Tools) > 0 && !slices.Contains(cfg.Tools, name) {
+		return result
+	}
+	cfg = cfg.withDefaults()
+	content := strings.TrimSpace(result.Content)
+	if len(content) < cfg.MinBytes || (content[0] != '{' && content[0] != '[') {
+		return result
+	}
+	root, err := decodeJSONNode([]byte(content))
+	if err != nil {
+		return result
+	}
+	var compressed bytes.Buffer
+	root.write(&compressed, cfg, 0)
+	if compressed.Len() >= len(content) {
+		return result
+	}
+
+	artifact := toolCtx.Artifacts.Add(name+"-"+id+".json", "application/json",
+		fmt.Sprintf("Full output of %s", name), []byte(content))
+	log.Printf("[orchestrator] compressed JSON output of %s: %d -> %d bytes, artifact=%s",
+		name, len(content), compressed.Len(), artifact.ID)
+
+	note := fmt.Sprintf(locale.Text(req.Locale, locale.JSONCompressedNote), name, len(content), artifact.ID)
+	if queryable {
+		note += " " + fmt.Sprintf(locale.Text(req.Locale, locale.JSONCompressedQueryHint), artifact.ID)
+	}
+	result.Content = note + "\n" + compressed.String()
+	if result.Details == nil {
+		result.Details = map[string]any{}
+	}
+	result.Details["artifact_id"] = artifact.ID
+	return result
+}
+
+// jsonNode is a decoded JSON value that keeps the order of object keys.
+type jsonNode struct {
+	// kind is '{' for objects, '[' for arrays, and 0 for scalars.
+	kind   byte
+	keys   []string
+	items  []jsonNode
+	scalar any
+}
+
+// decodeJSONNode decodes data, which must hold exactly one JSON value.
+func decodeJSONNode(data []byte) (jsonNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeJSONValue(dec)
+	if err != nil {
+		return jsonNode{}, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return jsonNode{}, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return node, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return jsonNode{}, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return jsonNode{scalar: tok}, nil
+	}
+	node := jsonNode{kind: byte(delim)}
+	for dec.More() {
+		if node.kind == '{' {
+			key, err := dec.Token()
+			if err != nil {
+				return jsonNode{}, err
+			}
+			node.keys = append(node.keys, key.(string))
+		}
+		item, err := decodeJSONValue(dec)
+		if err != nil {
+			return jsonNode{}, err
+		}
+		node.items = append(node.items, item)
+	}
+	if _, err := dec.Token(); err != nil {
+		return jsonNode{}, err
+	}
+	return node, nil
+}
+
+// write renders n as compact JSON within the limits of cfg. Left-out parts
+// are replaced by strings describing them, so the output stays valid JSON.
+func (n jsonNode) write(buf *bytes.Buffer, cfg JSONCompressionConfig, depth int) {
+	switch n.kind {
+	case '{':
+		if depth >= cfg.MaxDepth && len(n.items) > 0 {
+			writeJSON(buf, fmt.Sprintf("{... %d keys}", len(n.items)))
+			return
+		}
+		buf.WriteByte('{')
+		for i, item := range n.items {
+			if i == cfg.MaxKeys {
+				buf.WriteByte(',')
+				writeJSON(buf, "...")
+				buf.WriteByte(':')
+				more := n.keys[i:min(len(n.keys), i+cfg.MaxKeys)]
+				writeJSON(buf, fmt.Sprintf("%d more keys: %s", len(n.keys)-i, strings.Join(more, ", ")))
+				break
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSON(buf, n.keys[i])
+			buf.WriteByte(':')
+			item.write(buf, cfg, depth+1)
+		}
+		buf.WriteByte('}')
+	case '[':
+		if depth >= cfg.MaxDepth && len(n.items) > 0 {
+			writeJSON(buf, fmt.Sprintf("[... %d items]", len(n.items)))
+			return
+		}
+		buf.WriteByte('[')
+		for i, item := range n.items {
+			if i == cfg.MaxArrayItems {
+				buf.WriteByte(',')
+				writeJSON(buf, fmt.Sprintf("... %d more items (%d total)", len(n.items)-i, len(n.items)))
+				break
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			item.write(buf, cfg, depth+1)
+		}
+		buf.WriteByte(']')
+	default:
+		if s, ok := n.scalar.(string); ok && len(s) > cfg.MaxStringBytes {
+			cut := cfg.MaxStringBytes
+			for cut > 0 && !utf8.RuneStart(s[cut]) {
+				cut--
+			}
+			writeJSON(buf, fmt.Sprintf("%s...(+%d bytes)", s[:cut], len(s)-cut))
+			return
+		}
+		writeJSON(buf, n.scalar)
+	}
+}
+
+// writeJSON writes v without escaping HTML characters, which would
+// only make the output longer.
+func writeJSON(buf *bytes.Buffer, v any) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	buf.Truncate(buf.Len() - 1) // Encode's newline
+}
@@ -0,0 +1,118 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func largeJSONPayload(items int) string {
+	var b strings.Builder
+	b.WriteString(`{"total":` + fmt.Sprint(items) + `,"items":[`)
+	for i := 0; i < items; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"id":%d,"name":"item-%d","owner":{"login":"bot","profile":{"links":{"html":"https://example.com/%d"}}},"body":%q}`,
+			i, i, i, strings.Repeat("x", 300))
+	}
+	b.WriteString(`]}`)
+	return b.String()
+}
+
+func TestCompressJSONResultStoresFullPayloadAsArtifact(t *testing.T) {
+	payload := largeJSONPayload(200)
+	toolCtx := tools.NewToolContext(t.TempDir()).WithArtifacts(tools.NewArtifacts())
+	req := OrchestratorRequest{JSONCompression: JSONCompressionConfig{Enabled: true}}
+
+	result := compressJSONResult(toolCtx, req, "github_api", "tool-1", tools.NewToolResult(payload), true)
+
+	artifacts := toolCtx.Artifacts.List()
+	if len(artifacts) != 1 {
+		t.Fatalf("expected 1 artifact, got %d", len(artifacts))
+	}
+	if got := string(artifacts[0].Content()); got != payload {
+		t.Fatalf("expected the artifact to hold the full payload")
+	}
+	if result.Details["artifact_id"] != artifacts[0].ID {
+		t.Fatalf("expected artifact_id detail %s, got %v", artifacts[0].ID, result.Details)
+	}
+	if len(result.Content) > len(payload)/10 {
+		t.Fatalf("expected a much smaller output, got %d of %d bytes", len(result.Content), len(payload))
+	}
+
+	note, body, ok := strings.Cut(result.Content, "\n")
+	if !ok || !strings.Contains(note, artifacts[0].ID) || !strings.Contains(note, "github_api") || !strings.Contains(note, "json_query") {
+		t.Fatalf("unexpected note: %q", note)
+	}
+	if !json.Valid([]byte(body)) {
+		t.Fatalf("expected the compressed output to be valid JSON: %s", body)
+	}
+	for _, want := range []string{
+		`{"total":200,"items":[{"id":0,`,
+		`"... 197 more items (200 total)"`,
+		`"owner":{"login":"bot","profile":"{... 1 keys}"}`,
+		`...(+100 bytes)"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected compressed output to contain %s, got: %s", want, body)
+		}
+	}
+}
+
+func TestCompressJSONResultKeepsOtherResults(t *testing.T) {
+	large := largeJSONPayload(200)
+	tests := []struct {
+		name   string
+		cfg    JSONCompressionConfig
+		result tools.ToolResult
+		noArts bool
+	}{
+		{name: "disabled", result: tools.NewToolResult(large)},
+		{name: "small", cfg: JSONCompressionConfig{Enabled: true}, result: tools.NewToolResult(`{"a":1}`)},
+		{name: "not json", cfg: JSONCompressionConfig{Enabled: true, MinBytes: 10}, result: tools.NewToolResult(strings.Repeat("log line\n", 100))},
+		{name: "invalid json", cfg: JSONCompressionConfig{Enabled: true, MinBytes: 10}, result: tools.NewToolResult(large[:len(large)-1])},
+		{name: "error", cfg: JSONCompressionConfig{Enabled: true}, result: tools.ToolResult{Content: large, IsError: true}},
+		{name: "other tool", cfg: JSONCompressionConfig{Enabled: true, Tools: []string{"web_fetch"}}, result: tools.NewToolResult(large)},
+		{name: "no artifacts", cfg: JSONCompressionConfig{Enabled: true}, result: tools.NewToolResult(large), noArts: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toolCtx := tools.NewToolContext(t.TempDir())
+			if !tt.noArts {
+				toolCtx.WithArtifacts(tools.NewArtifacts())
+			}
+			got := compressJSONResult(toolCtx, OrchestratorRequest{JSONCompression: tt.cfg}, "github_api", "tool-1", tt.result, true)
+			if got.Content != tt.result.Content {
+				t.Fatalf("expected the output to be unchanged")
+			}
+			if len(toolCtx.Artifacts.List()) != 0 {
+				t.Fatalf("expected no artifacts")
+			}
+		})
+	}
+}
+
+func TestCompressJSONResultSamplesKeys(t *testing.T) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i < 400; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `"key%03d":"value %d <tag>"`, i, i)
+	}
+	b.WriteByte('}')
+	toolCtx := tools.NewToolContext(t.TempDir()).WithArtifacts(tools.NewArtifacts())
+	req := OrchestratorRequest{JSONCompression: JSONCompressionConfig{Enabled: true, MinBytes: 1024, MaxKeys: 2}}
+
+	result := compressJSONResult(toolCtx, req, "api", "tool-1", tools.NewToolResult(b.String()), false)
+	_, body, _ := strings.Cut(result.Content, "\n")
+	want := `{"key000":"value 0 <tag>","key001":"value 1 <tag>","...":"398 more keys: key002, key003"}`
+	if body != want {
+		t.Fatalf("unexpected output:\n got: %s\nwant: %s", body, want)
+	}
+}
@@ -1,6 +1,7 @@
 package orchestrator
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
@@ -10,10 +11,14 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/guard"
 	"github.com/MimeLyc/agent-core-go/pkg/instructions"
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
 	"github.com/MimeLyc/agent-core-go/pkg/skills"
 	"github.com/MimeLyc/agent-core-go/pkg/soul"
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
@@ -82,6 +87,10 @@ func validateToolPairs(messages []llm.Message) error {
 }
 
 // AgentLoop implements the Orchestrator interface.
+//
+// Run may be called concurrently. Provider and Registry are shared by all
+// runs; everything a run mutates lives in its own State and ToolContext, so
+// concurrent requests must not share an OrchestratorRequest.ToolContext.
 type AgentLoop struct {
 	// Provider is the LLM provider for making API calls.
 	// This abstracts Claude, OpenAI, and other LLM backends.
@@ -89,6 +98,17 @@ type AgentLoop struct {
 
 	// Registry contains all available tools.
 	Registry *tools.Registry
+
+	// Clock times provider and tool calls and profiles; nil uses the
+	// system clock.
+	Clock Clock
+
+	// IDs generates replacement tool_use IDs unless the request sets
+	// NewToolUseID; nil uses random IDs (sequential in deterministic mode).
+	IDs IDGenerator
+
+	// stable holds the SOUL and repository instructions of recent runs.
+	stable stableContextCache
 }
 
 // NewAgentLoop creates a new agent loop orchestrator.
@@ -108,25 +128,47 @@ func NewAgentLoop(provider llm.LLMProvider, registry *tools.Registry) *AgentLoop
 func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (OrchestratorResult, error) {
 	// Initialize state
 	state := NewState(req.InitialMessages)
+	if l.Clock != nil {
+		state.clock = l.Clock
+	}
+	state.toolCache = newToolCache(req.ToolCache)
+	if req.Profile {
+		state.profiler = newProfiler(state.clock)
+	}
+	state.provider = l.Provider.Name()
 
 	// Set up tool context
 	toolCtx := req.ToolContext
 	if toolCtx == nil {
 		toolCtx = tools.NewToolContext(req.WorkDir)
 	}
+	if req.Journal != nil {
+		toolCtx.WithJournal(req.Journal)
+	}
+	// Skill variables such as ${DATE} follow the loop's clock.
+	toolCtx.Clock = state.clock
+	if toolCtx.FileReads != nil {
+		// A cached read_file would not record the read, so a file changed
+		// outside the run could never be read again before writing it.
+		state.toolCache.uncache("read_file")
+	}
+
+	// Apply the project configuration before loading instructions: it can
+	// choose the instruction files.
+	req.ContextSections = append(applyProject(&req, toolCtx), req.ContextSections...)
 
-	// Read repository instruction files from repo root if repo instructions not provided
+	// Load the SOUL file, and repository instructions from the repo root if
+	// not provided. Later runs reuse them while their files are unchanged.
+	stable := l.stableContext(ctx, req, toolCtx.SkillDirs)
 	repoInstructions := req.RepoInstructions
-	if repoInstructions == "" && req.WorkDir != "" {
-		repoInstructions = readRepoInstructions(req.WorkDir, req.InstructionFiles)
+	if repoInstructions == "" {
+		repoInstructions = stable.repoInstructions
 	}
-
-	// Load SOUL file
-	soulContent := readSoulContent(req.WorkDir, req.SoulFile)
+	soulContent := stable.soul
 
 	// Handle explicit slash-skill invocation from the initial user message.
 	// This mirrors Claude Code's user-triggered "/skill args" behavior.
-	if applied, err := applySlashSkillInvocation(state, toolCtx, req.WorkDir); err != nil {
+	if applied, err := applySlashSkillInvocation(state, toolCtx, req.WorkDir, req.Commands); err != nil {
 		log.Printf("[orchestrator] WARNING: slash skill invocation failed: %v", err)
 	} else if applied {
 		log.Printf("[orchestrator] applied explicit slash skill invocation")
@@ -134,22 +176,46 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 
 	// Build tool definitions from registry
 	allTools := l.Registry.List()
-	toolDefs := make([]llm.ToolDefinition, len(allTools))
-	toolNames := make([]string, len(allTools))
-	for i, t := range allTools {
-		toolDefs[i] = llm.ToolDefinition{
+	toolDefs := make([]llm.ToolDefinition, 0, len(allTools))
+	toolNames := make([]string, 0, len(allTools))
+	for _, t := range allTools {
+		if !toolPermitted(req, t.Name()) {
+			continue
+		}
+		toolDefs = append(toolDefs, llm.ToolDefinition{
 			Name:        t.Name(),
 			Description: t.Description(),
 			InputSchema: t.InputSchema(),
+		})
+		toolNames = append(toolNames, t.Name())
+	}
+	state.skillTools = l.skillTools(req, toolCtx)
+	for _, t := range state.skillTools {
+		if !toolPermitted(req, t.Name()) {
+			continue
 		}
-		toolNames[i] = t.Name()
+		toolDefs = append(toolDefs, llm.ToolDefinition{
+			Name:        t.Name(),
+			Description: t.Description(),
+			InputSchema: t.InputSchema(),
+		})
+		toolNames = append(toolNames, t.Name())
 	}
 	log.Printf("[orchestrator] starting agent loop: workdir=%s tools=%v max_iterations=%d",
 		req.WorkDir, toolNames, req.MaxIterations)
+	if err := validateToolChoice(req.ToolChoice, toolDefs, req.ServerTools); err != nil {
+		return state.ToResult(), err
+	}
+	toolChoiceDone := false
 
-	// Build system prompt
-	systemPrompt := buildSystemPrompt(req.SystemPrompt, soulContent, repoInstructions)
-	log.Printf("[orchestrator] system prompt length: %d chars", len(systemPrompt))
+	// Build system prompt; dynamic sections are refreshed every iteration.
+	promptBuilder := newSystemPromptBuilder(req, soulContent, repoInstructions)
+	var systemPrompt string
+	var stableSystemBytes int
+	if !promptBuilder.Dynamic() {
+		systemPrompt, stableSystemBytes = promptBuilder.build(ctx, loopInputSnapshot(state))
+		log.Printf("[orchestrator] system prompt length: %d chars", len(systemPrompt))
+	}
 
 	// Set max iterations.
 	maxIterations := req.MaxIterations
@@ -161,16 +227,31 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 		maxMessages = defaultMaxMessages
 	}
 
+	if req.MessageSpill.Dir != "" {
+		spill, err := newMessageSpill(ctx, req.MessageSpill, maxMessages)
+		if err != nil {
+			log.Printf("[orchestrator] WARNING: message spill disabled: %v", err)
+		} else {
+			state.spill = spill
+			defer spill.close()
+		}
+	}
+
 	// Initialize compactor if enabled
 	var compactor *Compactor
 	if req.CompactConfig.Enabled {
-		compactor = NewCompactor(l.Provider, req.CompactConfig)
+		compactor = NewCompactor(l.Provider, req.CompactConfig).WithLocale(req.Locale)
 		log.Printf("[orchestrator] compaction enabled: threshold=%d keep_recent=%d",
 			req.CompactConfig.Threshold, req.CompactConfig.KeepRecent)
 	}
 
+	overflowRetries := maxOverflowRetries(req)
+	shrinker := &overflowShrinker{loop: l, compactor: compactor}
+
 	// Track all tool_use IDs to detect and fix duplicates from the LLM
 	seenToolUseIDs := make(map[string]bool)
+	newToolUseID := toolUseIDGenerator(req, l.IDs)
+	loops := newLoopDetector(req.LoopDetection)
 
 	// Agent loop
 	for !hasIterationLimit || state.Iterations < maxIterations {
@@ -188,32 +269,59 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 			log.Printf("[orchestrator] === iteration %d/unbounded ===", state.Iterations)
 		}
 
-		transformPlugins := buildTransformPlugins(req, state, compactor, maxMessages)
-		contextMessages, err := runTransformPlugins(ctx, state.Messages, transformPlugins)
+		llmMessages, err := l.buildContextMessages(ctx, req, state, compactor, maxMessages)
 		if err != nil {
-			return state.ToResult(), fmt.Errorf("transform context failed: %w", err)
+			return state.ToResult(), err
 		}
-
-		// Convert agent-context messages into provider-ready LLM messages.
-		llmMessages := defaultConvertToLlm(contextMessages)
-		if req.ConvertToLlm != nil {
-			converted, err := req.ConvertToLlm(ctx, contextMessages, l.Provider.Name())
-			if err != nil {
-				return state.ToResult(), fmt.Errorf("convert to llm failed: %w", err)
-			}
-			llmMessages = converted
+		if promptBuilder.Dynamic() {
+			systemPrompt, stableSystemBytes = promptBuilder.build(ctx, loopInputSnapshot(state))
+			log.Printf("[orchestrator] system prompt length: %d chars", len(systemPrompt))
 		}
 
 		// Build request
 		agentReq := llm.AgentRequest{
-			System:   systemPrompt,
-			Messages: llmMessages,
-			Tools:    toolDefs,
+			Model:       req.Model,
+			System:      systemPrompt,
+			Messages:    llmMessages,
+			Tools:       toolDefs,
+			ServerTools: req.ServerTools,
+
+			AssistantPrefill:  req.AssistantPrefill,
+			ExtraBody:         req.ProviderParams,
+			StableSystemBytes: stableSystemBytes,
+		}
+		if req.ThinkingBudgetTokens > 0 {
+			agentReq.Thinking = llm.NewThinkingConfig(req.ThinkingBudgetTokens)
 		}
-		log.Printf("[orchestrator] sending request: messages=%d tools=%d", len(llmMessages), len(toolDefs))
+		applySampling(req, &agentReq)
+		applyToolChoice(req, toolChoiceDone, &agentReq)
 
-		// Call the agent
-		resp, err := l.callProvider(ctx, agentReq, req.EnableStreaming, req.OnStreamDelta)
+		// Pre-flight: shrink the context before the call if it is close to the window.
+		agentReq, err = l.relieveContextPressure(ctx, req, state, compactor, maxMessages, agentReq)
+		if err != nil {
+			return state.ToResult(), err
+		}
+		agentReq.Messages = shrinker.prepare(agentReq.Messages)
+		log.Printf("[orchestrator] sending request: messages=%d tools=%d", len(agentReq.Messages), len(toolDefs))
+
+		// Call the agent, shrinking and retrying if the provider reports a context overflow.
+		var rateLimits rateLimitTally
+		callCtx := observeRateLimits(ctx, req, state.Iterations, &rateLimits)
+		callStart := state.clock.Now()
+		resp, err := l.callProvider(callCtx, agentReq, req.EnableStreaming, req.OnStreamDelta)
+		for attempt := 1; llm.IsContextOverflow(err) && attempt <= overflowRetries; attempt++ {
+			log.Printf("[orchestrator] context overflow (retry %d/%d): %v", attempt, overflowRetries, err)
+			shrunk, shrinkErr := shrinker.shrink(ctx, req, state, &agentReq, &maxMessages)
+			if shrinkErr != nil {
+				return state.ToResult(), shrinkErr
+			}
+			if !shrunk {
+				log.Printf("[orchestrator] context overflow: nothing left to shrink")
+				break
+			}
+			resp, err = l.callProvider(callCtx, agentReq, req.EnableStreaming, req.OnStreamDelta)
+		}
+		state.profiler.providerCall(agentReq, state.clock.Now().Sub(callStart))
 		if err != nil {
 			log.Printf("[orchestrator] ERROR: agent call failed: %v", err)
 			return state.ToResult(), fmt.Errorf("agent call failed: %w", err)
@@ -224,7 +332,22 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 
 		// Update usage stats
 		state.UpdateUsage(resp.Usage)
-		state.LastResponse = resp
+		model := resp.Model
+		if model == "" {
+			model = agentReq.Model
+		}
+		state.responses = append(state.responses, ResponseRecord{
+			Iteration:          state.Iterations,
+			ID:                 resp.ID,
+			Model:              model,
+			StopReason:         resp.StopReason,
+			Retries:            rateLimits.allRetries,
+			RateLimitedRetries: rateLimits.retries,
+			Repairs:            resp.Repairs,
+		})
+		for _, repair := range resp.Repairs {
+			log.Printf("[orchestrator] WARNING: repaired malformed response: %s", repair)
+		}
 
 		// Ensure all tool_use IDs are unique across the entire conversation.
 		// Some LLM APIs (e.g., Kimi K2.5) may return empty IDs or reuse IDs
@@ -234,7 +357,7 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 			if resp.Content[i].Type == llm.ContentTypeToolUse {
 				origID := resp.Content[i].ID
 				if origID == "" || seenToolUseIDs[origID] {
-					newID := generateToolUseID()
+					newID := newToolUseID()
 					if origID == "" {
 						log.Printf("[orchestrator] generated ID %s for tool %s (API returned empty ID)",
 							newID, resp.Content[i].Name)
@@ -248,6 +371,17 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 			}
 		}
 
+		// Moderate the message before it is committed or any tool runs.
+		guardErr := guardOutput(ctx, req, state.Iterations, &resp)
+		if guardErr != nil && !errors.As(guardErr, new(*guard.BlockedError)) {
+			log.Printf("[orchestrator] ERROR: %v", guardErr)
+			return state.ToResult(), guardErr
+		}
+		state.LastResponse = resp
+		if satisfiesToolChoice(req.ToolChoice, resp) {
+			toolChoiceDone = true
+		}
+
 		// Add assistant message to history (now with fixed IDs)
 		assistantMsg := resp.ToMessage()
 		state.AddMessage(assistantMsg)
@@ -264,6 +398,20 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 		if req.OnMessage != nil {
 			req.OnMessage(assistantMsg)
 		}
+		if guardErr != nil {
+			log.Printf("[orchestrator] %v", guardErr)
+			return state.ToResult(), guardErr
+		}
+		if req.OnUsage != nil {
+			req.OnUsage(UsageUpdate{
+				Iteration:              state.Iterations,
+				Model:                  model,
+				Usage:                  resp.Usage,
+				TotalInputTokens:       state.InputTokens,
+				TotalOutputTokens:      state.OutputTokens,
+				TotalCachedInputTokens: state.CachedInputTokens,
+			})
+		}
 
 		if resp.StopReason == llm.StopReasonEndTurn {
 			// TS-like runtime loop input injection point.
@@ -276,6 +424,12 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 			return state.ToResult(), nil
 		}
 
+		if resp.StopReason == llm.StopReasonPauseTurn {
+			// A long server tool turn was paused; resend it so the model continues.
+			log.Printf("[orchestrator] provider paused the turn, continuing")
+			continue
+		}
+
 		if resp.StopReason == llm.StopReasonMaxTokens {
 			log.Printf("[orchestrator] ERROR: max tokens reached at iteration %d", state.Iterations)
 			return state.ToResult(), errors.New("max tokens reached")
@@ -286,7 +440,13 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 			toolUses := resp.GetToolUses()
 			log.Printf("[orchestrator] executing %d tool(s)", len(toolUses))
 
+			if req.WorkDirWatcher != nil {
+				req.WorkDirWatcher.Pause()
+			}
 			toolResults, steering, followUp, interrupted, err := l.executeTools(ctx, toolCtx, toolUses, req, state)
+			if req.WorkDirWatcher != nil {
+				req.WorkDirWatcher.Resume()
+			}
 			if err != nil {
 				log.Printf("[orchestrator] ERROR: tool execution failed: %v", err)
 				return state.ToResult(), fmt.Errorf("tool execution failed: %w", err)
@@ -294,7 +454,12 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 
 			// Add tool results to state
 			for _, tr := range toolResults {
-				state.AddToolCall(tr.Name, tr.Input, tr.Result)
+				state.RecordToolCall(ToolCallRecord{
+					Name:    tr.Name,
+					Input:   req.Redactor.RedactMap(tr.Input),
+					Result:  tr.Result,
+					WorkDir: tr.WorkDir,
+				})
 				resultPreview := tr.Result.Content
 				if len(resultPreview) > 200 {
 					resultPreview = resultPreview[:200] + "..."
@@ -304,8 +469,26 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 			}
 
 			// Build tool result message
+			if req.OnTransformToolResult != nil {
+				for i, tr := range toolResults {
+					toolResults[i].Result = req.OnTransformToolResult(ctx, tr.Name, tr.Input, tr.Result)
+				}
+			}
 			resultMsg := buildToolResultMessage(toolResults)
+			if note, ok := rateLimits.note(req); ok {
+				resultMsg.Content = append(resultMsg.Content, note)
+			}
+			nudge, stuckErr, looped := loops.observe(req, toolResults)
+			if looped && stuckErr == nil {
+				resultMsg.Content = append(resultMsg.Content, nudge)
+			}
 			state.AddMessage(resultMsg)
+			if stuckErr != nil {
+				return state.ToResult(), stuckErr
+			}
+			if msg, ok := workDirChangesMessage(req, state); ok {
+				l.applyLoopInputs(state, req, nil, []llm.Message{msg})
+			}
 			if interrupted {
 				l.applyLoopInputs(state, req, steering, followUp)
 				continue
@@ -324,6 +507,160 @@ func (l *AgentLoop) Run(ctx context.Context, req OrchestratorRequest) (Orchestra
 	return state.ToResult(), fmt.Errorf("max iterations (%d) reached", maxIterations)
 }
 
+// buildContextMessages runs the context transform pipeline over the current
+// history and converts the result into provider-ready LLM messages.
+func (l *AgentLoop) buildContextMessages(
+	ctx context.Context,
+	req OrchestratorRequest,
+	state *State,
+	compactor *Compactor,
+	maxMessages int,
+) ([]LLMMessage, error) {
+	return l.buildContextMessagesFrom(ctx, req, state, compactor, maxMessages, state.Messages)
+}
+
+// buildContextMessagesFrom is buildContextMessages for a window built from
+// messages instead of state.Messages.
+func (l *AgentLoop) buildContextMessagesFrom(
+	ctx context.Context,
+	req OrchestratorRequest,
+	state *State,
+	compactor *Compactor,
+	maxMessages int,
+	messages []AgentMessage,
+) ([]LLMMessage, error) {
+	transformPlugins := buildTransformPlugins(req, state, compactor, maxMessages)
+	contextMessages, err := runTransformPlugins(ctx, messages, transformPlugins)
+	if err != nil {
+		return nil, fmt.Errorf("transform context failed: %w", err)
+	}
+
+	// Convert agent-context messages into provider-ready LLM messages.
+	llmMessages := defaultConvertToLlm(contextMessages)
+	if req.ConvertToLlm != nil {
+		converted, err := req.ConvertToLlm(ctx, contextMessages, l.Provider.Name())
+		if err != nil {
+			return nil, fmt.Errorf("convert to llm failed: %w", err)
+		}
+		llmMessages = converted
+	}
+	return limitRequestMessages(llmMessages, providerMessageLimit(l.Provider)), nil
+}
+
+// runTool executes a single tool. Streaming tools have their incremental
+// output forwarded to OnToolOutput and, when they return no content, the
+// accumulated output becomes the result.
+func (l *AgentLoop) runTool(
+	ctx context.Context,
+	toolCtx *tools.ToolContext,
+	tool tools.Tool,
+	use llm.ContentBlock,
+	req OrchestratorRequest,
+) (tools.ToolResult, error) {
+	opened, err := beginCheckpoint(toolCtx.Journal, toolCtx, tool, use)
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+	if opened {
+		defer func() {
+			if changed := toolCtx.Journal.End(); len(changed) > 0 {
+				log.Printf("[orchestrator] tool %s changed %d file(s)", use.Name, len(changed))
+			}
+		}()
+	}
+
+	streamingTool, ok := tool.(tools.StreamingTool)
+	if !ok {
+		return tool.Execute(ctx, toolCtx, use.Input)
+	}
+
+	w := &toolOutputWriter{
+		name:     use.Name,
+		id:       use.ID,
+		redactor: req.Redactor,
+		onOutput: req.OnToolOutput,
+	}
+	result, err := streamingTool.ExecuteStream(ctx, toolCtx, use.Input, w)
+	w.flush()
+	if err == nil && result.Content == "" {
+		result.Content = w.String()
+	}
+	return result, err
+}
+
+// maxPendingToolOutput is how much of an unfinished line toolOutputWriter
+// holds back before forwarding it anyway.
+const maxPendingToolOutput = 64 << 10
+
+// toolOutputWriter accumulates streamed tool output and forwards it in
+// whole lines, so that a secret split across writes is still redacted.
+type toolOutputWriter struct {
+	mu       sync.Mutex
+	buf      strings.Builder
+	pending  []byte // output after the last forwarded line
+	name     string
+	id       string
+	redactor *redact.Redactor
+	onOutput func(name, toolCallID, chunk string)
+}
+
+func (w *toolOutputWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	if w.onOutput == nil {
+		return len(p), nil
+	}
+	w.pending = append(w.pending, p...)
+	if i := bytes.LastIndexByte(w.pending, '\n'); i >= 0 {
+		w.forward(i + 1)
+	} else if len(w.pending) > maxPendingToolOutput {
+		w.forward(len(w.pending))
+	}
+	return len(p), nil
+}
+
+// flush forwards the output left after the last newline.
+func (w *toolOutputWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.onOutput != nil && len(w.pending) > 0 {
+		w.forward(len(w.pending))
+	}
+}
+
+// forward redacts and forwards the first n pending bytes. w.mu must be held.
+func (w *toolOutputWriter) forward(n int) {
+	chunk := string(w.pending[:n])
+	w.pending = append(w.pending[:0], w.pending[n:]...)
+	w.onOutput(w.name, w.id, w.redactor.Redact(chunk))
+}
+
+func (w *toolOutputWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// rejectToolCall returns an error result when a call is blocked by the
+// run's AllowedTools/DeniedTools, the active skill's tool allowlist, or an
+// exhausted tool budget.
+func rejectToolCall(toolCtx *tools.ToolContext, req OrchestratorRequest, state *State, name string) (tools.ToolResult, bool) {
+	if !toolPermitted(req, name) {
+		log.Printf("[orchestrator] tool %s is not allowed for this run", name)
+		return tools.NewErrorResult(fmt.Errorf("tool %q is not allowed for this run", name)), true
+	}
+	if err := ensureToolAllowedByActiveSkill(toolCtx, name); err != nil {
+		log.Printf("[orchestrator] skill-allowlist blocked tool %s: %v", name, err)
+		return tools.NewErrorResult(err), true
+	}
+	if result, exhausted := checkToolBudget(req, state, name); exhausted {
+		log.Printf("[orchestrator] tool %s budget exhausted", name)
+		return result, true
+	}
+	return tools.ToolResult{}, false
+}
+
 // executeTools runs all tool use blocks and returns results.
 func (l *AgentLoop) executeTools(
 	ctx context.Context,
@@ -337,11 +674,10 @@ func (l *AgentLoop) executeTools(
 	var pendingFollowUp []llm.Message
 
 	for _, use := range uses {
-		log.Printf("[orchestrator] calling tool: %s id=%s input=%v", use.Name, use.ID, use.Input)
+		log.Printf("[orchestrator] calling tool: %s id=%s input=%v", use.Name, use.ID, req.Redactor.RedactMap(use.Input))
 
-		if err := ensureToolAllowedByActiveSkill(toolCtx, use.Name); err != nil {
-			log.Printf("[orchestrator] skill-allowlist blocked tool %s: %v", use.Name, err)
-			result := tools.NewErrorResult(err)
+		if result, rejected := rejectToolCall(toolCtx, req, state, use.Name); rejected {
+			state.recordToolStats(use.Name, result, toolCallOutcome{rejected: true})
 			results = append(results, toolExecResult{
 				ID:     use.ID,
 				Name:   use.Name,
@@ -362,23 +698,52 @@ func (l *AgentLoop) executeTools(
 
 		// Notify callback
 		if req.OnToolCall != nil {
-			req.OnToolCall(use.Name, use.Input)
+			req.OnToolCall(use.Name, req.Redactor.RedactMap(use.Input))
 		}
 
 		// Find and execute the tool
 		tool := l.Registry.Get(use.Name)
+		if tool == nil {
+			tool = findSkillTool(state.skillTools, use.Name)
+		}
+
+		// Record the directory the call runs in (per-call cwd or current dir)
+		workDir := toolWorkDir(toolCtx, tool, use.Input)
 		var result tools.ToolResult
+		var outcome toolCallOutcome
 		if tool == nil {
 			log.Printf("[orchestrator] ERROR: tool not found: %s", use.Name)
-			result = tools.NewErrorResultf("tool not found: %s", use.Name)
+			result = tools.NewErrorResultf("tool not found: %s", use.Name).WithErrorCode(tools.ErrCodeUnknownTool, false)
+		} else if use.InputError != "" {
+			log.Printf("[orchestrator] tool %s arguments unusable: %s", use.Name, use.InputError)
+			result = tools.NewErrorResultf("could not parse the arguments of this %s call: %s; call the tool again with complete JSON arguments",
+				use.Name, use.InputError).WithErrorCode(tools.ErrCodeInvalidInput, false)
+		} else if input, err := tools.ValidateInput(use.Name, tool.InputSchema(), use.Input); err != nil {
+			log.Printf("[orchestrator] tool %s input validation failed: %v", use.Name, err)
+			result = tools.NewErrorResult(err).WithErrorCode(tools.ErrCodeInvalidInput, false)
+		} else if cached, ok := state.toolCache.get(use.Name, workDir, input); ok {
+			log.Printf("[orchestrator] tool %s served from cache", use.Name)
+			use.Input = input
+			result = cached
+			outcome.cached = true
 		} else {
-			var err error
-			result, err = tool.Execute(ctx, toolCtx, use.Input)
-			if err != nil {
-				log.Printf("[orchestrator] ERROR: tool %s execution error: %v", use.Name, err)
-				result = tools.NewErrorResult(err)
-			}
+			use.Input = input
+			state.toolCache.observe(tool, use.Name)
+			started := state.clock.Now()
+			result = l.runToolWithRetries(ctx, toolCtx, tool, use, req)
+			outcome.executed, outcome.duration = true, state.clock.Now().Sub(started)
+			state.toolCache.put(use.Name, workDir, use.Input, result)
 		}
+		result.Content = req.Redactor.Redact(result.Content)
+		if result.Command != nil {
+			command := *result.Command
+			command.Stdout = req.Redactor.Redact(command.Stdout)
+			command.Stderr = req.Redactor.Redact(command.Stderr)
+			result.Command = &command
+		}
+		state.recordToolStats(use.Name, result, outcome)
+		result = compressJSONResult(toolCtx, req, use.Name, use.ID, result,
+			l.Registry.Has(jsonQueryTool) && toolPermitted(req, jsonQueryTool))
 
 		// Notify callback
 		if req.OnToolResult != nil {
@@ -386,10 +751,11 @@ func (l *AgentLoop) executeTools(
 		}
 
 		results = append(results, toolExecResult{
-			ID:     use.ID,
-			Name:   use.Name,
-			Input:  use.Input,
-			Result: result,
+			ID:      use.ID,
+			Name:    use.Name,
+			Input:   use.Input,
+			Result:  result,
+			WorkDir: workDir,
 		})
 
 		steering, followUp := l.fetchLoopInputs(ctx, state, req)
@@ -417,13 +783,19 @@ func (l *AgentLoop) callProvider(
 	return l.Provider.Call(ctx, req)
 }
 
-func (l *AgentLoop) fetchLoopInputs(ctx context.Context, state *State, req OrchestratorRequest) ([]llm.Message, []llm.Message) {
-	snapshot := LoopInputSnapshot{
+// loopInputSnapshot captures the loop state passed to input and context
+// section callbacks.
+func loopInputSnapshot(state *State) LoopInputSnapshot {
+	return LoopInputSnapshot{
 		Iteration:      state.Iterations,
-		MessageCount:   len(state.Messages),
+		MessageCount:   len(state.Messages) + state.SpilledMessages(),
 		ToolCallCount:  len(state.ToolCalls),
 		LastStopReason: state.LastResponse.StopReason,
 	}
+}
+
+func (l *AgentLoop) fetchLoopInputs(ctx context.Context, state *State, req OrchestratorRequest) ([]llm.Message, []llm.Message) {
+	snapshot := loopInputSnapshot(state)
 
 	var steering []llm.Message
 	var followUp []llm.Message
@@ -473,7 +845,7 @@ func (l *AgentLoop) applyLoopInputs(
 ) {
 	if len(steering) > 0 {
 		for _, msg := range steering {
-			state.AddMessage(msg)
+			state.AddMessage(msg.WithMetadata(LoopInputMetadataKey, LoopInputSteering))
 		}
 		if req.OnSteeringApplied != nil {
 			req.OnSteeringApplied(steering)
@@ -483,7 +855,7 @@ func (l *AgentLoop) applyLoopInputs(
 
 	if len(followUp) > 0 {
 		for _, msg := range followUp {
-			state.AddMessage(msg)
+			state.AddMessage(msg.WithMetadata(LoopInputMetadataKey, LoopInputFollowUp))
 		}
 		if req.OnFollowUpApplied != nil {
 			req.OnFollowUpApplied(followUp)
@@ -493,10 +865,11 @@ func (l *AgentLoop) applyLoopInputs(
 }
 
 type toolExecResult struct {
-	ID     string
-	Name   string
-	Input  map[string]any
-	Result tools.ToolResult
+	ID      string
+	Name    string
+	Input   map[string]any
+	Result  tools.ToolResult
+	WorkDir string
 }
 
 // buildToolResultMessage creates a message with all tool results.
@@ -521,44 +894,43 @@ func buildToolResultMessage(results []toolExecResult) llm.Message {
 
 // buildSystemPrompt combines the base system prompt with SOUL and repo instructions.
 func buildSystemPrompt(base, soulContent, repoInstructions string) string {
-	parts := []string{}
-
-	base = strings.TrimSpace(base)
-	if base != "" {
-		parts = append(parts, base)
-	}
+	req := OrchestratorRequest{SystemPrompt: base}
+	return newSystemPromptBuilder(req, soulContent, repoInstructions).Build(context.Background(), LoopInputSnapshot{})
+}
 
+// soulSection formats SOUL content as the Soul system prompt section.
+func soulSection(lang, soulContent string) string {
 	soulContent = strings.TrimSpace(soulContent)
-	if soulContent != "" {
-		parts = append(parts, strings.Join([]string{
-			"## Soul",
-			"",
-			"The following defines your character, personality, and behavioral directives.",
-			"Follow these directives throughout the conversation.",
-			"",
-			soulContent,
-		}, "\n"))
+	if soulContent == "" {
+		return ""
 	}
+	return strings.Join([]string{
+		locale.Text(lang, locale.SoulHeading),
+		"",
+		locale.Text(lang, locale.SoulIntro),
+		"",
+		soulContent,
+	}, "\n")
+}
 
+// repoInstructionsSection formats repository instructions as a system
+// prompt section.
+func repoInstructionsSection(lang, repoInstructions string) string {
 	repoInstructions = strings.TrimSpace(repoInstructions)
-	if repoInstructions != "" {
-		parts = append(parts, strings.Join([]string{
-			"## Repository Instructions",
-			"",
-			"The sections below are ordered from repository root to current directory.",
-			"More specific instructions should override broader ones.",
-			"",
-			repoInstructions,
-		}, "\n"))
-	}
-	if len(parts) == 0 {
+	if repoInstructions == "" {
 		return ""
 	}
-	return strings.Join(parts, "\n\n")
+	return strings.Join([]string{
+		locale.Text(lang, locale.RepoInstructionsHeading),
+		"",
+		locale.Text(lang, locale.RepoInstructionsIntro),
+		"",
+		repoInstructions,
+	}, "\n")
 }
 
-// readSoulContent loads the SOUL file content.
-func readSoulContent(workDir, soulFile string) string {
+// loadSoul loads the SOUL file content.
+func loadSoul(workDir, soulFile string) soul.LoadResult {
 	opts := soul.LoadOptions{
 		File: soulFile,
 	}
@@ -569,20 +941,33 @@ func readSoulContent(workDir, soulFile string) string {
 			result.Source, len(result.Content), truncatedSuffix(result.Truncated))
 	}
 
-	return result.Content
+	return result
+}
+
+// loadedInstructions are repository instructions with skill metadata, the
+// paths they were loaded from or looked for, and whether every directory
+// was read.
+type loadedInstructions struct {
+	content  string
+	watched  []string
+	complete bool
 }
 
-// readRepoInstructions loads repository instructions from repo root to workDir.
-// If instructionFiles is non-empty, those file names are used as candidates;
-// otherwise the default candidate list from the instructions package is used.
-func readRepoInstructions(workDir string, instructionFiles []string) string {
-	opts := instructions.LoadOptions{
-		MaxBytes: instructions.DefaultMaxBytes,
+// loadRepoInstructions loads repository instructions from repo root to workDir.
+// Empty opts.CandidateFiles uses the default candidate list from the
+// instructions package.
+// Skill metadata is discovered from the default directories plus skillDirs
+// and rendered in lang, unless listSkills is false. Directories that do not
+// respond in time, e.g. on an unreachable network mount, are skipped and
+// logged.
+func loadRepoInstructions(ctx context.Context, workDir string, opts instructions.LoadOptions, skillDirs []string, lang string, listSkills bool) loadedInstructions {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = instructions.DefaultMaxBytes
 	}
-	if len(instructionFiles) > 0 {
-		opts.CandidateFiles = instructionFiles
+	result := instructions.LoadContext(ctx, workDir, opts)
+	for _, skipped := range result.Skipped {
+		log.Printf("[orchestrator] WARNING: skipped repo instructions in %s: %s", skipped.Path, skipped.Reason)
 	}
-	result := instructions.Load(workDir, opts)
 
 	combined := strings.TrimSpace(result.Content)
 	if combined != "" {
@@ -595,7 +980,15 @@ func readRepoInstructions(workDir string, instructionFiles []string) string {
 		log.Printf("[orchestrator] no repository instructions found in %s", workDir)
 	}
 
-	skillBlock, skillCount, skillTruncated := buildSkillMetadata(workDir)
+	loaded := loadedInstructions{watched: result.Watched, complete: len(result.Skipped) == 0}
+	if !listSkills {
+		loaded.content = combined
+		return loaded
+	}
+	discovered := discoverSkills(ctx, workDir, skillDirs)
+	loaded.watched = append(loaded.watched, discovered.Watched...)
+	loaded.complete = loaded.complete && len(discovered.Skipped) == 0
+	skillBlock, skillCount, skillTruncated := renderSkillMetadata(discovered.Skills, lang)
 	if strings.TrimSpace(skillBlock) != "" {
 		if combined != "" {
 			combined += "\n\n" + skillBlock
@@ -609,7 +1002,8 @@ func readRepoInstructions(workDir string, instructionFiles []string) string {
 		log.Printf("[orchestrator] no discoverable skills found for workdir=%s", workDir)
 	}
 
-	return combined
+	loaded.content = combined
+	return loaded
 }
 
 func truncatedSuffix(truncated bool) string {
@@ -619,22 +1013,41 @@ func truncatedSuffix(truncated bool) string {
 	return ""
 }
 
-func buildSkillMetadata(workDir string) (content string, count int, truncated bool) {
-	searchDirs := skills.DefaultSearchDirs(workDir)
-	discovered, err := skills.Discover(searchDirs)
-	if err != nil {
-		log.Printf("[orchestrator] failed to discover skills for workdir=%s: %v", workDir, err)
-		return "", 0, false
+func discoverSkills(ctx context.Context, workDir string, extraDirs []string) skills.DiscoverResult {
+	searchDirs := skills.SearchDirs(workDir, extraDirs)
+	result := skills.DiscoverContext(ctx, searchDirs, skills.DiscoverOptions{})
+	if len(result.Skipped) > 0 {
+		log.Printf("[orchestrator] WARNING: skipped skill directories: %s", skills.FormatSkipped(result.Skipped))
 	}
-	logSkillDiscoveryByDir(searchDirs, discovered)
+	logSkillDiscoveryByDir(searchDirs, result.Skills)
+	return result
+}
+
+func renderSkillMetadata(discovered []skills.Skill, lang string) (content string, count int, truncated bool) {
 	if len(discovered) == 0 {
 		return "", 0, false
 	}
-	block := skills.BuildPromptBlock(discovered, skills.DefaultPromptBlockMaxBytes)
+	block := skills.BuildLocalizedPromptBlock(discovered, skills.DefaultPromptBlockMaxBytes, lang)
 	return block.Content, block.SkillCount, block.Truncated
 }
 
-func applySlashSkillInvocation(state *State, toolCtx *tools.ToolContext, workDir string) (bool, error) {
+// toolWorkDir returns the directory a call runs in: its CwdInputKey value
+// when the tool's schema declares one (bash, run_in_container), otherwise
+// the current directory. Other tools never read the key, so a stray value
+// must not change the recorded directory or the cache key.
+func toolWorkDir(toolCtx *tools.ToolContext, tool tools.Tool, input map[string]any) string {
+	if tool != nil {
+		props, _ := tool.InputSchema()["properties"].(map[string]any)
+		if _, ok := props[tools.CwdInputKey]; ok {
+			if dir, err := toolCtx.ResolveCwd(input); err == nil {
+				return dir
+			}
+		}
+	}
+	return toolCtx.Cwd()
+}
+
+func applySlashSkillInvocation(state *State, toolCtx *tools.ToolContext, workDir string, commands []SlashCommand) (bool, error) {
 	if state == nil || len(state.Messages) == 0 {
 		return false, nil
 	}
@@ -647,7 +1060,20 @@ func applySlashSkillInvocation(state *State, toolCtx *tools.ToolContext, workDir
 		return false, nil
 	}
 
-	discovered, err := skills.Discover(skills.DefaultSearchDirs(workDir))
+	vars := skills.VariablesFor(toolCtx, toolCtx.Now())
+
+	if cmd, ok := findSlashCommand(commands, name); ok {
+		log.Printf("[orchestrator] slash command resolved: command=%s args=%q", cmd.Name, strings.TrimSpace(arguments))
+		rendered := skills.RenderBodyVars(cmd.Prompt, arguments, vars)
+		state.Messages[0] = llm.NewTextMessage(llm.RoleUser, slashInvocationText(name, arguments, rendered))
+		return true, nil
+	}
+
+	var skillDirs []string
+	if toolCtx != nil {
+		skillDirs = toolCtx.SkillDirs
+	}
+	discovered, err := skills.Discover(skills.SearchDirs(workDir, skillDirs))
 	if err != nil {
 		return false, err
 	}
@@ -670,26 +1096,14 @@ func applySlashSkillInvocation(state *State, toolCtx *tools.ToolContext, workDir
 		strings.TrimSpace(arguments),
 	)
 
-	sessionID := ""
-	if toolCtx != nil && toolCtx.Env != nil {
-		sessionID = strings.TrimSpace(toolCtx.Env[skills.EnvClaudeSessionID])
-	}
-	rendered, truncated, err := skills.RenderForInvocation(selected, arguments, sessionID, skills.DefaultSkillReadMaxBytes)
+	rendered, truncated, err := skills.RenderForInvocationVars(selected, arguments, vars, skills.DefaultSkillReadMaxBytes)
 	if err != nil {
 		return false, err
 	}
-
-	var b strings.Builder
-	fmt.Fprintf(&b, "User invoked /%s\n", name)
-	if strings.TrimSpace(arguments) != "" {
-		fmt.Fprintf(&b, "Arguments: %s\n", strings.TrimSpace(arguments))
-	}
-	b.WriteString("\n")
-	b.WriteString(rendered)
 	if truncated {
-		fmt.Fprintf(&b, "\n\n[truncated to %d bytes]", skills.DefaultSkillReadMaxBytes)
+		rendered += fmt.Sprintf("\n\n[truncated to %d bytes]", skills.DefaultSkillReadMaxBytes)
 	}
-	state.Messages[0] = llm.NewTextMessage(llm.RoleUser, strings.TrimSpace(b.String()))
+	state.Messages[0] = llm.NewTextMessage(llm.RoleUser, slashInvocationText(name, arguments, rendered))
 
 	if toolCtx != nil {
 		toolCtx.WithEnv(skills.EnvActiveSkillName, selected.Name)
@@ -699,11 +1113,40 @@ func applySlashSkillInvocation(state *State, toolCtx *tools.ToolContext, workDir
 		} else if toolCtx.Env != nil {
 			delete(toolCtx.Env, skills.EnvActiveSkillAllowedTools)
 		}
+		toolCtx.Sandbox = selected.Sandbox
 	}
 
 	return true, nil
 }
 
+// findSlashCommand matches name against commands, exactly first and then
+// case-insensitively.
+func findSlashCommand(commands []SlashCommand, name string) (SlashCommand, bool) {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+	for _, cmd := range commands {
+		if strings.EqualFold(cmd.Name, name) {
+			return cmd, true
+		}
+	}
+	return SlashCommand{}, false
+}
+
+// slashInvocationText builds the user message that replaces "/name args".
+func slashInvocationText(name, arguments, rendered string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "User invoked /%s\n", name)
+	if strings.TrimSpace(arguments) != "" {
+		fmt.Fprintf(&b, "Arguments: %s\n", strings.TrimSpace(arguments))
+	}
+	b.WriteString("\n")
+	b.WriteString(rendered)
+	return strings.TrimSpace(b.String())
+}
+
 const unmatchedSkillDirLabel = "<unmatched>"
 
 type skillDiscoveryLogEntry struct {
@@ -828,12 +1271,21 @@ func formatSkillListForLog(skillList []skills.Skill) string {
 	return "[" + strings.Join(items, ", ") + "]"
 }
 
+// toolPermitted reports whether the run's AllowedTools and DeniedTools
+// admit the tool.
+func toolPermitted(req OrchestratorRequest, name string) bool {
+	if len(req.AllowedTools) > 0 && !skills.IsToolAllowed(name, req.AllowedTools) {
+		return false
+	}
+	return len(req.DeniedTools) == 0 || !skills.IsToolAllowed(name, req.DeniedTools)
+}
+
 func ensureToolAllowedByActiveSkill(toolCtx *tools.ToolContext, toolName string) error {
 	if toolCtx == nil || toolCtx.Env == nil {
 		return nil
 	}
 	// Allow reloading/switching skills even under a restrictive skill allowlist.
-	if toolName == "use_skill" {
+	if toolName == useSkillToolName || isSkillToolName(toolName) {
 		return nil
 	}
 
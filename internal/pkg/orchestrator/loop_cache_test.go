@@ -0,0 +1,152 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/tools/builtin"
+)
+
+func TestRunCachesRepeatedToolCallsUntilWrite(t *testing.T) {
+	workDir := t.TempDir()
+	path := filepath.Join(workDir, "a.txt")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	read := map[string]any{"path": "a.txt"}
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "read_file", read),
+		toolUseResponse("tool-2", "read_file", read),
+		toolUseResponse("tool-3", "write_file", map[string]any{"path": "a.txt", "content": "v3"}),
+		toolUseResponse("tool-4", "read_file", read),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	builtin.RegisterFileTools(registry)
+
+	loop := NewAgentLoop(provider, registry)
+	calls := 0
+	result, err := loop.Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "read twice")},
+		WorkDir:         workDir,
+		ToolCache:       ToolCacheConfig{Enabled: true},
+		OnToolResult: func(name string, _ tools.ToolResult) {
+			// Change the file behind the agent's back after the first read:
+			// a cached second read still sees v1.
+			if calls++; calls == 1 {
+				_ = os.WriteFile(path, []byte("v2"), 0o644)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 4 {
+		t.Fatalf("expected 4 tool calls, got %d", len(result.ToolCalls))
+	}
+
+	want := []struct {
+		content string
+		cached  bool
+	}{
+		{"v1", false},
+		{"v1", true},
+		{"", false},
+		{"v3", false},
+	}
+	for i, w := range want {
+		call := result.ToolCalls[i]
+		if call.Result.IsError {
+			t.Fatalf("tool call %d failed: %s", i, call.Result.Content)
+		}
+		if call.Name == "read_file" && call.Result.Content != w.content {
+			t.Errorf("tool call %d: expected content %q, got %q", i, w.content, call.Result.Content)
+		}
+		if cached, _ := call.Result.Metadata["cached"].(bool); cached != w.cached {
+			t.Errorf("tool call %d: expected cached=%v, got %v", i, w.cached, cached)
+		}
+	}
+}
+
+func TestToolCacheConfig(t *testing.T) {
+	if newToolCache(ToolCacheConfig{}) != nil {
+		t.Fatal("expected disabled cache to be nil")
+	}
+
+	cache := newToolCache(ToolCacheConfig{Enabled: true, Tools: []string{"count"}, InvalidateOn: []string{"reset"}})
+	input := map[string]any{"n": float64(1)}
+	cache.put("count", "/w", input, tools.NewToolResult("one"))
+	cache.put("count", "/w", map[string]any{"n": float64(2)}, tools.NewErrorResult(os.ErrNotExist))
+	cache.put("read_file", "/w", input, tools.NewToolResult("not cacheable"))
+
+	if r, ok := cache.get("count", "/w", map[string]any{"n": float64(1)}); !ok || r.Content != "one" {
+		t.Fatalf("expected cache hit, got %+v, %v", r, ok)
+	}
+	if _, ok := cache.get("count", "/other", input); ok {
+		t.Fatal("expected working directory to be part of the key")
+	}
+	if _, ok := cache.get("count", "/w", map[string]any{"n": float64(2)}); ok {
+		t.Fatal("expected error results not to be cached")
+	}
+	if _, ok := cache.get("read_file", "/w", input); ok {
+		t.Fatal("expected only configured tools to be cached")
+	}
+
+	cache.observe(&countTool{}, "other")
+	if _, ok := cache.get("count", "/w", input); !ok {
+		t.Fatal("expected unrelated tool to keep the cache")
+	}
+	cache.observe(&countTool{}, "reset")
+	if _, ok := cache.get("count", "/w", input); ok {
+		t.Fatal("expected InvalidateOn tool to clear the cache")
+	}
+}
+
+func TestRunDoesNotCacheReadsTrackedByFileReads(t *testing.T) {
+	workDir := t.TempDir()
+	path := filepath.Join(workDir, "a.txt")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	read := map[string]any{"path": "a.txt"}
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "read_file", read),
+		toolUseResponse("tool-2", "read_file", read),
+		toolUseResponse("tool-3", "write_file", map[string]any{"path": "a.txt", "content": "v3"}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	builtin.RegisterFileTools(registry)
+
+	calls := 0
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "edit a.txt")},
+		ToolContext:     tools.NewToolContext(workDir).WithFileReads(tools.NewFileReads()),
+		ToolCache:       ToolCacheConfig{Enabled: true},
+		OnToolResult: func(string, tools.ToolResult) {
+			// Change the file outside the run after the first read.
+			if calls++; calls == 1 {
+				_ = os.WriteFile(path, []byte("v2 changed"), 0o644)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 3 {
+		t.Fatalf("expected 3 tool calls, got %d", len(result.ToolCalls))
+	}
+	reread := result.ToolCalls[1].Result
+	if cached, _ := reread.Metadata["cached"].(bool); cached || reread.Content != "v2 changed" {
+		t.Fatalf("expected the re-read to see the new content, got %+v", reread)
+	}
+	if result.ToolCalls[2].Result.IsError {
+		t.Fatalf("expected the write after a re-read to succeed, got %+v", result.ToolCalls[2].Result)
+	}
+}
@@ -0,0 +1,216 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+)
+
+const (
+	defaultLoopMaxRepeats        = 3
+	defaultLoopOscillationCycles = 3
+	defaultLoopMaxNudges         = 2
+
+	// loopCallDescriptionBytes bounds the tool input quoted in notes and
+	// errors.
+	loopCallDescriptionBytes = 120
+)
+
+// ErrAgentStuck is matched by errors.Is when loop detection aborted a run
+// that kept repeating itself. errors.As with *StuckError gives the details.
+var ErrAgentStuck = errors.New("agent is stuck")
+
+// LoopPattern is the kind of loop loop detection noticed.
+type LoopPattern string
+
+const (
+	// LoopRepeat is the same tool calls, with identical input, in
+	// consecutive responses.
+	LoopRepeat LoopPattern = "repeat"
+	// LoopOscillation is alternating between two sets of tool calls.
+	LoopOscillation LoopPattern = "oscillation"
+)
+
+// LoopAction is what loop detection does about a loop.
+type LoopAction string
+
+const (
+	// LoopActionNudge appends a corrective note to the tool results so the
+	// model changes course. This is the default.
+	LoopActionNudge LoopAction = "nudge"
+	// LoopActionAbort ends the run with a *StuckError.
+	LoopActionAbort LoopAction = "abort"
+)
+
+// LoopDetectionConfig configures detection of runs that repeat themselves
+// instead of making progress.
+type LoopDetectionConfig struct {
+	Enabled bool
+
+	// MaxRepeats is how many consecutive responses with the same tool calls
+	// and identical input count as a loop (default 3).
+	MaxRepeats int
+
+	// OscillationCycles is how many times a run may alternate between two
+	// sets of tool calls (A, B, A, B, ...) before it counts as a loop
+	// (default 3, i.e. six responses).
+	OscillationCycles int
+
+	// Action is LoopActionNudge (default) or LoopActionAbort.
+	Action LoopAction
+
+	// MaxNudges is how many notes are sent before a further loop aborts the
+	// run (default 2). Negative never aborts.
+	MaxNudges int
+}
+
+func (c LoopDetectionConfig) withDefaults() LoopDetectionConfig {
+	if c.MaxRepeats <= 1 {
+		c.MaxRepeats = defaultLoopMaxRepeats
+	}
+	if c.OscillationCycles <= 1 {
+		c.OscillationCycles = defaultLoopOscillationCycles
+	}
+	if c.Action == "" {
+		c.Action = LoopActionNudge
+	}
+	if c.MaxNudges == 0 {
+		c.MaxNudges = defaultLoopMaxNudges
+	}
+	return c
+}
+
+// StuckError is returned when loop detection aborts a run.
+type StuckError struct {
+	Pattern LoopPattern
+
+	// Calls describes the repeated tool calls; an oscillation lists both
+	// alternatives.
+	Calls []string
+
+	// Count is how many responses in a row formed the pattern.
+	Count int
+
+	// Failing is set when every repeated call failed.
+	Failing bool
+}
+
+func (e *StuckError) Error() string {
+	return fmt.Sprintf("agent is stuck: %s of %s over %d responses", e.Pattern, strings.Join(e.Calls, " / "), e.Count)
+}
+
+// Is makes errors.Is(err, ErrAgentStuck) match.
+func (e *StuckError) Is(target error) bool {
+	return target == ErrAgentStuck
+}
+
+// loopDetector watches the tool calls of one run.
+type loopDetector struct {
+	cfg LoopDetectionConfig
+
+	// signatures holds one entry per tool-calling response since the last
+	// intervention; descriptions and failing are parallel to it.
+	signatures   []string
+	descriptions []string
+	failing      []bool
+	nudges       int
+}
+
+func newLoopDetector(cfg LoopDetectionConfig) *loopDetector {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &loopDetector{cfg: cfg.withDefaults()}
+}
+
+// observe records a response's executed tool calls. On a loop it returns
+// either a note to append to the tool results or the error ending the run.
+func (d *loopDetector) observe(req OrchestratorRequest, results []toolExecResult) (llm.ContentBlock, error, bool) {
+	if d == nil || len(results) == 0 {
+		return llm.ContentBlock{}, nil, false
+	}
+	keys := make([]string, len(results))
+	descriptions := make([]string, len(results))
+	failing := true
+	for i, r := range results {
+		input, _ := json.Marshal(r.Input)
+		keys[i] = r.Name + " " + string(input)
+		redacted, _ := json.Marshal(req.Redactor.RedactMap(r.Input))
+		descriptions[i] = r.Name + " " + truncateLoopText(string(redacted))
+		failing = failing && r.Result.IsError
+	}
+	sort.Strings(keys)
+	sort.Strings(descriptions)
+	d.signatures = append(d.signatures, strings.Join(keys, "\n"))
+	d.descriptions = append(d.descriptions, strings.Join(descriptions, ", "))
+	d.failing = append(d.failing, failing)
+
+	stuck, ok := d.detect()
+	if !ok {
+		return llm.ContentBlock{}, nil, false
+	}
+	d.signatures, d.descriptions, d.failing = nil, nil, nil
+	log.Printf("[orchestrator] loop detected: %v", stuck)
+	if d.cfg.Action == LoopActionAbort || (d.cfg.MaxNudges >= 0 && d.nudges >= d.cfg.MaxNudges) {
+		return llm.ContentBlock{}, stuck, true
+	}
+	d.nudges++
+	return llm.ContentBlock{Type: llm.ContentTypeText, Text: loopNote(req.Locale, stuck)}, nil, true
+}
+
+// detect checks the most recent responses for a repeat or an oscillation.
+func (d *loopDetector) detect() (*StuckError, bool) {
+	n := len(d.signatures)
+	if k := d.cfg.MaxRepeats; n >= k {
+		last := d.signatures[n-1]
+		repeated, failing := true, true
+		for i := n - k; i < n; i++ {
+			repeated = repeated && d.signatures[i] == last
+			failing = failing && d.failing[i]
+		}
+		if repeated {
+			return &StuckError{Pattern: LoopRepeat, Calls: []string{d.descriptions[n-1]}, Count: k, Failing: failing}, true
+		}
+	}
+	if k := 2 * d.cfg.OscillationCycles; n >= k && d.signatures[n-1] != d.signatures[n-2] {
+		failing := true
+		for i := n - k; i < n; i++ {
+			if i >= n-k+2 && d.signatures[i] != d.signatures[i-2] {
+				return nil, false
+			}
+			failing = failing && d.failing[i]
+		}
+		return &StuckError{Pattern: LoopOscillation, Calls: []string{d.descriptions[n-2], d.descriptions[n-1]}, Count: k, Failing: failing}, true
+	}
+	return nil, false
+}
+
+// loopNote is the corrective note sent to the model.
+func loopNote(lang string, stuck *StuckError) string {
+	if stuck.Pattern == LoopOscillation {
+		return fmt.Sprintf(locale.Text(lang, locale.LoopOscillationNote), stuck.Calls[0], stuck.Calls[1], stuck.Count)
+	}
+	key := locale.LoopRepeatNote
+	if stuck.Failing {
+		key = locale.LoopRepeatFailingNote
+	}
+	return fmt.Sprintf(locale.Text(lang, key), stuck.Calls[0], stuck.Count)
+}
+
+func truncateLoopText(s string) string {
+	if len(s) <= loopCallDescriptionBytes {
+		return s
+	}
+	cut := loopCallDescriptionBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
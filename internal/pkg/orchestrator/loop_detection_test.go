@@ -0,0 +1,145 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func runLoopDetection(t *testing.T, cfg LoopDetectionConfig, inputs ...map[string]any) (*scriptedProvider, OrchestratorResult, error) {
+	t.Helper()
+	var responses []llm.AgentResponse
+	for i, input := range inputs {
+		responses = append(responses, toolUseResponse(fmt.Sprintf("tool-%d", i+1), "count", input))
+	}
+	responses = append(responses, llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		StopReason: llm.StopReasonEndTurn,
+		Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}},
+	})
+	provider := &scriptedProvider{responses: responses}
+	registry := tools.NewRegistry()
+	registry.MustRegister(&countTool{})
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+		LoopDetection:   cfg,
+	})
+	return provider, result, err
+}
+
+// loopNotes returns the text blocks appended to tool result messages.
+func loopNotes(messages []llm.Message) []string {
+	var notes []string
+	for _, msg := range messages {
+		if !hasToolResult(msg) {
+			continue
+		}
+		for _, block := range msg.Content {
+			if block.Type == llm.ContentTypeText {
+				notes = append(notes, block.Text)
+			}
+		}
+	}
+	return notes
+}
+
+func TestLoopDetectionNudgesRepeatedCall(t *testing.T) {
+	same := map[string]any{"n": 1}
+	_, result, err := runLoopDetection(t, LoopDetectionConfig{Enabled: true}, same, same, same, same)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	notes := loopNotes(result.Messages)
+	if len(notes) != 1 {
+		t.Fatalf("expected one note, got %q", notes)
+	}
+	if !strings.Contains(notes[0], `count {"n":1}`) || !strings.Contains(notes[0], "3 times") {
+		t.Fatalf("unexpected note: %q", notes[0])
+	}
+
+	// The note follows the third call's result.
+	third := result.Messages[6]
+	if !hasToolResult(third) || third.Content[len(third.Content)-1].Type != llm.ContentTypeText {
+		t.Fatalf("expected note after third result, got %#v", third.Content)
+	}
+}
+
+func TestLoopDetectionNotesRepeatedFailures(t *testing.T) {
+	invalid := map[string]any{}
+	_, result, err := runLoopDetection(t, LoopDetectionConfig{Enabled: true}, invalid, invalid, invalid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	notes := loopNotes(result.Messages)
+	if len(notes) != 1 || !strings.Contains(notes[0], "has failed 3 times") {
+		t.Fatalf("expected failing note, got %q", notes)
+	}
+}
+
+func TestLoopDetectionIgnoresChangingInput(t *testing.T) {
+	_, result, err := runLoopDetection(t, LoopDetectionConfig{Enabled: true},
+		map[string]any{"n": 1}, map[string]any{"n": 2}, map[string]any{"n": 3}, map[string]any{"n": 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if notes := loopNotes(result.Messages); len(notes) != 0 {
+		t.Fatalf("expected no notes, got %q", notes)
+	}
+}
+
+func TestLoopDetectionAborts(t *testing.T) {
+	same := map[string]any{"n": 1}
+	provider, _, err := runLoopDetection(t, LoopDetectionConfig{Enabled: true, Action: LoopActionAbort}, same, same, same, same)
+	if !errors.Is(err, ErrAgentStuck) {
+		t.Fatalf("expected ErrAgentStuck, got %v", err)
+	}
+	var stuck *StuckError
+	if !errors.As(err, &stuck) || stuck.Pattern != LoopRepeat || stuck.Count != 3 {
+		t.Fatalf("unexpected stuck error: %#v", stuck)
+	}
+	if len(provider.requests) != 3 {
+		t.Fatalf("expected run to stop after 3 requests, got %d", len(provider.requests))
+	}
+}
+
+func TestLoopDetectionAbortsAfterMaxNudges(t *testing.T) {
+	same := map[string]any{"n": 1}
+	inputs := []map[string]any{same, same, same, same, same, same, same}
+	_, result, err := runLoopDetection(t, LoopDetectionConfig{Enabled: true, MaxNudges: 1}, inputs...)
+	if !errors.Is(err, ErrAgentStuck) {
+		t.Fatalf("expected ErrAgentStuck, got %v", err)
+	}
+	if notes := loopNotes(result.Messages); len(notes) != 1 {
+		t.Fatalf("expected one note before aborting, got %q", notes)
+	}
+}
+
+func TestLoopDetectionOscillation(t *testing.T) {
+	a, b := map[string]any{"n": 1}, map[string]any{"n": 2}
+	_, _, err := runLoopDetection(t, LoopDetectionConfig{Enabled: true, OscillationCycles: 2, Action: LoopActionAbort}, a, b, a, b, a)
+	var stuck *StuckError
+	if !errors.As(err, &stuck) {
+		t.Fatalf("expected StuckError, got %v", err)
+	}
+	if stuck.Pattern != LoopOscillation || stuck.Count != 4 || len(stuck.Calls) != 2 {
+		t.Fatalf("unexpected stuck error: %#v", stuck)
+	}
+}
+
+func TestLoopDetectionDisabled(t *testing.T) {
+	same := map[string]any{"n": 1}
+	_, result, err := runLoopDetection(t, LoopDetectionConfig{}, same, same, same, same)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if notes := loopNotes(result.Messages); len(notes) != 0 {
+		t.Fatalf("expected no notes, got %q", notes)
+	}
+}
@@ -1,15 +1,19 @@
 package orchestrator
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/instructions"
 	"github.com/MimeLyc/agent-core-go/pkg/skills"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
 )
 
-func TestReadRepoInstructionsAggregatesRootToLeafAndPrefersAgent(t *testing.T) {
+func TestLoadRepoInstructionsAggregatesRootToLeafAndPrefersAgent(t *testing.T) {
 	repo := t.TempDir()
 	mustMkdirAll(t, filepath.Join(repo, ".git"))
 	leaf := filepath.Join(repo, "services", "api")
@@ -20,7 +24,7 @@ func TestReadRepoInstructionsAggregatesRootToLeafAndPrefersAgent(t *testing.T) {
 	mustWriteText(t, filepath.Join(repo, "services", "AGENT.md"), "services rules")
 	mustWriteText(t, filepath.Join(leaf, "AGENT.md"), "api rules")
 
-	got := readRepoInstructions(leaf, nil)
+	got := loadRepoInstructions(context.Background(), leaf, instructions.LoadOptions{}, nil, "", true).content
 	if strings.Contains(got, "root claude rules") {
 		t.Fatalf("expected AGENT.md to win over CLAUDE.md in same directory, got: %q", got)
 	}
@@ -48,7 +52,7 @@ func TestBuildSystemPromptIncludesLayerPrecedenceHint(t *testing.T) {
 	}
 }
 
-func TestReadRepoInstructionsIncludesSkillMetadataBlock(t *testing.T) {
+func TestLoadRepoInstructionsIncludesSkillMetadataBlock(t *testing.T) {
 	repo := t.TempDir()
 	mustMkdirAll(t, filepath.Join(repo, ".git"))
 	mustWriteText(t, filepath.Join(repo, "AGENT.md"), "repo rules")
@@ -62,7 +66,7 @@ description: test description
 `)
 
 	t.Setenv(skills.SkillDirsEnv, skillsDir)
-	got := readRepoInstructions(repo, nil)
+	got := loadRepoInstructions(context.Background(), repo, instructions.LoadOptions{}, nil, "", true).content
 	if !strings.Contains(got, "Available Skills") {
 		t.Fatalf("expected Available Skills block in instructions, got: %q", got)
 	}
@@ -100,25 +104,83 @@ func TestBuildSystemPromptEmptyWhenNoInputs(t *testing.T) {
 	}
 }
 
-func TestReadSoulContentFromWorkDir(t *testing.T) {
+func TestLoadSoulFromWorkDir(t *testing.T) {
 	dir := t.TempDir()
 	mustWriteText(t, filepath.Join(dir, "SOUL.md"), "You are helpful.")
-	content := readSoulContent(dir, "")
+	content := loadSoul(dir, "").Content
 	if content != "You are helpful." {
 		t.Fatalf("expected soul content, got: %q", content)
 	}
 }
 
-func TestReadSoulContentExplicitFile(t *testing.T) {
+func TestLoadSoulExplicitFile(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "custom.md")
 	mustWriteText(t, path, "Custom soul.")
-	content := readSoulContent("", path)
+	content := loadSoul("", path).Content
 	if content != "Custom soul." {
 		t.Fatalf("expected custom soul content, got: %q", content)
 	}
 }
 
+func TestRunReusesStableContextUntilFilesChange(t *testing.T) {
+	repo := t.TempDir()
+	mustMkdirAll(t, filepath.Join(repo, ".git"))
+	rules := filepath.Join(repo, "AGENTS.md")
+	mustWriteText(t, rules, "rules v1")
+	mustWriteText(t, filepath.Join(repo, "SOUL.md"), "soul")
+	t.Setenv(skills.SkillDirsEnv, "")
+
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	loop := NewAgentLoop(provider, tools.NewRegistry())
+	run := func() string {
+		t.Helper()
+		_, err := loop.Run(context.Background(), OrchestratorRequest{
+			SystemPrompt:    "base",
+			InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "go")},
+			WorkDir:         repo,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		last := provider.requests[len(provider.requests)-1]
+		if last.StableSystemBytes != len(last.System) {
+			t.Fatalf("expected the whole prompt to be stable, got %d of %d bytes", last.StableSystemBytes, len(last.System))
+		}
+		return last.System
+	}
+
+	if got := run(); !strings.Contains(got, "rules v1") || !strings.Contains(got, "soul") {
+		t.Fatalf("expected SOUL and repository instructions, got %q", got)
+	}
+
+	// Same size and modification time: the cached instructions are reused.
+	info, err := os.Stat(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustWriteText(t, rules, "rules v2")
+	if err := os.Chtimes(rules, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(); !strings.Contains(got, "rules v1") {
+		t.Fatalf("expected reused instructions, got %q", got)
+	}
+
+	mustWriteText(t, rules, "rules version 3")
+	if got := run(); !strings.Contains(got, "rules version 3") {
+		t.Fatalf("expected reloaded instructions after the file changed, got %q", got)
+	}
+
+	// A new instruction file in a watched location is picked up too.
+	mustWriteText(t, filepath.Join(repo, "AGENT.md"), "agent rules")
+	if got := run(); !strings.Contains(got, "agent rules") {
+		t.Fatalf("expected new instruction file to be loaded, got %q", got)
+	}
+}
+
 func mustWriteText(t *testing.T, path, content string) {
 	t.Helper()
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
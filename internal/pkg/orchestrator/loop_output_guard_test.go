@@ -0,0 +1,86 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/guard"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestRunAppliesOutputGuardRewritesBeforeToolsRun(t *testing.T) {
+	first := toolUseResponse("tool-1", "count", map[string]any{"n": 1})
+	first.Content = append([]llm.ContentBlock{{Type: llm.ContentTypeText, Text: "counting"}}, first.Content...)
+	first.Content = append(first.Content, llm.ContentBlock{Type: llm.ContentTypeToolUse, ID: "tool-2", Name: "count", Input: map[string]any{"n": 2}})
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		first,
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	counter := &countTool{}
+	registry.MustRegister(counter)
+
+	var verdicts []guard.Verdict
+	rewrite := guard.Func(func(_ context.Context, out guard.Output) (guard.Verdict, error) {
+		if len(out.ToolCalls) == 0 {
+			return guard.Allow(), nil
+		}
+		return guard.Verdict{
+			Action: guard.ActionRewrite,
+			Output: guard.Output{
+				Text:      "counting once",
+				ToolCalls: []guard.ToolCall{{ID: "tool-2", Name: "count", Input: map[string]any{"n": 3}}},
+			},
+		}, nil
+	})
+	_, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+		OutputGuard:     rewrite,
+		OnOutputGuard:   func(v guard.Verdict) { verdicts = append(verdicts, v) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(counter.calls) != 1 || fmt.Sprint(counter.calls[0]["n"]) != "3" {
+		t.Fatalf("expected only the rewritten tool call to run, got %v", counter.calls)
+	}
+	if len(verdicts) != 1 {
+		t.Fatalf("expected one guard callback, got %d", len(verdicts))
+	}
+	committed := provider.requests[1].Messages[1]
+	if committed.GetText() != "counting once" || len(committed.GetToolUses()) != 1 {
+		t.Fatalf("expected the rewritten message in history, got %#v", committed.Content)
+	}
+}
+
+func TestRunStopsWhenOutputGuardBlocks(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "count", map[string]any{"n": 1}),
+	}}
+	registry := tools.NewRegistry()
+	counter := &countTool{}
+	registry.MustRegister(counter)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+		OutputGuard: guard.Named("policy", guard.Func(func(context.Context, guard.Output) (guard.Verdict, error) {
+			return guard.Block("no counting"), nil
+		})),
+	})
+	var blocked *guard.BlockedError
+	if !errors.As(err, &blocked) || blocked.Guard != "policy" || blocked.Reason != "no counting" {
+		t.Fatalf("expected a BlockedError, got %v", err)
+	}
+	if len(counter.calls) != 0 {
+		t.Fatalf("blocked tool calls must not run, got %v", counter.calls)
+	}
+	if got := result.FinalMessage.GetText(); got != "[output blocked by policy: no counting]" {
+		t.Fatalf("expected a placeholder final message, got %q", got)
+	}
+}
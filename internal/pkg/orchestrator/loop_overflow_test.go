@@ -0,0 +1,166 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+// overflowProvider rejects requests whose estimated size exceeds limit.
+type overflowProvider struct {
+	limit    int
+	requests []llm.AgentRequest
+}
+
+func (p *overflowProvider) Name() string {
+	return "overflow-provider"
+}
+
+func (p *overflowProvider) Call(_ context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	p.requests = append(p.requests, req)
+	if EstimateRequestTokens(req) > p.limit {
+		return llm.AgentResponse{}, fmt.Errorf("wrapped: %w", &llm.ContextOverflowError{Provider: "test", Status: 400, Message: "prompt is too long"})
+	}
+	return llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		StopReason: llm.StopReasonEndTurn,
+		Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}},
+	}, nil
+}
+
+func overflowHistory(toolResultSize int) []llm.Message {
+	history := []llm.Message{llm.NewTextMessage(llm.RoleUser, "task")}
+	for i := 0; i < 4; i++ {
+		id := fmt.Sprintf("tool-%d", i)
+		history = append(history,
+			llm.Message{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeToolUse, ID: id, Name: "read_file"}}},
+			llm.NewToolResultMessage(id, strings.Repeat("x", toolResultSize), false),
+		)
+	}
+	return history
+}
+
+func TestRunRetriesAfterContextOverflowByElidingToolResults(t *testing.T) {
+	provider := &overflowProvider{limit: 500}
+
+	result, err := NewAgentLoop(provider, nil).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: overflowHistory(1000),
+		MaxMessages:     50,
+	})
+	if err != nil {
+		t.Fatalf("expected run to recover from overflow, got %v", err)
+	}
+	if len(provider.requests) < 2 {
+		t.Fatalf("expected a retry, got %d request(s)", len(provider.requests))
+	}
+
+	last := provider.requests[len(provider.requests)-1]
+	if err := validateToolPairs(last.Messages); err != nil {
+		t.Fatalf("retried request has broken tool pairs: %v", err)
+	}
+	countElided := func(messages []llm.Message) int {
+		elided := 0
+		for _, msg := range messages {
+			for _, block := range msg.Content {
+				if block.Content == elidedToolResultText {
+					elided++
+				}
+			}
+		}
+		return elided
+	}
+	if countElided(provider.requests[1].Messages) == 0 {
+		t.Fatalf("expected old tool results to be elided in the first retry")
+	}
+	if n := countElided(result.Messages); n != 0 {
+		t.Fatalf("expected the stored history to keep every tool result, %d were elided", n)
+	}
+}
+
+// lengthLimitedProvider rejects requests with more than limit messages.
+type lengthLimitedProvider struct {
+	limit    int
+	requests []llm.AgentRequest
+}
+
+func (p *lengthLimitedProvider) Name() string {
+	return "length-limited-provider"
+}
+
+func (p *lengthLimitedProvider) Call(_ context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	p.requests = append(p.requests, req)
+	if len(req.Messages) > p.limit {
+		return llm.AgentResponse{}, &llm.ContextOverflowError{Provider: "test", Status: 400, Message: "prompt is too long"}
+	}
+	return llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		StopReason: llm.StopReasonEndTurn,
+		Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}},
+	}, nil
+}
+
+func TestRunOverflowCompactionLeavesHistoryUnchanged(t *testing.T) {
+	history := []llm.Message{llm.NewTextMessage(llm.RoleUser, "task")}
+	for i := 0; i < 4; i++ {
+		history = append(history,
+			llm.NewTextMessage(llm.RoleAssistant, fmt.Sprintf("step %d", i)),
+			llm.NewTextMessage(llm.RoleUser, fmt.Sprintf("continue %d", i)),
+		)
+	}
+	provider := &lengthLimitedProvider{limit: 5}
+
+	result, err := NewAgentLoop(provider, nil).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: history,
+		MaxMessages:     50,
+		CompactConfig:   CompactConfig{Enabled: true, Threshold: 100, KeepRecent: 2},
+	})
+	if err != nil {
+		t.Fatalf("expected run to recover from overflow, got %v", err)
+	}
+	last := provider.requests[len(provider.requests)-1]
+	if len(last.Messages) > provider.limit {
+		t.Fatalf("expected a compacted request, got %d messages", len(last.Messages))
+	}
+	if len(result.Messages) != len(history)+1 {
+		t.Fatalf("expected the stored history to keep all %d messages plus the reply, got %d", len(history), len(result.Messages))
+	}
+	for i, msg := range history {
+		if result.Messages[i].Content[0].Text != msg.Content[0].Text {
+			t.Fatalf("message %d changed: %+v", i, result.Messages[i])
+		}
+	}
+}
+
+func TestRunSurfacesContextOverflowAfterBoundedRetries(t *testing.T) {
+	provider := &overflowProvider{limit: 1}
+
+	_, err := NewAgentLoop(provider, nil).Run(context.Background(), OrchestratorRequest{
+		InitialMessages:    overflowHistory(100),
+		MaxMessages:        50,
+		MaxOverflowRetries: 2,
+	})
+	if !llm.IsContextOverflow(err) {
+		t.Fatalf("expected context overflow error, got %v", err)
+	}
+	if len(provider.requests) != 3 {
+		t.Fatalf("expected 1 call + 2 retries, got %d", len(provider.requests))
+	}
+}
+
+func TestRunOverflowRetriesDisabled(t *testing.T) {
+	provider := &overflowProvider{limit: 1}
+
+	_, err := NewAgentLoop(provider, nil).Run(context.Background(), OrchestratorRequest{
+		InitialMessages:    overflowHistory(100),
+		MaxOverflowRetries: -1,
+	})
+	if !llm.IsContextOverflow(err) {
+		t.Fatalf("expected context overflow error, got %v", err)
+	}
+	if len(provider.requests) != 1 {
+		t.Fatalf("expected no retries, got %d requests", len(provider.requests))
+	}
+}
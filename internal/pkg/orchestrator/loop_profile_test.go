@@ -0,0 +1,72 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestRunRecordsProfilePerIteration(t *testing.T) {
+	first := toolUseResponse("tool-1", "count", map[string]any{"n": 1})
+	first.Usage = llm.Usage{InputTokens: 100, OutputTokens: 10}
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		first,
+		{
+			Role:       llm.RoleAssistant,
+			StopReason: llm.StopReasonEndTurn,
+			Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}},
+			Usage:      llm.Usage{InputTokens: 150, OutputTokens: 5},
+		},
+	}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(&countTool{})
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+		Profile:         true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	profile := result.Profile
+	if profile == nil || len(profile.Iterations) != 2 {
+		t.Fatalf("expected a profile with 2 iterations, got %#v", profile)
+	}
+	it1, it2 := profile.Iterations[0], profile.Iterations[1]
+	if it1.Iteration != 1 || it1.InputTokens != 100 || it1.OutputTokens != 10 {
+		t.Fatalf("unexpected first iteration: %#v", it1)
+	}
+	if len(it1.Tools) != 1 || it1.Tools[0].Name != "count" || it1.Tools[0].IsError {
+		t.Fatalf("expected the count call in the first iteration, got %#v", it1.Tools)
+	}
+	if it2.InputTokens != 150 || len(it2.Tools) != 0 {
+		t.Fatalf("unexpected second iteration: %#v", it2)
+	}
+	if it1.ContextMessages != 1 || it2.ContextMessages != 3 || it2.ContextTokens <= it1.ContextTokens {
+		t.Fatalf("expected the context to grow, got %d/%d msgs and %d/%d tokens",
+			it1.ContextMessages, it2.ContextMessages, it1.ContextTokens, it2.ContextTokens)
+	}
+	if profile.TotalDuration < it1.Duration+it2.Duration || profile.ProviderLatency != it1.ProviderLatency+it2.ProviderLatency {
+		t.Fatalf("totals do not add up: %#v", profile)
+	}
+}
+
+func TestRunWithoutProfileLeavesProfileNil(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	result, err := NewAgentLoop(provider, tools.NewRegistry()).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "hi")},
+		MaxMessages:     50,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Profile != nil {
+		t.Fatalf("expected no profile, got %#v", result.Profile)
+	}
+}
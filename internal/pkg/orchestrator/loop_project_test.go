@@ -0,0 +1,99 @@
+package orchestrator
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/skills"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestRunAppliesProjectConfig(t *testing.T) {
+	repo := t.TempDir()
+	mustMkdirAll(t, filepath.Join(repo, ".git"))
+	mustMkdirAll(t, filepath.Join(repo, ".agents"))
+	api := filepath.Join(repo, "services", "api")
+	mustMkdirAll(t, api)
+	mustMkdirAll(t, filepath.Join(repo, "apps", "web"))
+	mustWriteText(t, filepath.Join(repo, ".agents", "config.yaml"), `
+denied_tools: [bash]
+roots:
+  - name: api
+    path: services/api
+    description: Go HTTP API
+    instructions: [RULES.md]
+    skills: [style]
+  - name: web
+    path: apps/web
+`)
+	mustWriteText(t, filepath.Join(api, "RULES.md"), "api rules")
+	mustWriteText(t, filepath.Join(api, "AGENTS.md"), "ignored rules")
+
+	skillsDir := filepath.Join(t.TempDir(), "skills")
+	mustMkdirAll(t, filepath.Join(skillsDir, "style"))
+	mustWriteText(t, filepath.Join(skillsDir, "style", "SKILL.md"), `---
+name: style
+description: style guide
+---
+Use tabs.`)
+	t.Setenv(skills.SkillDirsEnv, skillsDir)
+
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(namedTool{name: "bash"})
+	registry.MustRegister(namedTool{name: "read_file"})
+	toolCtx := tools.NewToolContext(api)
+
+	_, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "hi")},
+		WorkDir:         api,
+		ToolContext:     toolCtx,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	system := provider.requests[0].System
+	for _, want := range []string{"api rules", "- api: services/api (current) - Go HTTP API", "- web: apps/web", "Use tabs."} {
+		if !strings.Contains(system, want) {
+			t.Fatalf("expected system prompt to contain %q, got: %q", want, system)
+		}
+	}
+	if strings.Contains(system, "ignored rules") {
+		t.Fatalf("expected project instruction files to replace the defaults, got: %q", system)
+	}
+	if tools := provider.requests[0].Tools; len(tools) != 1 || tools[0].Name != "read_file" {
+		t.Fatalf("expected only read_file to be offered, got %#v", tools)
+	}
+	if !slices.Contains(toolCtx.AllowedRoots, filepath.Join(repo, "apps", "web")) {
+		t.Fatalf("expected web root to be allowed, got %v", toolCtx.AllowedRoots)
+	}
+}
+
+func TestRunIgnoresProjectConfigWhenDisabled(t *testing.T) {
+	repo := t.TempDir()
+	mustMkdirAll(t, filepath.Join(repo, ".git"))
+	mustMkdirAll(t, filepath.Join(repo, ".agents"))
+	mustWriteText(t, filepath.Join(repo, ".agents", "config.yaml"), "roots:\n  - {name: api, path: api}\n")
+
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	_, err := NewAgentLoop(provider, tools.NewRegistry()).Run(context.Background(), OrchestratorRequest{
+		InitialMessages:      []llm.Message{llm.NewTextMessage(llm.RoleUser, "hi")},
+		WorkDir:              repo,
+		DisableProjectConfig: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(provider.requests[0].System, "- api: api") {
+		t.Fatalf("expected no project section, got: %q", provider.requests[0].System)
+	}
+}
@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+type envDumpTool struct{}
+
+func (envDumpTool) Name() string {
+	return "read_env"
+}
+
+func (envDumpTool) Description() string {
+	return "returns a fake .env file"
+}
+
+func (envDumpTool) InputSchema() map[string]any {
+	return map[string]any{"type": "object"}
+}
+
+func (envDumpTool) Execute(_ context.Context, _ *tools.ToolContext, _ map[string]any) (tools.ToolResult, error) {
+	return tools.NewToolResult("OPENAI_API_KEY=sk-proj-abcdefghijklmnopqrstuvwx\nDEBUG=true"), nil
+}
+
+func TestRunRedactsToolResultsBeforeContext(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "read_env", map[string]any{}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(envDumpTool{})
+
+	var callbackContent string
+	_, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "read .env")},
+		MaxMessages:     50,
+		Redactor:        redact.New(redact.Config{}),
+		OnToolResult: func(_ string, result tools.ToolResult) {
+			callbackContent = result.Content
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(callbackContent, "sk-proj-") {
+		t.Fatalf("tool result callback leaked secret: %q", callbackContent)
+	}
+	last := provider.requests[len(provider.requests)-1]
+	for _, msg := range last.Messages {
+		for _, block := range msg.Content {
+			if strings.Contains(block.Content, "sk-proj-") {
+				t.Fatalf("secret reached provider context: %q", block.Content)
+			}
+		}
+	}
+	if !strings.Contains(callbackContent, "DEBUG=true") {
+		t.Fatalf("expected non-secret content to be kept, got %q", callbackContent)
+	}
+}
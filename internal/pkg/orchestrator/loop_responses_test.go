@@ -0,0 +1,129 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestRunRecordsResponseMetadata(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		case 2:
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+		case 3:
+			resp := toolUseResponse("tool-1", "count", map[string]any{})
+			resp.ID, resp.Model = "msg_1", "claude-test"
+			json.NewEncoder(w).Encode(resp)
+		default:
+			json.NewEncoder(w).Encode(llm.AgentResponse{
+				ID:         "msg_2",
+				Model:      "claude-test",
+				Role:       llm.RoleAssistant,
+				StopReason: llm.StopReasonEndTurn,
+				Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}},
+			})
+		}
+	}))
+	defer server.Close()
+
+	provider := llm.NewClaudeProvider(llm.LLMProviderConfig{BaseURL: server.URL, APIKey: "k", Model: "m", MaxAttempts: 3})
+	provider.Sleep = func(time.Duration) {}
+
+	registry := tools.NewRegistry()
+	registry.Register(&countTool{})
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Provider != "claude" {
+		t.Fatalf("expected provider claude, got %q", result.Provider)
+	}
+	want := []ResponseRecord{
+		{Iteration: 1, ID: "msg_1", Model: "claude-test", StopReason: llm.StopReasonToolUse, Retries: 2, RateLimitedRetries: 1},
+		{Iteration: 2, ID: "msg_2", Model: "claude-test", StopReason: llm.StopReasonEndTurn},
+	}
+	if len(result.Responses) != len(want) {
+		t.Fatalf("expected %d responses, got %+v", len(want), result.Responses)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(result.Responses[i], want[i]) {
+			t.Fatalf("response %d = %+v, want %+v", i, result.Responses[i], want[i])
+		}
+	}
+}
+
+// writeFileStub records write_file calls instead of writing.
+type writeFileStub struct {
+	calls []map[string]any
+}
+
+func (t *writeFileStub) Name() string        { return "write_file" }
+func (t *writeFileStub) Description() string { return "records writes" }
+
+func (t *writeFileStub) InputSchema() map[string]any {
+	return map[string]any{"type": "object"}
+}
+
+func (t *writeFileStub) Execute(_ context.Context, _ *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	t.calls = append(t.calls, input)
+	return tools.NewToolResult("written"), nil
+}
+
+func TestRunDoesNotExecuteTruncatedToolArguments(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/event-stream")
+		if calls == 1 {
+			// The stream ends after "path", before the file's content.
+			_, _ = w.Write([]byte(`data: {"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"write_file","arguments":"{\"path\":\"main.go\","}}]}}]}` + "\n\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`data: {"id":"c2","choices":[{"index":0,"delta":{"content":"done"},"finish_reason":"stop"}]}` + "\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(llm.LLMProviderConfig{BaseURL: server.URL, APIKey: "k", Model: "m"})
+	registry := tools.NewRegistry()
+	writer := &writeFileStub{}
+	registry.MustRegister(writer)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "write main.go")},
+		EnableStreaming: true,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(writer.calls) != 0 {
+		t.Fatalf("expected the truncated write_file call not to run, got %+v", writer.calls)
+	}
+	var answered bool
+	for _, msg := range result.Messages {
+		for _, block := range msg.Content {
+			if block.Type == llm.ContentTypeToolResult && block.ToolUseID == "call_1" {
+				answered = block.IsError && strings.Contains(block.Content, "truncated")
+			}
+		}
+	}
+	if !answered {
+		t.Fatalf("expected an error result for the truncated call, got %+v", result.Messages)
+	}
+}
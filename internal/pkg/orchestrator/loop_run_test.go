@@ -97,3 +97,62 @@ func TestRunNoIterationCapWhenMaxIterationsNonPositive(t *testing.T) {
 		t.Fatalf("expected provider call count %d, got %d", wantIterations, provider.callCount)
 	}
 }
+
+func TestRunContinuesPausedServerToolTurn(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{
+			Role:       llm.RoleAssistant,
+			StopReason: llm.StopReasonPauseTurn,
+			Content: []llm.ContentBlock{
+				{Type: llm.ContentTypeServerToolUse, ID: "srvtoolu_1", Name: "web_search", Input: map[string]any{"query": "go"}},
+			},
+		},
+		{
+			Role:       llm.RoleAssistant,
+			StopReason: llm.StopReasonEndTurn,
+			Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "found it"}},
+		},
+	}}
+	webSearch := llm.ServerTool{Type: "web_search_20250305", Name: "web_search"}
+
+	loop := NewAgentLoop(provider, tools.NewRegistry())
+	result, err := loop.Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "search")},
+		MaxIterations:   5,
+		ServerTools:     []llm.ServerTool{webSearch},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.GetFinalText() != "found it" || len(provider.requests) != 2 {
+		t.Fatalf("expected the paused turn to continue, got %q after %d calls", result.GetFinalText(), len(provider.requests))
+	}
+	second := provider.requests[1]
+	if last := second.Messages[len(second.Messages)-1]; last.Role != llm.RoleAssistant || last.Content[0].Type != llm.ContentTypeServerToolUse {
+		t.Fatalf("expected the paused assistant turn to be resent, got %#v", last)
+	}
+	if len(second.ServerTools) != 1 || second.ServerTools[0].Name != "web_search" {
+		t.Fatalf("expected server tools on every request, got %#v", second.ServerTools)
+	}
+	if len(result.ToolCalls) != 0 {
+		t.Fatalf("server tool calls must not run locally, got %#v", result.ToolCalls)
+	}
+}
+
+func TestRunSendsRequestModel(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+
+	_, err := NewAgentLoop(provider, tools.NewRegistry()).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "hi")},
+		MaxMessages:     50,
+		Model:           "gpt-4.1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := provider.requests[0].Model; got != "gpt-4.1" {
+		t.Fatalf("expected model gpt-4.1, got %q", got)
+	}
+}
@@ -1,7 +1,9 @@
 package orchestrator
 
 import (
+	"context"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -26,7 +28,7 @@ Deploy target: $ARGUMENTS`)
 		llm.NewTextMessage(llm.RoleUser, "/deploy staging"),
 	})
 	toolCtx := tools.NewToolContext(root)
-	applied, err := applySlashSkillInvocation(state, toolCtx, root)
+	applied, err := applySlashSkillInvocation(state, toolCtx, root, nil)
 	if err != nil {
 		t.Fatalf("applySlashSkillInvocation() error = %v", err)
 	}
@@ -50,7 +52,7 @@ func TestApplySlashSkillInvocationIgnoresUnknownCommand(t *testing.T) {
 	})
 	toolCtx := tools.NewToolContext(root)
 
-	applied, err := applySlashSkillInvocation(state, toolCtx, root)
+	applied, err := applySlashSkillInvocation(state, toolCtx, root, nil)
 	if err != nil {
 		t.Fatalf("applySlashSkillInvocation() error = %v", err)
 	}
@@ -59,6 +61,49 @@ func TestApplySlashSkillInvocationIgnoresUnknownCommand(t *testing.T) {
 	}
 }
 
+func TestApplySlashSkillInvocationPrefersCommands(t *testing.T) {
+	root := t.TempDir()
+	skillsDir := filepath.Join(root, "plugin-skills")
+	mustMkdirAll(t, filepath.Join(skillsDir, "deploy"))
+	mustWriteText(t, filepath.Join(skillsDir, "deploy", "SKILL.md"), `---
+name: deploy
+---
+Skill deploy: $ARGUMENTS`)
+	mustMkdirAll(t, filepath.Join(skillsDir, "triage"))
+	mustWriteText(t, filepath.Join(skillsDir, "triage", "SKILL.md"), `---
+name: triage
+---
+Triage issue: $ARGUMENTS`)
+
+	t.Setenv(skills.SkillDirsEnv, filepath.Join(root, "none"))
+	commands := []SlashCommand{{Name: "deploy", Prompt: "Command deploy: $ARGUMENTS"}}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"/deploy staging", "Command deploy: staging"},
+		{"/Deploy prod", "Command deploy: prod"},
+		{"/triage 42", "Triage issue: 42"},
+	}
+	for _, tt := range tests {
+		state := NewState([]llm.Message{llm.NewTextMessage(llm.RoleUser, tt.input)})
+		toolCtx := tools.NewToolContext(root)
+		toolCtx.SkillDirs = []string{skillsDir}
+
+		applied, err := applySlashSkillInvocation(state, toolCtx, root, commands)
+		if err != nil {
+			t.Fatalf("%s: applySlashSkillInvocation() error = %v", tt.input, err)
+		}
+		if !applied {
+			t.Fatalf("%s: expected slash invocation to be applied", tt.input)
+		}
+		if got := state.Messages[0].GetText(); !strings.Contains(got, tt.want) {
+			t.Fatalf("%s: expected %q in message, got %q", tt.input, tt.want, got)
+		}
+	}
+}
+
 func TestEnsureToolAllowedByActiveSkill(t *testing.T) {
 	toolCtx := tools.NewToolContext(t.TempDir())
 	toolCtx.WithEnv(skills.EnvActiveSkillName, "deploy")
@@ -147,3 +192,76 @@ func TestSummarizeSkillDiscoveryByDirIncludesUnmatchedAndZeroCount(t *testing.T)
 		t.Fatalf("unexpected unmatched entry: %+v", entries[2])
 	}
 }
+
+type recordingUseSkill struct {
+	inputs []map[string]any
+}
+
+func (t *recordingUseSkill) Name() string                { return "use_skill" }
+func (t *recordingUseSkill) Description() string         { return "records skill invocations" }
+func (t *recordingUseSkill) InputSchema() map[string]any { return map[string]any{"type": "object"} }
+
+func (t *recordingUseSkill) Execute(_ context.Context, _ *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	t.inputs = append(t.inputs, input)
+	return tools.NewToolResult("skill loaded"), nil
+}
+
+func TestRunOffersSkillsAsTools(t *testing.T) {
+	root := t.TempDir()
+	skillsDir := filepath.Join(root, "skills")
+	mustMkdirAll(t, filepath.Join(skillsDir, "deploy"))
+	mustWriteText(t, filepath.Join(skillsDir, "deploy", "SKILL.md"), `---
+name: deploy
+description: deploy helper
+arguments:
+  - env: string, required
+---
+Deploy ${ARG_ENV}`)
+	mustMkdirAll(t, filepath.Join(skillsDir, "hidden"))
+	mustWriteText(t, filepath.Join(skillsDir, "hidden", "SKILL.md"), `---
+name: hidden
+disable-model-invocation: true
+---
+Hidden`)
+	t.Setenv(skills.SkillDirsEnv, skillsDir)
+
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "skill__deploy", map[string]any{"env": "prod"}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	useSkill := &recordingUseSkill{}
+	registry := tools.NewRegistry()
+	registry.MustRegister(useSkill)
+
+	_, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "ship it")},
+		MaxMessages:     50,
+		WorkDir:         root,
+		SkillTools:      SkillToolsOnly,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var names []string
+	var deploy llm.ToolDefinition
+	for _, def := range provider.requests[0].Tools {
+		names = append(names, def.Name)
+		if def.Name == "skill__deploy" {
+			deploy = def
+		}
+	}
+	if !reflect.DeepEqual(names, []string{"use_skill", "skill__deploy"}) {
+		t.Fatalf("tools = %v", names)
+	}
+	if required, _ := deploy.InputSchema["required"].([]string); !reflect.DeepEqual(required, []string{"env"}) {
+		t.Fatalf("expected the argument schema, got %v", deploy.InputSchema)
+	}
+	if strings.Contains(provider.requests[0].System, "`deploy`") {
+		t.Fatal("expected SkillToolsOnly to leave out the skills prompt block")
+	}
+	want := []map[string]any{{"name": "deploy", "arguments": map[string]any{"env": "prod"}}}
+	if !reflect.DeepEqual(useSkill.inputs, want) {
+		t.Fatalf("use_skill inputs = %v, want %v", useSkill.inputs, want)
+	}
+}
@@ -0,0 +1,99 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+type chunkTool struct {
+	chunks []string
+	final  string
+}
+
+func (chunkTool) Name() string {
+	return "chunks"
+}
+
+func (chunkTool) Description() string {
+	return "writes output incrementally"
+}
+
+func (chunkTool) InputSchema() map[string]any {
+	return map[string]any{"type": "object"}
+}
+
+func (t chunkTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	return t.ExecuteStream(ctx, toolCtx, input, io.Discard)
+}
+
+func (t chunkTool) ExecuteStream(_ context.Context, _ *tools.ToolContext, _ map[string]any, w io.Writer) (tools.ToolResult, error) {
+	for _, chunk := range t.chunks {
+		_, _ = io.WriteString(w, chunk)
+	}
+	return tools.NewToolResult(t.final), nil
+}
+
+func runChunkTool(t *testing.T, tool chunkTool, redactor *redact.Redactor) ([]string, tools.ToolResult) {
+	t.Helper()
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "chunks", map[string]any{}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(tool)
+
+	var chunks []string
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "run")},
+		Redactor:        redactor,
+		OnToolOutput: func(name, toolCallID, chunk string) {
+			if name != "chunks" || toolCallID != "tool-1" {
+				t.Errorf("unexpected tool output source %s/%s", name, toolCallID)
+			}
+			chunks = append(chunks, chunk)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(result.ToolCalls))
+	}
+	return chunks, result.ToolCalls[0].Result
+}
+
+func TestRunForwardsStreamingToolOutput(t *testing.T) {
+	chunks, result := runChunkTool(t, chunkTool{chunks: []string{"line 1\n", "line 2\n"}}, nil)
+
+	if len(chunks) != 2 || chunks[0] != "line 1\n" || chunks[1] != "line 2\n" {
+		t.Fatalf("unexpected chunks: %q", chunks)
+	}
+	if result.Content != "line 1\nline 2\n" {
+		t.Fatalf("expected assembled output as result, got %q", result.Content)
+	}
+}
+
+func TestRunStreamingToolFinalResultWins(t *testing.T) {
+	_, result := runChunkTool(t, chunkTool{chunks: []string{"progress"}, final: "summary"}, nil)
+
+	if result.Content != "summary" {
+		t.Fatalf("expected explicit final result, got %q", result.Content)
+	}
+}
+
+func TestRunRedactsSecretsSplitAcrossToolOutputChunks(t *testing.T) {
+	chunks, _ := runChunkTool(t, chunkTool{
+		chunks: []string{"DB_PASSWORD=correct", "horsebattery", "staple\nnext ", "line"},
+	}, redact.New(redact.Config{}))
+
+	want := []string{"DB_PASSWORD=[REDACTED:secret_assignment]\n", "next line"}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected whole redacted lines %q, got %q", want, chunks)
+	}
+}
@@ -104,6 +104,31 @@ func TestTruncateMessagesKeepsFirstMessage(t *testing.T) {
 	}
 }
 
+func TestTruncateMessagesPreservesMetadata(t *testing.T) {
+	messages := make([]llm.Message, 20)
+	messages[0] = llm.NewTextMessage(llm.RoleUser, "Initial prompt").WithMetadata("origin", "task")
+	for i := 1; i < 20; i++ {
+		if i%2 == 1 {
+			messages[i] = llm.NewTextMessage(llm.RoleAssistant, "Response")
+		} else {
+			messages[i] = llm.NewTextMessage(llm.RoleUser, "Follow up").WithMetadata("index", i)
+		}
+	}
+
+	result := truncateMessages(messages, 10)
+
+	if result[0].Metadata["origin"] != "task" {
+		t.Errorf("first message metadata was not preserved: %#v", result[0].Metadata)
+	}
+	last := result[len(result)-1]
+	if last.Metadata != nil {
+		t.Errorf("expected assistant message without metadata, got %#v", last.Metadata)
+	}
+	if got := result[len(result)-2].Metadata["index"]; got != 18 {
+		t.Errorf("expected recent message metadata index=18, got %#v", got)
+	}
+}
+
 func TestTruncateMessagesNestedDependencies(t *testing.T) {
 	// Test case: nested tool pairs where truncation initially would break a pair,
 	// and including that pair exposes another broken pair.
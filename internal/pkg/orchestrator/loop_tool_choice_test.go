@@ -0,0 +1,59 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestRunForcedToolChoiceRelaxesAfterCall(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("t1", "count", map[string]any{"n": 1}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(&countTool{})
+
+	_, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+		ToolChoice:      &llm.ToolChoice{Type: llm.ToolChoiceTool, Name: "count"},
+		Deterministic:   true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, second := provider.requests[0].ToolChoice, provider.requests[1].ToolChoice
+	if first == nil || first.Type != llm.ToolChoiceTool || first.Name != "count" || !first.DisableParallelToolUse {
+		t.Fatalf("unexpected first tool choice: %#v", first)
+	}
+	if second == nil || second.Type != llm.ToolChoiceAuto || second.Name != "" || !second.DisableParallelToolUse {
+		t.Fatalf("expected auto after the forced call, got %#v", second)
+	}
+}
+
+func TestRunRejectsInvalidToolChoice(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(&countTool{})
+
+	for _, choice := range []*llm.ToolChoice{
+		{Type: llm.ToolChoiceTool, Name: "missing"},
+		{Type: llm.ToolChoiceTool},
+		{Type: "sometimes"},
+	} {
+		provider := &scriptedProvider{}
+		_, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+			InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+			ToolChoice:      choice,
+		})
+		if err == nil {
+			t.Fatalf("expected an error for %#v", choice)
+		}
+		if len(provider.requests) != 0 {
+			t.Fatalf("expected no provider call for %#v", choice)
+		}
+	}
+}
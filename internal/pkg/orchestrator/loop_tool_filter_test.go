@@ -0,0 +1,55 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// namedTool is a no-op tool registered under an arbitrary name.
+type namedTool struct{ name string }
+
+func (t namedTool) Name() string                { return t.name }
+func (t namedTool) Description() string         { return "no-op" }
+func (t namedTool) InputSchema() map[string]any { return map[string]any{"type": "object"} }
+func (t namedTool) Execute(context.Context, *tools.ToolContext, map[string]any) (tools.ToolResult, error) {
+	return tools.NewToolResult("ok"), nil
+}
+
+func TestRunFiltersToolsByAllowedAndDeniedPatterns(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "bash", map[string]any{}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	for _, name := range []string{"bash", "read_file", "mcp__github__search", "mcp__github__delete_repo", "mcp__jira__search"} {
+		registry.MustRegister(namedTool{name: name})
+	}
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "search")},
+		MaxMessages:     50,
+		AllowedTools:    []string{"read", "mcp__github"},
+		DeniedTools:     []string{"mcp__*__delete_*"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var offered []string
+	for _, def := range provider.requests[0].Tools {
+		offered = append(offered, def.Name)
+	}
+	if got := strings.Join(offered, ","); got != "mcp__github__search,read_file" {
+		t.Fatalf("unexpected offered tools: %s", got)
+	}
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call record, got %d", len(result.ToolCalls))
+	}
+	if res := result.ToolCalls[0].Result; !res.IsError || !strings.Contains(res.Content, "not allowed") {
+		t.Fatalf("expected disallowed tool error, got %#v", res)
+	}
+}
@@ -0,0 +1,99 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// flakyTool fails with a retryable timeout until it has been called
+// failures times.
+type flakyTool struct {
+	failures int
+	calls    int
+}
+
+func (t *flakyTool) Name() string {
+	return "flaky"
+}
+
+func (t *flakyTool) Description() string {
+	return "fails with a timeout a few times"
+}
+
+func (t *flakyTool) InputSchema() map[string]any {
+	return map[string]any{"type": "object"}
+}
+
+func (t *flakyTool) Execute(_ context.Context, _ *tools.ToolContext, _ map[string]any) (tools.ToolResult, error) {
+	t.calls++
+	if t.calls <= t.failures {
+		return tools.NewErrorResultf("timed out").WithErrorCode(tools.ErrCodeTimeout, true), nil
+	}
+	return tools.NewToolResult("ok"), nil
+}
+
+func runFlakyTool(t *testing.T, tool *flakyTool, retry ToolRetryConfig) tools.ToolResult {
+	t.Helper()
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "flaky", map[string]any{}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(tool)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "go")},
+		MaxMessages:     50,
+		ToolRetry:       retry,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call record, got %d", len(result.ToolCalls))
+	}
+	return result.ToolCalls[0].Result
+}
+
+func TestRunRetriesRetryableToolFailures(t *testing.T) {
+	tool := &flakyTool{failures: 2}
+	result := runFlakyTool(t, tool, ToolRetryConfig{MaxRetries: 3, Delay: time.Millisecond})
+
+	if tool.calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", tool.calls)
+	}
+	if result.IsError || result.Content != "ok" {
+		t.Fatalf("expected success after retries, got %#v", result)
+	}
+	if result.Details["attempts"] != 3 {
+		t.Fatalf("expected attempts=3, got %#v", result.Details)
+	}
+}
+
+func TestRunReturnsRetryableFailureAfterMaxRetries(t *testing.T) {
+	tool := &flakyTool{failures: 5}
+	result := runFlakyTool(t, tool, ToolRetryConfig{MaxRetries: 1, Delay: time.Millisecond})
+
+	if tool.calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", tool.calls)
+	}
+	if !result.IsError || result.ErrorCode != tools.ErrCodeTimeout || !result.Retryable {
+		t.Fatalf("expected retryable timeout error, got %#v", result)
+	}
+}
+
+func TestRunDoesNotRetryWithoutToolRetry(t *testing.T) {
+	tool := &flakyTool{failures: 1}
+	result := runFlakyTool(t, tool, ToolRetryConfig{})
+
+	if tool.calls != 1 {
+		t.Fatalf("expected 1 call, got %d", tool.calls)
+	}
+	if !result.IsError || result.Details["attempts"] != nil {
+		t.Fatalf("expected unretried error, got %#v", result)
+	}
+}
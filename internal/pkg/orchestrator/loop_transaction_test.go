@@ -0,0 +1,114 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/tools/builtin"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
+)
+
+func TestRunTransactionalRecordsAndRollsBackWrites(t *testing.T) {
+	workDir := t.TempDir()
+	target := filepath.Join(workDir, "main.go")
+	if err := os.WriteFile(target, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "write_file", map[string]any{"path": "main.go", "content": "broken"}),
+		toolUseResponse("tool-2", "rollback_last_changes", map[string]any{}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	builtin.RegisterFileTools(registry)
+	builtin.RegisterWorkspaceTools(registry)
+
+	journal := workspace.NewJournal(workDir)
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "edit")},
+		WorkDir:         workDir,
+		Journal:         journal,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 2 || result.ToolCalls[1].Result.IsError {
+		t.Fatalf("unexpected tool calls: %+v", result.ToolCalls)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "package main\n" {
+		t.Fatalf("expected write to be rolled back, got %q", data)
+	}
+	if journal.Len() != 0 {
+		t.Fatalf("expected journal to be empty after rollback, got %d", journal.Len())
+	}
+}
+
+func TestRunWithoutJournalDoesNotSnapshot(t *testing.T) {
+	workDir := t.TempDir()
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "rollback_last_changes", map[string]any{}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	builtin.RegisterWorkspaceTools(registry)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "undo")},
+		WorkDir:         workDir,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 1 || !result.ToolCalls[0].Result.IsError {
+		t.Fatalf("expected rollback to fail outside transactional mode, got %+v", result.ToolCalls)
+	}
+}
+
+func TestRunTransactionalRefusesToolWhenSnapshotFails(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "data.bin"), make([]byte, 64), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "bash", map[string]any{"command": "echo x > created.txt"}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	builtin.RegisterBashTools(registry)
+
+	// bash snapshots the whole tree, which is over the cap.
+	journal := workspace.NewJournal(workDir)
+	journal.MaxSnapshotBytes = 16
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "edit")},
+		WorkDir:         workDir,
+		Journal:         journal,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(result.ToolCalls))
+	}
+	call := result.ToolCalls[0].Result
+	if !call.IsError || !strings.Contains(call.Content, workspace.ErrSnapshotTooLarge.Error()) {
+		t.Fatalf("expected the snapshot failure in the tool result, got %+v", call)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "created.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected bash not to run, stat error = %v", err)
+	}
+	if journal.Len() != 0 {
+		t.Fatalf("expected no checkpoint, got %d", journal.Len())
+	}
+}
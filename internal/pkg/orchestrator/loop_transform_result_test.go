@@ -0,0 +1,44 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestRunTransformsToolResultsBeforeTheModelSeesThem(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "count", map[string]any{"n": 1}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(&countTool{})
+
+	var callbackContent string
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+		OnToolResult: func(_ string, result tools.ToolResult) {
+			callbackContent = result.Content
+		},
+		OnTransformToolResult: func(_ context.Context, name string, input map[string]any, result tools.ToolResult) tools.ToolResult {
+			result.Content = fmt.Sprintf("%s returned %s for n=%v", name, result.Content, input["n"])
+			return result
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sent := provider.requests[1].Messages
+	block := sent[len(sent)-1].Content[0]
+	if block.Type != llm.ContentTypeToolResult || block.Content != "count returned ok for n=1" {
+		t.Fatalf("expected the transformed result in the conversation, got %#v", block)
+	}
+	if callbackContent != "ok" || result.ToolCalls[0].Result.Content != "ok" {
+		t.Fatalf("expected callbacks and records to keep the original, got %q and %q", callbackContent, result.ToolCalls[0].Result.Content)
+	}
+}
@@ -0,0 +1,129 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+type scriptedProvider struct {
+	responses []llm.AgentResponse
+	requests  []llm.AgentRequest
+}
+
+func (p *scriptedProvider) Name() string {
+	return "scripted-provider"
+}
+
+func (p *scriptedProvider) Call(_ context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	p.requests = append(p.requests, req)
+	idx := len(p.requests) - 1
+	if idx >= len(p.responses) {
+		idx = len(p.responses) - 1
+	}
+	return p.responses[idx], nil
+}
+
+type countTool struct {
+	calls []map[string]any
+}
+
+func (t *countTool) Name() string {
+	return "count"
+}
+
+func (t *countTool) Description() string {
+	return "records validated input"
+}
+
+func (t *countTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"n": map[string]any{"type": "integer"},
+		},
+		"required": []string{"n"},
+	}
+}
+
+func (t *countTool) Execute(_ context.Context, _ *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	t.calls = append(t.calls, input)
+	return tools.NewToolResult("ok"), nil
+}
+
+func toolUseResponse(id, name string, input map[string]any) llm.AgentResponse {
+	return llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		StopReason: llm.StopReasonToolUse,
+		Content: []llm.ContentBlock{
+			{Type: llm.ContentTypeToolUse, ID: id, Name: name, Input: input},
+		},
+	}
+}
+
+func TestRunReturnsValidationErrorWithoutExecutingTool(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "count", map[string]any{}),
+		toolUseResponse("tool-2", "count", map[string]any{"n": "3"}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	tool := &countTool{}
+	registry := tools.NewRegistry()
+	registry.MustRegister(tool)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(tool.calls) != 1 {
+		t.Fatalf("expected tool to execute once, got %d", len(tool.calls))
+	}
+	if tool.calls[0]["n"] != float64(3) {
+		t.Fatalf("expected coerced input n=3, got %#v", tool.calls[0]["n"])
+	}
+
+	if len(result.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool call records, got %d", len(result.ToolCalls))
+	}
+	first := result.ToolCalls[0].Result
+	if !first.IsError || !strings.Contains(first.Content, "n: is required") {
+		t.Fatalf("expected validation error result, got %#v", first)
+	}
+}
+
+func TestRunAnswersUnparsableArgumentsWithoutExecutingTool(t *testing.T) {
+	truncated := toolUseResponse("tool-1", "count", nil)
+	truncated.Content[0].InputError = "arguments end inside a string value (truncated)"
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		truncated,
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	tool := &countTool{}
+	registry := tools.NewRegistry()
+	registry.MustRegister(tool)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tool.calls) != 0 {
+		t.Fatalf("expected the tool not to execute, got %d calls", len(tool.calls))
+	}
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call record, got %d", len(result.ToolCalls))
+	}
+	got := result.ToolCalls[0].Result
+	if !got.IsError || !strings.Contains(got.Content, "inside a string value") || !strings.Contains(got.Content, "complete JSON arguments") {
+		t.Fatalf("expected an argument error result, got %#v", got)
+	}
+}
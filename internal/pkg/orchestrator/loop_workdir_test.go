@@ -0,0 +1,119 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/tools/builtin"
+)
+
+func TestRunRecordsPerCallWorkDir(t *testing.T) {
+	workDir := t.TempDir()
+	for _, dir := range []string{"services/api", "services/web"} {
+		if err := os.MkdirAll(filepath.Join(workDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "change_dir", map[string]any{"path": "services/api"}),
+		toolUseResponse("tool-2", "bash", map[string]any{"command": "pwd"}),
+		toolUseResponse("tool-3", "bash", map[string]any{"command": "pwd", "cwd": "../web"}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	builtin.RegisterFileTools(registry)
+	builtin.RegisterBashTools(registry)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "work in the monorepo")},
+		WorkDir:         workDir,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 3 {
+		t.Fatalf("expected 3 tool calls, got %d", len(result.ToolCalls))
+	}
+
+	apiDir := filepath.Join(workDir, "services/api")
+	webDir := filepath.Join(workDir, "services/web")
+	wantDirs := []string{workDir, apiDir, webDir}
+	for i, call := range result.ToolCalls {
+		if call.Result.IsError {
+			t.Fatalf("tool call %d failed: %s", i, call.Result.Content)
+		}
+		if call.WorkDir != wantDirs[i] {
+			t.Errorf("tool call %d: expected WorkDir %s, got %s", i, wantDirs[i], call.WorkDir)
+		}
+	}
+	if got := strings.TrimSpace(result.ToolCalls[1].Result.Command.Stdout); got != apiDir {
+		t.Errorf("expected bash to run in %s, got %s", apiDir, got)
+	}
+	if got := strings.TrimSpace(result.ToolCalls[2].Result.Command.Stdout); got != webDir {
+		t.Errorf("expected cwd override %s, got %s", webDir, got)
+	}
+}
+
+func TestRunRejectsChangeDirOutsideWorkDir(t *testing.T) {
+	workDir := t.TempDir()
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "change_dir", map[string]any{"path": ".."}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	builtin.RegisterFileTools(registry)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "escape")},
+		WorkDir:         workDir,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 1 || !result.ToolCalls[0].Result.IsError {
+		t.Fatalf("expected change_dir outside workdir to fail, got %+v", result.ToolCalls)
+	}
+}
+
+func TestRunIgnoresCwdForToolsWithoutIt(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, "services/api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "notes.md"), []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// read_file does not take cwd; the stray value must not change the
+	// directory the call is recorded (and cached) under.
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "read_file", map[string]any{"path": "notes.md", "cwd": "services/api"}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	registry := tools.NewRegistry()
+	builtin.RegisterFileTools(registry)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "read notes")},
+		WorkDir:         workDir,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(result.ToolCalls))
+	}
+	call := result.ToolCalls[0]
+	if call.Result.IsError || call.Result.Content != "notes" {
+		t.Fatalf("expected notes.md to be read from the workdir, got %+v", call.Result)
+	}
+	if call.WorkDir != workDir {
+		t.Errorf("expected WorkDir %s, got %s", workDir, call.WorkDir)
+	}
+}
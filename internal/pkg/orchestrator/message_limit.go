@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"log"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+// providerMessageLimit returns the provider's per-request message limit, or
+// 0 when it has none.
+func providerMessageLimit(provider llm.LLMProvider) int {
+	if limiter, ok := provider.(llm.MessageLimiter); ok {
+		return limiter.MaxRequestMessages()
+	}
+	return 0
+}
+
+// limitRequestMessages keeps a request within limit messages. It first
+// merges consecutive messages of the same role, which loses nothing. If
+// the request is still too long, it drops the oldest messages after the
+// first one, and never keeps a tool_result without its tool_use.
+func limitRequestMessages(messages []llm.Message, limit int) []llm.Message {
+	if limit <= 0 || len(messages) <= limit {
+		return messages
+	}
+	merged := mergeConsecutiveRoles(messages)
+	if len(merged) <= limit {
+		log.Printf("[orchestrator] message limit %d: merged %d -> %d messages", limit, len(messages), len(merged))
+		return merged
+	}
+
+	// Keep the first message and the most recent ones after the watermark.
+	watermark := len(merged) - limit + 1
+	kept := map[string]bool{}
+	collectToolUses(kept, merged[0])
+	for _, msg := range merged[watermark:] {
+		collectToolUses(kept, msg)
+	}
+	for watermark < len(merged) && hasOrphanedToolResult(merged[watermark], kept) {
+		watermark++
+	}
+	limited := append([]llm.Message{merged[0]}, merged[watermark:]...)
+	limited = mergeConsecutiveRoles(limited)
+
+	log.Printf("[orchestrator] message limit %d: dropped %d oldest messages (%d -> %d)",
+		limit, watermark-1, len(messages), len(limited))
+	return limited
+}
+
+// mergeConsecutiveRoles joins runs of messages with the same role into one
+// message, concatenating their content. Metadata is taken from the first
+// message of each run.
+func mergeConsecutiveRoles(messages []llm.Message) []llm.Message {
+	out := make([]llm.Message, 0, len(messages))
+	for _, msg := range messages {
+		last := len(out) - 1
+		if last < 0 || out[last].Role != msg.Role {
+			out = append(out, msg)
+			continue
+		}
+		prev := &out[last]
+		prev.Content = append(append([]llm.ContentBlock(nil), prev.Content...), msg.Content...)
+		switch {
+		case prev.ReasoningContent == "":
+			prev.ReasoningContent = msg.ReasoningContent
+		case msg.ReasoningContent != "":
+			prev.ReasoningContent += "\n\n" + msg.ReasoningContent
+		}
+	}
+	return out
+}
+
+func collectToolUses(ids map[string]bool, msg llm.Message) {
+	for _, block := range msg.Content {
+		if block.Type == llm.ContentTypeToolUse && block.ID != "" {
+			ids[block.ID] = true
+		}
+	}
+}
+
+func hasOrphanedToolResult(msg llm.Message, toolUses map[string]bool) bool {
+	for _, block := range msg.Content {
+		if block.Type == llm.ContentTypeToolResult && !toolUses[block.ToolUseID] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// limitedProvider is a scriptedProvider behind a per-request message limit.
+type limitedProvider struct {
+	scriptedProvider
+	limit int
+}
+
+func (p *limitedProvider) MaxRequestMessages() int { return p.limit }
+
+func TestLimitRequestMessagesMergesBeforeDropping(t *testing.T) {
+	user := func(text string) llm.Message { return llm.NewTextMessage(llm.RoleUser, text) }
+	messages := []llm.Message{user("task"), user("steer"), llm.NewTextMessage(llm.RoleAssistant, "ok"), user("more")}
+
+	merged := limitRequestMessages(messages, 3)
+	if len(merged) != 3 || len(merged[0].Content) != 2 || merged[0].Content[1].Text != "steer" {
+		t.Fatalf("expected the two leading user messages merged, got %+v", merged)
+	}
+	if got := limitRequestMessages(messages, 0); len(got) != 4 {
+		t.Fatalf("a zero limit should leave the messages alone, got %d", len(got))
+	}
+}
+
+func TestRunKeepsRequestsWithinProviderMessageLimit(t *testing.T) {
+	provider := &limitedProvider{limit: 4, scriptedProvider: scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "count", map[string]any{"n": 1}),
+		toolUseResponse("tool-2", "count", map[string]any{"n": 2}),
+		toolUseResponse("tool-3", "count", map[string]any{"n": 3}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}}
+	registry := tools.NewRegistry()
+	registry.MustRegister(&countTool{})
+
+	_, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	last := provider.requests[len(provider.requests)-1]
+	if len(last.Messages) > 4 {
+		t.Fatalf("request has %d messages, want at most 4", len(last.Messages))
+	}
+	if last.Messages[0].GetText() != "count" {
+		t.Fatalf("expected the first message to be kept, got %+v", last.Messages[0])
+	}
+	uses := map[string]bool{}
+	for i, msg := range last.Messages {
+		if i > 0 && msg.Role == last.Messages[i-1].Role {
+			t.Fatalf("messages %d and %d share the role %s", i-1, i, msg.Role)
+		}
+		collectToolUses(uses, msg)
+		if hasOrphanedToolResult(msg, uses) {
+			t.Fatalf("message %d has a tool_result without its tool_use: %+v", i, last.Messages)
+		}
+	}
+	if !uses["tool-3"] {
+		t.Fatalf("expected the latest tool call to be kept: %+v", last.Messages)
+	}
+}
@@ -0,0 +1,202 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/encryption"
+)
+
+const (
+	defaultSpillKeepInMemory = 200
+	defaultSpillSegmentSize  = 100
+)
+
+// MessageSpillConfig configures spilling old conversation messages to disk
+// so very long runs do not keep the whole history in memory.
+type MessageSpillConfig struct {
+	// Dir is where per-run segment directories are created. Empty
+	// disables spilling.
+	Dir string
+
+	// KeepInMemory is the number of recent messages kept in memory
+	// (default 200). It is raised to MaxMessages if lower, so provider
+	// requests are unaffected.
+	KeepInMemory int
+
+	// SegmentSize is the number of messages written per segment file
+	// (default 100).
+	SegmentSize int
+
+	// Encryptor, when set, seals each segment file before it is written.
+	Encryptor *encryption.Encryptor
+}
+
+// messageSpill stores the oldest messages of a conversation (after the
+// pinned first message) in append-only JSONL segment files. Spilled
+// messages are only read back for compaction and the final result.
+type messageSpill struct {
+	ctx          context.Context
+	encryptor    *encryption.Encryptor
+	dir          string
+	keepInMemory int
+	segmentSize  int
+	segments     []string
+	count        int
+	failed       bool
+}
+
+// newMessageSpill creates the run's segment directory under cfg.Dir. ctx
+// is passed to the Encryptor's key provider.
+func newMessageSpill(ctx context.Context, cfg MessageSpillConfig, maxMessages int) (*messageSpill, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(cfg.Dir, "messages-")
+	if err != nil {
+		return nil, err
+	}
+	keep := cfg.KeepInMemory
+	if keep <= 0 {
+		keep = defaultSpillKeepInMemory
+	}
+	if keep < maxMessages {
+		keep = maxMessages
+	}
+	segmentSize := cfg.SegmentSize
+	if segmentSize <= 0 {
+		segmentSize = defaultSpillSegmentSize
+	}
+	return &messageSpill{
+		ctx:          ctx,
+		encryptor:    cfg.Encryptor,
+		dir:          dir,
+		keepInMemory: keep,
+		segmentSize:  segmentSize,
+	}, nil
+}
+
+// spillCut returns the end index of the messages to spill from messages,
+// or 0 when nothing should be spilled. Index 0 is always kept, and the
+// cut never leaves a tool_result in memory without its tool_use.
+func (s *messageSpill) spillCut(messages []llm.Message) int {
+	if len(messages)-1 <= s.keepInMemory+s.segmentSize {
+		return 0
+	}
+	cut := 1 + s.segmentSize
+	for cut < len(messages)-s.keepInMemory && hasToolResult(messages[cut]) {
+		cut++
+	}
+	if hasToolResult(messages[cut]) {
+		return 0
+	}
+	return cut
+}
+
+func hasToolResult(msg llm.Message) bool {
+	for _, block := range msg.Content {
+		if block.Type == llm.ContentTypeToolResult {
+			return true
+		}
+	}
+	return false
+}
+
+// spilledMessage is the segment file record. It keeps Metadata, which
+// llm.Message leaves out of its JSON, so e.g. compaction summaries survive.
+type spilledMessage struct {
+	llm.Message
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// write appends messages as a new segment file.
+func (s *messageSpill) write(messages []llm.Message) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, msg := range messages {
+		if err := enc.Encode(spilledMessage{Message: msg, Metadata: msg.Metadata}); err != nil {
+			return err
+		}
+	}
+	data := buf.Bytes()
+	name := fmt.Sprintf("segment-%06d.jsonl", len(s.segments))
+	if s.encryptor != nil {
+		sealed, err := s.encryptor.Seal(s.ctx, data)
+		if err != nil {
+			return fmt.Errorf("encrypt segment: %w", err)
+		}
+		data = sealed
+		name += ".enc"
+	}
+	path := filepath.Join(s.dir, name)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	s.segments = append(s.segments, path)
+	s.count += len(messages)
+	return nil
+}
+
+// load reads all spilled messages back in order.
+func (s *messageSpill) load() ([]llm.Message, error) {
+	messages := make([]llm.Message, 0, s.count)
+	for _, path := range s.segments {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if s.encryptor != nil {
+			if data, err = s.encryptor.Open(s.ctx, data); err != nil {
+				return nil, fmt.Errorf("decrypt %s: %w", filepath.Base(path), err)
+			}
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var rec spilledMessage
+			if err := dec.Decode(&rec); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+			}
+			msg := rec.Message
+			msg.Metadata = rec.Metadata
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// reset drops all segments, e.g. after they were compacted into a summary.
+func (s *messageSpill) reset() {
+	for _, path := range s.segments {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("[orchestrator] WARNING: remove message segment: %v", err)
+		}
+	}
+	s.segments = nil
+	s.count = 0
+}
+
+// close removes the run's segment directory.
+func (s *messageSpill) close() {
+	if err := os.RemoveAll(s.dir); err != nil {
+		log.Printf("[orchestrator] WARNING: remove message spill dir: %v", err)
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/guard"
+	"github.com/MimeLyc/agent-core-go/pkg/instructions"
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
 )
 
 // Orchestrator manages the agent loop with tool calling.
@@ -34,6 +38,27 @@ type OrchestratorRequest struct {
 	// Ignored if RepoInstructions is already set.
 	InstructionFiles []string
 
+	// InstructionMerge is the default merge strategy for nested instruction
+	// files (files can override it with "merge" front matter).
+	// Ignored if RepoInstructions is already set.
+	InstructionMerge instructions.MergeStrategy
+
+	// DisableProjectConfig skips the project configuration
+	// (.agents/config.yaml) found from WorkDir up to the repository root.
+	// Otherwise it fills in InstructionFiles and AllowedTools when unset,
+	// adds its DeniedTools, opens its roots to the tools, and adds the
+	// project and default_skills sections to the system prompt.
+	DisableProjectConfig bool
+
+	// ContextSections are added to the system prompt alongside the built-in
+	// system, soul, and repository_instructions sections. A section with a
+	// built-in name replaces it.
+	ContextSections []ContextSection
+
+	// MaxSystemPromptBytes caps the assembled system prompt. When exceeded,
+	// the lowest-priority sections are truncated first. Zero means no limit.
+	MaxSystemPromptBytes int
+
 	// InitialMessages are the starting messages for the conversation.
 	InitialMessages []AgentMessage
 
@@ -60,9 +85,128 @@ type OrchestratorRequest struct {
 	// When enabled, long conversations are summarized instead of truncated.
 	CompactConfig CompactConfig
 
+	// ToolCache memoizes repeated read-only tool calls within the run.
+	ToolCache ToolCacheConfig
+
+	// ToolRetry retries tool calls that fail with a retryable error before
+	// the failure is returned to the model.
+	ToolRetry ToolRetryConfig
+
+	// JSONCompression shortens large JSON tool outputs before the model
+	// sees them and keeps the full payload as an artifact.
+	JSONCompression JSONCompressionConfig
+
+	// ToolBudgets caps how many times each named tool may be called in the
+	// run. Further calls return a "budget exhausted" error result without
+	// running the tool. Non-positive values are ignored.
+	ToolBudgets map[string]int
+
+	// AllowedTools limits the run to tools matching these patterns (see
+	// skills.IsToolAllowed), e.g. "bash" or "mcp__github". DeniedTools
+	// removes matching tools. Excluded tools are not offered to the model,
+	// and calls to them return an error result.
+	AllowedTools []string
+	DeniedTools  []string
+
+	// SkillTools also offers each model-invocable skill as a tool (e.g.
+	// skill__deploy) whose schema is the skill's argument schema, for
+	// models that follow tool schemas better than prose. The zero value
+	// lists skills in the system prompt only.
+	SkillTools SkillToolsMode
+
+	// ToolPairRepair selects how a context window with orphaned
+	// tool_results is repaired before it is sent: ToolPairRepairDrop (the
+	// default for the zero value), ToolPairRepairStub, or
+	// ToolPairRepairFullHistory. Ignored with DisableDefaultContextRules.
+	ToolPairRepair ToolPairRepair
+
+	// LoopDetection notices runs that repeat the same tool calls or
+	// oscillate between two of them, and nudges the model or aborts with
+	// ErrAgentStuck. Disabled by default.
+	LoopDetection LoopDetectionConfig
+
+	// MessageSpill moves old messages to disk during long runs. They are
+	// read back only for compaction and the final result.
+	MessageSpill MessageSpillConfig
+
+	// ServerTools are provider-native tools (e.g. Claude's web_search)
+	// that the provider runs itself. Their blocks stay in the history and
+	// are never executed locally.
+	ServerTools []llm.ServerTool
+
+	// Commands are slash commands (e.g. contributed by plugins) checked
+	// before skills when the initial user message starts with "/name".
+	Commands []SlashCommand
+
+	// MaxContextTokens is the model context window size. When positive, each
+	// request is estimated before the provider call and compacted/truncated
+	// if it exceeds the window minus ContextMargin.
+	MaxContextTokens int
+
+	// ContextMargin is the fraction of MaxContextTokens kept free (default 0.1).
+	ContextMargin float64
+
+	// MaxOverflowRetries bounds shrink-and-retry attempts when the provider
+	// rejects a request as exceeding the context window. Zero uses the
+	// default (3); negative disables retries.
+	MaxOverflowRetries int
+
+	// Profile records per-iteration wall-clock time, model and tool
+	// latency, token usage, and context size in OrchestratorResult.Profile.
+	Profile bool
+
+	// RateLimitNotes appends a short note to the next tool result message
+	// when a provider call was delayed by rate limiting, so the model knows
+	// the pause was not caused by its tools and does not redo finished work.
+	RateLimitNotes bool
+
 	// EnableStreaming turns on provider streaming if supported.
 	EnableStreaming bool
 
+	// ThinkingBudgetTokens enables extended thinking on each provider call
+	// with this token budget. Zero leaves the provider default.
+	ThinkingBudgetTokens int
+
+	// Model overrides the provider's configured model on each call.
+	// Empty uses the provider's model.
+	Model string
+
+	// Temperature and Seed set the sampling parameters of each provider
+	// call. Nil leaves the provider default. Seed is only honored by
+	// OpenAI-compatible providers.
+	Temperature *float64
+	Seed        *int64
+
+	// ToolChoice controls whether and which tools the model calls. A forced
+	// choice (any, or a specific tool) applies until the model makes a call
+	// that satisfies it; later calls use auto. Nil leaves the provider
+	// default.
+	ToolChoice *llm.ToolChoice
+
+	// AssistantPrefill starts every model reply with this text (see
+	// llm.AgentRequest.AssistantPrefill). The recorded assistant messages
+	// include it.
+	AssistantPrefill string
+
+	// ProviderParams are merged into the JSON body of each provider request
+	// (see llm.AgentRequest.ExtraBody).
+	ProviderParams map[string]any
+
+	// Deterministic makes recorded runs reproducible: temperature defaults
+	// to 0, Seed to DeterministicSeed, the model may request only one tool
+	// call per response, and generated tool_use IDs are sequential.
+	Deterministic bool
+
+	// NewToolUseID generates IDs for tool_use blocks the provider returned
+	// with an empty or duplicate ID. Nil uses random IDs, or sequential
+	// ones in deterministic mode.
+	NewToolUseID func() string
+
+	// Locale selects the language of the built-in instructional text: the
+	// skills block, system prompt section headers, and compaction
+	// summaries. Empty uses English. See package locale.
+	Locale string
+
 	// SoulFile is an explicit path to the SOUL.md file.
 	// If empty, the orchestrator searches for SOUL.md in WorkDir then repo root.
 	// Set to a non-existent path to disable SOUL loading entirely.
@@ -74,6 +218,25 @@ type OrchestratorRequest struct {
 	// ToolContext provides execution context for tools.
 	ToolContext *tools.ToolContext
 
+	// Redactor masks secrets in tool results before they enter the context,
+	// and in tool inputs passed to OnToolCall, logs, and ToolCalls records.
+	// Nil disables redaction.
+	Redactor *redact.Redactor
+
+	// OutputGuard moderates each assistant message before it is added to
+	// the conversation and before its tool calls run. Nil disables it.
+	OutputGuard guard.OutputGuard
+
+	// Journal enables transactional mode: files are snapshotted before
+	// write-capable tools run so their changes can be rolled back.
+	// Nil disables it.
+	Journal *workspace.Journal
+
+	// WorkDirWatcher reports files changed outside the agent's tool calls.
+	// The loop pauses it while tools run and, after each tool batch, adds a
+	// follow-up message listing the files changed since. Nil disables it.
+	WorkDirWatcher *workspace.Watcher
+
 	// Runtime loop input providers. These are polled at key checkpoints.
 	GetSteeringMessages LoopInputFetcher
 	GetFollowUpMessages LoopInputFetcher
@@ -82,20 +245,60 @@ type OrchestratorRequest struct {
 	// context rules and provider conversion.
 	TransformContext TransformContextHook
 
+	// ContextTransforms are additional transform stages placed around the
+	// default context rules. See ContextStage.
+	ContextTransforms []ContextTransform
+
 	// ConvertToLlm is an optional conversion hook applied after context rules.
 	// It can adapt messages based on provider capabilities/protocol needs.
 	ConvertToLlm ConvertToLlmHook
 
+	// OnTransformToolResult is an optional hook that rewrites each tool
+	// result before it is added to the conversation, e.g. to strip ANSI
+	// codes or compact JSON. ToolCalls and OnToolResult keep the original.
+	OnTransformToolResult ToolResultTransformHook
+
 	// DisableDefaultContextRules disables built-in compaction/truncation/validation rules.
 	DisableDefaultContextRules bool
 
+	// Prune enables the pruning plugins, which run after TransformContext
+	// and before the default rules.
+	Prune PruneConfig
+
 	// Callbacks for monitoring the agent loop.
 	OnMessage         func(llm.Message)
 	OnToolCall        func(name string, input map[string]any)
 	OnToolResult      func(name string, result tools.ToolResult)
+	OnToolOutput      func(name, toolCallID, chunk string)
 	OnSteeringApplied func(messages []llm.Message)
 	OnFollowUpApplied func(messages []llm.Message)
 	OnStreamDelta     func(delta llm.ContentBlockDelta)
+	OnContextPressure func(pressure ContextPressure)
+	OnRateLimit       func(wait RateLimitWait)
+
+	// OnUsage is called after each provider response with its usage and
+	// the run's cumulative totals.
+	OnUsage func(update UsageUpdate)
+
+	// OnOutputGuard is called when OutputGuard redacts, rewrites, or
+	// blocks an assistant message.
+	OnOutputGuard func(verdict guard.Verdict)
+}
+
+// UsageUpdate reports token usage after a provider response.
+type UsageUpdate struct {
+	Iteration int
+
+	// Model is the model that produced the response.
+	Model string
+
+	// Usage is the usage of this response alone.
+	Usage llm.Usage
+
+	// Totals across the run so far.
+	TotalInputTokens       int
+	TotalOutputTokens      int
+	TotalCachedInputTokens int
 }
 
 // LoopInputSnapshot provides loop state to steering/follow-up providers.
@@ -112,9 +315,57 @@ type LoopInputFetcher func(ctx context.Context, snapshot LoopInputSnapshot) ([]l
 // TransformContextHook transforms conversation messages before the model call.
 type TransformContextHook func(ctx context.Context, messages []AgentMessage) ([]AgentMessage, error)
 
+// ContextStage is the point in the context pipeline where a ContextTransform
+// runs. The pipeline is: TransformContext, pruning, before_compaction,
+// compaction, after_compaction, truncation, after_truncation, tool pair
+// validation, after_validation.
+type ContextStage string
+
+const (
+	ContextStageBeforeCompaction ContextStage = "before_compaction"
+	ContextStageAfterCompaction  ContextStage = "after_compaction"
+	ContextStageAfterTruncation  ContextStage = "after_truncation"
+	ContextStageAfterValidation  ContextStage = "after_validation"
+)
+
+// ContextTransform is a named context pipeline stage.
+type ContextTransform struct {
+	// Name identifies the transform in errors and logs. A later transform
+	// with the same name replaces an earlier one.
+	Name string
+
+	// Stage places the transform (default ContextStageBeforeCompaction).
+	Stage ContextStage
+
+	// Priority orders transforms within a stage; higher runs first and
+	// equal priorities keep their order.
+	Priority int
+
+	// Transform rewrites the messages.
+	Transform TransformContextHook
+}
+
 // ConvertToLlmHook converts messages for the selected provider.
 type ConvertToLlmHook func(ctx context.Context, messages []AgentMessage, providerName string) ([]LLMMessage, error)
 
+// ToolResultTransformHook returns the tool result the model sees for a call
+// of tool name with input.
+type ToolResultTransformHook func(ctx context.Context, name string, input map[string]any, result tools.ToolResult) tools.ToolResult
+
+// SlashCommand is a user-invocable prompt template. "/name args" in the
+// initial user message is replaced with the rendered Prompt.
+type SlashCommand struct {
+	// Name is the command name without the leading slash.
+	Name string
+
+	// Description is a short human-readable summary.
+	Description string
+
+	// Prompt is the message template. $ARGUMENTS is replaced with the
+	// command arguments; they are appended when the placeholder is missing.
+	Prompt string
+}
+
 // MCPServerConfig configures an MCP server connection.
 type MCPServerConfig struct {
 	// Name is a unique identifier for the server.
@@ -150,8 +401,45 @@ type OrchestratorResult struct {
 	// TotalOutputTokens is the cumulative output token count.
 	TotalOutputTokens int
 
+	// TotalCachedInputTokens is the cumulative count of input tokens read
+	// from the provider's prompt cache.
+	TotalCachedInputTokens int
+
 	// ToolCalls contains all tool calls made during execution.
 	ToolCalls []ToolCallRecord
+
+	// ToolStats aggregates call counts and latency per tool name.
+	ToolStats map[string]ToolStats
+
+	// ToolPairRepairs is the number of model calls whose context had
+	// tool_results without their tool_use and was repaired.
+	ToolPairRepairs int
+
+	// Profile is the run's timing and token profile when
+	// OrchestratorRequest.Profile is set. Nil otherwise.
+	Profile *Profile
+
+	// Provider is the name of the provider that ran the loop.
+	Provider string
+
+	// Responses describes every model response, in order.
+	Responses []ResponseRecord
+}
+
+// ResponseRecord describes one model response.
+type ResponseRecord struct {
+	Iteration  int
+	ID         string
+	Model      string
+	StopReason llm.StopReason
+
+	// Retries is the number of failed attempts retried before the
+	// response arrived; RateLimitedRetries counts the rate-limited ones.
+	Retries            int
+	RateLimitedRetries int
+
+	// Repairs lists the defects the provider repaired in the response.
+	Repairs []llm.ResponseRepair
 }
 
 // ToolCallRecord records a single tool call and its result.
@@ -159,6 +447,9 @@ type ToolCallRecord struct {
 	Name   string
 	Input  map[string]any
 	Result tools.ToolResult
+
+	// WorkDir is the directory the call ran in.
+	WorkDir string
 }
 
 // GetFinalText extracts the final text response from the result.
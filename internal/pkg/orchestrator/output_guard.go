@@ -0,0 +1,96 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/guard"
+)
+
+// guardOutput runs the request's output guard over resp before it is added
+// to the history. Redact and rewrite verdicts are applied to resp in place.
+// A block verdict replaces resp with a placeholder and returns a
+// *guard.BlockedError.
+func guardOutput(ctx context.Context, req OrchestratorRequest, iteration int, resp *llm.AgentResponse) error {
+	if req.OutputGuard == nil {
+		return nil
+	}
+
+	out := guard.Output{Iteration: iteration, Text: resp.GetText()}
+	for _, use := range resp.GetToolUses() {
+		out.ToolCalls = append(out.ToolCalls, guard.ToolCall{ID: use.ID, Name: use.Name, Input: use.Input})
+	}
+	verdict, err := req.OutputGuard.Check(ctx, out)
+	if err != nil {
+		return fmt.Errorf("output guard failed: %w", err)
+	}
+	if verdict.Action == guard.ActionAllow || verdict.Action == "" {
+		return nil
+	}
+
+	log.Printf("[orchestrator] output guard: action=%s guard=%s reason=%s", verdict.Action, verdict.Guard, verdict.Reason)
+	if req.OnOutputGuard != nil {
+		req.OnOutputGuard(verdict)
+	}
+
+	switch verdict.Action {
+	case guard.ActionBlock:
+		blocked := &guard.BlockedError{Guard: verdict.Guard, Reason: verdict.Reason}
+		resp.Content = []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "[" + blocked.Error() + "]"}}
+		resp.ReasoningContent = ""
+		resp.StopReason = llm.StopReasonEndTurn
+		return blocked
+	case guard.ActionRedact, guard.ActionRewrite:
+		applyGuardedOutput(resp, out.Text, verdict.Output)
+		return nil
+	default:
+		return fmt.Errorf("output guard returned unknown action %q", verdict.Action)
+	}
+}
+
+// applyGuardedOutput rewrites resp to match out. Changed text replaces all
+// text blocks with one at the position of the first; tool calls are
+// matched by ID and dropped when out leaves them out.
+func applyGuardedOutput(resp *llm.AgentResponse, origText string, out guard.Output) {
+	calls := make(map[string]guard.ToolCall, len(out.ToolCalls))
+	for _, call := range out.ToolCalls {
+		calls[call.ID] = call
+	}
+	textChanged := out.Text != origText
+
+	content := make([]llm.ContentBlock, 0, len(resp.Content))
+	textPlaced := false
+	for _, block := range resp.Content {
+		switch block.Type {
+		case llm.ContentTypeText:
+			if !textChanged {
+				content = append(content, block)
+			} else if !textPlaced {
+				textPlaced = true
+				if out.Text != "" {
+					content = append(content, llm.ContentBlock{Type: llm.ContentTypeText, Text: out.Text})
+				}
+			}
+		case llm.ContentTypeToolUse:
+			call, ok := calls[block.ID]
+			if !ok {
+				log.Printf("[orchestrator] output guard dropped tool call %s id=%s", block.Name, block.ID)
+				continue
+			}
+			block.Input = call.Input
+			content = append(content, block)
+		default:
+			content = append(content, block)
+		}
+	}
+	if textChanged && !textPlaced && out.Text != "" {
+		content = append([]llm.ContentBlock{{Type: llm.ContentTypeText, Text: out.Text}}, content...)
+	}
+	resp.Content = content
+
+	if resp.StopReason == llm.StopReasonToolUse && len(resp.GetToolUses()) == 0 {
+		resp.StopReason = llm.StopReasonEndTurn
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 )
 
 type contextTransformPlugin struct {
@@ -17,7 +18,7 @@ func buildTransformPlugins(
 	compactor *Compactor,
 	maxMessages int,
 ) []contextTransformPlugin {
-	plugins := make([]contextTransformPlugin, 0, 4)
+	plugins := make([]contextTransformPlugin, 0, 6+len(req.ContextTransforms))
 
 	if req.TransformContext != nil {
 		plugins = append(plugins, contextTransformPlugin{
@@ -28,11 +29,42 @@ func buildTransformPlugins(
 		})
 	}
 
-	if req.DisableDefaultContextRules {
-		return plugins
+	// Pruning is opt-in, so it runs even without the default rules.
+	prune := req.Prune.withDefaults()
+	if prune.ToolResults {
+		plugins = append(plugins, contextTransformPlugin{
+			name: "prune_tool_results",
+			run: func(_ context.Context, messages []AgentMessage) ([]AgentMessage, error) {
+				if len(messages) <= prune.Threshold {
+					return messages, nil
+				}
+				return pruneToolResults(messages, prune, req.Locale), nil
+			},
+		})
+	}
+	if prune.Relevance && prune.Embedder != nil {
+		if state.relevance == nil {
+			state.relevance = newRelevancePruner(prune)
+		}
+		plugins = append(plugins, contextTransformPlugin{
+			name: "prune_irrelevant",
+			run: func(ctx context.Context, messages []AgentMessage) ([]AgentMessage, error) {
+				if len(messages) <= prune.Threshold {
+					return messages, nil
+				}
+				pruned, err := state.relevance.prune(ctx, messages)
+				if err != nil {
+					log.Printf("[orchestrator] WARNING: relevance pruning failed: %v", err)
+					return messages, nil
+				}
+				return pruned, nil
+			},
+		})
 	}
 
-	if compactor != nil {
+	plugins = append(plugins, stageTransformPlugins(req.ContextTransforms, ContextStageBeforeCompaction)...)
+
+	if compactor != nil && !req.DisableDefaultContextRules {
 		plugins = append(plugins, contextTransformPlugin{
 			name: "compact_context",
 			run: func(ctx context.Context, messages []AgentMessage) ([]AgentMessage, error) {
@@ -42,43 +74,88 @@ func buildTransformPlugins(
 
 				log.Printf("[orchestrator] triggering compaction: %d messages exceed threshold %d",
 					len(messages), req.CompactConfig.Threshold)
-				compactedMessages, err := compactor.Compact(ctx, messages)
+				// Spilled messages are only loaded back for compaction.
+				compactedMessages, err := compactor.Compact(ctx, state.history(messages))
 				if err != nil {
 					log.Printf("[orchestrator] WARNING: compaction failed: %v, falling back to truncation", err)
 					return messages, nil
 				}
 				// Compaction must persist to state for subsequent turns.
-				state.Messages = compactedMessages
+				state.replaceMessages(compactedMessages)
 				log.Printf("[orchestrator] compaction succeeded: reduced to %d messages", len(compactedMessages))
 				return compactedMessages, nil
 			},
 		})
 	}
 
-	plugins = append(plugins, contextTransformPlugin{
-		name: "truncate_context",
-		run: func(_ context.Context, messages []AgentMessage) ([]AgentMessage, error) {
-			if len(messages) <= maxMessages {
+	plugins = append(plugins, stageTransformPlugins(req.ContextTransforms, ContextStageAfterCompaction)...)
+
+	if !req.DisableDefaultContextRules {
+		plugins = append(plugins, contextTransformPlugin{
+			name: "truncate_context",
+			run: func(_ context.Context, messages []AgentMessage) ([]AgentMessage, error) {
+				if len(messages) <= maxMessages {
+					return messages, nil
+				}
+				return truncateMessages(messages, maxMessages), nil
+			},
+		})
+	}
+
+	plugins = append(plugins, stageTransformPlugins(req.ContextTransforms, ContextStageAfterTruncation)...)
+
+	if !req.DisableDefaultContextRules {
+		plugins = append(plugins, contextTransformPlugin{
+			name: "validate_tool_pairs",
+			run: func(_ context.Context, messages []AgentMessage) ([]AgentMessage, error) {
+				if err := validateToolPairs(messages); err != nil {
+					log.Printf("[orchestrator] ERROR: message validation failed: %v", err)
+					state.ToolPairRepairs++
+					if req.ToolPairRepair == ToolPairRepairFullHistory {
+						fallback := append([]AgentMessage(nil), state.Messages...)
+						log.Printf("[orchestrator] falling back to full message history: %d messages", len(fallback))
+						return fallback, nil
+					}
+					var history []AgentMessage
+					if req.ToolPairRepair == ToolPairRepairStub {
+						history = state.history(state.Messages)
+					}
+					repaired, _ := repairToolPairs(messages, history, req.ToolPairRepair, req.Locale)
+					return repaired, nil
+				}
 				return messages, nil
-			}
-			return truncateMessages(messages, maxMessages), nil
-		},
-	})
-
-	plugins = append(plugins, contextTransformPlugin{
-		name: "validate_tool_pairs",
-		run: func(_ context.Context, messages []AgentMessage) ([]AgentMessage, error) {
-			if err := validateToolPairs(messages); err != nil {
-				log.Printf("[orchestrator] ERROR: message validation failed: %v", err)
-				// Preserve historical behavior: fall back to full history.
-				fallback := append([]AgentMessage(nil), state.Messages...)
-				log.Printf("[orchestrator] falling back to full message history: %d messages", len(fallback))
-				return fallback, nil
-			}
-			return messages, nil
-		},
-	})
+			},
+		})
+	}
 
+	plugins = append(plugins, stageTransformPlugins(req.ContextTransforms, ContextStageAfterValidation)...)
+
+	return plugins
+}
+
+// stageTransformPlugins returns the transforms registered for stage, by
+// descending priority. Equal priorities keep their order, and a later
+// transform replaces an earlier one with the same name.
+func stageTransformPlugins(transforms []ContextTransform, stage ContextStage) []contextTransformPlugin {
+	last := make(map[string]int, len(transforms))
+	for i, t := range transforms {
+		last[t.Name] = i
+	}
+	var staged []ContextTransform
+	for i, t := range transforms {
+		if last[t.Name] != i || t.Transform == nil {
+			continue
+		}
+		if t.Stage == stage || (t.Stage == "" && stage == ContextStageBeforeCompaction) {
+			staged = append(staged, t)
+		}
+	}
+	sort.SliceStable(staged, func(i, j int) bool { return staged[i].Priority > staged[j].Priority })
+
+	plugins := make([]contextTransformPlugin, 0, len(staged))
+	for _, t := range staged {
+		plugins = append(plugins, contextTransformPlugin{name: t.Name, run: t.Transform})
+	}
 	return plugins
 }
 
@@ -0,0 +1,144 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// Profile records where a run spent its time and tokens. It is collected
+// when OrchestratorRequest.Profile is set.
+type Profile struct {
+	Iterations []IterationProfile
+
+	// TotalDuration is the wall-clock time of the run.
+	TotalDuration time.Duration
+
+	// ProviderLatency and ToolLatency sum the iterations' latencies.
+	ProviderLatency time.Duration
+	ToolLatency     time.Duration
+}
+
+// IterationProfile is the timing and token usage of one loop iteration.
+type IterationProfile struct {
+	Iteration int
+
+	// Duration is the iteration's wall-clock time, including context
+	// building, compaction, and loop input handling.
+	Duration time.Duration
+
+	// ProviderLatency is the time spent in model calls, including retries
+	// and rate limit waits.
+	ProviderLatency time.Duration
+
+	// ToolLatency is the summed execution time of the iteration's tools.
+	ToolLatency time.Duration
+
+	InputTokens  int
+	OutputTokens int
+
+	// ContextMessages and ContextTokens are the size of the request sent to
+	// the model; ContextTokens is estimated (see EstimateRequestTokens).
+	ContextMessages int
+	ContextTokens   int
+
+	Tools []ToolProfile
+}
+
+// ToolProfile is one tool call within an iteration.
+type ToolProfile struct {
+	Name     string
+	Duration time.Duration
+	Cached   bool
+	Rejected bool
+	IsError  bool
+}
+
+// profiler collects a Profile during a run.
+type profiler struct {
+	clock     Clock
+	started   time.Time
+	iterStart time.Time
+	profile   Profile
+}
+
+func newProfiler(clock Clock) *profiler {
+	return &profiler{clock: clock, started: clock.Now()}
+}
+
+// current returns the profile of the running iteration, or nil before the
+// first one.
+func (p *profiler) current() *IterationProfile {
+	if p == nil || len(p.profile.Iterations) == 0 {
+		return nil
+	}
+	return &p.profile.Iterations[len(p.profile.Iterations)-1]
+}
+
+// startIteration closes the previous iteration and opens iteration n.
+func (p *profiler) startIteration(n int) {
+	if p == nil {
+		return
+	}
+	now := p.clock.Now()
+	if it := p.current(); it != nil {
+		it.Duration = now.Sub(p.iterStart)
+	}
+	p.iterStart = now
+	p.profile.Iterations = append(p.profile.Iterations, IterationProfile{Iteration: n})
+}
+
+// providerCall records a model call of req that took d.
+func (p *profiler) providerCall(req llm.AgentRequest, d time.Duration) {
+	it := p.current()
+	if it == nil {
+		return
+	}
+	it.ProviderLatency += d
+	it.ContextMessages = len(req.Messages)
+	it.ContextTokens = EstimateRequestTokens(req)
+}
+
+func (p *profiler) usage(usage llm.Usage) {
+	if it := p.current(); it != nil {
+		it.InputTokens += usage.InputTokens
+		it.OutputTokens += usage.OutputTokens
+	}
+}
+
+func (p *profiler) tool(name string, result tools.ToolResult, outcome toolCallOutcome) {
+	it := p.current()
+	if it == nil {
+		return
+	}
+	it.ToolLatency += outcome.duration
+	it.Tools = append(it.Tools, ToolProfile{
+		Name:     name,
+		Duration: outcome.duration,
+		Cached:   outcome.cached,
+		Rejected: outcome.rejected,
+		IsError:  result.IsError,
+	})
+}
+
+// result returns the profile so far, with the running iteration measured
+// up to now.
+func (p *profiler) result() *Profile {
+	if p == nil {
+		return nil
+	}
+	now := p.clock.Now()
+	out := p.profile
+	out.Iterations = append([]IterationProfile(nil), p.profile.Iterations...)
+	if n := len(out.Iterations); n > 0 {
+		out.Iterations[n-1].Duration = now.Sub(p.iterStart)
+	}
+	out.TotalDuration = now.Sub(p.started)
+	out.ProviderLatency, out.ToolLatency = 0, 0
+	for _, it := range out.Iterations {
+		out.ProviderLatency += it.ProviderLatency
+		out.ToolLatency += it.ToolLatency
+	}
+	return &out
+}
@@ -0,0 +1,139 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+	"github.com/MimeLyc/agent-core-go/pkg/project"
+	"github.com/MimeLyc/agent-core-go/pkg/skills"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// Project configuration section names and priorities. The roots follow the
+// SOUL section; default skills follow the repository instructions.
+const (
+	SectionProject       = "project"
+	SectionDefaultSkills = "default_skills"
+
+	PriorityProject       = 150
+	PriorityDefaultSkills = 90
+)
+
+// applyProject loads the project configuration (.agents/config.yaml) of
+// req.WorkDir and fills in what the request leaves unset: instruction
+// files and allowed tools. Project denied tools add to the request's, and
+// every root becomes a sandbox root of toolCtx. The returned sections
+// describe the roots and carry the default skills; request sections with
+// the same names replace them.
+func applyProject(req *OrchestratorRequest, toolCtx *tools.ToolContext) []ContextSection {
+	if req.DisableProjectConfig || req.WorkDir == "" {
+		return nil
+	}
+	cfg, err := project.Find(req.WorkDir)
+	if err != nil {
+		log.Printf("[orchestrator] WARNING: ignoring project config: %v", err)
+		return nil
+	}
+	if cfg == nil {
+		return nil
+	}
+
+	settings := cfg.SettingsFor(req.WorkDir)
+	rootName := ""
+	if settings.Root != nil {
+		rootName = settings.Root.Name
+	}
+	log.Printf("[orchestrator] loaded project config from %s: roots=%d current=%s",
+		cfg.Dir, len(cfg.Roots), rootName)
+
+	if len(req.InstructionFiles) == 0 {
+		req.InstructionFiles = settings.Instructions
+	}
+	if len(req.AllowedTools) == 0 {
+		req.AllowedTools = settings.AllowedTools
+	}
+	req.DeniedTools = append(slices.Clone(req.DeniedTools), settings.DeniedTools...)
+	for _, root := range cfg.Roots {
+		dir := cfg.RootDir(root)
+		if dir != filepath.Clean(toolCtx.WorkDir) && !slices.Contains(toolCtx.AllowedRoots, dir) {
+			toolCtx.AllowedRoots = append(toolCtx.AllowedRoots, dir)
+		}
+	}
+
+	sections := []ContextSection{{
+		Name:     SectionProject,
+		Priority: PriorityProject,
+		Content:  projectSection(req.Locale, cfg, rootName),
+	}}
+	if len(settings.Skills) > 0 {
+		sections = append(sections, ContextSection{
+			Name:     SectionDefaultSkills,
+			Priority: PriorityDefaultSkills,
+			Content:  defaultSkillsSection(req.Locale, settings.Skills, req.WorkDir, toolCtx),
+		})
+	}
+	return sections
+}
+
+// projectSection lists the project's roots, marking the current one.
+func projectSection(lang string, cfg *project.Config, current string) string {
+	if len(cfg.Roots) == 0 {
+		return ""
+	}
+	lines := []string{
+		locale.Text(lang, locale.ProjectHeading),
+		"",
+		fmt.Sprintf(locale.Text(lang, locale.ProjectIntro), cfg.Dir),
+		"",
+	}
+	for _, root := range cfg.Roots {
+		line := fmt.Sprintf("- %s: %s", root.Name, filepath.ToSlash(filepath.Clean(root.Path)))
+		if root.Name == current {
+			line += " (" + locale.Text(lang, locale.ProjectCurrentRoot) + ")"
+		}
+		if desc := strings.TrimSpace(root.Description); desc != "" {
+			line += " - " + desc
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// defaultSkillsSection renders the named skills as if invoked without
+// arguments. Unknown skills are logged and left out.
+func defaultSkillsSection(lang string, names []string, workDir string, toolCtx *tools.ToolContext) string {
+	discovered, err := skills.Discover(skills.SearchDirs(workDir, toolCtx.SkillDirs))
+	if err != nil {
+		log.Printf("[orchestrator] WARNING: default skills not loaded: %v", err)
+		return ""
+	}
+	vars := skills.VariablesFor(toolCtx, toolCtx.Now())
+
+	var parts []string
+	for _, name := range names {
+		selected, err := skills.ResolveForInvocation(discovered, name)
+		if err != nil {
+			log.Printf("[orchestrator] WARNING: default skill %s not loaded: %v", name, err)
+			continue
+		}
+		rendered, truncated, err := skills.RenderForInvocationVars(selected, "", vars, skills.DefaultSkillReadMaxBytes)
+		if err != nil {
+			log.Printf("[orchestrator] WARNING: default skill %s not loaded: %v", name, err)
+			continue
+		}
+		if truncated {
+			rendered += fmt.Sprintf("\n\n[truncated to %d bytes]", skills.DefaultSkillReadMaxBytes)
+		}
+		parts = append(parts, "### "+selected.Name+"\n\n"+strings.TrimSpace(rendered))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return locale.Text(lang, locale.DefaultSkillsHeading) + "\n\n" +
+		locale.Text(lang, locale.DefaultSkillsIntro) + "\n\n" +
+		strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,283 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+)
+
+const (
+	defaultPruneKeepRecent     = 10
+	defaultPruneMinResultBytes = 256
+	defaultPruneMinSimilarity  = 0.25
+
+	// maxRelevanceTextBytes bounds the text embedded per turn.
+	maxRelevanceTextBytes = 4000
+)
+
+// Embedder converts texts to embedding vectors, one per text, for relevance
+// pruning.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// PruneConfig selects the built-in pruning plugins. They shrink what is sent
+// to the model before compaction and truncation run, and leave the stored
+// conversation untouched. Both are opt-in and apply even with
+// DisableDefaultContextRules.
+type PruneConfig struct {
+	// ToolResults replaces the content of older tool results with a stub
+	// naming the tool, keeping the tool_use/tool_result pairs intact.
+	ToolResults bool
+
+	// Relevance drops older assistant turns (an assistant message and the
+	// tool results and user messages that follow it) whose text is not
+	// similar to the latest user message. Requires Embedder.
+	Relevance bool
+
+	// Threshold prunes only conversations with more than this many
+	// messages. Zero prunes every model call.
+	Threshold int
+
+	// KeepRecent is the number of recent messages never pruned
+	// (default 10). The first message is always kept.
+	KeepRecent int
+
+	// MinResultBytes keeps tool results shorter than this (default 256).
+	MinResultBytes int
+
+	// Embedder embeds the task and turns for relevance pruning.
+	Embedder Embedder
+
+	// MinSimilarity is the cosine similarity to the task below which a
+	// turn is dropped (default 0.25).
+	MinSimilarity float64
+}
+
+func (c PruneConfig) withDefaults() PruneConfig {
+	if c.KeepRecent <= 0 {
+		c.KeepRecent = defaultPruneKeepRecent
+	}
+	if c.MinResultBytes <= 0 {
+		c.MinResultBytes = defaultPruneMinResultBytes
+	}
+	if c.MinSimilarity <= 0 {
+		c.MinSimilarity = defaultPruneMinSimilarity
+	}
+	return c
+}
+
+// pruneToolResults stubs the tool results in messages before the
+// KeepRecent most recent ones. Modified messages are copied.
+func pruneToolResults(messages []AgentMessage, cfg PruneConfig, lang string) []AgentMessage {
+	end := len(messages) - cfg.KeepRecent
+	if end <= 1 {
+		return messages
+	}
+	toolNames := make(map[string]string)
+	out := append([]AgentMessage(nil), messages...)
+	pruned := 0
+	for i := 0; i < end; i++ {
+		var content []llm.ContentBlock
+		for j, block := range messages[i].Content {
+			if block.Type == llm.ContentTypeToolUse {
+				toolNames[block.ID] = block.Name
+				continue
+			}
+			if block.Type != llm.ContentTypeToolResult || len(block.Content) < cfg.MinResultBytes {
+				continue
+			}
+			if content == nil {
+				content = append([]llm.ContentBlock(nil), messages[i].Content...)
+			}
+			name := toolNames[block.ToolUseID]
+			if name == "" {
+				name = "tool"
+			}
+			content[j].Content = fmt.Sprintf(locale.Text(lang, locale.PruneToolResultStub), name, len(block.Content))
+			pruned++
+		}
+		if content != nil {
+			out[i].Content = content
+		}
+	}
+	if pruned == 0 {
+		return messages
+	}
+	log.Printf("[orchestrator] pruned %d tool results before message %d", pruned, end)
+	return out
+}
+
+// relevancePruner drops older turns that are unrelated to the task. Turn
+// embeddings are cached by content for the rest of the run.
+type relevancePruner struct {
+	cfg   PruneConfig
+	cache map[[sha256.Size]byte][]float32
+}
+
+func newRelevancePruner(cfg PruneConfig) *relevancePruner {
+	return &relevancePruner{cfg: cfg, cache: make(map[[sha256.Size]byte][]float32)}
+}
+
+func (p *relevancePruner) prune(ctx context.Context, messages []AgentMessage) ([]AgentMessage, error) {
+	query := latestUserText(messages)
+	if query == "" {
+		return messages, nil
+	}
+
+	// Turns start at assistant messages, so dropping one never separates a
+	// tool_use from its tool_result. Messages before the first assistant
+	// message and the turns overlapping the recent window are kept.
+	first := -1
+	for i, msg := range messages {
+		if msg.Role == llm.RoleAssistant {
+			first = i
+			break
+		}
+	}
+	end := len(messages) - p.cfg.KeepRecent
+	for end > 0 && end < len(messages) && messages[end].Role != llm.RoleAssistant {
+		end--
+	}
+	if first < 1 || end <= first {
+		return messages, nil
+	}
+	var turns [][2]int
+	for start := first; start < end; {
+		stop := start + 1
+		for stop < end && messages[stop].Role != llm.RoleAssistant {
+			stop++
+		}
+		turns = append(turns, [2]int{start, stop})
+		start = stop
+	}
+
+	texts := []string{query}
+	for _, turn := range turns {
+		texts = append(texts, relevanceText(messages[turn[0]:turn[1]]))
+	}
+	vectors, err := p.embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append([]AgentMessage(nil), messages[:first]...)
+	dropped := 0
+	for i, turn := range turns {
+		if cosineSimilarity(vectors[0], vectors[i+1]) < p.cfg.MinSimilarity {
+			dropped++
+			continue
+		}
+		out = append(out, messages[turn[0]:turn[1]]...)
+	}
+	if dropped == 0 {
+		return messages, nil
+	}
+	out = append(out, messages[end:]...)
+	log.Printf("[orchestrator] relevance pruning dropped %d of %d turns (%d -> %d messages)",
+		dropped, len(turns), len(messages), len(out))
+	return out, nil
+}
+
+// embed returns the embeddings of texts, calling the embedder only for
+// texts not seen before.
+func (p *relevancePruner) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([][sha256.Size]byte, len(texts))
+	vectors := make([][]float32, len(texts))
+	var missing []string
+	var missingIdx []int
+	for i, text := range texts {
+		keys[i] = sha256.Sum256([]byte(text))
+		if v, ok := p.cache[keys[i]]; ok {
+			vectors[i] = v
+			continue
+		}
+		missing = append(missing, text)
+		missingIdx = append(missingIdx, i)
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := p.cfg.Embedder.Embed(ctx, missing)
+	if err != nil {
+		return nil, fmt.Errorf("embed: %w", err)
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("embed: got %d vectors for %d texts", len(embedded), len(missing))
+	}
+	for j, i := range missingIdx {
+		vectors[i] = embedded[j]
+		p.cache[keys[i]] = embedded[j]
+	}
+	return vectors, nil
+}
+
+// latestUserText returns the text of the most recent user message that is
+// not a tool result message, i.e. the task or the latest steering or
+// follow-up message.
+func latestUserText(messages []AgentMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != llm.RoleUser || hasToolResult(messages[i]) {
+			continue
+		}
+		if text := strings.TrimSpace(messages[i].GetText()); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// relevanceText is the text a turn is embedded by: its text, tool calls,
+// and tool results, cut to maxRelevanceTextBytes.
+func relevanceText(messages []AgentMessage) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case llm.ContentTypeText:
+				b.WriteString(block.Text)
+			case llm.ContentTypeToolUse:
+				fmt.Fprintf(&b, "%s %v", block.Name, block.Input)
+			case llm.ContentTypeToolResult:
+				b.WriteString(block.Content)
+			default:
+				continue
+			}
+			b.WriteString("\n")
+			if b.Len() >= maxRelevanceTextBytes {
+				return truncateUTF8(b.String(), maxRelevanceTextBytes)
+			}
+		}
+	}
+	return b.String()
+}
+
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
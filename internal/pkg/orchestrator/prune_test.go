@@ -0,0 +1,161 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// toolTurn returns an assistant tool call and its result message.
+func toolTurn(id, name, text, output string) []llm.Message {
+	return []llm.Message{
+		{Role: llm.RoleAssistant, Content: []llm.ContentBlock{
+			{Type: llm.ContentTypeText, Text: text},
+			{Type: llm.ContentTypeToolUse, ID: id, Name: name},
+		}},
+		{Role: llm.RoleUser, Content: []llm.ContentBlock{
+			{Type: llm.ContentTypeToolResult, ToolUseID: id, Content: output},
+		}},
+	}
+}
+
+func TestPruneToolResultsStubsOldResults(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	messages := []llm.Message{llm.NewTextMessage(llm.RoleUser, "task")}
+	messages = append(messages, toolTurn("t1", "read_file", "", long)...)
+	messages = append(messages, toolTurn("t2", "bash", "", "short")...)
+	messages = append(messages, toolTurn("t3", "bash", "", long)...)
+
+	cfg := PruneConfig{KeepRecent: 2}.withDefaults()
+	pruned := pruneToolResults(messages, cfg, "")
+
+	if got := pruned[2].Content[0].Content; !strings.Contains(got, "read_file") || !strings.Contains(got, "300 bytes") {
+		t.Fatalf("expected stub for old result, got %q", got)
+	}
+	if pruned[2].Content[0].ToolUseID != "t1" {
+		t.Fatalf("stub lost its tool_use_id: %+v", pruned[2].Content[0])
+	}
+	if pruned[4].Content[0].Content != "short" {
+		t.Fatalf("short result should be kept, got %q", pruned[4].Content[0].Content)
+	}
+	if pruned[6].Content[0].Content != long {
+		t.Fatal("recent result should be kept")
+	}
+	if messages[2].Content[0].Content != long {
+		t.Fatal("input messages must not be modified")
+	}
+}
+
+// keywordEmbedder embeds texts as [contains "auth", contains "docs"].
+type keywordEmbedder struct {
+	calls int
+	texts int
+	err   error
+}
+
+func (e *keywordEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	e.texts += len(texts)
+	if e.err != nil {
+		return nil, e.err
+	}
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := []float32{0.1, 0.1}
+		if strings.Contains(text, "auth") {
+			v[0] = 1
+		}
+		if strings.Contains(text, "docs") {
+			v[1] = 1
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func TestRelevancePrunerDropsUnrelatedTurns(t *testing.T) {
+	messages := []llm.Message{llm.NewTextMessage(llm.RoleUser, "fix the auth bug")}
+	messages = append(messages, toolTurn("t1", "read_file", "reading auth.go", "auth code")...)
+	messages = append(messages, toolTurn("t2", "read_file", "reading docs", "docs index")...)
+	messages = append(messages, toolTurn("t3", "bash", "running tests", "ok")...)
+	messages = append(messages, llm.NewTextMessage(llm.RoleAssistant, "the auth bug is fixed"))
+
+	embedder := &keywordEmbedder{}
+	pruner := newRelevancePruner(PruneConfig{Relevance: true, KeepRecent: 3, Embedder: embedder, MinSimilarity: 0.9}.withDefaults())
+
+	pruned, err := pruner.prune(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("prune() error = %v", err)
+	}
+	// The docs turn is dropped; the recent window starts at the t3 turn.
+	if len(pruned) != len(messages)-2 {
+		t.Fatalf("pruned to %d messages, want %d", len(pruned), len(messages)-2)
+	}
+	for _, msg := range pruned {
+		if strings.Contains(msg.GetText(), "docs") {
+			t.Fatalf("expected the docs turn to be dropped, got %+v", pruned)
+		}
+	}
+	if err := validateToolPairs(pruned); err != nil {
+		t.Fatalf("pruning broke tool pairs: %v", err)
+	}
+
+	if _, err := pruner.prune(context.Background(), messages); err != nil {
+		t.Fatalf("prune() error = %v", err)
+	}
+	if embedder.calls != 1 {
+		t.Fatalf("expected cached embeddings on the second prune, got %d calls", embedder.calls)
+	}
+}
+
+func TestPipelinePrunesContextWithoutChangingHistory(t *testing.T) {
+	provider := &pipelineTestProvider{}
+	loop := NewAgentLoop(provider, tools.NewRegistry())
+
+	long := strings.Repeat("x", 300)
+	initial := []llm.Message{llm.NewTextMessage(llm.RoleUser, "task")}
+	initial = append(initial, toolTurn("t1", "read_file", "", long)...)
+	initial = append(initial, llm.NewTextMessage(llm.RoleUser, "continue"))
+
+	result, err := loop.Run(context.Background(), OrchestratorRequest{
+		InitialMessages:            initial,
+		Prune:                      PruneConfig{ToolResults: true, KeepRecent: 1},
+		DisableDefaultContextRules: true,
+		MaxIterations:              1,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := provider.lastReq.Messages[2].Content[0].Content; got == long {
+		t.Fatal("expected the provider to receive a pruned tool result")
+	}
+	if result.Messages[2].Content[0].Content != long {
+		t.Fatal("expected the conversation to keep the full tool result")
+	}
+}
+
+func TestPipelineIgnoresRelevanceErrors(t *testing.T) {
+	provider := &pipelineTestProvider{}
+	loop := NewAgentLoop(provider, tools.NewRegistry())
+
+	initial := []llm.Message{llm.NewTextMessage(llm.RoleUser, "task")}
+	initial = append(initial, toolTurn("t1", "bash", "", "out")...)
+	initial = append(initial, llm.NewTextMessage(llm.RoleUser, "continue"))
+
+	_, err := loop.Run(context.Background(), OrchestratorRequest{
+		InitialMessages: initial,
+		Prune:           PruneConfig{Relevance: true, KeepRecent: 1, Embedder: &keywordEmbedder{err: errors.New("down")}},
+		MaxIterations:   1,
+		MaxMessages:     10,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(provider.lastReq.Messages) != len(initial) {
+		t.Fatalf("expected unpruned context, got %d messages", len(provider.lastReq.Messages))
+	}
+}
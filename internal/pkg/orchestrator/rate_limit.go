@@ -0,0 +1,69 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+// RateLimitWait describes a provider retry that waits out rate limiting.
+type RateLimitWait struct {
+	Iteration   int
+	Provider    string
+	Attempt     int
+	MaxAttempts int
+	Status      int
+	Wait        time.Duration
+}
+
+// rateLimitTally accumulates the retries of one iteration's provider
+// calls. retries and total cover rate-limited retries only.
+type rateLimitTally struct {
+	retries int
+	total   time.Duration
+
+	// allRetries counts every retried attempt, whatever the cause.
+	allRetries int
+}
+
+// observeRateLimits returns a context that reports rate-limited provider
+// retries to req.OnRateLimit and tally.
+func observeRateLimits(ctx context.Context, req OrchestratorRequest, iteration int, tally *rateLimitTally) context.Context {
+	return llm.WithRetryObserver(ctx, func(n llm.RetryNotice) {
+		tally.allRetries++
+		if !n.RateLimited {
+			return
+		}
+		tally.retries++
+		tally.total += n.Wait
+		log.Printf("[orchestrator] rate limited by %s (status %d), waiting %v", n.Provider, n.Status, n.Wait)
+		if req.OnRateLimit != nil {
+			req.OnRateLimit(RateLimitWait{
+				Iteration:   iteration,
+				Provider:    n.Provider,
+				Attempt:     n.Attempt,
+				MaxAttempts: n.MaxAttempts,
+				Status:      n.Status,
+				Wait:        n.Wait,
+			})
+		}
+	})
+}
+
+// note returns the text block appended to the next tool result message, or
+// false when no note is due.
+func (t rateLimitTally) note(req OrchestratorRequest) (llm.ContentBlock, bool) {
+	if !req.RateLimitNotes || t.retries == 0 {
+		return llm.ContentBlock{}, false
+	}
+	wait := max(t.total.Round(time.Second), time.Second)
+	return llm.ContentBlock{
+		Type: llm.ContentTypeText,
+		Text: fmt.Sprintf("[Note: your previous response was delayed about %s by model provider rate limiting (%d retries). "+
+			"The pause was not caused by your tools and no work was lost; continue without repeating completed steps.]",
+			wait, t.retries),
+	}, true
+}
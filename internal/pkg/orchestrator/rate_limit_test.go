@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestRunReportsRateLimitsAndNotesThem(t *testing.T) {
+	var requests []llm.AgentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.AgentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		requests = append(requests, req)
+		switch len(requests) {
+		case 1:
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+		case 2:
+			json.NewEncoder(w).Encode(toolUseResponse("tool-1", "count", map[string]any{}))
+		default:
+			json.NewEncoder(w).Encode(llm.AgentResponse{
+				Role:       llm.RoleAssistant,
+				StopReason: llm.StopReasonEndTurn,
+				Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}},
+			})
+		}
+	}))
+	defer server.Close()
+
+	provider := llm.NewClaudeProvider(llm.LLMProviderConfig{BaseURL: server.URL, APIKey: "k", Model: "m", MaxAttempts: 2})
+	provider.Backoff = func(int) time.Duration { return 3 * time.Second }
+	provider.Sleep = func(time.Duration) {}
+
+	registry := tools.NewRegistry()
+	registry.Register(&countTool{})
+
+	var waits []RateLimitWait
+	_, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		RateLimitNotes:  true,
+		OnRateLimit:     func(wait RateLimitWait) { waits = append(waits, wait) },
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(waits) != 1 || waits[0].Iteration != 1 || waits[0].Wait != 3*time.Second || waits[0].Status != http.StatusTooManyRequests {
+		t.Fatalf("unexpected rate limit waits: %+v", waits)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 provider requests, got %d", len(requests))
+	}
+	msgs := requests[2].Messages
+	results := msgs[len(msgs)-1].Content
+	if len(results) != 2 || results[0].Type != llm.ContentTypeToolResult || results[1].Type != llm.ContentTypeText ||
+		!strings.Contains(results[1].Text, "rate limiting") || !strings.Contains(results[1].Text, "3s") {
+		t.Fatalf("expected a rate limit note after the tool result, got %+v", results)
+	}
+}
@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/skills"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// SkillToolsMode selects how discovered skills are offered to the model.
+type SkillToolsMode string
+
+const (
+	// SkillToolsOff lists skills in the system prompt block only. It is
+	// the default.
+	SkillToolsOff SkillToolsMode = ""
+
+	// SkillToolsAdditional keeps the prompt block and also offers each
+	// model-invocable skill as a tool named skills.ToolName(name).
+	SkillToolsAdditional SkillToolsMode = "additional"
+
+	// SkillToolsOnly offers the skill tools instead of the prompt block.
+	SkillToolsOnly SkillToolsMode = "only"
+)
+
+// useSkillToolName is the registered tool that skill tools delegate to.
+const useSkillToolName = "use_skill"
+
+// skillTool exposes one skill as a tool whose input schema is the skill's
+// argument schema. Calling it runs use_skill for the skill.
+type skillTool struct {
+	skill    skills.Skill
+	useSkill tools.Tool
+}
+
+func (t skillTool) Name() string {
+	return skills.ToolName(t.skill.Name)
+}
+
+func (t skillTool) Description() string {
+	return fmt.Sprintf("Use the %s skill: %s", t.skill.Name, t.skill.Description)
+}
+
+func (t skillTool) InputSchema() map[string]any {
+	return skills.ArgumentsSchema(t.skill.Arguments)
+}
+
+func (t skillTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	var arguments any
+	switch {
+	case len(t.skill.Arguments) == 0:
+		arguments, _ = input["arguments"].(string)
+	case len(input) > 0:
+		arguments = input
+	}
+	return t.useSkill.Execute(ctx, toolCtx, map[string]any{
+		"name":      t.skill.Name,
+		"arguments": arguments,
+	})
+}
+
+// skillTools returns one tool per model-invocable skill when
+// req.SkillTools asks for them. It returns nil when use_skill is not
+// registered, since the skill tools run through it.
+func (l *AgentLoop) skillTools(req OrchestratorRequest, toolCtx *tools.ToolContext) []tools.Tool {
+	if req.SkillTools == SkillToolsOff {
+		return nil
+	}
+	useSkill := l.Registry.Get(useSkillToolName)
+	if useSkill == nil {
+		log.Printf("[orchestrator] skill tools requested but %s is not registered", useSkillToolName)
+		return nil
+	}
+	discovered, err := skills.Discover(skills.SearchDirs(req.WorkDir, toolCtx.SkillDirs))
+	if err != nil {
+		log.Printf("[orchestrator] failed to discover skills for skill tools: %v", err)
+		return nil
+	}
+	var out []tools.Tool
+	for _, skill := range skills.ModelInvocable(discovered) {
+		tool := skillTool{skill: skill, useSkill: useSkill}
+		if l.Registry.Has(tool.Name()) || findSkillTool(out, tool.Name()) != nil {
+			log.Printf("[orchestrator] skipping skill tool for %s: name %s already used", skill.Name, tool.Name())
+			continue
+		}
+		out = append(out, tool)
+	}
+	return out
+}
+
+func findSkillTool(list []tools.Tool, name string) tools.Tool {
+	for _, t := range list {
+		if t.Name() == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// isSkillToolName reports whether name is a skill tool.
+func isSkillToolName(name string) bool {
+	return strings.HasPrefix(name, skills.ToolNamePrefix)
+}
@@ -0,0 +1,167 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/instructions"
+)
+
+// maxStableContexts bounds the stable contexts an AgentLoop keeps, one per
+// working directory and loading options.
+const maxStableContexts = 64
+
+// stableContext is the part of the system prompt loaded from files: the
+// SOUL file, and the repository instructions with the skill metadata. A
+// session's later runs reuse it until one of its files changes, instead of
+// walking the repository and the skill directories again.
+type stableContext struct {
+	soul             string
+	repoInstructions string
+
+	// watched are the paths the content was loaded from or looked for;
+	// fingerprint hashes their state when the content was loaded.
+	watched     []string
+	fingerprint string
+	usedAt      time.Time
+}
+
+// stableContextKey identifies the files a stableContext is loaded from.
+type stableContextKey struct {
+	workDir    string
+	soulFile   string
+	candidates string
+	merge      instructions.MergeStrategy
+	skillDirs  string
+	locale     string
+	repo       bool
+	listSkills bool
+}
+
+// stableContextCache holds the stable contexts of recent runs.
+type stableContextCache struct {
+	mu      sync.Mutex
+	entries map[stableContextKey]*stableContext
+}
+
+// stableContext returns the SOUL content and repository instructions for
+// req, reusing a previous run's when none of their files changed.
+func (l *AgentLoop) stableContext(ctx context.Context, req OrchestratorRequest, skillDirs []string) stableContext {
+	key := stableContextKey{
+		workDir:    req.WorkDir,
+		soulFile:   req.SoulFile,
+		candidates: strings.Join(req.InstructionFiles, "\x00"),
+		merge:      req.InstructionMerge,
+		skillDirs:  strings.Join(skillDirs, "\x00"),
+		locale:     req.Locale,
+		repo:       req.RepoInstructions == "" && req.WorkDir != "",
+		listSkills: req.SkillTools != SkillToolsOnly,
+	}
+	if cached, ok := l.stable.get(ctx, key); ok {
+		log.Printf("[orchestrator] reusing SOUL and repository instructions for %s: files unchanged", req.WorkDir)
+		return cached
+	}
+
+	var loaded stableContext
+	complete := true
+	soul := loadSoul(req.WorkDir, req.SoulFile)
+	loaded.soul = soul.Content
+	loaded.watched = soul.Watched
+	if key.repo {
+		repo := loadRepoInstructions(ctx, req.WorkDir, instructions.LoadOptions{
+			CandidateFiles: req.InstructionFiles,
+			Merge:          req.InstructionMerge,
+		}, skillDirs, req.Locale, key.listSkills)
+		loaded.repoInstructions = repo.content
+		loaded.watched = append(loaded.watched, repo.watched...)
+		complete = repo.complete
+	}
+	if complete {
+		// Content loaded with directories skipped is not worth keeping.
+		loaded.fingerprint = fingerprintFiles(loaded.watched)
+		l.stable.put(key, loaded, time.Now())
+	}
+	return loaded
+}
+
+// get returns the entry for key if its files are unchanged.
+func (c *stableContextCache) get(ctx context.Context, key stableContextKey) (stableContext, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return stableContext{}, false
+	}
+
+	// A stat on an unreachable network mount may hang; reload instead.
+	fingerprint, err := withTimeout(ctx, instructions.DefaultDirTimeout, func() string {
+		return fingerprintFiles(entry.watched)
+	})
+	if err != nil || fingerprint != entry.fingerprint {
+		return stableContext{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.usedAt = time.Now()
+	return *entry, true
+}
+
+// put stores an entry, evicting the least recently used one when full.
+func (c *stableContextCache) put(key stableContextKey, entry stableContext, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[stableContextKey]*stableContext)
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxStableContexts {
+		var oldest stableContextKey
+		var oldestAt time.Time
+		for k, e := range c.entries {
+			if oldestAt.IsZero() || e.usedAt.Before(oldestAt) {
+				oldest, oldestAt = k, e.usedAt
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	entry.usedAt = now
+	c.entries[key] = &entry
+}
+
+// fingerprintFiles hashes the size, modification time, and existence of
+// paths.
+func fingerprintFiles(paths []string) string {
+	h := sha256.New()
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(h, "%s\x00-\n", path)
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// withTimeout runs fn and stops waiting for it when timeout expires or ctx
+// is done.
+func withTimeout[T any](ctx context.Context, timeout time.Duration, fn func() T) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan T, 1)
+	go func() { done <- fn() }()
+	select {
+	case v := <-done:
+		return v, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
@@ -1,6 +1,8 @@
 package orchestrator
 
 import (
+	"log"
+
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
 )
@@ -19,11 +21,46 @@ type State struct {
 	// OutputTokens tracks cumulative output tokens.
 	OutputTokens int
 
+	// CachedInputTokens tracks cumulative input tokens read from the
+	// provider's prompt cache.
+	CachedInputTokens int
+
 	// ToolCalls records all tool calls made.
 	ToolCalls []ToolCallRecord
 
+	// ToolStats aggregates call counts and latency per tool name.
+	ToolStats map[string]ToolStats
+
+	// ToolPairRepairs counts the model calls whose context had orphaned
+	// tool_results (see OrchestratorRequest.ToolPairRepair).
+	ToolPairRepairs int
+
 	// LastResponse holds the most recent agent response.
 	LastResponse llm.AgentResponse
+
+	// toolCache memoizes tool results when ToolCacheConfig is enabled.
+	toolCache *toolCache
+
+	// skillTools are the run's skill tools (see
+	// OrchestratorRequest.SkillTools).
+	skillTools []tools.Tool
+
+	// spill moves old messages to disk when MessageSpillConfig is set.
+	// Messages then holds the first message and the recent window only.
+	spill *messageSpill
+
+	// relevance caches embeddings for relevance pruning.
+	relevance *relevancePruner
+
+	// profiler collects OrchestratorResult.Profile when profiling is on.
+	profiler *profiler
+
+	// clock times provider and tool calls (AgentLoop.Clock).
+	clock Clock
+
+	// provider and responses are reported in OrchestratorResult.
+	provider  string
+	responses []ResponseRecord
 }
 
 // NewState creates a new conversation state with initial messages.
@@ -31,32 +68,97 @@ func NewState(messages []llm.Message) *State {
 	return &State{
 		Messages:  append([]llm.Message{}, messages...),
 		ToolCalls: []ToolCallRecord{},
+		clock:     systemClock{},
 	}
 }
 
 // AddMessage appends a message to the conversation history.
 func (s *State) AddMessage(msg llm.Message) {
 	s.Messages = append(s.Messages, msg)
+	s.spillOldMessages()
+}
+
+// SpilledMessages returns the number of messages currently stored on disk
+// instead of in Messages.
+func (s *State) SpilledMessages() int {
+	if s.spill == nil {
+		return 0
+	}
+	return s.spill.count
+}
+
+// spillOldMessages moves a segment of the oldest messages to disk once the
+// in-memory window is full. Errors disable spilling for the rest of the run.
+func (s *State) spillOldMessages() {
+	if s.spill == nil || s.spill.failed {
+		return
+	}
+	cut := s.spill.spillCut(s.Messages)
+	if cut == 0 {
+		return
+	}
+	if err := s.spill.write(s.Messages[1:cut]); err != nil {
+		log.Printf("[orchestrator] WARNING: message spill failed, keeping history in memory: %v", err)
+		s.spill.failed = true
+		return
+	}
+	kept := make([]llm.Message, 0, len(s.Messages)-cut+1+s.spill.segmentSize)
+	kept = append(kept, s.Messages[0])
+	s.Messages = append(kept, s.Messages[cut:]...)
+}
+
+// history returns messages (the in-memory window or a transform of it)
+// with the spilled messages loaded back in after the first message.
+func (s *State) history(messages []llm.Message) []llm.Message {
+	if s.spill == nil || s.spill.count == 0 || len(messages) == 0 {
+		return messages
+	}
+	spilled, err := s.spill.load()
+	if err != nil {
+		log.Printf("[orchestrator] WARNING: load spilled messages: %v", err)
+		return messages
+	}
+	full := make([]llm.Message, 0, len(messages)+len(spilled))
+	full = append(full, messages[0])
+	full = append(full, spilled...)
+	return append(full, messages[1:]...)
+}
+
+// replaceMessages sets the history after compaction. The result covers
+// the spilled messages too, so their segments are dropped.
+func (s *State) replaceMessages(messages []llm.Message) {
+	s.Messages = messages
+	if s.spill != nil {
+		s.spill.reset()
+	}
 }
 
 // AddToolCall records a tool call.
 func (s *State) AddToolCall(name string, input map[string]any, result tools.ToolResult) {
-	s.ToolCalls = append(s.ToolCalls, ToolCallRecord{
+	s.RecordToolCall(ToolCallRecord{
 		Name:   name,
 		Input:  input,
 		Result: result,
 	})
 }
 
+// RecordToolCall records a tool call with all of its details.
+func (s *State) RecordToolCall(record ToolCallRecord) {
+	s.ToolCalls = append(s.ToolCalls, record)
+}
+
 // UpdateUsage updates token usage statistics.
 func (s *State) UpdateUsage(usage llm.Usage) {
 	s.InputTokens += usage.InputTokens
 	s.OutputTokens += usage.OutputTokens
+	s.CachedInputTokens += usage.CacheReadInputTokens
+	s.profiler.usage(usage)
 }
 
 // IncrementIteration increments the iteration counter.
 func (s *State) IncrementIteration() {
 	s.Iterations++
+	s.profiler.startIteration(s.Iterations)
 }
 
 // ToResult converts the state to an OrchestratorResult.
@@ -72,11 +174,17 @@ func (s *State) ToResult() OrchestratorResult {
 	}
 
 	return OrchestratorResult{
-		FinalMessage:      finalMessage,
-		Messages:          s.Messages,
-		TotalIterations:   s.Iterations,
-		TotalInputTokens:  s.InputTokens,
-		TotalOutputTokens: s.OutputTokens,
-		ToolCalls:         s.ToolCalls,
+		FinalMessage:           finalMessage,
+		Messages:               s.history(s.Messages),
+		TotalIterations:        s.Iterations,
+		TotalInputTokens:       s.InputTokens,
+		TotalOutputTokens:      s.OutputTokens,
+		TotalCachedInputTokens: s.CachedInputTokens,
+		ToolCalls:              s.ToolCalls,
+		ToolStats:              s.ToolStats,
+		ToolPairRepairs:        s.ToolPairRepairs,
+		Profile:                s.profiler.result(),
+		Provider:               s.provider,
+		Responses:              s.responses,
 	}
 }
@@ -1,9 +1,14 @@
 package orchestrator
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/encryption"
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
 )
 
@@ -112,3 +117,144 @@ func TestStateToResultNoAssistantMessage(t *testing.T) {
 		t.Errorf("FinalMessage.GetText() = %q, want empty", result.FinalMessage.GetText())
 	}
 }
+
+func TestStateSpillsOldMessages(t *testing.T) {
+	spill, err := newMessageSpill(context.Background(), MessageSpillConfig{Dir: t.TempDir(), KeepInMemory: 4, SegmentSize: 3}, 2)
+	if err != nil {
+		t.Fatalf("newMessageSpill: %v", err)
+	}
+	defer spill.close()
+
+	state := NewState([]llm.Message{llm.NewTextMessage(llm.RoleUser, "task")})
+	state.spill = spill
+	for i := 1; i <= 10; i++ {
+		msg := llm.NewTextMessage(llm.RoleAssistant, fmt.Sprintf("m%d", i))
+		if i == 1 {
+			msg = msg.WithMetadata(CompactSummaryMetadataKey, CompactSummary{Task: "task", Messages: 3})
+		}
+		state.AddMessage(msg)
+	}
+
+	// The first segment spills once 8 messages follow the task.
+	if state.SpilledMessages() != 3 || len(state.Messages) != 8 {
+		t.Fatalf("expected 3 spilled and 8 in memory, got %d and %d", state.SpilledMessages(), len(state.Messages))
+	}
+	if state.Messages[0].GetText() != "task" || state.Messages[1].GetText() != "m4" {
+		t.Fatalf("unexpected in-memory window: %q, %q", state.Messages[0].GetText(), state.Messages[1].GetText())
+	}
+
+	result := state.ToResult()
+	if len(result.Messages) != 11 {
+		t.Fatalf("expected full history in result, got %d messages", len(result.Messages))
+	}
+	for i, msg := range result.Messages[1:] {
+		if want := fmt.Sprintf("m%d", i+1); msg.GetText() != want {
+			t.Fatalf("result.Messages[%d] = %q, want %q", i+1, msg.GetText(), want)
+		}
+	}
+	if summary, ok := SummaryFromMessage(result.Messages[1]); !ok || summary.Task != "task" || summary.Messages != 3 {
+		t.Fatalf("expected spilled metadata to survive, got %+v (ok=%v)", summary, ok)
+	}
+
+	state.replaceMessages(result.Messages[:2])
+	if state.SpilledMessages() != 0 || len(state.ToResult().Messages) != 2 {
+		t.Fatalf("expected spilled messages dropped after replace, got %d spilled", state.SpilledMessages())
+	}
+}
+
+func TestStateSpillEncryptsSegments(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := encryption.NewKeyring("k1", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := MessageSpillConfig{Dir: t.TempDir(), KeepInMemory: 2, SegmentSize: 2, Encryptor: encryption.New(keyring)}
+	spill, err := newMessageSpill(context.Background(), cfg, 2)
+	if err != nil {
+		t.Fatalf("newMessageSpill: %v", err)
+	}
+	defer spill.close()
+
+	state := NewState([]llm.Message{llm.NewTextMessage(llm.RoleUser, "task")})
+	state.spill = spill
+	for i := 1; i <= 6; i++ {
+		state.AddMessage(llm.NewTextMessage(llm.RoleAssistant, fmt.Sprintf("secret-%d", i)))
+	}
+	if state.SpilledMessages() == 0 {
+		t.Fatal("expected messages to spill")
+	}
+	for _, path := range spill.segments {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !encryption.IsEncrypted(data) || strings.Contains(string(data), "secret") {
+			t.Fatalf("segment %s is not encrypted", path)
+		}
+	}
+	result := state.ToResult()
+	if len(result.Messages) != 7 || result.Messages[1].GetText() != "secret-1" {
+		t.Fatalf("expected the decrypted history, got %d messages", len(result.Messages))
+	}
+}
+
+func TestStateSpillKeepsToolPairs(t *testing.T) {
+	spill, err := newMessageSpill(context.Background(), MessageSpillConfig{Dir: t.TempDir(), KeepInMemory: 2, SegmentSize: 1}, 2)
+	if err != nil {
+		t.Fatalf("newMessageSpill: %v", err)
+	}
+	defer spill.close()
+
+	state := NewState([]llm.Message{llm.NewTextMessage(llm.RoleUser, "task")})
+	state.spill = spill
+	state.AddMessage(llm.Message{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeToolUse, ID: "t1", Name: "count"}}})
+	state.AddMessage(llm.NewToolResultMessage("t1", "ok", false))
+	state.AddMessage(llm.NewTextMessage(llm.RoleAssistant, "a"))
+	state.AddMessage(llm.NewTextMessage(llm.RoleUser, "b"))
+
+	if err := validateToolPairs(state.Messages); err != nil {
+		t.Fatalf("spill split a tool pair: %v", err)
+	}
+	if state.SpilledMessages() != 2 {
+		t.Fatalf("expected tool_use and tool_result spilled together, got %d spilled", state.SpilledMessages())
+	}
+}
+
+func TestRunWithMessageSpill(t *testing.T) {
+	responses := make([]llm.AgentResponse, 0, 13)
+	for i := 0; i < 12; i++ {
+		responses = append(responses, toolUseResponse(fmt.Sprintf("tool-%d", i), "count", map[string]any{"n": i}))
+	}
+	responses = append(responses, llm.AgentResponse{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}})
+	provider := &scriptedProvider{responses: responses}
+	registry := tools.NewRegistry()
+	registry.MustRegister(&countTool{})
+
+	dir := t.TempDir()
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     4,
+		MessageSpill:    MessageSpillConfig{Dir: dir, KeepInMemory: 4, SegmentSize: 2},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Messages) != 26 {
+		t.Fatalf("expected the full 26-message history, got %d", len(result.Messages))
+	}
+	if err := validateToolPairs(result.Messages); err != nil {
+		t.Fatalf("result history has broken tool pairs: %v", err)
+	}
+	for _, req := range provider.requests {
+		if len(req.Messages) > 5 {
+			t.Fatalf("expected truncated provider requests, got %d messages", len(req.Messages))
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected spill directory cleaned up, found %d entries", len(entries))
+	}
+}
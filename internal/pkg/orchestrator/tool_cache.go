@@ -0,0 +1,170 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// DefaultCacheableTools are the read-only built-in tools cached when
+// ToolCacheConfig.Tools is empty.
+var DefaultCacheableTools = []string{
+	"read_file",
+	"list_files",
+	"git_status",
+	"git_diff",
+	"git_log",
+	"list_skills",
+	"read_skill",
+}
+
+// DefaultCacheInvalidatingTools clear the cache when they run, in addition
+// to any tool implementing tools.WorkspaceWriter.
+var DefaultCacheInvalidatingTools = []string{
+	"write_file",
+	"bash",
+	"rollback_last_changes",
+	"git_add",
+	"git_commit",
+	"git_branch",
+}
+
+// ToolCacheConfig configures per-run memoization of tool results. Repeated
+// calls with the same tool, input, and working directory return the cached
+// result instead of re-executing.
+type ToolCacheConfig struct {
+	Enabled bool
+
+	// Tools lists the cacheable tool names (default DefaultCacheableTools).
+	Tools []string
+
+	// InvalidateOn lists tools that clear the cache when they run
+	// (default DefaultCacheInvalidatingTools).
+	InvalidateOn []string
+}
+
+// toolCache memoizes successful tool results for one run. A nil cache
+// caches nothing.
+type toolCache struct {
+	mu          sync.Mutex
+	cacheable   map[string]bool
+	invalidates map[string]bool
+	entries     map[string]tools.ToolResult
+}
+
+func newToolCache(cfg ToolCacheConfig) *toolCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	cacheable := cfg.Tools
+	if len(cacheable) == 0 {
+		cacheable = DefaultCacheableTools
+	}
+	invalidates := cfg.InvalidateOn
+	if len(invalidates) == 0 {
+		invalidates = DefaultCacheInvalidatingTools
+	}
+	return &toolCache{
+		cacheable:   toSet(cacheable),
+		invalidates: toSet(invalidates),
+		entries:     make(map[string]tools.ToolResult),
+	}
+}
+
+func toSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// cacheKey identifies a call. Inputs are marshalled with sorted keys, so
+// equal inputs produce equal keys.
+func cacheKey(name, workDir string, input map[string]any) (string, bool) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", false
+	}
+	return name + "\x00" + workDir + "\x00" + string(data), true
+}
+
+// get returns the cached result for a call.
+func (c *toolCache) get(name, workDir string, input map[string]any) (tools.ToolResult, bool) {
+	if c == nil || !c.cacheable[name] {
+		return tools.ToolResult{}, false
+	}
+	key, ok := cacheKey(name, workDir, input)
+	if !ok {
+		return tools.ToolResult{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.entries[key]
+	if !ok {
+		return tools.ToolResult{}, false
+	}
+	return copyResult(result).WithMetadata("cached", true), true
+}
+
+// put stores a successful result for a cacheable call.
+func (c *toolCache) put(name, workDir string, input map[string]any, result tools.ToolResult) {
+	if c == nil || !c.cacheable[name] || result.IsError {
+		return
+	}
+	key, ok := cacheKey(name, workDir, input)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = copyResult(result)
+}
+
+// uncache stops caching name's results.
+func (c *toolCache) uncache(name string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cacheable, name)
+}
+
+// observe clears the cache before a tool that may change the workspace runs.
+func (c *toolCache) observe(tool tools.Tool, name string) {
+	if c == nil || c.cacheable[name] {
+		return
+	}
+	_, writer := tool.(tools.WorkspaceWriter)
+	if !writer && !c.invalidates[name] {
+		return
+	}
+	c.clear("tool " + name)
+}
+
+// clear drops every cached result; reason names what invalidated them.
+func (c *toolCache) clear(reason string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) > 0 {
+		log.Printf("[orchestrator] %s invalidated %d cached result(s)", reason, len(c.entries))
+		c.entries = make(map[string]tools.ToolResult)
+	}
+}
+
+func copyResult(result tools.ToolResult) tools.ToolResult {
+	if result.Metadata != nil {
+		metadata := make(map[string]any, len(result.Metadata))
+		for k, v := range result.Metadata {
+			metadata[k] = v
+		}
+		result.Metadata = metadata
+	}
+	return result
+}
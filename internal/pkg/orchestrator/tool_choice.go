@@ -0,0 +1,70 @@
+package orchestrator
+
+import (
+	"fmt"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+// validateToolChoice checks that a tool choice is well formed and that a
+// specific tool it names is offered to the model.
+func validateToolChoice(choice *llm.ToolChoice, toolDefs []llm.ToolDefinition, serverTools []llm.ServerTool) error {
+	if choice == nil {
+		return nil
+	}
+	switch choice.Type {
+	case "", llm.ToolChoiceAuto, llm.ToolChoiceAny, llm.ToolChoiceNone:
+		return nil
+	case llm.ToolChoiceTool:
+	default:
+		return fmt.Errorf("unknown tool choice %q", choice.Type)
+	}
+	if choice.Name == "" {
+		return fmt.Errorf("tool choice %q requires a tool name", choice.Type)
+	}
+	for _, def := range toolDefs {
+		if def.Name == choice.Name {
+			return nil
+		}
+	}
+	for _, tool := range serverTools {
+		if tool.Name == choice.Name {
+			return nil
+		}
+	}
+	return fmt.Errorf("tool choice names unknown tool %q", choice.Name)
+}
+
+// applyToolChoice sets the tool choice of a provider call. A forced choice
+// (any, or a specific tool) is relaxed to auto once forcedDone, so the run
+// can finish with a text reply.
+func applyToolChoice(req OrchestratorRequest, forcedDone bool, agentReq *llm.AgentRequest) {
+	if req.ToolChoice == nil || len(agentReq.Tools)+len(agentReq.ServerTools) == 0 {
+		return
+	}
+	choice := *req.ToolChoice
+	if choice.Type == "" {
+		choice.Type = llm.ToolChoiceAuto
+	}
+	if forcedDone && choice.Forced() {
+		choice.Type, choice.Name = llm.ToolChoiceAuto, ""
+	}
+	if agentReq.ToolChoice != nil && agentReq.ToolChoice.DisableParallelToolUse {
+		choice.DisableParallelToolUse = true
+	}
+	agentReq.ToolChoice = &choice
+}
+
+// satisfiesToolChoice reports whether resp made the call a forced choice
+// asks for.
+func satisfiesToolChoice(choice *llm.ToolChoice, resp llm.AgentResponse) bool {
+	if choice == nil || !choice.Forced() {
+		return false
+	}
+	for _, use := range resp.GetToolUses() {
+		if choice.Type == llm.ToolChoiceAny || use.Name == choice.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package orchestrator
+
+import (
+	"log"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+)
+
+// ToolPairRepair selects how the validate_tool_pairs rule handles a context
+// window containing tool_results whose tool_use is not in the window, e.g.
+// after a transform cut the assistant message that made the call.
+type ToolPairRepair string
+
+const (
+	// ToolPairRepairDrop removes the orphaned tool_results from the
+	// window. A message left without content keeps a short note instead.
+	// It is the default.
+	ToolPairRepairDrop ToolPairRepair = "drop"
+
+	// ToolPairRepairStub keeps the orphaned tool_results and adds a
+	// placeholder tool_use for each to the preceding assistant message,
+	// or to a new assistant message inserted before them. The placeholder
+	// has the original tool's name when the history still has it, and
+	// empty input.
+	ToolPairRepairStub ToolPairRepair = "stub"
+
+	// ToolPairRepairFullHistory sends the entire stored history instead of
+	// the window, as before repairs existed. The history is unbounded and
+	// can itself exceed the context limit.
+	ToolPairRepairFullHistory ToolPairRepair = "full_history"
+)
+
+// orphanedToolName names placeholder tool_uses whose tool is unknown.
+const orphanedToolName = "unknown_tool"
+
+// repairToolPairs returns messages without orphaned tool_results, using
+// mode (ToolPairRepairDrop or ToolPairRepairStub), and the number of
+// tool_results repaired. history is searched for the names of stubbed
+// tools. Results with an empty ToolUseID, and stubs with no message before
+// them, are always dropped. Modified messages are copied.
+func repairToolPairs(messages, history []AgentMessage, mode ToolPairRepair, lang string) ([]AgentMessage, int) {
+	toolUseIDs := make(map[string]bool)
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type == llm.ContentTypeToolUse && block.ID != "" {
+				toolUseIDs[block.ID] = true
+			}
+		}
+	}
+
+	var toolNames map[string]string
+	out := make([]AgentMessage, 0, len(messages))
+	repaired := 0
+	for i, msg := range messages {
+		var kept, stubs []llm.ContentBlock
+		orphaned := 0
+		for j, block := range msg.Content {
+			if block.Type != llm.ContentTypeToolResult || (block.ToolUseID != "" && toolUseIDs[block.ToolUseID]) {
+				if orphaned > 0 {
+					kept = append(kept, block)
+				}
+				continue
+			}
+			if orphaned == 0 {
+				kept = append([]llm.ContentBlock(nil), msg.Content[:j]...)
+			}
+			orphaned++
+			if mode != ToolPairRepairStub || block.ToolUseID == "" || msg.Role != llm.RoleUser || i == 0 {
+				continue
+			}
+			if toolNames == nil {
+				toolNames = toolUseNames(history)
+			}
+			name := toolNames[block.ToolUseID]
+			if name == "" {
+				name = orphanedToolName
+			}
+			stubs = append(stubs, llm.ContentBlock{
+				Type:  llm.ContentTypeToolUse,
+				ID:    block.ToolUseID,
+				Name:  name,
+				Input: map[string]interface{}{},
+			})
+			// Later results for the same call are answered by this stub.
+			toolUseIDs[block.ToolUseID] = true
+			kept = append(kept, block)
+		}
+		if orphaned == 0 {
+			out = append(out, msg)
+			continue
+		}
+		repaired += orphaned
+
+		if len(stubs) > 0 {
+			if prev := &out[len(out)-1]; prev.Role == llm.RoleAssistant {
+				prev.Content = append(append([]llm.ContentBlock(nil), prev.Content...), stubs...)
+			} else {
+				out = append(out, AgentMessage{Role: llm.RoleAssistant, Content: stubs})
+			}
+		}
+		if len(kept) == 0 {
+			// Keep the message so that roles still alternate.
+			kept = []llm.ContentBlock{{Type: llm.ContentTypeText, Text: locale.Text(lang, locale.OrphanedToolResultsNote)}}
+		}
+		msg.Content = kept
+		out = append(out, msg)
+	}
+	if repaired > 0 {
+		log.Printf("[orchestrator] repaired %d orphaned tool_results (mode=%s)", repaired, mode)
+	}
+	return out, repaired
+}
+
+// toolUseNames maps the tool_use IDs in messages to tool names.
+func toolUseNames(messages []AgentMessage) map[string]string {
+	names := make(map[string]string)
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type == llm.ContentTypeToolUse && block.ID != "" {
+				names[block.ID] = block.Name
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,130 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func toolResultBlock(id string) llm.ContentBlock {
+	return llm.ContentBlock{Type: llm.ContentTypeToolResult, ToolUseID: id, Content: "result of " + id}
+}
+
+func TestRepairToolPairsDrop(t *testing.T) {
+	messages := []AgentMessage{
+		llm.NewTextMessage(llm.RoleUser, "task"),
+		{Role: llm.RoleUser, Content: []llm.ContentBlock{toolResultBlock("gone")}},
+		{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeToolUse, ID: "kept", Name: "bash"}}},
+		{Role: llm.RoleUser, Content: []llm.ContentBlock{toolResultBlock("kept"), toolResultBlock("")}},
+	}
+
+	repaired, n := repairToolPairs(messages, nil, ToolPairRepairDrop, "")
+	if n != 2 {
+		t.Fatalf("repaired %d tool_results, want 2", n)
+	}
+	if len(repaired) != len(messages) {
+		t.Fatalf("expected the window to keep %d messages, got %d", len(messages), len(repaired))
+	}
+	if note := repaired[1].Content; len(note) != 1 || note[0].Text != locale.Text("", locale.OrphanedToolResultsNote) {
+		t.Fatalf("expected an emptied message to hold the note, got %+v", note)
+	}
+	if kept := repaired[3].Content; len(kept) != 1 || kept[0].ToolUseID != "kept" {
+		t.Fatalf("expected the paired result to stay, got %+v", kept)
+	}
+	if err := validateToolPairs(repaired); err != nil {
+		t.Fatalf("repaired window is invalid: %v", err)
+	}
+	if len(messages[3].Content) != 2 {
+		t.Fatal("expected the input messages to be left unchanged")
+	}
+}
+
+func TestRepairToolPairsStub(t *testing.T) {
+	history := []AgentMessage{
+		llm.NewTextMessage(llm.RoleUser, "task"),
+		{Role: llm.RoleAssistant, Content: []llm.ContentBlock{{Type: llm.ContentTypeToolUse, ID: "a", Name: "read_file", Input: map[string]interface{}{"path": "big.log"}}}},
+	}
+	messages := []AgentMessage{
+		llm.NewTextMessage(llm.RoleUser, "task"),
+		{Role: llm.RoleUser, Content: []llm.ContentBlock{toolResultBlock("a")}},
+		llm.NewTextMessage(llm.RoleAssistant, "checking"),
+		{Role: llm.RoleUser, Content: []llm.ContentBlock{toolResultBlock("b"), toolResultBlock("")}},
+	}
+
+	repaired, n := repairToolPairs(messages, history, ToolPairRepairStub, "")
+	if n != 3 {
+		t.Fatalf("repaired %d tool_results, want 3", n)
+	}
+	if len(repaired) != 5 {
+		t.Fatalf("expected one inserted assistant message, got %d messages", len(repaired))
+	}
+	stub := repaired[1]
+	if stub.Role != llm.RoleAssistant || len(stub.Content) != 1 || stub.Content[0].ID != "a" || stub.Content[0].Name != "read_file" || len(stub.Content[0].Input) != 0 {
+		t.Fatalf("expected a read_file stub before the first result, got %+v", stub)
+	}
+	prev := repaired[3].Content
+	if len(prev) != 2 || prev[0].Text != "checking" || prev[1].ID != "b" || prev[1].Name != orphanedToolName {
+		t.Fatalf("expected a stub appended to the preceding assistant message, got %+v", prev)
+	}
+	if last := repaired[4].Content; len(last) != 1 || last[0].ToolUseID != "b" {
+		t.Fatalf("expected the result without an ID to be dropped, got %+v", last)
+	}
+	if err := validateToolPairs(repaired); err != nil {
+		t.Fatalf("repaired window is invalid: %v", err)
+	}
+	if len(messages[2].Content) != 1 {
+		t.Fatal("expected the input messages to be left unchanged")
+	}
+}
+
+func TestRunRepairsOrphanedToolResults(t *testing.T) {
+	// Dropping the assistant messages orphans the tool_result that follows.
+	dropAssistant := func(_ context.Context, messages []AgentMessage) ([]AgentMessage, error) {
+		var out []AgentMessage
+		for _, msg := range messages {
+			if msg.Role != llm.RoleAssistant {
+				out = append(out, msg)
+			}
+		}
+		return out, nil
+	}
+
+	for _, tt := range []struct {
+		mode         ToolPairRepair
+		wantMessages int
+	}{
+		{ToolPairRepairDrop, 2},
+		{ToolPairRepairStub, 3},
+		{ToolPairRepairFullHistory, 3},
+	} {
+		provider := &scriptedProvider{responses: []llm.AgentResponse{
+			toolUseResponse("tool-1", "count", map[string]any{"n": 1}),
+			{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+		}}
+		registry := tools.NewRegistry()
+		registry.MustRegister(&countTool{})
+
+		result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+			InitialMessages:  []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+			MaxMessages:      50,
+			TransformContext: dropAssistant,
+			ToolPairRepair:   tt.mode,
+		})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.mode, err)
+		}
+		if result.ToolPairRepairs != 1 {
+			t.Fatalf("%s: ToolPairRepairs = %d, want 1", tt.mode, result.ToolPairRepairs)
+		}
+		sent := provider.requests[1].Messages
+		if len(sent) != tt.wantMessages {
+			t.Fatalf("%s: sent %d messages, want %d: %+v", tt.mode, len(sent), tt.wantMessages, sent)
+		}
+		if err := validateToolPairs(sent); err != nil {
+			t.Fatalf("%s: sent an invalid window: %v", tt.mode, err)
+		}
+	}
+}
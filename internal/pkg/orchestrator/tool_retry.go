@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// defaultToolRetryDelay is the wait before the first retry when
+// ToolRetryConfig.Delay is unset.
+const defaultToolRetryDelay = 500 * time.Millisecond
+
+// ToolRetryConfig configures automatic retries of tool calls that fail with
+// a retryable error (tools.ToolResult.Retryable), such as a timeout or a
+// rate-limited API, before the failure is handed to the model.
+type ToolRetryConfig struct {
+	// MaxRetries is how many times a failed call is retried. Zero disables
+	// retries.
+	MaxRetries int
+
+	// Delay is the wait before the first retry (default 500ms); it doubles
+	// with each further attempt.
+	Delay time.Duration
+}
+
+// runToolWithRetries runs a tool, retrying retryable failures as configured
+// by req.ToolRetry. Execution errors become error results. When the call
+// was retried, the final result's Details["attempts"] holds the number of
+// attempts made.
+func (l *AgentLoop) runToolWithRetries(
+	ctx context.Context,
+	toolCtx *tools.ToolContext,
+	tool tools.Tool,
+	use llm.ContentBlock,
+	req OrchestratorRequest,
+) tools.ToolResult {
+	delay := req.ToolRetry.Delay
+	if delay <= 0 {
+		delay = defaultToolRetryDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		result, err := l.runTool(ctx, toolCtx, tool, use, req)
+		if err != nil {
+			log.Printf("[orchestrator] ERROR: tool %s execution error: %v", use.Name, err)
+			result = tools.NewErrorResult(err)
+		}
+		if !result.IsError || !result.Retryable || attempt >= req.ToolRetry.MaxRetries {
+			if attempt > 0 {
+				result = result.WithDetail("attempts", attempt+1)
+			}
+			return result
+		}
+
+		log.Printf("[orchestrator] tool %s failed (%s), retrying (%d/%d)",
+			use.Name, result.ErrorCode, attempt+1, req.ToolRetry.MaxRetries)
+		select {
+		case <-ctx.Done():
+			return result.WithDetail("attempts", attempt+1)
+		case <-time.After(delay << attempt):
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// ToolStats aggregates the calls of one tool within a run.
+type ToolStats struct {
+	// Calls is the number of calls the model made, including rejected ones.
+	Calls int
+
+	// Executed is the number of calls that actually ran the tool.
+	Executed int
+
+	// Errors is the number of calls that returned an error result.
+	Errors int
+
+	// CacheHits is the number of calls served from the tool cache.
+	CacheHits int
+
+	// Rejected is the number of calls refused before running, by the
+	// active skill's tool allowlist or an exhausted ToolBudgets entry.
+	Rejected int
+
+	// TotalDuration and MaxDuration cover executed calls only.
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// AvgDuration returns the mean execution time of executed calls.
+func (s ToolStats) AvgDuration() time.Duration {
+	if s.Executed == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Executed)
+}
+
+// toolCallOutcome describes one finished call for ToolStats.
+type toolCallOutcome struct {
+	executed bool
+	cached   bool
+	rejected bool
+	duration time.Duration
+}
+
+// recordToolStats adds one call of name to the run's tool statistics.
+func (s *State) recordToolStats(name string, result tools.ToolResult, outcome toolCallOutcome) {
+	if s.ToolStats == nil {
+		s.ToolStats = make(map[string]ToolStats)
+	}
+	stats := s.ToolStats[name]
+	stats.Calls++
+	if result.IsError {
+		stats.Errors++
+	}
+	switch {
+	case outcome.rejected:
+		stats.Rejected++
+	case outcome.cached:
+		stats.CacheHits++
+	case outcome.executed:
+		stats.Executed++
+		stats.TotalDuration += outcome.duration
+		if outcome.duration > stats.MaxDuration {
+			stats.MaxDuration = outcome.duration
+		}
+	}
+	s.ToolStats[name] = stats
+	s.profiler.tool(name, result, outcome)
+}
+
+// checkToolBudget returns a "budget exhausted" result when name has used up
+// its ToolBudgets allowance. Non-positive budgets are ignored.
+func checkToolBudget(req OrchestratorRequest, state *State, name string) (tools.ToolResult, bool) {
+	limit := req.ToolBudgets[name]
+	if limit <= 0 {
+		return tools.ToolResult{}, false
+	}
+	stats := state.ToolStats[name]
+	if stats.Calls-stats.Rejected < limit {
+		return tools.ToolResult{}, false
+	}
+	result := tools.NewErrorResultf(
+		"tool budget exhausted: %s may be called at most %d times in this run. "+
+			"Do not call it again; continue with other tools or finish with the information you have.",
+		name, limit)
+	return result.
+		WithMetadata("budget_exhausted", true).
+		WithMetadata("tool", name).
+		WithMetadata("budget", limit), true
+}
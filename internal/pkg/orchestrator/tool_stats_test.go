@@ -0,0 +1,52 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestRunEnforcesToolBudgets(t *testing.T) {
+	provider := &scriptedProvider{responses: []llm.AgentResponse{
+		toolUseResponse("tool-1", "count", map[string]any{"n": 1}),
+		toolUseResponse("tool-2", "count", map[string]any{"n": 2}),
+		toolUseResponse("tool-3", "count", map[string]any{"n": 3}),
+		toolUseResponse("tool-4", "missing", map[string]any{}),
+		{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+	}}
+	tool := &countTool{}
+	registry := tools.NewRegistry()
+	registry.MustRegister(tool)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "count")},
+		MaxMessages:     50,
+		ToolBudgets:     map[string]int{"count": 2},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(tool.calls) != 2 {
+		t.Fatalf("expected tool to execute twice, got %d", len(tool.calls))
+	}
+	third := result.ToolCalls[2].Result
+	if !third.IsError || !strings.Contains(third.Content, "tool budget exhausted") || third.Metadata["budget_exhausted"] != true {
+		t.Fatalf("expected budget exhausted result, got %#v", third)
+	}
+
+	count := result.ToolStats["count"]
+	if count.Calls != 3 || count.Executed != 2 || count.Rejected != 1 || count.Errors != 1 {
+		t.Fatalf("unexpected count stats: %+v", count)
+	}
+	if count.MaxDuration <= 0 || count.AvgDuration() > count.MaxDuration {
+		t.Fatalf("unexpected count latency: avg=%s max=%s", count.AvgDuration(), count.MaxDuration)
+	}
+	missing := result.ToolStats["missing"]
+	if missing.Calls != 1 || missing.Executed != 0 || missing.Errors != 1 {
+		t.Fatalf("unexpected missing tool stats: %+v", missing)
+	}
+}
@@ -0,0 +1,224 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+// LoopInputMetadataKey marks steering and follow-up messages injected into
+// a run; the value is LoopInputSteering or LoopInputFollowUp.
+const LoopInputMetadataKey = "loop_input"
+
+const (
+	LoopInputSteering = "steering"
+	LoopInputFollowUp = "follow_up"
+)
+
+// TraceFormat selects the diagram language of ExportTrace.
+type TraceFormat string
+
+const (
+	// TraceMermaid renders a Mermaid sequence diagram.
+	TraceMermaid TraceFormat = "mermaid"
+	// TraceDOT renders a Graphviz digraph.
+	TraceDOT TraceFormat = "dot"
+)
+
+// traceLabelRunes bounds the text quoted from messages in a trace.
+const traceLabelRunes = 60
+
+type traceStepKind int
+
+const (
+	traceTask traceStepKind = iota
+	traceIteration
+	traceToolCall
+	traceSteering
+	traceFollowUp
+	traceCompaction
+	traceReply
+)
+
+// traceStep is one node of an execution trace.
+type traceStep struct {
+	kind    traceStepKind
+	label   string
+	isError bool
+}
+
+// Trace renders the run's conversation with ExportTrace.
+func (r OrchestratorResult) Trace(format TraceFormat) (string, error) {
+	return ExportTrace(r.Messages, format)
+}
+
+// ExportTrace renders a conversation as a diagram of its tasks, model
+// iterations, tool calls (failed ones marked), steering and follow-up
+// injections, compactions, and final replies.
+func ExportTrace(messages []llm.Message, format TraceFormat) (string, error) {
+	steps := traceSteps(messages)
+	switch format {
+	case TraceMermaid:
+		return renderMermaidTrace(steps), nil
+	case TraceDOT:
+		return renderDOTTrace(steps), nil
+	default:
+		return "", fmt.Errorf("unknown trace format %q (want %q or %q)", format, TraceMermaid, TraceDOT)
+	}
+}
+
+func traceSteps(messages []llm.Message) []traceStep {
+	failed := make(map[string]bool)
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type == llm.ContentTypeToolResult && block.IsError {
+				failed[block.ToolUseID] = true
+			}
+		}
+	}
+
+	var steps []traceStep
+	iteration := 0
+	for _, msg := range messages {
+		if summary, ok := SummaryFromMessage(msg); ok {
+			steps = append(steps, traceStep{kind: traceCompaction, label: fmt.Sprintf("compacted %d messages", summary.Messages)})
+			continue
+		}
+		text := msg.GetText()
+		switch msg.Role {
+		case llm.RoleAssistant:
+			iteration++
+			uses := msg.GetToolUses()
+			steps = append(steps, traceStep{kind: traceIteration, label: fmt.Sprintf("iteration %d", iteration)})
+			for _, use := range uses {
+				steps = append(steps, traceStep{kind: traceToolCall, label: use.Name, isError: failed[use.ID]})
+			}
+			if len(uses) == 0 && text != "" {
+				steps = append(steps, traceStep{kind: traceReply, label: text})
+			}
+		default:
+			if text == "" {
+				continue // tool results are drawn with their calls
+			}
+			kind := traceTask
+			switch msg.Metadata[LoopInputMetadataKey] {
+			case LoopInputSteering:
+				kind = traceSteering
+			case LoopInputFollowUp:
+				kind = traceFollowUp
+			}
+			steps = append(steps, traceStep{kind: kind, label: text})
+		}
+	}
+	return steps
+}
+
+func renderMermaidTrace(steps []traceStep) string {
+	var b strings.Builder
+	b.WriteString("sequenceDiagram\n")
+	b.WriteString("    participant U as User\n")
+	b.WriteString("    participant A as Agent\n")
+	b.WriteString("    participant T as Tools\n")
+	for _, step := range steps {
+		label := mermaidText(step.label)
+		switch step.kind {
+		case traceTask:
+			fmt.Fprintf(&b, "    U->>A: task: %s\n", label)
+		case traceIteration:
+			fmt.Fprintf(&b, "    Note over A: %s\n", label)
+		case traceToolCall:
+			fmt.Fprintf(&b, "    A->>T: %s\n", label)
+			if step.isError {
+				fmt.Fprintf(&b, "    T--xA: %s failed\n", label)
+			} else {
+				fmt.Fprintf(&b, "    T-->>A: %s ok\n", label)
+			}
+		case traceSteering:
+			fmt.Fprintf(&b, "    U-)A: steering: %s\n", label)
+		case traceFollowUp:
+			fmt.Fprintf(&b, "    U-)A: follow-up: %s\n", label)
+		case traceCompaction:
+			fmt.Fprintf(&b, "    Note over U,T: %s\n", label)
+		case traceReply:
+			fmt.Fprintf(&b, "    A->>U: %s\n", label)
+		}
+	}
+	return b.String()
+}
+
+func renderDOTTrace(steps []traceStep) string {
+	var b strings.Builder
+	b.WriteString("digraph trace {\n")
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+
+	// The main chain links tasks, iterations, injections, compactions, and
+	// replies in order; tool calls hang off their iteration.
+	prev := ""
+	parent := ""
+	for i, step := range steps {
+		id := "s" + strconv.Itoa(i)
+		label := dotText(step.label)
+		var attrs string
+		switch step.kind {
+		case traceTask:
+			attrs = fmt.Sprintf("label=\"task: %s\", shape=oval", label)
+		case traceIteration:
+			attrs = fmt.Sprintf("label=\"%s\"", label)
+		case traceToolCall:
+			if step.isError {
+				attrs = fmt.Sprintf("label=\"%s\", shape=ellipse, color=red", label)
+			} else {
+				attrs = fmt.Sprintf("label=\"%s\", shape=ellipse", label)
+			}
+		case traceSteering:
+			attrs = fmt.Sprintf("label=\"steering: %s\", shape=parallelogram", label)
+		case traceFollowUp:
+			attrs = fmt.Sprintf("label=\"follow-up: %s\", shape=parallelogram", label)
+		case traceCompaction:
+			attrs = fmt.Sprintf("label=\"%s\", shape=note", label)
+		case traceReply:
+			attrs = fmt.Sprintf("label=\"reply: %s\", shape=oval", label)
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", id, attrs)
+
+		if step.kind == traceToolCall {
+			if parent != "" {
+				fmt.Fprintf(&b, "  %s -> %s;\n", parent, id)
+			}
+			continue
+		}
+		if prev != "" {
+			fmt.Fprintf(&b, "  %s -> %s;\n", prev, id)
+		}
+		prev = id
+		if step.kind == traceIteration {
+			parent = id
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// traceLabel flattens text to one line of at most traceLabelRunes runes.
+func traceLabel(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= traceLabelRunes {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:traceLabelRunes-3]) + "..."
+}
+
+// mermaidText escapes characters Mermaid treats as syntax in messages.
+func mermaidText(text string) string {
+	return strings.NewReplacer("#", "#35;", ";", "#59;").Replace(traceLabel(text))
+}
+
+// dotText escapes a DOT double-quoted string.
+func dotText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(traceLabel(text))
+}
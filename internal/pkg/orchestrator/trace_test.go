@@ -0,0 +1,101 @@
+package orchestrator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+func traceTestMessages() []llm.Message {
+	return []llm.Message{
+		llm.NewTextMessage(llm.RoleUser, "fix the build; see #12"),
+		{Role: llm.RoleAssistant, Content: []llm.ContentBlock{
+			{Type: llm.ContentTypeToolUse, ID: "t1", Name: "bash"},
+			{Type: llm.ContentTypeToolUse, ID: "t2", Name: "read_file"},
+		}},
+		{Role: llm.RoleUser, Content: []llm.ContentBlock{
+			{Type: llm.ContentTypeToolResult, ToolUseID: "t1", Content: "exit 1", IsError: true},
+			{Type: llm.ContentTypeToolResult, ToolUseID: "t2", Content: "package main"},
+		}},
+		llm.NewTextMessage(llm.RoleUser, "use go 1.24").WithMetadata(LoopInputMetadataKey, LoopInputSteering),
+		llm.NewTextMessage(llm.RoleAssistant, "summary").WithMetadata(CompactSummaryMetadataKey, CompactSummary{Messages: 8}),
+		llm.NewTextMessage(llm.RoleAssistant, `Fixed the "go.mod" version.`),
+	}
+}
+
+func TestExportTraceMermaid(t *testing.T) {
+	got, err := ExportTrace(traceTestMessages(), TraceMermaid)
+	if err != nil {
+		t.Fatalf("ExportTrace: %v", err)
+	}
+	want := `sequenceDiagram
+    participant U as User
+    participant A as Agent
+    participant T as Tools
+    U->>A: task: fix the build#59; see #35;12
+    Note over A: iteration 1
+    A->>T: bash
+    T--xA: bash failed
+    A->>T: read_file
+    T-->>A: read_file ok
+    U-)A: steering: use go 1.24
+    Note over U,T: compacted 8 messages
+    Note over A: iteration 2
+    A->>U: Fixed the "go.mod" version.
+`
+	if got != want {
+		t.Fatalf("unexpected mermaid trace:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExportTraceDOT(t *testing.T) {
+	got, err := ExportTrace(traceTestMessages(), TraceDOT)
+	if err != nil {
+		t.Fatalf("ExportTrace: %v", err)
+	}
+	for _, want := range []string{
+		`s0 [label="task: fix the build; see #12", shape=oval];`,
+		`s1 [label="iteration 1"];`,
+		`s2 [label="bash", shape=ellipse, color=red];`,
+		`s1 -> s2;`,
+		`s1 -> s3;`,
+		`s1 -> s4;`,
+		`s4 [label="steering: use go 1.24", shape=parallelogram];`,
+		`s5 [label="compacted 8 messages", shape=note];`,
+		`s7 [label="reply: Fixed the \"go.mod\" version.", shape=oval];`,
+		`s6 -> s7;`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in DOT trace:\n%s", want, got)
+		}
+	}
+}
+
+func TestExportTraceRejectsUnknownFormat(t *testing.T) {
+	if _, err := ExportTrace(traceTestMessages(), "svg"); err == nil {
+		t.Fatal("expected unknown format error")
+	}
+}
+
+func TestTraceLabelTruncates(t *testing.T) {
+	got := traceLabel(strings.Repeat("word ", 30))
+	if len([]rune(got)) != traceLabelRunes || !strings.HasSuffix(got, "...") {
+		t.Fatalf("unexpected label %q", got)
+	}
+}
+
+func TestApplyLoopInputsMarksMessages(t *testing.T) {
+	state := NewState(nil)
+	loop := &AgentLoop{}
+	loop.applyLoopInputs(state, OrchestratorRequest{},
+		[]llm.Message{llm.NewTextMessage(llm.RoleUser, "steer")},
+		[]llm.Message{llm.NewTextMessage(llm.RoleUser, "follow")})
+
+	messages := state.Messages
+	if len(messages) != 2 ||
+		messages[0].Metadata[LoopInputMetadataKey] != LoopInputSteering ||
+		messages[1].Metadata[LoopInputMetadataKey] != LoopInputFollowUp {
+		t.Fatalf("unexpected loop input metadata: %+v", messages)
+	}
+}
@@ -0,0 +1,42 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
+)
+
+// beginCheckpoint snapshots the files a write-capable tool may modify.
+// It reports whether a checkpoint was opened; callers must then call
+// journal.End once the tool has run. A failed snapshot is returned as an
+// error and the tool must not run, since its changes could be neither
+// rolled back nor reported.
+func beginCheckpoint(journal *workspace.Journal, toolCtx *tools.ToolContext, tool tools.Tool, use llm.ContentBlock) (bool, error) {
+	writer, ok := tool.(tools.WorkspaceWriter)
+	if journal == nil || !ok {
+		return false, nil
+	}
+
+	paths := writer.WritePaths(toolCtx, use.Input)
+	if paths != nil && len(paths) == 0 {
+		return false, nil
+	}
+
+	journal.Begin(use.Name + " (" + use.ID + ")")
+	var err error
+	if paths == nil {
+		err = journal.SnapshotTree()
+	} else {
+		err = journal.SnapshotFiles(paths...)
+	}
+	if err != nil {
+		log.Printf("[orchestrator] WARNING: workspace snapshot for %s failed, refusing the call: %v", use.Name, err)
+		journal.End()
+		return false, fmt.Errorf("%s was not run: the workspace could not be snapshotted so its changes could be rolled back (%v); "+
+			"use tools that write specific files, such as write_file, instead", use.Name, err)
+	}
+	return true, nil
+}
@@ -60,3 +60,52 @@ func TestBuildTransformPluginsCanDisableBuiltins(t *testing.T) {
 		t.Fatalf("plugin name = %q, want %q", plugins[0].name, "user_transform_context")
 	}
 }
+
+func TestBuildTransformPluginsPlacesContextTransforms(t *testing.T) {
+	state := NewState([]llm.Message{
+		llm.NewTextMessage(llm.RoleUser, "hello"),
+	})
+	identity := func(_ context.Context, messages []AgentMessage) ([]AgentMessage, error) {
+		return messages, nil
+	}
+	req := OrchestratorRequest{
+		CompactConfig: CompactConfig{Enabled: true, Threshold: 1, KeepRecent: 1},
+		ContextTransforms: []ContextTransform{
+			{Name: "last", Stage: ContextStageAfterValidation, Transform: identity},
+			{Name: "low", Stage: ContextStageAfterCompaction, Priority: 1, Transform: identity},
+			{Name: "default_stage", Transform: identity},
+			{Name: "high", Stage: ContextStageAfterCompaction, Priority: 10, Transform: identity},
+			{Name: "replaced", Stage: ContextStageAfterTruncation, Transform: identity},
+			{Name: "replaced", Stage: ContextStageAfterValidation, Transform: identity},
+		},
+	}
+	compactor := &Compactor{config: req.CompactConfig}
+
+	var names []string
+	for _, plugin := range buildTransformPlugins(req, state, compactor, 20) {
+		names = append(names, plugin.name)
+	}
+	want := []string{
+		"default_stage",
+		"compact_context",
+		"high",
+		"low",
+		"truncate_context",
+		"validate_tool_pairs",
+		"last",
+		"replaced",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("plugin names = %v, want %v", names, want)
+	}
+
+	req.DisableDefaultContextRules = true
+	names = nil
+	for _, plugin := range buildTransformPlugins(req, state, nil, 20) {
+		names = append(names, plugin.name)
+	}
+	want = []string{"default_stage", "high", "low", "last", "replaced"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("plugin names without defaults = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,39 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+)
+
+// workDirChangesMessage drains the workdir watcher and describes the files
+// changed outside the agent as a user message. Cached tool results may show
+// the old contents, so any change clears state's tool cache. ok is false
+// when nothing changed or watching is disabled.
+func workDirChangesMessage(req OrchestratorRequest, state *State) (llm.Message, bool) {
+	if req.WorkDirWatcher == nil {
+		return llm.Message{}, false
+	}
+	changes := req.WorkDirWatcher.Changes()
+	if len(changes) == 0 {
+		return llm.Message{}, false
+	}
+	log.Printf("[orchestrator] %d file(s) changed outside tool calls", len(changes))
+	state.toolCache.clear("external file changes")
+
+	var b strings.Builder
+	b.WriteString(locale.Text(req.Locale, locale.WorkDirChangedHeader))
+	limit := req.WorkDirWatcher.MaxFiles()
+	for i, change := range changes {
+		if i == limit {
+			b.WriteString("\n")
+			fmt.Fprintf(&b, locale.Text(req.Locale, locale.WorkDirChangedMore), len(changes)-limit)
+			break
+		}
+		fmt.Fprintf(&b, "\n- %s (%s)", change.Path, change.Op)
+	}
+	return llm.NewTextMessage(llm.RoleUser, b.String()), true
+}
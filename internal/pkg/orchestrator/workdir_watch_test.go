@@ -0,0 +1,138 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/tools/builtin"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
+)
+
+// editingProvider edits a file on its call with index editAt (the first by
+// default), as a human working alongside the agent would.
+type editingProvider struct {
+	scriptedProvider
+	path   string
+	editAt int
+}
+
+func (p *editingProvider) Call(ctx context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	if len(p.requests) == p.editAt {
+		if err := os.WriteFile(p.path, []byte("edited"), 0o644); err != nil {
+			return llm.AgentResponse{}, err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return p.scriptedProvider.Call(ctx, req)
+}
+
+// touchTool writes agent.txt in its directory.
+type touchTool struct{ dir string }
+
+func (t *touchTool) Name() string                { return "touch" }
+func (t *touchTool) Description() string         { return "writes agent.txt" }
+func (t *touchTool) InputSchema() map[string]any { return map[string]any{"type": "object"} }
+
+func (t *touchTool) Execute(_ context.Context, _ *tools.ToolContext, _ map[string]any) (tools.ToolResult, error) {
+	if err := os.WriteFile(filepath.Join(t.dir, "agent.txt"), []byte("a"), 0o644); err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+	return tools.NewToolResult("ok"), nil
+}
+
+func TestRunReportsExternalFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	watcher, err := workspace.NewWatcher(dir, workspace.WatchConfig{})
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	defer watcher.Close()
+
+	provider := &editingProvider{
+		scriptedProvider: scriptedProvider{responses: []llm.AgentResponse{
+			toolUseResponse("tool-1", "touch", map[string]any{}),
+			{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+		}},
+		path: filepath.Join(dir, "notes.md"),
+	}
+	registry := tools.NewRegistry()
+	registry.MustRegister(&touchTool{dir: dir})
+
+	var followUps []llm.Message
+	_, err = NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages:   []llm.Message{llm.NewTextMessage(llm.RoleUser, "edit")},
+		WorkDirWatcher:    watcher,
+		OnFollowUpApplied: func(messages []llm.Message) { followUps = append(followUps, messages...) },
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(followUps) != 1 {
+		t.Fatalf("expected one follow-up message, got %d", len(followUps))
+	}
+	text := followUps[0].GetText()
+	if !strings.Contains(text, "notes.md (created)") {
+		t.Fatalf("follow-up = %q, want notes.md listed", text)
+	}
+	if strings.Contains(text, "agent.txt") {
+		t.Fatalf("follow-up = %q, should not list the agent's own writes", text)
+	}
+	last := provider.requests[1].Messages
+	if got := last[len(last)-1].GetText(); got != text {
+		t.Fatalf("expected the provider to see the follow-up, got %q", got)
+	}
+}
+
+func TestRunClearsToolCacheOnExternalFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.md")
+	if err := os.WriteFile(path, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The edit follows the first read at once, so keep it out of the grace
+	// period that attributes changes to the agent.
+	watcher, err := workspace.NewWatcher(dir, workspace.WatchConfig{Grace: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	defer watcher.Close()
+
+	read := map[string]any{"path": "notes.md"}
+	provider := &editingProvider{
+		scriptedProvider: scriptedProvider{responses: []llm.AgentResponse{
+			toolUseResponse("tool-1", "read_file", read),
+			toolUseResponse("tool-2", "read_file", read),
+			toolUseResponse("tool-3", "read_file", read),
+			{Role: llm.RoleAssistant, StopReason: llm.StopReasonEndTurn, Content: []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}}},
+		}},
+		path:   path,
+		editAt: 1,
+	}
+	registry := tools.NewRegistry()
+	builtin.RegisterFileTools(registry)
+
+	result, err := NewAgentLoop(provider, registry).Run(context.Background(), OrchestratorRequest{
+		InitialMessages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "read notes")},
+		WorkDir:         dir,
+		WorkDirWatcher:  watcher,
+		ToolCache:       ToolCacheConfig{Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.ToolCalls) != 3 {
+		t.Fatalf("expected 3 tool calls, got %d", len(result.ToolCalls))
+	}
+	// The change is reported after the second read; the third must not be
+	// served the original content from the cache.
+	last := result.ToolCalls[2].Result
+	if cached, _ := last.Metadata["cached"].(bool); cached || last.Content != "edited" {
+		t.Fatalf("expected a fresh read after the change was reported, got %+v", last)
+	}
+}
@@ -3,8 +3,118 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/internal/pkg/orchestrator"
+	"github.com/MimeLyc/agent-core-go/pkg/mcp"
+)
+
+// ErrContextOverflow is matched (via errors.Is) by execution errors returned
+// when the provider keeps rejecting the request as exceeding the context
+// window after the loop's shrink-and-retry attempts.
+var ErrContextOverflow = llm.ErrContextOverflow
+
+// ErrAgentStuck is matched (via errors.Is) by execution errors returned when
+// loop detection (AgentOptions.LoopDetection) aborts a run that kept
+// repeating the same tool calls. errors.As with *StuckError gives the details.
+var ErrAgentStuck = orchestrator.ErrAgentStuck
+
+// StuckError describes the loop that aborted a run.
+type StuckError = orchestrator.StuckError
+
+// LoopPattern is the kind of loop in a StuckError.
+type LoopPattern = orchestrator.LoopPattern
+
+const (
+	LoopRepeat      = orchestrator.LoopRepeat
+	LoopOscillation = orchestrator.LoopOscillation
+)
+
+// Clock tells an APIAgent the time for the durations it reports
+// (APIAgentOptions.Clock).
+type Clock = orchestrator.Clock
+
+// IDGenerator generates replacement tool_use IDs
+// (APIAgentOptions.IDGenerator).
+type IDGenerator = orchestrator.IDGenerator
+
+// ManualClock is a Clock for deterministic tests; see NewManualClock.
+type ManualClock = orchestrator.ManualClock
+
+// SequentialIDs is an IDGenerator returning Prefix followed by 1, 2, 3, ...
+type SequentialIDs = orchestrator.SequentialIDs
+
+// NewManualClock returns a clock at start that advances by step each time
+// it is read, so that every measured duration is a multiple of step.
+func NewManualClock(start time.Time, step time.Duration) *ManualClock {
+	return orchestrator.NewManualClock(start, step)
+}
+
+// SkillToolsMode selects whether skills are also offered to the model as
+// tools (APIConfig.SkillTools).
+type SkillToolsMode = orchestrator.SkillToolsMode
+
+const (
+	SkillToolsOff        = orchestrator.SkillToolsOff
+	SkillToolsAdditional = orchestrator.SkillToolsAdditional
+	SkillToolsOnly       = orchestrator.SkillToolsOnly
+)
+
+// ToolPairRepair selects how a context window with orphaned tool_results
+// is repaired (APIConfig.ToolPairRepair).
+type ToolPairRepair = orchestrator.ToolPairRepair
+
+const (
+	ToolPairRepairDrop        = orchestrator.ToolPairRepairDrop
+	ToolPairRepairStub        = orchestrator.ToolPairRepairStub
+	ToolPairRepairFullHistory = orchestrator.ToolPairRepairFullHistory
 )
 
+// ResponseValidation selects lenient or strict handling of malformed
+// responses from OpenAI-compatible backends (APIConfig.ResponseValidation).
+type ResponseValidation = llm.ResponseValidation
+
+const (
+	ResponseValidationLenient = llm.ResponseValidationLenient
+	ResponseValidationStrict  = llm.ResponseValidationStrict
+)
+
+// InstructionRoles selects how system and developer messages reach the
+// provider (APIConfig.InstructionRoles).
+type InstructionRoles = llm.InstructionRoles
+
+const (
+	InstructionRolesNative = llm.InstructionRolesNative
+	InstructionRolesSystem = llm.InstructionRolesSystem
+	InstructionRolesUser   = llm.InstructionRolesUser
+)
+
+// ResponseRepair describes a defect repaired in a provider response, as
+// listed in ResponseMetadata.Repairs.
+type ResponseRepair = llm.ResponseRepair
+
+// RepairKind classifies a ResponseRepair.
+type RepairKind = llm.RepairKind
+
+const (
+	RepairToolArguments          = llm.RepairToolArguments
+	RepairTruncatedToolArguments = llm.RepairTruncatedToolArguments
+	RepairInvalidToolArguments   = llm.RepairInvalidToolArguments
+	RepairMissingToolCallID      = llm.RepairMissingToolCallID
+	RepairMissingToolName        = llm.RepairMissingToolName
+	RepairFinishReason           = llm.RepairFinishReason
+)
+
+// ResponseValidationError is the execution error (matched with errors.As)
+// when ResponseValidationStrict rejects a malformed response.
+type ResponseValidationError = llm.ResponseValidationError
+
+// ErrNotTransactional is returned when rolling back a result from an
+// execution that did not run with AgentOptions.Transactional.
+var ErrNotTransactional = errors.New("execution was not transactional")
+
 // Agent is the unified interface for all agent implementations.
 // It abstracts the differences between local orchestrator and external agents.
 type Agent interface {
@@ -23,28 +133,84 @@ type Agent interface {
 	Close() error
 }
 
+// HealthChecker is implemented by agents that can check that their backend
+// is reachable and accepts their credentials, e.g. for a readiness probe.
+// APIAgent checks its provider when the provider supports it.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ErrUnauthorized is matched (via errors.Is) by health check errors when the
+// provider rejected the configured credentials.
+var ErrUnauthorized = llm.ErrUnauthorized
+
+// Compacter is implemented by agents that can compact a conversation on
+// demand instead of waiting for the compaction threshold mid-run.
+type Compacter interface {
+	// Compact summarizes all but the first and the most recent messages.
+	Compact(ctx context.Context, req CompactRequest) (CompactResult, error)
+}
+
 // AgentEventType identifies stream event categories.
 type AgentEventType string
 
 const (
-	AgentEventAgentStart      AgentEventType = "agent_start"
-	AgentEventMessageDelta    AgentEventType = "message_delta"
-	AgentEventMessageEnd      AgentEventType = "message_end"
-	AgentEventToolCall        AgentEventType = "tool_call"
-	AgentEventToolResult      AgentEventType = "tool_result"
-	AgentEventSteeringApplied AgentEventType = "steering_applied"
-	AgentEventFollowUpApplied AgentEventType = "followup_applied"
-	AgentEventAgentEnd        AgentEventType = "agent_end"
+	AgentEventAgentStart        AgentEventType = "agent_start"
+	AgentEventMessageDelta      AgentEventType = "message_delta"
+	AgentEventMessageEnd        AgentEventType = "message_end"
+	AgentEventPartialJSON       AgentEventType = "partial_json"
+	AgentEventThinking          AgentEventType = "thinking"
+	AgentEventToolCallStart     AgentEventType = "tool_call_start"
+	AgentEventToolCallDelta     AgentEventType = "tool_call_delta"
+	AgentEventToolCallReady     AgentEventType = "tool_call_ready"
+	AgentEventToolCall          AgentEventType = "tool_call"
+	AgentEventToolOutput        AgentEventType = "tool_output"
+	AgentEventToolResult        AgentEventType = "tool_result"
+	AgentEventSteeringApplied   AgentEventType = "steering_applied"
+	AgentEventFollowUpApplied   AgentEventType = "followup_applied"
+	AgentEventRateLimited       AgentEventType = "rate_limited"
+	AgentEventUsageUpdate       AgentEventType = "usage_update"
+	AgentEventUserInputRequired AgentEventType = "user_input_required"
+	AgentEventOutputGuarded     AgentEventType = "output_guarded"
+	AgentEventAgentEnd          AgentEventType = "agent_end"
 )
 
 // AgentStreamEvent is a structured streaming event emitted during execution.
+//
+// Streaming providers announce tool calls before execution: tool_call_start
+// carries the tool name, tool_call_delta carries raw argument fragments in
+// Delta, and tool_call_ready carries the parsed arguments in ToolInput.
+// Streaming tools emit tool_output events with incremental output in Delta.
+// rate_limited events report in WaitMs how long the provider waits before
+// retrying a throttled model call. usage_update events carry the cumulative
+// Usage after each model response. user_input_required events carry an
+// ask_user question in Message and its suggested answers in Options. output_guarded
+// events report an output guard's Action (redact, rewrite, or block) and its
+// reason in Message; the message_end that follows carries the guarded text.
+// Failed tool_result events carry the tool's ErrorCode when it set one.
+// With AgentOptions.StructuredOutput, partial_json events follow message
+// deltas that change the reply's JSON value and carry the best-effort
+// partial value in Partial: unterminated strings are closed, unfinished
+// keys and literals dropped, and open objects and arrays closed.
+// Under StreamDropDeltas, Dropped counts the delta events dropped so far
+// for a slow consumer; agent_end carries the run's total. agent_end also
+// lists the run's artifacts.
 type AgentStreamEvent struct {
-	Type     AgentEventType  `json:"type"`
-	Delta    string          `json:"delta,omitempty"`
-	Message  string          `json:"message,omitempty"`
-	ToolName string          `json:"tool_name,omitempty"`
-	IsError  bool            `json:"is_error,omitempty"`
-	Usage    *ExecutionUsage `json:"usage,omitempty"`
+	Type       AgentEventType  `json:"type"`
+	Delta      string          `json:"delta,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	ToolName   string          `json:"tool_name,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+	ToolInput  map[string]any  `json:"tool_input,omitempty"`
+	IsError    bool            `json:"is_error,omitempty"`
+	Usage      *ExecutionUsage `json:"usage,omitempty"`
+	WaitMs     int64           `json:"wait_ms,omitempty"`
+	Options    []string        `json:"options,omitempty"`
+	Action     string          `json:"action,omitempty"`
+	ErrorCode  string          `json:"error_code,omitempty"`
+	Partial    any             `json:"partial,omitempty"`
+	Dropped    int             `json:"dropped,omitempty"`
+	Artifacts  []Artifact      `json:"artifacts,omitempty"`
 }
 
 // AgentCapabilities describes what an agent can do.
@@ -67,8 +233,15 @@ type AgentCapabilities struct {
 	// Provider identifies the agent implementation.
 	// Examples: "api", "claude-code", "openai"
 	Provider string
+
+	// MCPServers reports the health of the MCP servers whose tools are
+	// registered with the agent, sorted by name.
+	MCPServers []MCPServerStatus
 }
 
+// MCPServerStatus is the health of an MCP server connection.
+type MCPServerStatus = mcp.ServerStatus
+
 // ToolInfo describes a tool available to the agent.
 type ToolInfo struct {
 	Name        string
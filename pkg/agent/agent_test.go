@@ -7,6 +7,7 @@ import (
 
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
 	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/llmprovider"
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
 )
 
@@ -77,6 +78,83 @@ func TestAPIAgentCapabilitiesDefaultContextTokens(t *testing.T) {
 	}
 }
 
+func TestNewAgentDefaultsFromModelRegistry(t *testing.T) {
+	tests := []struct {
+		name             string
+		model            string
+		maxTokens        int
+		maxContextTokens int
+		wantContext      int
+		wantMaxTokens    int
+	}{
+		{"known model", "claude-sonnet-4-20250514", 4096, 0, 200_000, 4096},
+		{"explicit window kept", "gpt-4o", 4096, 50_000, 50_000, 4096},
+		{"max tokens capped", "gpt-4o", 100_000, 0, 128_000, 16_384},
+		{"unknown model", "my-local-model", 4096, 0, 0, 4096},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &APIConfig{
+				ProviderType:     ProviderTypeOpenAI,
+				BaseURL:          "https://api.example.com",
+				APIKey:           "test-key",
+				Model:            tt.model,
+				MaxTokens:        tt.maxTokens,
+				MaxContextTokens: tt.maxContextTokens,
+			}
+			a, err := NewAgent(AgentConfig{Type: AgentTypeAPI, API: cfg})
+			if err != nil {
+				t.Fatalf("NewAgent: %v", err)
+			}
+			api := a.(*APIAgent)
+			if api.options.MaxContextTokens != tt.wantContext {
+				t.Errorf("MaxContextTokens = %d, want %d", api.options.MaxContextTokens, tt.wantContext)
+			}
+			if api.options.MaxTokens != tt.wantMaxTokens {
+				t.Errorf("MaxTokens = %d, want %d", api.options.MaxTokens, tt.wantMaxTokens)
+			}
+			if cfg.MaxContextTokens != tt.maxContextTokens {
+				t.Error("expected caller's APIConfig to be left unchanged")
+			}
+		})
+	}
+}
+
+// gatewayProvider is a custom backend built only on package llmprovider.
+type gatewayProvider struct {
+	requests []llmprovider.Request
+}
+
+func (p *gatewayProvider) Name() string { return "gateway" }
+
+func (p *gatewayProvider) Call(_ context.Context, req llmprovider.Request) (llmprovider.Response, error) {
+	p.requests = append(p.requests, req)
+	return llmprovider.Response{
+		Role:       llmprovider.RoleAssistant,
+		StopReason: llmprovider.StopReasonEndTurn,
+		Content:    []llmprovider.ContentBlock{{Type: llmprovider.ContentTypeText, Text: "from gateway"}},
+	}, nil
+}
+
+func TestNewAgentUsesCustomProvider(t *testing.T) {
+	provider := &gatewayProvider{}
+	a, err := NewAgent(AgentConfig{Type: AgentTypeAuto, API: &APIConfig{Provider: provider, Model: "gpt-4o"}})
+	if err != nil {
+		t.Fatalf("NewAgent: %v", err)
+	}
+	if got := a.(*APIAgent).options.MaxContextTokens; got != 128_000 {
+		t.Errorf("MaxContextTokens = %d, want registry default for Model", got)
+	}
+
+	result, err := a.Execute(context.Background(), AgentRequest{Task: "hello"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Message != "from gateway" || len(provider.requests) != 1 {
+		t.Fatalf("expected the custom provider to answer, got %q after %d calls", result.Message, len(provider.requests))
+	}
+}
+
 func TestRunnerAdapterConversion(t *testing.T) {
 	req := llm.Request{
 		Prompt: "User input text",
@@ -107,6 +185,7 @@ func TestConvertToRunResult(t *testing.T) {
 	result := AgentResult{
 		Success: true,
 		Summary: "Test summary",
+		Outcome: OutcomeProceed,
 		FileChanges: []FileChange{
 			{Path: "file.go", Content: "package main", Operation: FileOpModify},
 		},
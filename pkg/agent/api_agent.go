@@ -1,18 +1,35 @@
 package agent
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
 	"github.com/MimeLyc/agent-core-go/internal/pkg/orchestrator"
 	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/guard"
+	"github.com/MimeLyc/agent-core-go/pkg/instructions"
+	"github.com/MimeLyc/agent-core-go/pkg/llmprovider"
+	"github.com/MimeLyc/agent-core-go/pkg/mcp"
+	"github.com/MimeLyc/agent-core-go/pkg/models"
+	"github.com/MimeLyc/agent-core-go/pkg/plugins"
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
 )
 
 // APIAgent implements Agent using the local orchestrator with LLM API.
+//
+// An APIAgent is safe for concurrent use: executions share the provider,
+// tool registry, and options, which are read-only after construction, while
+// the conversation, tool context, tool_use ID bookkeeping, and caches are
+// created per execution. One agent can serve many simultaneous requests;
+// see ExecuteConcurrently.
 type APIAgent struct {
 	// provider is the LLM API provider (Claude, OpenAI, etc.).
 	provider llm.LLMProvider
@@ -25,6 +42,10 @@ type APIAgent struct {
 
 	// options configures the agent behavior.
 	options APIAgentOptions
+
+	// plugins holds the skill directories, commands, and hooks of
+	// options.Plugins; their tools are in registry.
+	plugins installedPlugins
 }
 
 // APIAgentOptions configures the APIAgent.
@@ -39,27 +60,156 @@ type APIAgentOptions struct {
 	// MaxTokens limits response token count.
 	MaxTokens int
 
-	// MaxContextTokens is the maximum context window size reported in capabilities.
+	// MaxContextTokens is the maximum context window size. It is reported in
+	// capabilities and, when positive, enables pre-flight context estimation.
 	MaxContextTokens int
 
+	// ContextMargin is the fraction of MaxContextTokens kept free before the
+	// loop compacts or truncates history (default 0.1).
+	ContextMargin float64
+
+	// MaxOverflowRetries bounds shrink-and-retry attempts after a provider
+	// context overflow. Zero uses the default (3); negative disables retries.
+	MaxOverflowRetries int
+
 	// SystemPrompt is the default system prompt.
 	SystemPrompt string
 
 	// CompactConfig configures context compaction.
 	CompactConfig *CompactConfig
 
+	// ToolCache memoizes repeated read-only tool calls within an execution.
+	ToolCache *ToolCacheConfig
+
+	// ToolRetry retries tool calls that fail with a retryable error.
+	// Nil hands every failure straight to the model.
+	ToolRetry *ToolRetryConfig
+
+	// JSONCompression shortens large JSON tool outputs for the model.
+	// Nil leaves them as they are.
+	JSONCompression *JSONCompressionConfig
+
+	// LoopDetection nudges or aborts executions that repeat the same tool
+	// calls. Nil disables it.
+	LoopDetection *LoopDetectionConfig
+
+	// Prune selects context pruning strategies. Nil disables pruning.
+	Prune *PruneConfig
+
+	// WatchWorkDir watches the workdir of every execution for external
+	// changes (see AgentOptions.WatchWorkDir). Nil disables watching.
+	WatchWorkDir *workspace.WatchConfig
+
+	// MessageSpill moves old messages to disk during long executions.
+	// Nil keeps the whole history in memory.
+	MessageSpill *MessageSpillConfig
+
+	// ContextSections add or replace system prompt sections for every
+	// execution (e.g. ticket data or CI status).
+	ContextSections []ContextSection
+
+	// ContextTransforms insert transform stages around the default context
+	// rules for every execution.
+	ContextTransforms []ContextTransform
+
+	// MaxSystemPromptBytes caps the assembled system prompt; the
+	// lowest-priority sections are cut first. Zero means no limit.
+	MaxSystemPromptBytes int
+
+	// InstructionMerge is how nested AGENT.md files combine with their
+	// parents when front matter does not say (default append).
+	InstructionMerge instructions.MergeStrategy
+
 	// EnableStreaming enables stream-mode execution paths.
 	EnableStreaming bool
+
+	// RateLimitNotes tells the model when a response was delayed by
+	// provider rate limiting (see AgentOptions.RateLimitNotes).
+	RateLimitNotes bool
+
+	// TrackFileReads refuses blind overwrites of files the execution has
+	// not read (see AgentOptions.TrackFileReads).
+	TrackFileReads bool
+
+	// Profile enables profiling for every execution (see AgentOptions.Profile).
+	Profile bool
+
+	// Temperature and Seed set the default sampling parameters.
+	Temperature *float64
+	Seed        *int64
+
+	// Deterministic enables deterministic mode for every execution
+	// (see AgentOptions.Deterministic).
+	Deterministic bool
+
+	// Clock times executions, model and tool calls, and profiles, e.g. a
+	// ManualClock for tests that assert on durations. Nil uses the system
+	// clock.
+	Clock Clock
+
+	// IDGenerator generates the tool_use IDs the provider left empty or
+	// duplicated, e.g. SequentialIDs. AgentOptions.NewToolUseID takes
+	// precedence. Nil uses random IDs (sequential in deterministic mode).
+	IDGenerator IDGenerator
+
+	// ToolChoice sets the default tool choice (see AgentOptions.ToolChoice).
+	ToolChoice *ToolChoice
+
+	// ServerTools are provider-native tools run by the provider.
+	ServerTools []ServerTool
+
+	// Redactor masks secrets in tool results before they enter the context.
+	Redactor *redact.Redactor
+
+	// OutputGuard moderates each assistant message before it is committed
+	// to the conversation and before its tool calls run.
+	OutputGuard guard.OutputGuard
+
+	// OutcomeClassifier sets AgentResult.Outcome for every execution.
+	OutcomeClassifier OutcomeClassifier
+
+	// UsageReporter receives the usage of every execution (see
+	// AgentOptions.UsageReporter).
+	UsageReporter UsageReporter
+
+	// Locale selects the language of built-in prompt text (see
+	// AgentConfig.Locale). Empty uses English.
+	Locale string
+
+	// SkillTools offers skills as tools (see APIConfig.SkillTools).
+	SkillTools SkillToolsMode
+
+	// SkillEnvVars names the request Env entries skill bodies may
+	// reference (see APIConfig.SkillEnvVars).
+	SkillEnvVars []string
+
+	// ToolPairRepair repairs windows with orphaned tool_results (see
+	// APIConfig.ToolPairRepair).
+	ToolPairRepair ToolPairRepair
+
+	// Plugins add tools, skills, slash commands, and hooks to this agent.
+	// Their tools are registered in a copy of the registry.
+	Plugins []plugins.Plugin
 }
 
 // NewAPIAgent creates a new APIAgent.
-// The provider parameter accepts any LLMProvider implementation (ClaudeProvider, OpenAIProvider, etc.)
-// or the legacy AgentRunner which implements LLMProvider for backward compatibility.
-func NewAPIAgent(provider llm.LLMProvider, registry *tools.Registry, opts APIAgentOptions) *APIAgent {
+// The provider parameter accepts any llmprovider.Provider implementation: the
+// built-in Claude and OpenAI providers, a custom backend, or the legacy
+// AgentRunner which implements it for backward compatibility.
+func NewAPIAgent(provider llmprovider.Provider, registry *tools.Registry, opts APIAgentOptions) *APIAgent {
 	if registry == nil {
 		registry = tools.NewRegistry()
 	}
+	registry, installed, err := installPlugins(registry, opts.Plugins)
+	if err != nil {
+		log.Printf("[api-agent] WARNING: plugin conflicts: %v", err)
+	}
+	if len(installed.names) > 0 {
+		log.Printf("[api-agent] installed plugins: %v", installed.names)
+	}
 	loop := orchestrator.NewAgentLoop(provider, registry)
+	loop.Clock = opts.Clock
+	loop.IDs = opts.IDGenerator
 
 	// Set defaults. Non-positive MaxIterations means unbounded.
 	if opts.MaxMessages <= 0 {
@@ -74,35 +224,130 @@ func NewAPIAgent(provider llm.LLMProvider, registry *tools.Registry, opts APIAge
 		registry: registry,
 		loop:     loop,
 		options:  opts,
+		plugins:  installed,
+	}
+}
+
+// now reads the agent's clock (APIAgentOptions.Clock).
+func (a *APIAgent) now() time.Time {
+	if a.options.Clock != nil {
+		return a.options.Clock.Now()
 	}
+	return time.Now()
 }
 
 // Execute runs the agent with the given request.
 func (a *APIAgent) Execute(ctx context.Context, req AgentRequest) (AgentResult, error) {
-	startTime := time.Now()
-	log.Printf("[api-agent] starting execution: workdir=%s task_length=%d",
-		req.WorkDir, len(req.Task))
+	startTime := a.now()
+	elapsed := func() time.Duration { return a.now().Sub(startTime) }
+	log.Printf("[api-agent] starting execution: workdir=%s task_length=%d history=%d",
+		req.WorkDir, len(req.Task), len(req.History))
+	if err := ValidateHistory(req.History); err != nil {
+		err = fmt.Errorf("invalid history: %w", err)
+		log.Printf("[api-agent] ERROR: %v", err)
+		return AgentResult{Success: false, Message: err.Error()}, err
+	}
+	for _, transforms := range [][]ContextTransform{a.options.ContextTransforms, req.Options.ContextTransforms} {
+		if err := validateContextTransforms(transforms); err != nil {
+			log.Printf("[api-agent] ERROR: %v", err)
+			return AgentResult{Success: false, Message: err.Error()}, err
+		}
+	}
 
 	systemPrompt := req.SystemPrompt
 	if systemPrompt == "" {
 		systemPrompt = a.options.SystemPrompt
 	}
 
+	// An empty Task continues History, e.g. a rewound recording, instead of
+	// starting a new turn.
+	initialMessages := toLLMMessages(req.History)
+	if req.Task != "" || len(initialMessages) == 0 {
+		initialMessages = append(initialMessages, llm.NewTextMessage(llm.RoleUser, req.Task))
+	}
+
 	// Convert AgentRequest to OrchestratorRequest
 	orchReq := orchestrator.OrchestratorRequest{
-		SystemPrompt:     systemPrompt,
-		RepoInstructions: req.RepoInstructions,
-		SoulFile:         req.SoulFile,
-		InitialMessages: []llm.Message{
-			llm.NewTextMessage(llm.RoleUser, req.Task),
-		},
+		SystemPrompt:               systemPrompt,
+		RepoInstructions:           req.RepoInstructions,
+		InstructionMerge:           a.options.InstructionMerge,
+		SoulFile:                   req.SoulFile,
+		InitialMessages:            initialMessages,
 		MaxIterations:              a.options.MaxIterations,
 		MaxMessages:                a.options.MaxMessages,
 		WorkDir:                    req.WorkDir,
 		ToolContext:                tools.NewToolContext(req.WorkDir),
+		MaxContextTokens:           a.options.MaxContextTokens,
+		ContextMargin:              a.options.ContextMargin,
+		MaxOverflowRetries:         a.options.MaxOverflowRetries,
 		EnableStreaming:            a.options.EnableStreaming || req.Options.EnableStreaming,
+		RateLimitNotes:             a.options.RateLimitNotes || req.Options.RateLimitNotes,
+		Profile:                    a.options.Profile || req.Options.Profile,
 		DisableIterationLimit:      req.Options.DisableIterationLimit,
 		DisableDefaultContextRules: req.Options.DisableDefaultContextRules,
+		DisableProjectConfig:       req.Options.DisableProjectConfig,
+		Redactor:                   a.options.Redactor,
+		OutputGuard:                a.options.OutputGuard,
+		ThinkingBudgetTokens:       req.Options.ThinkingBudgetTokens,
+		Model:                      req.Options.Model,
+		Temperature:                a.options.Temperature,
+		Seed:                       a.options.Seed,
+		AssistantPrefill:           req.Options.AssistantPrefill,
+		ProviderParams:             req.Options.ProviderParams,
+		Deterministic:              a.options.Deterministic || req.Options.Deterministic,
+		Locale:                     a.options.Locale,
+		ServerTools:                toLLMServerTools(a.options.ServerTools),
+		NewToolUseID:               req.Options.NewToolUseID,
+		ToolBudgets:                req.Options.ToolBudgets,
+		AllowedTools:               req.Options.AllowedTools,
+		DeniedTools:                req.Options.DeniedTools,
+		SkillTools:                 a.options.SkillTools,
+		ToolPairRepair:             a.options.ToolPairRepair,
+		Commands:                   a.plugins.commands,
+	}
+	if req.Options.Temperature != nil {
+		orchReq.Temperature = req.Options.Temperature
+	}
+	if req.Options.Seed != nil {
+		orchReq.Seed = req.Options.Seed
+	}
+	orchReq.ToolContext.SkillDirs = slices.Clone(a.plugins.skillDirs)
+	orchReq.ToolContext.SkillEnvVars = a.options.SkillEnvVars
+	if a.options.TrackFileReads || req.Options.TrackFileReads {
+		orchReq.ToolContext.WithFileReads(tools.NewFileReads())
+	}
+	orchReq.ToolContext.WithArtifacts(tools.NewArtifacts())
+	if req.Options.Redactor != nil {
+		orchReq.Redactor = req.Options.Redactor
+	}
+	if req.Options.OutputGuard != nil {
+		orchReq.OutputGuard = req.Options.OutputGuard
+	}
+
+	reqEnv, err := resolveRequestEnv(ctx, req)
+	if err != nil {
+		log.Printf("[api-agent] ERROR: %v", err)
+		return AgentResult{Success: false, Message: err.Error()}, err
+	}
+	reqEnv.apply(orchReq.ToolContext)
+	var unmask func()
+	orchReq.Redactor, unmask = reqEnv.mask(orchReq.Redactor)
+	defer unmask()
+	if req.Options.Transactional || req.Options.CollectPatch {
+		orchReq.Journal = workspace.NewJournal(req.WorkDir)
+	}
+	watchCfg := a.options.WatchWorkDir
+	if req.Options.WatchWorkDir != nil {
+		watchCfg = req.Options.WatchWorkDir
+	}
+	if watchCfg != nil && req.WorkDir != "" {
+		watcher, err := workspace.NewWatcher(req.WorkDir, *watchCfg)
+		if err != nil {
+			log.Printf("[api-agent] WARNING: workdir watching disabled: %v", err)
+		} else {
+			defer watcher.Close()
+			orchReq.WorkDirWatcher = watcher
+		}
 	}
 
 	// Apply request options
@@ -117,16 +362,65 @@ func (a *APIAgent) Execute(ctx context.Context, req AgentRequest) (AgentResult,
 			Enabled:    req.Options.CompactConfig.Enabled,
 			Threshold:  req.Options.CompactConfig.Threshold,
 			KeepRecent: req.Options.CompactConfig.KeepRecent,
+			MaxErrors:  req.Options.CompactConfig.MaxErrors,
 		}
 	} else if a.options.CompactConfig != nil {
 		orchReq.CompactConfig = orchestrator.CompactConfig{
 			Enabled:    a.options.CompactConfig.Enabled,
 			Threshold:  a.options.CompactConfig.Threshold,
 			KeepRecent: a.options.CompactConfig.KeepRecent,
+			MaxErrors:  a.options.CompactConfig.MaxErrors,
 		}
 	}
 
+	if a.options.MessageSpill != nil {
+		orchReq.MessageSpill = orchestrator.MessageSpillConfig(*a.options.MessageSpill)
+	}
+
+	if req.Options.ToolCache != nil {
+		orchReq.ToolCache = toOrchestratorToolCache(*req.Options.ToolCache)
+	} else if a.options.ToolCache != nil {
+		orchReq.ToolCache = toOrchestratorToolCache(*a.options.ToolCache)
+	}
+
+	if req.Options.ToolRetry != nil {
+		orchReq.ToolRetry = orchestrator.ToolRetryConfig(*req.Options.ToolRetry)
+	} else if a.options.ToolRetry != nil {
+		orchReq.ToolRetry = orchestrator.ToolRetryConfig(*a.options.ToolRetry)
+	}
+	if req.Options.JSONCompression != nil {
+		orchReq.JSONCompression = orchestrator.JSONCompressionConfig(*req.Options.JSONCompression)
+	} else if a.options.JSONCompression != nil {
+		orchReq.JSONCompression = orchestrator.JSONCompressionConfig(*a.options.JSONCompression)
+	}
+	if req.Options.LoopDetection != nil {
+		orchReq.LoopDetection = toLoopDetectionConfig(*req.Options.LoopDetection)
+	} else if a.options.LoopDetection != nil {
+		orchReq.LoopDetection = toLoopDetectionConfig(*a.options.LoopDetection)
+	}
+
+	if req.Options.ToolChoice != nil {
+		orchReq.ToolChoice = toLLMToolChoice(*req.Options.ToolChoice)
+	} else if a.options.ToolChoice != nil {
+		orchReq.ToolChoice = toLLMToolChoice(*a.options.ToolChoice)
+	}
+
+	if req.Options.Prune != nil {
+		orchReq.Prune = toOrchestratorPrune(*req.Options.Prune)
+	} else if a.options.Prune != nil {
+		orchReq.Prune = toOrchestratorPrune(*a.options.Prune)
+	}
+
+	orchReq.MaxSystemPromptBytes = a.options.MaxSystemPromptBytes
+	for _, section := range a.options.ContextSections {
+		orchReq.ContextSections = append(orchReq.ContextSections, toOrchestratorContextSection(section))
+	}
+	for _, section := range req.Options.ContextSections {
+		orchReq.ContextSections = append(orchReq.ContextSections, toOrchestratorContextSection(section))
+	}
+
 	// Set up callbacks
+	req.Callbacks = a.plugins.withHooks(req.Callbacks)
 	if req.Callbacks.OnMessage != nil {
 		orchReq.OnMessage = func(msg llm.Message) {
 			req.Callbacks.OnMessage(fromLLMMessage(msg))
@@ -138,6 +432,9 @@ func (a *APIAgent) Execute(ctx context.Context, req AgentRequest) (AgentResult,
 	if req.Callbacks.OnToolResult != nil {
 		orchReq.OnToolResult = req.Callbacks.OnToolResult
 	}
+	if req.Callbacks.OnToolOutput != nil {
+		orchReq.OnToolOutput = req.Callbacks.OnToolOutput
+	}
 	if req.Callbacks.OnSteeringApplied != nil {
 		orchReq.OnSteeringApplied = func(messages []llm.Message) {
 			req.Callbacks.OnSteeringApplied(fromLLMMessages(messages))
@@ -153,28 +450,69 @@ func (a *APIAgent) Execute(ctx context.Context, req AgentRequest) (AgentResult,
 			req.Callbacks.OnStreamDelta(fromLLMContentDelta(delta))
 		}
 	}
-	if req.Options.GetSteeringMessages != nil {
-		orchReq.GetSteeringMessages = func(ctx context.Context, snapshot orchestrator.LoopInputSnapshot) ([]llm.Message, error) {
-			msgs, err := req.Options.GetSteeringMessages(ctx, LoopInputSnapshot{
-				Iteration:      snapshot.Iteration,
-				MessageCount:   snapshot.MessageCount,
-				ToolCallCount:  snapshot.ToolCallCount,
-				LastStopReason: fromLLMStopReason(snapshot.LastStopReason),
+	if req.Callbacks.OnContextPressure != nil {
+		orchReq.OnContextPressure = func(pressure orchestrator.ContextPressure) {
+			req.Callbacks.OnContextPressure(ContextPressure(pressure))
+		}
+	}
+	if req.Callbacks.OnOutputGuard != nil {
+		orchReq.OnOutputGuard = req.Callbacks.OnOutputGuard
+	}
+	if req.Callbacks.OnRateLimit != nil {
+		orchReq.OnRateLimit = func(wait orchestrator.RateLimitWait) {
+			req.Callbacks.OnRateLimit(RateLimitWait(wait))
+		}
+	}
+	// The cost is priced per response, since model fallbacks can change the
+	// model mid-run.
+	var cost float64
+	usageReporter := req.Options.UsageReporter
+	if usageReporter == nil {
+		usageReporter = a.options.UsageReporter
+	}
+	orchReq.OnUsage = func(update orchestrator.UsageUpdate) {
+		var responseCost float64
+		if m, ok := models.Lookup(update.Model); ok {
+			responseCost = m.Cost(update.Usage.InputTokens, update.Usage.OutputTokens)
+			cost += responseCost
+		}
+		reportUsage(ctx, usageReporter, req.Options, UsageReport{
+			Provider:          a.provider.Name(),
+			Model:             update.Model,
+			Iteration:         update.Iteration,
+			InputTokens:       update.Usage.InputTokens,
+			OutputTokens:      update.Usage.OutputTokens,
+			CachedInputTokens: update.Usage.CacheReadInputTokens,
+			Cost:              responseCost,
+			Duration:          elapsed(),
+		})
+		if req.Callbacks.OnUsageUpdate != nil {
+			req.Callbacks.OnUsageUpdate(ExecutionUsage{
+				TotalIterations:        update.Iteration,
+				TotalInputTokens:       update.TotalInputTokens,
+				TotalOutputTokens:      update.TotalOutputTokens,
+				TotalCachedInputTokens: update.TotalCachedInputTokens,
+				EstimatedCost:          cost,
+				TotalDuration:          elapsed(),
 			})
+		}
+	}
+	getSteering, getFollowUp := req.Options.loopInputFetchers()
+	if getSteering != nil {
+		orchReq.ToolContext.AskUser = steeringAsker(getSteering, req.Options.AskUserTimeout, req.Callbacks.OnUserInputRequired)
+	}
+	if getSteering != nil {
+		orchReq.GetSteeringMessages = func(ctx context.Context, snapshot orchestrator.LoopInputSnapshot) ([]llm.Message, error) {
+			msgs, err := getSteering(ctx, fromOrchestratorSnapshot(snapshot))
 			if err != nil {
 				return nil, err
 			}
 			return toLLMMessages(msgs), nil
 		}
 	}
-	if req.Options.GetFollowUpMessages != nil {
+	if getFollowUp != nil {
 		orchReq.GetFollowUpMessages = func(ctx context.Context, snapshot orchestrator.LoopInputSnapshot) ([]llm.Message, error) {
-			msgs, err := req.Options.GetFollowUpMessages(ctx, LoopInputSnapshot{
-				Iteration:      snapshot.Iteration,
-				MessageCount:   snapshot.MessageCount,
-				ToolCallCount:  snapshot.ToolCallCount,
-				LastStopReason: fromLLMStopReason(snapshot.LastStopReason),
-			})
+			msgs, err := getFollowUp(ctx, fromOrchestratorSnapshot(snapshot))
 			if err != nil {
 				return nil, err
 			}
@@ -190,6 +528,12 @@ func (a *APIAgent) Execute(ctx context.Context, req AgentRequest) (AgentResult,
 			return toLLMMessages(transformed), nil
 		}
 	}
+	for _, transforms := range [][]ContextTransform{a.options.ContextTransforms, req.Options.ContextTransforms} {
+		for _, t := range transforms {
+			orchReq.ContextTransforms = append(orchReq.ContextTransforms, toOrchestratorContextTransform(t))
+		}
+	}
+	orchReq.OnTransformToolResult = req.Options.TransformToolResult
 	if req.Options.ConvertToLlm != nil {
 		orchReq.ConvertToLlm = func(ctx context.Context, messages []llm.Message, providerName string) ([]llm.Message, error) {
 			converted, err := req.Options.ConvertToLlm(ctx, fromLLMMessages(messages), providerName)
@@ -201,49 +545,78 @@ func (a *APIAgent) Execute(ctx context.Context, req AgentRequest) (AgentResult,
 	}
 
 	// Run the orchestrator
-	orchResult, err := a.loop.Run(ctx, orchReq)
+	runCtx, cancel := withRunTimeout(ctx, req.Options.Timeout)
+	defer cancel()
+	orchResult, err := a.loop.Run(runCtx, orchReq)
 	if err != nil {
+		err = runTimeoutError(ctx, runCtx, req.Options.Timeout, err)
 		log.Printf("[api-agent] ERROR: orchestrator failed: %v", err)
-		return AgentResult{
-			Success: false,
-			Message: fmt.Sprintf("orchestrator error: %v", err),
-		}, err
+		result := AgentResult{
+			Success:   false,
+			Message:   fmt.Sprintf("orchestrator error: %v", err),
+			Usage:     orchestratorUsage(orchResult, elapsed()),
+			Workspace: orchReq.Journal,
+			Profile:   fromOrchestratorProfile(orchResult.Profile),
+			Metadata:  fromOrchestratorResponses(orchResult.Provider, orchResult.Responses),
+			Artifacts: orchReq.ToolContext.Artifacts.List(),
+		}
+		if errors.Is(err, ErrRunTimeout) {
+			// Keep what the run did before the deadline.
+			partial := convertOrchestratorResult(orchResult, elapsed())
+			result.Summary = partial.Summary
+			result.RawOutput = partial.RawOutput
+			result.ToolCalls = partial.ToolCalls
+		}
+		result.Usage.EstimatedCost = cost
+		reportUsage(ctx, usageReporter, req.Options, finalUsageReport(result, err))
+		return result, err
 	}
 
 	// Convert OrchestratorResult to AgentResult
-	result := convertOrchestratorResult(orchResult, startTime)
+	result := convertOrchestratorResult(orchResult, elapsed())
+	result.Usage.EstimatedCost = cost
+	result.Artifacts = orchReq.ToolContext.Artifacts.List()
+	if orchReq.Journal != nil {
+		if err := finishTransaction(ctx, req, orchReq.Journal, &result); err != nil {
+			reportUsage(ctx, usageReporter, req.Options, finalUsageReport(result, err))
+			return result, err
+		}
+	}
+	classifier := req.Options.OutcomeClassifier
+	if classifier == nil {
+		classifier = a.options.OutcomeClassifier
+	}
+	classifyOutcome(ctx, classifier, &result)
 	log.Printf("[api-agent] execution complete: success=%v iterations=%d",
 		result.Success, result.Usage.TotalIterations)
+	reportUsage(ctx, usageReporter, req.Options, finalUsageReport(result, nil))
 
 	return result, nil
 }
 
 // ExecuteStream runs the agent and emits structured stream events.
+// req.Options.StreamBuffer decides what happens when the consumer falls
+// behind.
 func (a *APIAgent) ExecuteStream(
 	ctx context.Context, req AgentRequest) (<-chan AgentStreamEvent, <-chan error) {
-	eventCh := make(chan AgentStreamEvent, 128)
 	errCh := make(chan error, 1)
 
 	if !a.options.EnableStreaming && !req.Options.EnableStreaming {
+		eventCh := make(chan AgentStreamEvent)
 		close(eventCh)
 		errCh <- fmt.Errorf("streaming is disabled by configuration")
 		close(errCh)
 		return eventCh, errCh
 	}
 
+	runCtx, cancel := context.WithCancel(ctx)
+	stream := newStreamEmitter(ctx, cancel, req.Options.StreamBuffer)
 	go func() {
-		defer close(eventCh)
+		defer cancel()
+		defer stream.close()
 		defer close(errCh)
 
-		emit := func(evt AgentStreamEvent) bool {
-			select {
-			case <-ctx.Done():
-				return false
-			case eventCh <- evt:
-				return true
-			}
-		}
-
+		emit := stream.emit
 		if !emit(AgentStreamEvent{Type: AgentEventAgentStart}) {
 			return
 		}
@@ -251,16 +624,24 @@ func (a *APIAgent) ExecuteStream(
 		streamReq := req
 		streamReq.Options.EnableStreaming = true
 		cbs := streamReq.Callbacks
+		var partial partialJSONStream
 
 		prevMessage := cbs.OnMessage
 		cbs.OnMessage = func(msg agenttypes.Message) {
 			if prevMessage != nil {
 				prevMessage(msg)
 			}
+			if thinking := msg.GetThinking(); thinking != "" {
+				_ = emit(AgentStreamEvent{
+					Type:    AgentEventThinking,
+					Message: thinking,
+				})
+			}
 			_ = emit(AgentStreamEvent{
 				Type:    AgentEventMessageEnd,
 				Message: msg.GetText(),
 			})
+			partial.reset()
 		}
 
 		prevToolCall := cbs.OnToolCall
@@ -280,10 +661,24 @@ func (a *APIAgent) ExecuteStream(
 				prevToolResult(name, result)
 			}
 			_ = emit(AgentStreamEvent{
-				Type:     AgentEventToolResult,
-				ToolName: name,
-				Message:  result.Content,
-				IsError:  result.IsError,
+				Type:      AgentEventToolResult,
+				ToolName:  name,
+				Message:   result.Content,
+				IsError:   result.IsError,
+				ErrorCode: result.ErrorCode,
+			})
+		}
+
+		prevToolOutput := cbs.OnToolOutput
+		cbs.OnToolOutput = func(name, toolCallID, chunk string) {
+			if prevToolOutput != nil {
+				prevToolOutput(name, toolCallID, chunk)
+			}
+			_ = emit(AgentStreamEvent{
+				Type:       AgentEventToolOutput,
+				ToolName:   name,
+				ToolCallID: toolCallID,
+				Delta:      chunk,
 			})
 		}
 
@@ -307,19 +702,76 @@ func (a *APIAgent) ExecuteStream(
 			})
 		}
 
+		prevRateLimit := cbs.OnRateLimit
+		cbs.OnRateLimit = func(wait RateLimitWait) {
+			if prevRateLimit != nil {
+				prevRateLimit(wait)
+			}
+			_ = emit(AgentStreamEvent{
+				Type:    AgentEventRateLimited,
+				Message: fmt.Sprintf("rate limited by %s, retrying in %v", wait.Provider, wait.Wait),
+				WaitMs:  wait.Wait.Milliseconds(),
+			})
+		}
+
+		prevGuard := cbs.OnOutputGuard
+		cbs.OnOutputGuard = func(verdict guard.Verdict) {
+			if prevGuard != nil {
+				prevGuard(verdict)
+			}
+			_ = emit(AgentStreamEvent{
+				Type:    AgentEventOutputGuarded,
+				Action:  string(verdict.Action),
+				Message: verdict.Reason,
+			})
+		}
+
+		prevUsage := cbs.OnUsageUpdate
+		cbs.OnUsageUpdate = func(usage ExecutionUsage) {
+			if prevUsage != nil {
+				prevUsage(usage)
+			}
+			_ = emit(AgentStreamEvent{
+				Type:  AgentEventUsageUpdate,
+				Usage: &usage,
+			})
+		}
+
+		prevUserInput := cbs.OnUserInputRequired
+		cbs.OnUserInputRequired = func(q tools.UserQuestion) {
+			if prevUserInput != nil {
+				prevUserInput(q)
+			}
+			_ = emit(AgentStreamEvent{
+				Type:     AgentEventUserInputRequired,
+				ToolName: "ask_user",
+				Message:  q.Question,
+				Options:  q.Options,
+			})
+		}
+
 		prevDelta := cbs.OnStreamDelta
 		cbs.OnStreamDelta = func(delta agenttypes.ContentBlockDelta) {
 			if prevDelta != nil {
 				prevDelta(delta)
 			}
-			_ = emit(AgentStreamEvent{
-				Type:  AgentEventMessageDelta,
-				Delta: delta.Text,
-			})
+			_ = emit(streamDeltaEvent(delta))
+			if req.Options.StructuredOutput && delta.Type == agenttypes.ContentTypeText {
+				if value, ok := partial.feed(delta.Text); ok {
+					_ = emit(AgentStreamEvent{Type: AgentEventPartialJSON, Partial: value})
+				}
+			}
 		}
 
 		streamReq.Callbacks = cbs
-		result, err := a.Execute(ctx, streamReq)
+		result, err := a.Execute(runCtx, streamReq)
+		if dropped := stream.droppedEvents(); dropped > 0 {
+			log.Printf("[api-agent] dropped %d delta events for a slow stream consumer", dropped)
+		}
+		if disconnectErr := stream.disconnectErr(); disconnectErr != nil {
+			// The run may have finished before it saw the cancellation.
+			err = disconnectErr
+		}
 		if err != nil {
 			errCh <- err
 			return
@@ -327,25 +779,72 @@ func (a *APIAgent) ExecuteStream(
 
 		usage := result.Usage
 		_ = emit(AgentStreamEvent{
-			Type:    AgentEventAgentEnd,
-			Message: result.Message,
-			Usage:   &usage,
+			Type:      AgentEventAgentEnd,
+			Message:   result.Message,
+			Usage:     &usage,
+			Artifacts: result.Artifacts,
 		})
 	}()
 
-	return eventCh, errCh
+	return stream.events(), errCh
+}
+
+// streamDeltaEvent maps a content delta onto its stream event.
+func streamDeltaEvent(delta agenttypes.ContentBlockDelta) AgentStreamEvent {
+	if delta.Type != agenttypes.ContentTypeToolUse {
+		return AgentStreamEvent{
+			Type:  AgentEventMessageDelta,
+			Delta: delta.Text,
+		}
+	}
+
+	evt := AgentStreamEvent{
+		ToolName:   delta.Name,
+		ToolCallID: delta.ID,
+	}
+	switch delta.Phase {
+	case agenttypes.ToolCallPhaseStart:
+		evt.Type = AgentEventToolCallStart
+	case agenttypes.ToolCallPhaseReady:
+		evt.Type = AgentEventToolCallReady
+		evt.ToolInput = delta.Input
+	default:
+		evt.Type = AgentEventToolCallDelta
+		evt.Delta = delta.PartialInput
+	}
+	return evt
+}
+
+// HealthCheck checks that the provider is reachable and accepts its
+// credentials. Providers that cannot check themselves are assumed healthy.
+func (a *APIAgent) HealthCheck(ctx context.Context) error {
+	checker, ok := a.provider.(llm.HealthChecker)
+	if !ok {
+		return nil
+	}
+	if err := checker.HealthCheck(ctx); err != nil {
+		return fmt.Errorf("%s provider: %w", a.provider.Name(), err)
+	}
+	return nil
 }
 
 // Capabilities returns the agent's capabilities.
 func (a *APIAgent) Capabilities() AgentCapabilities {
 	toolList := a.registry.List()
 	toolInfos := make([]ToolInfo, len(toolList))
+	var mcpServers []MCPServerStatus
+	seen := map[*mcp.MCPServer]bool{}
 	for i, t := range toolList {
 		toolInfos[i] = ToolInfo{
 			Name:        t.Name(),
 			Description: t.Description(),
 		}
+		if mt, ok := t.(*mcp.MCPTool); ok && mt.Server() != nil && !seen[mt.Server()] {
+			seen[mt.Server()] = true
+			mcpServers = append(mcpServers, mt.Server().Status())
+		}
 	}
+	slices.SortFunc(mcpServers, func(a, b MCPServerStatus) int { return cmp.Compare(a.Name, b.Name) })
 
 	return AgentCapabilities{
 		SupportsTools:      true,
@@ -354,44 +853,292 @@ func (a *APIAgent) Capabilities() AgentCapabilities {
 		SupportsCompaction: true,
 		MaxContextTokens:   a.options.MaxContextTokens,
 		Provider:           "api",
+		MCPServers:         mcpServers,
 	}
 }
 
+// Compact summarizes the conversation with the agent's provider right away,
+// regardless of the compaction threshold.
+func (a *APIAgent) Compact(ctx context.Context, req CompactRequest) (CompactResult, error) {
+	keepRecent := req.KeepRecent
+	if keepRecent <= 0 && a.options.CompactConfig != nil {
+		keepRecent = a.options.CompactConfig.KeepRecent
+	}
+	if keepRecent <= 0 {
+		keepRecent = orchestrator.DefaultCompactConfig().KeepRecent
+	}
+
+	maxErrors := 0
+	if a.options.CompactConfig != nil {
+		maxErrors = a.options.CompactConfig.MaxErrors
+	}
+
+	messages := toLLMMessages(req.Messages)
+	compactor := orchestrator.NewCompactor(a.provider, orchestrator.CompactConfig{Enabled: true, KeepRecent: keepRecent, MaxErrors: maxErrors}).
+		WithLocale(a.options.Locale)
+	compacted, summary, err := compactor.CompactWithSummary(ctx, messages)
+	if err != nil {
+		log.Printf("[api-agent] ERROR: compaction failed: %v", err)
+		return CompactResult{}, err
+	}
+
+	result := CompactResult{
+		Messages:     fromLLMMessages(compacted),
+		Summary:      summary,
+		TokensBefore: orchestrator.EstimateRequestTokens(llm.AgentRequest{Messages: messages}),
+		TokensAfter:  orchestrator.EstimateRequestTokens(llm.AgentRequest{Messages: compacted}),
+	}
+	if summary != "" {
+		// The summary replaces everything between the first message and the
+		// kept recent ones.
+		result.CompactedMessages = len(messages) - len(compacted) + 1
+		if structured, ok := orchestrator.SummaryFromMessage(compacted[1]); ok {
+			result.Structured = fromOrchestratorSummary(structured)
+		}
+	}
+	log.Printf("[api-agent] compacted %d -> %d messages (~%d tokens saved)",
+		len(messages), len(compacted), result.TokensSaved())
+	return result, nil
+}
+
+func fromOrchestratorSummary(s orchestrator.CompactSummary) *CompactSummary {
+	out := &CompactSummary{
+		Task:         s.Task,
+		CurrentState: s.CurrentState,
+		CommandsRun:  s.CommandsRun,
+		Decisions:    s.Decisions,
+		OpenTODOs:    s.OpenTODOs,
+		Notes:        s.Notes,
+		ToolCalls:    s.ToolCalls,
+		Messages:     s.Messages,
+	}
+	for _, f := range s.FilesTouched {
+		out.FilesTouched = append(out.FilesTouched, FileTouch(f))
+	}
+	for _, f := range s.Errors {
+		out.Errors = append(out.Errors, ToolFailure(f))
+	}
+	return out
+}
+
 // Close releases resources.
 func (a *APIAgent) Close() error {
 	return nil
 }
 
 // convertOrchestratorResult converts an OrchestratorResult to an AgentResult.
-func convertOrchestratorResult(orchResult orchestrator.OrchestratorResult, startTime time.Time) AgentResult {
+func convertOrchestratorResult(orchResult orchestrator.OrchestratorResult, duration time.Duration) AgentResult {
 	finalText := orchResult.GetFinalText()
 
 	result := AgentResult{
-		Success: true,
-		Summary: finalText,
-		Message: finalText,
-		Usage: ExecutionUsage{
-			TotalIterations:   orchResult.TotalIterations,
-			TotalInputTokens:  orchResult.TotalInputTokens,
-			TotalOutputTokens: orchResult.TotalOutputTokens,
-			TotalDuration:     time.Since(startTime),
-		},
+		Success:   true,
+		Summary:   finalText,
+		Message:   finalText,
+		Usage:     orchestratorUsage(orchResult, duration),
 		RawOutput: fromLLMMessages(orchResult.Messages),
+		Profile:   fromOrchestratorProfile(orchResult.Profile),
+		Metadata:  fromOrchestratorResponses(orchResult.Provider, orchResult.Responses),
 	}
 
 	// Convert tool calls
 	for _, tc := range orchResult.ToolCalls {
 		result.ToolCalls = append(result.ToolCalls, ToolCallRecord{
-			Name:    tc.Name,
-			Input:   tc.Input,
-			Output:  tc.Result.Content,
-			IsError: tc.Result.IsError,
+			Name:      tc.Name,
+			Input:     tc.Input,
+			Output:    tc.Result.Content,
+			IsError:   tc.Result.IsError,
+			ErrorCode: tc.Result.ErrorCode,
+			WorkDir:   tc.WorkDir,
+			Command:   tc.Result.Command,
 		})
 	}
 
 	return result
 }
 
+// orchestratorUsage is the usage of orchResult, which took duration,
+// without the cost.
+func orchestratorUsage(orchResult orchestrator.OrchestratorResult, duration time.Duration) ExecutionUsage {
+	return ExecutionUsage{
+		TotalIterations:        orchResult.TotalIterations,
+		TotalInputTokens:       orchResult.TotalInputTokens,
+		TotalOutputTokens:      orchResult.TotalOutputTokens,
+		TotalCachedInputTokens: orchResult.TotalCachedInputTokens,
+		TotalDuration:          duration,
+		ToolStats:              fromOrchestratorToolStats(orchResult.ToolStats),
+		ToolPairRepairs:        orchResult.ToolPairRepairs,
+	}
+}
+
+func fromOrchestratorResponses(provider string, responses []orchestrator.ResponseRecord) ResultMetadata {
+	meta := ResultMetadata{Provider: provider}
+	for _, r := range responses {
+		meta.Responses = append(meta.Responses, ResponseMetadata{
+			Iteration:          r.Iteration,
+			ID:                 r.ID,
+			Model:              r.Model,
+			StopReason:         fromLLMStopReason(r.StopReason),
+			Retries:            r.Retries,
+			RateLimitedRetries: r.RateLimitedRetries,
+			Repairs:            r.Repairs,
+		})
+		meta.Retries += r.Retries
+	}
+	if n := len(meta.Responses); n > 0 {
+		meta.StopReason = meta.Responses[n-1].StopReason
+		meta.Model = meta.Responses[n-1].Model
+	}
+	return meta
+}
+
+func fromOrchestratorToolStats(stats map[string]orchestrator.ToolStats) map[string]ToolUsageStats {
+	if len(stats) == 0 {
+		return nil
+	}
+	out := make(map[string]ToolUsageStats, len(stats))
+	for name, s := range stats {
+		out[name] = ToolUsageStats{
+			Calls:         s.Calls,
+			Executed:      s.Executed,
+			Errors:        s.Errors,
+			CacheHits:     s.CacheHits,
+			Rejected:      s.Rejected,
+			TotalDuration: s.TotalDuration,
+			AvgDuration:   s.AvgDuration(),
+			MaxDuration:   s.MaxDuration,
+		}
+	}
+	return out
+}
+
+func toOrchestratorToolCache(cfg ToolCacheConfig) orchestrator.ToolCacheConfig {
+	return orchestrator.ToolCacheConfig{
+		Enabled:      cfg.Enabled,
+		Tools:        cfg.Tools,
+		InvalidateOn: cfg.InvalidateOn,
+	}
+}
+
+func toLoopDetectionConfig(cfg LoopDetectionConfig) orchestrator.LoopDetectionConfig {
+	return orchestrator.LoopDetectionConfig{
+		Enabled:           cfg.Enabled,
+		MaxRepeats:        cfg.MaxRepeats,
+		OscillationCycles: cfg.OscillationCycles,
+		Action:            orchestrator.LoopAction(cfg.Action),
+		MaxNudges:         cfg.MaxNudges,
+	}
+}
+
+func toLLMToolChoice(choice ToolChoice) *llm.ToolChoice {
+	return &llm.ToolChoice{
+		Type:                   string(choice.Mode),
+		Name:                   choice.Name,
+		DisableParallelToolUse: choice.DisableParallelToolUse,
+	}
+}
+
+func toOrchestratorPrune(cfg PruneConfig) orchestrator.PruneConfig {
+	return orchestrator.PruneConfig{
+		ToolResults:    cfg.ToolResults,
+		Relevance:      cfg.Relevance,
+		Threshold:      cfg.Threshold,
+		KeepRecent:     cfg.KeepRecent,
+		MinResultBytes: cfg.MinResultBytes,
+		Embedder:       cfg.Embedder,
+		MinSimilarity:  cfg.MinSimilarity,
+	}
+}
+
+func toOrchestratorContextTransform(t ContextTransform) orchestrator.ContextTransform {
+	return orchestrator.ContextTransform{
+		Name:     t.Name,
+		Stage:    orchestrator.ContextStage(t.Stage),
+		Priority: t.Priority,
+		Transform: func(ctx context.Context, messages []llm.Message) ([]llm.Message, error) {
+			transformed, err := t.Transform(ctx, fromLLMMessages(messages))
+			if err != nil {
+				return nil, err
+			}
+			return toLLMMessages(transformed), nil
+		},
+	}
+}
+
+func toOrchestratorContextSection(section ContextSection) orchestrator.ContextSection {
+	out := orchestrator.ContextSection{
+		Name:     section.Name,
+		Title:    section.Title,
+		Priority: section.Priority,
+		MaxBytes: section.MaxBytes,
+		Content:  section.Content,
+	}
+	if refresh := section.Refresh; refresh != nil {
+		out.Refresh = func(ctx context.Context, snapshot orchestrator.LoopInputSnapshot) (string, error) {
+			return refresh(ctx, fromOrchestratorSnapshot(snapshot))
+		}
+	}
+	return out
+}
+
+func fromOrchestratorSnapshot(snapshot orchestrator.LoopInputSnapshot) LoopInputSnapshot {
+	return LoopInputSnapshot{
+		Iteration:      snapshot.Iteration,
+		MessageCount:   snapshot.MessageCount,
+		ToolCallCount:  snapshot.ToolCallCount,
+		LastStopReason: fromLLMStopReason(snapshot.LastStopReason),
+	}
+}
+
+func toLLMServerTools(serverTools []ServerTool) []llm.ServerTool {
+	if len(serverTools) == 0 {
+		return nil
+	}
+	out := make([]llm.ServerTool, 0, len(serverTools))
+	for _, tool := range serverTools {
+		out = append(out, llm.ServerTool{Type: tool.Type, Name: tool.Name, Config: tool.Config})
+	}
+	return out
+}
+
+func toLLMHTTPConfig(cfg *HTTPConfig) llm.HTTPConfig {
+	if cfg == nil {
+		return llm.HTTPConfig{}
+	}
+	return llm.HTTPConfig{
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.MaxConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+		KeepAlive:           cfg.KeepAlive,
+		DisableHTTP2:        cfg.DisableHTTP2,
+		ProxyURL:            cfg.ProxyURL,
+		CAFile:              cfg.CAFile,
+		TLSConfig:           cfg.TLSConfig,
+	}
+}
+
+func toLLMOpenRouterOptions(cfg *OpenRouterConfig) *llm.OpenRouterOptions {
+	if cfg == nil {
+		return nil
+	}
+	opts := &llm.OpenRouterOptions{
+		FallbackModels: cfg.FallbackModels,
+		Transforms:     cfg.Transforms,
+	}
+	if p := cfg.Provider; p != nil {
+		opts.Provider = &llm.OpenRouterProviderPreferences{
+			Order:             p.Order,
+			AllowFallbacks:    p.AllowFallbacks,
+			RequireParameters: p.RequireParameters,
+			DataCollection:    p.DataCollection,
+			Only:              p.Only,
+			Ignore:            p.Ignore,
+			Sort:              p.Sort,
+		}
+	}
+	return opts
+}
+
 func fromLLMStopReason(reason llm.StopReason) agenttypes.StopReason {
 	return agenttypes.StopReason(reason)
 }
@@ -419,7 +1166,11 @@ func toLLMRole(r agenttypes.MessageRole) llm.Role {
 	switch r {
 	case agenttypes.RoleAssistant:
 		return llm.RoleAssistant
-	case agenttypes.RoleSystem, agenttypes.RoleDeveloper, agenttypes.RoleUser, agenttypes.RoleTool:
+	case agenttypes.RoleSystem:
+		return llm.RoleSystem
+	case agenttypes.RoleDeveloper:
+		return llm.RoleDeveloper
+	case agenttypes.RoleUser, agenttypes.RoleTool:
 		return llm.RoleUser
 	default:
 		return llm.RoleUser
@@ -436,6 +1187,11 @@ func fromLLMContentBlock(block llm.ContentBlock) agenttypes.ContentBlock {
 		ToolUseID: block.ToolUseID,
 		Content:   block.Content,
 		IsError:   block.IsError,
+		Thinking:  block.Thinking,
+		Signature: block.Signature,
+		Data:      block.Data,
+
+		ServerContent: block.ServerContent,
 	}
 }
 
@@ -449,6 +1205,11 @@ func toLLMContentBlock(block agenttypes.ContentBlock) llm.ContentBlock {
 		ToolUseID: block.ToolUseID,
 		Content:   block.Content,
 		IsError:   block.IsError,
+		Thinking:  block.Thinking,
+		Signature: block.Signature,
+		Data:      block.Data,
+
+		ServerContent: block.ServerContent,
 	}
 }
 
@@ -461,6 +1222,7 @@ func fromLLMMessage(msg llm.Message) agenttypes.Message {
 		Role:             fromLLMRole(msg.Role),
 		Content:          content,
 		ReasoningContent: msg.ReasoningContent,
+		Metadata:         cloneMetadata(msg.Metadata),
 	}
 }
 
@@ -473,9 +1235,21 @@ func toLLMMessage(msg agenttypes.Message) llm.Message {
 		Role:             toLLMRole(msg.Role),
 		Content:          content,
 		ReasoningContent: msg.ReasoningContent,
+		Metadata:         cloneMetadata(msg.Metadata),
 	}
 }
 
+func cloneMetadata(metadata map[string]any) map[string]any {
+	if metadata == nil {
+		return nil
+	}
+	out := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		out[k] = v
+	}
+	return out
+}
+
 func fromLLMMessages(messages []llm.Message) []agenttypes.Message {
 	if len(messages) == 0 {
 		return nil
@@ -500,7 +1274,13 @@ func toLLMMessages(messages []agenttypes.Message) []llm.Message {
 
 func fromLLMContentDelta(delta llm.ContentBlockDelta) agenttypes.ContentBlockDelta {
 	return agenttypes.ContentBlockDelta{
-		Type: fromLLMContentType(delta.Type),
-		Text: delta.Text,
+		Type:         fromLLMContentType(delta.Type),
+		Text:         delta.Text,
+		Phase:        agenttypes.ToolCallPhase(delta.Phase),
+		Index:        delta.Index,
+		ID:           delta.ID,
+		Name:         delta.Name,
+		PartialInput: delta.PartialInput,
+		Input:        delta.Input,
 	}
 }
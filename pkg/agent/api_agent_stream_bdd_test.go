@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -200,8 +201,8 @@ func TestExecuteStreamBehavior_GivenProviderWithoutStreamingSupport_WhenExecuteS
 	if len(streamErrors) != 0 {
 		t.Fatalf("expected no stream errors, got %v", streamErrors)
 	}
-	if len(streamEvents) != 3 {
-		t.Fatalf("expected 3 coarse events (start, message_end, end), got %d (%v)", len(streamEvents), streamEvents)
+	if len(streamEvents) != 4 {
+		t.Fatalf("expected 4 coarse events (start, message_end, usage_update, end), got %d (%v)", len(streamEvents), streamEvents)
 	}
 	if streamEvents[0].Type != AgentEventAgentStart {
 		t.Fatalf("expected first event agent_start, got %s", streamEvents[0].Type)
@@ -209,8 +210,11 @@ func TestExecuteStreamBehavior_GivenProviderWithoutStreamingSupport_WhenExecuteS
 	if streamEvents[1].Type != AgentEventMessageEnd || streamEvents[1].Message != "fallback" {
 		t.Fatalf("expected message_end fallback, got %+v", streamEvents[1])
 	}
-	if streamEvents[2].Type != AgentEventAgentEnd {
-		t.Fatalf("expected final event agent_end, got %s", streamEvents[2].Type)
+	if streamEvents[2].Type != AgentEventUsageUpdate {
+		t.Fatalf("expected usage_update after the response, got %s", streamEvents[2].Type)
+	}
+	if streamEvents[3].Type != AgentEventAgentEnd {
+		t.Fatalf("expected final event agent_end, got %s", streamEvents[3].Type)
 	}
 }
 
@@ -300,3 +304,62 @@ func TestExecuteStreamBehavior_GivenExecutionFails_WhenExecuteStream_ThenEmitsEr
 		t.Fatalf("did not expect agent_end on failure, got %v", streamEvents)
 	}
 }
+
+func TestExecuteStreamBehavior_GivenMultipleResponses_WhenExecuteStream_ThenEmitsCumulativeUsageUpdates(t *testing.T) {
+	// Given: two model responses with usage, the first one using a tool.
+	provider := &apiAgentSequentialEndTurnProvider{
+		responses: []llm.AgentResponse{
+			{
+				Role:       llm.RoleAssistant,
+				Model:      "claude-sonnet-4-20250514",
+				StopReason: llm.StopReasonToolUse,
+				Content: []llm.ContentBlock{
+					{Type: llm.ContentTypeToolUse, ID: "t1", Name: "missing_tool", Input: map[string]any{}},
+				},
+				Usage: llm.Usage{InputTokens: 1000, OutputTokens: 100, CacheReadInputTokens: 800},
+			},
+			{
+				Role:       llm.RoleAssistant,
+				Model:      "claude-sonnet-4-20250514",
+				StopReason: llm.StopReasonEndTurn,
+				Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "done"}},
+				Usage:      llm.Usage{InputTokens: 2000, OutputTokens: 200, CacheReadInputTokens: 900},
+			},
+		},
+	}
+	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{EnableStreaming: true})
+
+	// When: ExecuteStream is called.
+	events, errs := a.ExecuteStream(context.Background(), AgentRequest{Task: "usage"})
+	streamEvents, streamErrors := collectStreamResults(t, events, errs)
+	if len(streamErrors) != 0 {
+		t.Fatalf("expected no stream errors, got %v", streamErrors)
+	}
+
+	// Then: each response is followed by a usage_update with running totals.
+	var updates []ExecutionUsage
+	for _, evt := range streamEvents {
+		if evt.Type == AgentEventUsageUpdate {
+			updates = append(updates, *evt.Usage)
+		}
+	}
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 usage updates, got %d", len(updates))
+	}
+	first, last := updates[0], updates[1]
+	if first.TotalIterations != 1 || first.TotalInputTokens != 1000 || first.TotalOutputTokens != 100 || first.TotalCachedInputTokens != 800 {
+		t.Fatalf("unexpected first update: %+v", first)
+	}
+	if last.TotalIterations != 2 || last.TotalInputTokens != 3000 || last.TotalOutputTokens != 300 || last.TotalCachedInputTokens != 1700 {
+		t.Fatalf("unexpected last update: %+v", last)
+	}
+	// claude-sonnet-4 costs $3/$15 per million input/output tokens.
+	if math.Abs(first.EstimatedCost-0.0045) > 1e-9 || math.Abs(last.EstimatedCost-0.0135) > 1e-9 {
+		t.Fatalf("unexpected estimated costs: %v, %v", first.EstimatedCost, last.EstimatedCost)
+	}
+
+	end := streamEvents[len(streamEvents)-1]
+	if end.Type != AgentEventAgentEnd || end.Usage.EstimatedCost != last.EstimatedCost || end.Usage.TotalCachedInputTokens != 1700 {
+		t.Fatalf("expected agent_end to carry the final usage, got %+v", end.Usage)
+	}
+}
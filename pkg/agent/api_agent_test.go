@@ -2,8 +2,11 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
 	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
@@ -223,6 +226,105 @@ func TestAPIAgentExecuteStreamEmitsDeltaEvents(t *testing.T) {
 	}
 }
 
+func TestStreamDeltaEventMapsToolCallPhases(t *testing.T) {
+	tests := []struct {
+		name  string
+		delta agenttypes.ContentBlockDelta
+		want  AgentStreamEvent
+	}{
+		{
+			name:  "text",
+			delta: agenttypes.ContentBlockDelta{Type: agenttypes.ContentTypeText, Text: "hi"},
+			want:  AgentStreamEvent{Type: AgentEventMessageDelta, Delta: "hi"},
+		},
+		{
+			name:  "start",
+			delta: agenttypes.ContentBlockDelta{Type: agenttypes.ContentTypeToolUse, Phase: agenttypes.ToolCallPhaseStart, ID: "c1", Name: "bash"},
+			want:  AgentStreamEvent{Type: AgentEventToolCallStart, ToolName: "bash", ToolCallID: "c1"},
+		},
+		{
+			name:  "delta",
+			delta: agenttypes.ContentBlockDelta{Type: agenttypes.ContentTypeToolUse, Phase: agenttypes.ToolCallPhaseDelta, ID: "c1", Name: "bash", PartialInput: `{"cmd"`},
+			want:  AgentStreamEvent{Type: AgentEventToolCallDelta, ToolName: "bash", ToolCallID: "c1", Delta: `{"cmd"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := streamDeltaEvent(tt.delta)
+			if got.Type != tt.want.Type || got.Delta != tt.want.Delta || got.ToolName != tt.want.ToolName || got.ToolCallID != tt.want.ToolCallID {
+				t.Fatalf("streamDeltaEvent() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	ready := streamDeltaEvent(agenttypes.ContentBlockDelta{
+		Type:  agenttypes.ContentTypeToolUse,
+		Phase: agenttypes.ToolCallPhaseReady,
+		Name:  "bash",
+		Input: map[string]any{"cmd": "ls"},
+	})
+	if ready.Type != AgentEventToolCallReady || ready.ToolInput["cmd"] != "ls" {
+		t.Fatalf("unexpected ready event: %#v", ready)
+	}
+}
+
+type apiAgentStreamingTool struct {
+	apiAgentNoopTool
+}
+
+func (apiAgentStreamingTool) ExecuteStream(_ context.Context, _ *tools.ToolContext, _ map[string]any, w io.Writer) (tools.ToolResult, error) {
+	_, _ = io.WriteString(w, "building...\n")
+	_, _ = io.WriteString(w, "ok\n")
+	return tools.ToolResult{}, nil
+}
+
+func TestAPIAgentExecuteStreamEmitsToolOutputEvents(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(apiAgentStreamingTool{})
+	a := NewAPIAgent(&apiAgentLoopProvider{toolIterations: 1}, registry, APIAgentOptions{
+		EnableStreaming: true,
+	})
+
+	events, errs := a.ExecuteStream(context.Background(), AgentRequest{Task: "build"})
+
+	var output string
+	var toolResult string
+	for events != nil || errs != nil {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			switch evt.Type {
+			case AgentEventToolOutput:
+				if evt.ToolName != "noop" || evt.ToolCallID != "tool-1" {
+					t.Errorf("unexpected tool output source: %+v", evt)
+				}
+				output += evt.Delta
+			case AgentEventToolResult:
+				toolResult = evt.Message
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if err != nil {
+				t.Fatalf("unexpected stream error: %v", err)
+			}
+		}
+	}
+
+	if output != "building...\nok\n" {
+		t.Fatalf("unexpected streamed tool output %q", output)
+	}
+	if toolResult != output {
+		t.Fatalf("expected assembled tool result %q, got %q", output, toolResult)
+	}
+}
+
 func TestAPIAgentExecuteAppliesTransformAndConvertHooks(t *testing.T) {
 	provider := &apiAgentPipelineProvider{}
 	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{})
@@ -289,3 +391,292 @@ func TestMessageConversionPreservesReasoningContent(t *testing.T) {
 		t.Fatalf("toLLMMessage reasoning_content = %q, want %q", roundTrip.ReasoningContent, "chain of thought summary")
 	}
 }
+
+type apiAgentThinkingProvider struct {
+	lastReq llm.AgentRequest
+}
+
+func (p *apiAgentThinkingProvider) Name() string {
+	return "api-agent-thinking-provider"
+}
+
+func (p *apiAgentThinkingProvider) Call(_ context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	p.lastReq = req
+	return llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		StopReason: llm.StopReasonEndTurn,
+		Content: []llm.ContentBlock{
+			{Type: llm.ContentTypeThinking, Thinking: "considering", Signature: "sig"},
+			{Type: llm.ContentTypeText, Text: "answer"},
+		},
+	}, nil
+}
+
+func TestAPIAgentExecuteStreamEmitsThinkingEvents(t *testing.T) {
+	provider := &apiAgentThinkingProvider{}
+	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{EnableStreaming: true})
+
+	var raw []agenttypes.Message
+	events, errs := a.ExecuteStream(context.Background(), AgentRequest{
+		Task:    "think",
+		Options: AgentOptions{ThinkingBudgetTokens: 2048},
+		Callbacks: AgentCallbacks{
+			OnMessage: func(msg agenttypes.Message) { raw = append(raw, msg) },
+		},
+	})
+
+	var order []AgentEventType
+	var thinking string
+	for events != nil || errs != nil {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			order = append(order, evt.Type)
+			if evt.Type == AgentEventThinking {
+				thinking = evt.Message
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if err != nil {
+				t.Fatalf("unexpected stream error: %v", err)
+			}
+		}
+	}
+
+	if thinking != "considering" {
+		t.Fatalf("expected thinking event with text, got %q (events %v)", thinking, order)
+	}
+	want := []AgentEventType{AgentEventAgentStart, AgentEventThinking, AgentEventMessageEnd, AgentEventUsageUpdate, AgentEventAgentEnd}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Fatalf("events = %v, want %v", order, want)
+	}
+	if provider.lastReq.Thinking == nil || provider.lastReq.Thinking.BudgetTokens != 2048 {
+		t.Fatalf("expected thinking budget on provider request, got %#v", provider.lastReq.Thinking)
+	}
+	if len(raw) != 1 || raw[0].Content[0].Signature != "sig" {
+		t.Fatalf("expected thinking signature to survive conversion, got %#v", raw)
+	}
+}
+
+type apiAgentSummaryProvider struct{}
+
+func (apiAgentSummaryProvider) Name() string {
+	return "api-agent-summary-provider"
+}
+
+func (apiAgentSummaryProvider) Call(_ context.Context, _ llm.AgentRequest) (llm.AgentResponse, error) {
+	return llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		StopReason: llm.StopReasonEndTurn,
+		Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "short summary"}},
+	}, nil
+}
+
+func TestAPIAgentCompactSummarizesMessages(t *testing.T) {
+	a := NewAPIAgent(apiAgentSummaryProvider{}, tools.NewRegistry(), APIAgentOptions{})
+
+	var messages []agenttypes.Message
+	for i := 0; i < 8; i++ {
+		role := agenttypes.RoleUser
+		if i%2 == 1 {
+			role = agenttypes.RoleAssistant
+		}
+		messages = append(messages, agenttypes.NewTextMessage(role, fmt.Sprintf("message %d with some padding text to count", i)))
+	}
+
+	result, err := a.Compact(context.Background(), CompactRequest{Messages: messages, KeepRecent: 2})
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if result.Summary != "short summary" {
+		t.Fatalf("expected summary, got %q", result.Summary)
+	}
+	if len(result.Messages) >= len(messages) {
+		t.Fatalf("expected fewer messages, got %d", len(result.Messages))
+	}
+	if result.Messages[0].GetText() != messages[0].GetText() {
+		t.Fatalf("expected first message to be kept, got %q", result.Messages[0].GetText())
+	}
+	if last := result.Messages[len(result.Messages)-1]; last.GetText() != messages[7].GetText() {
+		t.Fatalf("expected recent messages to be kept, got %q", last.GetText())
+	}
+	if result.CompactedMessages != len(messages)-len(result.Messages)+1 {
+		t.Fatalf("unexpected CompactedMessages %d", result.CompactedMessages)
+	}
+	if result.TokensSaved() <= 0 {
+		t.Fatalf("expected token savings, got before=%d after=%d", result.TokensBefore, result.TokensAfter)
+	}
+}
+
+func TestAPIAgentExecuteReportsResultMetadata(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(apiAgentNoopTool{})
+	a := NewAPIAgent(&apiAgentLoopProvider{toolIterations: 1}, registry, APIAgentOptions{})
+
+	result, err := a.Execute(context.Background(), AgentRequest{Task: "run"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	meta := result.Metadata
+	if meta.Provider != "api-agent-loop-provider" || meta.StopReason != agenttypes.StopReasonEndTurn {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+	if len(meta.Responses) != 2 || meta.Responses[0].StopReason != agenttypes.StopReasonToolUse || meta.Responses[1].Iteration != 2 {
+		t.Fatalf("unexpected responses: %+v", meta.Responses)
+	}
+}
+
+func TestAPIAgentExecuteAppliesContextTransforms(t *testing.T) {
+	provider := &apiAgentPipelineProvider{}
+	appendText := func(text string) func(context.Context, []agenttypes.Message) ([]agenttypes.Message, error) {
+		return func(_ context.Context, messages []agenttypes.Message) ([]agenttypes.Message, error) {
+			return append(messages, agenttypes.NewTextMessage(agenttypes.RoleUser, text)), nil
+		}
+	}
+	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{
+		ContextTransforms: []ContextTransform{
+			{Name: "docs", Stage: ContextStageAfterValidation, Transform: appendText("agent docs")},
+			{Name: "notes", Stage: ContextStageAfterCompaction, Transform: appendText("agent notes")},
+		},
+	})
+
+	_, err := a.Execute(context.Background(), AgentRequest{
+		Task: "pipeline",
+		Options: AgentOptions{
+			ContextTransforms: []ContextTransform{
+				{Name: "docs", Stage: ContextStageAfterValidation, Transform: appendText("request docs")},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	var texts []string
+	for _, msg := range provider.lastReq.Messages {
+		texts = append(texts, msg.GetText())
+	}
+	want := []string{"pipeline", "agent notes", "request docs"}
+	if fmt.Sprint(texts) != fmt.Sprint(want) {
+		t.Fatalf("provider messages = %q, want %q", texts, want)
+	}
+}
+
+func TestAPIAgentExecutePassesProviderParams(t *testing.T) {
+	provider := &apiAgentPipelineProvider{}
+	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{})
+
+	_, err := a.Execute(context.Background(), AgentRequest{
+		Task:    "params",
+		Options: AgentOptions{ProviderParams: map[string]any{"reasoning_effort": "high"}},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if provider.lastReq.ExtraBody["reasoning_effort"] != "high" {
+		t.Fatalf("provider extra body = %v", provider.lastReq.ExtraBody)
+	}
+}
+
+func TestAPIAgentExecuteRejectsInvalidContextTransforms(t *testing.T) {
+	identity := func(_ context.Context, messages []agenttypes.Message) ([]agenttypes.Message, error) {
+		return messages, nil
+	}
+	tests := []struct {
+		name       string
+		transforms []ContextTransform
+	}{
+		{"missing name", []ContextTransform{{Transform: identity}}},
+		{"missing function", []ContextTransform{{Name: "x"}}},
+		{"unknown stage", []ContextTransform{{Name: "x", Stage: "later", Transform: identity}}},
+		{"duplicate name", []ContextTransform{{Name: "x", Transform: identity}, {Name: "x", Transform: identity}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAPIAgent(&apiAgentPipelineProvider{}, tools.NewRegistry(), APIAgentOptions{})
+			_, err := a.Execute(context.Background(), AgentRequest{
+				Task:    "pipeline",
+				Options: AgentOptions{ContextTransforms: tt.transforms},
+			})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestAPIAgentExecuteRequestToolChoiceOverridesAgent(t *testing.T) {
+	provider := &apiAgentPipelineProvider{}
+	registry := tools.NewRegistry()
+	registry.MustRegister(apiAgentNoopTool{})
+	a := NewAPIAgent(provider, registry, APIAgentOptions{
+		ToolChoice: &ToolChoice{DisableParallelToolUse: true},
+	})
+
+	if _, err := a.Execute(context.Background(), AgentRequest{Task: "go"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := provider.lastReq.ToolChoice; got == nil || got.Type != "auto" || !got.DisableParallelToolUse {
+		t.Fatalf("agent tool choice = %#v", got)
+	}
+
+	_, err := a.Execute(context.Background(), AgentRequest{
+		Task:    "go",
+		Options: AgentOptions{ToolChoice: &ToolChoice{Mode: ToolChoiceNone}},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := provider.lastReq.ToolChoice; got == nil || got.Type != "none" || got.DisableParallelToolUse {
+		t.Fatalf("request tool choice = %#v", got)
+	}
+}
+
+// apiAgentHangingProvider calls noop once, then waits for the context.
+type apiAgentHangingProvider struct {
+	apiAgentLoopProvider
+}
+
+func (p *apiAgentHangingProvider) Call(ctx context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	if p.callCount < p.toolIterations {
+		return p.apiAgentLoopProvider.Call(ctx, req)
+	}
+	<-ctx.Done()
+	return llm.AgentResponse{}, ctx.Err()
+}
+
+func TestAPIAgentExecuteTimeoutReturnsPartialResult(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(apiAgentNoopTool{})
+	a := NewAPIAgent(&apiAgentHangingProvider{apiAgentLoopProvider{toolIterations: 1}}, registry, APIAgentOptions{})
+
+	result, err := a.Execute(context.Background(), AgentRequest{
+		Task:    "run",
+		Options: AgentOptions{Timeout: 50 * time.Millisecond},
+	})
+	var timeoutErr *RunTimeoutError
+	if !errors.As(err, &timeoutErr) || !errors.Is(err, ErrRunTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a run timeout, got %v", err)
+	}
+	if timeoutErr.Timeout != 50*time.Millisecond {
+		t.Fatalf("Timeout = %v", timeoutErr.Timeout)
+	}
+	if result.Success || len(result.ToolCalls) != 1 || len(result.RawOutput) < 3 {
+		t.Fatalf("expected the partial run in the result, got success=%v tool_calls=%d messages=%d",
+			result.Success, len(result.ToolCalls), len(result.RawOutput))
+	}
+
+	// A caller's own cancellation is not a run timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	a = NewAPIAgent(&apiAgentHangingProvider{}, registry, APIAgentOptions{})
+	if _, err := a.Execute(ctx, AgentRequest{Task: "run", Options: AgentOptions{Timeout: time.Minute}}); errors.Is(err, ErrRunTimeout) {
+		t.Fatalf("expected the caller's deadline error, got %v", err)
+	}
+}
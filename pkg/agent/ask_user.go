@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// askUserPollInterval is how often a pending ask_user question checks the
+// steering source for the answer.
+const askUserPollInterval = 100 * time.Millisecond
+
+// steeringAsker returns a tools.UserAsker that announces the question via
+// notify and takes the next steering messages as the answer. A zero
+// timeout waits until ctx ends.
+func steeringAsker(fetch LoopInputFetcher, timeout time.Duration, notify func(tools.UserQuestion)) tools.UserAsker {
+	return func(ctx context.Context, q tools.UserQuestion) (string, error) {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		if notify != nil {
+			notify(q)
+		}
+
+		ticker := time.NewTicker(askUserPollInterval)
+		defer ticker.Stop()
+		for {
+			msgs, err := fetch(ctx, LoopInputSnapshot{})
+			if err != nil {
+				return "", fmt.Errorf("read answer: %w", err)
+			}
+			var parts []string
+			for _, msg := range msgs {
+				if text := strings.TrimSpace(msg.GetText()); text != "" {
+					parts = append(parts, text)
+				}
+			}
+			if len(parts) > 0 {
+				return strings.Join(parts, "\n"), nil
+			}
+			select {
+			case <-ctx.Done():
+				if ctx.Err() == context.DeadlineExceeded {
+					return "", fmt.Errorf("timed out after %s", timeout)
+				}
+				return "", ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/tools/builtin"
+)
+
+// apiAgentAskingProvider calls ask_user once, then replies with the answer.
+type apiAgentAskingProvider struct{}
+
+func (apiAgentAskingProvider) Name() string {
+	return "asking"
+}
+
+func (apiAgentAskingProvider) Call(_ context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	last := req.Messages[len(req.Messages)-1]
+	for _, block := range last.Content {
+		if block.Type == llm.ContentTypeToolResult {
+			return llm.AgentResponse{
+				Role:       llm.RoleAssistant,
+				StopReason: llm.StopReasonEndTurn,
+				Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "answer: " + block.Content}},
+			}, nil
+		}
+	}
+	return llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		StopReason: llm.StopReasonToolUse,
+		Content: []llm.ContentBlock{{
+			Type:  llm.ContentTypeToolUse,
+			ID:    "ask-1",
+			Name:  "ask_user",
+			Input: map[string]any{"question": "Which region?", "options": []any{"eu", "us"}},
+		}},
+	}, nil
+}
+
+func TestAPIAgentStreamAsksUserAndResumesWithAnswer(t *testing.T) {
+	registry := tools.NewRegistry()
+	builtin.RegisterUserTools(registry)
+	a := NewAPIAgent(apiAgentAskingProvider{}, registry, APIAgentOptions{EnableStreaming: true})
+
+	queue := NewLoopInputQueue(LoopInputQueueConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	eventCh, errCh := a.ExecuteStream(ctx, AgentRequest{
+		Task:    "deploy",
+		Options: AgentOptions{InputQueue: queue},
+	})
+
+	var final string
+	for evt := range eventCh {
+		switch evt.Type {
+		case AgentEventUserInputRequired:
+			if evt.Message != "Which region?" || len(evt.Options) != 2 {
+				t.Fatalf("unexpected question event: %+v", evt)
+			}
+			if err := queue.PushText("eu", LoopInputPush{}); err != nil {
+				t.Fatalf("push answer: %v", err)
+			}
+		case AgentEventMessageEnd:
+			final = evt.Message
+		}
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "answer: eu" {
+		t.Fatalf("expected the answer to reach the model, got %q", final)
+	}
+}
+
+func TestAskUserTimesOut(t *testing.T) {
+	asker := steeringAsker(NewLoopInputQueue(LoopInputQueueConfig{}).Steering(), 50*time.Millisecond, nil)
+	if _, err := asker(context.Background(), tools.UserQuestion{Question: "?"}); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -38,11 +41,42 @@ type CLIRequest struct {
 	// Context provides additional context.
 	Context map[string]any
 
-	// AllowedTools restricts which tools can be used.
+	// AllowedTools restricts which tools can be used (--allowedTools).
 	AllowedTools []string
 
+	// DeniedTools lists tools that cannot be used (--disallowedTools).
+	DeniedTools []string
+
+	// PermissionMode selects the CLI permission mode (--permission-mode).
+	PermissionMode CLIPermissionMode
+
 	// Timeout in seconds.
 	TimeoutSeconds int
+
+	// Env contains extra environment variables for the CLI process.
+	Env map[string]string
+
+	// ResumeSessionID continues an existing CLI session (--resume).
+	ResumeSessionID string
+
+	// SessionID starts a new CLI session with this ID (--session-id).
+	// Ignored when ResumeSessionID is set.
+	SessionID string
+
+	// OnEvent receives progress events from clients that stream them.
+	OnEvent func(CLIEvent)
+
+	// OnPermission answers tool permission prompts. Nil leaves permissions
+	// to the CLI's own settings.
+	OnPermission CLIPermissionHandler
+
+	// GetSteeringMessages is polled after tool results; returned messages
+	// are sent to the running CLI session.
+	GetSteeringMessages func(ctx context.Context) ([]string, error)
+
+	// GetFollowUpMessages is polled when the CLI finishes a turn; returned
+	// messages start another turn in the same session.
+	GetFollowUpMessages func(ctx context.Context) ([]string, error)
 }
 
 // CLIResponse is the response format from CLI agents.
@@ -61,12 +95,23 @@ type CLIResponse struct {
 
 	// Error contains any error message.
 	Error string
+
+	// Usage reports turns and tokens when the CLI provides them.
+	Usage ExecutionUsage
+
+	// SessionID is the CLI session identifier, when reported.
+	SessionID string
 }
 
 // CLIAgent wraps external CLI tools (like Claude Code) to implement the Agent interface.
 type CLIAgent struct {
 	client CLIAgentClient
 	config CLIAgentConfig
+
+	// mu guards session, the CLI session continued by later executions
+	// when config.SessionID or config.ResumeSessions is set.
+	mu      sync.Mutex
+	session string
 }
 
 // CLIAgentConfig configures a CLI agent.
@@ -83,8 +128,35 @@ type CLIAgentConfig struct {
 	// Timeout is the execution timeout.
 	Timeout time.Duration
 
-	// AllowedTools restricts which tools the agent can use.
+	// AllowedTools restricts which tools the agent can use. Entries are
+	// allowed-tools patterns as in AgentOptions.AllowedTools; built-in tool
+	// names are translated to Claude Code tools (see CLI Agent in the
+	// README).
 	AllowedTools []string
+
+	// DeniedTools lists tools the agent cannot use, with the same patterns
+	// as AllowedTools.
+	DeniedTools []string
+
+	// PermissionMode selects the CLI permission mode, e.g.
+	// CLIPermissionModeAcceptEdits. Empty leaves it to the CLI settings.
+	PermissionMode CLIPermissionMode
+
+	// Protocol selects single-result JSON (default) or the interactive
+	// stream-json protocol.
+	Protocol CLIProtocol
+
+	// PermissionHandler answers tool permission prompts in stream-json mode.
+	PermissionHandler CLIPermissionHandler
+
+	// SessionID pins the agent to one CLI session: the first execution
+	// creates it with this ID (a UUID for Claude Code) and later executions
+	// resume it.
+	SessionID string
+
+	// ResumeSessions continues the most recent session on each execution,
+	// turning consecutive Execute calls into one multi-turn conversation.
+	ResumeSessions bool
 }
 
 // ClaudeCodeConfig configures the Claude Code CLI agent.
@@ -116,75 +188,144 @@ func NewCLIAgent(client CLIAgentClient, config CLIAgentConfig) *CLIAgent {
 
 // Execute runs the CLI agent.
 func (a *CLIAgent) Execute(ctx context.Context, req AgentRequest) (AgentResult, error) {
+	return a.execute(ctx, req, nil)
+}
+
+// execute runs the CLI agent, forwarding session events to the request
+// callbacks and, when set, to emit.
+func (a *CLIAgent) execute(ctx context.Context, req AgentRequest, emit func(AgentStreamEvent)) (AgentResult, error) {
+	if len(req.History) > 0 {
+		err := fmt.Errorf("CLI agents do not accept History; resume with SessionID instead")
+		return AgentResult{Success: false, Message: err.Error()}, err
+	}
+	reqEnv, err := resolveRequestEnv(ctx, req)
+	if err != nil {
+		return AgentResult{Success: false, Message: err.Error()}, err
+	}
+	redactor, unmask := reqEnv.mask(nil)
+	defer unmask()
+
+	bridge := &cliEventBridge{callbacks: req.Callbacks, emit: emit, redactor: redactor}
+	resumeID, sessionID := a.sessionFor(req)
+
 	// Build CLI request
 	cliReq := CLIRequest{
-		Task:           req.Task,
-		SystemPrompt:   req.SystemPrompt,
-		WorkDir:        req.WorkDir,
-		AllowedTools:   a.config.AllowedTools,
-		TimeoutSeconds: int(a.config.Timeout.Seconds()),
+		Task:            req.Task,
+		SystemPrompt:    req.SystemPrompt,
+		WorkDir:         req.WorkDir,
+		AllowedTools:    a.config.AllowedTools,
+		DeniedTools:     a.config.DeniedTools,
+		PermissionMode:  a.config.PermissionMode,
+		TimeoutSeconds:  int(a.config.Timeout.Seconds()),
+		OnEvent:         bridge.handle,
+		OnPermission:    a.config.PermissionHandler,
+		ResumeSessionID: resumeID,
+		SessionID:       sessionID,
+	}
+	if len(req.Options.AllowedTools) > 0 {
+		cliReq.AllowedTools = req.Options.AllowedTools
+	}
+	if len(req.Options.DeniedTools) > 0 {
+		cliReq.DeniedTools = req.Options.DeniedTools
+	}
+	cliReq.Env = withToolPolicyEnv(reqEnv.env, cliReq.AllowedTools, cliReq.DeniedTools)
+	getSteering, getFollowUp := req.Options.loopInputFetchers()
+	if getSteering != nil {
+		cliReq.GetSteeringMessages = bridge.inputs(getSteering, req.Callbacks.OnSteeringApplied, AgentEventSteeringApplied)
+	}
+	if getFollowUp != nil {
+		cliReq.GetFollowUpMessages = bridge.inputs(getFollowUp, req.Callbacks.OnFollowUpApplied, AgentEventFollowUpApplied)
 	}
 
 	// Execute
-	cliResp, err := a.client.Execute(ctx, cliReq)
+	runCtx, cancel := withRunTimeout(ctx, req.Options.Timeout)
+	defer cancel()
+	cliResp, err := a.client.Execute(runCtx, cliReq)
 	if err != nil {
-		return AgentResult{
+		err = runTimeoutError(ctx, runCtx, req.Options.Timeout, err)
+		result := AgentResult{
 			Success: false,
 			Message: err.Error(),
-		}, err
+		}
+		if errors.Is(err, ErrRunTimeout) {
+			result.ToolCalls = bridge.records()
+		}
+		report := finalUsageReport(result, err)
+		report.SessionID = firstNonEmpty(resumeID, sessionID)
+		reportUsage(ctx, req.Options.UsageReporter, req.Options, report)
+		return result, err
 	}
 
-	// Convert response
-	return convertCLIResponse(cliResp), nil
+	// Convert response, masking any secrets the CLI echoed back
+	result := convertCLIResponse(cliResp)
+	if result.SessionID == "" {
+		result.SessionID = firstNonEmpty(resumeID, sessionID)
+	}
+	a.rememberSession(result.SessionID)
+	result.ToolCalls = bridge.records()
+	maskResult(redactor, &result)
+	classifyOutcome(ctx, req.Options.OutcomeClassifier, &result)
+	reportUsage(ctx, req.Options.UsageReporter, req.Options, finalUsageReport(result, nil))
+	return result, nil
 }
 
-// ExecuteStream runs the CLI agent and emits coarse-grained stream events.
-// CLI backends are currently non-streaming, so this degrades gracefully.
+// ExecuteStream runs the CLI agent and emits stream events. Clients using
+// the stream-json protocol report text deltas, messages, and tool calls as
+// they happen; other clients only report the final message.
+// req.Options.StreamBuffer decides what happens when the consumer falls
+// behind.
 func (a *CLIAgent) ExecuteStream(ctx context.Context, req AgentRequest) (<-chan AgentStreamEvent, <-chan error) {
-	eventCh := make(chan AgentStreamEvent, 8)
 	errCh := make(chan error, 1)
 
+	runCtx, cancel := context.WithCancel(ctx)
+	stream := newStreamEmitter(ctx, cancel, req.Options.StreamBuffer)
 	go func() {
-		defer close(eventCh)
+		defer cancel()
+		defer stream.close()
 		defer close(errCh)
 
-		select {
-		case <-ctx.Done():
-			errCh <- ctx.Err()
+		sawMessage := false
+		emit := func(evt AgentStreamEvent) bool {
+			if evt.Type == AgentEventMessageEnd {
+				sawMessage = true
+			}
+			return stream.emit(evt)
+		}
+
+		if !emit(AgentStreamEvent{Type: AgentEventAgentStart}) {
+			errCh <- stream.err()
 			return
-		case eventCh <- AgentStreamEvent{Type: AgentEventAgentStart}:
 		}
 
-		result, err := a.Execute(ctx, req)
+		result, err := a.execute(runCtx, req, func(evt AgentStreamEvent) { _ = emit(evt) })
+		if disconnectErr := stream.disconnectErr(); disconnectErr != nil {
+			// The run may have finished before it saw the cancellation.
+			err = disconnectErr
+		}
 		if err != nil {
 			errCh <- err
 			return
 		}
 
-		select {
-		case <-ctx.Done():
-			errCh <- ctx.Err()
-			return
-		case eventCh <- AgentStreamEvent{
+		if !sawMessage && !emit(AgentStreamEvent{
 			Type:    AgentEventMessageEnd,
 			Message: result.Message,
-		}:
+		}) {
+			errCh <- stream.err()
+			return
 		}
 
 		usage := result.Usage
-		select {
-		case <-ctx.Done():
-			errCh <- ctx.Err()
-			return
-		case eventCh <- AgentStreamEvent{
+		if !emit(AgentStreamEvent{
 			Type:    AgentEventAgentEnd,
 			Message: result.Message,
 			Usage:   &usage,
-		}:
+		}) {
+			errCh <- stream.err()
 		}
 	}()
 
-	return eventCh, errCh
+	return stream.events(), errCh
 }
 
 // Capabilities returns the agent's capabilities.
@@ -211,9 +352,35 @@ func convertCLIResponse(resp CLIResponse) AgentResult {
 		Summary:     resp.Summary,
 		Message:     resp.Message,
 		FileChanges: resp.FileChanges,
+		Usage:       resp.Usage,
+		SessionID:   resp.SessionID,
 	}
 }
 
+// sessionFor returns the session to resume or, for a pinned session that
+// does not exist yet, the session to create.
+func (a *CLIAgent) sessionFor(req AgentRequest) (resumeID, sessionID string) {
+	if req.SessionID != "" {
+		return req.SessionID, ""
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.session != "" {
+		return a.session, ""
+	}
+	return "", a.config.SessionID
+}
+
+// rememberSession records the session later executions continue.
+func (a *CLIAgent) rememberSession(sessionID string) {
+	if sessionID == "" || (a.config.SessionID == "" && !a.config.ResumeSessions) {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.session = sessionID
+}
+
 // ClaudeCodeClient communicates with Claude Code CLI.
 // It implements CLIAgentClient for the Claude Code tool.
 type ClaudeCodeClient struct {
@@ -225,6 +392,20 @@ type ClaudeCodeClient struct {
 
 	// Timeout is the execution timeout.
 	Timeout time.Duration
+
+	// Protocol selects single-result JSON (default) or stream-json.
+	Protocol CLIProtocol
+}
+
+// sessionArgs returns the CLI flags selecting the request's session.
+func (r CLIRequest) sessionArgs() []string {
+	switch {
+	case r.ResumeSessionID != "":
+		return []string{"--resume", r.ResumeSessionID}
+	case r.SessionID != "":
+		return []string{"--session-id", r.SessionID}
+	}
+	return nil
 }
 
 // NewClaudeCodeClient creates a new ClaudeCodeClient.
@@ -235,9 +416,10 @@ func NewClaudeCodeClient(cfg CLIAgentConfig) *ClaudeCodeClient {
 		timeout = 30 * time.Minute
 	}
 	return &ClaudeCodeClient{
-		Command: cmd,
-		Args:    cfg.Args,
-		Timeout: timeout,
+		Command:  cmd,
+		Args:     cfg.Args,
+		Timeout:  timeout,
+		Protocol: cfg.Protocol,
 	}
 }
 
@@ -245,10 +427,21 @@ func NewClaudeCodeClient(cfg CLIAgentConfig) *ClaudeCodeClient {
 func (c *ClaudeCodeClient) Execute(ctx context.Context, req CLIRequest) (CLIResponse, error) {
 	log.Printf("[claude-code] executing: workdir=%s task_length=%d", req.WorkDir, len(req.Task))
 
+	timeout := c.Timeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	if c.Protocol == CLIProtocolStreamJSON {
+		return c.executeStreamJSON(ctx, req, timeout)
+	}
+
 	// Build command arguments
 	args := make([]string, 0, len(c.Args)+4)
 	args = append(args, c.Args...)
 
+	args = append(args, req.sessionArgs()...)
+	args = append(args, req.permissionArgs()...)
+
 	// Add output format for structured response
 	args = append(args, "--output-format", "json")
 
@@ -256,15 +449,17 @@ func (c *ClaudeCodeClient) Execute(ctx context.Context, req CLIRequest) (CLIResp
 	args = append(args, "-p", req.Task)
 
 	// Create command with timeout
-	timeout := c.Timeout
-	if req.TimeoutSeconds > 0 {
-		timeout = time.Duration(req.TimeoutSeconds) * time.Second
-	}
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, c.Command, args...)
 	cmd.Dir = req.WorkDir
+	if len(req.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range req.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -314,13 +509,16 @@ func (c *ClaudeCodeClient) parseOutput(output []byte) (CLIResponse, error) {
 
 	if rawResp.Error != "" {
 		return CLIResponse{
-			Success: false,
-			Error:   rawResp.Error,
+			Success:   false,
+			Error:     rawResp.Error,
+			SessionID: rawResp.SessionID,
 		}, nil
 	}
 
 	// Parse the result content
-	return c.parseResultContent(rawResp.Result)
+	resp, err := c.parseResultContent(rawResp.Result)
+	resp.SessionID = rawResp.SessionID
+	return resp, err
 }
 
 // parseTextOutput parses plain text output from Claude Code.
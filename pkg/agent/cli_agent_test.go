@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+// fakeSessionCLI reports its session flags in the result and echoes the
+// selected session ID (or a fresh one).
+const fakeSessionCLI = `#!/bin/sh
+flags=""
+session="new-1"
+while [ $# -gt 0 ]; do
+	case "$1" in
+	--resume|--session-id) flags="$flags $1 $2"; session="$2"; shift;;
+	esac
+	shift
+done
+printf '{"result":"flags:%s","session_id":"%s"}\n' "$flags" "$session"
+`
+
+func newFakeSessionAgent(t *testing.T, cfg CLIAgentConfig) *CLIAgent {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	if err := os.WriteFile(path, []byte(fakeSessionCLI), 0o755); err != nil {
+		t.Fatalf("write fake cli: %v", err)
+	}
+	cfg.Command = path
+	cfg.Timeout = 5 * time.Second
+	return NewCLIAgent(NewClaudeCodeClient(cfg), cfg)
+}
+
+func TestCLIAgentSessions(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  CLIAgentConfig
+		reqs []string // AgentRequest.SessionID per call
+		want []string // result messages
+		ids  []string // result session IDs
+	}{
+		{
+			name: "independent by default",
+			reqs: []string{"", ""},
+			want: []string{"flags:", "flags:"},
+			ids:  []string{"new-1", "new-1"},
+		},
+		{
+			name: "resume sessions",
+			cfg:  CLIAgentConfig{ResumeSessions: true},
+			reqs: []string{"", ""},
+			want: []string{"flags:", "flags: --resume new-1"},
+			ids:  []string{"new-1", "new-1"},
+		},
+		{
+			name: "pinned session",
+			cfg:  CLIAgentConfig{SessionID: "pinned"},
+			reqs: []string{"", ""},
+			want: []string{"flags: --session-id pinned", "flags: --resume pinned"},
+			ids:  []string{"pinned", "pinned"},
+		},
+		{
+			name: "request session",
+			reqs: []string{"earlier", ""},
+			want: []string{"flags: --resume earlier", "flags:"},
+			ids:  []string{"earlier", "new-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newFakeSessionAgent(t, tt.cfg)
+			for i, sessionID := range tt.reqs {
+				result, err := a.Execute(context.Background(), AgentRequest{
+					Task:      "hi",
+					WorkDir:   t.TempDir(),
+					SessionID: sessionID,
+				})
+				if err != nil {
+					t.Fatalf("call %d: Execute error: %v", i, err)
+				}
+				if result.Message != tt.want[i] {
+					t.Errorf("call %d: expected message %q, got %q", i, tt.want[i], result.Message)
+				}
+				if result.SessionID != tt.ids[i] {
+					t.Errorf("call %d: expected session %q, got %q", i, tt.ids[i], result.SessionID)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIAgentRejectsHistory(t *testing.T) {
+	a := newFakeSessionAgent(t, CLIAgentConfig{})
+	_, err := a.Execute(context.Background(), AgentRequest{
+		Task:    "hi",
+		WorkDir: t.TempDir(),
+		History: []agenttypes.Message{agenttypes.NewTextMessage(agenttypes.RoleUser, "earlier")},
+	})
+	if err == nil {
+		t.Fatal("expected History to be rejected")
+	}
+}
+
+func TestClaudeCodeToolRules(t *testing.T) {
+	rules, all := claudeCodeToolRules([]string{"read_file", "Read", "git_status", "git", "mcp__github", "Bash(npm test:*)", " ", "custom_tool"})
+	want := []string{"Read", "Bash(git status:*)", "Bash(git:*)", "mcp__github", "Bash(npm test:*)", "custom_tool"}
+	if all || strings.Join(rules, "|") != strings.Join(want, "|") {
+		t.Fatalf("claudeCodeToolRules() = %q, %v; want %q", rules, all, want)
+	}
+	if _, all := claudeCodeToolRules([]string{"bash", "*"}); !all {
+		t.Fatal("expected * to allow all tools")
+	}
+}
+
+func TestCLIAgentPassesToolPolicy(t *testing.T) {
+	// The fake CLI echoes its arguments and the policy environment.
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	script := "#!/bin/sh\nprintf '{\"result\":\"%s|%s|%s\"}\\n' \"$*\" \"$(echo \"$AGENT_ALLOWED_TOOLS\" | paste -sd,)\" \"$AGENT_DENIED_TOOLS\"\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake cli: %v", err)
+	}
+	cfg := CLIAgentConfig{
+		Command:        path,
+		Timeout:        5 * time.Second,
+		AllowedTools:   []string{"bash"},
+		DeniedTools:    []string{"web_fetch"},
+		PermissionMode: CLIPermissionModeAcceptEdits,
+	}
+	a := NewCLIAgent(NewClaudeCodeClient(cfg), cfg)
+
+	result, err := a.Execute(context.Background(), AgentRequest{Task: "hi", WorkDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	want := "--allowedTools Bash --disallowedTools WebFetch --permission-mode acceptEdits --output-format json -p hi|bash|web_fetch"
+	if result.Message != want {
+		t.Fatalf("expected %q, got %q", want, result.Message)
+	}
+
+	result, err = a.Execute(context.Background(), AgentRequest{
+		Task:    "hi",
+		WorkDir: t.TempDir(),
+		Options: AgentOptions{AllowedTools: []string{"read_file", "write_file"}, DeniedTools: []string{"*"}},
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !strings.HasPrefix(result.Message, "--allowedTools Read,Write,Edit,MultiEdit --disallowedTools Bash,Read,") ||
+		!strings.HasSuffix(result.Message, "|read_file,write_file|*") {
+		t.Fatalf("expected request options to override the config, got %q", result.Message)
+	}
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/skills"
+)
+
+// CLIPermissionMode is the Claude Code permission mode (--permission-mode).
+type CLIPermissionMode string
+
+const (
+	// CLIPermissionModeDefault asks for permission on first use of a tool.
+	CLIPermissionModeDefault CLIPermissionMode = "default"
+
+	// CLIPermissionModeAcceptEdits accepts file edits without asking.
+	CLIPermissionModeAcceptEdits CLIPermissionMode = "acceptEdits"
+
+	// CLIPermissionModePlan lets the CLI analyze but not modify files or
+	// run commands.
+	CLIPermissionModePlan CLIPermissionMode = "plan"
+
+	// CLIPermissionModeBypassPermissions skips all permission prompts.
+	// Denied tools stay denied.
+	CLIPermissionModeBypassPermissions CLIPermissionMode = "bypassPermissions"
+)
+
+// Environment variables that expose the tool policy of a CLI run to the CLI
+// process, e.g. for hooks and MCP servers it starts. Values are
+// newline-separated allowed-tools patterns as given in the request.
+const (
+	EnvAllowedTools = "AGENT_ALLOWED_TOOLS"
+	EnvDeniedTools  = "AGENT_DENIED_TOOLS"
+)
+
+// claudeCodeToolNames maps built-in tool names and the allowed-tools
+// aliases accepted by skills.IsToolAllowed to Claude Code tool rules.
+var claudeCodeToolNames = map[string][]string{
+	"bash":       {"Bash"},
+	"read_file":  {"Read"},
+	"read":       {"Read"},
+	"list_files": {"Glob", "LS"},
+	"glob":       {"Glob"},
+	"grep":       {"Grep"},
+	"ls":         {"LS"},
+	"write_file": {"Write", "Edit", "MultiEdit"},
+	"write":      {"Write"},
+	"edit":       {"Edit", "MultiEdit"},
+	"web_fetch":  {"WebFetch"},
+	"web_search": {"WebSearch"},
+	"git":        {"Bash(git:*)"},
+	"git_*":      {"Bash(git:*)"},
+	"git:*":      {"Bash(git:*)"},
+}
+
+// claudeCodeBuiltinTools are denied for a "*" DeniedTools pattern.
+var claudeCodeBuiltinTools = []string{"Bash", "Read", "Write", "Edit", "MultiEdit", "Glob", "Grep", "LS", "WebFetch", "WebSearch", "NotebookEdit", "Task"}
+
+// claudeCodeToolRules translates allowed-tools patterns into Claude Code
+// tool rules. Built-in names and aliases are mapped (git_<cmd> becomes
+// Bash(git <cmd>:*)); MCP names, Claude Code rules such as "Bash(npm test)",
+// and unknown names are passed through. all reports a "*" pattern, which
+// rules leave out.
+func claudeCodeToolRules(patterns []string) (rules []string, all bool) {
+	seen := make(map[string]bool)
+	add := func(rule string) {
+		if !seen[rule] {
+			seen[rule] = true
+			rules = append(rules, rule)
+		}
+	}
+	for _, raw := range patterns {
+		pattern := strings.TrimSpace(raw)
+		lower := strings.ToLower(pattern)
+		switch {
+		case pattern == "":
+		case pattern == "*":
+			all = true
+		case claudeCodeToolNames[lower] != nil:
+			for _, rule := range claudeCodeToolNames[lower] {
+				add(rule)
+			}
+		case strings.HasPrefix(lower, "git_") && !strings.Contains(lower, "*"):
+			add("Bash(git " + strings.TrimPrefix(lower, "git_") + ":*)")
+		default:
+			add(pattern)
+		}
+	}
+	return rules, all
+}
+
+// permissionArgs returns the CLI flags carrying the request's tool policy.
+func (r CLIRequest) permissionArgs() []string {
+	var args []string
+	if rules, all := claudeCodeToolRules(r.AllowedTools); !all && len(rules) > 0 {
+		args = append(args, "--allowedTools", strings.Join(rules, ","))
+	}
+	rules, all := claudeCodeToolRules(r.DeniedTools)
+	if all {
+		rules = slices.Concat(claudeCodeBuiltinTools, rules)
+	}
+	if len(rules) > 0 {
+		args = append(args, "--disallowedTools", strings.Join(rules, ","))
+	}
+	if r.PermissionMode != "" {
+		args = append(args, "--permission-mode", string(r.PermissionMode))
+	}
+	return args
+}
+
+// withToolPolicyEnv returns env with EnvAllowedTools and EnvDeniedTools set
+// from the given policy. Values already in env are kept.
+func withToolPolicyEnv(env map[string]string, allowed, denied []string) map[string]string {
+	for key, patterns := range map[string][]string{EnvAllowedTools: allowed, EnvDeniedTools: denied} {
+		value := skills.JoinAllowedToolsEnv(patterns)
+		if value == "" {
+			continue
+		}
+		if _, ok := env[key]; ok {
+			continue
+		}
+		if env == nil {
+			env = make(map[string]string, 2)
+		}
+		env[key] = value
+	}
+	return env
+}
@@ -0,0 +1,646 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// CLIProtocol selects how ClaudeCodeClient talks to the CLI process.
+type CLIProtocol string
+
+const (
+	// CLIProtocolJSON runs the CLI once per request and parses a single JSON
+	// result from stdout (--output-format json). This is the default.
+	CLIProtocolJSON CLIProtocol = "json"
+
+	// CLIProtocolStreamJSON keeps the CLI process open for the whole request
+	// and exchanges JSONL messages over stdin/stdout (--input-format
+	// stream-json --output-format stream-json). It reports progress events,
+	// answers tool permission prompts, and forwards steering messages.
+	CLIProtocolStreamJSON CLIProtocol = "stream-json"
+)
+
+// CLIEventType identifies events decoded from an interactive CLI session.
+type CLIEventType string
+
+const (
+	CLIEventInit       CLIEventType = "init"
+	CLIEventTextDelta  CLIEventType = "text_delta"
+	CLIEventText       CLIEventType = "text"
+	CLIEventThinking   CLIEventType = "thinking"
+	CLIEventToolCall   CLIEventType = "tool_call"
+	CLIEventToolResult CLIEventType = "tool_result"
+	CLIEventResult     CLIEventType = "result"
+)
+
+// CLIEvent is one progress event reported by a streaming CLI client.
+type CLIEvent struct {
+	Type CLIEventType
+
+	// Text holds text, thinking, delta, tool result, or final result content.
+	Text string
+
+	ToolName   string
+	ToolCallID string
+	ToolInput  map[string]any
+	IsError    bool
+
+	// SessionID is the CLI's session identifier, when reported.
+	SessionID string
+}
+
+// CLIPermissionRequest asks whether the CLI may run a tool.
+type CLIPermissionRequest struct {
+	ToolName   string
+	ToolCallID string
+	Input      map[string]any
+}
+
+// CLIPermissionDecision answers a CLIPermissionRequest.
+type CLIPermissionDecision struct {
+	// Allow permits the tool call.
+	Allow bool
+
+	// Message explains a denial to the model.
+	Message string
+
+	// UpdatedInput replaces the tool input when the call is allowed.
+	// Nil keeps the original input.
+	UpdatedInput map[string]any
+}
+
+// CLIPermissionHandler decides tool permission prompts. An error denies the
+// call with the error text.
+type CLIPermissionHandler func(ctx context.Context, req CLIPermissionRequest) (CLIPermissionDecision, error)
+
+// streamJSONMessage is one line of the stream-json protocol. Only the fields
+// used by this client are decoded.
+type streamJSONMessage struct {
+	Type      string `json:"type"`
+	Subtype   string `json:"subtype"`
+	SessionID string `json:"session_id"`
+
+	// assistant / user
+	Message *struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+
+	// stream_event (with --include-partial-messages)
+	Event *struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+	} `json:"event"`
+
+	// result
+	Result   string `json:"result"`
+	IsError  bool   `json:"is_error"`
+	NumTurns int    `json:"num_turns"`
+	Usage    *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+
+	// control_request
+	RequestID string `json:"request_id"`
+	Request   *struct {
+		Subtype   string         `json:"subtype"`
+		ToolName  string         `json:"tool_name"`
+		ToolUseID string         `json:"tool_use_id"`
+		Input     map[string]any `json:"input"`
+	} `json:"request"`
+}
+
+type streamJSONBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	Thinking  string          `json:"thinking"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     map[string]any  `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// streamJSONSession drives one stream-json CLI process.
+type streamJSONSession struct {
+	req   CLIRequest
+	stdin io.WriteCloser
+
+	mu        sync.Mutex
+	closed    bool
+	toolNames map[string]string
+	resp      CLIResponse
+	gotResult bool
+}
+
+// executeStreamJSON runs the CLI with the stream-json protocol.
+func (c *ClaudeCodeClient) executeStreamJSON(ctx context.Context, req CLIRequest, timeout time.Duration) (CLIResponse, error) {
+	args := make([]string, 0, len(c.Args)+12)
+	args = append(args, c.Args...)
+	args = append(args, req.sessionArgs()...)
+	args = append(args, req.permissionArgs()...)
+	args = append(args, "-p",
+		"--input-format", "stream-json",
+		"--output-format", "stream-json",
+		"--verbose",
+		"--include-partial-messages")
+	if req.OnPermission != nil {
+		args = append(args, "--permission-prompt-tool", "stdio")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.Command, args...)
+	cmd.Dir = req.WorkDir
+	if len(req.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range req.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return CLIResponse{Success: false, Error: err.Error()}, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return CLIResponse{Success: false, Error: err.Error()}, err
+	}
+
+	log.Printf("[claude-code] running (stream-json): %s %s", c.Command, strings.Join(args, " "))
+	startTime := time.Now()
+	if err := cmd.Start(); err != nil {
+		return CLIResponse{Success: false, Error: fmt.Sprintf("execution error: %v", err)}, err
+	}
+
+	s := &streamJSONSession{req: req, stdin: stdin, toolNames: make(map[string]string)}
+	if err := s.sendUser(req.Task); err != nil {
+		log.Printf("[claude-code] WARNING: failed to send task: %v", err)
+	}
+
+	reader := bufio.NewReader(stdout)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			s.handleLine(ctx, line)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	s.closeInput()
+
+	err = cmd.Wait()
+	log.Printf("[claude-code] stream-json session completed in %v: stderr=%d err=%v",
+		time.Since(startTime), stderr.Len(), err)
+
+	s.mu.Lock()
+	resp, gotResult := s.resp, s.gotResult
+	s.mu.Unlock()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return CLIResponse{Success: false, Error: "execution timeout"},
+			fmt.Errorf("claude code execution timeout after %v", timeout)
+	}
+	if !gotResult {
+		if err == nil {
+			err = errors.New("CLI exited without a result")
+		}
+		return CLIResponse{
+			Success: false,
+			Error:   fmt.Sprintf("execution error: %v\nstderr: %s", err, stderr.String()),
+		}, err
+	}
+	resp.Usage.TotalDuration = time.Since(startTime)
+	return resp, nil
+}
+
+// handleLine decodes one stdout line and reacts to it.
+func (s *streamJSONSession) handleLine(ctx context.Context, line []byte) {
+	var msg streamJSONMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		log.Printf("[claude-code] WARNING: ignoring non-JSON output line: %s", truncateForLog(string(line), 200))
+		return
+	}
+
+	switch msg.Type {
+	case "system":
+		if msg.Subtype == "init" {
+			s.mu.Lock()
+			s.resp.SessionID = msg.SessionID
+			s.mu.Unlock()
+			s.emit(CLIEvent{Type: CLIEventInit, SessionID: msg.SessionID})
+		}
+
+	case "stream_event":
+		if msg.Event == nil || msg.Event.Type != "content_block_delta" {
+			return
+		}
+		if msg.Event.Delta.Type == "text_delta" {
+			s.emit(CLIEvent{Type: CLIEventTextDelta, Text: msg.Event.Delta.Text})
+		}
+
+	case "assistant":
+		for _, block := range decodeStreamJSONBlocks(msg) {
+			switch block.Type {
+			case "text":
+				s.emit(CLIEvent{Type: CLIEventText, Text: block.Text})
+			case "thinking":
+				s.emit(CLIEvent{Type: CLIEventThinking, Text: block.Thinking})
+			case "tool_use":
+				s.mu.Lock()
+				s.toolNames[block.ID] = block.Name
+				s.mu.Unlock()
+				s.emit(CLIEvent{Type: CLIEventToolCall, ToolName: block.Name, ToolCallID: block.ID, ToolInput: block.Input})
+			}
+		}
+
+	case "user":
+		results := 0
+		for _, block := range decodeStreamJSONBlocks(msg) {
+			if block.Type != "tool_result" {
+				continue
+			}
+			results++
+			s.mu.Lock()
+			name := s.toolNames[block.ToolUseID]
+			s.mu.Unlock()
+			s.emit(CLIEvent{
+				Type:       CLIEventToolResult,
+				ToolName:   name,
+				ToolCallID: block.ToolUseID,
+				Text:       streamJSONContentText(block.Content),
+				IsError:    block.IsError,
+			})
+		}
+		if results > 0 {
+			s.sendInputs(ctx, s.req.GetSteeringMessages)
+		}
+
+	case "result":
+		s.handleResult(ctx, msg)
+
+	case "control_request":
+		s.handleControlRequest(ctx, msg)
+	}
+}
+
+func (s *streamJSONSession) handleResult(ctx context.Context, msg streamJSONMessage) {
+	s.mu.Lock()
+	s.gotResult = true
+	if msg.SessionID != "" {
+		s.resp.SessionID = msg.SessionID
+	}
+	s.resp.Success = !msg.IsError && !strings.HasPrefix(msg.Subtype, "error")
+	s.resp.Summary = msg.Result
+	s.resp.Message = msg.Result
+	s.resp.Error = ""
+	if !s.resp.Success {
+		s.resp.Error = firstNonEmpty(msg.Result, msg.Subtype)
+	}
+	s.resp.Usage.TotalIterations += msg.NumTurns
+	if msg.Usage != nil {
+		s.resp.Usage.TotalInputTokens += msg.Usage.InputTokens
+		s.resp.Usage.TotalOutputTokens += msg.Usage.OutputTokens
+	}
+	closed := s.closed
+	s.mu.Unlock()
+
+	s.emit(CLIEvent{Type: CLIEventResult, Text: msg.Result, IsError: msg.IsError, SessionID: msg.SessionID})
+	if closed {
+		// A turn for input sent before closing; the latest result wins.
+		return
+	}
+	// Messages queued after the turn start a new one; otherwise closing
+	// stdin ends the session once the CLI has drained its input.
+	if !s.sendInputs(ctx, s.req.GetSteeringMessages) && !s.sendInputs(ctx, s.req.GetFollowUpMessages) {
+		s.closeInput()
+	}
+}
+
+func (s *streamJSONSession) handleControlRequest(ctx context.Context, msg streamJSONMessage) {
+	if msg.Request == nil {
+		return
+	}
+	if msg.Request.Subtype != "can_use_tool" || s.req.OnPermission == nil {
+		s.writeJSON(map[string]any{
+			"type": "control_response",
+			"response": map[string]any{
+				"subtype":    "error",
+				"request_id": msg.RequestID,
+				"error":      fmt.Sprintf("unsupported control request: %s", msg.Request.Subtype),
+			},
+		})
+		return
+	}
+
+	decision, err := s.req.OnPermission(ctx, CLIPermissionRequest{
+		ToolName:   msg.Request.ToolName,
+		ToolCallID: msg.Request.ToolUseID,
+		Input:      msg.Request.Input,
+	})
+	if err != nil {
+		decision = CLIPermissionDecision{Message: err.Error()}
+	}
+
+	answer := map[string]any{"behavior": "deny", "message": decision.Message}
+	if decision.Allow {
+		input := decision.UpdatedInput
+		if input == nil {
+			input = msg.Request.Input
+		}
+		answer = map[string]any{"behavior": "allow", "updatedInput": input}
+	} else if decision.Message == "" {
+		answer["message"] = "permission denied"
+	}
+	log.Printf("[claude-code] permission %s: %s", answer["behavior"], msg.Request.ToolName)
+	s.writeJSON(map[string]any{
+		"type": "control_response",
+		"response": map[string]any{
+			"subtype":    "success",
+			"request_id": msg.RequestID,
+			"response":   answer,
+		},
+	})
+}
+
+// sendInputs writes the messages returned by fetch as user turns and
+// reports whether any were sent.
+func (s *streamJSONSession) sendInputs(ctx context.Context, fetch func(context.Context) ([]string, error)) bool {
+	if fetch == nil {
+		return false
+	}
+	messages, err := fetch(ctx)
+	if err != nil {
+		log.Printf("[claude-code] WARNING: input provider failed: %v", err)
+		return false
+	}
+	sent := false
+	for _, text := range messages {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if err := s.sendUser(text); err != nil {
+			log.Printf("[claude-code] WARNING: failed to send message: %v", err)
+			return sent
+		}
+		sent = true
+	}
+	return sent
+}
+
+func (s *streamJSONSession) sendUser(text string) error {
+	return s.writeJSON(map[string]any{
+		"type":    "user",
+		"message": map[string]any{"role": "user", "content": text},
+	})
+}
+
+func (s *streamJSONSession) writeJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("CLI input is closed")
+	}
+	_, err = s.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (s *streamJSONSession) closeInput() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		_ = s.stdin.Close()
+	}
+}
+
+func (s *streamJSONSession) emit(evt CLIEvent) {
+	if s.req.OnEvent != nil {
+		s.req.OnEvent(evt)
+	}
+}
+
+func decodeStreamJSONBlocks(msg streamJSONMessage) []streamJSONBlock {
+	if msg.Message == nil || len(msg.Message.Content) == 0 {
+		return nil
+	}
+	var blocks []streamJSONBlock
+	if err := json.Unmarshal(msg.Message.Content, &blocks); err != nil {
+		// Plain string content.
+		var text string
+		if json.Unmarshal(msg.Message.Content, &text) == nil && text != "" {
+			return []streamJSONBlock{{Type: "text", Text: text}}
+		}
+		return nil
+	}
+	return blocks
+}
+
+// streamJSONContentText flattens tool result content, which is either a
+// string or a list of text blocks.
+func streamJSONContentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var blocks []streamJSONBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return string(raw)
+	}
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func truncateForLog(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
+
+// cliEventBridge forwards CLI session events to the request callbacks and
+// stream events, and records tool calls for the result.
+type cliEventBridge struct {
+	callbacks AgentCallbacks
+	emit      func(AgentStreamEvent)
+	redactor  *redact.Redactor
+
+	mu        sync.Mutex
+	snapshot  LoopInputSnapshot
+	inTurn    bool
+	toolCalls []ToolCallRecord
+	open      map[string]openCLIToolCall
+}
+
+// openCLIToolCall is a tool call waiting for its result.
+type openCLIToolCall struct {
+	index int
+	start time.Time
+}
+
+func (b *cliEventBridge) handle(evt CLIEvent) {
+	if b.redactor != nil {
+		evt.Text = b.redactor.Redact(evt.Text)
+		evt.ToolInput = b.redactor.RedactMap(evt.ToolInput)
+	}
+	iteration := b.track(evt)
+
+	cbs := b.callbacks
+	if iteration > 0 && cbs.OnIteration != nil {
+		cbs.OnIteration(iteration)
+	}
+	switch evt.Type {
+	case CLIEventTextDelta:
+		if cbs.OnStreamDelta != nil {
+			cbs.OnStreamDelta(agenttypes.ContentBlockDelta{Type: agenttypes.ContentTypeText, Text: evt.Text})
+		}
+		b.send(AgentStreamEvent{Type: AgentEventMessageDelta, Delta: evt.Text})
+	case CLIEventText:
+		if cbs.OnMessage != nil {
+			cbs.OnMessage(agenttypes.NewTextMessage(agenttypes.RoleAssistant, evt.Text))
+		}
+		b.send(AgentStreamEvent{Type: AgentEventMessageEnd, Message: evt.Text})
+	case CLIEventThinking:
+		b.send(AgentStreamEvent{Type: AgentEventThinking, Message: evt.Text})
+	case CLIEventToolCall:
+		if cbs.OnToolCall != nil {
+			cbs.OnToolCall(evt.ToolName, evt.ToolInput)
+		}
+		b.send(AgentStreamEvent{
+			Type:       AgentEventToolCall,
+			ToolName:   evt.ToolName,
+			ToolCallID: evt.ToolCallID,
+			ToolInput:  evt.ToolInput,
+		})
+	case CLIEventToolResult:
+		if cbs.OnToolResult != nil {
+			cbs.OnToolResult(evt.ToolName, tools.ToolResult{Content: evt.Text, IsError: evt.IsError})
+		}
+		b.send(AgentStreamEvent{
+			Type:       AgentEventToolResult,
+			ToolName:   evt.ToolName,
+			ToolCallID: evt.ToolCallID,
+			Message:    evt.Text,
+			IsError:    evt.IsError,
+		})
+	}
+}
+
+// track updates the loop snapshot and tool call records. It returns the
+// iteration number when evt starts a new assistant turn, or 0.
+func (b *cliEventBridge) track(evt CLIEvent) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	iteration := 0
+	switch evt.Type {
+	case CLIEventText, CLIEventThinking, CLIEventToolCall:
+		if !b.inTurn {
+			b.inTurn = true
+			b.snapshot.Iteration++
+			b.snapshot.MessageCount++
+			iteration = b.snapshot.Iteration
+		}
+	}
+
+	switch evt.Type {
+	case CLIEventToolCall:
+		b.snapshot.ToolCallCount++
+		b.snapshot.LastStopReason = agenttypes.StopReasonToolUse
+		if b.open == nil {
+			b.open = make(map[string]openCLIToolCall)
+		}
+		b.open[evt.ToolCallID] = openCLIToolCall{index: len(b.toolCalls), start: time.Now()}
+		b.toolCalls = append(b.toolCalls, ToolCallRecord{Name: evt.ToolName, Input: evt.ToolInput})
+	case CLIEventToolResult:
+		if b.inTurn {
+			b.inTurn = false
+			b.snapshot.MessageCount++
+		}
+		if call, ok := b.open[evt.ToolCallID]; ok {
+			delete(b.open, evt.ToolCallID)
+			rec := &b.toolCalls[call.index]
+			rec.Output = evt.Text
+			rec.IsError = evt.IsError
+			rec.Duration = time.Since(call.start)
+		}
+	case CLIEventResult:
+		b.inTurn = false
+		b.snapshot.LastStopReason = agenttypes.StopReasonEndTurn
+	}
+	return iteration
+}
+
+// inputs adapts a steering or follow-up fetcher to the CLI request.
+func (b *cliEventBridge) inputs(fetch LoopInputFetcher, onApplied func([]agenttypes.Message), evtType AgentEventType) func(context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		b.mu.Lock()
+		snapshot := b.snapshot
+		b.mu.Unlock()
+
+		messages, err := fetch(ctx, snapshot)
+		if err != nil || len(messages) == 0 {
+			return nil, err
+		}
+		texts := make([]string, 0, len(messages))
+		for _, msg := range messages {
+			texts = append(texts, msg.GetText())
+		}
+
+		b.mu.Lock()
+		b.snapshot.MessageCount += len(messages)
+		b.mu.Unlock()
+		if onApplied != nil {
+			onApplied(messages)
+		}
+		b.send(AgentStreamEvent{Type: evtType})
+		return texts, nil
+	}
+}
+
+func (b *cliEventBridge) records() []ToolCallRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]ToolCallRecord(nil), b.toolCalls...)
+}
+
+func (b *cliEventBridge) send(evt AgentStreamEvent) {
+	if b.emit != nil {
+		b.emit(evt)
+	}
+}
@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// fakeStreamJSONCLI answers one task with a tool call that needs
+// permission, waits for a steering message, and finishes the turn.
+const fakeStreamJSONCLI = `#!/bin/sh
+case "$*" in *"--input-format stream-json"*"--permission-prompt-tool stdio"*) ;; *) echo "bad args: $*" >&2; exit 2;; esac
+read -r task
+case "$task" in *'"content":"list files"'*) ;; *) echo "bad task: $task" >&2; exit 2;; esac
+printf '%s\n' '{"type":"system","subtype":"init","session_id":"sess-1"}'
+printf '%s\n' '{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"Look"}}}'
+printf '%s\n' '{"type":"assistant","message":{"content":[{"type":"text","text":"Looking."},{"type":"tool_use","id":"tu-1","name":"Bash","input":{"command":"ls"}}]}}'
+printf '%s\n' '{"type":"control_request","request_id":"req-1","request":{"subtype":"can_use_tool","tool_name":"Bash","tool_use_id":"tu-1","input":{"command":"ls"}}}'
+read -r answer
+case "$answer" in *'"behavior":"allow","updatedInput":{"command":"ls -a"}'*) out="allowed";; *) out="denied";; esac
+printf '{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"tu-1","content":[{"type":"text","text":"%s"}]}]}}\n' "$out"
+read -r steer
+case "$steer" in *"focus on tests"*) reply="steered";; *) reply="unsteered";; esac
+printf '{"type":"assistant","message":{"content":[{"type":"text","text":"%s"}]}}\n' "$reply"
+printf '%s\n' '{"type":"result","subtype":"success","is_error":false,"result":"done","session_id":"sess-1","num_turns":2,"usage":{"input_tokens":10,"output_tokens":5}}'
+while read -r line; do :; done
+`
+
+func newFakeStreamJSONAgent(t *testing.T) *CLIAgent {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	if err := os.WriteFile(path, []byte(fakeStreamJSONCLI), 0o755); err != nil {
+		t.Fatalf("write fake cli: %v", err)
+	}
+	cfg := CLIAgentConfig{
+		Command:  path,
+		Timeout:  10 * time.Second,
+		Protocol: CLIProtocolStreamJSON,
+		PermissionHandler: func(_ context.Context, req CLIPermissionRequest) (CLIPermissionDecision, error) {
+			if req.ToolName != "Bash" || req.Input["command"] != "ls" {
+				return CLIPermissionDecision{Message: "unexpected request"}, nil
+			}
+			return CLIPermissionDecision{Allow: true, UpdatedInput: map[string]any{"command": "ls -a"}}, nil
+		},
+	}
+	return NewCLIAgent(NewClaudeCodeClient(cfg), cfg)
+}
+
+func steerOnce(text string) LoopInputFetcher {
+	sent := false
+	return func(context.Context, LoopInputSnapshot) ([]agenttypes.Message, error) {
+		if sent {
+			return nil, nil
+		}
+		sent = true
+		return []agenttypes.Message{agenttypes.NewTextMessage(agenttypes.RoleUser, text)}, nil
+	}
+}
+
+func TestCLIAgentStreamJSONExecute(t *testing.T) {
+	a := newFakeStreamJSONAgent(t)
+
+	var toolResults []string
+	result, err := a.Execute(context.Background(), AgentRequest{
+		Task:    "list files",
+		WorkDir: t.TempDir(),
+		Options: AgentOptions{GetSteeringMessages: steerOnce("focus on tests")},
+		Callbacks: AgentCallbacks{
+			OnToolResult: func(name string, r tools.ToolResult) {
+				toolResults = append(toolResults, name+":"+r.Content)
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !result.Success || result.Message != "done" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Usage.TotalIterations != 2 || result.Usage.TotalInputTokens != 10 || result.Usage.TotalOutputTokens != 5 {
+		t.Fatalf("unexpected usage: %+v", result.Usage)
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].Name != "Bash" || result.ToolCalls[0].Output != "allowed" {
+		t.Fatalf("unexpected tool calls: %+v", result.ToolCalls)
+	}
+	if strings.Join(toolResults, ",") != "Bash:allowed" {
+		t.Fatalf("unexpected tool result callbacks: %v", toolResults)
+	}
+}
+
+func TestCLIAgentStreamJSONExecuteStream(t *testing.T) {
+	a := newFakeStreamJSONAgent(t)
+
+	eventCh, errCh := a.ExecuteStream(context.Background(), AgentRequest{
+		Task:    "list files",
+		WorkDir: t.TempDir(),
+		Options: AgentOptions{GetSteeringMessages: steerOnce("focus on tests")},
+	})
+
+	var got []string
+	var end AgentStreamEvent
+	for evt := range eventCh {
+		switch evt.Type {
+		case AgentEventMessageDelta:
+			got = append(got, "delta:"+evt.Delta)
+		case AgentEventMessageEnd:
+			got = append(got, "message:"+evt.Message)
+		case AgentEventToolCall:
+			got = append(got, "tool_call:"+evt.ToolName)
+		case AgentEventToolResult:
+			got = append(got, "tool_result:"+evt.Message)
+		default:
+			got = append(got, string(evt.Type))
+		}
+		if evt.Type == AgentEventAgentEnd {
+			end = evt
+		}
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+
+	want := []string{
+		"agent_start",
+		"delta:Look",
+		"message:Looking.",
+		"tool_call:Bash",
+		"tool_result:allowed",
+		"steering_applied",
+		"message:steered",
+		"agent_end",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected events:\n got: %v\nwant: %v", got, want)
+	}
+	if end.Message != "done" || end.Usage == nil || end.Usage.TotalOutputTokens != 5 {
+		t.Fatalf("unexpected agent_end event: %+v", end)
+	}
+}
@@ -0,0 +1,337 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+// IterationChange classifies an IterationDiff.
+type IterationChange string
+
+const (
+	// IterationSame means both runs made the same tool calls with the same
+	// inputs and results and replied with the same text.
+	IterationSame IterationChange = "same"
+	// IterationChanged means the iteration differs between the runs.
+	IterationChanged IterationChange = "changed"
+	// IterationOnlyA and IterationOnlyB mean only one run got this far.
+	IterationOnlyA IterationChange = "only_a"
+	IterationOnlyB IterationChange = "only_b"
+)
+
+// RunDiff is a structured comparison of two executions, typically of the
+// same task with a different prompt or model. Build it with CompareResults
+// or CompareTranscripts; marshal it as JSON for tooling or print Summary.
+type RunDiff struct {
+	// Iterations has one entry per model iteration of the longer run.
+	Iterations []IterationDiff
+
+	// FirstDivergence is the first iteration that is not IterationSame, or
+	// 0 when the runs match.
+	FirstDivergence int
+
+	// ToolCounts compares how often each tool was called, sorted by name.
+	// Tools called equally often in both runs are included.
+	ToolCounts []ToolCountDiff
+
+	// Tokens compares token usage. It is zero for CompareTranscripts.
+	Tokens TokenDiff
+
+	// Models are the models of the final responses, when known.
+	ModelA string
+	ModelB string
+
+	// FinalTextA and FinalTextB are the runs' last assistant replies.
+	FinalTextA string
+	FinalTextB string
+}
+
+// Identical reports whether every iteration matched.
+func (d RunDiff) Identical() bool {
+	return d.FirstDivergence == 0
+}
+
+// IterationDiff compares one model iteration of the two runs.
+type IterationDiff struct {
+	Iteration int
+	Change    IterationChange
+
+	// A and B are the iteration in each run; nil when that run stopped
+	// earlier.
+	A *IterationSnapshot
+	B *IterationSnapshot
+
+	// TextChanged and ToolCallsChanged tell which part of a changed
+	// iteration differs.
+	TextChanged      bool
+	ToolCallsChanged bool
+}
+
+// IterationSnapshot is what the model did in one iteration.
+type IterationSnapshot struct {
+	// Text is the assistant text of the iteration.
+	Text      string
+	ToolCalls []ToolCallSnapshot
+
+	// InputTokens and OutputTokens come from the execution profile; they
+	// are zero when the run was not profiled.
+	InputTokens  int
+	OutputTokens int
+}
+
+// ToolCallSnapshot is one tool call within an IterationSnapshot.
+type ToolCallSnapshot struct {
+	Name    string
+	Input   map[string]any
+	IsError bool
+}
+
+// ToolCountDiff compares how often one tool was called.
+type ToolCountDiff struct {
+	Name string
+	A    int
+	B    int
+}
+
+// TokenDiff compares the token usage of two runs.
+type TokenDiff struct {
+	InputA, InputB             int
+	OutputA, OutputB           int
+	CachedInputA, CachedInputB int
+}
+
+// InputDelta is run B's input tokens minus run A's.
+func (t TokenDiff) InputDelta() int { return t.InputB - t.InputA }
+
+// OutputDelta is run B's output tokens minus run A's.
+func (t TokenDiff) OutputDelta() int { return t.OutputB - t.OutputA }
+
+// CompareResults compares two executions from their conversations
+// (RawOutput) and token usage. Per-iteration token counts are filled in for
+// a run that was profiled (AgentOptions.Profile).
+func CompareResults(a, b AgentResult) RunDiff {
+	diff := compareSnapshots(iterationSnapshots(a.RawOutput, a.Profile), iterationSnapshots(b.RawOutput, b.Profile))
+	diff.Tokens = TokenDiff{
+		InputA:       a.Usage.TotalInputTokens,
+		InputB:       b.Usage.TotalInputTokens,
+		OutputA:      a.Usage.TotalOutputTokens,
+		OutputB:      b.Usage.TotalOutputTokens,
+		CachedInputA: a.Usage.TotalCachedInputTokens,
+		CachedInputB: b.Usage.TotalCachedInputTokens,
+	}
+	diff.ModelA = a.Metadata.Model
+	diff.ModelB = b.Metadata.Model
+	return diff
+}
+
+// CompareTranscripts compares two conversations, such as exported
+// AgentResult.RawOutput or stored chat sessions.
+func CompareTranscripts(a, b []agenttypes.Message) RunDiff {
+	return compareSnapshots(iterationSnapshots(a, nil), iterationSnapshots(b, nil))
+}
+
+// iterationSnapshots splits a conversation into model iterations: each
+// assistant message starts one, and tool results mark its calls as failed.
+func iterationSnapshots(messages []agenttypes.Message, profile *ExecutionProfile) []IterationSnapshot {
+	failed := make(map[string]bool)
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type == agenttypes.ContentTypeToolResult && block.IsError {
+				failed[block.ToolUseID] = true
+			}
+		}
+	}
+
+	var out []IterationSnapshot
+	for _, msg := range messages {
+		if msg.Role != agenttypes.RoleAssistant {
+			continue
+		}
+		snap := IterationSnapshot{Text: strings.TrimSpace(msg.GetText())}
+		for _, block := range msg.Content {
+			if block.Type == agenttypes.ContentTypeToolUse {
+				snap.ToolCalls = append(snap.ToolCalls, ToolCallSnapshot{
+					Name:    block.Name,
+					Input:   block.Input,
+					IsError: failed[block.ID],
+				})
+			}
+		}
+		out = append(out, snap)
+	}
+
+	if profile != nil {
+		for _, it := range profile.Iterations {
+			if i := it.Iteration - 1; i >= 0 && i < len(out) {
+				out[i].InputTokens = it.InputTokens
+				out[i].OutputTokens = it.OutputTokens
+			}
+		}
+	}
+	return out
+}
+
+func compareSnapshots(a, b []IterationSnapshot) RunDiff {
+	var diff RunDiff
+	n := max(len(a), len(b))
+	for i := range n {
+		d := IterationDiff{Iteration: i + 1}
+		switch {
+		case i >= len(b):
+			d.A, d.Change = &a[i], IterationOnlyA
+		case i >= len(a):
+			d.B, d.Change = &b[i], IterationOnlyB
+		default:
+			d.A, d.B = &a[i], &b[i]
+			d.TextChanged = a[i].Text != b[i].Text
+			d.ToolCallsChanged = !sameToolCalls(a[i].ToolCalls, b[i].ToolCalls)
+			d.Change = IterationSame
+			if d.TextChanged || d.ToolCallsChanged {
+				d.Change = IterationChanged
+			}
+		}
+		if d.Change != IterationSame && diff.FirstDivergence == 0 {
+			diff.FirstDivergence = d.Iteration
+		}
+		diff.Iterations = append(diff.Iterations, d)
+	}
+
+	diff.ToolCounts = compareToolCounts(a, b)
+	diff.FinalTextA = finalText(a)
+	diff.FinalTextB = finalText(b)
+	return diff
+}
+
+func sameToolCalls(a, b []ToolCallSnapshot) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].IsError != b[i].IsError || !sameInput(a[i].Input, b[i].Input) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameInput compares tool inputs by their JSON encoding, so that a live
+// result and one loaded from a file compare equal despite number types.
+func sameInput(a, b map[string]any) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(ja) == string(jb)
+}
+
+func compareToolCounts(a, b []IterationSnapshot) []ToolCountDiff {
+	counts := make(map[string]*ToolCountDiff)
+	var names []string
+	count := func(snaps []IterationSnapshot, inA bool) {
+		for _, snap := range snaps {
+			for _, call := range snap.ToolCalls {
+				c, ok := counts[call.Name]
+				if !ok {
+					c = &ToolCountDiff{Name: call.Name}
+					counts[call.Name] = c
+					names = append(names, call.Name)
+				}
+				if inA {
+					c.A++
+				} else {
+					c.B++
+				}
+			}
+		}
+	}
+	count(a, true)
+	count(b, false)
+
+	slices.Sort(names)
+	out := make([]ToolCountDiff, len(names))
+	for i, name := range names {
+		out[i] = *counts[name]
+	}
+	return out
+}
+
+func finalText(snaps []IterationSnapshot) string {
+	for i := len(snaps) - 1; i >= 0; i-- {
+		if snaps[i].Text != "" {
+			return snaps[i].Text
+		}
+	}
+	return ""
+}
+
+// Summary renders the diff as a short human-readable report: the first
+// divergence, one row per iteration, tool call counts that differ, and
+// token deltas.
+func (d RunDiff) Summary() string {
+	var b strings.Builder
+	if d.Identical() {
+		fmt.Fprintf(&b, "runs match over %d iteration(s)\n", len(d.Iterations))
+	} else {
+		fmt.Fprintf(&b, "runs diverge at iteration %d\n", d.FirstDivergence)
+	}
+	if d.ModelA != "" || d.ModelB != "" {
+		fmt.Fprintf(&b, "models: %s vs %s\n", d.ModelA, d.ModelB)
+	}
+
+	for _, it := range d.Iterations {
+		fmt.Fprintf(&b, "%4d  %-8s  %s | %s\n", it.Iteration, it.Change,
+			describeSnapshot(it.A), describeSnapshot(it.B))
+	}
+
+	var counts []string
+	for _, c := range d.ToolCounts {
+		if c.A != c.B {
+			counts = append(counts, fmt.Sprintf("%s %d -> %d", c.Name, c.A, c.B))
+		}
+	}
+	if len(counts) > 0 {
+		fmt.Fprintf(&b, "tool calls: %s\n", strings.Join(counts, ", "))
+	}
+
+	t := d.Tokens
+	if t != (TokenDiff{}) {
+		fmt.Fprintf(&b, "tokens: input %d -> %d (%+d), output %d -> %d (%+d), cached %d -> %d\n",
+			t.InputA, t.InputB, t.InputDelta(), t.OutputA, t.OutputB, t.OutputDelta(),
+			t.CachedInputA, t.CachedInputB)
+	}
+	if d.FinalTextA != d.FinalTextB {
+		b.WriteString("final replies differ\n")
+	}
+	return b.String()
+}
+
+// describeSnapshot renders an iteration's tool calls, marking failed ones,
+// or "reply" for a text-only iteration.
+func describeSnapshot(s *IterationSnapshot) string {
+	if s == nil {
+		return "-"
+	}
+	if len(s.ToolCalls) == 0 {
+		if s.Text == "" {
+			return "(empty)"
+		}
+		return "reply"
+	}
+	names := make([]string, len(s.ToolCalls))
+	for i, call := range s.ToolCalls {
+		names[i] = call.Name
+		if call.IsError {
+			names[i] += "!"
+		}
+	}
+	return strings.Join(names, ", ")
+}
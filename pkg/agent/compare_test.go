@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+func toolCallMessage(id, name string, input map[string]any) agenttypes.Message {
+	return agenttypes.Message{
+		Role:    agenttypes.RoleAssistant,
+		Content: []agenttypes.ContentBlock{{Type: agenttypes.ContentTypeToolUse, ID: id, Name: name, Input: input}},
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	task := agenttypes.NewTextMessage(agenttypes.RoleUser, "fix the test")
+	a := AgentResult{
+		RawOutput: []agenttypes.Message{
+			task,
+			toolCallMessage("1", "read_file", map[string]any{"path": "a.go"}),
+			agenttypes.NewToolResultMessage("1", "package a", false),
+			toolCallMessage("2", "bash", map[string]any{"command": "go test"}),
+			agenttypes.NewToolResultMessage("2", "FAIL", true),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "The test still fails."),
+		},
+		Usage:    ExecutionUsage{TotalInputTokens: 1200, TotalOutputTokens: 90},
+		Metadata: ResultMetadata{Model: "model-a"},
+	}
+	b := AgentResult{
+		RawOutput: []agenttypes.Message{
+			task,
+			toolCallMessage("1", "read_file", map[string]any{"path": "a.go"}),
+			agenttypes.NewToolResultMessage("1", "package a", false),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "Fixed."),
+		},
+		Usage:    ExecutionUsage{TotalInputTokens: 700, TotalOutputTokens: 40},
+		Metadata: ResultMetadata{Model: "model-b"},
+		Profile:  &ExecutionProfile{Iterations: []IterationProfile{{Iteration: 1, InputTokens: 300, OutputTokens: 20}}},
+	}
+
+	diff := CompareResults(a, b)
+	if diff.Identical() || diff.FirstDivergence != 2 || len(diff.Iterations) != 3 {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	if it := diff.Iterations[0]; it.Change != IterationSame || it.B.InputTokens != 300 || it.A.InputTokens != 0 {
+		t.Fatalf("unexpected first iteration: %+v", it)
+	}
+	if it := diff.Iterations[1]; it.Change != IterationChanged || !it.ToolCallsChanged || !it.TextChanged || !it.A.ToolCalls[0].IsError {
+		t.Fatalf("unexpected second iteration: %+v", it)
+	}
+	if it := diff.Iterations[2]; it.Change != IterationOnlyA || it.B != nil {
+		t.Fatalf("unexpected third iteration: %+v", it)
+	}
+	if diff.Tokens.InputDelta() != -500 || diff.Tokens.OutputDelta() != -50 {
+		t.Fatalf("unexpected token diff: %+v", diff.Tokens)
+	}
+	if diff.FinalTextA != "The test still fails." || diff.FinalTextB != "Fixed." {
+		t.Fatalf("unexpected final texts: %q, %q", diff.FinalTextA, diff.FinalTextB)
+	}
+
+	summary := diff.Summary()
+	for _, want := range []string{
+		"runs diverge at iteration 2\n",
+		"models: model-a vs model-b\n",
+		"   2  changed   bash! | reply\n",
+		"tool calls: bash 1 -> 0\n",
+		"tokens: input 1200 -> 700 (-500), output 90 -> 40 (-50), cached 0 -> 0\n",
+		"final replies differ\n",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected summary to contain %q, got:\n%s", want, summary)
+		}
+	}
+}
+
+func TestCompareTranscriptsFromJSON(t *testing.T) {
+	messages := []agenttypes.Message{
+		agenttypes.NewTextMessage(agenttypes.RoleUser, "count"),
+		toolCallMessage("1", "count", map[string]any{"n": 3}),
+		agenttypes.NewToolResultMessage("1", "3", false),
+		agenttypes.NewTextMessage(agenttypes.RoleAssistant, "Done."),
+	}
+	data, err := json.Marshal(messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported []agenttypes.Message
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatal(err)
+	}
+
+	diff := CompareTranscripts(messages, exported)
+	if !diff.Identical() || len(diff.Iterations) != 2 || diff.Tokens != (TokenDiff{}) {
+		t.Fatalf("expected identical transcripts, got %+v", diff)
+	}
+	if !strings.HasPrefix(diff.Summary(), "runs match over 2 iteration(s)\n") {
+		t.Fatalf("unexpected summary:\n%s", diff.Summary())
+	}
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"sync"
+)
+
+// ConcurrentResult is the outcome of one request run by ExecuteConcurrently.
+type ConcurrentResult struct {
+	Result AgentResult
+	Err    error
+}
+
+// ExecuteConcurrently runs every request on the same agent, at most
+// maxParallel at a time (zero or negative runs them all at once), and
+// returns the outcomes in request order. Requests that have not started
+// when ctx is canceled fail with ctx.Err().
+//
+// It is the pattern a server uses to share one APIAgent between
+// simultaneous HTTP requests: each execution gets its own conversation and
+// tool context, so requests only need their own WorkDir when their tools
+// must not see each other's files.
+func ExecuteConcurrently(ctx context.Context, agent Agent, reqs []AgentRequest, maxParallel int) []ConcurrentResult {
+	results := make([]ConcurrentResult, len(reqs))
+	if maxParallel <= 0 || maxParallel > len(reqs) {
+		maxParallel = len(reqs)
+	}
+	sem := make(chan struct{}, maxParallel)
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Result, results[i].Err = agent.Execute(ctx, req)
+		}()
+	}
+	wg.Wait()
+	return results
+}
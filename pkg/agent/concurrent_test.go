@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// apiAgentEchoProvider is stateless so one instance can serve concurrent
+// executions. It calls "echo_request" with the same tool_use ID every time,
+// then answers with the tool's output.
+type apiAgentEchoProvider struct{}
+
+func (apiAgentEchoProvider) Name() string {
+	return "echo"
+}
+
+func (apiAgentEchoProvider) Call(_ context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	last := req.Messages[len(req.Messages)-1]
+	for _, block := range last.Content {
+		if block.Type == llm.ContentTypeToolResult {
+			return llm.AgentResponse{
+				Role:       llm.RoleAssistant,
+				StopReason: llm.StopReasonEndTurn,
+				Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: block.Content}},
+			}, nil
+		}
+	}
+	return llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		StopReason: llm.StopReasonToolUse,
+		Content: []llm.ContentBlock{{
+			Type:  llm.ContentTypeToolUse,
+			ID:    "call_1",
+			Name:  "echo_request",
+			Input: map[string]any{},
+		}},
+	}, nil
+}
+
+type apiAgentEchoRequestTool struct{}
+
+func (apiAgentEchoRequestTool) Name() string {
+	return "echo_request"
+}
+
+func (apiAgentEchoRequestTool) Description() string {
+	return "echoes the REQUEST env var"
+}
+
+func (apiAgentEchoRequestTool) InputSchema() map[string]any {
+	return map[string]any{"type": "object"}
+}
+
+func (apiAgentEchoRequestTool) Execute(_ context.Context, toolCtx *tools.ToolContext, _ map[string]any) (tools.ToolResult, error) {
+	return tools.NewToolResult(toolCtx.Env["REQUEST"]), nil
+}
+
+func TestExecuteConcurrentlyKeepsExecutionsIsolated(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(apiAgentEchoRequestTool{})
+	a := NewAPIAgent(apiAgentEchoProvider{}, registry, APIAgentOptions{MaxMessages: 50})
+
+	reqs := make([]AgentRequest, 32)
+	for i := range reqs {
+		reqs[i] = AgentRequest{
+			Task:    "echo",
+			WorkDir: t.TempDir(),
+			Env:     map[string]string{"REQUEST": fmt.Sprintf("request-%d", i)},
+		}
+	}
+
+	results := ExecuteConcurrently(context.Background(), a, reqs, 8)
+	if len(results) != len(reqs) {
+		t.Fatalf("expected %d results, got %d", len(reqs), len(results))
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("request %d failed: %v", i, r.Err)
+		}
+		if want := fmt.Sprintf("request-%d", i); r.Result.Message != want {
+			t.Fatalf("request %d: expected %q, got %q", i, want, r.Result.Message)
+		}
+		// Tool_use IDs are tracked per execution, so no run sees another
+		// run's call_1 as a duplicate.
+		if id := r.Result.RawOutput[1].Content[0].ID; id != "call_1" {
+			t.Fatalf("request %d: expected tool_use ID call_1, got %q", i, id)
+		}
+	}
+}
+
+func TestExecuteConcurrentlyFailsUnstartedRequestsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a := NewAPIAgent(apiAgentEchoProvider{}, tools.NewRegistry(), APIAgentOptions{})
+	results := ExecuteConcurrently(ctx, a, []AgentRequest{{Task: "a"}, {Task: "b"}}, 1)
+	for i, r := range results {
+		if r.Err == nil {
+			t.Fatalf("request %d: expected an error after cancellation", i)
+		}
+	}
+}
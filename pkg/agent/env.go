@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// SecretProvider resolves secret environment variables for a single run.
+// Implementations may fetch short-lived credentials (e.g. a scoped
+// GITHUB_TOKEN) from a vault or token service.
+type SecretProvider interface {
+	Secrets(ctx context.Context) (map[string]string, error)
+}
+
+// StaticSecrets is a SecretProvider backed by a fixed map.
+type StaticSecrets map[string]string
+
+// Secrets returns the map itself.
+func (s StaticSecrets) Secrets(context.Context) (map[string]string, error) {
+	return s, nil
+}
+
+// githubTokenEnv also populates ToolContext.GitHubToken when supplied.
+const githubTokenEnv = "GITHUB_TOKEN"
+
+// requestEnv is the resolved environment of a run.
+type requestEnv struct {
+	env     map[string]string // plain env and secrets merged
+	secrets map[string]string
+}
+
+// resolveRequestEnv merges req.Env with the values from req.Secrets.
+// Secrets take precedence over plain env with the same key.
+func resolveRequestEnv(ctx context.Context, req AgentRequest) (requestEnv, error) {
+	resolved := requestEnv{env: make(map[string]string, len(req.Env))}
+	for k, v := range req.Env {
+		resolved.env[k] = v
+	}
+	if req.Secrets == nil {
+		return resolved, nil
+	}
+	secrets, err := req.Secrets.Secrets(ctx)
+	if err != nil {
+		return resolved, fmt.Errorf("resolve secrets: %w", err)
+	}
+	resolved.secrets = secrets
+	for k, v := range secrets {
+		resolved.env[k] = v
+	}
+	return resolved, nil
+}
+
+// apply copies the environment into toolCtx.
+func (e requestEnv) apply(toolCtx *tools.ToolContext) {
+	for k, v := range e.env {
+		toolCtx.WithEnv(k, v)
+	}
+	if token := e.env[githubTokenEnv]; token != "" {
+		toolCtx.GitHubToken = token
+	}
+}
+
+// mask registers every secret value with r, creating a redactor if needed,
+// and returns the redactor to use plus a function that unregisters them.
+func (e requestEnv) mask(r *redact.Redactor) (*redact.Redactor, func()) {
+	if len(e.secrets) == 0 {
+		return r, func() {}
+	}
+	if r == nil {
+		r = redact.New(redact.Config{DisableDefaults: true})
+	}
+	removers := make([]func(), 0, len(e.secrets))
+	for name, value := range e.secrets {
+		removers = append(removers, r.AddSecrets(name, value))
+	}
+	return r, func() {
+		for _, remove := range removers {
+			remove()
+		}
+	}
+}
+
+// maskResult redacts the text fields of result in place.
+func maskResult(r *redact.Redactor, result *AgentResult) {
+	if r == nil {
+		return
+	}
+	result.Summary = r.Redact(result.Summary)
+	result.Message = r.Redact(result.Message)
+	for i := range result.ToolCalls {
+		result.ToolCalls[i].Input = r.RedactMap(result.ToolCalls[i].Input)
+		result.ToolCalls[i].Output = r.Redact(result.ToolCalls[i].Output)
+	}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// apiAgentEnvTool is named "noop" so apiAgentLoopProvider invokes it. It
+// echoes the tool environment it was given.
+type apiAgentEnvTool struct {
+	seen *tools.ToolContext
+}
+
+func (apiAgentEnvTool) Name() string {
+	return "noop"
+}
+
+func (apiAgentEnvTool) Description() string {
+	return "echoes env"
+}
+
+func (apiAgentEnvTool) InputSchema() map[string]any {
+	return map[string]any{"type": "object"}
+}
+
+func (t apiAgentEnvTool) Execute(_ context.Context, toolCtx *tools.ToolContext, _ map[string]any) (tools.ToolResult, error) {
+	*t.seen = *toolCtx
+	return tools.NewToolResult("region=" + toolCtx.Env["REGION"] + " token=" + toolCtx.Env["GITHUB_TOKEN"]), nil
+}
+
+type failingSecrets struct{}
+
+func (failingSecrets) Secrets(context.Context) (map[string]string, error) {
+	return nil, errors.New("vault unavailable")
+}
+
+func TestAPIAgentInjectsRequestEnvAndMasksSecrets(t *testing.T) {
+	var seen tools.ToolContext
+	registry := tools.NewRegistry()
+	registry.MustRegister(apiAgentEnvTool{seen: &seen})
+	redactor := redact.New(redact.Config{DisableDefaults: true})
+	a := NewAPIAgent(&apiAgentLoopProvider{toolIterations: 1}, registry, APIAgentOptions{Redactor: redactor})
+
+	result, err := a.Execute(context.Background(), AgentRequest{
+		Task:    "run",
+		WorkDir: t.TempDir(),
+		Env:     map[string]string{"REGION": "eu-west-1"},
+		Secrets: StaticSecrets{"GITHUB_TOKEN": "scoped-token-123"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if seen.Env["REGION"] != "eu-west-1" || seen.Env["GITHUB_TOKEN"] != "scoped-token-123" {
+		t.Fatalf("expected request env in tool context, got %v", seen.Env)
+	}
+	if seen.GitHubToken != "scoped-token-123" {
+		t.Fatalf("expected GitHubToken from GITHUB_TOKEN secret, got %q", seen.GitHubToken)
+	}
+	if len(result.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(result.ToolCalls))
+	}
+	output := result.ToolCalls[0].Output
+	if strings.Contains(output, "scoped-token-123") || !strings.Contains(output, "[REDACTED:GITHUB_TOKEN]") {
+		t.Fatalf("expected secret to be masked, got %q", output)
+	}
+	if !strings.Contains(output, "region=eu-west-1") {
+		t.Fatalf("expected plain env to be left alone, got %q", output)
+	}
+	if got := redactor.Redact("scoped-token-123"); got != "scoped-token-123" {
+		t.Fatalf("expected run secrets to be unregistered after execution, got %q", got)
+	}
+}
+
+func TestAPIAgentFailsWhenSecretsCannotBeResolved(t *testing.T) {
+	a := NewAPIAgent(&apiAgentLoopProvider{}, tools.NewRegistry(), APIAgentOptions{})
+	result, err := a.Execute(context.Background(), AgentRequest{Task: "run", Secrets: failingSecrets{}})
+	if err == nil || !strings.Contains(err.Error(), "vault unavailable") {
+		t.Fatalf("expected secret resolution error, got %v", err)
+	}
+	if result.Success {
+		t.Fatal("expected unsuccessful result")
+	}
+}
@@ -3,19 +3,28 @@ package agent
 import (
 	"fmt"
 	"log"
+	"net/http"
 	"os/exec"
 	"time"
 
 	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/guard"
+	"github.com/MimeLyc/agent-core-go/pkg/instructions"
+	"github.com/MimeLyc/agent-core-go/pkg/llmprovider"
+	"github.com/MimeLyc/agent-core-go/pkg/models"
+	"github.com/MimeLyc/agent-core-go/pkg/plugins"
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
 )
 
 // ProviderType identifies supported LLM provider backends for API agents.
 type ProviderType string
 
 const (
-	ProviderTypeClaude ProviderType = "claude"
-	ProviderTypeOpenAI ProviderType = "openai"
+	ProviderTypeClaude     ProviderType = "claude"
+	ProviderTypeOpenAI     ProviderType = "openai"
+	ProviderTypeOpenRouter ProviderType = "openrouter"
 )
 
 // AgentType identifies the type of agent to create.
@@ -49,14 +58,31 @@ type AgentConfig struct {
 
 	// Registry is the tool registry (used by APIAgent).
 	Registry *tools.Registry
+
+	// Plugins bundle extra tools, skills, slash commands, and hooks for
+	// this agent (APIAgent only). The shared Registry is not modified.
+	Plugins []plugins.Plugin
+
+	// Locale switches the built-in instructional text (skills block,
+	// system prompt section headers, compaction summaries) to another
+	// language, e.g. "zh" or "es" (APIAgent only). Empty uses English.
+	// Register more languages with locale.Register.
+	Locale string
 }
 
 // APIConfig contains configuration for the API-based agent.
 type APIConfig struct {
-	// ProviderType specifies which LLM provider to use ("claude", "openai").
-	// Must be set explicitly by the caller.
+	// ProviderType specifies which LLM provider to use ("claude", "openai",
+	// "openrouter"). Must be set explicitly by the caller unless Provider
+	// is set.
 	ProviderType ProviderType
 
+	// Provider is a custom LLM backend, e.g. an in-house gateway (see
+	// package llmprovider). When set it is used as-is: ProviderType,
+	// BaseURL, APIKey, and the other connection settings are ignored, and
+	// Model is optional and only selects registry defaults.
+	Provider llmprovider.Provider
+
 	// BaseURL is the LLM API base URL.
 	BaseURL string
 
@@ -69,9 +95,77 @@ type APIConfig struct {
 	// MaxTokens limits response token count.
 	MaxTokens int
 
+	// ThinkingBudgetTokens enables Claude extended thinking with this token
+	// budget (minimum 1024). Zero disables it. Ignored by other providers.
+	ThinkingBudgetTokens int
+
+	// PromptCaching enables Claude prompt caching of the system prompt's
+	// stable part: the base prompt, SOUL file, and repository instructions.
+	// Ignored by other providers.
+	PromptCaching bool
+
+	// Temperature and Seed set the default sampling parameters. Seed is
+	// only honored by OpenAI-compatible providers.
+	Temperature *float64
+	Seed        *int64
+
+	// Deterministic enables deterministic mode for every execution
+	// (see AgentOptions.Deterministic).
+	Deterministic bool
+
+	// ToolChoice sets the default tool choice (see AgentOptions.ToolChoice),
+	// e.g. DisableParallelToolUse for backends that mishandle parallel calls.
+	ToolChoice *ToolChoice
+
+	// ServerTools are provider-native tools such as WebSearchTool. Only
+	// the Claude provider supports them.
+	ServerTools []ServerTool
+
 	// Timeout is the API request timeout.
 	Timeout time.Duration
 
+	// HTTP tunes connection pooling, HTTP/2, proxy, and TLS for API calls.
+	HTTP *HTTPConfig
+
+	// ExtraHeaders are added to every API request, e.g. HTTP-Referer and
+	// X-Title for OpenRouter or a tenant ID for a gateway. They override the
+	// provider's own headers of the same name.
+	ExtraHeaders map[string]string
+
+	// RequestMutator, if set, can modify every API request just before it
+	// is sent, e.g. to sign it for a proxy.
+	RequestMutator func(*http.Request)
+
+	// ExtraBody is merged into the JSON body of every API request, for
+	// fields the provider does not model, e.g. "top_k" or vendor
+	// extensions. It overrides the provider's own fields of the same name;
+	// a nil value removes a field. AgentOptions.ProviderParams apply on top.
+	ExtraBody map[string]any
+
+	// OpenRouter sets model fallbacks, provider routing, and transforms
+	// when ProviderType is "openrouter".
+	OpenRouter *OpenRouterConfig
+
+	// ResponseValidation controls malformed responses from OpenAI-compatible
+	// backends. Lenient (the default) repairs them and lists the repairs in
+	// ResultMetadata; strict fails the execution, e.g. in CI.
+	ResponseValidation ResponseValidation
+
+	// MaxRequestMessages caps the messages per provider request, for APIs
+	// or gateways that reject longer histories. Consecutive same-role
+	// messages are merged first, then the oldest are dropped. Zero is
+	// unlimited. Custom providers declare their limit by implementing
+	// llmprovider.MessageLimiter.
+	MaxRequestMessages int
+
+	// InstructionRoles selects how messages with RoleSystem or
+	// RoleDeveloper are sent. Native (the default) keeps the OpenAI system
+	// and developer roles and moves leading instructions into Claude's
+	// system prompt; system sends developer messages as system messages,
+	// for backends without the developer role; user sends both as marked
+	// user messages. OpenRouter defaults to system.
+	InstructionRoles InstructionRoles
+
 	// MaxAttempts is the maximum API retry count.
 	MaxAttempts int
 
@@ -87,8 +181,95 @@ type APIConfig struct {
 	// CompactConfig configures context compaction.
 	CompactConfig *CompactConfig
 
+	// ToolCache memoizes repeated read-only tool calls within an execution.
+	ToolCache *ToolCacheConfig
+
+	// ToolRetry retries tool calls that fail with a retryable error.
+	// Nil hands every failure straight to the model.
+	ToolRetry *ToolRetryConfig
+
+	// JSONCompression shortens large JSON tool outputs for the model.
+	// Nil leaves them as they are.
+	JSONCompression *JSONCompressionConfig
+
+	// LoopDetection nudges or aborts executions that repeat the same tool
+	// calls. Nil disables it.
+	LoopDetection *LoopDetectionConfig
+
+	// Prune selects context pruning strategies. Nil disables pruning.
+	Prune *PruneConfig
+
+	// WatchWorkDir watches the workdir for external changes during
+	// executions. Nil disables watching.
+	WatchWorkDir *workspace.WatchConfig
+
+	// MessageSpill moves old messages to disk during long executions.
+	MessageSpill *MessageSpillConfig
+
+	// ContextSections add or replace system prompt sections.
+	ContextSections []ContextSection
+
+	// ContextTransforms insert transform stages around the default context
+	// rules for every execution.
+	ContextTransforms []ContextTransform
+
+	// MaxSystemPromptBytes caps the assembled system prompt (0 = no limit).
+	MaxSystemPromptBytes int
+
+	// InstructionMerge is the default merge strategy for nested instruction files.
+	InstructionMerge instructions.MergeStrategy
+
 	// EnableStreaming turns on stream-capable execution paths.
 	EnableStreaming bool
+
+	// RateLimitNotes tells the model when a response was delayed by
+	// provider rate limiting.
+	RateLimitNotes bool
+
+	// TrackFileReads refuses overwrites of files an execution has not read
+	// or that changed since it read them.
+	TrackFileReads bool
+
+	// SkillTools also offers each model-invocable skill as a skill__<name>
+	// tool (SkillToolsAdditional), or instead of the skills prompt block
+	// (SkillToolsOnly).
+	SkillTools SkillToolsMode
+
+	// SkillEnvVars names the AgentRequest.Env entries that skill and
+	// command bodies may reference as ${env:NAME}, e.g. "DEPLOY_TARGET".
+	// Entries not listed, such as secrets, are never substituted.
+	SkillEnvVars []string
+
+	// ToolPairRepair selects how a context window whose tool_results lost
+	// their tool_use is repaired: ToolPairRepairDrop (default) removes
+	// them, ToolPairRepairStub adds placeholder tool_uses, and
+	// ToolPairRepairFullHistory sends the whole history instead.
+	ToolPairRepair ToolPairRepair
+
+	// Profile records a timing and token profile for every execution.
+	Profile bool
+
+	// MaxContextTokens is the model context window; enables pre-flight compaction.
+	// Zero uses the window from the models registry when Model is known;
+	// negative disables pre-flight checks.
+	MaxContextTokens int
+
+	// ContextMargin is the fraction of MaxContextTokens kept free (default 0.1).
+	ContextMargin float64
+
+	// Redactor masks secrets in tool results before they enter the context.
+	Redactor *redact.Redactor
+
+	// OutputGuard moderates each assistant message before it is committed
+	// to the conversation and before its tool calls run (see package guard).
+	OutputGuard guard.OutputGuard
+
+	// OutcomeClassifier sets AgentResult.Outcome for every execution.
+	OutcomeClassifier OutcomeClassifier
+
+	// UsageReporter receives the usage of every execution, e.g. a
+	// WebhookUsageReporter for billing.
+	UsageReporter UsageReporter
 }
 
 // NewAgent creates a new agent based on the configuration.
@@ -111,18 +292,81 @@ func newAPIAgentFromConfig(cfg AgentConfig) (*APIAgent, error) {
 		return nil, fmt.Errorf("API configuration is required for api agent type")
 	}
 
-	apiCfg := cfg.API
-	if apiCfg.BaseURL == "" {
-		return nil, fmt.Errorf("API base URL is required")
+	apiCfg := *cfg.API
+	provider := apiCfg.Provider
+	if provider == nil {
+		if apiCfg.BaseURL == "" {
+			return nil, fmt.Errorf("API base URL is required")
+		}
+		if apiCfg.APIKey == "" {
+			return nil, fmt.Errorf("API key is required")
+		}
+		if apiCfg.Model == "" {
+			return nil, fmt.Errorf("API model is required")
+		}
 	}
-	if apiCfg.APIKey == "" {
-		return nil, fmt.Errorf("API key is required")
+
+	applyModelDefaults(&apiCfg)
+
+	if provider == nil {
+		var err error
+		if provider, err = newLLMProvider(apiCfg); err != nil {
+			return nil, err
+		}
 	}
-	if apiCfg.Model == "" {
-		return nil, fmt.Errorf("API model is required")
+
+	registry := cfg.Registry
+	if registry == nil {
+		registry = tools.NewRegistry()
+	}
+	if _, _, err := installPlugins(registry, cfg.Plugins); err != nil {
+		return nil, fmt.Errorf("failed to install plugins: %w", err)
+	}
+
+	opts := APIAgentOptions{
+		MaxIterations:        apiCfg.MaxIterations,
+		MaxMessages:          apiCfg.MaxMessages,
+		MaxTokens:            apiCfg.MaxTokens,
+		MaxContextTokens:     apiCfg.MaxContextTokens,
+		ContextMargin:        apiCfg.ContextMargin,
+		SystemPrompt:         apiCfg.SystemPrompt,
+		CompactConfig:        apiCfg.CompactConfig,
+		ToolCache:            apiCfg.ToolCache,
+		ToolRetry:            apiCfg.ToolRetry,
+		JSONCompression:      apiCfg.JSONCompression,
+		LoopDetection:        apiCfg.LoopDetection,
+		Prune:                apiCfg.Prune,
+		WatchWorkDir:         apiCfg.WatchWorkDir,
+		MessageSpill:         apiCfg.MessageSpill,
+		EnableStreaming:      apiCfg.EnableStreaming,
+		RateLimitNotes:       apiCfg.RateLimitNotes,
+		TrackFileReads:       apiCfg.TrackFileReads,
+		SkillTools:           apiCfg.SkillTools,
+		SkillEnvVars:         apiCfg.SkillEnvVars,
+		ToolPairRepair:       apiCfg.ToolPairRepair,
+		Profile:              apiCfg.Profile,
+		Redactor:             apiCfg.Redactor,
+		OutputGuard:          apiCfg.OutputGuard,
+		OutcomeClassifier:    apiCfg.OutcomeClassifier,
+		UsageReporter:        apiCfg.UsageReporter,
+		Plugins:              cfg.Plugins,
+		ContextSections:      apiCfg.ContextSections,
+		ContextTransforms:    apiCfg.ContextTransforms,
+		MaxSystemPromptBytes: apiCfg.MaxSystemPromptBytes,
+		InstructionMerge:     apiCfg.InstructionMerge,
+		Temperature:          apiCfg.Temperature,
+		Seed:                 apiCfg.Seed,
+		Deterministic:        apiCfg.Deterministic,
+		ToolChoice:           apiCfg.ToolChoice,
+		ServerTools:          apiCfg.ServerTools,
+		Locale:               cfg.Locale,
 	}
 
-	// Create LLM provider based on configured type
+	return NewAPIAgent(provider, registry, opts), nil
+}
+
+// newLLMProvider creates the built-in provider selected by apiCfg.
+func newLLMProvider(apiCfg APIConfig) (llm.LLMProvider, error) {
 	providerCfg := llm.LLMProviderConfig{
 		Type:           llm.LLMProviderType(apiCfg.ProviderType),
 		BaseURL:        apiCfg.BaseURL,
@@ -131,28 +375,42 @@ func newAPIAgentFromConfig(cfg AgentConfig) (*APIAgent, error) {
 		MaxTokens:      apiCfg.MaxTokens,
 		TimeoutSeconds: int(apiCfg.Timeout.Seconds()),
 		MaxAttempts:    apiCfg.MaxAttempts,
+
+		ThinkingBudgetTokens: apiCfg.ThinkingBudgetTokens,
+		PromptCaching:        apiCfg.PromptCaching,
+		HTTP:                 toLLMHTTPConfig(apiCfg.HTTP),
+		ExtraHeaders:         apiCfg.ExtraHeaders,
+		RequestMutator:       apiCfg.RequestMutator,
+		ExtraBody:            apiCfg.ExtraBody,
+		OpenRouter:           toLLMOpenRouterOptions(apiCfg.OpenRouter),
+		ResponseValidation:   apiCfg.ResponseValidation,
+		MaxRequestMessages:   apiCfg.MaxRequestMessages,
+		InstructionRoles:     apiCfg.InstructionRoles,
 	}
 
 	provider, err := llm.NewLLMProvider(providerCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create LLM provider: %w", err)
 	}
+	return provider, nil
+}
 
-	registry := cfg.Registry
-	if registry == nil {
-		registry = tools.NewRegistry()
+// applyModelDefaults fills limits the caller left unset from the models
+// registry, and caps MaxTokens at the model's output limit.
+func applyModelDefaults(apiCfg *APIConfig) {
+	model, ok := models.Lookup(apiCfg.Model)
+	if !ok {
+		return
 	}
-
-	opts := APIAgentOptions{
-		MaxIterations:   apiCfg.MaxIterations,
-		MaxMessages:     apiCfg.MaxMessages,
-		MaxTokens:       apiCfg.MaxTokens,
-		SystemPrompt:    apiCfg.SystemPrompt,
-		CompactConfig:   apiCfg.CompactConfig,
-		EnableStreaming: apiCfg.EnableStreaming,
+	if apiCfg.MaxContextTokens == 0 && model.ContextWindow > 0 {
+		apiCfg.MaxContextTokens = model.ContextWindow
+		log.Printf("[agent-factory] using context window %d for model %s", model.ContextWindow, apiCfg.Model)
+	}
+	if model.MaxOutputTokens > 0 && apiCfg.MaxTokens > model.MaxOutputTokens {
+		log.Printf("[agent-factory] capping max tokens %d to %d for model %s",
+			apiCfg.MaxTokens, model.MaxOutputTokens, apiCfg.Model)
+		apiCfg.MaxTokens = model.MaxOutputTokens
 	}
-
-	return NewAPIAgent(provider, registry, opts), nil
 }
 
 // newCLIAgentFromConfig creates a CLIAgent from configuration.
@@ -161,6 +419,10 @@ func newCLIAgentFromConfig(cfg AgentConfig) (*CLIAgent, error) {
 		return nil, fmt.Errorf("CLI configuration is required for cli agent type")
 	}
 
+	if len(cfg.Plugins) > 0 {
+		return nil, fmt.Errorf("plugins are not supported for cli agent type")
+	}
+
 	cliCfg := cfg.CLI
 	if cliCfg.Command == "" {
 		return nil, fmt.Errorf("CLI command is required")
@@ -168,6 +430,16 @@ func newCLIAgentFromConfig(cfg AgentConfig) (*CLIAgent, error) {
 	if cliCfg.Timeout <= 0 {
 		cliCfg.Timeout = 30 * time.Minute
 	}
+	switch cliCfg.Protocol {
+	case "", CLIProtocolJSON, CLIProtocolStreamJSON:
+	default:
+		return nil, fmt.Errorf("unsupported CLI protocol: %s", cliCfg.Protocol)
+	}
+	switch cliCfg.PermissionMode {
+	case "", CLIPermissionModeDefault, CLIPermissionModeAcceptEdits, CLIPermissionModePlan, CLIPermissionModeBypassPermissions:
+	default:
+		return nil, fmt.Errorf("unsupported CLI permission mode: %s", cliCfg.PermissionMode)
+	}
 
 	// Verify CLI command exists
 	if _, err := exec.LookPath(cliCfg.Command); err != nil {
@@ -183,7 +455,7 @@ func autoDetectAgent(cfg AgentConfig) (Agent, error) {
 	log.Printf("[agent-factory] auto-detecting agent type")
 
 	// First, try API agent if configured
-	if cfg.API != nil && cfg.API.BaseURL != "" && cfg.API.APIKey != "" {
+	if cfg.API != nil && (cfg.API.Provider != nil || (cfg.API.BaseURL != "" && cfg.API.APIKey != "")) {
 		log.Printf("[agent-factory] API configuration found, using api agent")
 		return newAPIAgentFromConfig(cfg)
 	}
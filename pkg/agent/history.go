@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"fmt"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+// ValidateHistory checks that history can seed an execution: every
+// tool_use has a non-empty, unique ID and is answered by a tool_result
+// before the next assistant message, and every tool_result answers a
+// tool_use of the preceding assistant message. Providers reject
+// conversations that break these rules, so they are checked up front.
+func ValidateHistory(history []agenttypes.Message) error {
+	seen := make(map[string]bool)
+	pending := make(map[string]int) // tool_use ID -> message index
+	for i, msg := range history {
+		if msg.Role == agenttypes.RoleAssistant {
+			if err := unansweredToolUse(pending); err != nil {
+				return err
+			}
+			for _, block := range msg.Content {
+				if block.Type != agenttypes.ContentTypeToolUse {
+					continue
+				}
+				if block.ID == "" {
+					return fmt.Errorf("message %d: tool_use %q has no ID", i, block.Name)
+				}
+				if seen[block.ID] {
+					return fmt.Errorf("message %d: duplicate tool_use ID %s", i, block.ID)
+				}
+				seen[block.ID] = true
+				pending[block.ID] = i
+			}
+			continue
+		}
+		for _, block := range msg.Content {
+			if block.Type != agenttypes.ContentTypeToolResult {
+				continue
+			}
+			if block.ToolUseID == "" {
+				return fmt.Errorf("message %d: tool_result has no tool_use_id", i)
+			}
+			if _, ok := pending[block.ToolUseID]; !ok {
+				return fmt.Errorf("message %d: tool_result for %s does not answer a tool_use in the preceding assistant message", i, block.ToolUseID)
+			}
+			delete(pending, block.ToolUseID)
+		}
+	}
+	return unansweredToolUse(pending)
+}
+
+// unansweredToolUse reports the earliest tool_use still in pending.
+func unansweredToolUse(pending map[string]int) error {
+	id, idx := "", -1
+	for pendingID, i := range pending {
+		if idx == -1 || i < idx || (i == idx && pendingID < id) {
+			id, idx = pendingID, i
+		}
+	}
+	if idx == -1 {
+		return nil
+	}
+	return fmt.Errorf("message %d: tool_use %s has no tool_result", idx, id)
+}
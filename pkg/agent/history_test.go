@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func toolUseMessage(ids ...string) agenttypes.Message {
+	msg := agenttypes.Message{Role: agenttypes.RoleAssistant}
+	for _, id := range ids {
+		msg.Content = append(msg.Content, agenttypes.ContentBlock{Type: agenttypes.ContentTypeToolUse, ID: id, Name: "bash"})
+	}
+	return msg
+}
+
+func TestValidateHistory(t *testing.T) {
+	user := agenttypes.NewTextMessage(agenttypes.RoleUser, "list files")
+	reply := agenttypes.NewTextMessage(agenttypes.RoleAssistant, "done")
+
+	tests := []struct {
+		name    string
+		history []agenttypes.Message
+		wantErr string
+	}{
+		{"empty", nil, ""},
+		{"text only", []agenttypes.Message{user, reply}, ""},
+		{"answered tool uses", []agenttypes.Message{
+			user, toolUseMessage("t1", "t2"),
+			agenttypes.NewToolResultMessage("t1", "a", false),
+			agenttypes.NewToolResultMessage("t2", "b", true),
+			reply,
+		}, ""},
+		{"unanswered tool use at end", []agenttypes.Message{user, toolUseMessage("t1")}, "tool_use t1 has no tool_result"},
+		{"unanswered tool use before assistant", []agenttypes.Message{
+			user, toolUseMessage("t1", "t2"), agenttypes.NewToolResultMessage("t2", "b", false), reply,
+		}, "tool_use t1 has no tool_result"},
+		{"orphaned tool result", []agenttypes.Message{user, agenttypes.NewToolResultMessage("t1", "a", false)}, "tool_result for t1"},
+		{"result for earlier turn", []agenttypes.Message{
+			user, toolUseMessage("t1"), agenttypes.NewToolResultMessage("t1", "a", false),
+			reply, agenttypes.NewToolResultMessage("t1", "a", false),
+		}, "tool_result for t1"},
+		{"empty tool use ID", []agenttypes.Message{user, toolUseMessage("")}, "has no ID"},
+		{"duplicate tool use ID", []agenttypes.Message{
+			user, toolUseMessage("t1"), agenttypes.NewToolResultMessage("t1", "a", false), toolUseMessage("t1"),
+		}, "duplicate tool_use ID t1"},
+		{"empty tool result ID", []agenttypes.Message{
+			user, toolUseMessage("t1"), agenttypes.NewToolResultMessage("", "a", false),
+		}, "tool_result has no tool_use_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHistory(tt.history)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateHistory() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateHistory() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIAgentExecutePrependsHistory(t *testing.T) {
+	provider := &apiAgentPipelineProvider{}
+	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{})
+
+	_, err := a.Execute(context.Background(), AgentRequest{
+		Task: "and now?",
+		History: []agenttypes.Message{
+			agenttypes.NewTextMessage(agenttypes.RoleUser, "list files"),
+			toolUseMessage("t1"),
+			agenttypes.NewToolResultMessage("t1", "a.go", false),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "a.go"),
+		},
+		Options: AgentOptions{DisableDefaultContextRules: true},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	messages := provider.lastReq.Messages
+	if len(messages) != 5 {
+		t.Fatalf("provider message count = %d, want 5", len(messages))
+	}
+	if got := messages[0].GetText(); got != "list files" {
+		t.Fatalf("first message = %q, want history first", got)
+	}
+	if messages[1].Content[0].Type != llm.ContentTypeToolUse || messages[2].Content[0].ToolUseID != "t1" {
+		t.Fatalf("tool pair not preserved: %+v %+v", messages[1], messages[2])
+	}
+	if got := messages[4].GetText(); got != "and now?" || messages[4].Role != llm.RoleUser {
+		t.Fatalf("last message = %s %q, want the task", messages[4].Role, got)
+	}
+}
+
+func TestAPIAgentExecuteRejectsInvalidHistory(t *testing.T) {
+	provider := &apiAgentPipelineProvider{}
+	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{})
+
+	result, err := a.Execute(context.Background(), AgentRequest{
+		Task:    "continue",
+		History: []agenttypes.Message{toolUseMessage("t1")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid history") {
+		t.Fatalf("Execute() error = %v, want invalid history", err)
+	}
+	if result.Success {
+		t.Fatal("expected unsuccessful result")
+	}
+	if provider.lastReq.Messages != nil {
+		t.Fatal("expected the provider not to be called")
+	}
+}
@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+// ErrInputQueueFull is returned by LoopInputQueue.Push when MaxPending
+// messages are queued and none has a lower priority than the new one.
+var ErrInputQueueFull = errors.New("loop input queue is full")
+
+// LoopInputKind selects when a queued message is delivered.
+type LoopInputKind int
+
+const (
+	// LoopInputSteering messages are delivered at the next loop checkpoint,
+	// including between tool calls, and steer the next model turn.
+	LoopInputSteering LoopInputKind = iota
+
+	// LoopInputFollowUp messages are delivered after steering, once the
+	// current turn's tool calls have finished.
+	LoopInputFollowUp
+)
+
+// LoopInputPriority orders messages of the same kind. Higher priorities are
+// delivered first; equal priorities keep push order.
+type LoopInputPriority int
+
+const (
+	LoopInputPriorityLow    LoopInputPriority = -1
+	LoopInputPriorityNormal LoopInputPriority = 0
+	LoopInputPriorityHigh   LoopInputPriority = 1
+)
+
+// LoopInputQueueConfig configures a LoopInputQueue.
+type LoopInputQueueConfig struct {
+	// MaxPending caps the number of queued messages across both kinds.
+	// When full, a push evicts the oldest lowest-priority message if it has a
+	// lower priority than the new one and fails with ErrInputQueueFull
+	// otherwise. Zero means unlimited.
+	MaxPending int
+
+	// DefaultTTL expires messages that were not delivered in time. Zero
+	// means messages never expire unless LoopInputPush.TTL is set.
+	DefaultTTL time.Duration
+}
+
+// LoopInputPush describes how a message is queued.
+type LoopInputPush struct {
+	Kind     LoopInputKind
+	Priority LoopInputPriority
+
+	// Key deduplicates pending messages: pushing with the key of a message
+	// that has not been delivered yet replaces it, and the replacement takes
+	// the new message's place in the queue.
+	Key string
+
+	// TTL overrides LoopInputQueueConfig.DefaultTTL for this message.
+	TTL time.Duration
+}
+
+// LoopInputQueue is a push-based source of steering and follow-up messages.
+// Callers push from any goroutine; set it as AgentOptions.InputQueue and the
+// agent drains it at the same checkpoints as GetSteeringMessages and
+// GetFollowUpMessages. Each message is delivered at most once.
+type LoopInputQueue struct {
+	mu      sync.Mutex
+	config  LoopInputQueueConfig
+	pending []queuedInput
+	now     func() time.Time
+}
+
+type queuedInput struct {
+	msg      agenttypes.Message
+	kind     LoopInputKind
+	priority LoopInputPriority
+	key      string
+	expires  time.Time
+}
+
+// NewLoopInputQueue creates an empty queue.
+func NewLoopInputQueue(cfg LoopInputQueueConfig) *LoopInputQueue {
+	return &LoopInputQueue{config: cfg, now: time.Now}
+}
+
+// Push queues msg. A message without a role is sent as a user message;
+// messages without content are ignored.
+func (q *LoopInputQueue) Push(msg agenttypes.Message, opts LoopInputPush) error {
+	if len(msg.Content) == 0 {
+		return nil
+	}
+	if msg.Role == "" {
+		msg.Role = agenttypes.RoleUser
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	q.expireLocked(now)
+
+	item := queuedInput{msg: msg, kind: opts.Kind, priority: opts.Priority, key: opts.Key}
+	ttl := opts.TTL
+	if ttl == 0 {
+		ttl = q.config.DefaultTTL
+	}
+	if ttl > 0 {
+		item.expires = now.Add(ttl)
+	}
+
+	if item.key != "" {
+		for i, p := range q.pending {
+			if p.key == item.key {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if q.config.MaxPending > 0 && len(q.pending) >= q.config.MaxPending {
+		victim := -1
+		for i, p := range q.pending {
+			if victim < 0 || p.priority < q.pending[victim].priority {
+				victim = i
+			}
+		}
+		if q.pending[victim].priority >= item.priority {
+			return ErrInputQueueFull
+		}
+		q.pending = append(q.pending[:victim], q.pending[victim+1:]...)
+	}
+
+	q.pending = append(q.pending, item)
+	return nil
+}
+
+// PushText queues a plain text message from the user.
+func (q *LoopInputQueue) PushText(text string, opts LoopInputPush) error {
+	if text == "" {
+		return nil
+	}
+	return q.Push(agenttypes.NewTextMessage(agenttypes.RoleUser, text), opts)
+}
+
+// Len returns the number of pending, unexpired messages of both kinds.
+func (q *LoopInputQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.expireLocked(q.now())
+	return len(q.pending)
+}
+
+// Steering returns a fetcher that drains pending steering messages.
+func (q *LoopInputQueue) Steering() LoopInputFetcher {
+	return q.fetcher(LoopInputSteering)
+}
+
+// FollowUp returns a fetcher that drains pending follow-up messages.
+func (q *LoopInputQueue) FollowUp() LoopInputFetcher {
+	return q.fetcher(LoopInputFollowUp)
+}
+
+func (q *LoopInputQueue) fetcher(kind LoopInputKind) LoopInputFetcher {
+	return func(context.Context, LoopInputSnapshot) ([]agenttypes.Message, error) {
+		return q.drain(kind), nil
+	}
+}
+
+// drain removes and returns the pending messages of kind by descending
+// priority, then push order.
+func (q *LoopInputQueue) drain(kind LoopInputKind) []agenttypes.Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.expireLocked(q.now())
+
+	var taken []queuedInput
+	kept := q.pending[:0]
+	for _, p := range q.pending {
+		if p.kind == kind {
+			taken = append(taken, p)
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	q.pending = kept
+	if len(taken) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(taken, func(i, j int) bool {
+		return taken[i].priority > taken[j].priority
+	})
+	out := make([]agenttypes.Message, len(taken))
+	for i, p := range taken {
+		out[i] = p.msg
+	}
+	return out
+}
+
+func (q *LoopInputQueue) expireLocked(now time.Time) {
+	kept := q.pending[:0]
+	for _, p := range q.pending {
+		if p.expires.IsZero() || now.Before(p.expires) {
+			kept = append(kept, p)
+		}
+	}
+	q.pending = kept
+}
+
+// loopInputFetchers returns the steering and follow-up fetchers for an
+// execution. Explicit fetchers take precedence over InputQueue.
+func (o AgentOptions) loopInputFetchers() (steering, followUp LoopInputFetcher) {
+	steering, followUp = o.GetSteeringMessages, o.GetFollowUpMessages
+	if o.InputQueue != nil {
+		if steering == nil {
+			steering = o.InputQueue.Steering()
+		}
+		if followUp == nil {
+			followUp = o.InputQueue.FollowUp()
+		}
+	}
+	return steering, followUp
+}
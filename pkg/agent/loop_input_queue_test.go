@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func drainTexts(t *testing.T, fetch LoopInputFetcher) []string {
+	t.Helper()
+	msgs, err := fetch(context.Background(), LoopInputSnapshot{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, m := range msgs {
+		out = append(out, m.GetText())
+	}
+	return out
+}
+
+func TestLoopInputQueueOrdersByPriorityAndKind(t *testing.T) {
+	q := NewLoopInputQueue(LoopInputQueueConfig{})
+	q.PushText("low", LoopInputPush{Priority: LoopInputPriorityLow})
+	q.PushText("normal 1", LoopInputPush{})
+	q.PushText("later", LoopInputPush{Kind: LoopInputFollowUp})
+	q.PushText("high", LoopInputPush{Priority: LoopInputPriorityHigh})
+	q.PushText("normal 2", LoopInputPush{})
+	q.PushText("", LoopInputPush{})
+
+	if got := drainTexts(t, q.Steering()); len(got) != 4 || got[0] != "high" || got[1] != "normal 1" || got[2] != "normal 2" || got[3] != "low" {
+		t.Fatalf("unexpected steering order: %v", got)
+	}
+	if got := drainTexts(t, q.Steering()); len(got) != 0 {
+		t.Fatalf("expected steering to be drained, got %v", got)
+	}
+	if got := drainTexts(t, q.FollowUp()); len(got) != 1 || got[0] != "later" {
+		t.Fatalf("unexpected follow-up: %v", got)
+	}
+}
+
+func TestLoopInputQueueDedupAndExpiry(t *testing.T) {
+	now := time.Now()
+	q := NewLoopInputQueue(LoopInputQueueConfig{DefaultTTL: time.Minute})
+	q.now = func() time.Time { return now }
+
+	q.PushText("focus on a", LoopInputPush{Key: "focus"})
+	q.PushText("stale", LoopInputPush{TTL: time.Second})
+	q.PushText("focus on b", LoopInputPush{Key: "focus"})
+	if q.Len() != 2 {
+		t.Fatalf("expected the keyed push to replace the pending one, Len = %d", q.Len())
+	}
+
+	now = now.Add(2 * time.Second)
+	if got := drainTexts(t, q.Steering()); len(got) != 1 || got[0] != "focus on b" {
+		t.Fatalf("unexpected steering after expiry: %v", got)
+	}
+
+	q.PushText("too late", LoopInputPush{})
+	now = now.Add(2 * time.Minute)
+	if q.Len() != 0 {
+		t.Fatalf("expected default TTL to expire the message, Len = %d", q.Len())
+	}
+}
+
+func TestLoopInputQueueMaxPending(t *testing.T) {
+	q := NewLoopInputQueue(LoopInputQueueConfig{MaxPending: 2})
+	q.PushText("low", LoopInputPush{Priority: LoopInputPriorityLow})
+	q.PushText("normal", LoopInputPush{})
+
+	if err := q.PushText("another low", LoopInputPush{Priority: LoopInputPriorityLow}); !errors.Is(err, ErrInputQueueFull) {
+		t.Fatalf("expected ErrInputQueueFull, got %v", err)
+	}
+	if err := q.PushText("high", LoopInputPush{Priority: LoopInputPriorityHigh}); err != nil {
+		t.Fatalf("expected high priority push to evict, got %v", err)
+	}
+	if got := drainTexts(t, q.Steering()); len(got) != 2 || got[0] != "high" || got[1] != "normal" {
+		t.Fatalf("unexpected steering: %v", got)
+	}
+}
+
+func TestAPIAgentDrainsInputQueue(t *testing.T) {
+	provider := &apiAgentSequentialEndTurnProvider{}
+	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{})
+
+	q := NewLoopInputQueue(LoopInputQueueConfig{})
+	q.PushText("steer", LoopInputPush{})
+	q.PushText("follow", LoopInputPush{Kind: LoopInputFollowUp})
+
+	var applied []string
+	_, err := a.Execute(context.Background(), AgentRequest{
+		Task:    "queued input",
+		Options: AgentOptions{InputQueue: q},
+		Callbacks: AgentCallbacks{
+			OnSteeringApplied: func(messages []agenttypes.Message) {
+				for _, m := range messages {
+					applied = append(applied, m.GetText())
+				}
+			},
+			OnFollowUpApplied: func(messages []agenttypes.Message) {
+				for _, m := range messages {
+					applied = append(applied, m.GetText())
+				}
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(applied) != 2 || applied[0] != "steer" || applied[1] != "follow" {
+		t.Fatalf("unexpected applied inputs: %v", applied)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("expected the queue to be drained, Len = %d", q.Len())
+	}
+}
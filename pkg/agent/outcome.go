@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Outcome is a machine-readable classification of how an execution ended,
+// for workflows that branch on it.
+type Outcome string
+
+const (
+	// OutcomeProceed means the task was handled and the workflow can continue.
+	OutcomeProceed Outcome = "proceed"
+	// OutcomeNeedsInfo means the agent needs more information from a human.
+	OutcomeNeedsInfo Outcome = "needs_info"
+	// OutcomeStop means the workflow should not continue.
+	OutcomeStop Outcome = "stop"
+)
+
+// OutcomeClassification is an OutcomeClassifier's verdict.
+type OutcomeClassification struct {
+	Outcome Outcome
+
+	// Reason explains the outcome, e.g. the question to ask for
+	// OutcomeNeedsInfo.
+	Reason string
+}
+
+// OutcomeClassifier derives an Outcome from a finished execution, usually
+// from its final message. Set one with AgentOptions.OutcomeClassifier or
+// APIConfig.OutcomeClassifier; the result is reported in
+// AgentResult.Outcome and AgentResult.OutcomeReason.
+type OutcomeClassifier interface {
+	Classify(ctx context.Context, result AgentResult) (OutcomeClassification, error)
+}
+
+// OutcomeClassifierFunc adapts a function to OutcomeClassifier.
+type OutcomeClassifierFunc func(ctx context.Context, result AgentResult) (OutcomeClassification, error)
+
+// Classify calls f.
+func (f OutcomeClassifierFunc) Classify(ctx context.Context, result AgentResult) (OutcomeClassification, error) {
+	return f(ctx, result)
+}
+
+// OutcomeSchema describes where a JSON object in the final message keeps
+// its outcome, e.g. {"decision": "needs_info", "reason": "Which region?"}.
+type OutcomeSchema struct {
+	// Field is the key holding the outcome (default "decision").
+	Field string
+
+	// ReasonField is the key holding the reason (default "reason").
+	ReasonField string
+
+	// Values maps field values (compared case-insensitively) to outcomes,
+	// e.g. {"approve": OutcomeProceed, "reject": OutcomeStop}. When empty,
+	// the names of the Outcome constants map to themselves.
+	Values map[string]Outcome
+
+	// Default is used when the message has no object with Field or its
+	// value is not in Values. Empty makes that an error.
+	Default Outcome
+}
+
+// DefaultOutcomeSchema returns a schema with a "decision" field holding
+// proceed, needs_info, or stop, an optional "reason", and OutcomeProceed
+// when the message carries no decision.
+func DefaultOutcomeSchema() OutcomeSchema {
+	return OutcomeSchema{Field: "decision", ReasonField: "reason", Default: OutcomeProceed}
+}
+
+// NewJSONOutcomeClassifier returns a classifier that reads the outcome from
+// the first JSON object in the final message that has schema.Field.
+func NewJSONOutcomeClassifier(schema OutcomeSchema) OutcomeClassifier {
+	if schema.Field == "" {
+		schema.Field = "decision"
+	}
+	if schema.ReasonField == "" {
+		schema.ReasonField = "reason"
+	}
+	values := make(map[string]Outcome, len(schema.Values))
+	for raw, outcome := range schema.Values {
+		values[strings.ToLower(raw)] = outcome
+	}
+	if len(values) == 0 {
+		for _, outcome := range []Outcome{OutcomeProceed, OutcomeNeedsInfo, OutcomeStop} {
+			values[string(outcome)] = outcome
+		}
+	}
+	schema.Values = values
+	return jsonOutcomeClassifier{schema: schema}
+}
+
+type jsonOutcomeClassifier struct {
+	schema OutcomeSchema
+}
+
+func (c jsonOutcomeClassifier) Classify(_ context.Context, result AgentResult) (OutcomeClassification, error) {
+	obj, ok := findJSONObjectWith(result.Message, c.schema.Field)
+	if !ok {
+		return c.fallback(fmt.Errorf("no JSON object with %q in the final message", c.schema.Field))
+	}
+	raw, _ := obj[c.schema.Field].(string)
+	outcome, ok := c.schema.Values[strings.ToLower(strings.TrimSpace(raw))]
+	if !ok {
+		return c.fallback(fmt.Errorf("unknown %s %q", c.schema.Field, raw))
+	}
+	reason, _ := obj[c.schema.ReasonField].(string)
+	return OutcomeClassification{Outcome: outcome, Reason: reason}, nil
+}
+
+func (c jsonOutcomeClassifier) fallback(err error) (OutcomeClassification, error) {
+	if c.schema.Default == "" {
+		return OutcomeClassification{}, err
+	}
+	return OutcomeClassification{Outcome: c.schema.Default}, nil
+}
+
+// findJSONObjectWith returns the first JSON object in text, including one
+// inside a fenced code block, that has key.
+func findJSONObjectWith(text, key string) (map[string]any, bool) {
+	data := []byte(text)
+	for idx := bytes.IndexByte(data, '{'); idx != -1; {
+		var obj map[string]any
+		if err := json.NewDecoder(bytes.NewReader(data[idx:])).Decode(&obj); err == nil {
+			if _, ok := obj[key]; ok {
+				return obj, true
+			}
+		}
+		next := bytes.IndexByte(data[idx+1:], '{')
+		if next == -1 {
+			break
+		}
+		idx += next + 1
+	}
+	return nil, false
+}
+
+// classifyOutcome sets result's outcome with classifier. Failures are
+// logged and leave the outcome empty; they do not fail the execution.
+func classifyOutcome(ctx context.Context, classifier OutcomeClassifier, result *AgentResult) {
+	if classifier == nil {
+		return
+	}
+	classification, err := classifier.Classify(ctx, *result)
+	if err != nil {
+		log.Printf("[agent] WARNING: outcome classification failed: %v", err)
+		return
+	}
+	result.Outcome = classification.Outcome
+	result.OutcomeReason = classification.Reason
+}
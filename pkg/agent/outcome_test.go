@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+func TestJSONOutcomeClassifier(t *testing.T) {
+	ctx := context.Background()
+	classifier := NewJSONOutcomeClassifier(DefaultOutcomeSchema())
+
+	got, err := classifier.Classify(ctx, AgentResult{Message: "I need details.\n```json\n{\"decision\": \"NEEDS_INFO\", \"reason\": \"Which region?\"}\n```"})
+	if err != nil || got.Outcome != OutcomeNeedsInfo || got.Reason != "Which region?" {
+		t.Fatalf("unexpected classification %+v, %v", got, err)
+	}
+
+	got, err = classifier.Classify(ctx, AgentResult{Message: "All done."})
+	if err != nil || got.Outcome != OutcomeProceed {
+		t.Fatalf("expected the default outcome, got %+v, %v", got, err)
+	}
+
+	custom := NewJSONOutcomeClassifier(OutcomeSchema{
+		Field:  "verdict",
+		Values: map[string]Outcome{"approve": OutcomeProceed, "reject": OutcomeStop},
+	})
+	got, err = custom.Classify(ctx, AgentResult{Message: `{"other": 1} then {"verdict": "reject", "reason": "tests fail"}`})
+	if err != nil || got.Outcome != OutcomeStop || got.Reason != "tests fail" {
+		t.Fatalf("unexpected custom classification %+v, %v", got, err)
+	}
+	if _, err := custom.Classify(ctx, AgentResult{Message: `{"verdict": "maybe"}`}); err == nil {
+		t.Fatal("expected an error for an unknown value without a default")
+	}
+}
+
+// outcomeStubAgent returns a fixed result.
+type outcomeStubAgent struct {
+	Agent
+	result AgentResult
+}
+
+func (a outcomeStubAgent) Execute(context.Context, AgentRequest) (AgentResult, error) {
+	return a.result, nil
+}
+
+func TestRunnerAdapterClassifiesLegacyDecision(t *testing.T) {
+	adapter := NewRunnerAdapter(outcomeStubAgent{result: AgentResult{
+		Success: true,
+		Message: `{"decision": "needs_info", "needs_info_comment": "Which branch?"}`,
+	}}, "")
+	runResult, err := adapter.Run(context.Background(), llm.Request{Prompt: "fix it"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runResult.Response.Decision != llm.DecisionNeedsInfo || runResult.Response.NeedsInfoComment != "Which branch?" {
+		t.Fatalf("unexpected response %+v", runResult.Response)
+	}
+
+	adapter.Agent = outcomeStubAgent{result: AgentResult{Success: true, Message: "done", Outcome: OutcomeStop}}
+	runResult, err = adapter.Run(context.Background(), llm.Request{Prompt: "fix it"}, t.TempDir())
+	if err != nil || runResult.Response.Decision != llm.DecisionStop {
+		t.Fatalf("expected the agent's outcome to win, got %+v, %v", runResult.Response, err)
+	}
+}
+
+func TestAPIAgentExecuteClassifiesOutcome(t *testing.T) {
+	a := NewAPIAgent(apiAgentTestProvider{}, nil, APIAgentOptions{
+		OutcomeClassifier: OutcomeClassifierFunc(func(_ context.Context, result AgentResult) (OutcomeClassification, error) {
+			return OutcomeClassification{Outcome: OutcomeStop, Reason: result.Message}, nil
+		}),
+	})
+	result, err := a.Execute(context.Background(), AgentRequest{Task: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Outcome != OutcomeStop || result.OutcomeReason != result.Message {
+		t.Fatalf("unexpected outcome %q (%q)", result.Outcome, result.OutcomeReason)
+	}
+}
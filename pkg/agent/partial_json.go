@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// partialJSONStream accumulates streamed reply text and parses it into the
+// best-effort JSON value seen so far.
+type partialJSONStream struct {
+	text strings.Builder
+	last string
+}
+
+// reset starts a new reply.
+func (s *partialJSONStream) reset() {
+	s.text.Reset()
+	s.last = ""
+}
+
+// feed appends delta and returns the partial value when it changed.
+func (s *partialJSONStream) feed(delta string) (any, bool) {
+	s.text.WriteString(delta)
+	completed, ok := completePartialJSON(s.text.String())
+	if !ok || completed == s.last {
+		return nil, false
+	}
+	var value any
+	if err := json.Unmarshal([]byte(completed), &value); err != nil {
+		return nil, false
+	}
+	s.last = completed
+	return value, true
+}
+
+// partialJSONPoint is a prefix of the JSON text that becomes valid once the
+// containers open at that point are closed.
+type partialJSONPoint struct {
+	end   int
+	stack []byte
+}
+
+// completePartialJSON turns the prefix of a JSON object or array into valid
+// JSON: text before the first '{' or '[' (such as a code fence) is skipped,
+// an unterminated string value is closed, an unfinished key, literal, or
+// number is dropped, and open containers are closed. Text after the value
+// is ignored.
+func completePartialJSON(text string) (string, bool) {
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return "", false
+	}
+	text = text[start:]
+
+	var (
+		stack     []byte // open containers, '{' or '['
+		expectKey bool   // in an object, before a key
+		inString  bool
+		isKey     bool // the open string is an object key
+		escaped   bool
+		strStart  int
+		safe      partialJSONPoint
+	)
+	mark := func(end int) {
+		safe = partialJSONPoint{end: end, stack: append([]byte(nil), stack...)}
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if !isKey {
+					mark(i + 1)
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString, escaped, strStart = true, false, i
+			isKey = len(stack) > 0 && stack[len(stack)-1] == '{' && expectKey
+		case '{', '[':
+			stack = append(stack, c)
+			expectKey = c == '{'
+			mark(i + 1)
+		case '}', ']':
+			if len(stack) == 0 {
+				return "", false
+			}
+			stack = stack[:len(stack)-1]
+			expectKey = false
+			if len(stack) == 0 {
+				return text[:i+1], true
+			}
+			mark(i + 1)
+		case ':':
+			expectKey = false
+		case ',':
+			mark(i)
+			expectKey = stack[len(stack)-1] == '{'
+		}
+	}
+
+	// Close an unterminated string value at the last complete character.
+	if inString && !isKey {
+		body := text[strStart:]
+		if escaped {
+			body = body[:len(body)-1]
+		} else if i := strings.LastIndex(body, `\u`); i >= 0 && len(body)-i < 6 {
+			body = body[:i]
+		}
+		if candidate := closePartialJSON(text[:strStart]+body+`"`, stack); json.Valid([]byte(candidate)) {
+			return candidate, true
+		}
+	}
+	// A trailing number or literal may already be complete.
+	if !inString {
+		trimmed := strings.TrimRight(text, " \t\r\n")
+		if !strings.HasSuffix(trimmed, ",") && !strings.HasSuffix(trimmed, ":") {
+			if candidate := closePartialJSON(trimmed, stack); json.Valid([]byte(candidate)) {
+				return candidate, true
+			}
+		}
+	}
+	return closePartialJSON(text[:safe.end], safe.stack), true
+}
+
+// closePartialJSON appends the closers for the open containers in stack.
+func closePartialJSON(prefix string, stack []byte) string {
+	var b strings.Builder
+	b.Grow(len(prefix) + len(stack))
+	b.WriteString(prefix)
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			b.WriteByte('}')
+		} else {
+			b.WriteByte(']')
+		}
+	}
+	return b.String()
+}
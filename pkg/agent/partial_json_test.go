@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestCompletePartialJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		ok   bool
+	}{
+		{name: "no json yet", text: "Here is", ok: false},
+		{name: "open object", text: "{", want: "{}", ok: true},
+		{name: "code fence", text: "```json\n{\"a\": 1", want: "{\"a\": 1}", ok: true},
+		{name: "partial key dropped", text: `{"a": 1, "fi`, want: `{"a": 1}`, ok: true},
+		{name: "key without value dropped", text: `{"a": 1, "files":`, want: `{"a": 1}`, ok: true},
+		{name: "partial string closed", text: `{"files": ["main.go", "ut`, want: `{"files": ["main.go", "ut"]}`, ok: true},
+		{name: "partial escape dropped", text: `{"a": "x\`, want: `{"a": "x"}`, ok: true},
+		{name: "partial unicode escape dropped", text: `{"a": "x\u00`, want: `{"a": "x"}`, ok: true},
+		{name: "partial literal dropped", text: `{"a": [1, tr`, want: `{"a": [1]}`, ok: true},
+		{name: "nested containers closed", text: `[{"a": {"b": [1, 2`, want: `[{"a": {"b": [1, 2]}}]`, ok: true},
+		{name: "trailing comma dropped", text: `[1, 2, `, want: `[1, 2]`, ok: true},
+		{name: "text after value ignored", text: `{"a": 1} done`, want: `{"a": 1}`, ok: true},
+		{name: "braces in strings", text: `{"a": "}{[", "b`, want: `{"a": "}{["}`, ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := completePartialJSON(tt.text)
+			if ok != tt.ok || got != tt.want {
+				t.Fatalf("completePartialJSON(%q) = %q, %v; want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+type partialJSONProvider struct {
+	chunks []string
+}
+
+func (p partialJSONProvider) Name() string { return "partial-json-provider" }
+
+func (p partialJSONProvider) Call(ctx context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	return p.Stream(ctx, req, nil)
+}
+
+func (p partialJSONProvider) Stream(_ context.Context, _ llm.AgentRequest, onDelta func(llm.ContentBlockDelta)) (llm.AgentResponse, error) {
+	text := ""
+	for _, chunk := range p.chunks {
+		text += chunk
+		if onDelta != nil {
+			onDelta(llm.ContentBlockDelta{Type: llm.ContentTypeText, Text: chunk})
+		}
+	}
+	return llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		StopReason: llm.StopReasonEndTurn,
+		Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: text}},
+	}, nil
+}
+
+func TestExecuteStream_StructuredOutputEmitsPartialJSON(t *testing.T) {
+	provider := partialJSONProvider{chunks: []string{`{"files": [`, `"a.go", "b`, `.go"`, `]}`}}
+	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{EnableStreaming: true})
+
+	events, errs := a.ExecuteStream(context.Background(), AgentRequest{
+		Task:    "list files as JSON",
+		Options: AgentOptions{StructuredOutput: true},
+	})
+	streamEvents, streamErrors := collectStreamResults(t, events, errs)
+	if len(streamErrors) != 0 {
+		t.Fatalf("expected no stream errors, got %v", streamErrors)
+	}
+
+	var partials []any
+	for _, evt := range streamEvents {
+		if evt.Type == AgentEventPartialJSON {
+			partials = append(partials, evt.Partial)
+		}
+	}
+	want := []any{
+		map[string]any{"files": []any{}},
+		map[string]any{"files": []any{"a.go", "b"}},
+		map[string]any{"files": []any{"a.go", "b.go"}},
+	}
+	if !reflect.DeepEqual(partials, want) {
+		t.Fatalf("unexpected partial values:\n got %#v\nwant %#v", partials, want)
+	}
+}
+
+func TestExecuteStream_PartialJSONRequiresStructuredOutput(t *testing.T) {
+	provider := partialJSONProvider{chunks: []string{`{"a": 1}`}}
+	a := NewAPIAgent(provider, tools.NewRegistry(), APIAgentOptions{EnableStreaming: true})
+
+	events, errs := a.ExecuteStream(context.Background(), AgentRequest{Task: "answer"})
+	streamEvents, _ := collectStreamResults(t, events, errs)
+	if idx := findEventIndex(streamEvents, AgentEventPartialJSON); idx >= 0 {
+		t.Fatalf("unexpected partial_json event: %+v", streamEvents[idx])
+	}
+}
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/orchestrator"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/plugins"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// installedPlugins holds the combined contributions of an agent's plugins.
+type installedPlugins struct {
+	names     []string
+	skillDirs []string
+	commands  []orchestrator.SlashCommand
+	hooks     []plugins.Hooks
+}
+
+// installPlugins returns a registry holding base's tools plus the plugins'
+// tools, leaving base untouched so plugins stay scoped to one agent.
+// Conflicting plugin, tool, or command names are skipped and reported in
+// the returned error; everything else is still installed.
+func installPlugins(base *tools.Registry, list []plugins.Plugin) (*tools.Registry, installedPlugins, error) {
+	var installed installedPlugins
+	if len(list) == 0 {
+		return base, installed, nil
+	}
+
+	registry := tools.NewRegistry()
+	for _, t := range base.List() {
+		registry.MustRegister(t)
+	}
+
+	var errs []error
+	seenPlugins := make(map[string]bool)
+	seenCommands := make(map[string]string)
+	for _, p := range list {
+		name := p.Name()
+		if seenPlugins[name] {
+			errs = append(errs, fmt.Errorf("plugin %s: already installed", name))
+			continue
+		}
+		seenPlugins[name] = true
+		installed.names = append(installed.names, name)
+
+		for _, t := range p.Tools() {
+			if err := registry.Register(t); err != nil {
+				errs = append(errs, fmt.Errorf("plugin %s: %w", name, err))
+			}
+		}
+		installed.skillDirs = append(installed.skillDirs, p.SkillDirs()...)
+		for _, cmd := range p.Commands() {
+			key := strings.ToLower(cmd.Name)
+			if owner, ok := seenCommands[key]; ok {
+				errs = append(errs, fmt.Errorf("plugin %s: command /%s already provided by plugin %s", name, cmd.Name, owner))
+				continue
+			}
+			seenCommands[key] = name
+			installed.commands = append(installed.commands, orchestrator.SlashCommand{
+				Name:        cmd.Name,
+				Description: cmd.Description,
+				Prompt:      cmd.Prompt,
+			})
+		}
+		installed.hooks = append(installed.hooks, p.Hooks())
+	}
+	return registry, installed, errors.Join(errs...)
+}
+
+// withHooks chains the plugin hooks after the request callbacks.
+func (p installedPlugins) withHooks(cbs AgentCallbacks) AgentCallbacks {
+	for _, hooks := range p.hooks {
+		if hook := hooks.OnMessage; hook != nil {
+			prev := cbs.OnMessage
+			cbs.OnMessage = func(msg agenttypes.Message) {
+				if prev != nil {
+					prev(msg)
+				}
+				hook(msg)
+			}
+		}
+		if hook := hooks.OnToolCall; hook != nil {
+			prev := cbs.OnToolCall
+			cbs.OnToolCall = func(name string, input map[string]any) {
+				if prev != nil {
+					prev(name, input)
+				}
+				hook(name, input)
+			}
+		}
+		if hook := hooks.OnToolResult; hook != nil {
+			prev := cbs.OnToolResult
+			cbs.OnToolResult = func(name string, result tools.ToolResult) {
+				if prev != nil {
+					prev(name, result)
+				}
+				hook(name, result)
+			}
+		}
+	}
+	return cbs
+}
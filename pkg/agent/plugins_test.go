@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/plugins"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestAPIAgentInstallsPlugins(t *testing.T) {
+	var hookCalls []string
+	plugin, err := plugins.New(plugins.Spec{
+		Name:     "greeter",
+		Tools:    []tools.Tool{apiAgentNoopTool{}},
+		Commands: []plugins.Command{{Name: "greet", Prompt: "Say hello to $ARGUMENTS."}},
+		Hooks: plugins.Hooks{
+			OnToolCall: func(name string, _ map[string]any) {
+				hookCalls = append(hookCalls, name)
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("plugins.New() error = %v", err)
+	}
+
+	shared := tools.NewRegistry()
+	provider := &apiAgentLoopProvider{toolIterations: 1}
+	a := NewAPIAgent(provider, shared, APIAgentOptions{Plugins: []plugins.Plugin{plugin}})
+
+	var callbackCalls int
+	result, err := a.Execute(context.Background(), AgentRequest{
+		Task:    "/greet world",
+		WorkDir: t.TempDir(),
+		Callbacks: AgentCallbacks{
+			OnToolCall: func(string, map[string]any) { callbackCalls++ },
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if shared.Count() != 0 {
+		t.Fatalf("expected shared registry to stay empty, got %v", shared.Names())
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].IsError {
+		t.Fatalf("expected plugin tool to run, got %+v", result.ToolCalls)
+	}
+	if len(hookCalls) != 1 || hookCalls[0] != "noop" || callbackCalls != 1 {
+		t.Fatalf("expected plugin hook and request callback once each, got hooks=%v callbacks=%d", hookCalls, callbackCalls)
+	}
+	if first := result.RawOutput[0].GetText(); !strings.Contains(first, "Say hello to world.") {
+		t.Fatalf("expected plugin command to be rendered, got %q", first)
+	}
+}
+
+func TestNewAgentRejectsConflictingPlugins(t *testing.T) {
+	newPlugin := func(name string) plugins.Plugin {
+		p, err := plugins.New(plugins.Spec{Name: name, Tools: []tools.Tool{apiAgentNoopTool{}}})
+		if err != nil {
+			t.Fatalf("plugins.New() error = %v", err)
+		}
+		return p
+	}
+
+	_, err := NewAgent(AgentConfig{
+		Type: AgentTypeAPI,
+		API: &APIConfig{
+			ProviderType: ProviderTypeOpenAI,
+			BaseURL:      "http://localhost",
+			APIKey:       "key",
+			Model:        "gpt-4.1",
+		},
+		Plugins: []plugins.Plugin{newPlugin("first"), newPlugin("second")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "plugin second") {
+		t.Fatalf("expected tool conflict error for plugin second, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/orchestrator"
+)
+
+// ExecutionProfile records where an execution spent its time and tokens.
+// It is set on AgentResult when AgentOptions.Profile is enabled; marshal it
+// as JSON for tooling or print Summary for a quick look.
+type ExecutionProfile struct {
+	Iterations []IterationProfile
+
+	// TotalDuration is the wall-clock time of the agent loop.
+	TotalDuration time.Duration
+
+	// ProviderLatency and ToolLatency sum the iterations' latencies.
+	ProviderLatency time.Duration
+	ToolLatency     time.Duration
+}
+
+// IterationProfile is the timing and token usage of one loop iteration.
+type IterationProfile struct {
+	Iteration int
+
+	// Duration is the iteration's wall-clock time.
+	Duration time.Duration
+
+	// ProviderLatency is the time spent waiting for the model, including
+	// retries and rate limit waits.
+	ProviderLatency time.Duration
+
+	// ToolLatency is the summed execution time of the iteration's tools.
+	ToolLatency time.Duration
+
+	InputTokens  int
+	OutputTokens int
+
+	// ContextMessages and ContextTokens are the size of the request sent to
+	// the model. ContextTokens is an estimate.
+	ContextMessages int
+	ContextTokens   int
+
+	Tools []ToolProfile
+}
+
+// ToolProfile is one tool call within an iteration.
+type ToolProfile struct {
+	Name     string
+	Duration time.Duration
+	Cached   bool
+	Rejected bool
+	IsError  bool
+}
+
+// profileSlowestTools is how many tool calls Summary lists.
+const profileSlowestTools = 5
+
+// Summary renders the profile as a short human-readable report: totals,
+// one row per iteration, and the slowest tool calls.
+func (p *ExecutionProfile) Summary() string {
+	if p == nil {
+		return ""
+	}
+	var b strings.Builder
+	other := p.TotalDuration - p.ProviderLatency - p.ToolLatency
+	if other < 0 {
+		other = 0
+	}
+	fmt.Fprintf(&b, "total %s: model %s (%s), tools %s (%s), other %s\n",
+		roundDuration(p.TotalDuration),
+		roundDuration(p.ProviderLatency), percentOf(p.ProviderLatency, p.TotalDuration),
+		roundDuration(p.ToolLatency), percentOf(p.ToolLatency, p.TotalDuration),
+		roundDuration(other))
+
+	fmt.Fprintf(&b, "%4s  %9s  %9s  %9s  %8s  %8s  %s\n",
+		"iter", "wall", "model", "tools", "in", "out", "context")
+	var calls []ToolProfile
+	for _, it := range p.Iterations {
+		fmt.Fprintf(&b, "%4d  %9s  %9s  %9s  %8d  %8d  %d msgs, ~%d tokens\n",
+			it.Iteration, roundDuration(it.Duration), roundDuration(it.ProviderLatency),
+			roundDuration(it.ToolLatency), it.InputTokens, it.OutputTokens,
+			it.ContextMessages, it.ContextTokens)
+		calls = append(calls, it.Tools...)
+	}
+
+	sort.SliceStable(calls, func(i, j int) bool { return calls[i].Duration > calls[j].Duration })
+	if len(calls) > profileSlowestTools {
+		calls = calls[:profileSlowestTools]
+	}
+	if len(calls) > 0 && calls[0].Duration > 0 {
+		b.WriteString("slowest tools:\n")
+		for _, c := range calls {
+			if c.Duration == 0 {
+				break
+			}
+			note := ""
+			if c.IsError {
+				note = " (error)"
+			}
+			fmt.Fprintf(&b, "  %s %s%s\n", c.Name, roundDuration(c.Duration), note)
+		}
+	}
+	return b.String()
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(10 * time.Millisecond)
+	}
+	return d.Round(100 * time.Microsecond)
+}
+
+func percentOf(part, total time.Duration) string {
+	if total <= 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(part)/float64(total))
+}
+
+func fromOrchestratorProfile(p *orchestrator.Profile) *ExecutionProfile {
+	if p == nil {
+		return nil
+	}
+	out := &ExecutionProfile{
+		TotalDuration:   p.TotalDuration,
+		ProviderLatency: p.ProviderLatency,
+		ToolLatency:     p.ToolLatency,
+	}
+	for _, it := range p.Iterations {
+		ip := IterationProfile{
+			Iteration:       it.Iteration,
+			Duration:        it.Duration,
+			ProviderLatency: it.ProviderLatency,
+			ToolLatency:     it.ToolLatency,
+			InputTokens:     it.InputTokens,
+			OutputTokens:    it.OutputTokens,
+			ContextMessages: it.ContextMessages,
+			ContextTokens:   it.ContextTokens,
+		}
+		for _, t := range it.Tools {
+			ip.Tools = append(ip.Tools, ToolProfile(t))
+		}
+		out.Iterations = append(out.Iterations, ip)
+	}
+	return out
+}
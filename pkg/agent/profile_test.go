@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecutionProfileSummary(t *testing.T) {
+	profile := &ExecutionProfile{
+		TotalDuration:   10 * time.Second,
+		ProviderLatency: 7 * time.Second,
+		ToolLatency:     2 * time.Second,
+		Iterations: []IterationProfile{
+			{Iteration: 1, Duration: 3 * time.Second, ProviderLatency: 3 * time.Second, InputTokens: 500, OutputTokens: 40, ContextMessages: 1, ContextTokens: 480},
+			{
+				Iteration: 2, Duration: 7 * time.Second, ProviderLatency: 4 * time.Second, ToolLatency: 2 * time.Second,
+				InputTokens: 900, OutputTokens: 60, ContextMessages: 3, ContextTokens: 870,
+				Tools: []ToolProfile{
+					{Name: "read_file", Duration: 50 * time.Millisecond},
+					{Name: "bash", Duration: 1950 * time.Millisecond, IsError: true},
+				},
+			},
+		},
+	}
+
+	summary := profile.Summary()
+	for _, want := range []string{
+		"total 10s: model 7s (70%), tools 2s (20%), other 1s",
+		"3 msgs, ~870 tokens",
+		"slowest tools:\n  bash 1.95s (error)\n  read_file 50ms\n",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Fatalf("expected summary to contain %q, got:\n%s", want, summary)
+		}
+	}
+	if (*ExecutionProfile)(nil).Summary() != "" {
+		t.Fatal("expected an empty summary for a nil profile")
+	}
+}
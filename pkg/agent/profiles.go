@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// Profile is a named variant of an API agent configuration, e.g. a
+// "reviewer" with read-only tools or a "docs" agent on a cheaper model.
+// Zero fields keep the base configuration's value.
+type Profile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Provider, BaseURL, and Model select the LLM. APIKeyEnv names the
+	// environment variable holding the API key when the profile uses a
+	// different provider account than the base configuration.
+	Provider  ProviderType `json:"provider,omitempty"`
+	BaseURL   string       `json:"base_url,omitempty"`
+	APIKeyEnv string       `json:"api_key_env,omitempty"`
+	Model     string       `json:"model,omitempty"`
+
+	SystemPrompt  string `json:"system_prompt,omitempty"`
+	MaxIterations int    `json:"max_iterations,omitempty"`
+
+	// Tools is an allowlist of registry tool names; empty keeps every tool.
+	Tools []string `json:"tools,omitempty"`
+
+	// Compact replaces the base compaction settings.
+	Compact *CompactConfig `json:"compact,omitempty"`
+}
+
+// LoadProfiles reads a JSON array of profiles from path.
+func LoadProfiles(path string) ([]Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read profiles: %w", err)
+	}
+	var profiles []Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("parse profiles %s: %w", path, err)
+	}
+	seen := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		if p.Name == "" {
+			return nil, fmt.Errorf("profiles %s: profile name is required", path)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("profiles %s: duplicate profile %q", path, p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return profiles, nil
+}
+
+// Apply returns base with the profile's overrides. base must configure an
+// API agent; its API config and registry are copied, not modified.
+func (p Profile) Apply(base AgentConfig) (AgentConfig, error) {
+	if base.API == nil {
+		return AgentConfig{}, fmt.Errorf("profile %s: API configuration is required", p.Name)
+	}
+	cfg := base
+	cfg.Type = AgentTypeAPI
+	apiCfg := *base.API
+	cfg.API = &apiCfg
+
+	if p.Provider != "" {
+		apiCfg.ProviderType = p.Provider
+	}
+	if p.BaseURL != "" {
+		apiCfg.BaseURL = p.BaseURL
+	}
+	if p.APIKeyEnv != "" {
+		apiCfg.APIKey = os.Getenv(p.APIKeyEnv)
+		if apiCfg.APIKey == "" {
+			return AgentConfig{}, fmt.Errorf("profile %s: %s is not set", p.Name, p.APIKeyEnv)
+		}
+	}
+	if p.Model != "" {
+		apiCfg.Model = p.Model
+	}
+	if p.SystemPrompt != "" {
+		apiCfg.SystemPrompt = p.SystemPrompt
+	}
+	if p.MaxIterations > 0 {
+		apiCfg.MaxIterations = p.MaxIterations
+	}
+	if p.Compact != nil {
+		compact := *p.Compact
+		apiCfg.CompactConfig = &compact
+	}
+
+	if len(p.Tools) > 0 {
+		registry := tools.NewRegistry()
+		for _, name := range p.Tools {
+			var tool tools.Tool
+			if base.Registry != nil {
+				tool = base.Registry.Get(name)
+			}
+			if tool == nil {
+				return AgentConfig{}, fmt.Errorf("profile %s: unknown tool %q", p.Name, name)
+			}
+			if err := registry.Register(tool); err != nil {
+				return AgentConfig{}, fmt.Errorf("profile %s: %w", p.Name, err)
+			}
+		}
+		cfg.Registry = registry
+	}
+	return cfg, nil
+}
+
+// NewProfileAgents creates one agent per profile from base, keyed by
+// profile name. On error, agents created so far are closed.
+func NewProfileAgents(base AgentConfig, profiles []Profile) (map[string]Agent, error) {
+	agents := make(map[string]Agent, len(profiles))
+	closeAll := func() {
+		for _, a := range agents {
+			_ = a.Close()
+		}
+	}
+	for _, p := range profiles {
+		if _, ok := agents[p.Name]; ok {
+			closeAll()
+			return nil, fmt.Errorf("duplicate profile %q", p.Name)
+		}
+		cfg, err := p.Apply(base)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		a, err := NewAgent(cfg)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		agents[p.Name] = a
+	}
+	return agents, nil
+}
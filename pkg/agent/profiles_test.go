@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestLoadProfilesAndApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	data := `[
+		{"name": "reviewer", "model": "reviewer-model", "system_prompt": "Review only.", "tools": ["noop"], "compact": {"enabled": true, "threshold": 20}},
+		{"name": "docs", "api_key_env": "PROFILE_TEST_DOCS_KEY"}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := LoadProfiles(path)
+	if err != nil || len(profiles) != 2 {
+		t.Fatalf("unexpected profiles %+v, %v", profiles, err)
+	}
+
+	registry := tools.NewRegistry()
+	registry.MustRegister(apiAgentNoopTool{})
+	registry.MustRegister(apiAgentEchoRequestTool{})
+	base := AgentConfig{
+		Type:     AgentTypeAPI,
+		API:      &APIConfig{ProviderType: ProviderTypeClaude, BaseURL: "http://localhost", APIKey: "base-key", Model: "base-model", SystemPrompt: "base"},
+		Registry: registry,
+	}
+
+	cfg, err := profiles[0].Apply(base)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if cfg.API.Model != "reviewer-model" || cfg.API.SystemPrompt != "Review only." || cfg.API.CompactConfig.Threshold != 20 {
+		t.Fatalf("overrides not applied: %+v", cfg.API)
+	}
+	if names := cfg.Registry.Names(); len(names) != 1 || names[0] != "noop" {
+		t.Fatalf("expected only the allowed tool, got %v", names)
+	}
+	if base.API.Model != "base-model" || base.Registry.Count() != 2 {
+		t.Fatal("Apply must not modify the base configuration")
+	}
+
+	if _, err := profiles[1].Apply(base); err == nil || !strings.Contains(err.Error(), "PROFILE_TEST_DOCS_KEY") {
+		t.Fatalf("expected a missing key error, got %v", err)
+	}
+	t.Setenv("PROFILE_TEST_DOCS_KEY", "docs-key")
+	if cfg, err := profiles[1].Apply(base); err != nil || cfg.API.APIKey != "docs-key" || cfg.API.Model != "base-model" {
+		t.Fatalf("unexpected docs config %+v, %v", cfg.API, err)
+	}
+
+	if _, err := (Profile{Name: "bad", Tools: []string{"missing"}}).Apply(base); err == nil {
+		t.Fatal("expected an unknown tool error")
+	}
+}
+
+func TestLoadProfilesRejectsDuplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(path, []byte(`[{"name":"a"},{"name":"a"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProfiles(path); err == nil {
+		t.Fatal("expected a duplicate profile error")
+	}
+}
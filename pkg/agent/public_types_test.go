@@ -34,3 +34,19 @@ func TestAgentCallbacksUsePublicAgentTypes(t *testing.T) {
 		t.Fatalf("on message callback should be set")
 	}
 }
+
+func TestMessageMetadataSurvivesConversion(t *testing.T) {
+	msg := agenttypes.NewTextMessage(agenttypes.RoleUser, "hello").
+		WithMetadata("origin", "ui").
+		WithMetadata("visibility", "hidden")
+
+	roundTrip := fromLLMMessage(toLLMMessage(msg))
+	if roundTrip.Metadata["origin"] != "ui" || roundTrip.Metadata["visibility"] != "hidden" {
+		t.Fatalf("metadata lost in conversion: %#v", roundTrip.Metadata)
+	}
+
+	roundTrip.Metadata["origin"] = "changed"
+	if msg.Metadata["origin"] != "ui" {
+		t.Fatalf("conversion should not alias metadata maps")
+	}
+}
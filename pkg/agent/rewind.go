@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/orchestrator"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+// Rewind selects where to re-run a recorded execution and what to change
+// before continuing live, for debugging why an agent went off the rails.
+type Rewind struct {
+	// Iteration is the model iteration to re-run, counting from 1. The
+	// recorded messages before it are replayed as they are, apart from the
+	// edits below; the model is called live from this iteration on.
+	Iteration int
+
+	// ToolResults replaces recorded tool results, keyed by tool_use ID.
+	// Only results of earlier iterations can be replaced.
+	ToolResults map[string]ToolResultEdit
+
+	// Messages replaces the text of recorded user messages, such as the
+	// task or a steering message, keyed by their index in the recording.
+	Messages map[int]string
+
+	// Steering, if not empty, is added as a steering message right before
+	// the re-run iteration.
+	Steering string
+
+	// SystemPrompt, if not empty, replaces the system prompt of the re-run.
+	// Only RewindRequest uses it.
+	SystemPrompt string
+}
+
+// ToolResultEdit is the replacement for one recorded tool result.
+type ToolResultEdit struct {
+	Content string
+	IsError bool
+}
+
+// RewindHistory cuts recording, e.g. AgentResult.RawOutput, before the
+// model response of rw.Iteration and applies rw's edits. The result is
+// ready to seed AgentRequest.History; leave Task empty to continue the
+// recorded turn rather than start a new one (see RewindRequest).
+//
+// Only the conversation is rewound. Files changed by the recorded tool
+// calls keep their current content; roll them back first, e.g. with
+// AgentResult.Workspace, if the re-run depends on them.
+func RewindHistory(recording []agenttypes.Message, rw Rewind) ([]agenttypes.Message, error) {
+	iterations := 0
+	cut := -1
+	for i, msg := range recording {
+		if msg.Role != agenttypes.RoleAssistant {
+			continue
+		}
+		iterations++
+		if iterations == rw.Iteration {
+			cut = i
+			break
+		}
+	}
+	if rw.Iteration < 1 || cut < 0 {
+		return nil, fmt.Errorf("iteration %d not in recording (%d iterations)", rw.Iteration, iterations)
+	}
+
+	history := make([]agenttypes.Message, cut, cut+1)
+	copy(history, recording[:cut])
+
+	for _, idx := range sortedKeys(rw.Messages) {
+		if idx < 0 || idx >= cut {
+			return nil, fmt.Errorf("message %d is not before iteration %d", idx, rw.Iteration)
+		}
+		msg := history[idx]
+		if msg.Role != agenttypes.RoleUser || msg.GetText() == "" {
+			return nil, fmt.Errorf("message %d is not a user text message", idx)
+		}
+		edited := agenttypes.NewTextMessage(agenttypes.RoleUser, rw.Messages[idx])
+		edited.Metadata = msg.Metadata
+		history[idx] = edited
+	}
+
+	remaining := make(map[string]bool, len(rw.ToolResults))
+	for id := range rw.ToolResults {
+		remaining[id] = true
+	}
+	for i, msg := range history {
+		edited := false
+		content := msg.Content
+		for j, block := range content {
+			edit, ok := rw.ToolResults[block.ToolUseID]
+			if block.Type != agenttypes.ContentTypeToolResult || !ok {
+				continue
+			}
+			if !edited {
+				content = append([]agenttypes.ContentBlock(nil), content...)
+				edited = true
+			}
+			content[j].Content = edit.Content
+			content[j].IsError = edit.IsError
+			delete(remaining, block.ToolUseID)
+		}
+		history[i].Content = content
+	}
+	if len(remaining) > 0 {
+		ids := sortedKeys(remaining)
+		return nil, fmt.Errorf("no tool result for %s before iteration %d", ids[0], rw.Iteration)
+	}
+
+	if rw.Steering != "" {
+		history = append(history, agenttypes.NewTextMessage(agenttypes.RoleUser, rw.Steering).
+			WithMetadata(LoopInputMetadataKey, orchestrator.LoopInputSteering))
+	}
+	return history, nil
+}
+
+// RewindRequest returns a copy of req that re-runs recording from
+// rw.Iteration: History is the rewound conversation, Task is empty, and
+// rw.SystemPrompt replaces SystemPrompt if set.
+func RewindRequest(req AgentRequest, recording []agenttypes.Message, rw Rewind) (AgentRequest, error) {
+	history, err := RewindHistory(recording, rw)
+	if err != nil {
+		return AgentRequest{}, err
+	}
+	req.History = history
+	req.Task = ""
+	if rw.SystemPrompt != "" {
+		req.SystemPrompt = rw.SystemPrompt
+	}
+	return req, nil
+}
+
+func sortedKeys[K int | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
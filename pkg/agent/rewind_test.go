@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// rewindRecordingProvider wraps apiAgentLoopProvider and records requests.
+type rewindRecordingProvider struct {
+	apiAgentLoopProvider
+	requests []llm.AgentRequest
+}
+
+func (p *rewindRecordingProvider) Call(ctx context.Context, req llm.AgentRequest) (llm.AgentResponse, error) {
+	p.requests = append(p.requests, req)
+	return p.apiAgentLoopProvider.Call(ctx, req)
+}
+
+func TestRewindRequestContinuesFromIteration(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(apiAgentNoopTool{})
+	recorded, err := NewAPIAgent(&apiAgentLoopProvider{toolIterations: 2}, registry, APIAgentOptions{}).
+		Execute(context.Background(), AgentRequest{Task: "run", SystemPrompt: "old prompt"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	req, err := RewindRequest(AgentRequest{Task: "run", SystemPrompt: "old prompt"}, recorded.RawOutput, Rewind{
+		Iteration:    2,
+		ToolResults:  map[string]ToolResultEdit{"tool-1": {Content: "disk full", IsError: true}},
+		Steering:     "check disk space first",
+		SystemPrompt: "new prompt",
+	})
+	if err != nil {
+		t.Fatalf("RewindRequest() error = %v", err)
+	}
+	if recorded.RawOutput[2].Content[0].Content != "ok" {
+		t.Fatal("the recording was modified")
+	}
+
+	// The re-run continues at iteration 2, so the tool loop has one more
+	// tool call left before the final reply.
+	provider := &rewindRecordingProvider{apiAgentLoopProvider: apiAgentLoopProvider{toolIterations: 1}}
+	result, err := NewAPIAgent(provider, registry, APIAgentOptions{}).Execute(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Usage.TotalIterations != 2 || result.Message != "done" {
+		t.Fatalf("unexpected re-run result: %+v", result)
+	}
+
+	first := provider.requests[0]
+	if !strings.Contains(first.System, "new prompt") || strings.Contains(first.System, "old prompt") {
+		t.Fatalf("expected the new system prompt, got %q", first.System)
+	}
+	if len(first.Messages) != 4 {
+		t.Fatalf("expected task, tool call, tool result, and steering, got %+v", first.Messages)
+	}
+	if block := first.Messages[2].Content[0]; block.Content != "disk full" || !block.IsError {
+		t.Fatalf("expected the edited tool result, got %+v", block)
+	}
+	if first.Messages[3].GetText() != "check disk space first" {
+		t.Fatalf("expected the steering message last, got %+v", first.Messages[3])
+	}
+}
+
+func TestRewindHistoryRejectsInvalidEdits(t *testing.T) {
+	recording := []agenttypes.Message{
+		agenttypes.NewTextMessage(agenttypes.RoleUser, "run"),
+		toolCallMessage("t1", "noop", nil),
+		agenttypes.NewToolResultMessage("t1", "ok", false),
+		agenttypes.NewTextMessage(agenttypes.RoleAssistant, "done"),
+	}
+
+	history, err := RewindHistory(recording, Rewind{Iteration: 2, Messages: map[int]string{0: "run again"}})
+	if err != nil || len(history) != 3 || history[0].GetText() != "run again" {
+		t.Fatalf("RewindHistory() = %+v, %v", history, err)
+	}
+
+	for name, rw := range map[string]Rewind{
+		"iteration out of range":  {Iteration: 3},
+		"no iteration":            {},
+		"later tool result":       {Iteration: 1, ToolResults: map[string]ToolResultEdit{"t1": {Content: "x"}}},
+		"unknown tool result":     {Iteration: 2, ToolResults: map[string]ToolResultEdit{"t9": {Content: "x"}}},
+		"assistant message edit":  {Iteration: 2, Messages: map[int]string{1: "x"}},
+		"message after iteration": {Iteration: 1, Messages: map[int]string{2: "x"}},
+	} {
+		if _, err := RewindHistory(recording, rw); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
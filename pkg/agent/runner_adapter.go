@@ -17,6 +17,17 @@ type RunnerAdapter struct {
 
 	// SystemPrompt is the default system prompt.
 	SystemPrompt string
+
+	// Classifier derives the legacy decision when the agent did not report
+	// an outcome. Nil uses LegacyOutcomeSchema.
+	Classifier OutcomeClassifier
+}
+
+// LegacyOutcomeSchema is the llm.Response contract of the legacy runner:
+// a "decision" field with an optional "needs_info_comment", defaulting to
+// proceed when the final message carries no decision.
+func LegacyOutcomeSchema() OutcomeSchema {
+	return OutcomeSchema{Field: "decision", ReasonField: "needs_info_comment", Default: OutcomeProceed}
 }
 
 // NewRunnerAdapter creates a new RunnerAdapter.
@@ -42,10 +53,23 @@ func (a *RunnerAdapter) Run(ctx context.Context, req llm.Request, workDir string
 		return llm.RunResult{}, fmt.Errorf("agent execution failed: %w", err)
 	}
 
+	if result.Outcome == "" {
+		classifier := a.Classifier
+		if classifier == nil {
+			classifier = NewJSONOutcomeClassifier(LegacyOutcomeSchema())
+		}
+		classification, err := classifier.Classify(ctx, result)
+		if err != nil {
+			return llm.RunResult{}, fmt.Errorf("classify outcome: %w", err)
+		}
+		result.Outcome = classification.Outcome
+		result.OutcomeReason = classification.Reason
+	}
+
 	// Convert AgentResult to llm.RunResult
 	runResult := convertToRunResult(result)
-	log.Printf("[runner-adapter] run complete: files=%d",
-		len(runResult.Response.Files))
+	log.Printf("[runner-adapter] run complete: decision=%s files=%d",
+		runResult.Response.Decision, len(runResult.Response.Files))
 
 	return runResult, nil
 }
@@ -91,14 +115,16 @@ func convertToRunResult(result AgentResult) llm.RunResult {
 		files[fc.Path] = fc.Content
 	}
 
+	resp := llm.Response{
+		Decision: llm.Decision(result.Outcome),
+		Files:    files,
+		Summary:  result.Summary,
+	}
+	if result.Outcome == OutcomeNeedsInfo {
+		resp.NeedsInfoComment = result.OutcomeReason
+	}
 	return llm.RunResult{
-		Response: llm.Response{
-			// Internal legacy runner contract still requires decision.
-			// Public agent APIs no longer expose decision semantics.
-			Decision: llm.DecisionProceed,
-			Files:    files,
-			Summary:  result.Summary,
-		},
-		Stdout: result.Message,
+		Response: resp,
+		Stdout:   result.Message,
 	}
 }
@@ -0,0 +1,268 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultStreamBufferSize is the number of events ExecuteStream buffers for
+// a consumer that has not caught up yet.
+const DefaultStreamBufferSize = 128
+
+// ErrStreamConsumerTooSlow is the stream error (matched with errors.Is) when
+// StreamDisconnect ends a run whose consumer let the buffer fill up.
+var ErrStreamConsumerTooSlow = errors.New("stream consumer too slow")
+
+// StreamBufferPolicy selects what ExecuteStream does when its consumer falls
+// behind and the event buffer is full.
+type StreamBufferPolicy string
+
+const (
+	// StreamBlock waits for the consumer, stalling the run until it reads.
+	// This is the default.
+	StreamBlock StreamBufferPolicy = "block"
+
+	// StreamDropDeltas keeps the run going by dropping the oldest delta
+	// events (message_delta, tool_call_delta, tool_output, partial_json).
+	// Lifecycle events are never dropped; the events that complete a delta
+	// stream, such as message_end and tool_result, still carry the full
+	// text. When the buffer holds only lifecycle events, the run waits.
+	StreamDropDeltas StreamBufferPolicy = "drop_deltas"
+
+	// StreamDisconnect cancels the run and reports ErrStreamConsumerTooSlow
+	// as soon as an event does not fit in the buffer.
+	StreamDisconnect StreamBufferPolicy = "disconnect"
+)
+
+// Validate reports an error if p is not one of the policies above. The
+// empty policy is valid and means StreamBlock.
+func (p StreamBufferPolicy) Validate() error {
+	switch p {
+	case "", StreamBlock, StreamDropDeltas, StreamDisconnect:
+		return nil
+	}
+	return fmt.Errorf("unknown stream buffer policy %q (want %q, %q or %q)", p, StreamBlock, StreamDropDeltas, StreamDisconnect)
+}
+
+// StreamBufferConfig bounds the events ExecuteStream buffers for a slow
+// consumer, such as an SSE client on a bad connection.
+type StreamBufferConfig struct {
+	// Size is the number of buffered events (default DefaultStreamBufferSize).
+	Size int
+
+	// Policy applies when the buffer is full (default StreamBlock).
+	Policy StreamBufferPolicy
+}
+
+// isDeltaEvent reports whether StreamDropDeltas may drop evt.
+func isDeltaEvent(evt AgentStreamEvent) bool {
+	switch evt.Type {
+	case AgentEventMessageDelta, AgentEventToolCallDelta, AgentEventToolOutput, AgentEventPartialJSON:
+		return true
+	}
+	return false
+}
+
+// streamEmitter delivers an ExecuteStream's events to its consumer under a
+// StreamBufferConfig.
+type streamEmitter struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	out    chan AgentStreamEvent
+	policy StreamBufferPolicy
+	size   int
+
+	mu       sync.Mutex
+	queue    []AgentStreamEvent
+	dropped  int
+	finished bool
+	tooSlow  error
+
+	// wake tells the pump that the queue has events or is finished; space
+	// tells a waiting emit that the pump took an event.
+	wake  chan struct{}
+	space chan struct{}
+}
+
+// newStreamEmitter returns an emitter for a run under ctx. cancel stops the
+// run when StreamDisconnect gives up on the consumer.
+func newStreamEmitter(ctx context.Context, cancel context.CancelFunc, cfg *StreamBufferConfig) *streamEmitter {
+	e := &streamEmitter{ctx: ctx, cancel: cancel, policy: StreamBlock, size: DefaultStreamBufferSize}
+	if cfg != nil {
+		if cfg.Size > 0 {
+			e.size = cfg.Size
+		}
+		if cfg.Policy != "" {
+			e.policy = cfg.Policy
+		}
+	}
+	if e.policy != StreamDropDeltas {
+		e.out = make(chan AgentStreamEvent, e.size)
+		return e
+	}
+	e.out = make(chan AgentStreamEvent)
+	e.wake = make(chan struct{}, 1)
+	e.space = make(chan struct{}, 1)
+	go e.pump()
+	return e
+}
+
+// events is the channel returned to the consumer.
+func (e *streamEmitter) events() <-chan AgentStreamEvent {
+	return e.out
+}
+
+// emit buffers evt for the consumer. It returns false once the run's
+// context is done or the consumer was disconnected.
+func (e *streamEmitter) emit(evt AgentStreamEvent) bool {
+	switch e.policy {
+	case StreamDropDeltas:
+		return e.enqueue(evt)
+	case StreamDisconnect:
+		if e.failed() {
+			return false
+		}
+		select {
+		case <-e.ctx.Done():
+			return false
+		case e.out <- evt:
+			return true
+		default:
+			e.mu.Lock()
+			e.tooSlow = fmt.Errorf("%w: %d events buffered", ErrStreamConsumerTooSlow, e.size)
+			e.mu.Unlock()
+			e.cancel()
+			return false
+		}
+	default:
+		select {
+		case <-e.ctx.Done():
+			return false
+		case e.out <- evt:
+			return true
+		}
+	}
+}
+
+func (e *streamEmitter) enqueue(evt AgentStreamEvent) bool {
+	for {
+		if e.ctx.Err() != nil {
+			return false
+		}
+		e.mu.Lock()
+		if len(e.queue) >= e.size {
+			if i := e.oldestDelta(); i >= 0 {
+				e.queue = append(e.queue[:i], e.queue[i+1:]...)
+				e.dropped++
+			} else if isDeltaEvent(evt) {
+				e.dropped++
+				e.mu.Unlock()
+				return true
+			}
+		}
+		if len(e.queue) < e.size {
+			e.queue = append(e.queue, evt)
+			e.mu.Unlock()
+			notify(e.wake)
+			return true
+		}
+		e.mu.Unlock()
+
+		select {
+		case <-e.ctx.Done():
+			return false
+		case <-e.space:
+		}
+	}
+}
+
+func (e *streamEmitter) oldestDelta() int {
+	for i, evt := range e.queue {
+		if isDeltaEvent(evt) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pump moves queued events to the consumer until the emitter is closed and
+// drained, or the run's context is done.
+func (e *streamEmitter) pump() {
+	defer close(e.out)
+	for {
+		e.mu.Lock()
+		if len(e.queue) == 0 {
+			finished := e.finished
+			e.mu.Unlock()
+			if finished {
+				return
+			}
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-e.wake:
+			}
+			continue
+		}
+		evt := e.queue[0]
+		e.queue = e.queue[1:]
+		evt.Dropped = e.dropped
+		e.mu.Unlock()
+		notify(e.space)
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case e.out <- evt:
+		}
+	}
+}
+
+// close ends the stream after the buffered events are delivered.
+func (e *streamEmitter) close() {
+	if e.policy != StreamDropDeltas {
+		close(e.out)
+		return
+	}
+	e.mu.Lock()
+	e.finished = true
+	e.mu.Unlock()
+	notify(e.wake)
+}
+
+// failed reports whether StreamDisconnect gave up on the consumer.
+func (e *streamEmitter) failed() bool {
+	return e.disconnectErr() != nil
+}
+
+// err explains why emit returned false.
+func (e *streamEmitter) err() error {
+	if err := e.disconnectErr(); err != nil {
+		return err
+	}
+	return e.ctx.Err()
+}
+
+// disconnectErr is the error to report when StreamDisconnect gave up on
+// the consumer, or nil.
+func (e *streamEmitter) disconnectErr() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.tooSlow
+}
+
+// droppedEvents is the number of delta events dropped so far.
+func (e *streamEmitter) droppedEvents() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.dropped
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
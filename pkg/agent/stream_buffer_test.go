@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func drainStream(t *testing.T, events <-chan AgentStreamEvent) []AgentStreamEvent {
+	t.Helper()
+	var out []AgentStreamEvent
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				return out
+			}
+			out = append(out, evt)
+		case <-timeout:
+			t.Fatalf("timed out draining stream, got %v", out)
+		}
+	}
+}
+
+func TestStreamEmitterDropDeltasKeepsLifecycleEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := newStreamEmitter(ctx, cancel, &StreamBufferConfig{Size: 4, Policy: StreamDropDeltas})
+
+	// Nobody reads until the run is over, so every emit must return
+	// without waiting for the consumer.
+	stream.emit(AgentStreamEvent{Type: AgentEventAgentStart})
+	for range 10 {
+		if !stream.emit(AgentStreamEvent{Type: AgentEventMessageDelta, Delta: "x"}) {
+			t.Fatal("emit of a delta failed")
+		}
+	}
+	stream.emit(AgentStreamEvent{Type: AgentEventMessageEnd, Message: "xxxxxxxxxx"})
+	stream.emit(AgentStreamEvent{Type: AgentEventAgentEnd})
+	stream.close()
+
+	events := drainStream(t, stream.events())
+	var lifecycle []AgentEventType
+	deltas := 0
+	for _, evt := range events {
+		if isDeltaEvent(evt) {
+			deltas++
+		} else {
+			lifecycle = append(lifecycle, evt.Type)
+		}
+	}
+	want := []AgentEventType{AgentEventAgentStart, AgentEventMessageEnd, AgentEventAgentEnd}
+	if len(lifecycle) != len(want) {
+		t.Fatalf("lifecycle events = %v, want %v", lifecycle, want)
+	}
+	for i := range want {
+		if lifecycle[i] != want[i] {
+			t.Fatalf("lifecycle events = %v, want %v", lifecycle, want)
+		}
+	}
+
+	end := events[len(events)-1]
+	if end.Dropped == 0 || end.Dropped != stream.droppedEvents() {
+		t.Fatalf("agent_end dropped = %d, emitter dropped %d", end.Dropped, stream.droppedEvents())
+	}
+	if deltas+end.Dropped != 10 {
+		t.Fatalf("delivered %d deltas and dropped %d, want 10 in total", deltas, end.Dropped)
+	}
+}
+
+func TestStreamEmitterBlockStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newStreamEmitter(ctx, cancel, &StreamBufferConfig{Size: 1})
+
+	if !stream.emit(AgentStreamEvent{Type: AgentEventAgentStart}) {
+		t.Fatal("first emit should fit in the buffer")
+	}
+	done := make(chan bool)
+	go func() { done <- stream.emit(AgentStreamEvent{Type: AgentEventMessageDelta}) }()
+
+	select {
+	case <-done:
+		t.Fatal("emit returned while the buffer was full")
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	if <-done {
+		t.Fatal("emit succeeded after cancel")
+	}
+	if err := stream.err(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestExecuteStreamDisconnectsSlowConsumer(t *testing.T) {
+	a := NewAPIAgent(apiAgentStreamingProvider{}, tools.NewRegistry(), APIAgentOptions{EnableStreaming: true})
+
+	events, errs := a.ExecuteStream(context.Background(), AgentRequest{
+		Task: "stream please",
+		Options: AgentOptions{
+			StreamBuffer: &StreamBufferConfig{Size: 1, Policy: StreamDisconnect},
+		},
+	})
+
+	// Read nothing until the run gave up on us.
+	var streamErr error
+	select {
+	case streamErr = <-errs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the stream error")
+	}
+	if !errors.Is(streamErr, ErrStreamConsumerTooSlow) {
+		t.Fatalf("stream error = %v, want ErrStreamConsumerTooSlow", streamErr)
+	}
+
+	got := drainStream(t, events)
+	if len(got) != 1 || got[0].Type != AgentEventAgentStart {
+		t.Fatalf("events = %v, want only agent_start", got)
+	}
+}
+
+func TestStreamBufferPolicyValidate(t *testing.T) {
+	for _, p := range []StreamBufferPolicy{"", StreamBlock, StreamDropDeltas, StreamDisconnect} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Validate(%q) = %v", p, err)
+		}
+	}
+	err := StreamBufferPolicy("drop").Validate()
+	if err == nil || !strings.Contains(err.Error(), `"drop_deltas"`) {
+		t.Fatalf("expected an error listing the valid policies, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRunTimeout is matched (via errors.Is) by execution errors returned when
+// a run exceeded AgentOptions.Timeout. The AgentResult returned with it
+// holds the messages and tool calls of the run so far; errors.As with
+// *RunTimeoutError gives the details.
+var ErrRunTimeout = errors.New("run timed out")
+
+// RunTimeoutError is returned when a run exceeds AgentOptions.Timeout.
+type RunTimeoutError struct {
+	Timeout time.Duration
+
+	// Err is the error the run stopped with, e.g. a provider call failing
+	// with context.DeadlineExceeded.
+	Err error
+}
+
+func (e *RunTimeoutError) Error() string {
+	return fmt.Sprintf("run timed out after %v: %v", e.Timeout, e.Err)
+}
+
+// Is makes errors.Is(err, ErrRunTimeout) match.
+func (e *RunTimeoutError) Is(target error) bool {
+	return target == ErrRunTimeout
+}
+
+func (e *RunTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// withRunTimeout bounds ctx by timeout; zero or negative leaves it as is.
+// Provider calls and tools see the deadline through the context, so their
+// own timeouts never outlast the run.
+func withRunTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// runTimeoutError wraps err in a *RunTimeoutError when runCtx ran out of
+// time. Errors of runs the caller cancelled, or whose ctx had an earlier
+// deadline, are returned unchanged.
+func runTimeoutError(ctx, runCtx context.Context, timeout time.Duration, err error) error {
+	if err == nil || timeout <= 0 || ctx.Err() != nil || !errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return &RunTimeoutError{Timeout: timeout, Err: err}
+}
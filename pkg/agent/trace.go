@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"github.com/MimeLyc/agent-core-go/internal/pkg/orchestrator"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+// TraceFormat selects the diagram language of ExportTrace.
+type TraceFormat string
+
+const (
+	// TraceMermaid renders a Mermaid sequence diagram.
+	TraceMermaid TraceFormat = "mermaid"
+	// TraceDOT renders a Graphviz digraph.
+	TraceDOT TraceFormat = "dot"
+)
+
+// LoopInputMetadataKey is the message metadata key marking steering
+// ("steering") and follow-up ("follow_up") messages injected into a run.
+const LoopInputMetadataKey = "loop_input"
+
+// ExportTrace renders a conversation, such as AgentResult.RawOutput or a
+// stored chat session, as a diagram for debugging long runs: tasks, model
+// iterations, tool calls (failed ones marked), steering and follow-up
+// injections, compactions, and final replies. Message text is cut to one
+// short line.
+func ExportTrace(messages []agenttypes.Message, format TraceFormat) (string, error) {
+	return orchestrator.ExportTrace(toLLMMessages(messages), orchestrator.TraceFormat(format))
+}
+
+// Trace renders the result's RawOutput with ExportTrace.
+func (r AgentResult) Trace(format TraceFormat) (string, error) {
+	return ExportTrace(r.RawOutput, format)
+}
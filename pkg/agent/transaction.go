@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
+)
+
+// finishTransaction attaches the journal's changes to result and runs
+// ValidateChanges, rolling everything back if validation fails.
+func finishTransaction(ctx context.Context, req AgentRequest, journal *workspace.Journal, result *AgentResult) error {
+	result.Workspace = journal
+	result.FileChanges, result.Patch = fileChangesFromJournal(journal)
+
+	if req.Options.ValidateChanges == nil || len(result.FileChanges) == 0 {
+		return nil
+	}
+	validateErr := req.Options.ValidateChanges(ctx, req.WorkDir, result.FileChanges)
+	if validateErr == nil {
+		return nil
+	}
+
+	restored, rollbackErr := journal.RollbackAll()
+	log.Printf("[api-agent] change validation failed, rolled back %d file(s): %v", len(restored), validateErr)
+	result.Success = false
+	result.FileChanges = nil
+	result.Patch = ""
+	result.Message = fmt.Sprintf("change validation failed, changes rolled back: %v", validateErr)
+	return fmt.Errorf("change validation failed: %w", errors.Join(validateErr, rollbackErr))
+}
+
+func fileChangesFromJournal(journal *workspace.Journal) ([]FileChange, string) {
+	diffs := journal.Diffs()
+	if len(diffs) == 0 {
+		return nil, ""
+	}
+	out := make([]FileChange, 0, len(diffs))
+	var patch strings.Builder
+	for _, d := range diffs {
+		out = append(out, FileChange{
+			Path:            d.Path,
+			Content:         string(d.After),
+			PreviousContent: string(d.Before),
+			Diff:            d.Diff,
+			Operation:       FileOperation(d.Kind),
+		})
+		patch.WriteString(d.Diff)
+	}
+	return out, patch.String()
+}
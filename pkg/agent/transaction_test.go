@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// apiAgentWriteTool is named "noop" so apiAgentLoopProvider invokes it.
+type apiAgentWriteTool struct{}
+
+func (apiAgentWriteTool) Name() string {
+	return "noop"
+}
+
+func (apiAgentWriteTool) Description() string {
+	return "writes out.txt"
+}
+
+func (apiAgentWriteTool) InputSchema() map[string]any {
+	return map[string]any{"type": "object"}
+}
+
+func (apiAgentWriteTool) WritePaths(_ *tools.ToolContext, _ map[string]any) []string {
+	return []string{"out.txt"}
+}
+
+func (apiAgentWriteTool) Execute(_ context.Context, toolCtx *tools.ToolContext, _ map[string]any) (tools.ToolResult, error) {
+	if err := os.WriteFile(filepath.Join(toolCtx.WorkDir, "out.txt"), []byte("new"), 0o644); err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+	return tools.NewToolResult("ok"), nil
+}
+
+func newTransactionalTestAgent() *APIAgent {
+	registry := tools.NewRegistry()
+	registry.MustRegister(apiAgentWriteTool{})
+	return NewAPIAgent(&apiAgentLoopProvider{toolIterations: 1}, registry, APIAgentOptions{})
+}
+
+func TestAPIAgentTransactionalResultCanRollBack(t *testing.T) {
+	workDir := t.TempDir()
+	result, err := newTransactionalTestAgent().Execute(context.Background(), AgentRequest{
+		Task:    "write",
+		WorkDir: workDir,
+		Options: AgentOptions{Transactional: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.FileChanges) != 1 || result.FileChanges[0].Path != "out.txt" ||
+		result.FileChanges[0].Operation != FileOpCreate || result.FileChanges[0].Content != "new" {
+		t.Fatalf("unexpected file changes: %+v", result.FileChanges)
+	}
+
+	restored, err := result.RollbackLastChanges()
+	if err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	if len(restored) != 1 || restored[0] != "out.txt" {
+		t.Fatalf("unexpected restored paths: %v", restored)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "out.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected out.txt to be removed, stat err=%v", err)
+	}
+}
+
+func TestAPIAgentValidationFailureRollsBackChanges(t *testing.T) {
+	workDir := t.TempDir()
+	validateErr := errors.New("tests failed")
+	var validated []FileChange
+	result, err := newTransactionalTestAgent().Execute(context.Background(), AgentRequest{
+		Task:    "write",
+		WorkDir: workDir,
+		Options: AgentOptions{
+			Transactional: true,
+			ValidateChanges: func(_ context.Context, dir string, changes []FileChange) error {
+				if dir != workDir {
+					t.Errorf("expected workdir %s, got %s", workDir, dir)
+				}
+				validated = changes
+				return validateErr
+			},
+		},
+	})
+	if !errors.Is(err, validateErr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if result.Success || len(result.FileChanges) != 0 {
+		t.Fatalf("expected failed result without changes, got %+v", result)
+	}
+	if len(validated) != 1 {
+		t.Fatalf("expected validator to see 1 change, got %+v", validated)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "out.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected out.txt to be rolled back, stat err=%v", err)
+	}
+}
+
+func TestAgentResultRollbackRequiresTransactionalMode(t *testing.T) {
+	if _, err := (AgentResult{}).RollbackLastChanges(); !errors.Is(err, ErrNotTransactional) {
+		t.Fatalf("expected ErrNotTransactional, got %v", err)
+	}
+}
+
+func TestAPIAgentCollectPatch(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "out.txt"), []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := newTransactionalTestAgent().Execute(context.Background(), AgentRequest{
+		Task:    "write",
+		WorkDir: workDir,
+		Options: AgentOptions{CollectPatch: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.FileChanges) != 1 {
+		t.Fatalf("unexpected file changes: %+v", result.FileChanges)
+	}
+	change := result.FileChanges[0]
+	if change.Operation != FileOpModify || change.PreviousContent != "old\n" || change.Content != "new" {
+		t.Fatalf("unexpected file change: %+v", change)
+	}
+	want := "diff --git a/out.txt b/out.txt\n--- a/out.txt\n+++ b/out.txt\n@@ -1 +1 @@\n-old\n+new\n\\ No newline at end of file\n"
+	if result.Patch != want || change.Diff != want {
+		t.Fatalf("unexpected patch:\n%s", result.Patch)
+	}
+}
@@ -2,10 +2,16 @@ package agent
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"time"
 
 	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/encryption"
+	"github.com/MimeLyc/agent-core-go/pkg/guard"
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
 )
 
 // AgentRequest contains all inputs for an agent execution.
@@ -13,6 +19,13 @@ type AgentRequest struct {
 	// Task is the task description or prompt for the agent.
 	Task string
 
+	// History seeds the execution with an earlier conversation. It is
+	// checked with ValidateHistory and sent before Task, which becomes the
+	// next user message; with an empty Task the model continues History as
+	// it is (see RewindRequest). Only API agents use it; CLI agents resume
+	// conversations with SessionID and reject a non-empty History.
+	History []agenttypes.Message
+
 	// SystemPrompt is the system message for the agent.
 	SystemPrompt string
 
@@ -26,6 +39,18 @@ type AgentRequest struct {
 	// WorkDir is the working directory for tool execution.
 	WorkDir string
 
+	// Env contains request-scoped environment variables for tool execution.
+	// They are added to ToolContext.Env (and the CLI process environment).
+	Env map[string]string
+
+	// Secrets supplies secret environment variables for this run. Values are
+	// added like Env and masked in tool results, callbacks, and logs.
+	Secrets SecretProvider
+
+	// SessionID resumes an earlier CLI session (AgentResult.SessionID).
+	// Only CLI agents use it.
+	SessionID string
+
 	// Options configures execution behavior.
 	Options AgentOptions
 
@@ -45,50 +70,684 @@ type AgentOptions struct {
 	// EnableStreaming turns on incremental model output when supported.
 	EnableStreaming bool
 
+	// StreamBuffer bounds the events ExecuteStream buffers and selects
+	// what happens when the consumer falls behind (default: 128 events,
+	// StreamBlock).
+	StreamBuffer *StreamBufferConfig
+
 	// MaxTokens limits the response token count.
 	MaxTokens int
 
-	// TransformContext is an optional pre-LLM context transform hook.
+	// Model overrides the configured model for this request, e.g. to switch
+	// a chat session to another model. Empty uses APIConfig.Model.
+	Model string
+
+	// ThinkingBudgetTokens enables Claude extended thinking for this request
+	// with the given token budget, overriding APIConfig.ThinkingBudgetTokens.
+	ThinkingBudgetTokens int
+
+	// Temperature and Seed override the agent's sampling parameters for
+	// this request. Seed is only honored by OpenAI-compatible providers.
+	Temperature *float64
+	Seed        *int64
+
+	// ToolChoice controls whether and which tools the model calls, e.g. to
+	// force a "final_answer" tool for structured output. Overrides
+	// APIAgentOptions.ToolChoice when set.
+	ToolChoice *ToolChoice
+
+	// AssistantPrefill starts the model's reply with this text to constrain
+	// its format, e.g. "{" for a JSON object. Claude receives it as a
+	// prefilled assistant turn (ignored with extended thinking);
+	// OpenAI-compatible backends receive a trailing assistant message, which
+	// only backends with prefix completion continue. The text is part of
+	// the result. It applies to every model call of the run, so a prefill
+	// that rules out tool calls suits runs that answer in one turn.
+	AssistantPrefill string
+
+	// ProviderParams are merged into the JSON body of every model request
+	// of this execution, for fields the provider does not model, e.g.
+	// {"reasoning_effort": "high"} or {"top_k": 40}. They override
+	// APIConfig.ExtraBody and the provider's own fields of the same name; a
+	// nil value removes a field.
+	ProviderParams map[string]any
+
+	// StructuredOutput marks the reply as a JSON object or array, e.g. with
+	// AssistantPrefill "{". ExecuteStream then parses the streamed text with
+	// a tolerant parser and emits partial_json events carrying the partial
+	// value so far (API agents with a streaming provider only).
+	StructuredOutput bool
+
+	// Deterministic makes the run reproducible for recorded tests:
+	// temperature 0, a fixed seed, one tool call per response, and
+	// sequential generated tool_use IDs. Explicit Temperature/Seed win.
+	Deterministic bool
+
+	// NewToolUseID replaces the generator for tool_use IDs the provider
+	// left empty or duplicated.
+	NewToolUseID func() string
+
+	// TransformContext is an optional pre-LLM context transform hook. It
+	// runs first, before pruning and every ContextTransforms stage.
 	TransformContext func(ctx context.Context, messages []agenttypes.Message) ([]agenttypes.Message, error)
 
+	// ContextTransforms insert transform stages around the default context
+	// rules for this execution. They are added after
+	// APIAgentOptions.ContextTransforms, so a transform with the same name
+	// replaces the agent's.
+	ContextTransforms []ContextTransform
+
 	// ConvertToLlm is an optional final conversion hook before provider call.
 	// It converts agent messages into provider-facing LLM messages.
 	ConvertToLlm func(ctx context.Context, messages []agenttypes.Message, providerName string) ([]agenttypes.LLMMessage, error)
 
+	// TransformToolResult is an optional hook that rewrites, truncates, or
+	// annotates each tool result before the model sees it (APIAgent only).
+	// AgentResult.ToolCalls and OnToolResult keep the original result.
+	TransformToolResult func(ctx context.Context, name string, input map[string]any, result tools.ToolResult) tools.ToolResult
+
 	// DisableDefaultContextRules disables built-in compaction/truncation/validation.
 	DisableDefaultContextRules bool
 
-	// Timeout is the maximum execution time.
+	// DisableProjectConfig ignores the project configuration
+	// (.agents/config.yaml) of the working directory (APIAgent only).
+	DisableProjectConfig bool
+
+	// Timeout is the maximum execution time. A run that exceeds it stops
+	// with a *RunTimeoutError (ErrRunTimeout) and a partial result. Zero
+	// leaves the run bounded only by ctx.
 	Timeout time.Duration
 
 	// AllowedTools restricts which tools the agent can use.
-	// Empty means all tools are allowed.
+	// Empty means all tools are allowed. Entries are allowed-tools patterns
+	// as in skills: names, trailing or inner * wildcards, and MCP names
+	// such as "mcp__github__search" or "mcp__github" for all of a
+	// server's tools. CLI agents pass it to the CLI (--allowedTools) in
+	// place of CLIAgentConfig.AllowedTools.
 	AllowedTools []string
 
-	// DeniedTools specifies tools the agent cannot use.
+	// DeniedTools specifies tools the agent cannot use, with the same
+	// patterns as AllowedTools. CLI agents pass it to the CLI
+	// (--disallowedTools) in place of CLIAgentConfig.DeniedTools.
 	DeniedTools []string
 
 	// CompactConfig configures context compaction.
 	CompactConfig *CompactConfig
 
+	// ToolBudgets caps calls per tool name for this execution, e.g.
+	// {"bash": 20, "web_fetch": 5}. Further calls return a "budget
+	// exhausted" error result telling the model to proceed differently.
+	ToolBudgets map[string]int
+
+	// ToolCache memoizes repeated read-only tool calls within the execution.
+	// Overrides APIAgentOptions.ToolCache when set.
+	ToolCache *ToolCacheConfig
+
+	// ToolRetry retries tool calls that fail with a retryable error.
+	// Overrides APIAgentOptions.ToolRetry when set.
+	ToolRetry *ToolRetryConfig
+
+	// JSONCompression shortens large JSON tool outputs for the model and
+	// attaches the full payloads as artifacts. Overrides
+	// APIAgentOptions.JSONCompression when set.
+	JSONCompression *JSONCompressionConfig
+
+	// LoopDetection nudges or aborts runs that repeat the same tool calls.
+	// Overrides APIAgentOptions.LoopDetection when set.
+	LoopDetection *LoopDetectionConfig
+
+	// Prune selects context pruning strategies for this execution.
+	// Overrides APIAgentOptions.Prune when set.
+	Prune *PruneConfig
+
+	// WatchWorkDir watches WorkDir for files changed outside the agent's
+	// tool calls, e.g. by a human editing alongside it, and tells the model
+	// which files changed after each tool batch. Overrides
+	// APIAgentOptions.WatchWorkDir when set.
+	WatchWorkDir *workspace.WatchConfig
+
+	// ContextSections add or replace system prompt sections for this
+	// execution. They are applied after APIAgentOptions.ContextSections, so a
+	// section with the same name overrides the agent's.
+	ContextSections []ContextSection
+
 	// GetSteeringMessages fetches high-priority runtime messages that can steer
 	// the next model turn immediately.
 	GetSteeringMessages LoopInputFetcher
 
 	// GetFollowUpMessages fetches runtime follow-up messages appended after steering.
 	GetFollowUpMessages LoopInputFetcher
+
+	// RateLimitNotes tells the model when a response was delayed by
+	// provider rate limiting, via a note appended to the next tool results.
+	// Enabled when either this or APIAgentOptions.RateLimitNotes is set.
+	RateLimitNotes bool
+
+	// TrackFileReads makes write_file refuse to overwrite a file the run
+	// has not read, or that changed since it was read, so the model reads
+	// it again instead of clobbering edits made by someone else. Enabled
+	// when either this or APIAgentOptions.TrackFileReads is set.
+	TrackFileReads bool
+
+	// Profile records per-iteration timing, token usage, and context size
+	// in AgentResult.Profile. Enabled when either this or
+	// APIAgentOptions.Profile is set.
+	Profile bool
+
+	// InputQueue is a push-based alternative to the fetchers above. It
+	// supplies whichever of GetSteeringMessages and GetFollowUpMessages is nil.
+	InputQueue *LoopInputQueue
+
+	// AskUserTimeout bounds how long the ask_user tool waits for an answer
+	// (zero waits until the execution ends). ask_user can only reach the
+	// user when a steering source is set; the next steering messages are
+	// taken as the answer.
+	AskUserTimeout time.Duration
+
+	// Redactor masks secrets in tool results before they enter the context.
+	// Overrides APIAgentOptions.Redactor when set.
+	Redactor *redact.Redactor
+
+	// OutputGuard moderates assistant messages for this request.
+	// Overrides APIAgentOptions.OutputGuard when set; combine guards
+	// with guard.Chain.
+	OutputGuard guard.OutputGuard
+
+	// OutcomeClassifier derives AgentResult.Outcome from the finished
+	// execution, e.g. NewJSONOutcomeClassifier(DefaultOutcomeSchema()).
+	// Overrides APIAgentOptions.OutcomeClassifier when set.
+	OutcomeClassifier OutcomeClassifier
+
+	// UsageReporter receives the execution's usage after each model
+	// response and when it ends, e.g. for billing. Overrides
+	// APIAgentOptions.UsageReporter when set.
+	UsageReporter UsageReporter
+
+	// UsageSessionID and UsageLabels are copied into every UsageReport,
+	// e.g. a chat session ID and {"tenant": "acme"}.
+	UsageSessionID string
+	UsageLabels    map[string]string
+
+	// Transactional snapshots files before write-capable tools run so the
+	// execution's changes can be reverted with AgentResult.RollbackLastChanges.
+	Transactional bool
+
+	// CollectPatch records file changes the same way as Transactional so
+	// AgentResult.Patch and the FileChanges diffs are filled in. Transactional
+	// executions always collect them.
+	CollectPatch bool
+
+	// ValidateChanges is called after a transactional execution that changed
+	// files. If it returns an error, all changes are rolled back automatically.
+	ValidateChanges func(ctx context.Context, workDir string, changes []FileChange) error
 }
 
 // CompactConfig configures context compaction (summarization).
 type CompactConfig struct {
 	// Enabled turns on context compaction.
-	Enabled bool
+	Enabled bool `json:"enabled"`
 
 	// Threshold triggers compaction when message count exceeds this.
-	Threshold int
+	Threshold int `json:"threshold,omitempty"`
 
 	// KeepRecent is the number of recent messages to preserve.
+	KeepRecent int `json:"keep_recent,omitempty"`
+
+	// MaxErrors caps the failed tool calls a summary keeps verbatim (0 uses
+	// the default of 20, negative keeps none).
+	MaxErrors int `json:"max_errors,omitempty"`
+}
+
+// ServerTool is a provider-native tool run by the provider itself, such as
+// Claude's web_search. It has no local implementation; its calls and
+// results appear in the conversation as server_tool_use and
+// *_tool_result blocks. Only the Claude provider supports server tools.
+type ServerTool struct {
+	// Type is the versioned tool type, e.g. "web_search_20250305".
+	Type string
+
+	// Name is the tool name the model calls, e.g. "web_search".
+	Name string
+
+	// Config holds the tool's other fields (max_uses, allowed_domains,
+	// blocked_domains, user_location, ...), sent as-is.
+	Config map[string]any
+}
+
+// WebSearchTool returns Claude's web_search server tool. A positive
+// maxUses caps searches per request; allowedDomains restricts results.
+func WebSearchTool(maxUses int, allowedDomains ...string) ServerTool {
+	tool := ServerTool{Type: "web_search_20250305", Name: "web_search", Config: map[string]any{}}
+	if maxUses > 0 {
+		tool.Config["max_uses"] = maxUses
+	}
+	if len(allowedDomains) > 0 {
+		tool.Config["allowed_domains"] = allowedDomains
+	}
+	return tool
+}
+
+// HTTPConfig tunes the HTTP client used for LLM API calls. Agents with the
+// same HTTPConfig share one connection pool. Zero values use pooled
+// defaults (100 idle connections, 32 per host, HTTP/2 when available,
+// proxy from HTTPS_PROXY/HTTP_PROXY).
+type HTTPConfig struct {
+	// MaxIdleConns and MaxIdleConnsPerHost size the idle connection pool.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost caps concurrent connections per host (0 = unlimited).
+	MaxConnsPerHost int
+
+	// IdleConnTimeout closes idle pooled connections (default 90s).
+	IdleConnTimeout time.Duration
+
+	// KeepAlive is the TCP keep-alive period (default 30s).
+	KeepAlive time.Duration
+
+	// DisableHTTP2 keeps connections on HTTP/1.1.
+	DisableHTTP2 bool
+
+	// ProxyURL overrides the proxy from the environment.
+	ProxyURL string
+
+	// CAFile is a PEM bundle of extra trusted root CAs, e.g. for a
+	// TLS-inspecting corporate proxy.
+	CAFile string
+
+	// TLSConfig replaces the derived TLS settings entirely.
+	TLSConfig *tls.Config
+}
+
+// OpenRouterConfig holds OpenRouter-specific request fields.
+type OpenRouterConfig struct {
+	// FallbackModels are tried in order when the primary model is
+	// unavailable, rate limited, or refuses the request.
+	FallbackModels []string
+
+	// Provider sets the upstream provider routing preferences.
+	Provider *OpenRouterProviderPreferences
+
+	// Transforms are prompt transforms such as "middle-out".
+	Transforms []string
+}
+
+// OpenRouterProviderPreferences controls which upstream providers OpenRouter
+// routes requests to.
+type OpenRouterProviderPreferences struct {
+	// Order lists providers to try first, e.g. ["anthropic", "openai"].
+	Order []string
+
+	// AllowFallbacks set to false restricts routing to Order (and Only).
+	AllowFallbacks *bool
+
+	// RequireParameters only routes to providers that support every
+	// request parameter, such as tools.
+	RequireParameters bool
+
+	// DataCollection is "allow" or "deny".
+	DataCollection string
+
+	// Only and Ignore allow or exclude providers by name.
+	Only   []string
+	Ignore []string
+
+	// Sort ranks providers by "price", "throughput", or "latency".
+	Sort string
+}
+
+// ToolCacheConfig configures per-execution memoization of tool results.
+// Repeated calls with the same tool, input, and working directory return the
+// cached result; write_file, bash, and other write-capable tools clear it.
+type ToolCacheConfig struct {
+	// Enabled turns on tool result caching.
+	Enabled bool
+
+	// Tools lists the cacheable tool names. Empty uses the read-only
+	// built-ins (read_file, list_files, git_status, git_diff, git_log,
+	// list_skills, read_skill).
+	Tools []string
+
+	// InvalidateOn lists tools that clear the cache when they run, in
+	// addition to tools implementing tools.WorkspaceWriter. Empty uses
+	// write_file, bash, rollback_last_changes, git_add, git_commit, and git_branch.
+	InvalidateOn []string
+}
+
+// ToolRetryConfig configures automatic retries of tool calls that fail with
+// a retryable error (tools.ToolResult.Retryable), such as a timeout or a
+// rate-limited GitHub API, before the failure is returned to the model.
+type ToolRetryConfig struct {
+	// MaxRetries is how many times a failed call is retried.
+	MaxRetries int
+
+	// Delay is the wait before the first retry (default 500ms); it doubles
+	// with each further attempt.
+	Delay time.Duration
+}
+
+// JSONCompressionConfig configures compression of large JSON tool
+// outputs. The model sees a shortened copy: arrays keep their first items,
+// objects their first keys, long strings are cut, and deeper levels are
+// replaced by their size. The full output is attached to the result as an
+// artifact (see AgentResult.Artifacts).
+type JSONCompressionConfig struct {
+	Enabled bool
+
+	// MinBytes is the output size from which JSON is compressed
+	// (default 16 KiB).
+	MinBytes int
+
+	// MaxDepth is how many levels of nesting are kept (default 4).
+	MaxDepth int
+
+	// MaxArrayItems is how many items of an array are kept (default 3).
+	MaxArrayItems int
+
+	// MaxKeys is how many keys of an object are kept (default 25).
+	MaxKeys int
+
+	// MaxStringBytes is how long a string value may be (default 200).
+	MaxStringBytes int
+
+	// Tools limits compression to the outputs of these tools. Empty
+	// compresses the output of every tool.
+	Tools []string
+}
+
+// LoopAction is what loop detection does about a loop.
+type LoopAction string
+
+const (
+	// LoopActionNudge appends a corrective note to the tool results so the
+	// model changes course.
+	LoopActionNudge LoopAction = "nudge"
+	// LoopActionAbort ends the run with a *StuckError.
+	LoopActionAbort LoopAction = "abort"
+)
+
+// LoopDetectionConfig configures detection of runs that repeat the same tool
+// call with identical input, or alternate between two sets of calls, instead
+// of making progress.
+type LoopDetectionConfig struct {
+	Enabled bool
+
+	// MaxRepeats is how many consecutive responses with the same tool calls
+	// and identical input count as a loop (default 3).
+	MaxRepeats int
+
+	// OscillationCycles is how many A, B alternations count as a loop
+	// (default 3).
+	OscillationCycles int
+
+	// Action is LoopActionNudge (default) or LoopActionAbort.
+	Action LoopAction
+
+	// MaxNudges is how many notes are sent before a further loop aborts the
+	// run with ErrAgentStuck (default 2). Negative never aborts.
+	MaxNudges int
+}
+
+// ToolChoiceMode selects how the model may use tools.
+type ToolChoiceMode string
+
+const (
+	// ToolChoiceAuto lets the model decide whether to call tools.
+	ToolChoiceAuto ToolChoiceMode = "auto"
+	// ToolChoiceAny requires a call to some tool.
+	ToolChoiceAny ToolChoiceMode = "any"
+	// ToolChoiceNone prevents tool calls.
+	ToolChoiceNone ToolChoiceMode = "none"
+	// ToolChoiceTool requires a call to the tool named by ToolChoice.Name.
+	ToolChoiceTool ToolChoiceMode = "tool"
+)
+
+// ToolChoice controls tool use on each model call of an execution (API
+// agents only). A forced mode (ToolChoiceAny or ToolChoiceTool) applies
+// until the model makes a call that satisfies it; later calls use
+// ToolChoiceAuto so the run can finish. Claude falls back to auto with
+// extended thinking, which does not allow forced tool use.
+type ToolChoice struct {
+	// Mode defaults to ToolChoiceAuto.
+	Mode ToolChoiceMode
+
+	// Name is the tool to call with ToolChoiceTool. It must be a
+	// registered tool or server tool.
+	Name string
+
+	// DisableParallelToolUse limits the model to one tool call per
+	// response (parallel_tool_calls=false on OpenAI-compatible backends).
+	DisableParallelToolUse bool
+}
+
+// PruneConfig selects built-in context pruning strategies. They shrink the
+// messages sent to the model before compaction and truncation run; the
+// conversation itself, and AgentResult, keep the full history.
+type PruneConfig struct {
+	// ToolResults replaces the content of older tool results with a short
+	// stub naming the tool. Tool calls and their results stay paired.
+	ToolResults bool
+
+	// Relevance drops older assistant turns whose text is not similar to
+	// the latest user message. Requires Embedder.
+	Relevance bool
+
+	// Threshold prunes only conversations with more than this many
+	// messages. Zero prunes before every model call.
+	Threshold int
+
+	// KeepRecent is the number of recent messages never pruned
+	// (default 10). The first message is always kept.
 	KeepRecent int
+
+	// MinResultBytes keeps tool results shorter than this (default 256).
+	MinResultBytes int
+
+	// Embedder embeds the task and turns for relevance pruning.
+	Embedder Embedder
+
+	// MinSimilarity is the cosine similarity to the task below which a
+	// turn is dropped (default 0.25).
+	MinSimilarity float64
+}
+
+// Embedder converts texts to embedding vectors, one per text.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// CompactRequest is the input to Compacter.Compact.
+type CompactRequest struct {
+	// Messages is the conversation to compact.
+	Messages []agenttypes.Message
+
+	// KeepRecent is the number of recent messages kept verbatim. Zero uses
+	// the agent's CompactConfig.KeepRecent, or 10.
+	KeepRecent int
+}
+
+// CompactResult is the outcome of Compacter.Compact.
+type CompactResult struct {
+	// Messages is the compacted conversation: the first message, the
+	// summary, and the recent messages. It equals the input when there was
+	// nothing to compact.
+	Messages []agenttypes.Message
+
+	// Summary is the generated summary text (empty if nothing was compacted).
+	Summary string
+
+	// Structured is the summary's structured form. The summary message keeps
+	// it in its metadata under CompactSummaryMetadataKey, so compacting the
+	// returned messages again updates it instead of re-summarizing it.
+	Structured *CompactSummary
+
+	// CompactedMessages is the number of messages replaced by the summary.
+	CompactedMessages int
+
+	// TokensBefore and TokensAfter are estimated conversation sizes.
+	TokensBefore int
+	TokensAfter  int
+}
+
+// CompactSummaryMetadataKey is the message metadata key of a compaction
+// summary. Keep metadata when storing compacted conversations.
+const CompactSummaryMetadataKey = "compact_summary"
+
+// CompactSummary is the structured state a compaction keeps in place of the
+// summarized messages.
+type CompactSummary struct {
+	Task         string
+	CurrentState string
+	FilesTouched []FileTouch
+	CommandsRun  []string
+	Decisions    []string
+	OpenTODOs    []string
+	Notes        string
+
+	// ToolCalls counts the summarized tool calls by tool name.
+	ToolCalls map[string]int
+
+	// Errors are the failed tool calls of the summarized messages, oldest
+	// first, kept verbatim across compactions.
+	Errors []ToolFailure
+
+	// Messages is the total number of messages summarized so far.
+	Messages int
+}
+
+// FileTouch is a file a summarized conversation worked with. Action is
+// read, created, modified, or deleted, or the tool name when it was only
+// seen in a tool call.
+type FileTouch struct {
+	Path   string
+	Action string
+	Note   string
+}
+
+// ToolFailure is a failed tool call kept in a compaction summary. Input is
+// the call's command or path, or its JSON input, and Count is how many times
+// the same call failed with the same error.
+type ToolFailure struct {
+	Tool  string
+	Input string
+	Error string
+	Count int
+}
+
+// TokensSaved returns the estimated token savings of the compaction.
+func (r CompactResult) TokensSaved() int {
+	return r.TokensBefore - r.TokensAfter
+}
+
+// MessageSpillConfig moves old conversation messages to append-only segment
+// files during long executions so the full history is not held in memory.
+// Spilled messages are read back only for compaction and AgentResult.
+type MessageSpillConfig struct {
+	// Dir is where per-execution segment directories are created.
+	Dir string
+
+	// KeepInMemory is the number of recent messages kept in memory
+	// (default 200, at least MaxMessages).
+	KeepInMemory int
+
+	// SegmentSize is the number of messages per segment file (default 100).
+	SegmentSize int
+
+	// Encryptor, when set, encrypts segment files at rest. See package
+	// encryption.
+	Encryptor *encryption.Encryptor
+}
+
+// ContextStage is the point in the context pipeline where a ContextTransform
+// runs. Before every model call the messages pass through TransformContext,
+// pruning, before_compaction, compaction, after_compaction, truncation,
+// after_truncation, tool pair validation, and after_validation. With
+// DisableDefaultContextRules the built-in rules are skipped but the stages
+// keep their order.
+type ContextStage string
+
+const (
+	ContextStageBeforeCompaction ContextStage = "before_compaction"
+	ContextStageAfterCompaction  ContextStage = "after_compaction"
+	ContextStageAfterTruncation  ContextStage = "after_truncation"
+	ContextStageAfterValidation  ContextStage = "after_validation"
+)
+
+// ContextTransform is a named context pipeline stage, e.g. to inject
+// retrieved documents after compaction or to drop messages the model
+// should not see.
+type ContextTransform struct {
+	// Name identifies the transform in errors and logs.
+	Name string
+
+	// Stage places the transform (default ContextStageBeforeCompaction).
+	Stage ContextStage
+
+	// Priority orders transforms within a stage; higher runs first and
+	// equal priorities keep their registration order.
+	Priority int
+
+	// Transform rewrites the messages. An error fails the execution.
+	Transform func(ctx context.Context, messages []agenttypes.Message) ([]agenttypes.Message, error)
+}
+
+// validateContextTransforms checks that transforms have a name, a known
+// stage, and a function, and that names are unique.
+func validateContextTransforms(transforms []ContextTransform) error {
+	seen := make(map[string]bool, len(transforms))
+	for _, t := range transforms {
+		if t.Name == "" {
+			return fmt.Errorf("context transform has no name")
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate context transform %q", t.Name)
+		}
+		seen[t.Name] = true
+		if t.Transform == nil {
+			return fmt.Errorf("context transform %q has no Transform function", t.Name)
+		}
+		switch t.Stage {
+		case "", ContextStageBeforeCompaction, ContextStageAfterCompaction,
+			ContextStageAfterTruncation, ContextStageAfterValidation:
+		default:
+			return fmt.Errorf("context transform %q has unknown stage %q", t.Name, t.Stage)
+		}
+	}
+	return nil
+}
+
+// Built-in system prompt section names. A ContextSection with one of these
+// names replaces the built-in section.
+const (
+	ContextSectionSystem           = "system"
+	ContextSectionSoul             = "soul"
+	ContextSectionRepoInstructions = "repository_instructions"
+)
+
+// ContextSection is one named part of the system prompt. The built-in
+// sections have priorities 300 (system), 200 (soul), and 100
+// (repository_instructions); higher priorities render first and are the
+// last to be cut when the prompt exceeds its byte budget.
+type ContextSection struct {
+	// Name identifies the section.
+	Name string
+
+	// Title, when set, is rendered as a "## Title" heading.
+	Title string
+
+	// Priority orders sections (higher first).
+	Priority int
+
+	// MaxBytes truncates the section's content. Zero means no limit.
+	MaxBytes int
+
+	// Content is the static section content.
+	Content string
+
+	// Refresh, when set, is called before every model call and its result
+	// replaces Content (e.g. live CI status). On error the previous
+	// content is kept.
+	Refresh func(ctx context.Context, snapshot LoopInputSnapshot) (string, error)
 }
 
 // AgentCallbacks provides hooks for monitoring agent execution.
@@ -102,17 +761,86 @@ type AgentCallbacks struct {
 	// OnToolResult is called when a tool returns a result.
 	OnToolResult func(name string, result tools.ToolResult)
 
+	// OnToolOutput is called with incremental output from streaming tools.
+	OnToolOutput func(name, toolCallID, chunk string)
+
 	// OnSteeringApplied is called when steering messages are injected.
 	OnSteeringApplied func(messages []agenttypes.Message)
 
 	// OnFollowUpApplied is called when follow-up messages are injected.
 	OnFollowUpApplied func(messages []agenttypes.Message)
 
-	// OnStreamDelta is called for incremental model text output.
+	// OnStreamDelta is called for incremental model text and tool call output.
 	OnStreamDelta func(delta agenttypes.ContentBlockDelta)
 
 	// OnIteration is called at the start of each iteration.
 	OnIteration func(iteration int)
+
+	// OnContextPressure is called when a request is estimated to exceed the
+	// context window threshold and the history was compacted or truncated.
+	OnContextPressure func(pressure ContextPressure)
+
+	// OnRateLimit is called before the provider waits out rate limiting
+	// (HTTP 429 or an overloaded API) and retries.
+	OnRateLimit func(wait RateLimitWait)
+
+	// OnUsageUpdate is called after each model response with the run's
+	// cumulative usage and estimated cost so far, e.g. for a live cost
+	// display or to cancel a run that exceeds a budget.
+	OnUsageUpdate func(usage ExecutionUsage)
+
+	// OnOutputGuard is called when the output guard redacts, rewrites, or
+	// blocks an assistant message. Streamed deltas of that message have
+	// already been delivered; the guarded version is the one committed.
+	OnOutputGuard func(verdict guard.Verdict)
+
+	// OnUserInputRequired is called when the ask_user tool asks the user a
+	// question. Answer it through the steering source (InputQueue or
+	// GetSteeringMessages).
+	OnUserInputRequired func(q tools.UserQuestion)
+}
+
+// RateLimitWait describes a provider retry delayed by rate limiting.
+type RateLimitWait struct {
+	// Iteration is the loop iteration whose model call was throttled.
+	Iteration int
+
+	// Provider is the LLM provider name (e.g. "claude").
+	Provider string
+
+	// Attempt is the failed attempt; the retry is Attempt+1 of MaxAttempts.
+	Attempt     int
+	MaxAttempts int
+
+	// Status is the HTTP status that triggered the wait.
+	Status int
+
+	// Wait is how long the provider sleeps before retrying.
+	Wait time.Duration
+}
+
+// ContextPressure describes a pre-flight context window relief.
+type ContextPressure struct {
+	// Iteration is the loop iteration that triggered relief.
+	Iteration int
+
+	// MaxContextTokens is the configured context window.
+	MaxContextTokens int
+
+	// ThresholdTokens is the window minus the configured margin.
+	ThresholdTokens int
+
+	// EstimatedTokens and ResultTokens are the estimates before and after relief.
+	EstimatedTokens int
+	ResultTokens    int
+
+	// MessagesBefore and MessagesAfter are the message counts before and after relief.
+	MessagesBefore int
+	MessagesAfter  int
+
+	// Compacted and Truncated report which relief steps ran.
+	Compacted bool
+	Truncated bool
 }
 
 // LoopInputSnapshot describes the current loop state for runtime input providers.
@@ -140,6 +868,10 @@ type AgentResult struct {
 	// FileChanges lists all file modifications made.
 	FileChanges []FileChange
 
+	// Patch is a git-style unified diff of every file change, applicable
+	// with git apply. Set with AgentOptions.Transactional or CollectPatch.
+	Patch string
+
 	// ToolCalls records all tool invocations.
 	ToolCalls []ToolCallRecord
 
@@ -148,6 +880,81 @@ type AgentResult struct {
 
 	// RawOutput contains the complete conversation (for debugging).
 	RawOutput []agenttypes.Message
+
+	// Workspace records file changes in transactional mode. Nil otherwise.
+	Workspace *workspace.Journal
+
+	// SessionID is the CLI session the execution ran in, when the CLI
+	// reports one. Pass it as AgentRequest.SessionID to continue it.
+	SessionID string
+
+	// Profile is the execution's timing and token profile when
+	// AgentOptions.Profile is set. Nil otherwise.
+	Profile *ExecutionProfile
+
+	// Metadata reports the provider, model, and stop reason of the
+	// execution and the provider's response IDs, for debugging.
+	Metadata ResultMetadata
+
+	// Outcome and OutcomeReason classify how the execution ended when an
+	// OutcomeClassifier is configured. Empty otherwise.
+	Outcome       Outcome
+	OutcomeReason string
+
+	// Artifacts are the outputs tools attached to the execution, e.g.
+	// with the attach_artifact tool, in the order they were attached.
+	Artifacts []Artifact
+}
+
+// Artifact is an output a tool attached to an execution. Its content is
+// available with Content or Open.
+type Artifact = tools.Artifact
+
+// ResultMetadata describes the provider responses behind an AgentResult.
+type ResultMetadata struct {
+	// StopReason is why the model stopped in the final response.
+	StopReason agenttypes.StopReason
+
+	// Model is the model that produced the final response, as reported by
+	// the provider.
+	Model string
+
+	// Provider is the provider name, e.g. "claude" or "openrouter".
+	Provider string
+
+	// Responses describes every model response, in order.
+	Responses []ResponseMetadata
+
+	// Retries is the total number of retried provider attempts.
+	Retries int
+}
+
+// ResponseMetadata describes one model response.
+type ResponseMetadata struct {
+	Iteration int
+
+	// ID is the provider's response ID.
+	ID string
+
+	Model      string
+	StopReason agenttypes.StopReason
+
+	// Retries is the number of failed attempts retried before the
+	// response arrived; RateLimitedRetries counts the rate-limited ones.
+	Retries            int
+	RateLimitedRetries int
+
+	// Repairs lists the defects repaired in a malformed response.
+	Repairs []ResponseRepair
+}
+
+// RollbackLastChanges reverts every file change made by the execution and
+// returns the restored paths. It requires AgentOptions.Transactional.
+func (r AgentResult) RollbackLastChanges() ([]string, error) {
+	if r.Workspace == nil {
+		return nil, ErrNotTransactional
+	}
+	return r.Workspace.RollbackAll()
 }
 
 // FileChange represents a file modification.
@@ -158,6 +965,13 @@ type FileChange struct {
 	// Content is the new file content.
 	Content string
 
+	// PreviousContent is the content before the execution. Empty for
+	// created files.
+	PreviousContent string
+
+	// Diff is this file's part of AgentResult.Patch.
+	Diff string
+
 	// Operation describes the change type.
 	Operation FileOperation
 }
@@ -185,8 +999,19 @@ type ToolCallRecord struct {
 	// IsError indicates if the tool returned an error.
 	IsError bool
 
+	// ErrorCode classifies a failed call (see tools.ToolResult.ErrorCode).
+	ErrorCode string
+
 	// Duration is how long the tool took to execute.
 	Duration time.Duration
+
+	// WorkDir is the directory the call ran in (per-call cwd or the
+	// current directory set by change_dir).
+	WorkDir string
+
+	// Command holds the exit code, stdout, stderr, and duration of a shell
+	// command run by bash or run_in_container. Nil for other tools.
+	Command *tools.CommandOutput
 }
 
 // ExecutionUsage contains resource usage statistics.
@@ -200,6 +1025,47 @@ type ExecutionUsage struct {
 	// TotalOutputTokens is the cumulative output token count.
 	TotalOutputTokens int
 
+	// TotalCachedInputTokens is the cumulative count of input tokens read
+	// from the provider's prompt cache.
+	TotalCachedInputTokens int
+
+	// EstimatedCost is the USD cost of the tokens at the model registry's
+	// prices (see models.Lookup); zero when the model's pricing is unknown.
+	// Cached input tokens are not priced separately.
+	EstimatedCost float64
+
 	// TotalDuration is the total execution time.
 	TotalDuration time.Duration
+
+	// ToolStats aggregates call counts and latency per tool name.
+	ToolStats map[string]ToolUsageStats
+
+	// ToolPairRepairs is the number of model calls whose context had
+	// tool_results without their tool_use and was repaired (see
+	// APIConfig.ToolPairRepair).
+	ToolPairRepairs int
+}
+
+// ToolUsageStats aggregates the calls of one tool within an execution.
+type ToolUsageStats struct {
+	// Calls is the number of calls the model made, including rejected ones.
+	Calls int
+
+	// Executed is the number of calls that actually ran the tool.
+	Executed int
+
+	// Errors is the number of calls that returned an error result.
+	Errors int
+
+	// CacheHits is the number of calls served from the tool cache.
+	CacheHits int
+
+	// Rejected is the number of calls refused before running (skill tool
+	// allowlist or an exhausted ToolBudgets entry).
+	Rejected int
+
+	// TotalDuration, AvgDuration, and MaxDuration cover executed calls.
+	TotalDuration time.Duration
+	AvgDuration   time.Duration
+	MaxDuration   time.Duration
 }
@@ -1,5 +1,7 @@
 package types
 
+import "encoding/json"
+
 // MessageRole identifies who produced a message.
 type MessageRole string
 
@@ -18,6 +20,15 @@ const (
 	ContentTypeText       ContentType = "text"
 	ContentTypeToolUse    ContentType = "tool_use"
 	ContentTypeToolResult ContentType = "tool_result"
+
+	// Claude extended thinking blocks; keep them in history unchanged.
+	ContentTypeThinking         ContentType = "thinking"
+	ContentTypeRedactedThinking ContentType = "redacted_thinking"
+
+	// Provider-run server tool call and web search result blocks; keep them
+	// in history unchanged.
+	ContentTypeServerToolUse       ContentType = "server_tool_use"
+	ContentTypeWebSearchToolResult ContentType = "web_search_tool_result"
 )
 
 // StopReason describes why the model stopped.
@@ -28,6 +39,7 @@ const (
 	StopReasonToolUse   StopReason = "tool_use"
 	StopReasonMaxTokens StopReason = "max_tokens"
 	StopReasonStopSeq   StopReason = "stop_sequence"
+	StopReasonPauseTurn StopReason = "pause_turn"
 )
 
 // ContentBlock is a unit of message content.
@@ -46,6 +58,17 @@ type ContentBlock struct {
 	ToolUseID string `json:"tool_use_id,omitempty"`
 	Content   string `json:"content,omitempty"`
 	IsError   bool   `json:"is_error,omitempty"`
+
+	// Thinking block fields.
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+
+	// Redacted thinking block payload.
+	Data string `json:"data,omitempty"`
+
+	// Server tool result payload (e.g. web search results), as returned
+	// by the provider.
+	ServerContent json.RawMessage `json:"server_content,omitempty"`
 }
 
 // Message is the public message model for agent callbacks/results.
@@ -53,16 +76,38 @@ type Message struct {
 	Role             MessageRole    `json:"role"`
 	Content          []ContentBlock `json:"content"`
 	ReasoningContent string         `json:"reasoning_content,omitempty"`
+
+	// Metadata holds caller-defined annotations (origin, visibility, pinning,
+	// redaction, ...). It survives conversions, compaction, and truncation and
+	// is never sent to the model.
+	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
 // LLMMessage is the provider-facing message model after convertToLlm.
 // It intentionally aliases Message so existing callers remain source-compatible.
 type LLMMessage = Message
 
+// ToolCallPhase identifies which part of a streamed tool call a delta carries.
+type ToolCallPhase string
+
+const (
+	ToolCallPhaseStart ToolCallPhase = "start"
+	ToolCallPhaseDelta ToolCallPhase = "delta"
+	ToolCallPhaseReady ToolCallPhase = "ready"
+)
+
 // ContentBlockDelta describes streamed content increments.
+// Text deltas only set Text; tool_use deltas set the tool call fields.
 type ContentBlockDelta struct {
 	Type ContentType `json:"type"`
 	Text string      `json:"text,omitempty"`
+
+	Phase        ToolCallPhase  `json:"phase,omitempty"`
+	Index        int            `json:"index,omitempty"`
+	ID           string         `json:"id,omitempty"`
+	Name         string         `json:"name,omitempty"`
+	PartialInput string         `json:"partial_input,omitempty"`
+	Input        map[string]any `json:"input,omitempty"`
 }
 
 // NewTextMessage creates a simple text message.
@@ -90,6 +135,17 @@ func NewToolResultMessage(toolUseID, content string, isError bool) Message {
 	}
 }
 
+// WithMetadata returns a copy of the message with key set in its metadata.
+func (m Message) WithMetadata(key string, value any) Message {
+	metadata := make(map[string]any, len(m.Metadata)+1)
+	for k, v := range m.Metadata {
+		metadata[k] = v
+	}
+	metadata[key] = value
+	m.Metadata = metadata
+	return m
+}
+
 // GetText concatenates text blocks using newlines.
 func (m Message) GetText() string {
 	result := ""
@@ -104,3 +160,18 @@ func (m Message) GetText() string {
 	}
 	return result
 }
+
+// GetThinking concatenates thinking blocks using newlines.
+func (m Message) GetThinking() string {
+	result := ""
+	for _, block := range m.Content {
+		if block.Type != ContentTypeThinking {
+			continue
+		}
+		if result != "" {
+			result += "\n"
+		}
+		result += block.Thinking
+	}
+	return result
+}
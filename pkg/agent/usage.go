@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// UsageReport describes the usage of an execution for metering and
+// billing. Reports with Final false are sent after each model response and
+// cover that response alone; the report with Final true is sent once when
+// the execution ends and covers all of it.
+type UsageReport struct {
+	// SessionID is AgentOptions.UsageSessionID, or the CLI session when
+	// that is empty.
+	SessionID string
+
+	// Labels are AgentOptions.UsageLabels, e.g. {"tenant": "acme"}.
+	Labels map[string]string
+
+	// Provider and Model produced the response, or the final response for
+	// a final report.
+	Provider string
+	Model    string
+
+	// Iteration is the loop iteration of the response, or the number of
+	// iterations for a final report.
+	Iteration int
+
+	InputTokens       int
+	OutputTokens      int
+	CachedInputTokens int
+
+	// Cost is the estimated USD cost (see ExecutionUsage.EstimatedCost).
+	Cost float64
+
+	// Duration is the time since the execution started.
+	Duration time.Duration
+
+	// Final marks the end-of-execution report. Success and Error describe
+	// how the execution ended and are only set on it.
+	Final   bool
+	Success bool
+	Error   string
+
+	// Time is when the report was made.
+	Time time.Time
+}
+
+// UsageReporter receives usage reports. Set one with
+// AgentOptions.UsageReporter or APIConfig.UsageReporter. Reports are
+// delivered synchronously on the execution's goroutine, so reporters that
+// do I/O should hand them off (see WebhookUsageReporter). Errors are
+// logged and do not fail the execution.
+type UsageReporter interface {
+	ReportUsage(ctx context.Context, report UsageReport) error
+}
+
+// UsageReporterFunc adapts a function to UsageReporter.
+type UsageReporterFunc func(ctx context.Context, report UsageReport) error
+
+// ReportUsage calls f.
+func (f UsageReporterFunc) ReportUsage(ctx context.Context, report UsageReport) error {
+	return f(ctx, report)
+}
+
+// reportUsage sends report to reporter with the request's session ID and
+// labels. The final report is sent even when ctx was cancelled.
+func reportUsage(ctx context.Context, reporter UsageReporter, opts AgentOptions, report UsageReport) {
+	if reporter == nil {
+		return
+	}
+	if opts.UsageSessionID != "" {
+		report.SessionID = opts.UsageSessionID
+	}
+	report.Labels = opts.UsageLabels
+	report.Time = time.Now()
+	if report.Final {
+		ctx = context.WithoutCancel(ctx)
+	}
+	if err := reporter.ReportUsage(ctx, report); err != nil {
+		log.Printf("[agent] WARNING: usage report failed: %v", err)
+	}
+}
+
+// finalUsageReport is the end-of-execution report for result.
+func finalUsageReport(result AgentResult, err error) UsageReport {
+	report := UsageReport{
+		SessionID:         result.SessionID,
+		Provider:          result.Metadata.Provider,
+		Model:             result.Metadata.Model,
+		Iteration:         result.Usage.TotalIterations,
+		InputTokens:       result.Usage.TotalInputTokens,
+		OutputTokens:      result.Usage.TotalOutputTokens,
+		CachedInputTokens: result.Usage.TotalCachedInputTokens,
+		Cost:              result.Usage.EstimatedCost,
+		Duration:          result.Usage.TotalDuration,
+		Final:             true,
+		Success:           result.Success && err == nil,
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
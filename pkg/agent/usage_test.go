@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// meteredProvider answers every call with fixed token usage.
+type meteredProvider struct{}
+
+func (meteredProvider) Name() string { return "metered" }
+
+func (meteredProvider) Call(_ context.Context, _ llm.AgentRequest) (llm.AgentResponse, error) {
+	return llm.AgentResponse{
+		Role:       llm.RoleAssistant,
+		Model:      "gpt-4o",
+		StopReason: llm.StopReasonEndTurn,
+		Content:    []llm.ContentBlock{{Type: llm.ContentTypeText, Text: "ok"}},
+		Usage:      llm.Usage{InputTokens: 1000, OutputTokens: 100},
+	}, nil
+}
+
+func TestAPIAgentExecuteReportsUsage(t *testing.T) {
+	var reports []UsageReport
+	reporter := UsageReporterFunc(func(_ context.Context, report UsageReport) error {
+		reports = append(reports, report)
+		return nil
+	})
+	a := NewAPIAgent(meteredProvider{}, tools.NewRegistry(), APIAgentOptions{UsageReporter: reporter})
+
+	result, err := a.Execute(context.Background(), AgentRequest{
+		Task: "hello",
+		Options: AgentOptions{
+			UsageSessionID: "s1",
+			UsageLabels:    map[string]string{"tenant": "acme"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("got %d reports, want a per-response and a final one", len(reports))
+	}
+	step, final := reports[0], reports[1]
+	if step.Final || step.Iteration != 1 || step.InputTokens != 1000 || step.Provider != "metered" || step.Model != "gpt-4o" {
+		t.Fatalf("per-response report = %+v", step)
+	}
+	if !final.Final || !final.Success || final.OutputTokens != 100 || final.Cost != result.Usage.EstimatedCost || final.Cost == 0 {
+		t.Fatalf("final report = %+v, result usage = %+v", final, result.Usage)
+	}
+	for _, report := range reports {
+		if report.SessionID != "s1" || report.Labels["tenant"] != "acme" || report.Time.IsZero() {
+			t.Fatalf("report missing session or labels: %+v", report)
+		}
+	}
+}
+
+func TestWebhookUsageReporterRetriesAndDropsIterations(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if r.Header.Get("Authorization") != "Bearer t" {
+			t.Errorf("missing configured header")
+		}
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+	}))
+	defer srv.Close()
+
+	reporter, err := NewWebhookUsageReporter(WebhookUsageConfig{
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "Bearer t"},
+		Backoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookUsageReporter() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := reporter.ReportUsage(ctx, UsageReport{Iteration: 1, InputTokens: 5}); err != nil {
+		t.Fatalf("ReportUsage() error = %v", err)
+	}
+	if err := reporter.ReportUsage(ctx, UsageReport{Final: true, SessionID: "s1", InputTokens: 7, Duration: 2 * time.Second}); err != nil {
+		t.Fatalf("ReportUsage() error = %v", err)
+	}
+	reporter.Close()
+	if err := reporter.ReportUsage(ctx, UsageReport{Final: true}); err != ErrUsageReporterClosed {
+		t.Fatalf("ReportUsage() after Close error = %v, want ErrUsageReporterClosed", err)
+	}
+
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Fatalf("expected one retried delivery with a stable idempotency key, got %q", keys)
+	}
+	if len(bodies) != 1 || bodies[0]["session_id"] != "s1" || bodies[0]["input_tokens"] != float64(7) || bodies[0]["duration_ms"] != float64(2000) {
+		t.Fatalf("delivered bodies = %v", bodies)
+	}
+}
+
+func TestWebhookUsageReporterDoesNotRetryClientErrors(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	reporter, err := NewWebhookUsageReporter(WebhookUsageConfig{URL: srv.URL, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewWebhookUsageReporter() error = %v", err)
+	}
+	if err := reporter.ReportUsage(context.Background(), UsageReport{Final: true}); err != nil {
+		t.Fatalf("ReportUsage() error = %v", err)
+	}
+	reporter.Close()
+	if calls != 1 {
+		t.Fatalf("got %d attempts, want 1", calls)
+	}
+}
@@ -0,0 +1,235 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUsageWebhookAttempts  = 5
+	defaultUsageWebhookBackoff   = time.Second
+	defaultUsageWebhookTimeout   = 10 * time.Second
+	defaultUsageWebhookQueueSize = 1000
+)
+
+// ErrUsageReporterClosed is returned by WebhookUsageReporter.ReportUsage
+// after Close.
+var ErrUsageReporterClosed = errors.New("usage reporter is closed")
+
+// WebhookUsageConfig configures a WebhookUsageReporter.
+type WebhookUsageConfig struct {
+	// URL receives each report as a JSON POST. Required.
+	URL string
+
+	// Headers are added to every request, e.g. Authorization.
+	Headers map[string]string
+
+	// PerIteration also posts the per-response reports. By default only
+	// the final report of each execution is posted.
+	PerIteration bool
+
+	// MaxAttempts bounds delivery attempts per report (default 5).
+	// Transport errors, 429, and 5xx responses are retried; other
+	// responses are not.
+	MaxAttempts int
+
+	// Backoff is the wait before the first retry, doubled for each later
+	// one (default 1s).
+	Backoff time.Duration
+
+	// Timeout bounds each attempt (default 10s).
+	Timeout time.Duration
+
+	// QueueSize is the number of reports buffered for delivery (default
+	// 1000). ReportUsage blocks while the queue is full.
+	QueueSize int
+
+	// Client sends the requests. Nil uses a client with Timeout.
+	Client *http.Client
+}
+
+// WebhookUsageReporter posts usage reports to a webhook from a background
+// goroutine, so executions do not wait for delivery. Each request carries
+// an Idempotency-Key header that stays the same across retries. Call Close
+// to deliver the queued reports before exiting.
+type WebhookUsageReporter struct {
+	cfg   WebhookUsageConfig
+	queue chan usageWebhookPayload
+	done  chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// usageWebhookPayload is the JSON body of a webhook request.
+type usageWebhookPayload struct {
+	ID                string            `json:"id"`
+	SessionID         string            `json:"session_id,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Provider          string            `json:"provider,omitempty"`
+	Model             string            `json:"model,omitempty"`
+	Iteration         int               `json:"iteration"`
+	InputTokens       int               `json:"input_tokens"`
+	OutputTokens      int               `json:"output_tokens"`
+	CachedInputTokens int               `json:"cached_input_tokens"`
+	CostUSD           float64           `json:"cost_usd"`
+	DurationMs        int64             `json:"duration_ms"`
+	Final             bool              `json:"final"`
+	Success           bool              `json:"success"`
+	Error             string            `json:"error,omitempty"`
+	Time              time.Time         `json:"time"`
+}
+
+// NewWebhookUsageReporter starts a reporter posting to cfg.URL.
+func NewWebhookUsageReporter(cfg WebhookUsageConfig) (*WebhookUsageReporter, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("usage webhook URL is required")
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultUsageWebhookAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultUsageWebhookBackoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultUsageWebhookTimeout
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultUsageWebhookQueueSize
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+	r := &WebhookUsageReporter{
+		cfg:   cfg,
+		queue: make(chan usageWebhookPayload, cfg.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go r.run()
+	return r, nil
+}
+
+// ReportUsage queues report for delivery. Per-response reports are dropped
+// unless PerIteration is set.
+func (r *WebhookUsageReporter) ReportUsage(ctx context.Context, report UsageReport) error {
+	if !report.Final && !r.cfg.PerIteration {
+		return nil
+	}
+	payload := usageWebhookPayload{
+		ID:                newUsageReportID(),
+		SessionID:         report.SessionID,
+		Labels:            report.Labels,
+		Provider:          report.Provider,
+		Model:             report.Model,
+		Iteration:         report.Iteration,
+		InputTokens:       report.InputTokens,
+		OutputTokens:      report.OutputTokens,
+		CachedInputTokens: report.CachedInputTokens,
+		CostUSD:           report.Cost,
+		DurationMs:        report.Duration.Milliseconds(),
+		Final:             report.Final,
+		Success:           report.Success,
+		Error:             report.Error,
+		Time:              report.Time,
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return ErrUsageReporterClosed
+	}
+	select {
+	case r.queue <- payload:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting reports and waits until the queued ones are
+// delivered or give up.
+func (r *WebhookUsageReporter) Close() error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+	<-r.done
+	return nil
+}
+
+func (r *WebhookUsageReporter) run() {
+	defer close(r.done)
+	for payload := range r.queue {
+		if err := r.deliver(payload); err != nil {
+			log.Printf("[usage-webhook] ERROR: dropping usage report %s: %v", payload.ID, err)
+		}
+	}
+}
+
+// deliver posts payload, retrying transient failures.
+func (r *WebhookUsageReporter) deliver(payload usageWebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	backoff := r.cfg.Backoff
+	var lastErr error
+	for attempt := 1; attempt <= r.cfg.MaxAttempts; attempt++ {
+		retry, err := r.post(body, payload.ID)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry || attempt == r.cfg.MaxAttempts {
+			break
+		}
+		log.Printf("[usage-webhook] attempt %d/%d failed, retrying in %v: %v", attempt, r.cfg.MaxAttempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return lastErr
+}
+
+// post sends one attempt and reports whether a failure is worth retrying.
+func (r *WebhookUsageReporter) post(body []byte, id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", id)
+	for name, value := range r.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := r.cfg.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
+
+func newUsageReportID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
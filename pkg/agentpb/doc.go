@@ -0,0 +1,6 @@
+// Package agentpb contains the generated gRPC bindings for
+// proto/agentcore/v1/agent.proto. controller.AgentService implements the
+// server; use NewAgentServiceClient to call it.
+//
+// Regenerate with `make proto` after editing the .proto file.
+package agentpb
@@ -0,0 +1,271 @@
+// Package client is a Go client for the chat server in pkg/controller.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/controller"
+)
+
+// Config holds client configuration.
+type Config struct {
+	// BaseURL is the server address, e.g. "http://localhost:8080".
+	BaseURL string
+
+	// HTTPClient is used for requests. Defaults to http.DefaultClient.
+	// Streaming requests run until the agent finishes, so avoid a short
+	// Client.Timeout and use context deadlines instead.
+	HTTPClient *http.Client
+
+	// AdminToken is sent as a bearer token to admin endpoints (Sessions,
+	// Messages, Trace, Compact, Artifacts, and DownloadArtifact).
+	AdminToken string
+}
+
+// Client calls the chat server's HTTP API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	adminToken string
+}
+
+// APIError is returned when the server responds with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	controller.ErrorResponse
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("chat server: %d %s: %s", e.StatusCode, e.Code, e.ErrorResponse.Error)
+	}
+	return fmt.Sprintf("chat server: %d: %s", e.StatusCode, e.ErrorResponse.Error)
+}
+
+// New creates a Client.
+func New(cfg Config) *Client {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		httpClient: httpClient,
+		adminToken: cfg.AdminToken,
+	}
+}
+
+// Chat runs the agent and returns its final reply.
+func (c *Client) Chat(ctx context.Context, req controller.ChatRequest) (*controller.ChatResponse, error) {
+	var resp controller.ChatResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/chat", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ChatStream runs the agent and streams its events. The caller must Close
+// the returned stream.
+func (c *Client) ChatStream(ctx context.Context, req controller.ChatRequest) (*Stream, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	httpReq, err := c.newRequest(ctx, http.MethodPost, "/api/chat/stream", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return c.openStream(httpReq, "")
+}
+
+// ResumeStream reattaches to a streaming run, replaying events after
+// lastEventID (or from the start when it is empty).
+func (c *Client) ResumeStream(ctx context.Context, runID, lastEventID string) (*Stream, error) {
+	httpReq, err := c.newRequest(ctx, http.MethodGet, "/api/chat/stream/"+url.PathEscape(runID), nil)
+	if err != nil {
+		return nil, err
+	}
+	if lastEventID != "" {
+		httpReq.Header.Set("Last-Event-ID", lastEventID)
+	}
+	return c.openStream(httpReq, lastEventID)
+}
+
+// CancelRun cancels an in-progress streaming run.
+func (c *Client) CancelRun(ctx context.Context, runID string) (*controller.CancelResponse, error) {
+	var resp controller.CancelResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/chat/stream/"+url.PathEscape(runID)+"/cancel", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Reply answers a run's pending ask_user question (a user_input_required
+// event), or steers its next turn when no question is pending.
+func (c *Client) Reply(ctx context.Context, runID, message string) (*controller.ReplyResponse, error) {
+	var resp controller.ReplyResponse
+	req := controller.ReplyRequest{Message: message}
+	if err := c.doJSON(ctx, http.MethodPost, "/api/chat/stream/"+url.PathEscape(runID)+"/reply", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Compact summarizes a session's stored history now. A keepRecent of zero
+// uses the agent's default. It needs Config.AdminToken.
+func (c *Client) Compact(ctx context.Context, sessionID string, keepRecent int) (*controller.CompactResponse, error) {
+	var resp controller.CompactResponse
+	req := controller.CompactRequest{KeepRecent: keepRecent}
+	if err := c.doJSON(ctx, http.MethodPost, "/api/chat/"+url.PathEscape(sessionID)+"/compact", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Messages returns a page of a session's stored conversation. A negative
+// offset counts from the end; a limit of zero uses the server default. It
+// needs Config.AdminToken.
+func (c *Client) Messages(ctx context.Context, sessionID string, offset, limit int) (*controller.MessagesResponse, error) {
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(offset))
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var resp controller.MessagesResponse
+	path := "/api/chat/" + url.PathEscape(sessionID) + "/messages?" + query.Encode()
+	if err := c.doJSON(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Trace renders a session's conversation as a diagram. format is
+// "mermaid" or "dot"; empty uses the server default, mermaid. It needs
+// Config.AdminToken.
+func (c *Client) Trace(ctx context.Context, sessionID, format string) (*controller.TraceResponse, error) {
+	path := "/api/sessions/" + url.PathEscape(sessionID) + "/trace"
+	if format != "" {
+		path += "?format=" + url.QueryEscape(format)
+	}
+	var resp controller.TraceResponse
+	if err := c.doJSON(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Artifacts lists the artifacts tools attached to a session's runs. It
+// needs Config.AdminToken.
+func (c *Client) Artifacts(ctx context.Context, sessionID string) ([]controller.ArtifactInfo, error) {
+	var resp controller.ArtifactsResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/sessions/"+url.PathEscape(sessionID)+"/artifacts", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Artifacts, nil
+}
+
+// DownloadArtifact returns a reader over an artifact's content. The caller
+// closes it. It needs Config.AdminToken.
+func (c *Client) DownloadArtifact(ctx context.Context, sessionID, artifactID string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/chat/"+url.PathEscape(sessionID)+"/artifacts/"+url.PathEscape(artifactID), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, decodeAPIError(resp)
+	}
+	return resp.Body, nil
+}
+
+// Sessions lists live sessions and their usage.
+func (c *Client) Sessions(ctx context.Context) ([]controller.SessionInfo, error) {
+	var resp controller.SessionsResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/api/sessions", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Sessions, nil
+}
+
+// Health checks that the server is up.
+func (c *Client) Health(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodGet, "/healthz", nil, nil)
+}
+
+// Ready checks that the server's agents can reach their providers with
+// valid credentials. When they cannot, it returns an *APIError with status
+// 503 whose message is the readiness report.
+func (c *Client) Ready(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodGet, "/readyz", nil, nil)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+	return req, nil
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return decodeAPIError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func decodeAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(data, &apiErr.ErrorResponse); err != nil || apiErr.ErrorResponse.Error == "" {
+		apiErr.ErrorResponse.Error = strings.TrimSpace(string(data))
+		if apiErr.ErrorResponse.Error == "" {
+			apiErr.ErrorResponse.Error = resp.Status
+		}
+	}
+	return apiErr
+}
@@ -0,0 +1,318 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/controller"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// stubAgent implements agent.Agent for testing. When block is set, streams
+// emit their events and then wait for cancellation.
+type stubAgent struct {
+	result    agent.AgentResult
+	err       error
+	stream    []agent.AgentStreamEvent
+	streamErr error
+	block     bool
+}
+
+func (s *stubAgent) Execute(_ context.Context, _ agent.AgentRequest) (agent.AgentResult, error) {
+	return s.result, s.err
+}
+
+func (s *stubAgent) Capabilities() agent.AgentCapabilities {
+	return agent.AgentCapabilities{}
+}
+
+func (s *stubAgent) ExecuteStream(ctx context.Context, _ agent.AgentRequest) (<-chan agent.AgentStreamEvent, <-chan error) {
+	eventCh := make(chan agent.AgentStreamEvent, len(s.stream))
+	errCh := make(chan error, 1)
+	for _, evt := range s.stream {
+		eventCh <- evt
+	}
+	go func() {
+		defer close(eventCh)
+		defer close(errCh)
+		if s.block {
+			<-ctx.Done()
+			return
+		}
+		if s.streamErr != nil {
+			errCh <- s.streamErr
+		}
+	}()
+	return eventCh, errCh
+}
+
+func (s *stubAgent) Close() error { return nil }
+
+func newTestServer(t *testing.T, a agent.Agent, cfg controller.ChatConfig) *Client {
+	t.Helper()
+	cfg.EnableStreaming = true
+	cfg.DefaultDir = t.TempDir()
+	mux := http.NewServeMux()
+	controller.NewChatController(a, cfg).RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return New(Config{BaseURL: srv.URL + "/", AdminToken: cfg.AdminToken})
+}
+
+func TestClientChat(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{
+		Message: "hi there",
+		Usage:   agent.ExecutionUsage{TotalIterations: 1, TotalInputTokens: 10, TotalOutputTokens: 3},
+	}}
+	c := newTestServer(t, stub, controller.ChatConfig{AdminToken: "secret"})
+	ctx := context.Background()
+
+	if err := c.Health(ctx); err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	resp, err := c.Chat(ctx, controller.ChatRequest{Message: "hello", SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Reply != "hi there" || resp.SessionID != "s1" || resp.Usage.OutputTokens != 3 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	sessions, err := c.Sessions(ctx)
+	if err != nil {
+		t.Fatalf("Sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != "s1" || sessions[0].Runs != 1 {
+		t.Fatalf("unexpected sessions: %+v", sessions)
+	}
+}
+
+func TestClientChat_APIError(t *testing.T) {
+	c := newTestServer(t, &stubAgent{}, controller.ChatConfig{})
+
+	_, err := c.Chat(context.Background(), controller.ChatRequest{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.ErrorResponse.Error != "message is required" {
+		t.Fatalf("unexpected error: %+v", apiErr)
+	}
+}
+
+func TestClientChatStream(t *testing.T) {
+	stub := &stubAgent{stream: []agent.AgentStreamEvent{
+		{Type: agent.AgentEventAgentStart},
+		{Type: agent.AgentEventMessageDelta, Delta: "Hel"},
+		{Type: agent.AgentEventMessageDelta, Delta: "lo"},
+		{Type: agent.AgentEventAgentEnd},
+	}}
+	c := newTestServer(t, stub, controller.ChatConfig{})
+	ctx := context.Background()
+
+	stream, err := c.ChatStream(ctx, controller.ChatRequest{Message: "hello"})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+	if stream.RunID == "" {
+		t.Fatal("expected run ID")
+	}
+
+	var text string
+	var secondID string
+	for i := 0; ; i++ {
+		evt, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if i == 1 {
+			secondID = evt.ID
+		}
+		agentEvt, err := evt.AgentEvent()
+		if err != nil {
+			t.Fatalf("AgentEvent: %v", err)
+		}
+		text += agentEvt.Delta
+	}
+	if text != "Hello" {
+		t.Fatalf("expected Hello, got %q", text)
+	}
+
+	resumed, err := c.ResumeStream(ctx, stream.RunID, secondID)
+	if err != nil {
+		t.Fatalf("ResumeStream: %v", err)
+	}
+	defer resumed.Close()
+	evt, err := resumed.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if agentEvt, _ := evt.AgentEvent(); agentEvt.Delta != "lo" {
+		t.Fatalf("expected resume after %s to start at \"lo\", got %s", secondID, evt.Data)
+	}
+}
+
+func TestClientChatStream_GzipAndChunkedEvents(t *testing.T) {
+	long := strings.Repeat("héllo ", 100)
+	stub := &stubAgent{stream: []agent.AgentStreamEvent{
+		{Type: agent.AgentEventAgentStart},
+		{Type: agent.AgentEventMessageDelta, Delta: long},
+		{Type: agent.AgentEventAgentEnd},
+	}}
+	c := newTestServer(t, stub, controller.ChatConfig{SSE: controller.SSEConfig{Gzip: true, MaxEventBytes: 100}})
+
+	stream, err := c.ChatStream(context.Background(), controller.ChatRequest{Message: "hello"})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	var names []string
+	var text string
+	for {
+		evt, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		names = append(names, evt.Name)
+		agentEvt, err := evt.AgentEvent()
+		if err != nil {
+			t.Fatalf("AgentEvent %s: %v", evt.Data, err)
+		}
+		text += agentEvt.Delta
+	}
+	if strings.Join(names, ",") != "agent_start,message_delta,agent_end" || text != long {
+		t.Fatalf("expected the chunked delta to be reassembled, got %v %q", names, text)
+	}
+	if !strings.HasSuffix(stream.LastEventID(), ":3") {
+		t.Fatalf("expected last event ID to count whole events, got %q", stream.LastEventID())
+	}
+}
+
+func TestClientChatStream_Error(t *testing.T) {
+	stub := &stubAgent{streamErr: errors.New("boom")}
+	c := newTestServer(t, stub, controller.ChatConfig{})
+
+	stream, err := c.ChatStream(context.Background(), controller.ChatRequest{Message: "hello"})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	_, err = stream.Next()
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) || streamErr.Message != "boom" {
+		t.Fatalf("expected StreamError boom, got %v", err)
+	}
+}
+
+func TestClientCancelRun(t *testing.T) {
+	stub := &stubAgent{stream: []agent.AgentStreamEvent{{Type: agent.AgentEventAgentStart}}, block: true}
+	c := newTestServer(t, stub, controller.ChatConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := c.ChatStream(ctx, controller.ChatRequest{Message: "hello"})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Next(); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+
+	resp, err := c.CancelRun(ctx, stream.RunID)
+	if err != nil {
+		t.Fatalf("CancelRun: %v", err)
+	}
+	if !resp.Cancelled || resp.RunID != stream.RunID {
+		t.Fatalf("unexpected cancel response: %+v", resp)
+	}
+	for {
+		if _, err := stream.Next(); err != nil {
+			if err != io.EOF {
+				t.Fatalf("expected stream to end after cancel, got %v", err)
+			}
+			break
+		}
+	}
+
+	_, err = c.CancelRun(ctx, "run_missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != controller.ErrCodeStreamNotFound {
+		t.Fatalf("expected %s, got %v", controller.ErrCodeStreamNotFound, err)
+	}
+}
+
+func TestClientMessages(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{
+		Message: "hi there",
+		RawOutput: []agenttypes.Message{
+			agenttypes.NewTextMessage(agenttypes.RoleUser, "hello"),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "hi there"),
+		},
+	}}
+	c := newTestServer(t, stub, controller.ChatConfig{AdminToken: "secret"})
+	ctx := context.Background()
+
+	if _, err := c.Chat(ctx, controller.ChatRequest{Message: "hello", SessionID: "s1"}); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	page, err := c.Messages(ctx, "s1", -1, 0)
+	if err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	if page.Total != 2 || page.Offset != 1 || len(page.Messages) != 1 || page.Messages[0].Content[0].Text != "hi there" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+
+	_, err = c.Messages(ctx, "missing", 0, 0)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 APIError, got %v", err)
+	}
+}
+
+func TestClientArtifacts(t *testing.T) {
+	artifacts := tools.NewArtifacts()
+	artifacts.Add("build.log", "text/plain", "", []byte("ok\n"))
+	c := newTestServer(t, &stubAgent{result: agent.AgentResult{Message: "built", Artifacts: artifacts.List()}}, controller.ChatConfig{AdminToken: "secret"})
+	ctx := context.Background()
+
+	if _, err := c.Chat(ctx, controller.ChatRequest{Message: "build", SessionID: "s1"}); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	list, err := c.Artifacts(ctx, "s1")
+	if err != nil || len(list) != 1 || list[0].Name != "build.log" {
+		t.Fatalf("Artifacts = %+v, %v", list, err)
+	}
+	body, err := c.DownloadArtifact(ctx, "s1", list[0].ID)
+	if err != nil {
+		t.Fatalf("DownloadArtifact: %v", err)
+	}
+	defer body.Close()
+	if data, _ := io.ReadAll(body); string(data) != "ok\n" {
+		t.Fatalf("downloaded %q", data)
+	}
+
+	_, err = c.DownloadArtifact(ctx, "s1", "art_missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != controller.ErrCodeArtifactNotFound {
+		t.Fatalf("expected artifact_not_found, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/controller"
+)
+
+// Event is one server-sent event from a chat stream.
+type Event struct {
+	// ID is "<run_id>:<seq>"; pass it to ResumeStream to continue after it.
+	ID string
+	// Name is the SSE event name (the agent event type, or "message" for errors).
+	Name string
+	// Data is the JSON payload.
+	Data json.RawMessage
+}
+
+// Decode unmarshals the event payload into v.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// AgentEvent decodes the payload as an agent stream event.
+func (e Event) AgentEvent() (agent.AgentStreamEvent, error) {
+	var evt agent.AgentStreamEvent
+	err := json.Unmarshal(e.Data, &evt)
+	return evt, err
+}
+
+// StreamError is returned by Stream.Next when the server reports that the
+// agent run failed.
+type StreamError struct {
+	Message string
+}
+
+func (e *StreamError) Error() string {
+	return "agent stream error: " + e.Message
+}
+
+// Stream reads events from a streaming chat run.
+type Stream struct {
+	// RunID identifies the run for ResumeStream and CancelRun.
+	RunID string
+
+	body        io.ReadCloser
+	reader      *bufio.Reader
+	lastEventID string
+
+	// chunks holds the data of the chunk events received for the next
+	// event (see controller.SSEConfig.MaxEventBytes).
+	chunks strings.Builder
+}
+
+func (c *Client) openStream(req *http.Request, lastEventID string) (*Stream, error) {
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, decodeAPIError(resp)
+	}
+	return &Stream{
+		RunID:       resp.Header.Get("X-Run-ID"),
+		body:        resp.Body,
+		reader:      bufio.NewReader(resp.Body),
+		lastEventID: lastEventID,
+	}, nil
+}
+
+// Next returns the next event. It returns io.EOF when the run has finished
+// and a *StreamError when the server reports an agent error. Any other error
+// means the connection dropped; resume with ResumeStream(RunID, LastEventID()).
+func (s *Stream) Next() (Event, error) {
+	var evt Event
+	var data strings.Builder
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) && line == "" && data.Len() == 0 && evt.ID == "" {
+				return Event{}, io.EOF
+			}
+			if errors.Is(err, io.EOF) {
+				return Event{}, io.ErrUnexpectedEOF
+			}
+			return Event{}, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if data.Len() == 0 && evt.ID == "" && evt.Name == "" {
+				continue
+			}
+			if evt.Name == controller.SSEChunkEvent {
+				s.chunks.WriteString(data.String())
+				evt = Event{}
+				data.Reset()
+				continue
+			}
+			evt.Data = json.RawMessage(s.chunks.String() + data.String())
+			s.chunks.Reset()
+			if evt.ID != "" {
+				s.lastEventID = evt.ID
+			}
+			return evt, streamErr(evt)
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			evt.ID = value
+		case "event":
+			evt.Name = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+}
+
+// LastEventID returns the ID of the last event received.
+func (s *Stream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Close closes the connection. The run keeps going on the server until it
+// finishes, is cancelled, or no client reattaches within the detach timeout.
+func (s *Stream) Close() error {
+	return s.body.Close()
+}
+
+func streamErr(evt Event) error {
+	if evt.Name != "message" {
+		return nil
+	}
+	var payload struct {
+		Type  string `json:"type"`
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(evt.Data, &payload) == nil && payload.Type == "error" {
+		return &StreamError{Message: payload.Error}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+// Package commands implements slash commands ("/help", "/model gpt-4.1")
+// that the chat layer handles itself instead of sending them to the model.
+//
+// Unlike skills and plugin commands, which render a prompt for the agent, a
+// Command runs Go code: it can answer directly, change session state, or
+// rewrite the message the agent receives. controller.ChatController
+// dispatches registered commands and provides the built-in ones; embedders
+// register their own on a Registry.
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MimeLyc/agent-core-go/pkg/skills"
+)
+
+// Command is a slash command.
+type Command struct {
+	// Name is the command name without the leading slash.
+	Name string
+
+	// Description is a short summary shown by /help.
+	Description string
+
+	// Usage documents the arguments, e.g. "<model>". Optional.
+	Usage string
+
+	// Handler runs the command.
+	Handler Handler
+}
+
+// Handler runs a command invocation.
+type Handler func(ctx context.Context, inv Invocation) (Result, error)
+
+// Invocation is a parsed command and the chat context it was sent in.
+type Invocation struct {
+	// Name is the command name as typed, without the leading slash.
+	Name string
+
+	// Args is the rest of the first line after the name.
+	Args string
+
+	// Message is the full message that invoked the command.
+	Message string
+
+	SessionID string
+	WorkDir   string
+	Profile   string
+}
+
+// Result is a command's outcome.
+type Result struct {
+	// Reply is returned to the user without running the agent.
+	Reply string
+
+	// Prompt, when set, runs the agent with Prompt as the message instead
+	// of replying directly.
+	Prompt string
+}
+
+// Registry holds commands by name. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[string]Command
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{commands: make(map[string]Command)}
+}
+
+// Register adds a command. Names must be valid slash names and unique.
+func (r *Registry) Register(cmd Command) error {
+	if cmd.Handler == nil {
+		return fmt.Errorf("command %q has no handler", cmd.Name)
+	}
+	if name, _, ok := skills.ParseSlashSkillCommand("/" + cmd.Name); !ok || name != cmd.Name {
+		return fmt.Errorf("invalid command name %q", cmd.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.commands[cmd.Name]; exists {
+		return fmt.Errorf("command %q already registered", cmd.Name)
+	}
+	r.commands[cmd.Name] = cmd
+	return nil
+}
+
+// MustRegister adds a command and panics on error.
+func (r *Registry) MustRegister(cmd Command) {
+	if err := r.Register(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// Get returns the command registered under name, matching exactly first
+// and then case-insensitively.
+func (r *Registry) Get(name string) (Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if cmd, ok := r.commands[name]; ok {
+		return cmd, true
+	}
+	for key, cmd := range r.commands {
+		if strings.EqualFold(key, name) {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
+
+// List returns all commands sorted by name.
+func (r *Registry) List() []Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		out = append(out, cmd)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Match parses message as "/name args" and returns the registered command
+// with its invocation. ok is false for messages that are not slash
+// commands and for names not in the registry, which callers pass on to
+// the agent (where skills and plugin commands may handle them).
+func (r *Registry) Match(message string) (Command, Invocation, bool) {
+	if r == nil {
+		return Command{}, Invocation{}, false
+	}
+	name, args, ok := skills.ParseSlashSkillCommand(message)
+	if !ok {
+		return Command{}, Invocation{}, false
+	}
+	cmd, ok := r.Get(name)
+	if !ok {
+		return Command{}, Invocation{}, false
+	}
+	return cmd, Invocation{Name: name, Args: args, Message: message}, true
+}
+
+// Help renders the /help listing of cmds.
+func Help(cmds []Command) string {
+	var b strings.Builder
+	b.WriteString("Available commands:\n")
+	for _, cmd := range cmds {
+		b.WriteString("  /" + cmd.Name)
+		if cmd.Usage != "" {
+			b.WriteString(" " + cmd.Usage)
+		}
+		if cmd.Description != "" {
+			b.WriteString(" - " + cmd.Description)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func noop(context.Context, Invocation) (Result, error) {
+	return Result{}, nil
+}
+
+func TestRegistryRegisterValidates(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(Command{Name: "deploy", Handler: noop}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cmd  Command
+	}{
+		{"duplicate", Command{Name: "deploy", Handler: noop}},
+		{"no handler", Command{Name: "other"}},
+		{"empty name", Command{Handler: noop}},
+		{"space in name", Command{Name: "two words", Handler: noop}},
+		{"invalid character", Command{Name: "bad!", Handler: noop}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Register(tt.cmd); err == nil {
+				t.Fatalf("expected error registering %+v", tt.cmd)
+			}
+		})
+	}
+}
+
+func TestRegistryMatch(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Command{Name: "model", Handler: noop})
+
+	cmd, inv, ok := r.Match("  /Model gpt-4.1\nmore text")
+	if !ok || cmd.Name != "model" {
+		t.Fatalf("expected /Model to match model, got %+v, %v", cmd, ok)
+	}
+	if inv.Name != "Model" || inv.Args != "gpt-4.1" || !strings.HasPrefix(inv.Message, "  /Model") {
+		t.Fatalf("unexpected invocation: %+v", inv)
+	}
+
+	for _, msg := range []string{"model gpt-4.1", "/unknown", "", "/"} {
+		if _, _, ok := r.Match(msg); ok {
+			t.Fatalf("expected %q not to match", msg)
+		}
+	}
+
+	var nilRegistry *Registry
+	if _, _, ok := nilRegistry.Match("/model"); ok {
+		t.Fatal("expected nil registry not to match")
+	}
+}
+
+func TestHelpListsCommands(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Command{Name: "model", Usage: "<model>", Description: "switch models", Handler: noop})
+	r.MustRegister(Command{Name: "clear", Description: "forget history", Handler: noop})
+
+	got := Help(r.List())
+	want := "Available commands:\n  /clear - forget history\n  /model <model> - switch models"
+	if got != want {
+		t.Fatalf("Help() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+// ErrCodeArtifactNotFound is returned in ErrorResponse.Code for an unknown
+// artifact ID.
+const ErrCodeArtifactNotFound = "artifact_not_found"
+
+// ArtifactInfo describes an artifact a tool attached to a run.
+type ArtifactInfo struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	MediaType   string    `json:"media_type"`
+	Size        int       `json:"size"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// URL downloads the artifact's content.
+	URL string `json:"url"`
+}
+
+// ArtifactsResponse is the JSON response from
+// GET /api/sessions/{session}/artifacts.
+type ArtifactsResponse struct {
+	SessionID string         `json:"session_id"`
+	Artifacts []ArtifactInfo `json:"artifacts"`
+}
+
+// inlineArtifactTypes are the media types HandleArtifact lets a browser
+// display. Anything else, notably HTML and SVG, is sent as an attachment so
+// a tool's output cannot run script in the server's origin.
+var inlineArtifactTypes = map[string]bool{
+	"text/plain":       true,
+	"text/markdown":    true,
+	"text/csv":         true,
+	"application/json": true,
+	"application/pdf":  true,
+	"image/png":        true,
+	"image/jpeg":       true,
+	"image/gif":        true,
+	"image/webp":       true,
+}
+
+// HandleListArtifacts lists the artifacts of a session's runs, oldest
+// first. It requires the admin token.
+func (c *ChatController) HandleListArtifacts(w http.ResponseWriter, r *http.Request) {
+	if !c.requireAdmin(w, r) {
+		return
+	}
+	sessionID := r.PathValue("session")
+	artifacts, ok := c.sessions.sessionArtifacts(sessionID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "session not found", Code: ErrCodeSessionNotFound})
+		return
+	}
+	infos := artifactInfos(sessionID, artifacts)
+	if infos == nil {
+		infos = []ArtifactInfo{}
+	}
+	writeJSON(w, http.StatusOK, ArtifactsResponse{SessionID: sessionID, Artifacts: infos})
+}
+
+// HandleArtifact downloads an artifact's content with its media type. It
+// requires the admin token. Media types outside inlineArtifactTypes are
+// sent as attachments, and content sniffing is disabled.
+func (c *ChatController) HandleArtifact(w http.ResponseWriter, r *http.Request) {
+	if !c.requireAdmin(w, r) {
+		return
+	}
+	sessionID := r.PathValue("session")
+	artifacts, ok := c.sessions.sessionArtifacts(sessionID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "session not found", Code: ErrCodeSessionNotFound})
+		return
+	}
+	id := r.PathValue("id")
+	for _, artifact := range artifacts {
+		if artifact.ID != id {
+			continue
+		}
+		disposition := "attachment"
+		if mediaType, _, err := mime.ParseMediaType(artifact.MediaType); err == nil && inlineArtifactTypes[mediaType] {
+			disposition = "inline"
+		}
+		w.Header().Set("Content-Type", artifact.MediaType)
+		w.Header().Set("Content-Length", strconv.Itoa(artifact.Size))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": artifact.Name}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		w.Write(artifact.Content())
+		return
+	}
+	writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "artifact not found", Code: ErrCodeArtifactNotFound})
+}
+
+func artifactInfos(sessionID string, artifacts []agent.Artifact) []ArtifactInfo {
+	if len(artifacts) == 0 {
+		return nil
+	}
+	infos := make([]ArtifactInfo, 0, len(artifacts))
+	for _, a := range artifacts {
+		infos = append(infos, ArtifactInfo{
+			ID:          a.ID,
+			Name:        a.Name,
+			MediaType:   a.MediaType,
+			Size:        a.Size,
+			Description: a.Description,
+			CreatedAt:   a.CreatedAt,
+			URL:         "/api/chat/" + url.PathEscape(sessionID) + "/artifacts/" + url.PathEscape(a.ID),
+		})
+	}
+	return infos
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestArtifactsAreListedAndDownloadable(t *testing.T) {
+	artifacts := tools.NewArtifacts()
+	report := artifacts.Add("report.md", "text/markdown", "weekly report", []byte("# Report\n"))
+	stub := &stubAgent{result: agent.AgentResult{Success: true, Message: "done", Artifacts: artifacts.List()}}
+	mux := http.NewServeMux()
+	NewChatController(stub, ChatConfig{AdminToken: "secret"}).RegisterRoutes(mux)
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/chat", strings.NewReader(`{"message":"report","session_id":"s1"}`)))
+	var chat ChatResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &chat); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("POST /api/chat = %d %s", rec.Code, rec.Body.String())
+	}
+	wantURL := "/api/chat/s1/artifacts/" + report.ID
+	if len(chat.Artifacts) != 1 || chat.Artifacts[0].URL != wantURL || chat.Artifacts[0].Description != "weekly report" {
+		t.Fatalf("unexpected artifacts in reply: %+v", chat.Artifacts)
+	}
+
+	rec = get("/api/sessions/s1/artifacts")
+	var list ArtifactsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Artifacts) != 1 || list.Artifacts[0].ID != report.ID {
+		t.Fatalf("GET artifacts = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = get(wantURL)
+	if rec.Code != http.StatusOK || rec.Body.String() != "# Report\n" || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/markdown") {
+		t.Fatalf("download = %d %q %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); disposition != `inline; filename=report.md` {
+		t.Fatalf("Content-Disposition = %q", disposition)
+	}
+	if nosniff := rec.Header().Get("X-Content-Type-Options"); nosniff != "nosniff" {
+		t.Fatalf("X-Content-Type-Options = %q", nosniff)
+	}
+
+	for path, code := range map[string]string{
+		"/api/chat/s1/artifacts/art_missing": ErrCodeArtifactNotFound,
+		"/api/sessions/s2/artifacts":         ErrCodeSessionNotFound,
+	} {
+		rec = get(path)
+		var errResp ErrorResponse
+		json.Unmarshal(rec.Body.Bytes(), &errResp)
+		if rec.Code != http.StatusNotFound || errResp.Code != code {
+			t.Errorf("GET %s = %d %+v, want 404 %s", path, rec.Code, errResp, code)
+		}
+	}
+}
+
+func TestArtifactsRequireAdminAndDownloadUnsafeTypesAsAttachments(t *testing.T) {
+	artifacts := tools.NewArtifacts()
+	page := artifacts.Add("page.html", "text/html; charset=utf-8", "", []byte("<script>alert(1)</script>"))
+	stub := &stubAgent{result: agent.AgentResult{Success: true, Message: "done", Artifacts: artifacts.List()}}
+	mux := http.NewServeMux()
+	NewChatController(stub, ChatConfig{AdminToken: "secret"}).RegisterRoutes(mux)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/chat", strings.NewReader(`{"message":"page","session_id":"s1"}`)))
+
+	downloadURL := "/api/chat/s1/artifacts/" + page.ID
+	for _, path := range []string{"/api/sessions/s1/artifacts", downloadURL} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s without a token = %d, want 401", path, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, downloadURL, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("download = %d %s", rec.Code, rec.Body.String())
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); disposition != `attachment; filename=page.html` {
+		t.Fatalf("Content-Disposition = %q", disposition)
+	}
+	if nosniff := rec.Header().Get("X-Content-Type-Options"); nosniff != "nosniff" {
+		t.Fatalf("X-Content-Type-Options = %q", nosniff)
+	}
+}
+
+func TestSessionStoreCapsArtifacts(t *testing.T) {
+	artifacts := tools.NewArtifacts()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		artifacts.Add(name+".txt", "text/plain", "", []byte(strings.Repeat("x", 10)))
+	}
+	all := artifacts.List()
+
+	store := newSessionStore(SessionLimits{MaxArtifacts: 3, MaxArtifactBytes: 25}, TokenPricing{})
+	store.begin("s")
+	store.addArtifacts("s", all[:3])
+	store.addArtifacts("s", all[3:])
+
+	kept, _ := store.sessionArtifacts("s")
+	if len(kept) != 2 || kept[0].Name != "c.txt" || kept[1].Name != "d.txt" {
+		t.Fatalf("expected the two newest artifacts within 25 bytes, got %+v", kept)
+	}
+
+	unlimited := newSessionStore(SessionLimits{MaxArtifacts: -1, MaxArtifactBytes: -1}, TokenPricing{})
+	unlimited.begin("s")
+	unlimited.addArtifacts("s", all)
+	if kept, _ := unlimited.sessionArtifacts("s"); len(kept) != len(all) {
+		t.Fatalf("expected negative limits to keep every artifact, got %d", len(kept))
+	}
+}
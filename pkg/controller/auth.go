@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// ErrCodeAdminDisabled is returned by admin endpoints when no
+// ChatConfig.AdminToken is configured.
+const ErrCodeAdminDisabled = "admin_disabled"
+
+// requireAdmin reports whether r carries the admin bearer token and writes
+// the error response if not. Admin endpoints fail closed: without a
+// configured token they answer 404.
+func (c *ChatController) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if c.cfg.AdminToken == "" {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "admin endpoints are disabled; set an admin token", Code: ErrCodeAdminDisabled})
+		return false
+	}
+	want := "Bearer " + c.cfg.AdminToken
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: "admin token required", Code: ErrCodeUnauthorized})
+		return false
+	}
+	return true
+}
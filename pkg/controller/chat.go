@@ -3,16 +3,26 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/commands"
+	"github.com/MimeLyc/agent-core-go/pkg/models"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
 )
 
 // ChatController handles HTTP requests for AI chat.
 type ChatController struct {
-	agent agent.Agent
-	cfg   ChatConfig
+	agent    agent.Agent
+	cfg      ChatConfig
+	sessions *sessionStore
+	streams  *streamHub
+	commands *commands.Registry
+	ready    readiness
 }
 
 // ChatConfig holds controller-level configuration.
@@ -21,18 +31,97 @@ type ChatConfig struct {
 	SoulFile        string
 	DefaultDir      string
 	EnableStreaming bool
+
+	// SessionLimits bounds per-session runs, tokens, cost, and idle lifetime.
+	SessionLimits SessionLimits
+	// Pricing is used to compute session cost for SessionLimits.MaxCost.
+	// When zero, prices for Model from the models registry are used.
+	Pricing TokenPricing
+	// Model is the agent's model name, used to look up default pricing.
+	Model string
+	// AdminToken is the bearer token admin endpoints such as GET
+	// /api/sessions require. Without it they are disabled and answer 404.
+	AdminToken string
+	// StreamReplay controls event buffering for resuming dropped streams.
+	StreamReplay StreamReplayConfig
+	// SSE adds keep-alive comments, event chunking, and gzip to stream
+	// responses for reverse proxies.
+	SSE SSEConfig
+	// Readiness controls the provider probe behind GET /readyz.
+	Readiness ReadinessConfig
+	// StreamBuffer bounds the events an agent buffers for a slow stream
+	// consumer and selects the backpressure policy (AgentOptions.StreamBuffer).
+	StreamBuffer *agent.StreamBufferConfig
+	// Limits bounds request and reply sizes and restricts work_dir.
+	Limits RequestLimits
+	// Workspaces checks out ChatRequest.Repo into a per-session worktree.
+	// Requests naming a repo are rejected when nil.
+	Workspaces *workspace.Manager
+	// Profiles are named agents selectable with ChatRequest.Profile, e.g.
+	// built with agent.NewProfileAgents. They use their own system prompt
+	// instead of SystemPrompt.
+	Profiles map[string]agent.Agent
+	// Commands are slash commands ("/name args") the controller runs
+	// instead of sending the message to the agent. Unregistered names go
+	// to the agent, where skills and plugin commands may handle them.
+	Commands *commands.Registry
+	// BuiltinCommands adds /help, /tools, /model, /compact, and /clear.
+	// Commands with the same name take precedence.
+	BuiltinCommands bool
 }
 
 // ChatRequest is the JSON body for POST /api/chat.
 type ChatRequest struct {
-	Message string `json:"message"`
-	WorkDir string `json:"work_dir,omitempty"`
+	Message   string `json:"message"`
+	WorkDir   string `json:"work_dir,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+
+	// Repo is a git URL to run against instead of WorkDir. The session
+	// gets its own worktree at Ref (default branch when empty), reused by
+	// later requests of the session and removed when the session expires.
+	Repo string `json:"repo,omitempty"`
+	Ref  string `json:"ref,omitempty"`
+
+	// Profile selects one of ChatConfig.Profiles instead of the default agent.
+	Profile string `json:"profile,omitempty"`
+
+	// StructuredOutput marks the reply as JSON, so streams also emit
+	// partial_json events (see agent.AgentOptions.StructuredOutput).
+	StructuredOutput bool `json:"structured_output,omitempty"`
+
+	// IncludeToolCalls, IncludeMessages, and IncludeFileChanges add the
+	// run's tool calls, messages, and file changes to the ChatResponse of
+	// POST /api/chat. The same-named query parameters work too.
+	IncludeToolCalls   bool `json:"include_tool_calls,omitempty"`
+	IncludeMessages    bool `json:"include_messages,omitempty"`
+	IncludeFileChanges bool `json:"include_file_changes,omitempty"`
 }
 
 // ChatResponse is the JSON response from POST /api/chat.
 type ChatResponse struct {
-	Reply string    `json:"reply"`
-	Usage UsageInfo `json:"usage"`
+	Reply     string    `json:"reply"`
+	SessionID string    `json:"session_id,omitempty"`
+	Usage     UsageInfo `json:"usage"`
+	// Truncated is set when Reply was cut to RequestLimits.MaxReplyBytes.
+	Truncated bool `json:"truncated,omitempty"`
+	// Outcome and OutcomeReason are set when the agent has an
+	// OutcomeClassifier (proceed, needs_info, or stop).
+	Outcome       string `json:"outcome,omitempty"`
+	OutcomeReason string `json:"outcome_reason,omitempty"`
+	// Command names the slash command that produced Reply without
+	// running the agent.
+	Command string `json:"command,omitempty"`
+
+	// ToolCalls, Messages, and FileChanges are set when the request asks
+	// for them (see ChatRequest.IncludeToolCalls). Messages are the ones
+	// the run added to the session.
+	ToolCalls   []ToolCallInfo   `json:"tool_calls,omitempty"`
+	Messages    []HistoryMessage `json:"messages,omitempty"`
+	FileChanges []FileChangeInfo `json:"file_changes,omitempty"`
+
+	// Artifacts lists the outputs tools attached to the run, downloadable
+	// from their URL.
+	Artifacts []ArtifactInfo `json:"artifacts,omitempty"`
 }
 
 // UsageInfo mirrors token/iteration stats.
@@ -44,7 +133,9 @@ type UsageInfo struct {
 
 // ErrorResponse is the JSON error envelope.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error   string       `json:"error"`
+	Code    string       `json:"code,omitempty"`
+	Session *SessionInfo `json:"session,omitempty"`
 }
 
 // NewChatController creates a ChatController.
@@ -52,58 +143,168 @@ func NewChatController(a agent.Agent, cfg ChatConfig) *ChatController {
 	if cfg.DefaultDir == "" {
 		cfg.DefaultDir = "."
 	}
-	return &ChatController{agent: a, cfg: cfg}
+	c := &ChatController{
+		agent:    a,
+		cfg:      cfg,
+		sessions: newSessionStore(cfg.SessionLimits, cfg.pricing()),
+		streams:  newStreamHub(cfg.StreamReplay),
+	}
+	if cfg.Workspaces != nil {
+		c.sessions.onEvict = c.releaseWorkspace
+	}
+	c.commands = c.newCommandRegistry()
+	return c
+}
+
+// pricing returns the configured pricing, falling back to the registry
+// prices for the configured model.
+func (c ChatConfig) pricing() TokenPricing {
+	if c.Pricing != (TokenPricing{}) || c.Model == "" {
+		return c.Pricing
+	}
+	if m, ok := models.Lookup(c.Model); ok {
+		return TokenPricing{InputPerMillion: m.InputPerMillion, OutputPerMillion: m.OutputPerMillion}
+	}
+	return c.Pricing
 }
 
 // RegisterRoutes wires the controller's handlers onto the given mux.
 func (c *ChatController) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /api/chat", c.HandleChat)
 	mux.HandleFunc("POST /api/chat/stream", c.HandleChatStream)
+	mux.HandleFunc("GET /api/chat/stream/{run_id}", c.HandleResumeStream)
+	mux.HandleFunc("POST /api/chat/stream/{run_id}/cancel", c.HandleCancelStream)
+	mux.HandleFunc("POST /api/chat/stream/{run_id}/reply", c.HandleReplyStream)
+	mux.HandleFunc("POST /api/chat/{session}/compact", c.HandleCompact)
+	mux.HandleFunc("GET /api/chat/{session}/{resource}", c.handleChatSessionGet)
+	mux.HandleFunc("GET /api/chat/{session}/artifacts/{id}", c.HandleArtifact)
+	mux.HandleFunc("GET /api/sessions", c.HandleListSessions)
+	mux.HandleFunc("GET /api/sessions/{session}/trace", c.HandleTrace)
+	mux.HandleFunc("GET /api/sessions/{session}/artifacts", c.HandleListArtifacts)
+	mux.HandleFunc("GET /api/openapi.json", c.HandleOpenAPI)
 	mux.HandleFunc("GET /healthz", c.HandleHealth)
+	mux.HandleFunc("GET /readyz", c.HandleReady)
+	mux.HandleFunc("GET /metrics", c.HandleMetrics)
 }
 
 // HandleChat processes a single chat request.
 func (c *ChatController) HandleChat(w http.ResponseWriter, r *http.Request) {
-	var req ChatRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid JSON: " + err.Error()})
+	req, workDir, reqErr := c.decodeChatRequest(w, r)
+	if reqErr != nil {
+		writeJSON(w, reqErr.status, reqErr.response())
 		return
 	}
-	if req.Message == "" {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "message is required"})
+	include, reqErr := parseIncludeOptions(r, req)
+	if reqErr != nil {
+		writeJSON(w, reqErr.status, reqErr.response())
 		return
 	}
 
-	workDir := req.WorkDir
-	if workDir == "" {
-		workDir = c.cfg.DefaultDir
+	sessionID := resolveSessionID(r, req.SessionID)
+	if name, cmdResult, handled, reqErr := c.runCommand(r.Context(), req, sessionID, workDir); handled {
+		if reqErr != nil {
+			writeJSON(w, reqErr.status, reqErr.response())
+			return
+		}
+		if cmdResult.Prompt == "" {
+			writeJSON(w, http.StatusOK, ChatResponse{Reply: cmdResult.Reply, SessionID: sessionID, Command: name})
+			return
+		}
+		req.Message = cmdResult.Prompt
+	}
+	if limitErr := c.sessions.begin(sessionID); limitErr != nil {
+		writeJSON(w, limitErr.status, limitErr.response())
+		return
+	}
+	if req.Repo != "" {
+		dir, reqErr := c.checkoutRepo(r.Context(), sessionID, req)
+		if reqErr != nil {
+			c.sessions.finish(sessionID, agent.ExecutionUsage{})
+			writeJSON(w, reqErr.status, reqErr.response())
+			return
+		}
+		workDir = dir
 	}
 
+	a, systemPrompt := c.agentFor(req.Profile)
+	history := c.sessionHistory(a, sessionID)
 	agentReq := agent.AgentRequest{
 		Task:         req.Message,
-		SystemPrompt: c.cfg.SystemPrompt,
+		History:      history,
+		SystemPrompt: systemPrompt,
 		SoulFile:     c.cfg.SoulFile,
 		WorkDir:      workDir,
+		Options: agent.AgentOptions{
+			Model:            c.sessions.model(sessionID),
+			UsageSessionID:   sessionID,
+			StructuredOutput: req.StructuredOutput,
+		},
 	}
 
-	result, err := c.agent.Execute(r.Context(), agentReq)
+	result, err := a.Execute(r.Context(), agentReq)
+	c.sessions.finish(sessionID, result.Usage)
 	if err != nil {
 		log.Printf("[chat-controller] agent error: %v", err)
-		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "agent execution failed: " + err.Error()})
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "agent execution failed: " + err.Error(), Code: ErrCodeAgentFailed})
 		return
 	}
+	if len(history) > 0 {
+		// RawOutput is the whole conversation, starting from history.
+		c.sessions.replaceHistory(sessionID, len(history), result.RawOutput)
+	} else {
+		c.sessions.appendHistory(sessionID, result.RawOutput)
+	}
+	c.sessions.addArtifacts(sessionID, result.Artifacts)
 
+	reply, truncated := truncateReply(result.Message, c.cfg.Limits.MaxReplyBytes)
 	resp := ChatResponse{
-		Reply: result.Message,
+		Reply:     reply,
+		SessionID: sessionID,
 		Usage: UsageInfo{
 			Iterations:   result.Usage.TotalIterations,
 			InputTokens:  result.Usage.TotalInputTokens,
 			OutputTokens: result.Usage.TotalOutputTokens,
 		},
+		Truncated:     truncated,
+		Outcome:       string(result.Outcome),
+		OutcomeReason: result.OutcomeReason,
+		Artifacts:     artifactInfos(sessionID, result.Artifacts),
 	}
+	include.apply(&resp, result, c.cfg.Limits.maxHistoryOutputBytes())
 	writeJSON(w, http.StatusOK, resp)
 }
 
+// sessionHistory returns the stored conversation a run of a in sessionID
+// continues. Only agents that implement agent.Compacter, such as APIAgent,
+// accept AgentRequest.History; CLI agents resume their own sessions. Runs in
+// the shared default session start fresh, so callers that do not name a
+// session never see each other's conversations.
+func (c *ChatController) sessionHistory(a agent.Agent, sessionID string) []agenttypes.Message {
+	if _, ok := a.(agent.Compacter); !ok || sessionID == defaultSessionID {
+		return nil
+	}
+	history, _ := c.sessions.history(sessionID)
+	return history
+}
+
+// agentFor returns the agent for a request's profile and the system prompt
+// to send with it. Profiles keep the system prompt they were built with.
+// decodeChatRequest has already rejected unknown profiles.
+func (c *ChatController) agentFor(profile string) (agent.Agent, string) {
+	if profile == "" {
+		return c.agent, c.cfg.SystemPrompt
+	}
+	return c.cfg.Profiles[profile], ""
+}
+
+// HandleListSessions lists active chat sessions and their usage.
+func (c *ChatController) HandleListSessions(w http.ResponseWriter, r *http.Request) {
+	if !c.requireAdmin(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, SessionsResponse{Sessions: c.sessions.list()})
+}
+
 // HandleHealth returns a simple health check.
 func (c *ChatController) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
@@ -112,60 +313,209 @@ func (c *ChatController) HandleHealth(w http.ResponseWriter, r *http.Request) {
 // HandleChatStream processes a streaming chat request using SSE.
 func (c *ChatController) HandleChatStream(w http.ResponseWriter, r *http.Request) {
 	if !c.cfg.EnableStreaming {
-		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "streaming is disabled"})
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "streaming is disabled", Code: ErrCodeStreamingDisabled})
 		return
 	}
 
-	var req ChatRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid JSON: " + err.Error()})
+	// A reconnecting client resumes its run instead of starting a new one.
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		runID, seq, ok := parseEventID(lastID)
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid Last-Event-ID", Code: ErrCodeInvalidRequest})
+			return
+		}
+		c.resumeStream(w, r, runID, seq)
 		return
 	}
-	if req.Message == "" {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "message is required"})
+
+	req, workDir, reqErr := c.decodeChatRequest(w, r)
+	if reqErr != nil {
+		writeJSON(w, reqErr.status, reqErr.response())
 		return
 	}
 
-	workDir := req.WorkDir
-	if workDir == "" {
-		workDir = c.cfg.DefaultDir
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "streaming is not supported by this server"})
+		return
 	}
 
+	sessionID := resolveSessionID(r, req.SessionID)
+	if _, cmdResult, handled, reqErr := c.runCommand(r.Context(), req, sessionID, workDir); handled {
+		if reqErr != nil {
+			writeJSON(w, reqErr.status, reqErr.response())
+			return
+		}
+		if cmdResult.Prompt == "" {
+			c.serveCommandReply(w, r, flusher, cmdResult.Reply)
+			return
+		}
+		req.Message = cmdResult.Prompt
+	}
+
+	a, systemPrompt := c.agentFor(req.Profile)
 	agentReq := agent.AgentRequest{
 		Task:         req.Message,
-		SystemPrompt: c.cfg.SystemPrompt,
+		SystemPrompt: systemPrompt,
 		SoulFile:     c.cfg.SoulFile,
 		WorkDir:      workDir,
 		Options: agent.AgentOptions{
-			EnableStreaming: true,
+			EnableStreaming:  true,
+			StreamBuffer:     c.cfg.StreamBuffer,
+			Model:            c.sessions.model(sessionID),
+			UsageSessionID:   sessionID,
+			StructuredOutput: req.StructuredOutput,
 		},
 	}
 
+	if limitErr := c.sessions.begin(sessionID); limitErr != nil {
+		writeJSON(w, limitErr.status, limitErr.response())
+		return
+	}
+	agentReq.History = c.sessionHistory(a, sessionID)
+	if req.Repo != "" {
+		dir, reqErr := c.checkoutRepo(r.Context(), sessionID, req)
+		if reqErr != nil {
+			c.sessions.finish(sessionID, agent.ExecutionUsage{})
+			writeJSON(w, reqErr.status, reqErr.response())
+			return
+		}
+		agentReq.WorkDir = dir
+	}
+
+	// The run outlives this connection so a client can resume after a drop;
+	// it is cancelled if no client reattaches within DetachTimeout.
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	run := c.streams.start(cancel)
+	agentReq.Options.InputQueue = run.input
+	go c.pumpStream(runCtx, cancel, run, sessionID, a, agentReq)
+
+	c.serveStream(w, r, flusher, run, 0)
+}
+
+// HandleResumeStream reattaches to a streaming run by ID, replaying events
+// after Last-Event-ID (or from the start when the header is absent).
+func (c *ChatController) HandleResumeStream(w http.ResponseWriter, r *http.Request) {
+	runID := r.PathValue("run_id")
+	var after int64
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		id, seq, ok := parseEventID(lastID)
+		if !ok || id != runID {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid Last-Event-ID", Code: ErrCodeInvalidRequest})
+			return
+		}
+		after = seq
+	}
+	c.resumeStream(w, r, runID, after)
+}
+
+// HandleCancelStream cancels an in-progress streaming run.
+func (c *ChatController) HandleCancelStream(w http.ResponseWriter, r *http.Request) {
+	runID := r.PathValue("run_id")
+	run := c.streams.get(runID)
+	if run == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "stream not found or expired", Code: ErrCodeStreamNotFound})
+		return
+	}
+	cancelled := run.stop()
+	if cancelled {
+		log.Printf("[chat-controller] cancelled stream %s", runID)
+	}
+	writeJSON(w, http.StatusOK, CancelResponse{RunID: runID, Cancelled: cancelled})
+}
+
+// HandleReplyStream sends a message to an in-progress streaming run. It
+// answers a pending ask_user question (user_input_required event), or
+// otherwise steers the run's next model turn.
+func (c *ChatController) HandleReplyStream(w http.ResponseWriter, r *http.Request) {
+	var req ReplyRequest
+	if reqErr := c.decodeBody(w, r, &req, false); reqErr != nil {
+		writeJSON(w, reqErr.status, reqErr.response())
+		return
+	}
+	if req.Message == "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "message is required", Code: ErrCodeInvalidRequest})
+		return
+	}
+	if limit := c.cfg.Limits.MaxMessageBytes; limit > 0 && len(req.Message) > limit {
+		writeJSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{Error: fmt.Sprintf("message exceeds %d bytes", limit), Code: ErrCodeMessageTooLong})
+		return
+	}
+
+	runID := r.PathValue("run_id")
+	run := c.streams.get(runID)
+	if run == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "stream not found or expired", Code: ErrCodeStreamNotFound})
+		return
+	}
+	running, err := run.reply(req.Message)
+	switch {
+	case !running:
+		writeJSON(w, http.StatusConflict, ErrorResponse{Error: "stream has already finished", Code: ErrCodeStreamFinished})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "queue reply: " + err.Error()})
+	default:
+		writeJSON(w, http.StatusAccepted, ReplyResponse{RunID: runID})
+	}
+}
+
+func (c *ChatController) resumeStream(w http.ResponseWriter, r *http.Request, runID string, after int64) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: "streaming is not supported by this server"})
 		return
 	}
+	run := c.streams.get(runID)
+	if run == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "stream not found or expired", Code: ErrCodeStreamNotFound})
+		return
+	}
+	if _, _, _, ok := run.since(after); !ok {
+		writeJSON(w, http.StatusGone, ErrorResponse{Error: "requested events are no longer buffered", Code: ErrCodeStreamExpired})
+		return
+	}
+	log.Printf("[chat-controller] resuming stream %s after event %d", runID, after)
+	c.serveStream(w, r, flusher, run, after)
+}
 
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.WriteHeader(http.StatusOK)
+// pumpStream runs the agent and buffers its events on run.
+func (c *ChatController) pumpStream(ctx context.Context, cancel context.CancelFunc, run *streamRun, sessionID string, a agent.Agent, agentReq agent.AgentRequest) {
+	defer cancel()
+	var usage agent.ExecutionUsage
+	var completed bool
+	transcript := newStreamTranscript(agentReq.Task)
+	defer func() {
+		if completed {
+			c.sessions.appendHistory(sessionID, transcript.messages)
+		}
+		c.sessions.finish(sessionID, usage)
+		run.finish(c.streams.now())
+	}()
 
-	events, errs := c.agent.ExecuteStream(r.Context(), agentReq)
+	bufferSize := c.streams.cfg.BufferSize
+	events, errs := a.ExecuteStream(ctx, agentReq)
 	for events != nil || errs != nil {
 		select {
-		case <-r.Context().Done():
+		case <-ctx.Done():
 			return
 		case evt, ok := <-events:
 			if !ok {
 				events = nil
 				continue
 			}
-			if !writeSSEEvent(w, evt) {
+			if evt.Type == agent.AgentEventAgentEnd {
+				completed = true
+				if evt.Usage != nil {
+					usage = *evt.Usage
+				}
+				c.sessions.addArtifacts(sessionID, evt.Artifacts)
+			}
+			transcript.observe(evt)
+			name, data, ok := encodeSSEEvent(evt)
+			if !ok {
 				return
 			}
-			flusher.Flush()
+			run.append(name, data, bufferSize)
 		case err, ok := <-errs:
 			if !ok {
 				errs = nil
@@ -174,16 +524,71 @@ func (c *ChatController) HandleChatStream(w http.ResponseWriter, r *http.Request
 			if err == nil {
 				continue
 			}
-			_ = writeSSEEvent(w, map[string]any{
+			if name, data, ok := encodeSSEEvent(map[string]any{
 				"type":  "error",
 				"error": err.Error(),
-			})
-			flusher.Flush()
+			}); ok {
+				run.append(name, data, bufferSize)
+			}
 			return
 		}
 	}
 }
 
+// serveStream writes run's events after seq to the client until the run
+// finishes or the client disconnects.
+func (c *ChatController) serveStream(w http.ResponseWriter, r *http.Request, flusher http.Flusher, run *streamRun, after int64) {
+	run.attach()
+	defer run.detach(c.streams.cfg.DetachTimeout)
+
+	sse := newSSEWriter(w, r, flusher, c.cfg.SSE)
+	defer sse.close()
+	w.Header().Set("X-Run-ID", run.id)
+	w.WriteHeader(http.StatusOK)
+	sse.flush()
+
+	// keepAlive fires after KeepAlive without anything written.
+	var keepAlive <-chan time.Time
+	resetKeepAlive := func() {}
+	if interval := c.cfg.SSE.KeepAlive; interval > 0 {
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		keepAlive = timer.C
+		resetKeepAlive = func() { timer.Reset(interval) }
+	}
+	for {
+		events, done, notify, ok := run.since(after)
+		if !ok {
+			log.Printf("[chat-controller] client fell behind stream %s buffer", run.id)
+			return
+		}
+		for _, e := range events {
+			if !sse.event(run.eventID(e.seq), e) {
+				return
+			}
+			after = e.seq
+		}
+		if len(events) > 0 {
+			sse.flush()
+			resetKeepAlive()
+		}
+		if done {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-notify:
+		case <-keepAlive:
+			if !sse.comment("keep-alive") {
+				return
+			}
+			sse.flush()
+			resetKeepAlive()
+		}
+	}
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -192,26 +597,19 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	}
 }
 
-func writeSSEEvent(w http.ResponseWriter, event any) bool {
+// encodeSSEEvent returns the SSE event name and JSON payload for event.
+func encodeSSEEvent(event any) (string, []byte, bool) {
 	payload, err := json.Marshal(event)
 	if err != nil {
 		log.Printf("[chat-controller] failed to marshal SSE payload: %v", err)
-		return false
+		return "", nil, false
 	}
 
 	eventName := "message"
 	if ev, ok := event.(agent.AgentStreamEvent); ok && ev.Type != "" {
 		eventName = string(ev.Type)
 	}
-	if _, err := w.Write([]byte("event: " + eventName + "\n")); err != nil {
-		log.Printf("[chat-controller] failed to write SSE event name: %v", err)
-		return false
-	}
-	if _, err := w.Write([]byte("data: " + string(payload) + "\n\n")); err != nil {
-		log.Printf("[chat-controller] failed to write SSE data: %v", err)
-		return false
-	}
-	return true
+	return eventName, payload, true
 }
 
 // ContextWithTimeout wraps context.WithTimeout for use in tests/callers.
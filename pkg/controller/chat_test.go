@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/MimeLyc/agent-core-go/pkg/agent"
 )
@@ -140,15 +142,16 @@ func TestHandleChat_CustomWorkDir(t *testing.T) {
 		result: agent.AgentResult{},
 	}
 	ctrl := NewChatController(stub, ChatConfig{DefaultDir: "/default"})
+	custom := t.TempDir()
 
-	body := `{"message":"hi","work_dir":"/custom"}`
+	body := fmt.Sprintf(`{"message":"hi","work_dir":%q}`, custom)
 	req := httptest.NewRequest(http.MethodPost, "/api/chat", bytes.NewBufferString(body))
 	w := httptest.NewRecorder()
 
 	ctrl.HandleChat(w, req)
 
-	if stub.lastReq.WorkDir != "/custom" {
-		t.Errorf("expected work dir '/custom', got %q", stub.lastReq.WorkDir)
+	if stub.lastReq.WorkDir != custom {
+		t.Errorf("expected work dir %q, got %q", custom, stub.lastReq.WorkDir)
 	}
 }
 
@@ -210,3 +213,199 @@ func TestHandleChatStream_Success(t *testing.T) {
 		t.Fatalf("expected SSE stream output, got %q", w.Body.String())
 	}
 }
+
+func postChat(t *testing.T, ctrl *ChatController, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/chat", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ctrl.HandleChat(w, req)
+	return w
+}
+
+func TestHandleChat_SessionRunLimit(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{Message: "ok"}}
+	ctrl := NewChatController(stub, ChatConfig{
+		SessionLimits: SessionLimits{MaxRuns: 1},
+	})
+
+	if w := postChat(t, ctrl, `{"message":"one","session_id":"s1"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := postChat(t, ctrl, `{"message":"two","session_id":"s1"}`)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Code != ErrCodeRunLimit || resp.Session == nil || resp.Session.Runs != 1 {
+		t.Errorf("unexpected error response: %+v", resp)
+	}
+
+	// Other sessions are unaffected.
+	if w := postChat(t, ctrl, `{"message":"one","session_id":"s2"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for another session, got %d", w.Code)
+	}
+}
+
+func TestHandleChat_SessionTokenAndCostBudget(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{
+		Message: "ok",
+		Usage:   agent.ExecutionUsage{TotalInputTokens: 600, TotalOutputTokens: 400},
+	}}
+
+	tests := []struct {
+		name     string
+		limits   SessionLimits
+		wantCode string
+	}{
+		{name: "tokens", limits: SessionLimits{MaxTotalTokens: 1000}, wantCode: ErrCodeTokenBudget},
+		{name: "cost", limits: SessionLimits{MaxCost: 0.001}, wantCode: ErrCodeCostBudget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := NewChatController(stub, ChatConfig{
+				SessionLimits: tt.limits,
+				Pricing:       TokenPricing{InputPerMillion: 1, OutputPerMillion: 1},
+			})
+			if w := postChat(t, ctrl, `{"message":"one"}`); w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", w.Code)
+			}
+			w := postChat(t, ctrl, `{"message":"two"}`)
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("expected 403, got %d: %s", w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantCode) {
+				t.Errorf("expected code %s, got %s", tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestChatConfigPricingFallsBackToModelRegistry(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ChatConfig
+		want TokenPricing
+	}{
+		{"explicit", ChatConfig{Model: "gpt-4o", Pricing: TokenPricing{InputPerMillion: 1}}, TokenPricing{InputPerMillion: 1}},
+		{"registry", ChatConfig{Model: "gpt-4o-2024-08-06"}, TokenPricing{InputPerMillion: 2.5, OutputPerMillion: 10}},
+		{"unknown model", ChatConfig{Model: "local"}, TokenPricing{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.pricing(); got != tt.want {
+				t.Fatalf("pricing() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleListSessions(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{
+		Message: "ok",
+		Usage:   agent.ExecutionUsage{TotalInputTokens: 10, TotalOutputTokens: 5},
+	}}
+	ctrl := NewChatController(stub, ChatConfig{AdminToken: "secret"})
+	postChat(t, ctrl, `{"message":"hi","session_id":"alice"}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+	w := httptest.NewRecorder()
+	ctrl.HandleListSessions(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer secreT")
+	w = httptest.NewRecorder()
+	ctrl.HandleListSessions(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with a wrong token, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	ctrl.HandleListSessions(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp SessionsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Sessions) != 1 {
+		t.Fatalf("expected 1 session, got %+v", resp.Sessions)
+	}
+	got := resp.Sessions[0]
+	if got.ID != "alice" || got.Runs != 1 || got.TotalTokens != 15 || got.ActiveRuns != 0 {
+		t.Errorf("unexpected session info: %+v", got)
+	}
+}
+
+func TestHandleListSessionsDisabledWithoutAdminToken(t *testing.T) {
+	ctrl := NewChatController(&stubAgent{result: agent.AgentResult{Message: "ok"}}, ChatConfig{})
+	postChat(t, ctrl, `{"message":"hi","session_id":"alice"}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	ctrl.HandleListSessions(w, req)
+	if w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "alice") {
+		t.Fatalf("expected 404 without sessions, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSessionStoreEvictsIdleSessions(t *testing.T) {
+	store := newSessionStore(SessionLimits{MaxRuns: 1, IdleTTL: time.Minute}, TokenPricing{})
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+
+	if err := store.begin("s"); err != nil {
+		t.Fatalf("unexpected limit error: %v", err.message)
+	}
+	store.finish("s", agent.ExecutionUsage{})
+	if err := store.begin("s"); err == nil {
+		t.Fatalf("expected run limit before TTL elapses")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := store.begin("s"); err != nil {
+		t.Fatalf("expected idle session to be evicted and restarted, got %v", err.message)
+	}
+}
+
+func TestHandleChat_RoutesToProfile(t *testing.T) {
+	base := &stubAgent{result: agent.AgentResult{Success: true, Message: "base"}}
+	reviewer := &stubAgent{result: agent.AgentResult{Success: true, Message: "reviewed"}}
+	ctrl := NewChatController(base, ChatConfig{
+		SystemPrompt: "default prompt",
+		DefaultDir:   "/tmp",
+		Profiles:     map[string]agent.Agent{"reviewer": reviewer},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chat", bytes.NewBufferString(`{"message":"check this","profile":"reviewer"}`))
+	w := httptest.NewRecorder()
+	ctrl.HandleChat(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"reviewed"`) {
+		t.Fatalf("expected the reviewer profile to answer, got %d: %s", w.Code, w.Body.String())
+	}
+	if reviewer.lastReq.SystemPrompt != "" {
+		t.Fatalf("profiles keep their own system prompt, got %q", reviewer.lastReq.SystemPrompt)
+	}
+	if base.lastReq.Task != "" {
+		t.Fatal("the default agent must not run for a profile request")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/chat", bytes.NewBufferString(`{"message":"hi","profile":"missing"}`))
+	w = httptest.NewRecorder()
+	ctrl.HandleChat(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), ErrCodeUnknownProfile) {
+		t.Fatalf("expected 400 unknown_profile, got %d: %s", w.Code, w.Body.String())
+	}
+}
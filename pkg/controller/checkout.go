@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
+)
+
+// checkoutRepo provisions the session's worktree of req.Repo and returns
+// its directory.
+func (c *ChatController) checkoutRepo(ctx context.Context, sessionID string, req ChatRequest) (string, *requestError) {
+	co, err := c.cfg.Workspaces.Acquire(ctx, sessionID, req.Repo, req.Ref)
+	switch {
+	case err == nil:
+		return co.Dir, nil
+	case errors.Is(err, workspace.ErrCheckoutConflict):
+		return "", &requestError{status: http.StatusConflict, code: ErrCodeCheckoutConflict, message: err.Error()}
+	default:
+		log.Printf("[chat-controller] checkout of %s for session %s failed: %v", req.Repo, sessionID, err)
+		return "", &requestError{status: http.StatusBadRequest, code: ErrCodeCheckoutFailed, message: "repo checkout failed: " + err.Error()}
+	}
+}
+
+// releaseWorkspace removes an evicted session's worktree. It is called with
+// the session store locked, so the git work runs in the background. The
+// checkout is detached first, before the session can be reused: a new run
+// of the same session gets a fresh worktree instead of the one being
+// removed.
+func (c *ChatController) releaseWorkspace(sessionID string) {
+	remove := c.cfg.Workspaces.Detach(sessionID)
+	go func() {
+		if err := remove(context.Background()); err != nil {
+			log.Printf("[chat-controller] failed to release workspace for session %s: %v", sessionID, err)
+		}
+	}()
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
+)
+
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestHandleChat_RepoCheckout(t *testing.T) {
+	repo := newTestRepo(t)
+	manager, err := workspace.NewManager(workspace.ManagerConfig{Root: t.TempDir(), AllowLocal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &stubAgent{result: agent.AgentResult{Message: "ok"}}
+	ctrl := NewChatController(stub, ChatConfig{
+		Workspaces:    manager,
+		SessionLimits: SessionLimits{IdleTTL: time.Minute},
+	})
+
+	w := postChat(t, ctrl, fmt.Sprintf(`{"message":"hi","session_id":"s1","repo":%q}`, repo))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	checkoutDir := stub.lastReq.WorkDir
+	if _, err := os.Stat(filepath.Join(checkoutDir, "main.go")); err != nil {
+		t.Fatalf("expected repo checked out in %q: %v", checkoutDir, err)
+	}
+
+	w = postChat(t, ctrl, fmt.Sprintf(`{"message":"hi","session_id":"s1","repo":%q,"ref":"no-such-branch"}`, repo))
+	if w.Code != http.StatusConflict || decodeError(t, w.Body.Bytes()).Code != ErrCodeCheckoutConflict {
+		t.Fatalf("expected checkout conflict, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = postChat(t, ctrl, fmt.Sprintf(`{"message":"hi","session_id":"s2","repo":%q,"ref":"no-such-branch"}`, repo))
+	if w.Code != http.StatusBadRequest || decodeError(t, w.Body.Bytes()).Code != ErrCodeCheckoutFailed {
+		t.Fatalf("expected checkout failure, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Evicting the session removes its worktree.
+	ctrl.sessions.now = func() time.Time { return time.Now().Add(time.Hour) }
+	ctrl.sessions.list()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(checkoutDir); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected worktree to be removed after session eviction")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandleChat_RepoCheckoutSurvivesEvictThenReuse(t *testing.T) {
+	repo := newTestRepo(t)
+	manager, err := workspace.NewManager(workspace.ManagerConfig{Root: t.TempDir(), AllowLocal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &stubAgent{result: agent.AgentResult{Message: "ok"}}
+	ctrl := NewChatController(stub, ChatConfig{
+		Workspaces:    manager,
+		SessionLimits: SessionLimits{IdleTTL: time.Minute},
+	})
+	body := fmt.Sprintf(`{"message":"hi","session_id":"s1","repo":%q}`, repo)
+	if w := postChat(t, ctrl, body); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	oldDir := stub.lastReq.WorkDir
+
+	// The next request of s1 evicts the idle session and recreates it.
+	ctrl.sessions.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if w := postChat(t, ctrl, body); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	newDir := stub.lastReq.WorkDir
+	if newDir == oldDir {
+		t.Fatal("expected the recreated session to get a new worktree")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(oldDir); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the evicted worktree to be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(filepath.Join(newDir, "main.go")); err != nil {
+		t.Fatalf("expected the new worktree to survive the removal: %v", err)
+	}
+}
+
+func TestHandleChat_RepoRequiresWorkspaces(t *testing.T) {
+	ctrl := NewChatController(&stubAgent{}, ChatConfig{})
+	w := postChat(t, ctrl, `{"message":"hi","repo":"https://github.com/o/r.git"}`)
+	if w.Code != http.StatusBadRequest || decodeError(t, w.Body.Bytes()).Code != ErrCodeRepoCheckoutDisabled {
+		t.Fatalf("expected repo_checkout_disabled, got %d: %s", w.Code, w.Body.String())
+	}
+}
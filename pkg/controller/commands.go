@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/commands"
+)
+
+// ErrCodeCommandFailed is returned in ErrorResponse.Code when a slash
+// command's handler fails.
+const ErrCodeCommandFailed = "command_failed"
+
+// newCommandRegistry merges the configured commands with the built-ins
+// when enabled. Configured commands take precedence over built-ins of the
+// same name.
+func (c *ChatController) newCommandRegistry() *commands.Registry {
+	if c.cfg.Commands == nil && !c.cfg.BuiltinCommands {
+		return nil
+	}
+	registry := commands.NewRegistry()
+	if c.cfg.Commands != nil {
+		for _, cmd := range c.cfg.Commands.List() {
+			registry.MustRegister(cmd)
+		}
+	}
+	if c.cfg.BuiltinCommands {
+		for _, cmd := range c.builtinCommands() {
+			if _, exists := registry.Get(cmd.Name); !exists {
+				registry.MustRegister(cmd)
+			}
+		}
+	}
+	return registry
+}
+
+// builtinCommands returns the commands enabled by ChatConfig.BuiltinCommands.
+func (c *ChatController) builtinCommands() []commands.Command {
+	return []commands.Command{
+		{
+			Name:        "help",
+			Description: "list the available commands",
+			Handler: func(context.Context, commands.Invocation) (commands.Result, error) {
+				return commands.Result{Reply: commands.Help(c.commands.List())}, nil
+			},
+		},
+		{
+			Name:        "tools",
+			Description: "list the tools the agent can use",
+			Handler: func(_ context.Context, inv commands.Invocation) (commands.Result, error) {
+				a, _ := c.agentFor(inv.Profile)
+				available := a.Capabilities().AvailableTools
+				if len(available) == 0 {
+					return commands.Result{Reply: "No tools are available."}, nil
+				}
+				var b strings.Builder
+				b.WriteString("Available tools:")
+				for _, tool := range available {
+					b.WriteString("\n  " + tool.Name)
+					if tool.Description != "" {
+						b.WriteString(" - " + firstLine(tool.Description))
+					}
+				}
+				return commands.Result{Reply: b.String()}, nil
+			},
+		},
+		{
+			Name:        "model",
+			Usage:       "[<model>|default]",
+			Description: "show or switch the model for this session",
+			Handler: func(_ context.Context, inv commands.Invocation) (commands.Result, error) {
+				switch model := strings.TrimSpace(inv.Args); model {
+				case "":
+					current := c.sessions.model(inv.SessionID)
+					if current == "" {
+						current = c.cfg.Model
+					}
+					if current == "" {
+						return commands.Result{Reply: "Using the agent's default model."}, nil
+					}
+					return commands.Result{Reply: "Current model: " + current}, nil
+				case "default":
+					c.sessions.setModel(inv.SessionID, "")
+					return commands.Result{Reply: "Restored the agent's default model."}, nil
+				default:
+					c.sessions.setModel(inv.SessionID, model)
+					return commands.Result{Reply: "Switched to model " + model + "."}, nil
+				}
+			},
+		},
+		{
+			Name:        "compact",
+			Usage:       "[<keep_recent>]",
+			Description: "summarize this session's history",
+			Handler: func(ctx context.Context, inv commands.Invocation) (commands.Result, error) {
+				compacter, ok := c.agent.(agent.Compacter)
+				if !ok {
+					return commands.Result{}, fmt.Errorf("agent does not support compaction")
+				}
+				keepRecent := 0
+				if arg := strings.TrimSpace(inv.Args); arg != "" {
+					n, err := strconv.Atoi(arg)
+					if err != nil {
+						return commands.Result{}, fmt.Errorf("keep_recent must be a number, got %q", arg)
+					}
+					keepRecent = n
+				}
+				resp, reqErr := c.compactSession(ctx, compacter, inv.SessionID, keepRecent)
+				if reqErr != nil {
+					return commands.Result{}, fmt.Errorf("%s", reqErr.message)
+				}
+				return commands.Result{Reply: fmt.Sprintf("Compacted %d messages into %d, saving %d tokens.",
+					resp.MessagesBefore, resp.MessagesAfter, resp.TokensSaved)}, nil
+			},
+		},
+		{
+			Name:        "clear",
+			Description: "forget this session's history",
+			Handler: func(_ context.Context, inv commands.Invocation) (commands.Result, error) {
+				c.sessions.clearHistory(inv.SessionID)
+				return commands.Result{Reply: "Cleared the session history."}, nil
+			},
+		},
+	}
+}
+
+// runCommand runs the slash command in req.Message, if one is registered.
+// handled is false for other messages, which go to the agent unchanged.
+// A command that returns a Prompt is handled but still runs the agent.
+func (c *ChatController) runCommand(ctx context.Context, req ChatRequest, sessionID, workDir string) (name string, result commands.Result, handled bool, reqErr *requestError) {
+	cmd, inv, ok := c.commands.Match(req.Message)
+	if !ok {
+		return "", commands.Result{}, false, nil
+	}
+	inv.SessionID, inv.WorkDir, inv.Profile = sessionID, workDir, req.Profile
+
+	log.Printf("[chat-controller] running command /%s for session %s", cmd.Name, sessionID)
+	result, err := cmd.Handler(ctx, inv)
+	if err != nil {
+		return cmd.Name, result, true, &requestError{status: http.StatusBadRequest, code: ErrCodeCommandFailed, message: fmt.Sprintf("/%s: %v", cmd.Name, err)}
+	}
+	return cmd.Name, result, true, nil
+}
+
+// serveCommandReply streams a command's reply as a complete run, so
+// streaming clients need no special handling for commands.
+func (c *ChatController) serveCommandReply(w http.ResponseWriter, r *http.Request, flusher http.Flusher, reply string) {
+	run := c.streams.start(nil)
+	for _, evt := range []agent.AgentStreamEvent{
+		{Type: agent.AgentEventAgentStart},
+		{Type: agent.AgentEventMessageEnd, Message: reply},
+		{Type: agent.AgentEventAgentEnd, Message: reply},
+	} {
+		if name, data, ok := encodeSSEEvent(evt); ok {
+			run.append(name, data, c.streams.cfg.BufferSize)
+		}
+	}
+	run.finish(c.streams.now())
+	c.serveStream(w, r, flusher, run, 0)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/commands"
+)
+
+func decodeChatResponse(t *testing.T, body []byte) ChatResponse {
+	t.Helper()
+	var resp ChatResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestHandleChat_BuiltinHelpSkipsAgent(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{Message: "from agent"}}
+	ctrl := NewChatController(stub, ChatConfig{BuiltinCommands: true})
+
+	w := postChat(t, ctrl, `{"message":"/help"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	resp := decodeChatResponse(t, w.Body.Bytes())
+	if resp.Command != "help" || !strings.Contains(resp.Reply, "/model") || !strings.Contains(resp.Reply, "/clear") {
+		t.Fatalf("unexpected help response: %+v", resp)
+	}
+	if stub.lastReq.Task != "" {
+		t.Fatalf("expected agent not to run, got task %q", stub.lastReq.Task)
+	}
+}
+
+func TestHandleChat_ModelCommandOverridesSessionModel(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{Message: "ok"}}
+	ctrl := NewChatController(stub, ChatConfig{BuiltinCommands: true, Model: "base-model"})
+
+	w := postChat(t, ctrl, `{"message":"/model gpt-4.1","session_id":"s1"}`)
+	if resp := decodeChatResponse(t, w.Body.Bytes()); resp.Command != "model" {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+
+	postChat(t, ctrl, `{"message":"hello","session_id":"s1"}`)
+	if stub.lastReq.Options.Model != "gpt-4.1" {
+		t.Fatalf("expected session model gpt-4.1, got %q", stub.lastReq.Options.Model)
+	}
+	postChat(t, ctrl, `{"message":"hello","session_id":"s2"}`)
+	if stub.lastReq.Options.Model != "" {
+		t.Fatalf("expected other sessions to keep the default model, got %q", stub.lastReq.Options.Model)
+	}
+
+	w = postChat(t, ctrl, `{"message":"/model","session_id":"s2"}`)
+	if resp := decodeChatResponse(t, w.Body.Bytes()); resp.Reply != "Current model: base-model" {
+		t.Fatalf("unexpected reply: %q", resp.Reply)
+	}
+
+	postChat(t, ctrl, `{"message":"/model default","session_id":"s1"}`)
+	postChat(t, ctrl, `{"message":"hello","session_id":"s1"}`)
+	if stub.lastReq.Options.Model != "" {
+		t.Fatalf("expected /model default to restore the agent's model, got %q", stub.lastReq.Options.Model)
+	}
+}
+
+func TestHandleChat_CompactAndClearCommands(t *testing.T) {
+	stub := &compactingStub{stubAgent: stubAgent{result: agent.AgentResult{
+		Message: "done",
+		RawOutput: []agenttypes.Message{
+			agenttypes.NewTextMessage(agenttypes.RoleUser, "hello"),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "done"),
+		},
+	}}}
+	ctrl := NewChatController(stub, ChatConfig{BuiltinCommands: true})
+
+	for i := 0; i < 2; i++ {
+		postChat(t, ctrl, `{"message":"hello","session_id":"s1"}`)
+	}
+
+	w := postChat(t, ctrl, `{"message":"/compact 1","session_id":"s1"}`)
+	if resp := decodeChatResponse(t, w.Body.Bytes()); resp.Command != "compact" || !strings.HasPrefix(resp.Reply, "Compacted 4 messages") {
+		t.Fatalf("unexpected compact response: %s", w.Body.String())
+	}
+	if stub.compactReq.KeepRecent != 1 {
+		t.Fatalf("expected keep_recent 1, got %d", stub.compactReq.KeepRecent)
+	}
+
+	postChat(t, ctrl, `{"message":"/clear","session_id":"s1"}`)
+	if history, _ := ctrl.sessions.history("s1"); len(history) != 0 {
+		t.Fatalf("expected history to be cleared, got %d messages", len(history))
+	}
+
+	w = postChat(t, ctrl, `{"message":"/compact","session_id":"s1"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "no history") {
+		t.Fatalf("expected compacting an empty session to fail, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleChatStream_CommandReplyStreamsRun(t *testing.T) {
+	stub := &stubAgent{}
+	ctrl := NewChatController(stub, ChatConfig{EnableStreaming: true, BuiltinCommands: true})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/stream", strings.NewReader(`{"message":"/model gpt-4.1"}`))
+	w := httptest.NewRecorder()
+	ctrl.HandleChatStream(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{"event: agent_start", "event: message_end", "event: agent_end", "Switched to model gpt-4.1."} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in stream, got %q", want, body)
+		}
+	}
+	if stub.lastReq.Task != "" {
+		t.Fatalf("expected agent not to run, got task %q", stub.lastReq.Task)
+	}
+}
+
+func TestHandleChat_CustomCommands(t *testing.T) {
+	registry := commands.NewRegistry()
+	registry.MustRegister(commands.Command{
+		Name: "review",
+		Handler: func(_ context.Context, inv commands.Invocation) (commands.Result, error) {
+			return commands.Result{Prompt: "Review the changes in " + inv.Args}, nil
+		},
+	})
+	registry.MustRegister(commands.Command{
+		Name: "fail",
+		Handler: func(context.Context, commands.Invocation) (commands.Result, error) {
+			return commands.Result{}, errors.New("nope")
+		},
+	})
+	registry.MustRegister(commands.Command{
+		Name: "help",
+		Handler: func(context.Context, commands.Invocation) (commands.Result, error) {
+			return commands.Result{Reply: "custom help"}, nil
+		},
+	})
+	stub := &stubAgent{result: agent.AgentResult{Message: "reviewed"}}
+	ctrl := NewChatController(stub, ChatConfig{Commands: registry, BuiltinCommands: true})
+
+	w := postChat(t, ctrl, `{"message":"/review main.go"}`)
+	if resp := decodeChatResponse(t, w.Body.Bytes()); resp.Reply != "reviewed" || resp.Command != "" {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+	if stub.lastReq.Task != "Review the changes in main.go" {
+		t.Fatalf("expected rewritten task, got %q", stub.lastReq.Task)
+	}
+
+	w = postChat(t, ctrl, `{"message":"/fail"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), ErrCodeCommandFailed) {
+		t.Fatalf("expected command failure, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = postChat(t, ctrl, `{"message":"/help"}`)
+	if resp := decodeChatResponse(t, w.Body.Bytes()); resp.Reply != "custom help" {
+		t.Fatalf("expected configured /help to override the built-in, got %q", resp.Reply)
+	}
+
+	postChat(t, ctrl, `{"message":"/some-skill arg"}`)
+	if stub.lastReq.Task != "/some-skill arg" {
+		t.Fatalf("expected unknown commands to reach the agent, got %q", stub.lastReq.Task)
+	}
+}
+
+func TestHandleChat_CommandsDisabledByDefault(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{Message: "ok"}}
+	ctrl := NewChatController(stub, ChatConfig{})
+
+	postChat(t, ctrl, `{"message":"/help"}`)
+	if stub.lastReq.Task != "/help" {
+		t.Fatalf("expected /help to reach the agent, got %q", stub.lastReq.Task)
+	}
+}
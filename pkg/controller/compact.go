@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+// CompactRequest is the optional JSON body for POST /api/chat/{session}/compact.
+type CompactRequest struct {
+	// KeepRecent is the number of recent messages kept verbatim. Zero uses
+	// the agent's default.
+	KeepRecent int `json:"keep_recent,omitempty"`
+}
+
+// CompactResponse is the JSON response from POST /api/chat/{session}/compact.
+type CompactResponse struct {
+	SessionID      string `json:"session_id"`
+	Summary        string `json:"summary"`
+	MessagesBefore int    `json:"messages_before"`
+	MessagesAfter  int    `json:"messages_after"`
+	TokensBefore   int    `json:"tokens_before"`
+	TokensAfter    int    `json:"tokens_after"`
+	TokensSaved    int    `json:"tokens_saved"`
+}
+
+// HandleCompact summarizes a session's stored history right away instead of
+// waiting for the compaction threshold to trip during a run. Later runs in
+// the session continue from the summary. It requires the admin token.
+func (c *ChatController) HandleCompact(w http.ResponseWriter, r *http.Request) {
+	if !c.requireAdmin(w, r) {
+		return
+	}
+	compacter, ok := c.agent.(agent.Compacter)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, ErrorResponse{Error: "agent does not support compaction", Code: ErrCodeCompactionUnsupported})
+		return
+	}
+
+	var req CompactRequest
+	if reqErr := c.decodeBody(w, r, &req, true); reqErr != nil {
+		writeJSON(w, reqErr.status, reqErr.response())
+		return
+	}
+	resp, reqErr := c.compactSession(r.Context(), compacter, r.PathValue("session"), req.KeepRecent)
+	if reqErr != nil {
+		writeJSON(w, reqErr.status, reqErr.response())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// compactSession summarizes a session's stored history. The shared default
+// session has no history that runs continue, so it cannot be compacted.
+func (c *ChatController) compactSession(ctx context.Context, compacter agent.Compacter, sessionID string, keepRecent int) (CompactResponse, *requestError) {
+	if keepRecent < 0 {
+		return CompactResponse{}, badRequest("keep_recent must not be negative")
+	}
+	history, ok := c.sessions.history(sessionID)
+	if !ok || len(history) == 0 || sessionID == defaultSessionID {
+		return CompactResponse{}, &requestError{status: http.StatusNotFound, code: ErrCodeSessionNotFound, message: "session not found or has no history"}
+	}
+
+	result, err := compacter.Compact(ctx, agent.CompactRequest{
+		Messages:   history,
+		KeepRecent: keepRecent,
+	})
+	if err != nil {
+		log.Printf("[chat-controller] compaction of session %s failed: %v", sessionID, err)
+		return CompactResponse{}, &requestError{status: http.StatusInternalServerError, code: ErrCodeAgentFailed, message: "compaction failed: " + err.Error()}
+	}
+	if result.Summary != "" {
+		// Runs that finished while compacting appended after history.
+		c.sessions.replaceHistory(sessionID, len(history), result.Messages)
+	}
+
+	return CompactResponse{
+		SessionID:      sessionID,
+		Summary:        result.Summary,
+		MessagesBefore: len(history),
+		MessagesAfter:  len(result.Messages),
+		TokensBefore:   result.TokensBefore,
+		TokensAfter:    result.TokensAfter,
+		TokensSaved:    result.TokensSaved(),
+	}, nil
+}
+
+// streamTranscript rebuilds a text-only conversation from stream events,
+// since streaming runs do not return the full message history.
+type streamTranscript struct {
+	messages []agenttypes.Message
+}
+
+func newStreamTranscript(task string) *streamTranscript {
+	return &streamTranscript{
+		messages: []agenttypes.Message{agenttypes.NewTextMessage(agenttypes.RoleUser, task)},
+	}
+}
+
+func (t *streamTranscript) observe(evt agent.AgentStreamEvent) {
+	if evt.Type == agent.AgentEventMessageEnd && evt.Message != "" {
+		t.messages = append(t.messages, agenttypes.NewTextMessage(agenttypes.RoleAssistant, evt.Message))
+	}
+}
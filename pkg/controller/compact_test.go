@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+// compactingStub adds agent.Compacter to stubAgent. It keeps the last
+// message and replaces the rest with a summary.
+type compactingStub struct {
+	stubAgent
+	compactReq agent.CompactRequest
+	compactErr error
+}
+
+func (s *compactingStub) Compact(_ context.Context, req agent.CompactRequest) (agent.CompactResult, error) {
+	s.compactReq = req
+	if s.compactErr != nil {
+		return agent.CompactResult{}, s.compactErr
+	}
+	summary := agenttypes.NewTextMessage(agenttypes.RoleUser, "summary")
+	return agent.CompactResult{
+		Messages:          []agenttypes.Message{summary, req.Messages[len(req.Messages)-1]},
+		Summary:           "summary",
+		CompactedMessages: len(req.Messages) - 1,
+		TokensBefore:      100,
+		TokensAfter:       40,
+	}, nil
+}
+
+// Execute returns the run's messages after req.History, as APIAgent does.
+func (s *compactingStub) Execute(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+	result, err := s.stubAgent.Execute(ctx, req)
+	result.RawOutput = append(append([]agenttypes.Message(nil), req.History...), result.RawOutput...)
+	return result, err
+}
+
+func postCompact(t *testing.T, ctrl *ChatController, session, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/"+session+"/compact", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+ctrl.cfg.AdminToken)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleCompact_CompactsSessionHistory(t *testing.T) {
+	stub := &compactingStub{stubAgent: stubAgent{result: agent.AgentResult{
+		Message: "done",
+		RawOutput: []agenttypes.Message{
+			agenttypes.NewTextMessage(agenttypes.RoleUser, "hello"),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "done"),
+		},
+	}}}
+	ctrl := NewChatController(stub, ChatConfig{AdminToken: "secret"})
+
+	for i := 0; i < 2; i++ {
+		if w := postChat(t, ctrl, `{"message":"hello","session_id":"s1"}`); w.Code != http.StatusOK {
+			t.Fatalf("chat %d: expected 200, got %d: %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	w := postCompact(t, ctrl, "s1", `{"keep_recent":3}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp CompactResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := CompactResponse{
+		SessionID:      "s1",
+		Summary:        "summary",
+		MessagesBefore: 4,
+		MessagesAfter:  2,
+		TokensBefore:   100,
+		TokensAfter:    40,
+		TokensSaved:    60,
+	}
+	if resp != want {
+		t.Fatalf("unexpected response: %+v, want %+v", resp, want)
+	}
+	if stub.compactReq.KeepRecent != 3 || len(stub.compactReq.Messages) != 4 {
+		t.Fatalf("unexpected compact request: %+v", stub.compactReq)
+	}
+
+	history, _ := ctrl.sessions.history("s1")
+	if len(history) != 2 || history[0].GetText() != "summary" {
+		t.Fatalf("expected compacted history to be stored, got %+v", history)
+	}
+}
+
+func TestHandleCompact_RecordsStreamTranscript(t *testing.T) {
+	stub := &compactingStub{stubAgent: stubAgent{stream: []agent.AgentStreamEvent{
+		{Type: agent.AgentEventAgentStart},
+		{Type: agent.AgentEventMessageEnd, Message: "Hello"},
+		{Type: agent.AgentEventAgentEnd},
+	}}}
+	ctrl := NewChatController(stub, ChatConfig{EnableStreaming: true, AdminToken: "secret"})
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/stream", bytes.NewBufferString(`{"message":"hello","session_id":"s1"}`))
+	ctrl.HandleChatStream(httptest.NewRecorder(), req)
+
+	w := postCompact(t, ctrl, "s1", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	msgs := stub.compactReq.Messages
+	if len(msgs) != 2 || msgs[0].GetText() != "hello" || msgs[1].GetText() != "Hello" {
+		t.Fatalf("unexpected stream transcript: %+v", msgs)
+	}
+}
+
+func TestHandleCompact_Errors(t *testing.T) {
+	withHistory := func(a agent.Agent) *ChatController {
+		ctrl := NewChatController(a, ChatConfig{AdminToken: "secret"})
+		for _, id := range []string{"s1", defaultSessionID} {
+			ctrl.sessions.begin(id)
+			ctrl.sessions.appendHistory(id, []agenttypes.Message{agenttypes.NewTextMessage(agenttypes.RoleUser, "hi")})
+		}
+		return ctrl
+	}
+
+	tests := []struct {
+		name     string
+		ctrl     *ChatController
+		session  string
+		body     string
+		wantCode int
+		wantErr  string
+	}{
+		{
+			name:     "unsupported agent",
+			ctrl:     withHistory(&stubAgent{}),
+			session:  "s1",
+			wantCode: http.StatusNotImplemented,
+			wantErr:  ErrCodeCompactionUnsupported,
+		},
+		{
+			name:     "unknown session",
+			ctrl:     withHistory(&compactingStub{}),
+			session:  "missing",
+			wantCode: http.StatusNotFound,
+			wantErr:  ErrCodeSessionNotFound,
+		},
+		{
+			name:     "default session",
+			ctrl:     withHistory(&compactingStub{}),
+			session:  defaultSessionID,
+			wantCode: http.StatusNotFound,
+			wantErr:  ErrCodeSessionNotFound,
+		},
+		{
+			name:     "invalid body",
+			ctrl:     withHistory(&compactingStub{}),
+			session:  "s1",
+			body:     `{"keep_recent":-1}`,
+			wantCode: http.StatusBadRequest,
+			wantErr:  ErrCodeInvalidRequest,
+		},
+		{
+			name:     "compaction failure",
+			ctrl:     withHistory(&compactingStub{compactErr: errors.New("provider down")}),
+			session:  "s1",
+			wantCode: http.StatusInternalServerError,
+			wantErr:  ErrCodeAgentFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postCompact(t, tt.ctrl, tt.session, tt.body)
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Code != tt.wantErr {
+				t.Fatalf("expected code %q, got %q", tt.wantErr, resp.Code)
+			}
+		})
+	}
+}
+
+func TestHandleCompact_RequiresAdmin(t *testing.T) {
+	ctrl := NewChatController(&compactingStub{}, ChatConfig{AdminToken: "secret"})
+	ctrl.sessions.begin("s1")
+	ctrl.sessions.appendHistory("s1", []agenttypes.Message{agenttypes.NewTextMessage(agenttypes.RoleUser, "hi")})
+
+	w := httptest.NewRecorder()
+	ctrl.HandleCompact(w, httptest.NewRequest(http.MethodPost, "/api/chat/s1/compact", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", w.Code)
+	}
+	if history, _ := ctrl.sessions.history("s1"); len(history) != 1 {
+		t.Fatalf("expected history to be left alone, got %+v", history)
+	}
+}
+
+func TestHandleChat_ContinuesSessionHistory(t *testing.T) {
+	stub := &compactingStub{stubAgent: stubAgent{result: agent.AgentResult{
+		Message: "done",
+		RawOutput: []agenttypes.Message{
+			agenttypes.NewTextMessage(agenttypes.RoleUser, "hello"),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "done"),
+		},
+	}}}
+	ctrl := NewChatController(stub, ChatConfig{AdminToken: "secret"})
+
+	postChat(t, ctrl, `{"message":"hello","session_id":"s1"}`)
+	if len(stub.lastReq.History) != 0 {
+		t.Fatalf("expected the first run to start fresh, got %+v", stub.lastReq.History)
+	}
+	postChat(t, ctrl, `{"message":"hello","session_id":"s1"}`)
+	if len(stub.lastReq.History) != 2 {
+		t.Fatalf("expected the second run to continue the first, got %+v", stub.lastReq.History)
+	}
+
+	if w := postCompact(t, ctrl, "s1", ""); w.Code != http.StatusOK {
+		t.Fatalf("compact = %d: %s", w.Code, w.Body.String())
+	}
+	postChat(t, ctrl, `{"message":"hello","session_id":"s1"}`)
+	if history := stub.lastReq.History; len(history) != 2 || history[0].GetText() != "summary" {
+		t.Fatalf("expected the run after compaction to continue from the summary, got %+v", history)
+	}
+	if history, _ := ctrl.sessions.history("s1"); len(history) != 4 {
+		t.Fatalf("expected the run to be stored after the summary, got %d messages", len(history))
+	}
+
+	postChat(t, ctrl, `{"message":"hello"}`)
+	postChat(t, ctrl, `{"message":"hello"}`)
+	if len(stub.lastReq.History) != 0 {
+		t.Fatalf("expected runs in the default session to start fresh, got %+v", stub.lastReq.History)
+	}
+}
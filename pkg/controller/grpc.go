@@ -0,0 +1,295 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/agentpb"
+)
+
+// AgentService serves an agent over gRPC (proto/agentcore/v1/agent.proto)
+// for services that prefer a typed contract to the HTTP API. It shares
+// ChatConfig with ChatController: SystemPrompt, SoulFile, DefaultDir,
+// EnableStreaming, Limits.MaxMessageBytes, Limits.AllowedWorkDirs, and
+// Profiles apply the same way. Sessions, stream resume, slash commands, and
+// repo checkout are HTTP-only.
+type AgentService struct {
+	agentpb.UnimplementedAgentServiceServer
+
+	agent agent.Agent
+	cfg   ChatConfig
+
+	mu   sync.Mutex
+	runs map[string]context.CancelFunc
+}
+
+// NewAgentService creates an AgentService.
+func NewAgentService(a agent.Agent, cfg ChatConfig) *AgentService {
+	if cfg.DefaultDir == "" {
+		cfg.DefaultDir = "."
+	}
+	return &AgentService{
+		agent: a,
+		cfg:   cfg,
+		runs:  make(map[string]context.CancelFunc),
+	}
+}
+
+// Register registers the service on srv.
+func (s *AgentService) Register(srv *grpc.Server) {
+	agentpb.RegisterAgentServiceServer(srv, s)
+}
+
+// Execute runs the agent to completion.
+func (s *AgentService) Execute(ctx context.Context, req *agentpb.ExecuteRequest) (*agentpb.ExecuteResponse, error) {
+	a, agentReq, err := s.agentRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx, runID, err := s.startRun(ctx, req.GetRunId())
+	if err != nil {
+		return nil, err
+	}
+	defer s.finishRun(runID)
+
+	result, err := a.Execute(ctx, agentReq)
+	if err != nil {
+		log.Printf("[grpc-controller] agent error: %v", err)
+		return nil, executionStatus(ctx, err)
+	}
+	return executeResponse(runID, result), nil
+}
+
+// ExecuteStream runs the agent and streams its events.
+func (s *AgentService) ExecuteStream(req *agentpb.ExecuteRequest, stream agentpb.AgentService_ExecuteStreamServer) error {
+	if !s.cfg.EnableStreaming {
+		return status.Error(codes.Unimplemented, "streaming is disabled")
+	}
+	a, agentReq, err := s.agentRequest(req)
+	if err != nil {
+		return err
+	}
+	agentReq.Options.EnableStreaming = true
+	agentReq.Options.StreamBuffer = s.cfg.StreamBuffer
+	ctx, runID, err := s.startRun(stream.Context(), req.GetRunId())
+	if err != nil {
+		return err
+	}
+	defer s.finishRun(runID)
+
+	events, errs := a.ExecuteStream(ctx, agentReq)
+	for events != nil || errs != nil {
+		select {
+		case <-ctx.Done():
+			return executionStatus(ctx, ctx.Err())
+		case evt, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if err := stream.Send(streamEventProto(runID, evt)); err != nil {
+				return err
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if err != nil {
+				log.Printf("[grpc-controller] agent stream error: %v", err)
+				return executionStatus(ctx, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Cancel stops an in-progress run. Unknown and finished runs report
+// Cancelled false.
+func (s *AgentService) Cancel(_ context.Context, req *agentpb.CancelRequest) (*agentpb.CancelResponse, error) {
+	s.mu.Lock()
+	cancel, ok := s.runs[req.GetRunId()]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+		log.Printf("[grpc-controller] cancelled run %s", req.GetRunId())
+	}
+	return &agentpb.CancelResponse{RunId: req.GetRunId(), Cancelled: ok}, nil
+}
+
+// Capabilities describes the default agent or a profile's agent.
+func (s *AgentService) Capabilities(_ context.Context, req *agentpb.CapabilitiesRequest) (*agentpb.CapabilitiesResponse, error) {
+	a := s.agent
+	if profile := req.GetProfile(); profile != "" {
+		var ok bool
+		if a, ok = s.cfg.Profiles[profile]; !ok {
+			return nil, status.Errorf(codes.NotFound, "unknown profile %q", profile)
+		}
+	}
+	caps := a.Capabilities()
+	resp := &agentpb.CapabilitiesResponse{
+		SupportsTools:      caps.SupportsTools,
+		SupportsStreaming:  caps.SupportsStreaming,
+		SupportsCompaction: caps.SupportsCompaction,
+		MaxContextTokens:   int32(caps.MaxContextTokens),
+		Provider:           caps.Provider,
+	}
+	for _, tool := range caps.AvailableTools {
+		resp.Tools = append(resp.Tools, &agentpb.ToolInfo{Name: tool.Name, Description: tool.Description})
+	}
+	return resp, nil
+}
+
+// agentRequest validates req and builds the agent request for it.
+func (s *AgentService) agentRequest(req *agentpb.ExecuteRequest) (agent.Agent, agent.AgentRequest, error) {
+	chatReq := ChatRequest{Message: req.GetMessage(), WorkDir: req.GetWorkDir(), Profile: req.GetProfile()}
+	workDir, reqErr := s.cfg.validateChatRequest(chatReq)
+	if reqErr != nil {
+		return nil, agent.AgentRequest{}, requestStatus(reqErr)
+	}
+
+	a, systemPrompt := s.agent, s.cfg.SystemPrompt
+	if req.GetProfile() != "" {
+		a, systemPrompt = s.cfg.Profiles[req.GetProfile()], ""
+	}
+	return a, agent.AgentRequest{
+		Task:         req.GetMessage(),
+		SystemPrompt: systemPrompt,
+		SoulFile:     s.cfg.SoulFile,
+		WorkDir:      workDir,
+	}, nil
+}
+
+// startRun registers a cancellable run under runID, generating one when
+// empty.
+func (s *AgentService) startRun(ctx context.Context, runID string) (context.Context, string, error) {
+	if runID == "" {
+		runID = newRunID()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.runs[runID]; exists {
+		cancel()
+		return nil, "", status.Errorf(codes.AlreadyExists, "run %s is already in progress", runID)
+	}
+	s.runs[runID] = cancel
+	return ctx, runID, nil
+}
+
+func (s *AgentService) finishRun(runID string) {
+	s.mu.Lock()
+	cancel := s.runs[runID]
+	delete(s.runs, runID)
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// requestStatus converts a rejected request to a gRPC status carrying the
+// HTTP API's error code.
+func requestStatus(reqErr *requestError) error {
+	code := codes.InvalidArgument
+	if reqErr.status == http.StatusRequestEntityTooLarge {
+		code = codes.ResourceExhausted
+	}
+	return status.Errorf(code, "%s: %s", reqErr.code, reqErr.message)
+}
+
+// executionStatus converts an execution error to a gRPC status.
+func executionStatus(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return status.Error(codes.Canceled, "run cancelled")
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "run deadline exceeded")
+	default:
+		return status.Errorf(codes.Internal, "%s: agent execution failed: %v", ErrCodeAgentFailed, err)
+	}
+}
+
+func executeResponse(runID string, result agent.AgentResult) *agentpb.ExecuteResponse {
+	resp := &agentpb.ExecuteResponse{
+		RunId:         runID,
+		Reply:         result.Message,
+		Usage:         usageProto(result.Usage),
+		Outcome:       string(result.Outcome),
+		OutcomeReason: result.OutcomeReason,
+		StopReason:    string(result.Metadata.StopReason),
+		Model:         result.Metadata.Model,
+		Provider:      result.Metadata.Provider,
+	}
+	for _, call := range result.ToolCalls {
+		resp.ToolCalls = append(resp.ToolCalls, &agentpb.ToolCall{
+			Name:      call.Name,
+			Input:     structProto(call.Input),
+			Output:    call.Output,
+			IsError:   call.IsError,
+			ErrorCode: call.ErrorCode,
+		})
+	}
+	return resp
+}
+
+func streamEventProto(runID string, evt agent.AgentStreamEvent) *agentpb.StreamEvent {
+	out := &agentpb.StreamEvent{
+		RunId:      runID,
+		Type:       string(evt.Type),
+		Delta:      evt.Delta,
+		Message:    evt.Message,
+		ToolName:   evt.ToolName,
+		ToolCallId: evt.ToolCallID,
+		ToolInput:  structProto(evt.ToolInput),
+		IsError:    evt.IsError,
+		WaitMs:     evt.WaitMs,
+		Options:    evt.Options,
+		Action:     evt.Action,
+		ErrorCode:  evt.ErrorCode,
+	}
+	if evt.Usage != nil {
+		out.Usage = usageProto(*evt.Usage)
+	}
+	return out
+}
+
+func usageProto(usage agent.ExecutionUsage) *agentpb.Usage {
+	return &agentpb.Usage{
+		Iterations:        int32(usage.TotalIterations),
+		InputTokens:       int64(usage.TotalInputTokens),
+		OutputTokens:      int64(usage.TotalOutputTokens),
+		CachedInputTokens: int64(usage.TotalCachedInputTokens),
+		EstimatedCost:     usage.EstimatedCost,
+	}
+}
+
+// structProto converts tool input to a Struct. Values structpb cannot
+// represent directly, e.g. typed slices, go through a JSON round trip.
+func structProto(m map[string]any) *structpb.Struct {
+	if m == nil {
+		return nil
+	}
+	if s, err := structpb.NewStruct(m); err == nil {
+		return s
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	var generic map[string]any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+	s, _ := structpb.NewStruct(generic)
+	return s
+}
@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/agentpb"
+)
+
+// dialAgentService serves an AgentService over an in-memory listener.
+func dialAgentService(t *testing.T, a agent.Agent, cfg ChatConfig) agentpb.AgentServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	NewAgentService(a, cfg).Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return agentpb.NewAgentServiceClient(conn)
+}
+
+func TestAgentServiceExecute(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{
+		Message: "done",
+		Usage:   agent.ExecutionUsage{TotalIterations: 2, TotalInputTokens: 10, TotalOutputTokens: 5},
+		ToolCalls: []agent.ToolCallRecord{
+			{Name: "read_file", Input: map[string]any{"path": "a.go"}, IsError: true, ErrorCode: "file_not_found"},
+		},
+		Outcome: agent.OutcomeProceed,
+	}}
+	client := dialAgentService(t, stub, ChatConfig{SystemPrompt: "be brief", DefaultDir: "/tmp"})
+
+	resp, err := client.Execute(context.Background(), &agentpb.ExecuteRequest{Message: "hi", RunId: "run-1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if resp.GetRunId() != "run-1" || resp.GetReply() != "done" || resp.GetOutcome() != "proceed" {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+	if resp.GetUsage().GetIterations() != 2 || resp.GetUsage().GetOutputTokens() != 5 {
+		t.Fatalf("unexpected usage: %v", resp.GetUsage())
+	}
+	calls := resp.GetToolCalls()
+	if len(calls) != 1 || calls[0].GetErrorCode() != "file_not_found" || calls[0].GetInput().AsMap()["path"] != "a.go" {
+		t.Fatalf("unexpected tool calls: %v", calls)
+	}
+	if stub.lastReq.Task != "hi" || stub.lastReq.SystemPrompt != "be brief" || stub.lastReq.WorkDir != "/tmp" {
+		t.Fatalf("unexpected agent request: %+v", stub.lastReq)
+	}
+}
+
+func TestAgentServiceExecuteRejectsInvalidRequests(t *testing.T) {
+	client := dialAgentService(t, &stubAgent{}, ChatConfig{Limits: RequestLimits{MaxMessageBytes: 4}})
+
+	tests := []struct {
+		name string
+		req  *agentpb.ExecuteRequest
+		code codes.Code
+	}{
+		{"empty message", &agentpb.ExecuteRequest{}, codes.InvalidArgument},
+		{"message too long", &agentpb.ExecuteRequest{Message: "hello"}, codes.ResourceExhausted},
+		{"unknown profile", &agentpb.ExecuteRequest{Message: "hi", Profile: "nope"}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Execute(context.Background(), tt.req)
+			if status.Code(err) != tt.code {
+				t.Fatalf("expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestAgentServiceExecuteStream(t *testing.T) {
+	stub := &stubAgent{stream: []agent.AgentStreamEvent{
+		{Type: agent.AgentEventAgentStart},
+		{Type: agent.AgentEventToolCall, ToolName: "bash", ToolInput: map[string]any{"command": "ls"}},
+		{Type: agent.AgentEventAgentEnd, Message: "done", Usage: &agent.ExecutionUsage{TotalIterations: 1}},
+	}}
+	client := dialAgentService(t, stub, ChatConfig{EnableStreaming: true})
+
+	stream, err := client.ExecuteStream(context.Background(), &agentpb.ExecuteRequest{Message: "hi"})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var events []*agentpb.StreamEvent
+	for {
+		evt, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		events = append(events, evt)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].GetRunId() == "" || events[2].GetRunId() != events[0].GetRunId() {
+		t.Fatalf("expected events to share a run ID, got %q and %q", events[0].GetRunId(), events[2].GetRunId())
+	}
+	if events[1].GetToolInput().AsMap()["command"] != "ls" {
+		t.Fatalf("unexpected tool input: %v", events[1].GetToolInput())
+	}
+	if events[2].GetType() != "agent_end" || events[2].GetUsage().GetIterations() != 1 {
+		t.Fatalf("unexpected final event: %v", events[2])
+	}
+	if !stub.lastReq.Options.EnableStreaming {
+		t.Fatal("expected streaming to be enabled on the agent request")
+	}
+}
+
+func TestAgentServiceExecuteStreamReportsAgentError(t *testing.T) {
+	stub := &stubAgent{streamErr: errors.New("boom")}
+	client := dialAgentService(t, stub, ChatConfig{EnableStreaming: true})
+
+	stream, err := client.ExecuteStream(context.Background(), &agentpb.ExecuteRequest{Message: "hi"})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Internal {
+		t.Fatalf("expected Internal, got %v", err)
+	}
+}
+
+func TestAgentServiceExecuteStreamDisabled(t *testing.T) {
+	client := dialAgentService(t, &stubAgent{}, ChatConfig{})
+
+	stream, err := client.ExecuteStream(context.Background(), &agentpb.ExecuteRequest{Message: "hi"})
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected Unimplemented, got %v", err)
+	}
+}
+
+// blockingAgent runs until its context is cancelled.
+type blockingAgent struct {
+	stubAgent
+	started chan struct{}
+}
+
+func (b *blockingAgent) Execute(ctx context.Context, _ agent.AgentRequest) (agent.AgentResult, error) {
+	close(b.started)
+	<-ctx.Done()
+	return agent.AgentResult{}, ctx.Err()
+}
+
+func TestAgentServiceCancel(t *testing.T) {
+	blocking := &blockingAgent{started: make(chan struct{})}
+	client := dialAgentService(t, blocking, ChatConfig{})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.Execute(context.Background(), &agentpb.ExecuteRequest{Message: "hi", RunId: "run-1"})
+		errCh <- err
+	}()
+	<-blocking.started
+
+	resp, err := client.Cancel(context.Background(), &agentpb.CancelRequest{RunId: "run-1"})
+	if err != nil || !resp.GetCancelled() {
+		t.Fatalf("expected run to be cancelled, got %v, %v", resp, err)
+	}
+	if err := <-errCh; status.Code(err) != codes.Canceled {
+		t.Fatalf("expected Canceled, got %v", err)
+	}
+
+	resp, err = client.Cancel(context.Background(), &agentpb.CancelRequest{RunId: "run-1"})
+	if err != nil || resp.GetCancelled() {
+		t.Fatalf("expected finished run not to be cancelled, got %v, %v", resp, err)
+	}
+}
+
+func TestAgentServiceCapabilities(t *testing.T) {
+	client := dialAgentService(t, &stubAgent{}, ChatConfig{})
+
+	if _, err := client.Capabilities(context.Background(), &agentpb.CapabilitiesRequest{}); err != nil {
+		t.Fatalf("Capabilities: %v", err)
+	}
+	_, err := client.Capabilities(context.Background(), &agentpb.CapabilitiesRequest{Profile: "nope"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
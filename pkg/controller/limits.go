@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Request error codes returned in ErrorResponse.Code.
+const (
+	ErrCodeInvalidRequest    = "invalid_request"
+	ErrCodeRequestTooLarge   = "request_too_large"
+	ErrCodeMessageTooLong    = "message_too_long"
+	ErrCodeInvalidWorkDir    = "invalid_work_dir"
+	ErrCodeStreamingDisabled = "streaming_disabled"
+	ErrCodeAgentFailed       = "agent_failed"
+	ErrCodeUnknownProfile    = "unknown_profile"
+
+	ErrCodeRepoCheckoutDisabled = "repo_checkout_disabled"
+	ErrCodeCheckoutConflict     = "checkout_conflict"
+	ErrCodeCheckoutFailed       = "checkout_failed"
+)
+
+// defaultMaxBodyBytes caps request bodies when RequestLimits.MaxBodyBytes
+// is zero.
+const defaultMaxBodyBytes = 1 << 20
+
+// RequestLimits guards the chat endpoints against oversized payloads and
+// work_dir values outside the directories the server is meant to expose.
+type RequestLimits struct {
+	// MaxBodyBytes caps request bodies (default 1 MiB; negative disables).
+	MaxBodyBytes int64
+
+	// MaxMessageBytes caps ChatRequest.Message. Zero is unlimited.
+	MaxMessageBytes int
+
+	// MaxReplyBytes truncates ChatResponse.Reply and sets Truncated.
+	// Zero is unlimited. Streamed replies are not truncated.
+	MaxReplyBytes int
+
+	// MaxHistoryOutputBytes collapses tool outputs longer than this in
+	// GET /api/chat/{session}/messages (default 2048; negative disables).
+	MaxHistoryOutputBytes int
+
+	// AllowedWorkDirs restricts ChatRequest.WorkDir. When set, a requested
+	// work_dir must be inside one of these roots, after resolving symlinks.
+	// Empty accepts any work_dir. Either way it must be an existing
+	// directory.
+	AllowedWorkDirs []string
+}
+
+// requestError describes a rejected request.
+type requestError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *requestError) response() ErrorResponse {
+	return ErrorResponse{Error: e.message, Code: e.code}
+}
+
+func badRequest(message string) *requestError {
+	return &requestError{status: http.StatusBadRequest, code: ErrCodeInvalidRequest, message: message}
+}
+
+// decodeBody decodes the JSON body into v within MaxBodyBytes. An empty
+// body is accepted when allowEmpty is set.
+func (c *ChatController) decodeBody(w http.ResponseWriter, r *http.Request, v any, allowEmpty bool) *requestError {
+	body := r.Body
+	if limit := c.cfg.Limits.maxBodyBytes(); limit > 0 {
+		body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	err := json.NewDecoder(body).Decode(v)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil, allowEmpty && errors.Is(err, io.EOF):
+		return nil
+	case errors.As(err, &tooLarge):
+		return &requestError{
+			status:  http.StatusRequestEntityTooLarge,
+			code:    ErrCodeRequestTooLarge,
+			message: fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit),
+		}
+	default:
+		return badRequest("invalid JSON: " + err.Error())
+	}
+}
+
+// decodeChatRequest reads and validates a ChatRequest and returns it with
+// the working directory the run should use.
+func (c *ChatController) decodeChatRequest(w http.ResponseWriter, r *http.Request) (ChatRequest, string, *requestError) {
+	var req ChatRequest
+	if reqErr := c.decodeBody(w, r, &req, false); reqErr != nil {
+		return req, "", reqErr
+	}
+	workDir, reqErr := c.cfg.validateChatRequest(req)
+	return req, workDir, reqErr
+}
+
+// validateChatRequest checks req against the configured limits and
+// profiles and returns the working directory the run should use.
+func (c ChatConfig) validateChatRequest(req ChatRequest) (string, *requestError) {
+	if req.Message == "" {
+		return "", badRequest("message is required")
+	}
+	if limit := c.Limits.MaxMessageBytes; limit > 0 && len(req.Message) > limit {
+		return "", &requestError{
+			status:  http.StatusRequestEntityTooLarge,
+			code:    ErrCodeMessageTooLong,
+			message: fmt.Sprintf("message exceeds %d bytes", limit),
+		}
+	}
+
+	if _, ok := c.Profiles[req.Profile]; req.Profile != "" && !ok {
+		return "", &requestError{status: http.StatusBadRequest, code: ErrCodeUnknownProfile, message: fmt.Sprintf("unknown profile %q", req.Profile)}
+	}
+
+	if req.Repo != "" {
+		switch {
+		case c.Workspaces == nil:
+			return "", &requestError{status: http.StatusBadRequest, code: ErrCodeRepoCheckoutDisabled, message: "repo checkout is not enabled on this server"}
+		case req.WorkDir != "":
+			return "", badRequest("repo and work_dir are mutually exclusive")
+		}
+		// checkoutRepo provides the directory once the session is known.
+		return "", nil
+	}
+	if req.WorkDir == "" {
+		return c.DefaultDir, nil
+	}
+	workDir, err := c.Limits.resolveWorkDir(req.WorkDir)
+	if err != nil {
+		return "", &requestError{status: http.StatusBadRequest, code: ErrCodeInvalidWorkDir, message: err.Error()}
+	}
+	return workDir, nil
+}
+
+func (l RequestLimits) maxBodyBytes() int64 {
+	if l.MaxBodyBytes == 0 {
+		return defaultMaxBodyBytes
+	}
+	return l.MaxBodyBytes
+}
+
+func (l RequestLimits) maxHistoryOutputBytes() int {
+	if l.MaxHistoryOutputBytes == 0 {
+		return defaultHistoryOutputBytes
+	}
+	return l.MaxHistoryOutputBytes
+}
+
+// resolveWorkDir returns dir if it is an existing directory. With
+// AllowedWorkDirs set, it must also be inside one of them and is returned
+// with symlinks resolved; the lexical path is checked first so the error
+// does not reveal whether paths outside the roots exist.
+func (l RequestLimits) resolveWorkDir(dir string) (string, error) {
+	if len(l.AllowedWorkDirs) == 0 {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return "", errors.New("work_dir does not exist")
+		}
+		if !info.IsDir() {
+			return "", errors.New("work_dir is not a directory")
+		}
+		return dir, nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid work_dir: %v", err)
+	}
+	if !l.allowsWorkDir(abs, false) {
+		return "", errors.New("work_dir is outside the allowed directories")
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", errors.New("work_dir does not exist")
+	}
+	if info, err := os.Stat(resolved); err != nil || !info.IsDir() {
+		return "", errors.New("work_dir is not a directory")
+	}
+	if !l.allowsWorkDir(resolved, true) {
+		return "", errors.New("work_dir is outside the allowed directories")
+	}
+	return resolved, nil
+}
+
+func (l RequestLimits) allowsWorkDir(path string, resolveRoots bool) bool {
+	for _, root := range l.AllowedWorkDirs {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if resolveRoots {
+			if resolved, err := filepath.EvalSymlinks(root); err == nil {
+				root = resolved
+			}
+		}
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateReply cuts reply to at most limit bytes on a UTF-8 boundary.
+func truncateReply(reply string, limit int) (string, bool) {
+	if limit <= 0 || len(reply) <= limit {
+		return reply, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(reply[cut]) {
+		cut--
+	}
+	return reply[:cut], true
+}
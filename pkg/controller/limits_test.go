@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+func decodeError(t *testing.T, body []byte) ErrorResponse {
+	t.Helper()
+	var resp ErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	return resp
+}
+
+func TestHandleChat_RequestSizeLimits(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{Message: "ok"}}
+	ctrl := NewChatController(stub, ChatConfig{
+		Limits: RequestLimits{MaxBodyBytes: 64, MaxMessageBytes: 10},
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"body too large", fmt.Sprintf(`{"message":%q}`, strings.Repeat("a", 100)), http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge},
+		{"message too long", `{"message":"hello world!"}`, http.StatusRequestEntityTooLarge, ErrCodeMessageTooLong},
+		{"invalid JSON", `{`, http.StatusBadRequest, ErrCodeInvalidRequest},
+		{"missing message", `{}`, http.StatusBadRequest, ErrCodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postChat(t, ctrl, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := decodeError(t, w.Body.Bytes()).Code; got != tt.wantCode {
+				t.Fatalf("code = %q, want %q", got, tt.wantCode)
+			}
+		})
+	}
+
+	if w := postChat(t, ctrl, `{"message":"hi"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected small request to pass, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleChat_TruncatesReply(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{Message: "héllo world"}}
+	ctrl := NewChatController(stub, ChatConfig{Limits: RequestLimits{MaxReplyBytes: 2}})
+
+	w := postChat(t, ctrl, `{"message":"hi"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ChatResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	// "é" spans bytes 1-2, so the cut backs off to a rune boundary.
+	if resp.Reply != "h" || !resp.Truncated {
+		t.Fatalf("reply = %q truncated = %v, want %q true", resp.Reply, resp.Truncated, "h")
+	}
+}
+
+func TestHandleChat_WorkDirMustBeDirectory(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stub := &stubAgent{result: agent.AgentResult{Message: "ok"}}
+	ctrl := NewChatController(stub, ChatConfig{DefaultDir: root})
+
+	for workDir, wantErr := range map[string]string{
+		filepath.Join(root, "missing"): "does not exist",
+		file:                           "not a directory",
+	} {
+		w := postChat(t, ctrl, fmt.Sprintf(`{"message":"hi","work_dir":%q}`, workDir))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400: %s", workDir, w.Code, w.Body.String())
+		}
+		resp := decodeError(t, w.Body.Bytes())
+		if resp.Code != ErrCodeInvalidWorkDir || !strings.Contains(resp.Error, wantErr) {
+			t.Fatalf("%s: error = %+v, want %s containing %q", workDir, resp, ErrCodeInvalidWorkDir, wantErr)
+		}
+	}
+	if stub.lastReq.WorkDir != "" {
+		t.Fatalf("expected the agent not to run, got work dir %q", stub.lastReq.WorkDir)
+	}
+}
+
+func TestHandleChat_AllowedWorkDirs(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	if err := os.Mkdir(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	escape := filepath.Join(root, "escape")
+	if err := os.Symlink(outside, escape); err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &stubAgent{result: agent.AgentResult{Message: "ok"}}
+	ctrl := NewChatController(stub, ChatConfig{
+		DefaultDir: root,
+		Limits:     RequestLimits{AllowedWorkDirs: []string{root}},
+	})
+
+	rejected := []struct {
+		name    string
+		workDir string
+		wantErr string
+	}{
+		{"outside root", outside, "outside the allowed directories"},
+		{"dot-dot escape", filepath.Join(root, "..", "etc"), "outside the allowed directories"},
+		{"symlink escape", escape, "outside the allowed directories"},
+		{"missing", filepath.Join(root, "missing"), "does not exist"},
+		{"not a directory", file, "not a directory"},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postChat(t, ctrl, fmt.Sprintf(`{"message":"hi","work_dir":%q}`, tt.workDir))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			resp := decodeError(t, w.Body.Bytes())
+			if resp.Code != ErrCodeInvalidWorkDir || !strings.Contains(resp.Error, tt.wantErr) {
+				t.Fatalf("error = %+v, want %s containing %q", resp, ErrCodeInvalidWorkDir, tt.wantErr)
+			}
+		})
+	}
+
+	w := postChat(t, ctrl, fmt.Sprintf(`{"message":"hi","work_dir":%q}`, project))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want, _ := filepath.EvalSymlinks(project)
+	if stub.lastReq.WorkDir != want {
+		t.Fatalf("work dir = %q, want %q", stub.lastReq.WorkDir, want)
+	}
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+const (
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 500
+
+	// defaultHistoryOutputBytes collapses tool outputs when
+	// RequestLimits.MaxHistoryOutputBytes is zero.
+	defaultHistoryOutputBytes = 2048
+)
+
+// MessagesResponse is the JSON response from GET /api/chat/{session}/messages.
+type MessagesResponse struct {
+	SessionID string `json:"session_id"`
+
+	// Total is the number of stored messages; Offset is the index of the
+	// first returned one.
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+
+	Messages []HistoryMessage `json:"messages"`
+
+	// HasMore is set when messages after this page exist.
+	HasMore bool `json:"has_more"`
+}
+
+// HistoryMessage is a stored conversation message.
+type HistoryMessage struct {
+	Index   int                   `json:"index"`
+	Role    string                `json:"role"`
+	Content []HistoryContentBlock `json:"content"`
+}
+
+// HistoryContentBlock is one block of a HistoryMessage. Thinking signatures
+// and provider payloads are omitted.
+type HistoryContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+
+	// Tool use fields.
+	ID    string         `json:"id,omitempty"`
+	Name  string         `json:"name,omitempty"`
+	Input map[string]any `json:"input,omitempty"`
+
+	// Tool result fields. Outputs longer than
+	// RequestLimits.MaxHistoryOutputBytes are cut and marked Collapsed;
+	// ContentBytes is then the full size.
+	ToolUseID    string `json:"tool_use_id,omitempty"`
+	Content      string `json:"content,omitempty"`
+	IsError      bool   `json:"is_error,omitempty"`
+	Collapsed    bool   `json:"collapsed,omitempty"`
+	ContentBytes int    `json:"content_bytes,omitempty"`
+}
+
+// handleChatSessionGet serves GET /api/chat/{session}/{resource}. The
+// routes share one pattern because GET /api/chat/{session}/messages would
+// conflict with GET /api/chat/stream/{run_id}.
+func (c *ChatController) handleChatSessionGet(w http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("resource") {
+	case "messages":
+		c.HandleMessages(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// HandleMessages returns a page of a session's stored conversation so
+// frontends can render it after a reload. offset (default 0; negative counts
+// from the end) and limit (default 50, at most 500) select the page. It
+// requires the admin token, and the shared default session is never
+// readable.
+func (c *ChatController) HandleMessages(w http.ResponseWriter, r *http.Request) {
+	if !c.requireAdmin(w, r) {
+		return
+	}
+	offset, limit, reqErr := parsePage(r)
+	if reqErr != nil {
+		writeJSON(w, reqErr.status, reqErr.response())
+		return
+	}
+	sessionID := r.PathValue("session")
+	history, ok := c.sessions.history(sessionID)
+	if !ok || sessionID == defaultSessionID {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "session not found", Code: ErrCodeSessionNotFound})
+		return
+	}
+
+	total := len(history)
+	if offset < 0 {
+		offset = max(total+offset, 0)
+	}
+	offset = min(offset, total)
+	end := min(offset+limit, total)
+
+	resp := MessagesResponse{
+		SessionID: sessionID,
+		Total:     total,
+		Offset:    offset,
+		Messages:  make([]HistoryMessage, 0, end-offset),
+		HasMore:   end < total,
+	}
+	maxOutput := c.cfg.Limits.maxHistoryOutputBytes()
+	for i, msg := range history[offset:end] {
+		resp.Messages = append(resp.Messages, historyMessage(offset+i, msg, maxOutput))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parsePage reads the offset and limit query parameters.
+func parsePage(r *http.Request) (int, int, *requestError) {
+	offset, limit := 0, defaultMessagesLimit
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, badRequest("offset must be an integer")
+		}
+		offset = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxMessagesLimit {
+			return 0, 0, badRequest("limit must be between 1 and " + strconv.Itoa(maxMessagesLimit))
+		}
+		limit = n
+	}
+	return offset, limit, nil
+}
+
+func historyMessage(index int, msg agenttypes.Message, maxOutput int) HistoryMessage {
+	out := HistoryMessage{Index: index, Role: string(msg.Role), Content: make([]HistoryContentBlock, 0, len(msg.Content))}
+	for _, block := range msg.Content {
+		hb := HistoryContentBlock{
+			Type:      string(block.Type),
+			Text:      block.Text,
+			ID:        block.ID,
+			Name:      block.Name,
+			Input:     block.Input,
+			ToolUseID: block.ToolUseID,
+			Content:   block.Content,
+			IsError:   block.IsError,
+		}
+		if block.Type == agenttypes.ContentTypeThinking {
+			hb.Text = block.Thinking
+		}
+		if content, collapsed := truncateReply(block.Content, maxOutput); collapsed {
+			hb.Content = content
+			hb.Collapsed = true
+			hb.ContentBytes = len(block.Content)
+		}
+		out.Content = append(out.Content, hb)
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+func getMessages(t *testing.T, ctrl *ChatController, session, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+	req := httptest.NewRequest(http.MethodGet, "/api/chat/"+session+"/messages"+query, nil)
+	req.Header.Set("Authorization", "Bearer "+ctrl.cfg.AdminToken)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func decodeMessages(t *testing.T, w *httptest.ResponseRecorder) MessagesResponse {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp MessagesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestHandleMessages_PagesHistory(t *testing.T) {
+	longOutput := strings.Repeat("x", 100)
+	stub := &stubAgent{result: agent.AgentResult{
+		Message: "done",
+		RawOutput: []agenttypes.Message{
+			agenttypes.NewTextMessage(agenttypes.RoleUser, "list files"),
+			{Role: agenttypes.RoleAssistant, Content: []agenttypes.ContentBlock{{
+				Type:  agenttypes.ContentTypeToolUse,
+				ID:    "call-1",
+				Name:  "bash",
+				Input: map[string]any{"command": "ls"},
+			}}},
+			agenttypes.NewToolResultMessage("call-1", longOutput, false),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "done"),
+		},
+	}}
+	ctrl := NewChatController(stub, ChatConfig{AdminToken: "secret", Limits: RequestLimits{MaxHistoryOutputBytes: 10}})
+	for i := 0; i < 2; i++ {
+		if w := postChat(t, ctrl, `{"message":"list files","session_id":"s1"}`); w.Code != http.StatusOK {
+			t.Fatalf("chat %d: expected 200, got %d: %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	resp := decodeMessages(t, getMessages(t, ctrl, "s1", "?limit=3"))
+	if resp.SessionID != "s1" || resp.Total != 8 || resp.Offset != 0 || !resp.HasMore || len(resp.Messages) != 3 {
+		t.Fatalf("unexpected first page: %+v", resp)
+	}
+	call := resp.Messages[1].Content[0]
+	if resp.Messages[1].Role != "assistant" || call.Type != "tool_use" || call.Name != "bash" || call.Input["command"] != "ls" {
+		t.Fatalf("unexpected tool call: %+v", resp.Messages[1])
+	}
+	result := resp.Messages[2].Content[0]
+	if result.ToolUseID != "call-1" || !result.Collapsed || result.ContentBytes != len(longOutput) || len(result.Content) >= len(longOutput) {
+		t.Fatalf("expected collapsed tool result, got %+v", result)
+	}
+
+	resp = decodeMessages(t, getMessages(t, ctrl, "s1", "?offset=-2"))
+	if resp.Offset != 6 || resp.HasMore || len(resp.Messages) != 2 || resp.Messages[1].Index != 7 {
+		t.Fatalf("unexpected last page: %+v", resp)
+	}
+	if got := resp.Messages[1].Content[0].Text; got != "done" {
+		t.Fatalf("expected final reply, got %q", got)
+	}
+}
+
+func TestHandleMessages_Errors(t *testing.T) {
+	ctrl := NewChatController(&stubAgent{result: agent.AgentResult{Message: "ok"}}, ChatConfig{AdminToken: "secret"})
+	if w := postChat(t, ctrl, `{"message":"hi","session_id":"s1"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := getMessages(t, ctrl, "missing", "")
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), ErrCodeSessionNotFound) {
+		t.Fatalf("expected 404 session_not_found, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, query := range []string{"?limit=0", "?limit=501", "?offset=x"} {
+		if w := getMessages(t, ctrl, "s1", query); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+	resp := decodeMessages(t, getMessages(t, ctrl, "s1", ""))
+	if resp.Total != 0 || len(resp.Messages) != 0 || resp.HasMore {
+		t.Fatalf("expected empty history, got %+v", resp)
+	}
+}
+
+func TestHandleMessages_RequiresAdminAndNamedSession(t *testing.T) {
+	ctrl := NewChatController(&stubAgent{result: agent.AgentResult{Message: "ok"}}, ChatConfig{AdminToken: "secret"})
+	for _, body := range []string{`{"message":"hi","session_id":"s1"}`, `{"message":"hi"}`} {
+		if w := postChat(t, ctrl, body); w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/chat/s1/messages", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := getMessages(t, ctrl, defaultSessionID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected the default session to be unreadable, got %d: %s", w.Code, w.Body.String())
+	}
+
+	open := NewChatController(&stubAgent{result: agent.AgentResult{Message: "ok"}}, ChatConfig{})
+	postChat(t, open, `{"message":"hi","session_id":"s1"}`)
+	if w := getMessages(t, open, "s1", ""); w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), ErrCodeAdminDisabled) {
+		t.Fatalf("expected 404 without an admin token configured, got %d: %s", w.Code, w.Body.String())
+	}
+}
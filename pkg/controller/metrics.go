@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/mcp"
+)
+
+// HandleMetrics serves the health of the agents' MCP servers in the
+// Prometheus text format (see mcp.WriteMetrics). A server shared by several
+// agents is reported once.
+func (c *ChatController) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	agents := []agent.Agent{c.agent}
+	names := make([]string, 0, len(c.cfg.Profiles))
+	for name := range c.cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		agents = append(agents, c.cfg.Profiles[name])
+	}
+
+	var statuses []mcp.ServerStatus
+	seen := map[string]bool{}
+	for _, a := range agents {
+		for _, s := range a.Capabilities().MCPServers {
+			if !seen[s.Name] {
+				seen[s.Name] = true
+				statuses = append(statuses, s)
+			}
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := mcp.WriteMetrics(w, statuses); err != nil {
+		log.Printf("[chat-controller] failed to write metrics: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/mcp"
+)
+
+// mcpAgent is a stubAgent reporting MCP server health.
+type mcpAgent struct {
+	stubAgent
+	servers []agent.MCPServerStatus
+}
+
+func (m *mcpAgent) Capabilities() agent.AgentCapabilities {
+	return agent.AgentCapabilities{MCPServers: m.servers}
+}
+
+func TestMetricsReportsEachMCPServerOnce(t *testing.T) {
+	github := agent.MCPServerStatus{Name: "github", State: mcp.StateReady, Tools: 3}
+	jira := agent.MCPServerStatus{Name: "jira", State: mcp.StateDown, LastError: "broken pipe"}
+	ctrl := NewChatController(&mcpAgent{servers: []agent.MCPServerStatus{github}}, ChatConfig{
+		Profiles: map[string]agent.Agent{
+			"reviewer": &mcpAgent{servers: []agent.MCPServerStatus{github, jira}},
+		},
+	})
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("GET /metrics = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, line := range []string{`mcp_server_up{server="github"} 1`, `mcp_server_up{server="jira"} 0`, `mcp_server_tools{server="github"} 3`} {
+		if strings.Count(body, line+"\n") != 1 {
+			t.Errorf("expected %q once in:\n%s", line, body)
+		}
+	}
+}
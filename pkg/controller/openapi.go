@@ -0,0 +1,526 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+//go:generate go run ../../cmd/openapi -o ../../openapi.yaml
+
+// OpenAPIVersion is the version reported in the generated spec's info block.
+const OpenAPIVersion = "1.0.0"
+
+// HandleOpenAPI serves the OpenAPI document for the chat server.
+func (c *ChatController) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, OpenAPISpec())
+}
+
+// OpenAPISpec returns the OpenAPI 3 document describing the chat server.
+// Schemas are derived from the controller's request/response types so the
+// spec cannot drift from the handlers.
+func OpenAPISpec() map[string]any {
+	schemas := map[string]any{}
+	ref := func(v any) map[string]any {
+		t := reflect.TypeOf(v)
+		addSchema(schemas, t)
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	errorResponse := func(desc string) map[string]any {
+		return jsonContent(desc, ref(ErrorResponse{}))
+	}
+	chatBody := map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": ref(ChatRequest{})}},
+	}
+	runIDParam := map[string]any{
+		"name":     "run_id",
+		"in":       "path",
+		"required": true,
+		"schema":   map[string]any{"type": "string"},
+	}
+	sessionPathParam := map[string]any{
+		"name":     "session",
+		"in":       "path",
+		"required": true,
+		"schema":   map[string]any{"type": "string"},
+	}
+	lastEventIDParam := map[string]any{
+		"name":        "Last-Event-ID",
+		"in":          "header",
+		"description": "Resume after this event ID (<run_id>:<seq>).",
+		"schema":      map[string]any{"type": "string"},
+	}
+	eventStream := map[string]any{
+		"description": "Server-sent events; each data payload is an AgentStreamEvent.",
+		"headers": map[string]any{
+			"X-Run-ID": map[string]any{"schema": map[string]any{"type": "string"}},
+		},
+		"content": map[string]any{"text/event-stream": map[string]any{"schema": ref(agent.AgentStreamEvent{})}},
+	}
+
+	paths := map[string]any{
+		"/api/chat": map[string]any{
+			"post": map[string]any{
+				"operationId": "chat",
+				"summary":     "Run the agent and return the final reply.",
+				"parameters": []any{
+					sessionHeaderParam(),
+					includeParam(includeToolCallsParam, "Add the run's tool calls to the response."),
+					includeParam(includeMessagesParam, "Add the messages the run appended to the session."),
+					includeParam(includeFileChangesParam, "Add the files the run changed (transactional runs)."),
+				},
+				"requestBody": chatBody,
+				"responses": map[string]any{
+					"200": jsonContent("Agent reply.", ref(ChatResponse{})),
+					"400": errorResponse("Invalid request, work_dir, or repo checkout."),
+					"403": errorResponse("Session token or cost budget exhausted."),
+					"409": errorResponse("The session already has a checkout of another repo or ref."),
+					"413": errorResponse("Request body or message too large."),
+					"429": errorResponse("Session run limit reached."),
+					"500": errorResponse("Agent execution failed."),
+				},
+			},
+		},
+		"/api/chat/stream": map[string]any{
+			"post": map[string]any{
+				"operationId": "chatStream",
+				"summary":     "Run the agent and stream events. With Last-Event-ID, resume a run instead.",
+				"parameters":  []any{sessionHeaderParam(), lastEventIDParam},
+				"requestBody": chatBody,
+				"responses": map[string]any{
+					"200": eventStream,
+					"400": errorResponse("Invalid request, work_dir, or repo checkout."),
+					"403": errorResponse("Session token or cost budget exhausted."),
+					"404": errorResponse("Streaming disabled, or the resumed run is unknown."),
+					"410": errorResponse("Requested events are no longer buffered."),
+					"409": errorResponse("The session already has a checkout of another repo or ref."),
+					"413": errorResponse("Request body or message too large."),
+					"429": errorResponse("Session run limit reached."),
+				},
+			},
+		},
+		"/api/chat/stream/{run_id}": map[string]any{
+			"get": map[string]any{
+				"operationId": "resumeStream",
+				"summary":     "Reattach to a streaming run, replaying events after Last-Event-ID.",
+				"parameters":  []any{runIDParam, lastEventIDParam},
+				"responses": map[string]any{
+					"200": eventStream,
+					"400": errorResponse("Invalid Last-Event-ID."),
+					"404": errorResponse("Unknown or expired run."),
+					"410": errorResponse("Requested events are no longer buffered."),
+				},
+			},
+		},
+		"/api/chat/stream/{run_id}/cancel": map[string]any{
+			"post": map[string]any{
+				"operationId": "cancelStream",
+				"summary":     "Cancel an in-progress streaming run.",
+				"parameters":  []any{runIDParam},
+				"responses": map[string]any{
+					"200": jsonContent("Cancellation result.", ref(CancelResponse{})),
+					"404": errorResponse("Unknown or expired run."),
+				},
+			},
+		},
+		"/api/chat/stream/{run_id}/reply": map[string]any{
+			"post": map[string]any{
+				"operationId": "replyStream",
+				"summary":     "Answer a user_input_required question, or steer the run's next turn.",
+				"parameters":  []any{runIDParam},
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  map[string]any{"application/json": map[string]any{"schema": ref(ReplyRequest{})}},
+				},
+				"responses": map[string]any{
+					"202": jsonContent("Reply queued.", ref(ReplyResponse{})),
+					"400": errorResponse("Invalid request."),
+					"404": errorResponse("Unknown or expired run."),
+					"409": errorResponse("The run has already finished."),
+					"413": errorResponse("Request body or message too large."),
+				},
+			},
+		},
+		"/api/chat/{session}/compact": map[string]any{
+			"post": map[string]any{
+				"operationId": "compactSession",
+				"summary":     "Summarize a session's stored history now.",
+				"security":    []any{map[string]any{"adminToken": []any{}}},
+				"parameters":  []any{sessionPathParam},
+				"requestBody": map[string]any{
+					"content": map[string]any{"application/json": map[string]any{"schema": ref(CompactRequest{})}},
+				},
+				"responses": map[string]any{
+					"200": jsonContent("Compaction result.", ref(CompactResponse{})),
+					"400": errorResponse("Invalid request."),
+					"401": errorResponse("Admin token required."),
+					"404": errorResponse("Unknown or default session, no stored history, or no admin token is configured."),
+					"413": errorResponse("Request body too large."),
+					"500": errorResponse("Compaction failed."),
+					"501": errorResponse("The agent does not support compaction."),
+				},
+			},
+		},
+		"/api/sessions/{session}/artifacts": map[string]any{
+			"get": map[string]any{
+				"operationId": "listArtifacts",
+				"summary":     "List the artifacts tools attached to a session's runs.",
+				"security":    []any{map[string]any{"adminToken": []any{}}},
+				"parameters":  []any{sessionPathParam},
+				"responses": map[string]any{
+					"200": jsonContent("The session's artifacts.", ref(ArtifactsResponse{})),
+					"401": errorResponse("Admin token required."),
+					"404": errorResponse("Unknown session, or no admin token is configured."),
+				},
+			},
+		},
+		"/api/chat/{session}/artifacts/{id}": map[string]any{
+			"get": map[string]any{
+				"operationId": "downloadArtifact",
+				"summary":     "Download an artifact's content.",
+				"security":    []any{map[string]any{"adminToken": []any{}}},
+				"parameters": []any{
+					sessionPathParam,
+					map[string]any{
+						"name":     "id",
+						"in":       "path",
+						"required": true,
+						"schema":   map[string]any{"type": "string"},
+					},
+				},
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "The artifact's content, with its media type. Types that are not safe to display are sent as attachments.",
+						"content":     map[string]any{"application/octet-stream": map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}},
+					},
+					"401": errorResponse("Admin token required."),
+					"404": errorResponse("Unknown session or artifact, or no admin token is configured."),
+				},
+			},
+		},
+		"/api/chat/{session}/messages": map[string]any{
+			"get": map[string]any{
+				"operationId": "listMessages",
+				"summary":     "Page through a session's stored conversation.",
+				"security":    []any{map[string]any{"adminToken": []any{}}},
+				"parameters": []any{
+					sessionPathParam,
+					map[string]any{
+						"name":        "offset",
+						"in":          "query",
+						"description": "Index of the first message; negative counts from the end.",
+						"schema":      map[string]any{"type": "integer", "default": 0},
+					},
+					map[string]any{
+						"name":   "limit",
+						"in":     "query",
+						"schema": map[string]any{"type": "integer", "default": defaultMessagesLimit, "minimum": 1, "maximum": maxMessagesLimit},
+					},
+				},
+				"responses": map[string]any{
+					"200": jsonContent("A page of messages.", ref(MessagesResponse{})),
+					"400": errorResponse("Invalid offset or limit."),
+					"401": errorResponse("Admin token required."),
+					"404": errorResponse("Unknown or default session, or no admin token is configured."),
+				},
+			},
+		},
+		"/api/sessions/{session}/trace": map[string]any{
+			"get": map[string]any{
+				"operationId": "sessionTrace",
+				"summary":     "Render a session's conversation as a Mermaid or DOT diagram.",
+				"security":    []any{map[string]any{"adminToken": []any{}}},
+				"parameters": []any{
+					sessionPathParam,
+					map[string]any{
+						"name":   "format",
+						"in":     "query",
+						"schema": map[string]any{"type": "string", "enum": []any{"mermaid", "dot"}, "default": "mermaid"},
+					},
+				},
+				"responses": map[string]any{
+					"200": jsonContent("The diagram source.", ref(TraceResponse{})),
+					"400": errorResponse("Invalid format."),
+					"401": errorResponse("Admin token required."),
+					"404": errorResponse("Unknown or default session, or no admin token is configured."),
+				},
+			},
+		},
+		"/api/sessions": map[string]any{
+			"get": map[string]any{
+				"operationId": "listSessions",
+				"summary":     "List live sessions and their usage.",
+				"security":    []any{map[string]any{"adminToken": []any{}}},
+				"responses": map[string]any{
+					"200": jsonContent("Live sessions.", ref(SessionsResponse{})),
+					"401": errorResponse("Admin token required."),
+					"404": errorResponse("No admin token is configured."),
+				},
+			},
+		},
+		"/api/openapi.json": map[string]any{
+			"get": map[string]any{
+				"operationId": "openAPI",
+				"summary":     "This document.",
+				"responses": map[string]any{
+					"200": jsonContent("OpenAPI document.", map[string]any{"type": "object"}),
+				},
+			},
+		},
+		"/healthz": map[string]any{
+			"get": map[string]any{
+				"operationId": "health",
+				"summary":     "Health check.",
+				"responses": map[string]any{
+					"200": jsonContent("Server is healthy.", map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"status": map[string]any{"type": "string"}},
+					}),
+				},
+			},
+		},
+		"/readyz": map[string]any{
+			"get": map[string]any{
+				"operationId": "ready",
+				"summary":     "Check that the agents' providers are reachable with valid credentials.",
+				"responses": map[string]any{
+					"200": jsonContent("Every agent passed its health check.", ref(ReadinessResponse{})),
+					"503": jsonContent("An agent failed its health check.", ref(ReadinessResponse{})),
+				},
+			},
+		},
+		"/metrics": map[string]any{
+			"get": map[string]any{
+				"operationId": "metrics",
+				"summary":     "Health of the agents' MCP servers in the Prometheus text format.",
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "Prometheus metrics.",
+						"content":     map[string]any{"text/plain": map[string]any{"schema": map[string]any{"type": "string"}}},
+					},
+				},
+			},
+		},
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "agent-core-go chat server",
+			"version": OpenAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"adminToken": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// OpenAPIYAML returns OpenAPISpec encoded as YAML (the checked-in openapi.yaml).
+func OpenAPIYAML() []byte {
+	var b strings.Builder
+	b.WriteString("# Code generated by cmd/openapi; DO NOT EDIT.\n")
+	writeYAML(&b, OpenAPISpec(), 0)
+	return []byte(b.String())
+}
+
+func sessionHeaderParam() map[string]any {
+	return map[string]any{
+		"name":        "X-Session-ID",
+		"in":          "header",
+		"description": "Session to account the run to when session_id is not in the body.",
+		"schema":      map[string]any{"type": "string"},
+	}
+}
+
+func includeParam(name, desc string) map[string]any {
+	return map[string]any{
+		"name":        name,
+		"in":          "query",
+		"description": desc,
+		"schema":      map[string]any{"type": "boolean", "default": false},
+	}
+}
+
+func jsonContent(desc string, schema map[string]any) map[string]any {
+	return map[string]any{
+		"description": desc,
+		"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// addSchema registers the JSON schema for struct type t (and any struct
+// types it references) under its type name.
+func addSchema(schemas map[string]any, t reflect.Type) {
+	if _, ok := schemas[t.Name()]; ok {
+		return
+	}
+	schemas[t.Name()] = nil // reserve to stop recursion
+	schemas[t.Name()] = structSchema(schemas, t)
+}
+
+func structSchema(schemas map[string]any, t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []any
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, omitEmpty, skip := jsonFieldName(f)
+		if skip {
+			continue
+		}
+		properties[name] = typeSchema(schemas, f.Type)
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func typeSchema(schemas map[string]any, t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "format": "int64", "description": "Duration in nanoseconds."}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(schemas, t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(schemas, t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": true}
+	case reflect.Struct:
+		addSchema(schemas, t)
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+func jsonFieldName(f reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// writeYAML emits v (maps, slices, and JSON scalars) as block-style YAML
+// with sorted keys.
+func writeYAML(b *strings.Builder, v any, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString(pad + yamlScalar(k) + ":")
+			writeYAMLValue(b, val[k], indent)
+		}
+	case []any:
+		for _, item := range val {
+			b.WriteString(pad + "-")
+			writeYAMLValue(b, item, indent)
+		}
+	}
+}
+
+func writeYAMLValue(b *strings.Builder, v any, indent int) {
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, val, indent+1)
+	case []any:
+		if len(val) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAML(b, val, indent+1)
+	default:
+		b.WriteString(" " + yamlScalar(val) + "\n")
+	}
+}
+
+func yamlScalar(v any) string {
+	switch val := v.(type) {
+	case string:
+		if val == "" || strings.ContainsAny(val, ":#{}[]&*!|>'\"%@`,\n") ||
+			strings.TrimSpace(val) != val || isYAMLKeyword(val) {
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(val)
+			return strings.TrimSuffix(buf.String(), "\n")
+		}
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func isYAMLKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIYAMLUpToDate(t *testing.T) {
+	committed, err := os.ReadFile("../../openapi.yaml")
+	if err != nil {
+		t.Fatalf("read openapi.yaml: %v", err)
+	}
+	if !bytes.Equal(committed, OpenAPIYAML()) {
+		t.Fatal("openapi.yaml is stale; run go generate ./pkg/controller")
+	}
+}
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	spec := OpenAPISpec()
+	paths := spec["paths"].(map[string]any)
+	for _, p := range []string{
+		"/api/chat",
+		"/api/chat/stream",
+		"/api/chat/stream/{run_id}",
+		"/api/chat/stream/{run_id}/cancel",
+		"/api/sessions",
+		"/api/openapi.json",
+		"/healthz",
+	} {
+		if _, ok := paths[p]; !ok {
+			t.Errorf("missing path %s", p)
+		}
+	}
+
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	chat := schemas["ChatRequest"].(map[string]any)
+	if required := chat["required"].([]any); len(required) != 1 || required[0] != "message" {
+		t.Fatalf("expected only message to be required, got %v", required)
+	}
+	if _, ok := chat["properties"].(map[string]any)["work_dir"]; !ok {
+		t.Fatal("expected work_dir property from json tag")
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	ctrl := NewChatController(&stubAgent{}, ChatConfig{})
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.HasPrefix(doc["openapi"].(string), "3.") {
+		t.Fatalf("unexpected openapi version %v", doc["openapi"])
+	}
+}
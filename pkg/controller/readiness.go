@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+const (
+	defaultReadinessInterval = time.Minute
+	defaultReadinessTimeout  = 10 * time.Second
+)
+
+// ReadinessConfig controls the provider probe behind GET /readyz.
+// Zero values select the defaults.
+type ReadinessConfig struct {
+	// Interval re-probes the agents in the background after
+	// StartReadinessProbe (default 1m).
+	Interval time.Duration
+
+	// Timeout bounds each agent's health check (default 10s).
+	Timeout time.Duration
+}
+
+func (c ReadinessConfig) withDefaults() ReadinessConfig {
+	if c.Interval <= 0 {
+		c.Interval = defaultReadinessInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultReadinessTimeout
+	}
+	return c
+}
+
+// ReadinessCheck is the health check result of one agent.
+type ReadinessCheck struct {
+	// Agent is "default" or a profile name.
+	Agent string `json:"agent"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// ReadinessResponse is the JSON response from GET /readyz.
+type ReadinessResponse struct {
+	// Status is "ready" when every agent passed its check, otherwise
+	// "unavailable".
+	Status    string           `json:"status"`
+	CheckedAt time.Time        `json:"checked_at"`
+	Checks    []ReadinessCheck `json:"checks"`
+}
+
+// readiness caches the latest probe of the controller's agents.
+type readiness struct {
+	mu   sync.Mutex
+	last *ReadinessResponse
+}
+
+// StartReadinessProbe checks that the default agent and every profile can
+// reach their provider with valid credentials, then keeps re-checking every
+// ReadinessConfig.Interval until ctx is done. It returns the first probe's
+// failures, so a server can refuse to start with a rejected API key (see
+// agent.ErrUnauthorized). Agents that do not implement agent.HealthChecker
+// always pass.
+func (c *ChatController) StartReadinessProbe(ctx context.Context) error {
+	resp, err := c.probeReadiness(ctx)
+	c.ready.store(resp)
+	go func() {
+		ticker := time.NewTicker(c.cfg.Readiness.withDefaults().Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				resp, err := c.probeReadiness(ctx)
+				if err != nil {
+					log.Printf("[chat-controller] readiness probe failed: %v", err)
+				}
+				c.ready.store(resp)
+			}
+		}
+	}()
+	return err
+}
+
+func (r *readiness) store(resp ReadinessResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last = &resp
+}
+
+// probeReadiness checks every agent.
+func (c *ChatController) probeReadiness(ctx context.Context) (ReadinessResponse, error) {
+	cfg := c.cfg.Readiness.withDefaults()
+	agents := map[string]agent.Agent{"default": c.agent}
+	names := []string{"default"}
+	profiles := make([]string, 0, len(c.cfg.Profiles))
+	for name, a := range c.cfg.Profiles {
+		agents[name] = a
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	names = append(names, profiles...)
+
+	resp := ReadinessResponse{Status: "ready", Checks: make([]ReadinessCheck, len(names))}
+	checkErrs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		resp.Checks[i] = ReadinessCheck{Agent: name, OK: true}
+		checker, ok := agents[name].(agent.HealthChecker)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+			defer cancel()
+			checkErrs[i] = checker.HealthCheck(checkCtx)
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, err := range checkErrs {
+		if err != nil {
+			resp.Status = "unavailable"
+			resp.Checks[i].OK = false
+			resp.Checks[i].Error = err.Error()
+			errs = append(errs, fmt.Errorf("agent %s: %w", names[i], err))
+		}
+	}
+	resp.CheckedAt = time.Now().UTC()
+	return resp, errors.Join(errs...)
+}
+
+// HandleReady reports whether the agents' providers are reachable with
+// valid credentials: 200 when ready, 503 otherwise. It serves the latest
+// background probe, or probes now when StartReadinessProbe was not called.
+func (c *ChatController) HandleReady(w http.ResponseWriter, r *http.Request) {
+	c.ready.mu.Lock()
+	last := c.ready.last
+	c.ready.mu.Unlock()
+
+	var resp ReadinessResponse
+	if last != nil {
+		resp = *last
+	} else {
+		resp, _ = c.probeReadiness(r.Context())
+	}
+	status := http.StatusOK
+	if resp.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+// healthAgent is a stubAgent whose provider health check returns err.
+type healthAgent struct {
+	stubAgent
+	err error
+}
+
+func (h *healthAgent) HealthCheck(context.Context) error { return h.err }
+
+func getReady(t *testing.T, mux *http.ServeMux) (int, ReadinessResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var resp ReadinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode /readyz: %v (%s)", err, rec.Body.String())
+	}
+	return rec.Code, resp
+}
+
+func TestReadyzReportsFailingProfile(t *testing.T) {
+	rejected := fmt.Errorf("claude provider: %w", agent.ErrUnauthorized)
+	ctrl := NewChatController(&healthAgent{}, ChatConfig{
+		Profiles: map[string]agent.Agent{
+			"reviewer": &healthAgent{err: rejected},
+			"cli":      &stubAgent{},
+		},
+	})
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+
+	code, resp := getReady(t, mux)
+	if code != http.StatusServiceUnavailable || resp.Status != "unavailable" {
+		t.Fatalf("GET /readyz = %d %q, want 503 unavailable", code, resp.Status)
+	}
+	want := []ReadinessCheck{
+		{Agent: "default", OK: true},
+		{Agent: "cli", OK: true},
+		{Agent: "reviewer", OK: false, Error: rejected.Error()},
+	}
+	if len(resp.Checks) != len(want) {
+		t.Fatalf("checks = %+v, want %+v", resp.Checks, want)
+	}
+	for i := range want {
+		if resp.Checks[i] != want[i] {
+			t.Fatalf("checks = %+v, want %+v", resp.Checks, want)
+		}
+	}
+}
+
+func TestStartReadinessProbeReturnsStartupFailure(t *testing.T) {
+	a := &healthAgent{err: fmt.Errorf("claude provider: %w", agent.ErrUnauthorized)}
+	ctrl := NewChatController(a, ChatConfig{})
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := ctrl.StartReadinessProbe(ctx)
+	if !errors.Is(err, agent.ErrUnauthorized) {
+		t.Fatalf("StartReadinessProbe() = %v, want ErrUnauthorized", err)
+	}
+
+	// /readyz serves the cached probe until the next interval.
+	a.err = nil
+	if code, _ := getReady(t, mux); code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /readyz = %d, want the cached 503", code)
+	}
+}
@@ -0,0 +1,380 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+// defaultSessionID is used when a request does not name a session.
+const defaultSessionID = "default"
+
+// Session limit error codes returned in ErrorResponse.Code.
+const (
+	ErrCodeRunLimit     = "session_run_limit_exceeded"
+	ErrCodeTokenBudget  = "session_token_budget_exceeded"
+	ErrCodeCostBudget   = "session_cost_budget_exceeded"
+	ErrCodeUnauthorized = "unauthorized"
+
+	ErrCodeSessionNotFound       = "session_not_found"
+	ErrCodeCompactionUnsupported = "compaction_unsupported"
+)
+
+// Defaults for SessionLimits.MaxArtifacts and MaxArtifactBytes.
+const (
+	defaultMaxSessionArtifacts     = 100
+	defaultMaxSessionArtifactBytes = 64 << 20
+)
+
+// SessionLimits bounds what a single chat session may consume.
+// Zero values disable the corresponding limit unless noted otherwise.
+type SessionLimits struct {
+	// MaxRuns is the maximum number of chat runs per session.
+	MaxRuns int
+
+	// MaxTotalTokens caps input+output tokens across all runs.
+	MaxTotalTokens int
+
+	// MaxCost caps the accumulated cost computed from TokenPricing.
+	MaxCost float64
+
+	// IdleTTL evicts sessions with no activity for this long.
+	// An evicted session starts again with zero usage.
+	IdleTTL time.Duration
+
+	// MaxArtifacts caps the artifacts kept per session (default 100;
+	// negative disables). The oldest are dropped first.
+	MaxArtifacts int
+
+	// MaxArtifactBytes caps the total size of the artifacts kept per
+	// session (default 64 MiB; negative disables). The oldest are dropped
+	// first.
+	MaxArtifactBytes int
+}
+
+func (l SessionLimits) maxArtifacts() int {
+	if l.MaxArtifacts == 0 {
+		return defaultMaxSessionArtifacts
+	}
+	return l.MaxArtifacts
+}
+
+func (l SessionLimits) maxArtifactBytes() int {
+	if l.MaxArtifactBytes == 0 {
+		return defaultMaxSessionArtifactBytes
+	}
+	return l.MaxArtifactBytes
+}
+
+// TokenPricing converts token usage into cost. Prices are per million tokens.
+type TokenPricing struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Cost returns the cost of the given token counts.
+func (p TokenPricing) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.InputPerMillion + float64(outputTokens)*p.OutputPerMillion) / 1_000_000
+}
+
+// SessionInfo reports usage for one chat session.
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	Runs         int       `json:"runs"`
+	ActiveRuns   int       `json:"active_runs"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	TotalTokens  int       `json:"total_tokens"`
+	Cost         float64   `json:"cost"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+}
+
+// SessionsResponse is the JSON response from GET /api/sessions.
+type SessionsResponse struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+// sessionLimitError describes a rejected run.
+type sessionLimitError struct {
+	status  int
+	code    string
+	message string
+	session SessionInfo
+}
+
+func (e *sessionLimitError) response() ErrorResponse {
+	session := e.session
+	return ErrorResponse{Error: e.message, Code: e.code, Session: &session}
+}
+
+// sessionStore tracks per-session usage and enforces SessionLimits.
+type sessionStore struct {
+	mu       sync.Mutex
+	limits   SessionLimits
+	pricing  TokenPricing
+	now      func() time.Time
+	sessions map[string]*SessionInfo
+
+	// histories holds each session's conversation across its runs.
+	histories map[string][]agenttypes.Message
+
+	// models holds per-session model overrides set with /model.
+	models map[string]string
+
+	// artifacts holds the artifacts each session's runs attached.
+	artifacts map[string][]agent.Artifact
+
+	// onEvict, when set, is called with the lock held for each evicted
+	// session. It must not block.
+	onEvict func(id string)
+}
+
+func newSessionStore(limits SessionLimits, pricing TokenPricing) *sessionStore {
+	return &sessionStore{
+		limits:    limits,
+		pricing:   pricing,
+		now:       time.Now,
+		sessions:  make(map[string]*SessionInfo),
+		histories: make(map[string][]agenttypes.Message),
+		models:    make(map[string]string),
+		artifacts: make(map[string][]agent.Artifact),
+	}
+}
+
+// begin registers the start of a run, or rejects it if a limit is reached.
+func (s *sessionStore) begin(id string) *sessionLimitError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evictIdleLocked(now)
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		sess = &SessionInfo{ID: id, CreatedAt: now}
+		s.sessions[id] = sess
+	}
+	sess.LastActiveAt = now
+
+	switch {
+	case s.limits.MaxRuns > 0 && sess.Runs >= s.limits.MaxRuns:
+		return &sessionLimitError{
+			status:  http.StatusTooManyRequests,
+			code:    ErrCodeRunLimit,
+			message: fmt.Sprintf("session %q reached the run limit (%d)", id, s.limits.MaxRuns),
+			session: *sess,
+		}
+	case s.limits.MaxTotalTokens > 0 && sess.TotalTokens >= s.limits.MaxTotalTokens:
+		return &sessionLimitError{
+			status:  http.StatusForbidden,
+			code:    ErrCodeTokenBudget,
+			message: fmt.Sprintf("session %q exhausted its token budget (%d)", id, s.limits.MaxTotalTokens),
+			session: *sess,
+		}
+	case s.limits.MaxCost > 0 && sess.Cost >= s.limits.MaxCost:
+		return &sessionLimitError{
+			status:  http.StatusForbidden,
+			code:    ErrCodeCostBudget,
+			message: fmt.Sprintf("session %q exhausted its cost budget (%.4f)", id, s.limits.MaxCost),
+			session: *sess,
+		}
+	}
+
+	sess.Runs++
+	sess.ActiveRuns++
+	return nil
+}
+
+// finish records the usage of a completed (or failed) run.
+func (s *sessionStore) finish(id string, usage agent.ExecutionUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return
+	}
+	if sess.ActiveRuns > 0 {
+		sess.ActiveRuns--
+	}
+	sess.InputTokens += usage.TotalInputTokens
+	sess.OutputTokens += usage.TotalOutputTokens
+	sess.TotalTokens = sess.InputTokens + sess.OutputTokens
+	sess.Cost += s.pricing.Cost(usage.TotalInputTokens, usage.TotalOutputTokens)
+	sess.LastActiveAt = s.now()
+}
+
+// appendHistory adds the conversation of a finished run to the session.
+func (s *sessionStore) appendHistory(id string, messages []agenttypes.Message) {
+	if len(messages) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[id]; !ok {
+		return
+	}
+	s.histories[id] = append(s.histories[id], messages...)
+}
+
+// history returns a copy of the session's stored conversation.
+func (s *sessionStore) history(id string) ([]agenttypes.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictIdleLocked(s.now())
+	if _, ok := s.sessions[id]; !ok {
+		return nil, false
+	}
+	return append([]agenttypes.Message(nil), s.histories[id]...), true
+}
+
+// replaceHistory swaps the first n stored messages for replacement, keeping
+// anything appended since they were read.
+func (s *sessionStore) replaceHistory(id string, n int, replacement []agenttypes.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.histories[id]
+	if !ok || n > len(current) {
+		return
+	}
+	next := make([]agenttypes.Message, 0, len(replacement)+len(current)-n)
+	next = append(next, replacement...)
+	s.histories[id] = append(next, current[n:]...)
+	if sess, ok := s.sessions[id]; ok {
+		sess.LastActiveAt = s.now()
+	}
+}
+
+// clearHistory drops the session's stored conversation.
+func (s *sessionStore) clearHistory(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.histories, id)
+	if sess, ok := s.sessions[id]; ok {
+		sess.LastActiveAt = s.now()
+	}
+}
+
+// addArtifacts keeps the artifacts of a finished run for download, dropping
+// the session's oldest artifacts beyond MaxArtifacts and MaxArtifactBytes.
+func (s *sessionStore) addArtifacts(id string, artifacts []agent.Artifact) {
+	if len(artifacts) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[id]; !ok {
+		return
+	}
+	kept := append(s.artifacts[id], artifacts...)
+	size := 0
+	for _, a := range kept {
+		size += a.Size
+	}
+	maxCount, maxBytes := s.limits.maxArtifacts(), s.limits.maxArtifactBytes()
+	dropped := 0
+	for len(kept) > 0 && ((maxCount > 0 && len(kept) > maxCount) || (maxBytes > 0 && size > maxBytes)) {
+		size -= kept[0].Size
+		kept = kept[1:]
+		dropped++
+	}
+	if dropped > 0 {
+		log.Printf("[chat-controller] session %s: dropped %d old artifacts over the session limit", id, dropped)
+	}
+	s.artifacts[id] = append([]agent.Artifact(nil), kept...)
+}
+
+// sessionArtifacts returns the artifacts the session's runs attached.
+func (s *sessionStore) sessionArtifacts(id string) ([]agent.Artifact, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictIdleLocked(s.now())
+	if _, ok := s.sessions[id]; !ok {
+		return nil, false
+	}
+	return append([]agent.Artifact(nil), s.artifacts[id]...), true
+}
+
+// setModel sets the model later runs of the session use; empty restores
+// the agent's model. The session is created if it does not exist yet.
+func (s *sessionStore) setModel(id, model string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evictIdleLocked(now)
+	sess, ok := s.sessions[id]
+	if !ok {
+		sess = &SessionInfo{ID: id, CreatedAt: now}
+		s.sessions[id] = sess
+	}
+	sess.LastActiveAt = now
+	if model == "" {
+		delete(s.models, id)
+		return
+	}
+	s.models[id] = model
+}
+
+// model returns the session's model override, if any.
+func (s *sessionStore) model(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.models[id]
+}
+
+// list returns a snapshot of all live sessions sorted by ID.
+func (s *sessionStore) list() []SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictIdleLocked(s.now())
+	out := make([]SessionInfo, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		out = append(out, *sess)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (s *sessionStore) evictIdleLocked(now time.Time) {
+	if s.limits.IdleTTL <= 0 {
+		return
+	}
+	for id, sess := range s.sessions {
+		if sess.ActiveRuns == 0 && now.Sub(sess.LastActiveAt) > s.limits.IdleTTL {
+			delete(s.sessions, id)
+			delete(s.histories, id)
+			delete(s.models, id)
+			delete(s.artifacts, id)
+			if s.onEvict != nil {
+				s.onEvict(id)
+			}
+		}
+	}
+}
+
+// resolveSessionID picks the session from the request body, then the
+// X-Session-ID header, falling back to a shared default session.
+func resolveSessionID(r *http.Request, fromBody string) string {
+	if fromBody != "" {
+		return fromBody
+	}
+	if id := r.Header.Get("X-Session-ID"); id != "" {
+		return id
+	}
+	return defaultSessionID
+}
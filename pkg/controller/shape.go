@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+// Query parameters that add run details to a ChatResponse. Each may also
+// be set in the ChatRequest body.
+const (
+	includeToolCallsParam   = "include_tool_calls"
+	includeMessagesParam    = "include_messages"
+	includeFileChangesParam = "include_file_changes"
+)
+
+// ToolCallInfo is one tool call of a run, in ChatResponse.ToolCalls.
+type ToolCallInfo struct {
+	Name      string         `json:"name"`
+	Input     map[string]any `json:"input,omitempty"`
+	Output    string         `json:"output"`
+	IsError   bool           `json:"is_error,omitempty"`
+	ErrorCode string         `json:"error_code,omitempty"`
+	WorkDir   string         `json:"work_dir,omitempty"`
+
+	DurationMs int64 `json:"duration_ms"`
+
+	// Command is set for shell commands run by bash or run_in_container.
+	Command *CommandInfo `json:"command,omitempty"`
+}
+
+// CommandInfo is the structured result of a shell command tool call.
+type CommandInfo struct {
+	ExitCode        int    `json:"exit_code"`
+	Stdout          string `json:"stdout,omitempty"`
+	Stderr          string `json:"stderr,omitempty"`
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+	TimedOut        bool   `json:"timed_out,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// FileChangeInfo is one file changed by a run, in ChatResponse.FileChanges.
+// File changes are reported for transactional runs.
+type FileChangeInfo struct {
+	Path      string `json:"path"`
+	Operation string `json:"operation"`
+	Diff      string `json:"diff,omitempty"`
+}
+
+// includeOptions reports which run details to add to a ChatResponse.
+type includeOptions struct {
+	toolCalls   bool
+	messages    bool
+	fileChanges bool
+}
+
+// parseIncludeOptions combines the include flags of req with the matching
+// query parameters; either can turn a detail on.
+func parseIncludeOptions(r *http.Request, req ChatRequest) (includeOptions, *requestError) {
+	opts := includeOptions{
+		toolCalls:   req.IncludeToolCalls,
+		messages:    req.IncludeMessages,
+		fileChanges: req.IncludeFileChanges,
+	}
+	for param, flag := range map[string]*bool{
+		includeToolCallsParam:   &opts.toolCalls,
+		includeMessagesParam:    &opts.messages,
+		includeFileChangesParam: &opts.fileChanges,
+	} {
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return includeOptions{}, badRequest(param + " must be a boolean")
+		}
+		*flag = *flag || b
+	}
+	return opts, nil
+}
+
+// apply adds the requested details of result to resp. Tool outputs and
+// message contents are cut to RequestLimits.MaxHistoryOutputBytes, like
+// stored history.
+func (o includeOptions) apply(resp *ChatResponse, result agent.AgentResult, maxOutput int) {
+	if o.toolCalls {
+		resp.ToolCalls = make([]ToolCallInfo, 0, len(result.ToolCalls))
+		for _, call := range result.ToolCalls {
+			resp.ToolCalls = append(resp.ToolCalls, toolCallInfo(call, maxOutput))
+		}
+	}
+	if o.messages {
+		resp.Messages = make([]HistoryMessage, 0, len(result.RawOutput))
+		for i, msg := range result.RawOutput {
+			resp.Messages = append(resp.Messages, historyMessage(i, msg, maxOutput))
+		}
+	}
+	if o.fileChanges {
+		resp.FileChanges = make([]FileChangeInfo, 0, len(result.FileChanges))
+		for _, fc := range result.FileChanges {
+			resp.FileChanges = append(resp.FileChanges, FileChangeInfo{
+				Path:      fc.Path,
+				Operation: string(fc.Operation),
+				Diff:      fc.Diff,
+			})
+		}
+	}
+}
+
+func toolCallInfo(call agent.ToolCallRecord, maxOutput int) ToolCallInfo {
+	output, _ := truncateReply(call.Output, maxOutput)
+	info := ToolCallInfo{
+		Name:       call.Name,
+		Input:      call.Input,
+		Output:     output,
+		IsError:    call.IsError,
+		ErrorCode:  call.ErrorCode,
+		WorkDir:    call.WorkDir,
+		DurationMs: call.Duration.Milliseconds(),
+	}
+	if cmd := call.Command; cmd != nil {
+		stdout, stdoutCut := truncateReply(cmd.Stdout, maxOutput)
+		stderr, stderrCut := truncateReply(cmd.Stderr, maxOutput)
+		info.Command = &CommandInfo{
+			ExitCode:        cmd.ExitCode,
+			Stdout:          stdout,
+			Stderr:          stderr,
+			StdoutTruncated: cmd.StdoutTruncated || stdoutCut,
+			StderrTruncated: cmd.StderrTruncated || stderrCut,
+			TimedOut:        cmd.TimedOut,
+			Error:           cmd.Error,
+		}
+	}
+	return info
+}
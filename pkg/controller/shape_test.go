@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func shapedResultAgent() *stubAgent {
+	return &stubAgent{result: agent.AgentResult{
+		Message: "done",
+		ToolCalls: []agent.ToolCallRecord{
+			{Name: "read_file", Input: map[string]any{"path": "a.go"}, Output: "package a // long output", Duration: 1500 * time.Millisecond},
+			{Name: "bash", Output: "exit_code: 1", IsError: true, Command: &tools.CommandOutput{ExitCode: 1, Stderr: "boom"}},
+		},
+		RawOutput: []agenttypes.Message{
+			agenttypes.NewTextMessage(agenttypes.RoleUser, "fix it"),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "done"),
+		},
+		FileChanges: []agent.FileChange{{Path: "a.go", Operation: agent.FileOpModify, Diff: "-a\n+b\n"}},
+	}}
+}
+
+func TestHandleChatOmitsRunDetailsByDefault(t *testing.T) {
+	ctrl := NewChatController(shapedResultAgent(), ChatConfig{})
+	w := postChat(t, ctrl, `{"message":"fix it"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"tool_calls", "messages", "file_changes"} {
+		if _, ok := raw[key]; ok {
+			t.Errorf("did not expect %s in the default response", key)
+		}
+	}
+}
+
+func TestHandleChatIncludesRequestedRunDetails(t *testing.T) {
+	ctrl := NewChatController(shapedResultAgent(), ChatConfig{Limits: RequestLimits{MaxHistoryOutputBytes: 10}})
+	req := httptest.NewRequest(http.MethodPost, "/api/chat?include_messages=1&include_file_changes=true",
+		bytes.NewBufferString(`{"message":"fix it","include_tool_calls":true}`))
+	w := httptest.NewRecorder()
+	ctrl.HandleChat(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp ChatResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %+v", resp.ToolCalls)
+	}
+	if call := resp.ToolCalls[0]; call.Output != "package a " || call.DurationMs != 1500 || call.Input["path"] != "a.go" {
+		t.Errorf("unexpected tool call: %+v", call)
+	}
+	if cmd := resp.ToolCalls[1].Command; cmd == nil || cmd.ExitCode != 1 || cmd.Stderr != "boom" || !resp.ToolCalls[1].IsError {
+		t.Errorf("unexpected command call: %+v", resp.ToolCalls[1])
+	}
+	if len(resp.Messages) != 2 || resp.Messages[1].Role != "assistant" || resp.Messages[1].Content[0].Text != "done" {
+		t.Errorf("unexpected messages: %+v", resp.Messages)
+	}
+	if len(resp.FileChanges) != 1 || resp.FileChanges[0] != (FileChangeInfo{Path: "a.go", Operation: "modify", Diff: "-a\n+b\n"}) {
+		t.Errorf("unexpected file changes: %+v", resp.FileChanges)
+	}
+}
+
+func TestHandleChatRejectsInvalidIncludeParam(t *testing.T) {
+	stub := shapedResultAgent()
+	ctrl := NewChatController(stub, ChatConfig{})
+	req := httptest.NewRequest(http.MethodPost, "/api/chat?include_tool_calls=maybe", bytes.NewBufferString(`{"message":"hi"}`))
+	w := httptest.NewRecorder()
+	ctrl.HandleChat(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if stub.lastReq.Task != "" {
+		t.Fatal("expected the agent not to run")
+	}
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"compress/gzip"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SSEChunkEvent names the events that carry the leading pieces of an event
+// split by SSEConfig.MaxEventBytes.
+const SSEChunkEvent = "chunk"
+
+// minSSEChunkBytes is the smallest MaxEventBytes honoured.
+const minSSEChunkBytes = 64
+
+// SSEConfig adapts stream responses to reverse proxies such as nginx or
+// AWS ALB. Zero values keep the plain behavior.
+type SSEConfig struct {
+	// KeepAlive writes a ": keep-alive" comment after this long without an
+	// event, so proxies with an idle timeout (nginx proxy_read_timeout, ALB
+	// idle timeout) keep the connection open during long tool calls.
+	KeepAlive time.Duration
+
+	// MaxEventBytes splits events whose data is larger into SSEChunkEvent
+	// events followed by the event itself, each at most this many bytes of
+	// data. Clients rebuild the data by concatenating the chunks with the
+	// final event's data. Only the final event carries the id, so a resume
+	// never starts in the middle of an event.
+	MaxEventBytes int
+
+	// Gzip compresses streams for clients that send Accept-Encoding: gzip.
+	// Every flush ends a gzip block, so events are not held back.
+	Gzip bool
+}
+
+// sseWriter writes the events of one stream response.
+type sseWriter struct {
+	w       io.Writer
+	gz      *gzip.Writer
+	flusher http.Flusher
+
+	maxEventBytes int
+}
+
+// newSSEWriter sets the stream response headers and returns a writer for
+// the response body. Call it before the header is written.
+func newSSEWriter(w http.ResponseWriter, r *http.Request, flusher http.Flusher, cfg SSEConfig) *sseWriter {
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	// nginx buffers proxied responses unless told otherwise.
+	h.Set("X-Accel-Buffering", "no")
+
+	s := &sseWriter{w: w, flusher: flusher, maxEventBytes: cfg.MaxEventBytes}
+	if s.maxEventBytes > 0 && s.maxEventBytes < minSSEChunkBytes {
+		s.maxEventBytes = minSSEChunkBytes
+	}
+	if cfg.Gzip {
+		h.Add("Vary", "Accept-Encoding")
+		if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			h.Set("Content-Encoding", "gzip")
+			s.gz = gzip.NewWriter(w)
+			s.w = s.gz
+		}
+	}
+	return s
+}
+
+// event writes e with the given id, split into chunks when its data is
+// larger than maxEventBytes.
+func (s *sseWriter) event(id string, e streamEvent) bool {
+	data := e.data
+	for s.maxEventBytes > 0 && len(data) > s.maxEventBytes {
+		n := chunkEnd(data, s.maxEventBytes)
+		if !s.write("", SSEChunkEvent, data[:n]) {
+			return false
+		}
+		data = data[n:]
+	}
+	return s.write(id, e.name, data)
+}
+
+// write writes one SSE event. Fields are stripped of line breaks, and data
+// containing line breaks is sent as several data lines, which clients join
+// with "\n".
+func (s *sseWriter) write(id, name string, data []byte) bool {
+	var b strings.Builder
+	if id != "" {
+		b.WriteString("id: " + sseField(id) + "\n")
+	}
+	b.WriteString("event: " + sseField(name) + "\n")
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	if _, err := io.WriteString(s.w, b.String()); err != nil {
+		log.Printf("[chat-controller] failed to write SSE event: %v", err)
+		return false
+	}
+	return true
+}
+
+// comment writes an SSE comment line, which clients ignore.
+func (s *sseWriter) comment(text string) bool {
+	if _, err := io.WriteString(s.w, ": "+sseField(text)+"\n\n"); err != nil {
+		log.Printf("[chat-controller] failed to write SSE comment: %v", err)
+		return false
+	}
+	return true
+}
+
+// flush sends everything written so far to the client.
+func (s *sseWriter) flush() {
+	if s.gz != nil {
+		if err := s.gz.Flush(); err != nil {
+			log.Printf("[chat-controller] failed to flush gzip stream: %v", err)
+		}
+	}
+	s.flusher.Flush()
+}
+
+// close ends the gzip stream, if any.
+func (s *sseWriter) close() {
+	if s.gz == nil {
+		return
+	}
+	if err := s.gz.Close(); err != nil {
+		log.Printf("[chat-controller] failed to close gzip stream: %v", err)
+	}
+	s.flusher.Flush()
+}
+
+// sseField removes the line breaks that would end an SSE field early.
+func sseField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// chunkEnd returns the length of the first chunk of data, at most max
+// bytes and not splitting a UTF-8 sequence, which clients decode before
+// they join the chunks.
+func chunkEnd(data []byte, max int) int {
+	n := max
+	for n > max-utf8.UTFMax && n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	if n == 0 {
+		return max
+	}
+	return n
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := strings.ReplaceAll(strings.ToLower(params), " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+// pausingAgent streams agent_start, waits for pause, and finishes.
+type pausingAgent struct {
+	stubAgent
+	pause time.Duration
+}
+
+func (a *pausingAgent) ExecuteStream(ctx context.Context, _ agent.AgentRequest) (<-chan agent.AgentStreamEvent, <-chan error) {
+	eventCh := make(chan agent.AgentStreamEvent, 2)
+	errCh := make(chan error)
+	go func() {
+		defer close(eventCh)
+		defer close(errCh)
+		eventCh <- agent.AgentStreamEvent{Type: agent.AgentEventAgentStart}
+		select {
+		case <-ctx.Done():
+		case <-time.After(a.pause):
+			eventCh <- agent.AgentStreamEvent{Type: agent.AgentEventAgentEnd}
+		}
+	}()
+	return eventCh, errCh
+}
+
+func TestHandleChatStream_KeepAlive(t *testing.T) {
+	ctrl := NewChatController(&pausingAgent{pause: 100 * time.Millisecond}, ChatConfig{
+		DefaultDir:      "/tmp",
+		EnableStreaming: true,
+		SSE:             SSEConfig{KeepAlive: 10 * time.Millisecond},
+	})
+	w := postChatStream(t, ctrl, "")
+
+	body := w.Body.String()
+	if !strings.Contains(body, "\n: keep-alive\n\n") || !strings.Contains(body, "event: agent_end") {
+		t.Fatalf("expected keep-alive comments during the pause, got %q", body)
+	}
+	if w.Header().Get("X-Accel-Buffering") != "no" {
+		t.Fatalf("expected proxy buffering to be disabled, got headers %v", w.Header())
+	}
+}
+
+func TestHandleChatStream_ChunksLargeEvents(t *testing.T) {
+	stub := &stubAgent{stream: []agent.AgentStreamEvent{
+		{Type: agent.AgentEventMessageDelta, Delta: strings.Repeat("é", 100)},
+	}}
+	ctrl := NewChatController(stub, ChatConfig{
+		DefaultDir:      "/tmp",
+		EnableStreaming: true,
+		SSE:             SSEConfig{MaxEventBytes: 1},
+	})
+	w := postChatStream(t, ctrl, "")
+
+	var data strings.Builder
+	events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	for i, event := range events {
+		lines := strings.Split(event, "\n")
+		last := i == len(events)-1
+		if !last && (lines[0] != "event: "+SSEChunkEvent || len(lines) != 2) {
+			t.Fatalf("expected an id-less chunk event, got %q", event)
+		}
+		if last && (!strings.HasPrefix(lines[0], "id: ") || lines[1] != "event: message_delta") {
+			t.Fatalf("expected the final event to carry the id and name, got %q", event)
+		}
+		piece := strings.TrimPrefix(lines[len(lines)-1], "data: ")
+		if len(piece) > minSSEChunkBytes {
+			t.Fatalf("chunk of %d bytes exceeds the limit", len(piece))
+		}
+		data.WriteString(piece)
+	}
+	if len(events) < 3 || !strings.Contains(data.String(), strings.Repeat("é", 100)) {
+		t.Fatalf("expected chunks to rebuild the event, got %d events: %q", len(events), data.String())
+	}
+}
+
+func TestHandleChatStream_Gzip(t *testing.T) {
+	ctrl := NewChatController(&stubAgent{stream: []agent.AgentStreamEvent{{Type: agent.AgentEventAgentEnd}}}, ChatConfig{
+		DefaultDir:      "/tmp",
+		EnableStreaming: true,
+		SSE:             SSEConfig{Gzip: true},
+	})
+	for _, encoding := range []string{"gzip, deflate", "identity", "gzip;q=0"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/chat/stream", bytes.NewBufferString(`{"message":"hello"}`))
+		req.Header.Set("Accept-Encoding", encoding)
+		w := httptest.NewRecorder()
+		ctrl.HandleChatStream(w, req)
+
+		gzipped := w.Header().Get("Content-Encoding") == "gzip"
+		if gzipped != (encoding == "gzip, deflate") {
+			t.Fatalf("Accept-Encoding %q: unexpected Content-Encoding %q", encoding, w.Header().Get("Content-Encoding"))
+		}
+		body := w.Body.Bytes()
+		if gzipped {
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("gzip reader: %v", err)
+			}
+			if body, err = io.ReadAll(zr); err != nil {
+				t.Fatalf("read gzip body: %v", err)
+			}
+		}
+		if !strings.Contains(string(body), "event: agent_end\n") {
+			t.Fatalf("Accept-Encoding %q: unexpected body %q", encoding, body)
+		}
+	}
+}
+
+func TestSSEWriterSanitizesFields(t *testing.T) {
+	var buf bytes.Buffer
+	s := &sseWriter{w: &buf}
+	s.write("run:1\n", "bad\r\nname", []byte("line one\r\nline two"))
+	want := "id: run:1\nevent: badname\ndata: line one\ndata: line two\n\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
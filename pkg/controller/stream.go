@@ -0,0 +1,272 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+// Stream resume error codes returned in ErrorResponse.Code.
+const (
+	ErrCodeStreamNotFound = "stream_not_found"
+	ErrCodeStreamExpired  = "stream_events_expired"
+	ErrCodeStreamFinished = "stream_finished"
+)
+
+const (
+	defaultStreamBufferSize    = 1024
+	defaultStreamRetainFor     = 5 * time.Minute
+	defaultStreamDetachTimeout = 30 * time.Second
+)
+
+// StreamReplayConfig controls how streaming runs are buffered for resume.
+// Zero values select the defaults.
+type StreamReplayConfig struct {
+	// BufferSize is the number of recent events kept per run (default 1024).
+	BufferSize int
+
+	// RetainFor keeps finished runs available for resume (default 5m).
+	RetainFor time.Duration
+
+	// DetachTimeout cancels a run that has had no connected client for this
+	// long (default 30s).
+	DetachTimeout time.Duration
+}
+
+func (c StreamReplayConfig) withDefaults() StreamReplayConfig {
+	if c.BufferSize <= 0 {
+		c.BufferSize = defaultStreamBufferSize
+	}
+	if c.RetainFor <= 0 {
+		c.RetainFor = defaultStreamRetainFor
+	}
+	if c.DetachTimeout <= 0 {
+		c.DetachTimeout = defaultStreamDetachTimeout
+	}
+	return c
+}
+
+// CancelResponse is the JSON response from POST /api/chat/stream/{run_id}/cancel.
+type CancelResponse struct {
+	RunID string `json:"run_id"`
+	// Cancelled is false when the run had already finished.
+	Cancelled bool `json:"cancelled"`
+}
+
+// ReplyRequest is the JSON body for POST /api/chat/stream/{run_id}/reply.
+type ReplyRequest struct {
+	Message string `json:"message"`
+}
+
+// ReplyResponse is the JSON response from POST /api/chat/stream/{run_id}/reply.
+type ReplyResponse struct {
+	RunID string `json:"run_id"`
+}
+
+// streamEvent is a serialized SSE event.
+type streamEvent struct {
+	seq  int64
+	name string
+	data []byte
+}
+
+// streamRun buffers the events of one streaming chat run so clients can
+// reconnect and resume with Last-Event-ID.
+type streamRun struct {
+	id string
+
+	mu          sync.Mutex
+	events      []streamEvent
+	nextSeq     int64
+	done        bool
+	finishedAt  time.Time
+	notify      chan struct{}
+	subscribers int
+	detachTimer *time.Timer
+	cancel      func()
+
+	// input delivers replies to the run as steering messages; a pending
+	// ask_user question takes them as its answer.
+	input *agent.LoopInputQueue
+}
+
+// eventID formats the SSE id for seq. IDs are "<run>:<seq>" with seq
+// increasing monotonically from 1 within a run.
+func (r *streamRun) eventID(seq int64) string {
+	return r.id + ":" + strconv.FormatInt(seq, 10)
+}
+
+// append buffers an event, dropping the oldest once limit is reached.
+func (r *streamRun) append(name string, data []byte, limit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextSeq++
+	r.events = append(r.events, streamEvent{seq: r.nextSeq, name: name, data: data})
+	if len(r.events) > limit {
+		r.events = append([]streamEvent(nil), r.events[len(r.events)-limit:]...)
+	}
+	r.wakeLocked()
+}
+
+// finish marks the run complete.
+func (r *streamRun) finish(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done = true
+	r.finishedAt = now
+	if r.detachTimer != nil {
+		r.detachTimer.Stop()
+	}
+	r.wakeLocked()
+}
+
+func (r *streamRun) wakeLocked() {
+	close(r.notify)
+	r.notify = make(chan struct{})
+}
+
+// since returns buffered events after seq, whether the run is done, and a
+// channel closed on the next change. ok is false if events after seq were
+// already dropped from the buffer.
+func (r *streamRun) since(seq int64) (events []streamEvent, done bool, notify <-chan struct{}, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.events) > 0 && r.events[0].seq > seq+1 {
+		return nil, r.done, r.notify, false
+	}
+	for i, e := range r.events {
+		if e.seq > seq {
+			events = append(events, r.events[i:]...)
+			break
+		}
+	}
+	return events, r.done, r.notify, true
+}
+
+// attach registers a connected client.
+func (r *streamRun) attach() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers++
+	if r.detachTimer != nil {
+		r.detachTimer.Stop()
+		r.detachTimer = nil
+	}
+}
+
+// detach unregisters a client and cancels the run if nobody reattaches
+// within timeout.
+func (r *streamRun) detach(timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers--
+	if r.subscribers > 0 || r.done || r.cancel == nil {
+		return
+	}
+	r.detachTimer = time.AfterFunc(timeout, func() {
+		r.mu.Lock()
+		abandoned := r.subscribers == 0 && !r.done
+		r.mu.Unlock()
+		if abandoned {
+			r.cancel()
+		}
+	})
+}
+
+// reply queues message for the run and reports whether the run was still
+// in progress.
+func (r *streamRun) reply(message string) (bool, error) {
+	r.mu.Lock()
+	done := r.done
+	r.mu.Unlock()
+	if done {
+		return false, nil
+	}
+	return true, r.input.PushText(message, agent.LoopInputPush{Kind: agent.LoopInputSteering})
+}
+
+// stop cancels a run that is still in progress and reports whether it did.
+func (r *streamRun) stop() bool {
+	r.mu.Lock()
+	running := !r.done && r.cancel != nil
+	r.mu.Unlock()
+	if running {
+		r.cancel()
+	}
+	return running
+}
+
+// streamHub tracks streaming runs available for resume.
+type streamHub struct {
+	mu   sync.Mutex
+	cfg  StreamReplayConfig
+	now  func() time.Time
+	runs map[string]*streamRun
+}
+
+func newStreamHub(cfg StreamReplayConfig) *streamHub {
+	return &streamHub{
+		cfg:  cfg.withDefaults(),
+		now:  time.Now,
+		runs: make(map[string]*streamRun),
+	}
+}
+
+// start registers a new run.
+func (h *streamHub) start(cancel func()) *streamRun {
+	run := &streamRun{
+		id:     newRunID(),
+		notify: make(chan struct{}),
+		cancel: cancel,
+		input:  agent.NewLoopInputQueue(agent.LoopInputQueueConfig{}),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.evictLocked()
+	h.runs[run.id] = run
+	return run
+}
+
+// get returns a live or recently finished run.
+func (h *streamHub) get(id string) *streamRun {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.evictLocked()
+	return h.runs[id]
+}
+
+func (h *streamHub) evictLocked() {
+	now := h.now()
+	for id, run := range h.runs {
+		run.mu.Lock()
+		expired := run.done && now.Sub(run.finishedAt) > h.cfg.RetainFor
+		run.mu.Unlock()
+		if expired {
+			delete(h.runs, id)
+		}
+	}
+}
+
+// parseEventID splits a Last-Event-ID value into run ID and sequence.
+func parseEventID(id string) (string, int64, bool) {
+	i := strings.LastIndex(id, ":")
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseInt(id[i+1:], 10, 64)
+	if err != nil || seq < 0 {
+		return "", 0, false
+	}
+	return id[:i], seq, true
+}
+
+func newRunID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "run_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+func streamTestController(replay StreamReplayConfig) *ChatController {
+	stub := &stubAgent{
+		stream: []agent.AgentStreamEvent{
+			{Type: agent.AgentEventAgentStart},
+			{Type: agent.AgentEventMessageDelta, Delta: "Hel"},
+			{Type: agent.AgentEventMessageDelta, Delta: "lo"},
+			{Type: agent.AgentEventAgentEnd},
+		},
+	}
+	return NewChatController(stub, ChatConfig{
+		DefaultDir:      "/tmp",
+		EnableStreaming: true,
+		StreamReplay:    replay,
+	})
+}
+
+func postChatStream(t *testing.T, ctrl *ChatController, lastEventID string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/chat/stream", bytes.NewBufferString(`{"message":"hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	w := httptest.NewRecorder()
+	ctrl.HandleChatStream(w, req)
+	return w
+}
+
+func TestHandleChatStream_AssignsMonotonicEventIDs(t *testing.T) {
+	ctrl := streamTestController(StreamReplayConfig{})
+	w := postChatStream(t, ctrl, "")
+
+	runID := w.Header().Get("X-Run-ID")
+	if runID == "" {
+		t.Fatal("expected X-Run-ID header")
+	}
+	body := w.Body.String()
+	for i := 1; i <= 4; i++ {
+		id := "id: " + runID + ":" + strconv.Itoa(i) + "\n"
+		if !strings.Contains(body, id) {
+			t.Fatalf("expected %q in stream, got %q", id, body)
+		}
+	}
+}
+
+func TestHandleChatStream_ResumesAfterLastEventID(t *testing.T) {
+	ctrl := streamTestController(StreamReplayConfig{})
+	runID := postChatStream(t, ctrl, "").Header().Get("X-Run-ID")
+
+	w := postChatStream(t, ctrl, runID+":2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if strings.Contains(body, `"delta":"Hel"`) || strings.Contains(body, "event: agent_start") {
+		t.Fatalf("expected events up to 2 to be skipped, got %q", body)
+	}
+	if !strings.Contains(body, "id: "+runID+":3\n") || !strings.Contains(body, `"delta":"lo"`) {
+		t.Fatalf("expected replay from event 3, got %q", body)
+	}
+}
+
+func TestHandleResumeStream_ReplaysFromStart(t *testing.T) {
+	ctrl := streamTestController(StreamReplayConfig{})
+	runID := postChatStream(t, ctrl, "").Header().Get("X-Run-ID")
+
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+	req := httptest.NewRequest(http.MethodGet, "/api/chat/stream/"+runID, nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "id: "+runID+":1\n") {
+		t.Fatalf("expected full replay, got %d: %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleChatStream_ResumeErrors(t *testing.T) {
+	ctrl := streamTestController(StreamReplayConfig{BufferSize: 2})
+	runID := postChatStream(t, ctrl, "").Header().Get("X-Run-ID")
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		status      int
+		code        string
+	}{
+		{"malformed", "nope", http.StatusBadRequest, ""},
+		{"unknown run", "run_missing:1", http.StatusNotFound, ErrCodeStreamNotFound},
+		{"dropped events", runID + ":1", http.StatusGone, ErrCodeStreamExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postChatStream(t, ctrl, tt.lastEventID)
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.code != "" && !strings.Contains(w.Body.String(), tt.code) {
+				t.Fatalf("expected code %s, got %s", tt.code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestStreamHubEvictsFinishedRuns(t *testing.T) {
+	hub := newStreamHub(StreamReplayConfig{RetainFor: time.Minute})
+	now := time.Now()
+	hub.now = func() time.Time { return now }
+
+	run := hub.start(nil)
+	run.finish(now)
+	if hub.get(run.id) == nil {
+		t.Fatal("expected finished run to be retained")
+	}
+	now = now.Add(2 * time.Minute)
+	if hub.get(run.id) != nil {
+		t.Fatal("expected finished run to be evicted after RetainFor")
+	}
+}
+
+func TestStreamRunCancelsWhenAbandoned(t *testing.T) {
+	cancelled := make(chan struct{})
+	hub := newStreamHub(StreamReplayConfig{})
+	run := hub.start(func() { close(cancelled) })
+
+	run.attach()
+	run.detach(10 * time.Millisecond)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("expected abandoned run to be cancelled")
+	}
+}
+
+func TestHandleCancelStream(t *testing.T) {
+	ctrl := streamTestController(StreamReplayConfig{})
+	cancelled := make(chan struct{})
+	running := ctrl.streams.start(func() { close(cancelled) })
+	finished := postChatStream(t, ctrl, "").Header().Get("X-Run-ID")
+
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+	cancel := func(runID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/chat/stream/"+runID+"/cancel", nil))
+		return w
+	}
+
+	w := cancel(running.id)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cancelled":true`) {
+		t.Fatalf("expected running stream to be cancelled, got %d: %s", w.Code, w.Body.String())
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("expected run cancel func to be called")
+	}
+
+	w = cancel(finished)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cancelled":false`) {
+		t.Fatalf("expected finished stream to report cancelled=false, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = cancel("run_missing")
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), ErrCodeStreamNotFound) {
+		t.Fatalf("expected 404 %s, got %d: %s", ErrCodeStreamNotFound, w.Code, w.Body.String())
+	}
+}
+
+// askingAgent emits a user_input_required event and replies with the first
+// steering message it receives.
+type askingAgent struct{ stubAgent }
+
+func (a *askingAgent) ExecuteStream(ctx context.Context, req agent.AgentRequest) (<-chan agent.AgentStreamEvent, <-chan error) {
+	eventCh := make(chan agent.AgentStreamEvent, 4)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(eventCh)
+		defer close(errCh)
+		eventCh <- agent.AgentStreamEvent{Type: agent.AgentEventUserInputRequired, Message: "Which region?", Options: []string{"eu", "us"}}
+		steering := req.Options.InputQueue.Steering()
+		for {
+			msgs, _ := steering(ctx, agent.LoopInputSnapshot{})
+			if len(msgs) > 0 {
+				eventCh <- agent.AgentStreamEvent{Type: agent.AgentEventMessageEnd, Message: "deploying to " + msgs[0].GetText()}
+				eventCh <- agent.AgentStreamEvent{Type: agent.AgentEventAgentEnd}
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+	return eventCh, errCh
+}
+
+func TestHandleReplyStream_AnswersPendingQuestion(t *testing.T) {
+	ctrl := NewChatController(&askingAgent{}, ChatConfig{DefaultDir: "/tmp", EnableStreaming: true})
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/chat/stream", "application/json", bytes.NewBufferString(`{"message":"deploy"}`))
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	runID := resp.Header.Get("X-Run-ID")
+	reader := bufio.NewReader(resp.Body)
+	readUntil := func(marker string) {
+		t.Helper()
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("stream ended before %q: %v", marker, err)
+			}
+			if strings.Contains(line, marker) {
+				return
+			}
+		}
+	}
+	readUntil(`"options":["eu","us"]`)
+
+	reply, err := http.Post(server.URL+"/api/chat/stream/"+runID+"/reply", "application/json", bytes.NewBufferString(`{"message":"eu"}`))
+	if err != nil {
+		t.Fatalf("reply: %v", err)
+	}
+	reply.Body.Close()
+	if reply.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", reply.StatusCode)
+	}
+	readUntil(`"message":"deploying to eu"`)
+	readUntil("event: agent_end")
+
+	for path, want := range map[string]int{
+		"/api/chat/stream/" + runID + "/reply": http.StatusConflict,
+		"/api/chat/stream/run_missing/reply":   http.StatusNotFound,
+	} {
+		var status int
+		deadline := time.Now().Add(time.Second)
+		for {
+			r, err := http.Post(server.URL+path, "application/json", bytes.NewBufferString(`{"message":"us"}`))
+			if err != nil {
+				t.Fatalf("reply: %v", err)
+			}
+			r.Body.Close()
+			status = r.StatusCode
+			// The run is marked finished just after its last event.
+			if status == want || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if status != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, status)
+		}
+	}
+}
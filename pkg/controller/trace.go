@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+// TraceResponse is the JSON response from GET /api/sessions/{session}/trace.
+type TraceResponse struct {
+	SessionID string `json:"session_id"`
+	Format    string `json:"format"`
+
+	// Trace is the diagram source, e.g. for a Mermaid or Graphviz renderer.
+	Trace string `json:"trace"`
+}
+
+// HandleTrace renders a session's stored conversation as a diagram (see
+// agent.ExportTrace). format is mermaid (default) or dot. It requires the
+// admin token, and the shared default session is never readable.
+func (c *ChatController) HandleTrace(w http.ResponseWriter, r *http.Request) {
+	if !c.requireAdmin(w, r) {
+		return
+	}
+	format := agent.TraceFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = agent.TraceMermaid
+	}
+	if format != agent.TraceMermaid && format != agent.TraceDOT {
+		reqErr := badRequest("format must be mermaid or dot")
+		writeJSON(w, reqErr.status, reqErr.response())
+		return
+	}
+	sessionID := r.PathValue("session")
+	history, ok := c.sessions.history(sessionID)
+	if !ok || sessionID == defaultSessionID {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "session not found", Code: ErrCodeSessionNotFound})
+		return
+	}
+	trace, err := agent.ExportTrace(history, format)
+	if err != nil {
+		reqErr := badRequest(err.Error())
+		writeJSON(w, reqErr.status, reqErr.response())
+		return
+	}
+	writeJSON(w, http.StatusOK, TraceResponse{SessionID: sessionID, Format: string(format), Trace: trace})
+}
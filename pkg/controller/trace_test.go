@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+)
+
+func getTrace(t *testing.T, ctrl *ChatController, session, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+	req := httptest.NewRequest(http.MethodGet, "/api/sessions/"+session+"/trace"+query, nil)
+	req.Header.Set("Authorization", "Bearer "+ctrl.cfg.AdminToken)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleTrace(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{
+		Message: "done",
+		RawOutput: []agenttypes.Message{
+			agenttypes.NewTextMessage(agenttypes.RoleUser, "list files"),
+			{Role: agenttypes.RoleAssistant, Content: []agenttypes.ContentBlock{{Type: agenttypes.ContentTypeToolUse, ID: "call-1", Name: "bash"}}},
+			agenttypes.NewToolResultMessage("call-1", "a.go", false),
+			agenttypes.NewTextMessage(agenttypes.RoleAssistant, "done"),
+		},
+	}}
+	ctrl := NewChatController(stub, ChatConfig{AdminToken: "secret"})
+	if w := postChat(t, ctrl, `{"message":"list files","session_id":"s1"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for format, want := range map[string]string{"": "A->>T: bash", "?format=dot": `[label="bash", shape=ellipse]`} {
+		w := getTrace(t, ctrl, "s1", format)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d: %s", format, w.Code, w.Body.String())
+		}
+		var resp TraceResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if resp.SessionID != "s1" || !strings.Contains(resp.Trace, want) {
+			t.Fatalf("%q: expected %q in trace, got %+v", format, want, resp)
+		}
+	}
+
+	if w := getTrace(t, ctrl, "s1", "?format=svg"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown format, got %d", w.Code)
+	}
+	if w := getTrace(t, ctrl, "missing", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown session, got %d", w.Code)
+	}
+}
+
+func TestHandleTrace_RequiresAdminAndNamedSession(t *testing.T) {
+	stub := &stubAgent{result: agent.AgentResult{
+		Message:   "done",
+		RawOutput: []agenttypes.Message{agenttypes.NewTextMessage(agenttypes.RoleUser, "secret task")},
+	}}
+	ctrl := NewChatController(stub, ChatConfig{AdminToken: "secret"})
+	postChat(t, ctrl, `{"message":"secret task"}`)
+	postChat(t, ctrl, `{"message":"secret task","session_id":"s1"}`)
+
+	mux := http.NewServeMux()
+	ctrl.RegisterRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/sessions/s1/trace", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", w.Code)
+	}
+	if w := getTrace(t, ctrl, defaultSessionID, ""); w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "secret task") {
+		t.Fatalf("expected 404 for the default session, got %d: %s", w.Code, w.Body.String())
+	}
+
+	ctrl = NewChatController(stub, ChatConfig{})
+	postChat(t, ctrl, `{"message":"secret task","session_id":"s1"}`)
+	var errResp ErrorResponse
+	w = getTrace(t, ctrl, "s1", "")
+	json.Unmarshal(w.Body.Bytes(), &errResp)
+	if w.Code != http.StatusNotFound || errResp.Code != ErrCodeAdminDisabled {
+		t.Fatalf("expected 404 admin_disabled without an admin token, got %d %+v", w.Code, errResp)
+	}
+}
@@ -0,0 +1,87 @@
+package coordinator
+
+import (
+	"sync"
+	"time"
+)
+
+// EntryKind classifies blackboard entries.
+type EntryKind string
+
+const (
+	// EntryTask is a task handed to a member (the initial task or a hand-off).
+	EntryTask EntryKind = "task"
+
+	// EntryMessage is a note posted by a member with post_message.
+	EntryMessage EntryKind = "message"
+
+	// EntryResult is a member's final reply for a turn.
+	EntryResult EntryKind = "result"
+
+	// EntryError records a failed turn.
+	EntryError EntryKind = "error"
+)
+
+// Entry is one message on the blackboard.
+type Entry struct {
+	// Seq is the 1-based position on the board.
+	Seq int
+
+	// From is the posting member; empty for the caller's initial task.
+	From string
+
+	// To is the addressed member; empty means everyone.
+	To string
+
+	Kind    EntryKind
+	Content string
+	Time    time.Time
+}
+
+// Blackboard is the shared, append-only message log of a coordinator run.
+// It is safe for concurrent use.
+type Blackboard struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+// NewBlackboard returns an empty blackboard.
+func NewBlackboard() *Blackboard {
+	return &Blackboard{}
+}
+
+// Post appends e, assigning its Seq and Time, and returns the stored entry.
+func (b *Blackboard) Post(e Entry) Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e.Seq = len(b.entries) + 1
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.entries = append(b.entries, e)
+	return e
+}
+
+// Entries returns a copy of all entries in posting order.
+func (b *Blackboard) Entries() []Entry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]Entry(nil), b.entries...)
+}
+
+// Visible returns the entries member can see: broadcasts and entries sent
+// by or addressed to it. limit > 0 keeps only the most recent entries.
+func (b *Blackboard) Visible(member string, limit int) []Entry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var out []Entry
+	for _, e := range b.entries {
+		if e.To == "" || e.To == member || e.From == member {
+			out = append(out, e)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
@@ -0,0 +1,390 @@
+// Package coordinator runs several named agents (e.g. planner, coder,
+// reviewer) concurrently over a shared blackboard. Members hand work to each
+// other with the handoff tool (or a Supervisor routes it), and the
+// coordinator collects every turn into one Result.
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+const (
+	// DefaultMaxTurns bounds member executions per run.
+	DefaultMaxTurns = 20
+
+	// DefaultBoardContextEntries is how many recent blackboard entries are
+	// included in each member task.
+	DefaultBoardContextEntries = 20
+
+	// EnvMemberName is set in AgentRequest.Env to the executing member's name.
+	EnvMemberName = "COORDINATOR_MEMBER"
+
+	// maxBoardEntryChars truncates long entries in member task context.
+	maxBoardEntryChars = 2000
+)
+
+// ErrMaxTurns is returned when hand-offs are still pending after MaxTurns
+// member executions.
+var ErrMaxTurns = errors.New("coordinator: max turns reached")
+
+// Member is a named agent participating in a run.
+type Member struct {
+	// Name identifies the member in hand-offs and on the blackboard.
+	Name string
+
+	// Agent executes the member's turns. A member runs at most one turn at
+	// a time, so agents need not be safe for concurrent Execute calls.
+	Agent agent.Agent
+
+	// Instructions describe the member's role and are prepended to every
+	// task it receives.
+	Instructions string
+}
+
+// Handoff asks a member to work on a task.
+type Handoff struct {
+	// From is the requesting member; empty for the initial task.
+	From string
+
+	// To is the member that should run the task.
+	To string
+
+	Task string
+}
+
+// Turn is one member execution.
+type Turn struct {
+	Member  string
+	Handoff Handoff
+	Result  agent.AgentResult
+	Err     error
+
+	// Handoffs were requested by the member with the handoff tool.
+	Handoffs []Handoff
+}
+
+// Supervisor decides which hand-offs follow a turn. The returned hand-offs
+// replace the ones the member requested (turn.Handoffs); returning them
+// unchanged keeps member-driven routing. An error stops the run.
+type Supervisor func(ctx context.Context, board *Blackboard, turn Turn) ([]Handoff, error)
+
+// Config configures a Coordinator.
+type Config struct {
+	// Members are the participating agents. Names must be unique.
+	Members []Member
+
+	// Entry is the member that receives the initial task (default: the
+	// first member).
+	Entry string
+
+	// MaxTurns bounds member executions per run (default DefaultMaxTurns).
+	MaxTurns int
+
+	// MaxConcurrent limits concurrently executing members (default: all).
+	MaxConcurrent int
+
+	// BoardContextEntries is how many recent visible blackboard entries are
+	// included in each task (default DefaultBoardContextEntries; negative
+	// disables).
+	BoardContextEntries int
+
+	// Supervisor routes work after each turn. Nil follows the hand-offs
+	// members request.
+	Supervisor Supervisor
+
+	// Request is the template for member requests (WorkDir, Env, Options,
+	// Callbacks, ...). Task is replaced per turn. Callbacks may be invoked
+	// concurrently from different members.
+	Request agent.AgentRequest
+}
+
+// Result is the combined outcome of a run.
+type Result struct {
+	// Success is true when every turn completed without error.
+	Success bool
+
+	// Message is the reply of the last completed turn.
+	Message string
+
+	// Turns lists member executions in completion order.
+	Turns []Turn
+
+	// Board is the final blackboard.
+	Board []Entry
+
+	// Usage sums the usage of all turns.
+	Usage agent.ExecutionUsage
+}
+
+// Coordinator runs multi-agent workflows. It is safe for concurrent Run
+// calls as long as members are not shared between concurrent runs.
+type Coordinator struct {
+	cfg     Config
+	members map[string]Member
+	names   []string
+}
+
+// New validates cfg and returns a Coordinator.
+func New(cfg Config) (*Coordinator, error) {
+	if len(cfg.Members) == 0 {
+		return nil, fmt.Errorf("coordinator: at least one member is required")
+	}
+	c := &Coordinator{cfg: cfg, members: make(map[string]Member, len(cfg.Members))}
+	for _, m := range cfg.Members {
+		if strings.TrimSpace(m.Name) == "" {
+			return nil, fmt.Errorf("coordinator: member name is required")
+		}
+		if m.Agent == nil {
+			return nil, fmt.Errorf("coordinator: member %s has no agent", m.Name)
+		}
+		if _, ok := c.members[m.Name]; ok {
+			return nil, fmt.Errorf("coordinator: duplicate member %s", m.Name)
+		}
+		c.members[m.Name] = m
+		c.names = append(c.names, m.Name)
+	}
+	if c.cfg.Entry == "" {
+		c.cfg.Entry = cfg.Members[0].Name
+	}
+	if _, ok := c.members[c.cfg.Entry]; !ok {
+		return nil, fmt.Errorf("coordinator: unknown entry member %s", c.cfg.Entry)
+	}
+	if c.cfg.MaxTurns <= 0 {
+		c.cfg.MaxTurns = DefaultMaxTurns
+	}
+	if c.cfg.MaxConcurrent <= 0 {
+		c.cfg.MaxConcurrent = len(cfg.Members)
+	}
+	if c.cfg.BoardContextEntries == 0 {
+		c.cfg.BoardContextEntries = DefaultBoardContextEntries
+	}
+	return c, nil
+}
+
+// Run hands task to the entry member and keeps dispatching hand-offs until
+// none are pending. Member failures are recorded in the result's turns;
+// the returned error reports cancellation, supervisor errors, or
+// ErrMaxTurns.
+func (c *Coordinator) Run(ctx context.Context, task string) (Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	board := NewBlackboard()
+	first := Handoff{To: c.cfg.Entry, Task: task}
+	board.Post(Entry{To: first.To, Kind: EntryTask, Content: task})
+	pending := []Handoff{first}
+
+	var result Result
+	busy := make(map[string]bool)
+	done := make(chan Turn)
+	running, started := 0, 0
+	var runErr error
+
+	for {
+		if ctx.Err() == nil && runErr == nil {
+			for i := 0; i < len(pending) && running < c.cfg.MaxConcurrent && started < c.cfg.MaxTurns; {
+				h := pending[i]
+				if busy[h.To] {
+					i++
+					continue
+				}
+				pending = append(pending[:i], pending[i+1:]...)
+				busy[h.To] = true
+				running++
+				started++
+				log.Printf("[coordinator] turn %d: %s (from %q)", started, h.To, h.From)
+				go func() { done <- c.runTurn(ctx, board, h) }()
+			}
+		}
+		if running == 0 {
+			break
+		}
+
+		turn := <-done
+		running--
+		busy[turn.Member] = false
+		result.Turns = append(result.Turns, turn)
+		if runErr != nil || ctx.Err() != nil {
+			continue
+		}
+
+		next := turn.Handoffs
+		if c.cfg.Supervisor != nil {
+			var err error
+			if next, err = c.cfg.Supervisor(ctx, board, turn); err != nil {
+				runErr = fmt.Errorf("coordinator: supervisor: %w", err)
+				cancel()
+				continue
+			}
+		}
+		for _, h := range next {
+			if _, ok := c.members[h.To]; !ok {
+				log.Printf("[coordinator] WARNING: dropping hand-off to unknown member %q", h.To)
+				board.Post(Entry{From: h.From, Kind: EntryError, Content: fmt.Sprintf("unknown member %q", h.To)})
+				continue
+			}
+			board.Post(Entry{From: h.From, To: h.To, Kind: EntryTask, Content: h.Task})
+			pending = append(pending, h)
+		}
+	}
+
+	result.Board = board.Entries()
+	result.Success = true
+	for _, turn := range result.Turns {
+		if turn.Err != nil {
+			result.Success = false
+		} else {
+			result.Message = turn.Result.Message
+		}
+		result.Usage.TotalIterations += turn.Result.Usage.TotalIterations
+		result.Usage.TotalInputTokens += turn.Result.Usage.TotalInputTokens
+		result.Usage.TotalOutputTokens += turn.Result.Usage.TotalOutputTokens
+		result.Usage.TotalDuration += turn.Result.Usage.TotalDuration
+	}
+
+	switch {
+	case runErr != nil:
+	case ctx.Err() != nil:
+		runErr = ctx.Err()
+	case len(pending) > 0:
+		runErr = ErrMaxTurns
+	}
+	if runErr != nil {
+		result.Success = false
+	}
+	log.Printf("[coordinator] run finished: turns=%d success=%v", len(result.Turns), result.Success)
+	return result, runErr
+}
+
+// runTurn executes one hand-off and posts the outcome to the board.
+func (c *Coordinator) runTurn(ctx context.Context, board *Blackboard, h Handoff) Turn {
+	m := c.members[h.To]
+	scope := &turnScope{board: board, member: m.Name, members: c.names}
+
+	req := c.cfg.Request
+	req.Task = c.buildTask(board, m, h)
+	req.Env = make(map[string]string, len(c.cfg.Request.Env)+1)
+	for k, v := range c.cfg.Request.Env {
+		req.Env[k] = v
+	}
+	req.Env[EnvMemberName] = m.Name
+
+	start := time.Now()
+	res, err := m.Agent.Execute(withTurnScope(ctx, scope), req)
+	if err == nil && !res.Success {
+		err = fmt.Errorf("member %s: %s", m.Name, res.Message)
+	}
+	if res.Usage.TotalDuration == 0 {
+		res.Usage.TotalDuration = time.Since(start)
+	}
+
+	turn := Turn{Member: m.Name, Handoff: h, Result: res, Err: err, Handoffs: scope.takeHandoffs()}
+	if err != nil {
+		log.Printf("[coordinator] ERROR: %s failed: %v", m.Name, err)
+		board.Post(Entry{From: m.Name, Kind: EntryError, Content: err.Error()})
+	} else {
+		board.Post(Entry{From: m.Name, Kind: EntryResult, Content: res.Message})
+	}
+	return turn
+}
+
+// buildTask renders the member's role, the visible blackboard, and the task.
+func (c *Coordinator) buildTask(board *Blackboard, m Member, h Handoff) string {
+	var b strings.Builder
+	if instructions := strings.TrimSpace(m.Instructions); instructions != "" {
+		b.WriteString(instructions)
+		b.WriteString("\n\n")
+	}
+
+	fmt.Fprintf(&b, "## Team\n\nYou are %s, working with: %s.\n", m.Name, strings.Join(c.names, ", "))
+	b.WriteString("Use the handoff tool to pass work to a teammate and post_message to share notes on the blackboard.\n")
+
+	if c.cfg.BoardContextEntries > 0 {
+		// The task itself is the newest visible entry; show what came before.
+		entries := board.Visible(m.Name, c.cfg.BoardContextEntries+1)
+		if n := len(entries); n > 0 && entries[n-1].Kind == EntryTask && entries[n-1].To == m.Name {
+			entries = entries[:n-1]
+		}
+		if len(entries) > 0 {
+			b.WriteString("\n## Blackboard\n\n")
+			for _, e := range entries {
+				b.WriteString(formatEntry(e))
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	from := h.From
+	if from == "" {
+		from = "user"
+	}
+	fmt.Fprintf(&b, "\n## Your Task (from %s)\n\n%s", from, h.Task)
+	return b.String()
+}
+
+func formatEntry(e Entry) string {
+	from, to := e.From, e.To
+	if from == "" {
+		from = "user"
+	}
+	if to == "" {
+		to = "all"
+	}
+	content := strings.TrimSpace(e.Content)
+	if len(content) > maxBoardEntryChars {
+		content = content[:maxBoardEntryChars] + "..."
+	}
+	return fmt.Sprintf("- #%d %s -> %s [%s]: %s", e.Seq, from, to, e.Kind, content)
+}
+
+// turnScope carries the blackboard and caller identity to coordinator tools.
+type turnScope struct {
+	board   *Blackboard
+	member  string
+	members []string
+
+	mu       sync.Mutex
+	handoffs []Handoff
+}
+
+func (s *turnScope) addHandoff(h Handoff) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handoffs = append(s.handoffs, h)
+}
+
+func (s *turnScope) takeHandoffs() []Handoff {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.handoffs
+	s.handoffs = nil
+	return out
+}
+
+func (s *turnScope) isMember(name string) bool {
+	for _, m := range s.members {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+type turnScopeKey struct{}
+
+func withTurnScope(ctx context.Context, scope *turnScope) context.Context {
+	return context.WithValue(ctx, turnScopeKey{}, scope)
+}
+
+func turnScopeFrom(ctx context.Context) (*turnScope, bool) {
+	scope, ok := ctx.Value(turnScopeKey{}).(*turnScope)
+	return scope, ok
+}
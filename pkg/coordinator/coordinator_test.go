@@ -0,0 +1,215 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+// funcAgent is an agent.Agent backed by a function.
+type funcAgent struct {
+	execute func(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error)
+}
+
+func (a funcAgent) Execute(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+	return a.execute(ctx, req)
+}
+
+func (a funcAgent) ExecuteStream(ctx context.Context, req agent.AgentRequest) (<-chan agent.AgentStreamEvent, <-chan error) {
+	events := make(chan agent.AgentStreamEvent)
+	errs := make(chan error, 1)
+	close(events)
+	close(errs)
+	return events, errs
+}
+
+func (a funcAgent) Capabilities() agent.AgentCapabilities { return agent.AgentCapabilities{} }
+func (a funcAgent) Close() error                          { return nil }
+
+func replyAgent(reply string, handoffs ...map[string]any) funcAgent {
+	return funcAgent{execute: func(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+		for _, input := range handoffs {
+			if res, _ := (HandoffTool{}).Execute(ctx, nil, input); res.IsError {
+				return agent.AgentResult{}, errors.New(res.Content)
+			}
+		}
+		return agent.AgentResult{Success: true, Message: reply, Usage: agent.ExecutionUsage{TotalIterations: 1}}, nil
+	}}
+}
+
+func TestCoordinatorRunsHandoffsConcurrently(t *testing.T) {
+	// coder and reviewer each wait for the other to start, so the run only
+	// finishes if they execute concurrently.
+	var started sync.WaitGroup
+	started.Add(2)
+	concurrent := func(reply string) funcAgent {
+		return funcAgent{execute: func(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+			started.Done()
+			waited := make(chan struct{})
+			go func() { started.Wait(); close(waited) }()
+			select {
+			case <-waited:
+			case <-time.After(5 * time.Second):
+				return agent.AgentResult{}, errors.New("teammate did not start concurrently")
+			}
+			if _, err := (PostMessageTool{}).Execute(ctx, nil, map[string]any{"content": reply + " note"}); err != nil {
+				return agent.AgentResult{}, err
+			}
+			return agent.AgentResult{Success: true, Message: reply, Usage: agent.ExecutionUsage{TotalIterations: 1}}, nil
+		}}
+	}
+
+	var plannerTask string
+	handoffs := replyAgent("planned",
+		map[string]any{"to": "coder", "task": "write code"},
+		map[string]any{"to": "reviewer", "task": "review design"},
+	)
+	planner := funcAgent{execute: func(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+		plannerTask = req.Task
+		if req.Env[EnvMemberName] != "planner" {
+			t.Errorf("expected member env, got %v", req.Env)
+		}
+		return handoffs.Execute(ctx, req)
+	}}
+
+	c, err := New(Config{Members: []Member{
+		{Name: "planner", Agent: planner, Instructions: "You plan."},
+		{Name: "coder", Agent: concurrent("coded")},
+		{Name: "reviewer", Agent: concurrent("reviewed")},
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	result, err := c.Run(context.Background(), "build a widget")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !result.Success || len(result.Turns) != 3 || result.Usage.TotalIterations != 3 {
+		t.Fatalf("unexpected result: success=%v turns=%d usage=%+v", result.Success, len(result.Turns), result.Usage)
+	}
+	if result.Turns[0].Member != "planner" || len(result.Turns[0].Handoffs) != 2 {
+		t.Fatalf("unexpected first turn: %+v", result.Turns[0])
+	}
+	for _, want := range []string{"You plan.", "You are planner", "## Your Task (from user)", "build a widget"} {
+		if !strings.Contains(plannerTask, want) {
+			t.Fatalf("expected %q in planner task:\n%s", want, plannerTask)
+		}
+	}
+
+	kinds := map[EntryKind]int{}
+	for _, e := range result.Board {
+		kinds[e.Kind]++
+	}
+	if kinds[EntryTask] != 3 || kinds[EntryResult] != 3 || kinds[EntryMessage] != 2 {
+		t.Fatalf("unexpected board entry kinds: %v", kinds)
+	}
+}
+
+func TestCoordinatorSupervisorRoutesAndStops(t *testing.T) {
+	var reviews int
+	supervisor := func(ctx context.Context, board *Blackboard, turn Turn) ([]Handoff, error) {
+		if turn.Member == "coder" {
+			return []Handoff{{From: "coder", To: "reviewer", Task: "review " + turn.Result.Message}}, nil
+		}
+		reviews++
+		return nil, nil
+	}
+
+	var reviewerTask string
+	reviewer := funcAgent{execute: func(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+		reviewerTask = req.Task
+		return agent.AgentResult{Success: true, Message: "lgtm"}, nil
+	}}
+	c, err := New(Config{
+		Members: []Member{
+			{Name: "coder", Agent: replyAgent("patch-1")},
+			{Name: "reviewer", Agent: reviewer},
+		},
+		Supervisor: supervisor,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	result, err := c.Run(context.Background(), "fix the bug")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if reviews != 1 || result.Message != "lgtm" {
+		t.Fatalf("expected one review ending in lgtm, got reviews=%d message=%q", reviews, result.Message)
+	}
+	if !strings.Contains(reviewerTask, "[result]: patch-1") || !strings.Contains(reviewerTask, "review patch-1") {
+		t.Fatalf("expected blackboard context in reviewer task:\n%s", reviewerTask)
+	}
+}
+
+func TestCoordinatorStopsAtMaxTurns(t *testing.T) {
+	loop := replyAgent("again", map[string]any{"to": "solo", "task": "keep going"})
+	c, err := New(Config{Members: []Member{{Name: "solo", Agent: loop}}, MaxTurns: 3})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	result, err := c.Run(context.Background(), "start")
+	if !errors.Is(err, ErrMaxTurns) {
+		t.Fatalf("expected ErrMaxTurns, got %v", err)
+	}
+	if len(result.Turns) != 3 || result.Success {
+		t.Fatalf("expected 3 turns and failure, got turns=%d success=%v", len(result.Turns), result.Success)
+	}
+}
+
+func TestCoordinatorRecordsMemberErrors(t *testing.T) {
+	failing := funcAgent{execute: func(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+		return agent.AgentResult{}, errors.New("boom")
+	}}
+	c, err := New(Config{Members: []Member{{Name: "coder", Agent: failing}}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	result, err := c.Run(context.Background(), "start")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Success || result.Turns[0].Err == nil {
+		t.Fatalf("expected failed turn, got %+v", result)
+	}
+	if last := result.Board[len(result.Board)-1]; last.Kind != EntryError || last.Content != "boom" {
+		t.Fatalf("expected error entry, got %+v", last)
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	a := replyAgent("ok")
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no members", Config{}},
+		{"missing name", Config{Members: []Member{{Agent: a}}}},
+		{"missing agent", Config{Members: []Member{{Name: "a"}}}},
+		{"duplicate", Config{Members: []Member{{Name: "a", Agent: a}, {Name: "a", Agent: a}}}},
+		{"unknown entry", Config{Members: []Member{{Name: "a", Agent: a}}, Entry: "b"}},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.cfg); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestToolsRequireCoordinator(t *testing.T) {
+	for _, tool := range Tools() {
+		res, err := tool.Execute(context.Background(), nil, map[string]any{"to": "a", "task": "t", "content": "c"})
+		if err != nil || !res.IsError {
+			t.Fatalf("%s: expected error result outside a run, got %+v, %v", tool.Name(), res, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// Tools returns the hand-off and blackboard tools. Register them in each
+// member agent's registry (or bundle them in a plugin); they act on the
+// coordinator run the executing member belongs to.
+func Tools() []tools.Tool {
+	return []tools.Tool{HandoffTool{}, PostMessageTool{}, ReadBlackboardTool{}}
+}
+
+var errNoCoordinator = errors.New("not running under a coordinator")
+
+// HandoffTool asks another member to work on a task after this turn.
+type HandoffTool struct{}
+
+func (t HandoffTool) Name() string {
+	return "handoff"
+}
+
+func (t HandoffTool) Description() string {
+	return "Hand a task to a teammate. The teammate starts once your turn ends; include all context it needs."
+}
+
+func (t HandoffTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"to": map[string]any{
+				"type":        "string",
+				"description": "Name of the teammate to hand the task to",
+			},
+			"task": map[string]any{
+				"type":        "string",
+				"description": "Task description for the teammate",
+			},
+		},
+		"required": []string{"to", "task"},
+	}
+}
+
+func (t HandoffTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	scope, ok := turnScopeFrom(ctx)
+	if !ok {
+		return tools.NewErrorResult(errNoCoordinator), nil
+	}
+	to, _ := input["to"].(string)
+	to = strings.TrimSpace(to)
+	task, _ := input["task"].(string)
+	if strings.TrimSpace(task) == "" {
+		return tools.NewErrorResultf("task is required"), nil
+	}
+	if !scope.isMember(to) {
+		return tools.NewErrorResultf("unknown teammate %q (available: %s)", to, strings.Join(scope.members, ", ")), nil
+	}
+	scope.addHandoff(Handoff{From: scope.member, To: to, Task: task})
+	return tools.NewToolResult(fmt.Sprintf("Handed off to %s.", to)), nil
+}
+
+// PostMessageTool posts a note to the shared blackboard.
+type PostMessageTool struct{}
+
+func (t PostMessageTool) Name() string {
+	return "post_message"
+}
+
+func (t PostMessageTool) Description() string {
+	return "Post a note to the shared blackboard, for everyone or for one teammate."
+}
+
+func (t PostMessageTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"content": map[string]any{
+				"type":        "string",
+				"description": "Message content",
+			},
+			"to": map[string]any{
+				"type":        "string",
+				"description": "Optional teammate to address (default: everyone)",
+			},
+		},
+		"required": []string{"content"},
+	}
+}
+
+func (t PostMessageTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	scope, ok := turnScopeFrom(ctx)
+	if !ok {
+		return tools.NewErrorResult(errNoCoordinator), nil
+	}
+	content, _ := input["content"].(string)
+	if strings.TrimSpace(content) == "" {
+		return tools.NewErrorResultf("content is required"), nil
+	}
+	to, _ := input["to"].(string)
+	to = strings.TrimSpace(to)
+	if to != "" && !scope.isMember(to) {
+		return tools.NewErrorResultf("unknown teammate %q (available: %s)", to, strings.Join(scope.members, ", ")), nil
+	}
+	entry := scope.board.Post(Entry{From: scope.member, To: to, Kind: EntryMessage, Content: content})
+	return tools.NewToolResult(fmt.Sprintf("Posted message #%d.", entry.Seq)), nil
+}
+
+// ReadBlackboardTool returns the blackboard entries visible to the member.
+type ReadBlackboardTool struct{}
+
+func (t ReadBlackboardTool) Name() string {
+	return "read_blackboard"
+}
+
+func (t ReadBlackboardTool) Description() string {
+	return "Read recent blackboard entries (tasks, results, and messages) visible to you."
+}
+
+func (t ReadBlackboardTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Maximum number of recent entries to return (default: 50)",
+			},
+		},
+	}
+}
+
+func (t ReadBlackboardTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	scope, ok := turnScopeFrom(ctx)
+	if !ok {
+		return tools.NewErrorResult(errNoCoordinator), nil
+	}
+	limit := 50
+	if l, ok := input["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+	entries := scope.board.Visible(scope.member, limit)
+	if len(entries) == 0 {
+		return tools.NewToolResult("The blackboard is empty."), nil
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(formatEntry(e))
+		b.WriteString("\n")
+	}
+	return tools.NewToolResult(b.String()), nil
+}
@@ -0,0 +1,203 @@
+// Package embeddings converts text to embedding vectors for semantic search
+// and memory retrieval. Providers satisfy agent.Embedder, so one can be
+// passed straight to PruneConfig.Embedder.
+//
+// OpenAI calls the OpenAI embeddings API or any compatible endpoint (Azure,
+// vLLM, LiteLLM); Ollama calls a local Ollama server.
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+const (
+	defaultTimeout     = 60 * time.Second
+	defaultMaxAttempts = 3
+
+	// maxErrorBodyBytes bounds the response body quoted in errors.
+	maxErrorBodyBytes = 512
+)
+
+// Provider converts texts to embedding vectors.
+type Provider interface {
+	// Name identifies the backend in logs, e.g. "openai".
+	Name() string
+
+	// Embed returns one vector per text, in order. Implementations split
+	// large inputs into batches the backend accepts.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Dimensions is the length of the vectors, or 0 when it is not known
+	// until the first call.
+	Dimensions() int
+}
+
+// EmbedOne embeds a single text.
+func EmbedOne(ctx context.Context, p Provider, text string) ([]float32, error) {
+	vectors, err := p.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0
+// when their lengths differ or either is zero.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// APIError is a non-2xx response from an embeddings backend.
+type APIError struct {
+	Provider string
+	Status   int
+	Body     string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s embeddings API returned status %d: %s", e.Provider, e.Status, e.Body)
+}
+
+// embedBatches calls embed for consecutive batches of at most size texts
+// and joins the results, checking that every batch returned one vector
+// per text.
+func embedBatches(ctx context.Context, texts []string, size int, embed func(context.Context, []string) ([][]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	out := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		batch := texts[start:min(start+size, len(texts))]
+		vectors, err := embed(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(batch) {
+			return nil, fmt.Errorf("embeddings API returned %d vectors for %d texts", len(vectors), len(batch))
+		}
+		out = append(out, vectors...)
+	}
+	return out, nil
+}
+
+// httpBackend posts JSON to an embeddings endpoint, retrying rate limits,
+// server errors, and network failures.
+type httpBackend struct {
+	provider    string
+	client      *http.Client
+	headers     map[string]string
+	maxAttempts int
+	backoff     func(attempt int) time.Duration
+}
+
+func newHTTPBackend(provider string, client *http.Client, timeout time.Duration, headers map[string]string, maxAttempts int) httpBackend {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if client == nil {
+		var err error
+		client, err = llm.NewHTTPClient(llm.HTTPConfig{}, timeout)
+		if err != nil {
+			log.Printf("[embeddings] WARNING: shared transport unavailable, using default client: %v", err)
+			client = &http.Client{Timeout: timeout}
+		}
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	return httpBackend{provider: provider, client: client, headers: headers, maxAttempts: maxAttempts, backoff: defaultBackoff}
+}
+
+func defaultBackoff(attempt int) time.Duration {
+	return time.Duration(1<<(attempt-1)) * 500 * time.Millisecond
+}
+
+// post sends payload to url and decodes the JSON response into out.
+func (b httpBackend) post(ctx context.Context, url string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	var lastErr error
+	for attempt := 1; attempt <= b.maxAttempts; attempt++ {
+		respBody, status, err := b.do(ctx, url, body)
+		switch {
+		case err == nil && status < 300:
+			if err := json.Unmarshal(respBody, out); err != nil {
+				return fmt.Errorf("decode %s embeddings response: %w", b.provider, err)
+			}
+			return nil
+		case err != nil:
+			lastErr = err
+		default:
+			lastErr = &APIError{Provider: b.provider, Status: status, Body: truncateBody(respBody)}
+		}
+		if attempt == b.maxAttempts || !shouldRetry(status, err) || ctx.Err() != nil {
+			break
+		}
+		wait := b.backoff(attempt)
+		log.Printf("[embeddings] %s attempt %d/%d failed, retrying in %v: %v", b.provider, attempt, b.maxAttempts, wait, lastErr)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return lastErr
+}
+
+func (b httpBackend) do(ctx context.Context, url string, body []byte) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	llm.CustomizeRequest(req, b.headers, nil)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return respBody, resp.StatusCode, nil
+}
+
+func shouldRetry(status int, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return status == http.StatusTooManyRequests || status == http.StatusRequestTimeout || status >= 500
+}
+
+func truncateBody(body []byte) string {
+	if len(body) > maxErrorBodyBytes {
+		return string(body[:maxErrorBodyBytes]) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,175 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+)
+
+var (
+	_ agent.Embedder = (*OpenAI)(nil)
+	_ agent.Embedder = (*Ollama)(nil)
+)
+
+func TestOpenAIEmbedBatchesAndOrders(t *testing.T) {
+	var requests []openaiEmbeddingRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request: %s auth=%q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req openaiEmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, req)
+
+		// Reply in reverse order; each vector encodes its text's length.
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var data []item
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, item{Index: i, Embedding: []float32{float32(len(req.Input[i])), 1}})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAI(OpenAIConfig{BaseURL: srv.URL + "/v1/", APIKey: "key", Model: "custom", Dimensions: 2, BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vectors, err := p.Embed(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if len(requests) != 2 || len(requests[0].Input) != 2 || len(requests[1].Input) != 1 {
+		t.Fatalf("expected batches of 2 and 1, got %+v", requests)
+	}
+	if requests[0].Model != "custom" || requests[0].Dimensions != 2 {
+		t.Fatalf("unexpected request fields: %+v", requests[0])
+	}
+	for i, want := range []float32{1, 2, 3} {
+		if vectors[i][0] != want {
+			t.Fatalf("vector %d = %v, want first component %v", i, vectors[i], want)
+		}
+	}
+	if p.Dimensions() != 2 {
+		t.Fatalf("Dimensions() = %d, want 2", p.Dimensions())
+	}
+}
+
+func TestOpenAIDimensions(t *testing.T) {
+	p, err := NewOpenAI(OpenAIConfig{APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Dimensions() != 1536 {
+		t.Fatalf("Dimensions() = %d, want 1536 for %s", p.Dimensions(), DefaultOpenAIModel)
+	}
+	if _, err := NewOpenAI(OpenAIConfig{}); err == nil {
+		t.Fatal("expected an error without an API key")
+	}
+}
+
+func TestOpenAIRetriesAndReportsErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			http.Error(w, `{"error":"slow down"}`, http.StatusTooManyRequests)
+		case 2:
+			json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{{"index": 0, "embedding": []float32{1}}}})
+		default:
+			http.Error(w, `{"error":"bad input"}`, http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAI(OpenAIConfig{BaseURL: srv.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.http.backoff = func(int) time.Duration { return 0 }
+
+	if _, err := EmbedOne(context.Background(), p, "x"); err != nil {
+		t.Fatalf("expected the retry to succeed: %v", err)
+	}
+	_, err = p.Embed(context.Background(), []string{"x"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest {
+		t.Fatalf("expected a 400 APIError, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected client errors not to be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestOpenAIRejectsMissingVectors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{{"index": 0, "embedding": []float32{1}}}})
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAI(OpenAIConfig{BaseURL: srv.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Embed(context.Background(), []string{"a", "b"}); err == nil {
+		t.Fatal("expected an error for a short response")
+	}
+}
+
+func TestOllamaEmbed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req ollamaEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Model != DefaultOllamaModel {
+			t.Errorf("unexpected model %q", req.Model)
+		}
+		vectors := make([][]float32, len(req.Input))
+		for i := range vectors {
+			vectors[i] = []float32{0, 1, 0}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"embeddings": vectors})
+	}))
+	defer srv.Close()
+
+	p := NewOllama(OllamaConfig{BaseURL: srv.URL})
+	if p.Dimensions() != 0 {
+		t.Fatalf("expected unknown dimensions before the first call, got %d", p.Dimensions())
+	}
+	vectors, err := p.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if len(vectors) != 2 || p.Dimensions() != 3 {
+		t.Fatalf("unexpected result: %v dimensions=%d", vectors, p.Dimensions())
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := CosineSimilarity([]float32{1, 0}, []float32{1, 0}); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("identical vectors: got %v", got)
+	}
+	if got := CosineSimilarity([]float32{1, 0}, []float32{0, 1}); got != 0 {
+		t.Fatalf("orthogonal vectors: got %v", got)
+	}
+	if got := CosineSimilarity([]float32{1}, []float32{1, 0}); got != 0 {
+		t.Fatalf("mismatched lengths: got %v", got)
+	}
+}
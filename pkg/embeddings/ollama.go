@@ -0,0 +1,108 @@
+package embeddings
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultOllamaBaseURL is the address of a local Ollama server.
+	DefaultOllamaBaseURL = "http://localhost:11434"
+
+	// DefaultOllamaModel is used when OllamaConfig.Model is empty.
+	DefaultOllamaModel = "nomic-embed-text"
+
+	defaultOllamaBatchSize = 64
+	ollamaEmbedPath        = "/api/embed"
+)
+
+// OllamaConfig configures a local Ollama embeddings provider.
+type OllamaConfig struct {
+	// BaseURL is the server address (default DefaultOllamaBaseURL).
+	BaseURL string
+
+	// Model defaults to DefaultOllamaModel. It must already be pulled.
+	Model string
+
+	// BatchSize caps the texts sent per request (default 64).
+	BatchSize int
+
+	// Timeout bounds each request (default 60s); MaxAttempts is the number
+	// of tries for server errors (default 3).
+	Timeout     time.Duration
+	MaxAttempts int
+
+	// HTTPClient overrides the client; nil uses the shared transport.
+	HTTPClient *http.Client
+}
+
+// Ollama embeds texts with a local Ollama server, keeping documents on the
+// machine.
+type Ollama struct {
+	url       string
+	model     string
+	batchSize int
+	http      httpBackend
+	learned   atomic.Int64
+}
+
+// NewOllama creates an Ollama embeddings provider.
+func NewOllama(cfg OllamaConfig) *Ollama {
+	base := strings.TrimRight(cfg.BaseURL, "/")
+	if base == "" {
+		base = DefaultOllamaBaseURL
+	}
+	model := cfg.Model
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultOllamaBatchSize
+	}
+	return &Ollama{
+		url:       base + ollamaEmbedPath,
+		model:     model,
+		batchSize: batchSize,
+		http:      newHTTPBackend("ollama", cfg.HTTPClient, cfg.Timeout, nil, cfg.MaxAttempts),
+	}
+}
+
+// Name returns "ollama".
+func (p *Ollama) Name() string {
+	return "ollama"
+}
+
+// Dimensions returns the vector length seen in the first response, or 0
+// before it.
+func (p *Ollama) Dimensions() int {
+	return int(p.learned.Load())
+}
+
+// Embed returns one vector per text.
+func (p *Ollama) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedBatches(ctx, texts, p.batchSize, p.embedBatch)
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func (p *Ollama) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp ollamaEmbedResponse
+	if err := p.http.post(ctx, p.url, ollamaEmbedRequest{Model: p.model, Input: texts}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) > 0 {
+		p.learned.CompareAndSwap(0, int64(len(resp.Embeddings[0])))
+	}
+	return resp.Embeddings, nil
+}
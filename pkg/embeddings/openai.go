@@ -0,0 +1,166 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultOpenAIBaseURL is the OpenAI API root.
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+	// DefaultOpenAIModel is used when OpenAIConfig.Model is empty.
+	DefaultOpenAIModel = "text-embedding-3-small"
+
+	defaultOpenAIBatchSize = 256
+	openaiEmbeddingsPath   = "/embeddings"
+)
+
+// openaiModelDimensions are the native vector lengths of OpenAI's models.
+var openaiModelDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+// OpenAIConfig configures an OpenAI-compatible embeddings provider.
+type OpenAIConfig struct {
+	// BaseURL is the API root (default DefaultOpenAIBaseURL). A URL that
+	// already ends in /embeddings is used as is.
+	BaseURL string
+
+	APIKey string
+
+	// Model defaults to DefaultOpenAIModel.
+	Model string
+
+	// Dimensions asks models that support it (text-embedding-3-*) for
+	// shorter vectors. Zero uses the model's native length.
+	Dimensions int
+
+	// BatchSize caps the texts sent per request (default 256).
+	BatchSize int
+
+	// Timeout bounds each request (default 60s); MaxAttempts is the number
+	// of tries for rate limits and server errors (default 3).
+	Timeout     time.Duration
+	MaxAttempts int
+
+	// HTTPClient overrides the client; nil uses the transport shared with
+	// the LLM providers.
+	HTTPClient *http.Client
+
+	// Headers are set on every request, e.g. an Azure api-key header.
+	Headers map[string]string
+}
+
+// OpenAI embeds texts with the OpenAI embeddings API.
+type OpenAI struct {
+	url        string
+	model      string
+	dimensions int
+	batchSize  int
+	http       httpBackend
+
+	// learned is the vector length seen in the first response when it is
+	// not known up front.
+	learned atomic.Int64
+}
+
+// NewOpenAI creates an OpenAI-compatible embeddings provider.
+func NewOpenAI(cfg OpenAIConfig) (*OpenAI, error) {
+	if strings.TrimSpace(cfg.APIKey) == "" && cfg.Headers == nil {
+		return nil, errors.New("OpenAI embeddings API key is empty")
+	}
+	if cfg.Dimensions < 0 {
+		return nil, fmt.Errorf("invalid dimensions %d", cfg.Dimensions)
+	}
+	base := strings.TrimRight(cfg.BaseURL, "/")
+	if base == "" {
+		base = DefaultOpenAIBaseURL
+	}
+	if !strings.HasSuffix(base, openaiEmbeddingsPath) {
+		base += openaiEmbeddingsPath
+	}
+	model := cfg.Model
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultOpenAIBatchSize
+	}
+	headers := make(map[string]string, len(cfg.Headers)+1)
+	if cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + cfg.APIKey
+	}
+	for name, value := range cfg.Headers {
+		headers[name] = value
+	}
+	return &OpenAI{
+		url:        base,
+		model:      model,
+		dimensions: cfg.Dimensions,
+		batchSize:  batchSize,
+		http:       newHTTPBackend("openai", cfg.HTTPClient, cfg.Timeout, headers, cfg.MaxAttempts),
+	}, nil
+}
+
+// Name returns "openai".
+func (p *OpenAI) Name() string {
+	return "openai"
+}
+
+// Dimensions returns the configured length, the model's native length, or
+// the length seen in the first response.
+func (p *OpenAI) Dimensions() int {
+	if p.dimensions > 0 {
+		return p.dimensions
+	}
+	if n, ok := openaiModelDimensions[p.model]; ok {
+		return n
+	}
+	return int(p.learned.Load())
+}
+
+// Embed returns one vector per text.
+func (p *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedBatches(ctx, texts, p.batchSize, p.embedBatch)
+}
+
+type openaiEmbeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type openaiEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (p *OpenAI) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp openaiEmbeddingResponse
+	req := openaiEmbeddingRequest{Model: p.model, Input: texts, Dimensions: p.dimensions}
+	if err := p.http.post(ctx, p.url, req, &resp); err != nil {
+		return nil, err
+	}
+	// Entries carry their input index; do not rely on response order.
+	sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].Index < resp.Data[j].Index })
+	vectors := make([][]float32, len(resp.Data))
+	for i, d := range resp.Data {
+		vectors[i] = d.Embedding
+	}
+	if len(vectors) > 0 {
+		p.learned.CompareAndSwap(0, int64(len(vectors[0])))
+	}
+	return vectors, nil
+}
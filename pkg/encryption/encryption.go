@@ -0,0 +1,229 @@
+// Package encryption seals persisted conversation artifacts, such as
+// spilled messages, with envelope encryption.
+//
+// Each Seal call encrypts the payload with a fresh AES-256-GCM data key.
+// The data key is wrapped by a KeyProvider and stored next to the
+// ciphertext together with the wrapping key's ID, so keys can be rotated:
+// Open finds the wrapping key by its ID, and Rewrap moves an envelope to
+// the current key. Keyring is the local KeyProvider;
+// implement KeyProvider to wrap data keys with a KMS instead.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// KeySize is the size of AES-256 keys, for both data keys and Keyring
+	// keys.
+	KeySize = 32
+
+	envelopeVersion = 1
+	nonceSize       = 12
+)
+
+// magic starts every envelope, so sealed data can be told from plaintext.
+var magic = []byte("ACE\x00")
+
+var (
+	// ErrNotEncrypted is returned by Open and Rewrap for data that is not
+	// an envelope.
+	ErrNotEncrypted = errors.New("data is not encrypted")
+
+	// ErrUnknownKey is returned when a wrapping key ID is not known to the
+	// KeyProvider.
+	ErrUnknownKey = errors.New("unknown encryption key")
+
+	// ErrDecrypt is returned when authentication fails: the wrong key, or
+	// corrupted or tampered data.
+	ErrDecrypt = errors.New("decryption failed")
+)
+
+// KeyProvider wraps and unwraps data keys. Implement it to wrap keys with
+// a KMS; Keyring wraps them locally.
+type KeyProvider interface {
+	// WrapKey encrypts dataKey with the current key-encryption key and
+	// returns that key's ID with the wrapped key.
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+
+	// UnwrapKey decrypts a data key wrapped by the key with keyID.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Encryptor seals and opens envelopes with data keys wrapped by a
+// KeyProvider. It is safe for concurrent use if its KeyProvider is.
+type Encryptor struct {
+	keys KeyProvider
+}
+
+// New creates an Encryptor that wraps data keys with keys.
+func New(keys KeyProvider) *Encryptor {
+	return &Encryptor{keys: keys}
+}
+
+// Seal encrypts plaintext under a new data key and returns the envelope.
+func (e *Encryptor) Seal(ctx context.Context, plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	return e.seal(ctx, dataKey, plaintext)
+}
+
+// Open decrypts an envelope created by Seal.
+func (e *Encryptor) Open(ctx context.Context, data []byte) ([]byte, error) {
+	_, plaintext, err := e.open(ctx, data)
+	return plaintext, err
+}
+
+// Rewrap re-wraps an envelope's data key with the KeyProvider's current
+// key, e.g. after Keyring.Rotate, so the old key can be retired.
+func (e *Encryptor) Rewrap(ctx context.Context, data []byte) ([]byte, error) {
+	// The header is authenticated with the payload, so the payload is
+	// sealed again under the new header, keeping its data key.
+	dataKey, plaintext, err := e.open(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return e.seal(ctx, dataKey, plaintext)
+}
+
+func (e *Encryptor) seal(ctx context.Context, dataKey, plaintext []byte) ([]byte, error) {
+	keyID, wrapped, err := e.keys.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+	header, err := encodeHeader(keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(header)+nonceSize+len(plaintext)+aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	// The header is authenticated, so a swapped key ID or wrapped key
+	// fails to open.
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// open returns an envelope's data key and plaintext.
+func (e *Encryptor) open(ctx context.Context, data []byte) (dataKey, plaintext []byte, err error) {
+	env, err := parseEnvelope(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	dataKey, err = e.keys.UnwrapKey(ctx, env.keyID, env.wrapped)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err = aead.Open(nil, env.nonce, env.ciphertext, env.header)
+	if err != nil {
+		return nil, nil, ErrDecrypt
+	}
+	return dataKey, plaintext, nil
+}
+
+// IsEncrypted reports whether data starts like an envelope.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// KeyID returns the ID of the key that wrapped an envelope's data key,
+// e.g. to find envelopes that still need Rewrap after a rotation.
+func KeyID(data []byte) (string, error) {
+	env, err := parseEnvelope(data)
+	if err != nil {
+		return "", err
+	}
+	return env.keyID, nil
+}
+
+// Envelope layout:
+//
+//	magic | version (1) | key ID length (1) | key ID |
+//	wrapped key length (2, big endian) | wrapped key | nonce (12) | ciphertext
+//
+// Everything before the nonce is the header.
+type envelope struct {
+	header     []byte
+	keyID      string
+	wrapped    []byte
+	nonce      []byte
+	ciphertext []byte
+}
+
+func encodeHeader(keyID string, wrapped []byte) ([]byte, error) {
+	if keyID == "" || len(keyID) > 255 {
+		return nil, fmt.Errorf("key ID must be 1 to 255 bytes, got %d", len(keyID))
+	}
+	if len(wrapped) > 0xffff {
+		return nil, fmt.Errorf("wrapped key too large: %d bytes", len(wrapped))
+	}
+	header := make([]byte, 0, len(magic)+4+len(keyID)+len(wrapped))
+	header = append(header, magic...)
+	header = append(header, envelopeVersion, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	return append(header, wrapped...), nil
+}
+
+func parseEnvelope(data []byte) (envelope, error) {
+	if !IsEncrypted(data) {
+		return envelope{}, ErrNotEncrypted
+	}
+	rest := data[len(magic):]
+	if len(rest) < 2 {
+		return envelope{}, ErrDecrypt
+	}
+	if rest[0] != envelopeVersion {
+		return envelope{}, fmt.Errorf("unsupported envelope version %d", rest[0])
+	}
+	idLen := int(rest[1])
+	rest = rest[2:]
+	if len(rest) < idLen+2 {
+		return envelope{}, ErrDecrypt
+	}
+	keyID := string(rest[:idLen])
+	wrappedLen := int(binary.BigEndian.Uint16(rest[idLen:]))
+	rest = rest[idLen+2:]
+	if len(rest) < wrappedLen+nonceSize {
+		return envelope{}, ErrDecrypt
+	}
+	headerLen := len(data) - len(rest) + wrappedLen
+	return envelope{
+		header:     data[:headerLen],
+		keyID:      keyID,
+		wrapped:    rest[:wrappedLen],
+		nonce:      rest[wrappedLen : wrappedLen+nonceSize],
+		ciphertext: rest[wrappedLen+nonceSize:],
+	}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
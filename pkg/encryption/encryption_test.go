@@ -0,0 +1,134 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func newTestKeyring(t *testing.T, id string) *Keyring {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKeyring(id, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.Background()
+	e := New(newTestKeyring(t, "k1"))
+	plaintext := []byte(`{"role":"user","content":"API_KEY=sk-123"}`)
+
+	sealed, err := e.Seal(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if !IsEncrypted(sealed) || bytes.Contains(sealed, []byte("sk-123")) {
+		t.Fatal("expected sealed data to be an envelope without the plaintext")
+	}
+	if id, err := KeyID(sealed); err != nil || id != "k1" {
+		t.Fatalf("KeyID() = %q, %v", id, err)
+	}
+	opened, err := e.Open(ctx, sealed)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open() = %q, %v", opened, err)
+	}
+
+	again, _ := e.Seal(ctx, plaintext)
+	if bytes.Equal(again, sealed) {
+		t.Fatal("expected a fresh data key and nonce per Seal")
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	ctx := context.Background()
+	e := New(newTestKeyring(t, "k1"))
+	sealed, err := e.Seal(ctx, []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, i := range map[string]int{"wrapped key": len(magic) + 8, "ciphertext": len(sealed) - 1} {
+		tampered := append([]byte(nil), sealed...)
+		tampered[i] ^= 0xff
+		if _, err := e.Open(ctx, tampered); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("%s: expected ErrDecrypt, got %v", name, err)
+		}
+	}
+	if _, err := e.Open(ctx, sealed[:len(magic)+3]); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("truncated: expected ErrDecrypt, got %v", err)
+	}
+	if _, err := e.Open(ctx, []byte("plain text")); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("plaintext: expected ErrNotEncrypted, got %v", err)
+	}
+	if _, err := New(newTestKeyring(t, "other")).Open(ctx, sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("other keyring: expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	keys := newTestKeyring(t, "2026-04")
+	e := New(keys)
+	old, err := e.Seal(ctx, []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, _ := GenerateKey()
+	if err := keys.Rotate("2026-10", next); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if keys.Primary() != "2026-10" {
+		t.Fatalf("Primary() = %q", keys.Primary())
+	}
+	if opened, err := e.Open(ctx, old); err != nil || string(opened) != "payload" {
+		t.Fatalf("expected the old envelope to open after rotation: %q, %v", opened, err)
+	}
+
+	rewrapped, err := e.Rewrap(ctx, old)
+	if err != nil {
+		t.Fatalf("Rewrap() error = %v", err)
+	}
+	if id, _ := KeyID(rewrapped); id != "2026-10" {
+		t.Fatalf("rewrapped key ID = %q", id)
+	}
+	if err := keys.Remove("2026-04"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if opened, err := e.Open(ctx, rewrapped); err != nil || string(opened) != "payload" {
+		t.Fatalf("expected the rewrapped envelope to open: %q, %v", opened, err)
+	}
+	if _, err := e.Open(ctx, old); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected the retired key to be gone, got %v", err)
+	}
+	if err := keys.Remove("2026-10"); err == nil {
+		t.Fatal("expected removing the primary key to fail")
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	a, _ := GenerateKey()
+	b, _ := GenerateKey()
+	spec := "new:" + base64.StdEncoding.EncodeToString(a) + ", old:" + base64.StdEncoding.EncodeToString(b)
+	keys, err := ParseKeyring(spec)
+	if err != nil {
+		t.Fatalf("ParseKeyring() error = %v", err)
+	}
+	if keys.Primary() != "new" || len(keys.IDs()) != 2 {
+		t.Fatalf("unexpected keyring: primary=%q ids=%v", keys.Primary(), keys.IDs())
+	}
+
+	for _, bad := range []string{"", "nokey", "k:not-base64!", "k:" + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParseKeyring(bad); err == nil {
+			t.Errorf("ParseKeyring(%q): expected an error", bad)
+		}
+	}
+}
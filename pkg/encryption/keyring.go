@@ -0,0 +1,175 @@
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Keyring is a KeyProvider holding AES-256 key-encryption keys in memory.
+// New data keys are wrapped with the primary key; any key in the ring
+// unwraps. To rotate, Rotate in a new primary, Rewrap stored envelopes,
+// then Remove the old key.
+type Keyring struct {
+	mu      sync.RWMutex
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring whose primary key is key, identified by id.
+func NewKeyring(id string, key []byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+	if err := k.Rotate(id, key); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// ParseKeyring parses comma-separated id:base64key pairs, as read from an
+// environment variable. The first key is the primary; the others only
+// unwrap existing envelopes.
+//
+//	2026-10:q83vEjRWeJq8..., 2026-04:3q2+7wABAgME...
+func ParseKeyring(spec string) (*Keyring, error) {
+	var k *Keyring
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("key %q: expected id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		if k == nil {
+			if k, err = NewKeyring(strings.TrimSpace(id), key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := k.Add(strings.TrimSpace(id), key); err != nil {
+			return nil, err
+		}
+	}
+	if k == nil {
+		return nil, fmt.Errorf("no keys in keyring")
+	}
+	return k, nil
+}
+
+// GenerateKey returns a random key of KeySize bytes.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Add adds a key that unwraps data keys but does not wrap new ones.
+func (k *Keyring) Add(id string, key []byte) error {
+	aead, err := newKeyAEAD(id, key)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("key %q already exists", id)
+	}
+	k.keys[id] = aead
+	return nil
+}
+
+// Rotate adds key and makes it the primary. Envelopes wrapped by earlier
+// keys still open as long as those keys stay in the ring.
+func (k *Keyring) Rotate(id string, key []byte) error {
+	if err := k.Add(id, key); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	k.primary = id
+	k.mu.Unlock()
+	return nil
+}
+
+// Remove drops a retired key. The primary key cannot be removed.
+func (k *Keyring) Remove(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if id == k.primary {
+		return fmt.Errorf("cannot remove primary key %q", id)
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// Primary returns the ID of the key that wraps new data keys.
+func (k *Keyring) Primary() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+// IDs returns the IDs of all keys in the ring, sorted.
+func (k *Keyring) IDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// WrapKey wraps dataKey with the primary key.
+func (k *Keyring) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	k.mu.RLock()
+	id, aead := k.primary, k.keys[k.primary]
+	k.mu.RUnlock()
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return id, aead.Seal(nonce, nonce, dataKey, []byte(id)), nil
+}
+
+// UnwrapKey unwraps a data key with the key identified by keyID.
+func (k *Keyring) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	k.mu.RLock()
+	aead, ok := k.keys[keyID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	dataKey, err := aead.Open(nil, nonce, ciphertext, []byte(keyID))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return dataKey, nil
+}
+
+func newKeyAEAD(id string, key []byte) (cipher.AEAD, error) {
+	if id == "" || len(id) > 255 || strings.ContainsAny(id, ",:") {
+		return nil, fmt.Errorf("invalid key ID %q", id)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+	return aead, nil
+}
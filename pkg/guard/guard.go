@@ -0,0 +1,160 @@
+// Package guard moderates model output. An OutputGuard inspects every
+// assistant message before it is committed to the conversation and before
+// any of its tool calls run, and can allow, redact, rewrite, or block it.
+package guard
+
+import (
+	"context"
+	"fmt"
+)
+
+// Action is what a guard decided to do with an assistant message.
+type Action string
+
+const (
+	// ActionAllow keeps the message unchanged.
+	ActionAllow Action = "allow"
+	// ActionRedact replaces the message with Verdict.Output, which masks
+	// parts of the original.
+	ActionRedact Action = "redact"
+	// ActionRewrite replaces the message with Verdict.Output.
+	ActionRewrite Action = "rewrite"
+	// ActionBlock discards the message and ends the run with a *BlockedError.
+	ActionBlock Action = "block"
+)
+
+// ToolCall is a tool invocation requested by the model.
+type ToolCall struct {
+	ID    string
+	Name  string
+	Input map[string]any
+}
+
+// Output is the part of an assistant message a guard inspects.
+type Output struct {
+	// Iteration is the loop iteration that produced the message.
+	Iteration int
+
+	// Text is the message text; multiple text blocks are joined with "\n".
+	Text string
+
+	// ToolCalls are the tool calls the model requested, in order.
+	ToolCalls []ToolCall
+}
+
+// Verdict is a guard's decision about an Output.
+type Verdict struct {
+	Action Action
+
+	// Output replaces the message for ActionRedact and ActionRewrite.
+	// Tool calls are matched by ID; calls left out are dropped and are
+	// not executed.
+	Output Output
+
+	// Reason explains the decision, e.g. the rule that matched.
+	Reason string
+
+	// Guard names the guard that made the decision. Chain fills it in
+	// from Named guards when empty.
+	Guard string
+}
+
+// Allow returns a verdict that keeps the message unchanged.
+func Allow() Verdict {
+	return Verdict{Action: ActionAllow}
+}
+
+// Block returns a verdict that discards the message.
+func Block(reason string) Verdict {
+	return Verdict{Action: ActionBlock, Reason: reason}
+}
+
+// OutputGuard moderates assistant messages. Implementations must be safe
+// for concurrent use; one guard is shared by all runs of an agent.
+//
+// A returned error fails the run: output that could not be checked is
+// never committed.
+type OutputGuard interface {
+	Check(ctx context.Context, out Output) (Verdict, error)
+}
+
+// Func adapts a function, e.g. a call to an external moderation API, to
+// OutputGuard.
+type Func func(ctx context.Context, out Output) (Verdict, error)
+
+// Check calls f.
+func (f Func) Check(ctx context.Context, out Output) (Verdict, error) {
+	return f(ctx, out)
+}
+
+// Named wraps a guard so verdicts and errors name it.
+func Named(name string, g OutputGuard) OutputGuard {
+	return named{name: name, guard: g}
+}
+
+type named struct {
+	name  string
+	guard OutputGuard
+}
+
+func (n named) Check(ctx context.Context, out Output) (Verdict, error) {
+	verdict, err := n.guard.Check(ctx, out)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("%s: %w", n.name, err)
+	}
+	if verdict.Guard == "" {
+		verdict.Guard = n.name
+	}
+	return verdict, nil
+}
+
+// Chain runs guards in order. Each guard sees the output as rewritten by
+// the guards before it; the first block or error stops the chain. The
+// result is ActionAllow when no guard changed anything, the last
+// redact/rewrite action otherwise. Nil guards are skipped.
+func Chain(guards ...OutputGuard) OutputGuard {
+	var chain chained
+	for _, g := range guards {
+		if g != nil {
+			chain = append(chain, g)
+		}
+	}
+	return chain
+}
+
+type chained []OutputGuard
+
+func (c chained) Check(ctx context.Context, out Output) (Verdict, error) {
+	result := Verdict{Action: ActionAllow, Output: out}
+	for _, g := range c {
+		verdict, err := g.Check(ctx, result.Output)
+		if err != nil {
+			return Verdict{}, err
+		}
+		switch verdict.Action {
+		case ActionBlock:
+			return verdict, nil
+		case ActionRedact, ActionRewrite:
+			verdict.Output.Iteration = out.Iteration
+			result = verdict
+		}
+	}
+	return result, nil
+}
+
+// BlockedError is returned by a run whose output a guard blocked.
+type BlockedError struct {
+	Guard  string
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	msg := "output blocked"
+	if e.Guard != "" {
+		msg += " by " + e.Guard
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
@@ -0,0 +1,154 @@
+package guard
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+
+	"github.com/MimeLyc/agent-core-go/pkg/redact"
+)
+
+// Rule is a named output pattern.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+
+	// Action is ActionRedact (the default) or ActionBlock. Redact rules
+	// mask each match as [REDACTED:<name>], or only its first capture
+	// group if the pattern has one; block rules block the whole message.
+	Action Action
+
+	// Verify, if set, reports whether a matched value (the first capture
+	// group that matched, or the whole match) counts as a match.
+	Verify func(value string) bool
+}
+
+// RegexConfig configures a Regex guard.
+type RegexConfig struct {
+	// Rules are additional patterns applied after the defaults.
+	Rules []Rule
+
+	// Allowlist contains literal values redact rules never mask.
+	Allowlist []string
+
+	// DisableDefaults skips DefaultRules and uses only Rules.
+	DisableDefaults bool
+}
+
+// DefaultRules returns the built-in output rules: they redact the secret
+// patterns of redact.DefaultRules so the model cannot echo credentials
+// into its replies or tool calls.
+func DefaultRules() []Rule {
+	var rules []Rule
+	for _, rule := range redact.DefaultRules() {
+		rules = append(rules, Rule{Name: rule.Name, Pattern: rule.Pattern, Action: ActionRedact, Verify: rule.Verify})
+	}
+	return rules
+}
+
+// Regex is an OutputGuard that matches rules against the message text and
+// every string in tool call inputs.
+type Regex struct {
+	block    []Rule
+	redactor *redact.Redactor
+}
+
+// NewRegex creates a Regex guard from cfg.
+func NewRegex(cfg RegexConfig) *Regex {
+	var rules []Rule
+	if !cfg.DisableDefaults {
+		rules = append(rules, DefaultRules()...)
+	}
+	rules = append(rules, cfg.Rules...)
+
+	g := &Regex{}
+	var redactRules []redact.Rule
+	for _, rule := range rules {
+		if rule.Pattern == nil {
+			continue
+		}
+		if rule.Action == ActionBlock {
+			g.block = append(g.block, rule)
+		} else {
+			redactRules = append(redactRules, redact.Rule{Name: rule.Name, Pattern: rule.Pattern, Verify: rule.Verify})
+		}
+	}
+	if len(redactRules) > 0 {
+		g.redactor = redact.New(redact.Config{Rules: redactRules, Allowlist: cfg.Allowlist, DisableDefaults: true})
+	}
+	return g
+}
+
+// Check blocks out if a block rule matches, and otherwise masks what the
+// redact rules match.
+func (g *Regex) Check(_ context.Context, out Output) (Verdict, error) {
+	for _, rule := range g.block {
+		if rule.matches(out.Text) || inputMatches(rule, out.ToolCalls) {
+			return Verdict{Action: ActionBlock, Reason: "matched " + rule.Name}, nil
+		}
+	}
+	if g.redactor == nil {
+		return Allow(), nil
+	}
+
+	redacted := Output{Iteration: out.Iteration, Text: g.redactor.Redact(out.Text)}
+	changed := redacted.Text != out.Text
+	for _, call := range out.ToolCalls {
+		input := g.redactor.RedactMap(call.Input)
+		changed = changed || !reflect.DeepEqual(input, call.Input)
+		redacted.ToolCalls = append(redacted.ToolCalls, ToolCall{ID: call.ID, Name: call.Name, Input: input})
+	}
+	if !changed {
+		return Allow(), nil
+	}
+	return Verdict{Action: ActionRedact, Output: redacted, Reason: "redacted rule matches"}, nil
+}
+
+// matches reports whether the rule matches s.
+func (r Rule) matches(s string) bool {
+	if r.Verify == nil {
+		return r.Pattern.MatchString(s)
+	}
+	for _, m := range r.Pattern.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[0], m[1]
+		for g := 2; g+1 < len(m); g += 2 {
+			if m[g] >= 0 {
+				start, end = m[g], m[g+1]
+				break
+			}
+		}
+		if r.Verify(s[start:end]) {
+			return true
+		}
+	}
+	return false
+}
+
+func inputMatches(rule Rule, calls []ToolCall) bool {
+	for _, call := range calls {
+		if valueMatches(rule, call.Input) {
+			return true
+		}
+	}
+	return false
+}
+
+func valueMatches(rule Rule, v any) bool {
+	switch val := v.(type) {
+	case string:
+		return rule.matches(val)
+	case map[string]any:
+		for _, item := range val {
+			if valueMatches(rule, item) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range val {
+			if valueMatches(rule, item) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package instructions
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// frontMatter holds the per-file settings of an instruction file.
+type frontMatter struct {
+	// Scope lists repo-relative working directory globs the file applies
+	// to. "**" matches any number of path segments. Empty applies everywhere.
+	Scope []string
+
+	Priority int
+	Enabled  bool
+	Merge    MergeStrategy
+}
+
+// parseFrontMatter splits an optional leading "---" block from the file
+// body. Only flat "key: value" pairs and "- item" lists are supported.
+func parseFrontMatter(data []byte) (meta frontMatter, body string) {
+	meta.Enabled = true
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	body = text
+
+	if !strings.HasPrefix(text, "---\n") {
+		return meta, body
+	}
+	rest := text[len("---\n"):]
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		return meta, body
+	}
+
+	front := rest[:end]
+	body = rest[end+len("\n---\n"):]
+	currentListKey := ""
+	for _, raw := range strings.Split(front, "\n") {
+		line := strings.TrimSpace(stripComment(raw))
+		if line == "" {
+			continue
+		}
+
+		if currentListKey != "" {
+			if strings.HasPrefix(line, "- ") {
+				setFrontMatterValue(&meta, currentListKey, strings.TrimPrefix(line, "- "))
+				continue
+			}
+			currentListKey = ""
+		}
+
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+		if val == "" {
+			currentListKey = key
+			continue
+		}
+		setFrontMatterValue(&meta, key, val)
+	}
+	return meta, body
+}
+
+func setFrontMatterValue(meta *frontMatter, key, raw string) {
+	clean := strings.Trim(strings.TrimSpace(raw), `"'`)
+	switch key {
+	case "scope":
+		for _, part := range strings.Split(strings.Trim(strings.TrimSpace(raw), "[]"), ",") {
+			if part = strings.Trim(strings.TrimSpace(part), `"'`); part != "" {
+				meta.Scope = append(meta.Scope, part)
+			}
+		}
+	case "priority":
+		if n, err := strconv.Atoi(clean); err == nil {
+			meta.Priority = n
+		}
+	case "enabled":
+		switch strings.ToLower(clean) {
+		case "true", "yes", "1":
+			meta.Enabled = true
+		case "false", "no", "0":
+			meta.Enabled = false
+		}
+	case "merge":
+		switch MergeStrategy(strings.ToLower(clean)) {
+		case MergeAppend:
+			meta.Merge = MergeAppend
+		case MergeOverride, "replace":
+			meta.Merge = MergeOverride
+		}
+	}
+}
+
+// stripComment drops a trailing " # comment" outside quotes.
+func stripComment(line string) string {
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return ""
+	}
+	if i := strings.Index(line, " #"); i >= 0 && !strings.ContainsAny(line[:i], `"'`) {
+		return line[:i]
+	}
+	return line
+}
+
+// inScope reports whether the file applies to relWorkDir, the working
+// directory relative to the repository root.
+func (m frontMatter) inScope(relWorkDir string) bool {
+	if len(m.Scope) == 0 {
+		return true
+	}
+	if relWorkDir == "." {
+		relWorkDir = ""
+	}
+	for _, pattern := range m.Scope {
+		if matchGlob(strings.Trim(pattern, "/"), relWorkDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches slash-separated paths, where "**" matches zero or more
+// segments and other segments use path.Match syntax.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(splitSegments(pattern), splitSegments(name))
+}
+
+func splitSegments(p string) []string {
+	if p == "" || p == "." {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -1,22 +1,49 @@
 package instructions
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	// DefaultMaxBytes caps loaded instruction size to avoid prompt bloat.
 	DefaultMaxBytes = 32 * 1024
+
+	// DefaultDirTimeout bounds reading the candidate files of one directory
+	// layer, and the repository root lookup.
+	DefaultDirTimeout = 5 * time.Second
+
+	// DefaultConcurrency is how many directory layers are read at once.
+	DefaultConcurrency = 4
 )
 
+// readFile is replaced in tests to simulate an unresponsive mount.
+var readFile = os.ReadFile
+
 var defaultCandidateFiles = []string{
 	"AGENT.md",
 	"AGENTS.md",
 }
 
+// MergeStrategy decides how a nested instruction file combines with the
+// files loaded from its parent directories.
+type MergeStrategy string
+
+const (
+	// MergeAppend keeps parent instructions and appends the nested file.
+	MergeAppend MergeStrategy = "append"
+
+	// MergeOverride drops parent instructions so the nested file replaces them.
+	MergeOverride MergeStrategy = "override"
+)
+
 // LoadOptions controls repository instruction discovery.
 type LoadOptions struct {
 	// CandidateFiles are checked in order for each directory layer.
@@ -26,6 +53,40 @@ type LoadOptions struct {
 	// MaxBytes limits the total serialized instruction content.
 	// If <= 0, DefaultMaxBytes is used.
 	MaxBytes int
+
+	// Merge is the default strategy for files without a "merge" front-matter
+	// key. Empty means MergeAppend.
+	Merge MergeStrategy
+
+	// DirTimeout bounds reading one directory layer. A layer that does not
+	// finish in time is skipped. If 0, DefaultDirTimeout is used; a negative
+	// value disables the timeout.
+	DirTimeout time.Duration
+
+	// Concurrency is how many directory layers are read in parallel.
+	// If <= 0, DefaultConcurrency is used.
+	Concurrency int
+}
+
+func (o LoadOptions) dirTimeout() time.Duration {
+	if o.DirTimeout == 0 {
+		return DefaultDirTimeout
+	}
+	return o.DirTimeout
+}
+
+func (o LoadOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return o.Concurrency
+}
+
+// SkippedDir is a directory whose instructions were not loaded because it
+// did not respond in time.
+type SkippedDir struct {
+	Path   string
+	Reason string
 }
 
 // LoadResult is the output of instruction discovery.
@@ -38,11 +99,39 @@ type LoadResult struct {
 
 	// Truncated indicates the content hit MaxBytes.
 	Truncated bool
+
+	// Skipped lists directories that timed out, in root-to-leaf order.
+	Skipped []SkippedDir
+
+	// Watched lists every candidate path checked, whether or not it
+	// exists. The result changes only when one of them does.
+	Watched []string
 }
 
 // Load discovers and merges repository instructions from root to workDir.
 // For each directory layer, only the first non-empty candidate file is loaded.
+//
+// Files may start with a front-matter block:
+//
+//	---
+//	scope: ["services/**"]  # repo-relative working directory globs
+//	priority: 10            # higher priority sections are placed later
+//	enabled: false          # skip the file
+//	merge: override         # drop instructions from parent directories
+//	---
+//
+// Disabled and out-of-scope files are treated as absent, so the next
+// candidate in the same directory is considered.
 func Load(workDir string, opts LoadOptions) LoadResult {
+	return LoadContext(context.Background(), workDir, opts)
+}
+
+// LoadContext is Load with a context. Directory layers are read in
+// parallel, each under opts.DirTimeout; layers that time out, or are still
+// being read when ctx is done, are skipped and reported in
+// LoadResult.Skipped. If the repository root lookup times out, only
+// workDir itself is read.
+func LoadContext(ctx context.Context, workDir string, opts LoadOptions) LoadResult {
 	if strings.TrimSpace(workDir) == "" {
 		return LoadResult{}
 	}
@@ -53,8 +142,14 @@ func Load(workDir string, opts LoadOptions) LoadResult {
 	}
 	workDir = filepath.Clean(workDir)
 
-	root := findRepoRoot(workDir)
+	var skipped []SkippedDir
+	root, err := withTimeout(ctx, opts.dirTimeout(), func(context.Context) string { return findRepoRoot(workDir) })
+	if err != nil {
+		root = workDir
+		skipped = append(skipped, SkippedDir{Path: workDir, Reason: "repository root lookup " + skipReason(ctx, err, opts.dirTimeout())})
+	}
 	dirs := dirsFromRoot(root, workDir)
+	relWorkDir := relToRoot(root, workDir)
 
 	candidates := opts.CandidateFiles
 	if len(candidates) == 0 {
@@ -66,47 +161,79 @@ func Load(workDir string, opts LoadOptions) LoadResult {
 		maxBytes = DefaultMaxBytes
 	}
 
-	remaining := maxBytes
-	parts := make([]string, 0, len(dirs))
-	sources := make([]string, 0, len(dirs))
-	seenResolved := map[string]struct{}{}
-	truncated := false
-
+	watched := make([]string, 0, len(dirs)*len(candidates))
 	for _, dir := range dirs {
 		for _, filename := range candidates {
-			path := filepath.Join(dir, filename)
-			data, err := os.ReadFile(path)
-			if err != nil {
-				continue
-			}
+			watched = append(watched, filepath.Join(dir, filename))
+		}
+	}
 
-			content := strings.TrimSpace(string(data))
-			if content == "" {
-				continue
+	layers := make([][]layerCandidate, len(dirs))
+	errs := make([]error, len(dirs))
+	sem := make(chan struct{}, opts.concurrency())
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
 			}
+			defer func() { <-sem }()
+			layers[i], errs[i] = withTimeout(ctx, opts.dirTimeout(), func(ctx context.Context) []layerCandidate {
+				return readLayer(ctx, dir, candidates, relWorkDir)
+			})
+		}()
+	}
+	wg.Wait()
 
-			resolved := filepath.Clean(path)
-			if p, err := filepath.EvalSymlinks(path); err == nil {
-				resolved = filepath.Clean(p)
-			}
-			if _, ok := seenResolved[resolved]; ok {
+	var files []instructionFile
+	seenResolved := map[string]struct{}{}
+
+	for i, layer := range layers {
+		if errs[i] != nil {
+			skipped = append(skipped, SkippedDir{Path: dirs[i], Reason: skipReason(ctx, errs[i], opts.dirTimeout())})
+			continue
+		}
+		for _, c := range layer {
+			if _, ok := seenResolved[c.resolved]; ok {
 				continue
 			}
+			seenResolved[c.resolved] = struct{}{}
 
-			relPath := relToRoot(root, path)
-			section := fmt.Sprintf("## %s\n%s", relPath, content)
-
-			appended, wasTruncated := appendWithinLimit(&parts, section, &remaining)
-			if wasTruncated {
-				truncated = true
+			merge := c.meta.Merge
+			if merge == "" {
+				merge = opts.Merge
 			}
-			if appended {
-				sources = append(sources, relPath)
-				seenResolved[resolved] = struct{}{}
+			if merge == MergeOverride {
+				files = files[:0]
 			}
+			files = append(files, instructionFile{
+				relPath:  relToRoot(root, c.path),
+				content:  c.content,
+				priority: c.meta.Priority,
+			})
 			break
 		}
-		if truncated || remaining <= 0 {
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].priority < files[j].priority })
+
+	remaining := maxBytes
+	parts := make([]string, 0, len(files))
+	sources := make([]string, 0, len(files))
+	truncated := false
+	for _, f := range files {
+		section := fmt.Sprintf("## %s\n%s", f.relPath, f.content)
+		appended, wasTruncated := appendWithinLimit(&parts, section, &remaining)
+		if appended {
+			sources = append(sources, f.relPath)
+		}
+		if wasTruncated {
+			truncated = true
 			break
 		}
 	}
@@ -115,7 +242,84 @@ func Load(workDir string, opts LoadOptions) LoadResult {
 		Content:   strings.Join(parts, "\n\n"),
 		Sources:   sources,
 		Truncated: truncated,
+		Skipped:   skipped,
+		Watched:   watched,
+	}
+}
+
+// layerCandidate is an eligible candidate file in one directory layer.
+type layerCandidate struct {
+	path     string
+	resolved string
+	meta     frontMatter
+	content  string
+}
+
+// readLayer returns the eligible candidate files in dir, in candidate
+// order. All of them are returned so that Load can fall through to the next
+// one when the first was already loaded through a symlink.
+func readLayer(ctx context.Context, dir string, candidates []string, relWorkDir string) []layerCandidate {
+	var out []layerCandidate
+	for _, filename := range candidates {
+		if ctx.Err() != nil {
+			return out
+		}
+		path := filepath.Join(dir, filename)
+		data, err := readFile(path)
+		if err != nil {
+			continue
+		}
+
+		meta, body := parseFrontMatter(data)
+		content := strings.TrimSpace(body)
+		if content == "" || !meta.Enabled || !meta.inScope(relWorkDir) {
+			continue
+		}
+
+		resolved := filepath.Clean(path)
+		if p, err := filepath.EvalSymlinks(path); err == nil {
+			resolved = filepath.Clean(p)
+		}
+		out = append(out, layerCandidate{path: path, resolved: resolved, meta: meta, content: content})
 	}
+	return out
+}
+
+// withTimeout runs fn and stops waiting for it when timeout expires or ctx
+// is done. A call stuck in a system call cannot be interrupted; it finishes
+// in the background and its result is discarded.
+func withTimeout[T any](ctx context.Context, timeout time.Duration, fn func(context.Context) T) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	done := make(chan T, 1)
+	go func() { done <- fn(ctx) }()
+	select {
+	case v := <-done:
+		return v, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func skipReason(ctx context.Context, err error, timeout time.Duration) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		return fmt.Sprintf("timed out after %s", timeout)
+	}
+	return "deadline exceeded"
+}
+
+// instructionFile is a loaded instruction file awaiting merge.
+type instructionFile struct {
+	relPath  string
+	content  string
+	priority int
 }
 
 func appendWithinLimit(parts *[]string, section string, remaining *int) (appended bool, truncated bool) {
@@ -1,10 +1,12 @@
 package instructions
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadPrefersAGENTOverCLAUDEInSameDirectory(t *testing.T) {
@@ -119,3 +121,137 @@ func mustMkdir(t *testing.T, path string) {
 		t.Fatalf("mkdir %s: %v", path, err)
 	}
 }
+
+func TestLoadFrontMatterOverrideReplacesParents(t *testing.T) {
+	repo := t.TempDir()
+	mustMkdir(t, filepath.Join(repo, ".git"))
+	nested := filepath.Join(repo, "services", "api")
+	mustMkdir(t, nested)
+
+	mustWriteFile(t, filepath.Join(repo, "AGENT.md"), "root rules")
+	mustWriteFile(t, filepath.Join(repo, "services", "AGENT.md"), "---\nmerge: override\n---\nservices rules")
+	mustWriteFile(t, filepath.Join(nested, "AGENT.md"), "api rules")
+
+	result := Load(nested, LoadOptions{})
+	wantSources := []string{"services/AGENT.md", "services/api/AGENT.md"}
+	if strings.Join(result.Sources, ",") != strings.Join(wantSources, ",") {
+		t.Fatalf("expected sources %v, got %v", wantSources, result.Sources)
+	}
+	if strings.Contains(result.Content, "root rules") || strings.Contains(result.Content, "merge:") {
+		t.Fatalf("expected root rules and front matter to be dropped, got %q", result.Content)
+	}
+}
+
+func TestLoadMergeOptionOverride(t *testing.T) {
+	repo := t.TempDir()
+	mustMkdir(t, filepath.Join(repo, ".git"))
+	nested := filepath.Join(repo, "sub")
+	mustMkdir(t, nested)
+
+	mustWriteFile(t, filepath.Join(repo, "AGENT.md"), "root rules")
+	mustWriteFile(t, filepath.Join(nested, "AGENT.md"), "sub rules")
+
+	result := Load(nested, LoadOptions{Merge: MergeOverride})
+	if len(result.Sources) != 1 || result.Sources[0] != "sub/AGENT.md" {
+		t.Fatalf("expected only the nearest file, got %v", result.Sources)
+	}
+
+	// A file can opt back into appending.
+	mustWriteFile(t, filepath.Join(nested, "AGENT.md"), "---\nmerge: append\n---\nsub rules")
+	result = Load(nested, LoadOptions{Merge: MergeOverride})
+	if len(result.Sources) != 2 {
+		t.Fatalf("expected append front matter to keep parents, got %v", result.Sources)
+	}
+}
+
+func TestLoadFrontMatterScopeAndEnabled(t *testing.T) {
+	repo := t.TempDir()
+	mustMkdir(t, filepath.Join(repo, ".git"))
+	api := filepath.Join(repo, "services", "api")
+	web := filepath.Join(repo, "web")
+	mustMkdir(t, api)
+	mustMkdir(t, web)
+
+	mustWriteFile(t, filepath.Join(repo, "AGENT.md"), "---\nscope:\n  - services/** # backend only\n---\nbackend rules")
+	mustWriteFile(t, filepath.Join(repo, "AGENTS.md"), "fallback rules")
+	mustWriteFile(t, filepath.Join(api, "AGENT.md"), "---\nenabled: false\n---\ndisabled rules")
+
+	result := Load(api, LoadOptions{})
+	if strings.Join(result.Sources, ",") != "AGENT.md" {
+		t.Fatalf("expected scoped root file only, got %v (%q)", result.Sources, result.Content)
+	}
+	if strings.Contains(result.Content, "disabled rules") {
+		t.Fatalf("expected disabled file to be skipped, got %q", result.Content)
+	}
+
+	result = Load(web, LoadOptions{})
+	if strings.Join(result.Sources, ",") != "AGENTS.md" {
+		t.Fatalf("expected out-of-scope file to fall back to AGENTS.md, got %v", result.Sources)
+	}
+}
+
+func TestLoadFrontMatterPriorityOrdersSections(t *testing.T) {
+	repo := t.TempDir()
+	mustMkdir(t, filepath.Join(repo, ".git"))
+	nested := filepath.Join(repo, "sub")
+	mustMkdir(t, nested)
+
+	mustWriteFile(t, filepath.Join(repo, "AGENT.md"), "---\npriority: 10\n---\nroot rules")
+	mustWriteFile(t, filepath.Join(nested, "AGENT.md"), "sub rules")
+
+	result := Load(nested, LoadOptions{})
+	if strings.Join(result.Sources, ",") != "sub/AGENT.md,AGENT.md" {
+		t.Fatalf("expected higher priority root file last, got %v", result.Sources)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"services/**", "services", true},
+		{"services/**", "services/api/v1", true},
+		{"services/*", "services/api", true},
+		{"services/*", "services/api/v1", false},
+		{"**/api", "services/api", true},
+		{"web", "services", false},
+		{"**", "", true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadContextSkipsUnresponsiveDirectory(t *testing.T) {
+	repo := t.TempDir()
+	mustMkdir(t, filepath.Join(repo, ".git"))
+	nested := filepath.Join(repo, "services")
+	mustMkdir(t, nested)
+	mustWriteFile(t, filepath.Join(repo, "AGENT.md"), "root rules")
+	mustWriteFile(t, filepath.Join(nested, "AGENT.md"), "services rules")
+
+	release, released := make(chan struct{}), make(chan struct{})
+	readFile = func(path string) ([]byte, error) {
+		if path == filepath.Join(nested, "AGENT.md") {
+			<-release
+			defer close(released)
+		}
+		return os.ReadFile(path)
+	}
+	defer func() {
+		close(release)
+		<-released
+		readFile = os.ReadFile
+	}()
+
+	result := LoadContext(context.Background(), nested, LoadOptions{DirTimeout: 50 * time.Millisecond})
+	if len(result.Sources) != 1 || result.Sources[0] != "AGENT.md" {
+		t.Fatalf("expected only the root file, got %v", result.Sources)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != nested || result.Skipped[0].Reason != "timed out after 50ms" {
+		t.Fatalf("unexpected skipped directories: %+v", result.Skipped)
+	}
+}
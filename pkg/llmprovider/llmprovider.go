@@ -0,0 +1,155 @@
+// Package llmprovider lets callers implement their own LLM backends, e.g.
+// an in-house gateway, and hand them to agent.NewAPIAgent or
+// agent.APIConfig.Provider. The interfaces and message types are aliases of
+// the ones the built-in Claude and OpenAI providers use, so values pass
+// between them without conversion. The helpers give custom providers the
+// same HTTP transport, retry reporting, and error classification as the
+// built-in ones.
+package llmprovider
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/internal/pkg/llm"
+)
+
+// Provider is the interface an LLM backend implements.
+type Provider = llm.LLMProvider
+
+// StreamingProvider is an optional extension for providers that stream
+// responses; Stream calls onDelta for each increment and returns the
+// complete response.
+type StreamingProvider = llm.StreamingProvider
+
+// HealthChecker is an optional extension for providers that can cheaply
+// check that their API is reachable and accepts their credentials. Agents
+// built on such a provider pass the check on in their HealthCheck.
+type HealthChecker = llm.HealthChecker
+
+// ErrUnauthorized marks a health check rejected for its credentials.
+var ErrUnauthorized = llm.ErrUnauthorized
+
+// MessageLimiter is an optional extension for providers whose API caps the
+// number of messages per request. The agent loop merges consecutive
+// same-role messages and then drops the oldest ones to stay within it.
+type MessageLimiter = llm.MessageLimiter
+
+// Request and response types.
+type (
+	Request           = llm.AgentRequest
+	Response          = llm.AgentResponse
+	Message           = llm.Message
+	ContentBlock      = llm.ContentBlock
+	ContentBlockDelta = llm.ContentBlockDelta
+	ToolCallPhase     = llm.ToolCallPhase
+	ToolDefinition    = llm.ToolDefinition
+	ToolChoice        = llm.ToolChoice
+	ThinkingConfig    = llm.ThinkingConfig
+	ServerTool        = llm.ServerTool
+	Usage             = llm.Usage
+	Role              = llm.Role
+	ContentType       = llm.ContentType
+	StopReason        = llm.StopReason
+	ResponseRepair    = llm.ResponseRepair
+)
+
+const (
+	RoleUser      = llm.RoleUser
+	RoleAssistant = llm.RoleAssistant
+	RoleSystem    = llm.RoleSystem
+	RoleDeveloper = llm.RoleDeveloper
+
+	ContentTypeText                = llm.ContentTypeText
+	ContentTypeToolUse             = llm.ContentTypeToolUse
+	ContentTypeToolResult          = llm.ContentTypeToolResult
+	ContentTypeThinking            = llm.ContentTypeThinking
+	ContentTypeRedactedThinking    = llm.ContentTypeRedactedThinking
+	ContentTypeServerToolUse       = llm.ContentTypeServerToolUse
+	ContentTypeWebSearchToolResult = llm.ContentTypeWebSearchToolResult
+
+	StopReasonEndTurn   = llm.StopReasonEndTurn
+	StopReasonToolUse   = llm.StopReasonToolUse
+	StopReasonMaxTokens = llm.StopReasonMaxTokens
+	StopReasonStopSeq   = llm.StopReasonStopSeq
+	StopReasonPauseTurn = llm.StopReasonPauseTurn
+
+	ToolChoiceAuto = llm.ToolChoiceAuto
+	ToolChoiceAny  = llm.ToolChoiceAny
+	ToolChoiceNone = llm.ToolChoiceNone
+	ToolChoiceTool = llm.ToolChoiceTool
+
+	ToolCallPhaseStart = llm.ToolCallPhaseStart
+	ToolCallPhaseDelta = llm.ToolCallPhaseDelta
+	ToolCallPhaseReady = llm.ToolCallPhaseReady
+)
+
+// NewTextMessage creates a message with a single text block.
+func NewTextMessage(role Role, text string) Message {
+	return llm.NewTextMessage(role, text)
+}
+
+// NewToolResultMessage creates a user message with a single tool result.
+func NewToolResultMessage(toolUseID, content string, isError bool) Message {
+	return llm.NewToolResultMessage(toolUseID, content, isError)
+}
+
+// Built-in providers.
+type (
+	// Config configures a built-in provider.
+	Config = llm.LLMProviderConfig
+
+	// Type identifies a built-in provider.
+	Type = llm.LLMProviderType
+
+	// HTTPConfig tunes the shared HTTP transport.
+	HTTPConfig = llm.HTTPConfig
+)
+
+const (
+	TypeClaude     = llm.ProviderClaude
+	TypeOpenAI     = llm.ProviderOpenAI
+	TypeOpenRouter = llm.ProviderOpenRouter
+)
+
+// New creates a built-in provider, e.g. to wrap it in a custom one that
+// adds caching or routing.
+func New(cfg Config) (Provider, error) {
+	return llm.NewLLMProvider(cfg)
+}
+
+// NewHTTPClient returns a client on the transport the built-in providers
+// share for cfg, so custom providers reuse their connection pools.
+func NewHTTPClient(cfg HTTPConfig, timeout time.Duration) (*http.Client, error) {
+	return llm.NewHTTPClient(cfg, timeout)
+}
+
+// CustomizeRequest sets headers on req and then calls mutate, if set.
+func CustomizeRequest(req *http.Request, headers map[string]string, mutate func(*http.Request)) {
+	llm.CustomizeRequest(req, headers, mutate)
+}
+
+// RetryNotice describes a failed attempt that is about to be retried.
+type RetryNotice = llm.RetryNotice
+
+// NotifyRetry reports a retry to the execution, which uses it for rate
+// limit notes and retry callbacks. Call it before waiting between attempts.
+func NotifyRetry(ctx context.Context, notice RetryNotice) {
+	llm.NotifyRetry(ctx, notice)
+}
+
+// ErrContextOverflow is matched by errors.Is when a request exceeded the
+// model context window. Providers that return it (for example via
+// CheckContextOverflow) get the agent's compact-and-retry handling.
+var ErrContextOverflow = llm.ErrContextOverflow
+
+// ContextOverflowError is a request rejected as too large.
+type ContextOverflowError = llm.ContextOverflowError
+
+// CheckContextOverflow returns a *ContextOverflowError when an HTTP error
+// response reports a context window overflow, and nil otherwise. errType
+// and code are the provider's error type and code fields, if any.
+func CheckContextOverflow(provider string, status int, errType, code, message string) error {
+	return llm.CheckContextOverflow(provider, status, errType, code, message)
+}
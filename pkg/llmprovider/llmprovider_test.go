@@ -0,0 +1,38 @@
+package llmprovider
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCheckContextOverflow(t *testing.T) {
+	err := CheckContextOverflow("gateway", http.StatusBadRequest, "invalid_request_error", "", "prompt is too long: 210000 tokens")
+	if !errors.Is(err, ErrContextOverflow) {
+		t.Fatalf("CheckContextOverflow() = %v, want a context overflow", err)
+	}
+	var overflow *ContextOverflowError
+	if !errors.As(err, &overflow) || overflow.Provider != "gateway" {
+		t.Fatalf("CheckContextOverflow() = %#v, want a *ContextOverflowError from gateway", err)
+	}
+	if err := CheckContextOverflow("gateway", http.StatusBadRequest, "", "", "missing field"); err != nil {
+		t.Fatalf("CheckContextOverflow() = %v, want nil for other errors", err)
+	}
+}
+
+func TestNewHTTPClientSharesTransport(t *testing.T) {
+	a, err := NewHTTPClient(HTTPConfig{MaxIdleConnsPerHost: 7}, 0)
+	if err != nil {
+		t.Fatalf("NewHTTPClient() error = %v", err)
+	}
+	b, err := NewHTTPClient(HTTPConfig{MaxIdleConnsPerHost: 7}, 0)
+	if err != nil {
+		t.Fatalf("NewHTTPClient() error = %v", err)
+	}
+	if a.Transport != b.Transport {
+		t.Fatal("expected clients with the same config to share a transport")
+	}
+	if _, err := NewHTTPClient(HTTPConfig{ProxyURL: "://bad"}, 0); err == nil {
+		t.Fatal("expected an error for an invalid proxy URL")
+	}
+}
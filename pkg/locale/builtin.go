@@ -0,0 +1,290 @@
+package locale
+
+import "strings"
+
+// builtinCatalogs seeds the catalog. English must define every key.
+var builtinCatalogs = map[string]Catalog{
+	"en": {
+		SkillsHeading: "## Available Skills",
+		SkillsIntro: lines(
+			"Skills use progressive disclosure: surface metadata first, load full content only on invocation.",
+			"When a relevant skill applies, invoke it with `use_skill` before taking action.",
+			"Use `list_skills` to discover available skills; use `read_skill` for direct inspection when needed.",
+			"Skills marked with disable-model-invocation are intentionally excluded from this list.",
+		),
+		SkillsNoDescription: "No description.",
+		SoulHeading:         "## Soul",
+		SoulIntro: lines(
+			"The following defines your character, personality, and behavioral directives.",
+			"Follow these directives throughout the conversation.",
+		),
+		RepoInstructionsHeading: "## Repository Instructions",
+		RepoInstructionsIntro: lines(
+			"The sections below are ordered from repository root to current directory.",
+			"More specific instructions should override broader ones.",
+		),
+		ProjectHeading:       "## Project Roots",
+		ProjectIntro:         "This project is made of the roots below, relative to %s. You can read and change files in all of them.",
+		ProjectCurrentRoot:   "current",
+		DefaultSkillsHeading: "## Default Skills",
+		DefaultSkillsIntro:   "The project applies these skills to every task. Follow them without invoking them.",
+		CompactSummaryPrompt: `You are a conversation summarizer. Your task is to record everything needed to continue the task in a fixed JSON structure.
+
+Respond with a single JSON object and nothing else:
+{
+  "task": "...",
+  "current_state": "...",
+  "files_touched": [{"path": "...", "action": "read|created|modified|deleted", "note": "..."}],
+  "commands_run": ["..."],
+  "decisions": ["..."],
+  "open_todos": ["..."],
+  "notes": "..."
+}
+
+Fields:
+- task: the user's original request or goal
+- current_state: what has been accomplished so far
+- files_touched: files that were read, created, modified, or deleted, with a brief note on each change
+- commands_run: important commands that were run and their outcome
+- decisions: important decisions made and why
+- open_todos: work that still needs to be done
+- notes: any other critical context (error messages, specific requirements, etc.)
+
+Be concise but don't omit important details. Do NOT include raw tool outputs.`,
+		CompactSummaryRequest:   "Please summarize the following conversation:",
+		CompactSummaryHeader:    "[Conversation Summary - %d messages compacted]",
+		CompactSummaryUpdate:    "Update the previous summary with the new messages that follow it. Keep every entry that still applies, and return the complete updated JSON object.\n\nPrevious summary:",
+		CompactNewMessages:      "New messages:",
+		CompactSectionTask:      "Task",
+		CompactSectionState:     "Current state",
+		CompactSectionFiles:     "Files touched",
+		CompactSectionCommands:  "Commands run",
+		CompactSectionDecisions: "Decisions",
+		CompactSectionTodos:     "Open TODOs",
+		CompactSectionErrors:    "Errors encountered (do not retry unchanged)",
+		CompactSectionToolCalls: "Tool calls",
+		PruneToolResultStub:     "[Output of %s pruned to save context (%d bytes). Run the tool again if you need it.]",
+		OrphanedToolResultsNote: "[Tool results omitted: their tool calls are no longer in the context.]",
+		JSONCompressedNote:      "[The JSON output of %s (%d bytes) was compressed to save context: arrays keep their first items, objects their first keys, and deeper levels are summarized. The full output is stored as artifact %s.]",
+		JSONCompressedQueryHint: "[Use json_query with artifact_id %s to read the parts you need.]",
+		WorkDirChangedHeader:    "Files in the working directory were changed outside your tool calls. Re-read them before relying on their earlier content:",
+		WorkDirChangedMore:      "...and %d more",
+		LoopRepeatNote:          "You have made the same tool call (%s) %d times in a row with identical input. Repeating it will not give a different result; change your approach or explain what is blocking you.",
+		LoopRepeatFailingNote:   "The same tool call (%s) has failed %d times in a row with identical input. Stop retrying it unchanged: read the error, fix the input, try a different tool, or explain what is blocking you.",
+		LoopOscillationNote:     "You are alternating between the same tool calls (%s and %s) without making progress (%d responses). Step back, summarize what you have learned, and try a different approach.",
+	},
+
+	"zh": {
+		SkillsHeading: "## 可用技能",
+		SkillsIntro: lines(
+			"技能采用渐进式披露：先展示元数据，仅在调用时加载完整内容。",
+			"当某个技能适用时，请在行动前使用 `use_skill` 调用它。",
+			"使用 `list_skills` 查找可用技能；需要时使用 `read_skill` 直接查看。",
+			"标记为 disable-model-invocation 的技能被有意排除在此列表之外。",
+		),
+		SkillsNoDescription: "无描述。",
+		SoulHeading:         "## 角色设定",
+		SoulIntro: lines(
+			"以下内容定义了你的性格、个性和行为准则。",
+			"请在整个对话中遵循这些准则。",
+		),
+		RepoInstructionsHeading: "## 仓库说明",
+		RepoInstructionsIntro: lines(
+			"以下各节按从仓库根目录到当前目录的顺序排列。",
+			"更具体的说明优先于更宽泛的说明。",
+		),
+		ProjectHeading:       "## 项目根目录",
+		ProjectIntro:         "本项目由以下根目录组成（相对于 %s）。你可以读取和修改其中所有的文件。",
+		ProjectCurrentRoot:   "当前",
+		DefaultSkillsHeading: "## 默认技能",
+		DefaultSkillsIntro:   "本项目对每个任务都应用以下技能。请直接遵循，无需调用。",
+		CompactSummaryPrompt: `你是一个对话摘要助手。你的任务是以固定的 JSON 结构记录继续完成任务所需的全部信息。
+
+只回复一个 JSON 对象，不要包含其他内容：
+{
+  "task": "...",
+  "current_state": "...",
+  "files_touched": [{"path": "...", "action": "read|created|modified|deleted", "note": "..."}],
+  "commands_run": ["..."],
+  "decisions": ["..."],
+  "open_todos": ["..."],
+  "notes": "..."
+}
+
+字段说明：
+- task：用户最初的请求或目标
+- current_state：目前已完成了什么
+- files_touched：读取、创建、修改或删除过的文件，并简要说明每处改动
+- commands_run：执行过的重要命令及其结果
+- decisions：做出的重要决定及原因
+- open_todos：还需要完成的工作
+- notes：其他关键上下文（错误信息、具体要求等）
+
+JSON 的键保持英文，值请使用中文。简明扼要，但不要遗漏重要细节。不要包含原始的工具输出。`,
+		CompactSummaryRequest:   "请总结以下对话：",
+		CompactSummaryHeader:    "[对话摘要 - 已压缩 %d 条消息]",
+		CompactSummaryUpdate:    "请根据之后的新消息更新先前的摘要。保留仍然适用的所有条目，并返回完整的更新后 JSON 对象。\n\n先前的摘要：",
+		CompactNewMessages:      "新消息：",
+		CompactSectionTask:      "任务",
+		CompactSectionState:     "当前状态",
+		CompactSectionFiles:     "涉及的文件",
+		CompactSectionCommands:  "执行的命令",
+		CompactSectionDecisions: "决策",
+		CompactSectionTodos:     "待办事项",
+		CompactSectionErrors:    "遇到的错误（不要原样重试）",
+		CompactSectionToolCalls: "工具调用",
+		PruneToolResultStub:     "[为节省上下文，已删减 %s 的输出（%d 字节）。如需再次查看，请重新运行该工具。]",
+		OrphanedToolResultsNote: "[已省略工具结果：对应的工具调用已不在上下文中。]",
+		JSONCompressedNote:      "[为节省上下文，已压缩 %s 的 JSON 输出（%d 字节）：数组只保留前几项，对象只保留前几个键，更深的层级以摘要代替。完整输出已保存为制品 %s。]",
+		JSONCompressedQueryHint: "[使用 json_query 并指定 artifact_id %s 读取所需的部分。]",
+		WorkDirChangedHeader:    "工作目录中的文件在你的工具调用之外被修改。依赖其先前内容之前，请重新读取：",
+		WorkDirChangedMore:      "……以及另外 %d 个文件",
+		LoopRepeatNote:          "你已连续 %[2]d 次以相同的输入发起同一个工具调用（%[1]s）。重复调用不会得到不同的结果；请改变方法，或说明阻碍你的原因。",
+		LoopRepeatFailingNote:   "同一个工具调用（%[1]s）已以相同的输入连续失败 %[2]d 次。不要原样重试：请阅读错误信息、修正输入、换用其他工具，或说明阻碍你的原因。",
+		LoopOscillationNote:     "你在相同的工具调用（%s 和 %s）之间来回切换，没有取得进展（%d 次回复）。请退一步，总结已了解的信息，然后尝试其他方法。",
+	},
+
+	"ja": {
+		SkillsHeading: "## 利用可能なスキル",
+		SkillsIntro: lines(
+			"スキルは段階的に開示されます。まずメタデータを提示し、完全な内容は呼び出し時にのみ読み込みます。",
+			"関連するスキルがある場合は、行動する前に `use_skill` で呼び出してください。",
+			"利用可能なスキルの確認には `list_skills` を、直接確認が必要な場合は `read_skill` を使用してください。",
+			"disable-model-invocation が指定されたスキルは意図的にこの一覧から除外されています。",
+		),
+		SkillsNoDescription: "説明なし。",
+		SoulHeading:         "## ソウル",
+		SoulIntro: lines(
+			"以下はあなたの性格、人格、行動指針を定義します。",
+			"会話全体を通してこれらの指針に従ってください。",
+		),
+		RepoInstructionsHeading: "## リポジトリの指示",
+		RepoInstructionsIntro: lines(
+			"以下のセクションはリポジトリのルートから現在のディレクトリの順に並んでいます。",
+			"より具体的な指示が、より一般的な指示よりも優先されます。",
+		),
+		ProjectHeading:       "## プロジェクトのルート",
+		ProjectIntro:         "このプロジェクトは以下のルート（%s からの相対パス）で構成されています。すべてのルートのファイルを読み書きできます。",
+		ProjectCurrentRoot:   "現在",
+		DefaultSkillsHeading: "## デフォルトのスキル",
+		DefaultSkillsIntro:   "このプロジェクトはすべてのタスクに以下のスキルを適用します。呼び出さずにそのまま従ってください。",
+		CompactSummaryPrompt: `あなたは会話の要約担当です。タスクを継続するために必要な情報をすべて、決められた JSON 構造で記録してください。
+
+JSON オブジェクトを 1 つだけ返し、それ以外は出力しないでください：
+{
+  "task": "...",
+  "current_state": "...",
+  "files_touched": [{"path": "...", "action": "read|created|modified|deleted", "note": "..."}],
+  "commands_run": ["..."],
+  "decisions": ["..."],
+  "open_todos": ["..."],
+  "notes": "..."
+}
+
+各フィールド：
+- task：ユーザーの最初の依頼・目標
+- current_state：これまでに達成されたこと
+- files_touched：読み取り・作成・変更・削除したファイルと、各変更の簡単な説明
+- commands_run：実行した重要なコマンドとその結果
+- decisions：行われた重要な決定とその理由
+- open_todos：まだ行う必要がある作業
+- notes：その他の重要な文脈（エラーメッセージ、具体的な要件など）
+
+JSON のキーは英語のまま、値は日本語で記述してください。簡潔に、ただし重要な詳細は省略しないでください。ツールの生の出力は含めないでください。`,
+		CompactSummaryRequest:   "次の会話を要約してください：",
+		CompactSummaryHeader:    "[会話の要約 - %d 件のメッセージを圧縮]",
+		CompactSummaryUpdate:    "前回の要約を、その後に続く新しいメッセージで更新してください。引き続き有効な項目はすべて残し、更新後の JSON オブジェクト全体を返してください。\n\n前回の要約：",
+		CompactNewMessages:      "新しいメッセージ：",
+		CompactSectionTask:      "タスク",
+		CompactSectionState:     "現在の状態",
+		CompactSectionFiles:     "関係したファイル",
+		CompactSectionCommands:  "実行したコマンド",
+		CompactSectionDecisions: "決定事項",
+		CompactSectionTodos:     "未完了の作業",
+		CompactSectionErrors:    "発生したエラー（同じ方法で再試行しないこと）",
+		CompactSectionToolCalls: "ツール呼び出し",
+		PruneToolResultStub:     "[コンテキスト節約のため %s の出力を削除しました（%d バイト）。必要な場合はツールを再実行してください。]",
+		OrphanedToolResultsNote: "[ツール結果を省略しました：対応するツール呼び出しはもうコンテキストにありません。]",
+		JSONCompressedNote:      "[コンテキスト節約のため %s の JSON 出力（%d バイト）を圧縮しました。配列は先頭の要素、オブジェクトは先頭のキーのみを残し、深い階層は要約しています。完全な出力はアーティファクト %s として保存されています。]",
+		JSONCompressedQueryHint: "[必要な部分は json_query で artifact_id %s を指定して取得してください。]",
+		WorkDirChangedHeader:    "作業ディレクトリのファイルがツール呼び出し以外で変更されました。以前の内容に依存する前に読み直してください：",
+		WorkDirChangedMore:      "…ほか %d 件",
+		LoopRepeatNote:          "同じ入力で同じツール呼び出し（%[1]s）を %[2]d 回連続で行っています。繰り返しても結果は変わりません。方法を変えるか、何が妨げになっているかを説明してください。",
+		LoopRepeatFailingNote:   "同じ入力の同じツール呼び出し（%[1]s）が %[2]d 回連続で失敗しました。そのまま再試行せず、エラーを読んで入力を修正するか、別のツールを使うか、何が妨げになっているかを説明してください。",
+		LoopOscillationNote:     "同じツール呼び出し（%s と %s）の間を行き来しており、進展がありません（%d 回の応答）。一度立ち止まって分かったことを整理し、別の方法を試してください。",
+	},
+
+	"es": {
+		SkillsHeading: "## Habilidades disponibles",
+		SkillsIntro: lines(
+			"Las habilidades usan divulgación progresiva: primero se muestran los metadatos y el contenido completo solo se carga al invocarlas.",
+			"Cuando una habilidad sea pertinente, invócala con `use_skill` antes de actuar.",
+			"Usa `list_skills` para descubrir las habilidades disponibles; usa `read_skill` para inspeccionarlas directamente cuando sea necesario.",
+			"Las habilidades marcadas con disable-model-invocation se excluyen intencionadamente de esta lista.",
+		),
+		SkillsNoDescription: "Sin descripción.",
+		SoulHeading:         "## Alma",
+		SoulIntro: lines(
+			"Lo siguiente define tu carácter, personalidad y directrices de comportamiento.",
+			"Sigue estas directrices durante toda la conversación.",
+		),
+		RepoInstructionsHeading: "## Instrucciones del repositorio",
+		RepoInstructionsIntro: lines(
+			"Las secciones siguientes están ordenadas desde la raíz del repositorio hasta el directorio actual.",
+			"Las instrucciones más específicas prevalecen sobre las más generales.",
+		),
+		ProjectHeading:       "## Raíces del proyecto",
+		ProjectIntro:         "Este proyecto se compone de las raíces siguientes, relativas a %s. Puedes leer y modificar archivos en todas ellas.",
+		ProjectCurrentRoot:   "actual",
+		DefaultSkillsHeading: "## Habilidades predeterminadas",
+		DefaultSkillsIntro:   "El proyecto aplica estas habilidades a todas las tareas. Síguelas sin invocarlas.",
+		CompactSummaryPrompt: `Eres un resumidor de conversaciones. Tu tarea es registrar todo lo necesario para continuar la tarea en una estructura JSON fija.
+
+Responde con un único objeto JSON y nada más:
+{
+  "task": "...",
+  "current_state": "...",
+  "files_touched": [{"path": "...", "action": "read|created|modified|deleted", "note": "..."}],
+  "commands_run": ["..."],
+  "decisions": ["..."],
+  "open_todos": ["..."],
+  "notes": "..."
+}
+
+Campos:
+- task: la solicitud u objetivo inicial del usuario
+- current_state: lo que se ha logrado hasta ahora
+- files_touched: archivos leídos, creados, modificados o eliminados, con una breve nota sobre cada cambio
+- commands_run: comandos importantes ejecutados y su resultado
+- decisions: decisiones importantes tomadas y por qué
+- open_todos: trabajo que queda por hacer
+- notes: cualquier otro contexto crítico (mensajes de error, requisitos específicos, etc.)
+
+Mantén las claves JSON en inglés y escribe los valores en español. Sé conciso, pero no omitas detalles importantes. NO incluyas salidas sin procesar de herramientas.`,
+		CompactSummaryRequest:   "Resume la siguiente conversación:",
+		CompactSummaryHeader:    "[Resumen de la conversación - %d mensajes compactados]",
+		CompactSummaryUpdate:    "Actualiza el resumen anterior con los mensajes nuevos que lo siguen. Conserva todas las entradas que sigan siendo válidas y devuelve el objeto JSON actualizado completo.\n\nResumen anterior:",
+		CompactNewMessages:      "Mensajes nuevos:",
+		CompactSectionTask:      "Tarea",
+		CompactSectionState:     "Estado actual",
+		CompactSectionFiles:     "Archivos afectados",
+		CompactSectionCommands:  "Comandos ejecutados",
+		CompactSectionDecisions: "Decisiones",
+		CompactSectionTodos:     "Pendientes",
+		CompactSectionErrors:    "Errores encontrados (no reintentar sin cambios)",
+		CompactSectionToolCalls: "Llamadas a herramientas",
+		PruneToolResultStub:     "[Salida de %s recortada para ahorrar contexto (%d bytes). Vuelve a ejecutar la herramienta si la necesitas.]",
+		OrphanedToolResultsNote: "[Resultados de herramientas omitidos: sus llamadas ya no están en el contexto.]",
+		JSONCompressedNote:      "[La salida JSON de %s (%d bytes) se comprimió para ahorrar contexto: los arrays conservan sus primeros elementos, los objetos sus primeras claves y los niveles más profundos se resumen. La salida completa está guardada como artefacto %s.]",
+		JSONCompressedQueryHint: "[Usa json_query con artifact_id %s para leer las partes que necesites.]",
+		WorkDirChangedHeader:    "Se modificaron archivos del directorio de trabajo fuera de tus llamadas a herramientas. Vuelve a leerlos antes de confiar en su contenido anterior:",
+		WorkDirChangedMore:      "...y %d más",
+		LoopRepeatNote:          "Has hecho la misma llamada a herramienta (%s) %d veces seguidas con la misma entrada. Repetirla no dará un resultado distinto; cambia de enfoque o explica qué te bloquea.",
+		LoopRepeatFailingNote:   "La misma llamada a herramienta (%s) ha fallado %d veces seguidas con la misma entrada. Deja de reintentarla sin cambios: lee el error, corrige la entrada, prueba otra herramienta o explica qué te bloquea.",
+		LoopOscillationNote:     "Estás alternando entre las mismas llamadas a herramientas (%s y %s) sin avanzar (%d respuestas). Detente, resume lo que has aprendido y prueba un enfoque distinto.",
+	},
+}
+
+func lines(s ...string) string {
+	return strings.Join(s, "\n")
+}
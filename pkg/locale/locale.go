@@ -0,0 +1,164 @@
+// Package locale is a message catalog for the built-in instructional
+// strings the agent adds to prompts (skills block, system prompt section
+// headers, compaction summaries), so non-English deployments do not mix
+// languages in the system prompt.
+package locale
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the locale used when none is set or a key is missing.
+const Default = "en"
+
+// Key identifies one catalog message.
+type Key string
+
+const (
+	// Skills prompt block and system prompt sections.
+	SkillsHeading           Key = "skills.heading"
+	SkillsIntro             Key = "skills.intro"
+	SkillsNoDescription     Key = "skills.no_description"
+	SoulHeading             Key = "soul.heading"
+	SoulIntro               Key = "soul.intro"
+	RepoInstructionsHeading Key = "repo_instructions.heading"
+	RepoInstructionsIntro   Key = "repo_instructions.intro"
+
+	// Project configuration sections. ProjectIntro is a format string
+	// taking the project directory; ProjectCurrentRoot marks the root of
+	// the working directory.
+	ProjectHeading       Key = "project.heading"
+	ProjectIntro         Key = "project.intro"
+	ProjectCurrentRoot   Key = "project.current_root"
+	DefaultSkillsHeading Key = "default_skills.heading"
+	DefaultSkillsIntro   Key = "default_skills.intro"
+
+	// Compaction. CompactSummaryHeader is a format string taking the number
+	// of compacted messages. CompactSummaryUpdate introduces the previous
+	// summary when a later compaction updates it, and the CompactSection
+	// keys head the rendered summary sections.
+	CompactSummaryPrompt    Key = "compact.summary_prompt"
+	CompactSummaryRequest   Key = "compact.summary_request"
+	CompactSummaryHeader    Key = "compact.summary_header"
+	CompactSummaryUpdate    Key = "compact.summary_update"
+	CompactNewMessages      Key = "compact.new_messages"
+	CompactSectionTask      Key = "compact.section.task"
+	CompactSectionState     Key = "compact.section.state"
+	CompactSectionFiles     Key = "compact.section.files"
+	CompactSectionCommands  Key = "compact.section.commands"
+	CompactSectionDecisions Key = "compact.section.decisions"
+	CompactSectionTodos     Key = "compact.section.todos"
+	CompactSectionErrors    Key = "compact.section.errors"
+	CompactSectionToolCalls Key = "compact.section.tool_calls"
+
+	// Pruning. PruneToolResultStub replaces a pruned tool result and is a
+	// format string taking the tool name and the original size in bytes.
+	PruneToolResultStub Key = "prune.tool_result_stub"
+
+	// Tool pair repair. OrphanedToolResultsNote replaces a message whose
+	// tool results were all dropped because their tool calls are no longer
+	// in the context.
+	OrphanedToolResultsNote Key = "tool_pairs.orphaned_results"
+
+	// JSON output compression. JSONCompressedNote precedes a compressed
+	// tool output and is a format string taking the tool name, the original
+	// size in bytes, and the ID of the artifact holding the full output.
+	JSONCompressedNote Key = "json_compress.note"
+
+	// JSONCompressedQueryHint follows the note when json_query is available
+	// and is a format string taking the artifact ID.
+	JSONCompressedQueryHint Key = "json_compress.query_hint"
+
+	// Workdir watching. WorkDirChangedMore is a format string taking the
+	// number of changed files not listed.
+	WorkDirChangedHeader Key = "workdir.changed_header"
+	WorkDirChangedMore   Key = "workdir.changed_more"
+
+	// Loop detection notes. LoopRepeatNote and LoopRepeatFailingNote are
+	// format strings taking the repeated calls and the number of repeats;
+	// LoopOscillationNote takes both alternating calls and the number of
+	// responses.
+	LoopRepeatNote        Key = "loop_guard.repeat"
+	LoopRepeatFailingNote Key = "loop_guard.repeat_failing"
+	LoopOscillationNote   Key = "loop_guard.oscillation"
+)
+
+// Catalog maps keys to messages for one locale.
+type Catalog map[Key]string
+
+var (
+	mu       sync.RWMutex
+	catalogs = map[string]Catalog{}
+)
+
+func init() {
+	for tag, c := range builtinCatalogs {
+		catalogs[tag] = c
+	}
+}
+
+// Register adds or overrides messages for tag (e.g. "fr" or "pt-BR").
+// Keys missing from every matching catalog fall back to English.
+func Register(tag string, c Catalog) {
+	tag = normalize(tag)
+	mu.Lock()
+	defer mu.Unlock()
+	merged := Catalog{}
+	for k, v := range catalogs[tag] {
+		merged[k] = v
+	}
+	for k, v := range c {
+		merged[k] = v
+	}
+	catalogs[tag] = merged
+}
+
+// Text returns the message for key in tag. Region tags fall back to their
+// language ("zh-TW" to "zh"), then to English.
+func Text(tag string, key Key) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, candidate := range fallbacks(tag) {
+		if msg, ok := catalogs[candidate][key]; ok {
+			return msg
+		}
+	}
+	return string(key)
+}
+
+// Supported returns the registered locale tags, sorted.
+func Supported() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	tags := make([]string, 0, len(catalogs))
+	for tag := range catalogs {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func fallbacks(tag string) []string {
+	tag = normalize(tag)
+	out := make([]string, 0, 3)
+	if tag != "" {
+		out = append(out, tag)
+		if lang, _, ok := strings.Cut(tag, "-"); ok {
+			out = append(out, lang)
+		}
+	}
+	return append(out, Default)
+}
+
+// normalize lowercases the language and accepts "_" as a separator, so
+// "zh_CN" and "zh-cn" both become "zh-CN".
+func normalize(tag string) string {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	lang, region, ok := strings.Cut(tag, "-")
+	if !ok {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+}
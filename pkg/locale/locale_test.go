@@ -0,0 +1,37 @@
+package locale
+
+import "testing"
+
+func TestBuiltinCatalogsDefineEveryEnglishKey(t *testing.T) {
+	for tag, c := range builtinCatalogs {
+		for key := range builtinCatalogs[Default] {
+			if c[key] == "" {
+				t.Errorf("catalog %q is missing %s", tag, key)
+			}
+		}
+	}
+}
+
+func TestTextFallbacks(t *testing.T) {
+	Register("xx", Catalog{SoulHeading: "## XX"})
+
+	tests := []struct {
+		tag  string
+		key  Key
+		want string
+	}{
+		{tag: "", key: SoulHeading, want: "## Soul"},
+		{tag: "zh", key: SoulHeading, want: "## 角色设定"},
+		{tag: "zh_CN", key: SoulHeading, want: "## 角色设定"},
+		{tag: "ES-mx", key: SkillsNoDescription, want: "Sin descripción."},
+		{tag: "unknown", key: SoulHeading, want: "## Soul"},
+		{tag: "xx", key: SoulHeading, want: "## XX"},
+		{tag: "xx", key: SkillsHeading, want: "## Available Skills"},
+		{tag: "en", key: Key("missing"), want: "missing"},
+	}
+	for _, tt := range tests {
+		if got := Text(tt.tag, tt.key); got != tt.want {
+			t.Errorf("Text(%q, %s) = %q, want %q", tt.tag, tt.key, got, tt.want)
+		}
+	}
+}
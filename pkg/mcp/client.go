@@ -49,7 +49,7 @@ func (c *Client) Initialize(ctx context.Context) error {
 	}
 
 	if resp.Error != nil {
-		return fmt.Errorf("initialize error: %s", resp.Error.Message)
+		return fmt.Errorf("initialize error: %w", resp.Error)
 	}
 
 	var result InitializeResult
@@ -92,7 +92,7 @@ func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
 	}
 
 	if resp.Error != nil {
-		return nil, fmt.Errorf("list tools error: %s", resp.Error.Message)
+		return nil, fmt.Errorf("list tools error: %w", resp.Error)
 	}
 
 	var result ListToolsResult
@@ -128,7 +128,7 @@ func (c *Client) CallTool(ctx context.Context, name string, arguments map[string
 	}
 
 	if resp.Error != nil {
-		return CallToolResult{}, fmt.Errorf("call tool error: %s", resp.Error.Message)
+		return CallToolResult{}, fmt.Errorf("call tool error: %w", resp.Error)
 	}
 
 	var result CallToolResult
@@ -0,0 +1,365 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	defaultMinBackoff    = time.Second
+	defaultMaxBackoff    = time.Minute
+	defaultDegradedAfter = 3
+)
+
+var errServerClosed = errors.New("server closed")
+
+// ServerState is the health of an MCP server connection.
+type ServerState string
+
+const (
+	// StateConnecting is a server that has not finished Initialize yet.
+	StateConnecting ServerState = "connecting"
+
+	// StateReady is a connected server whose last call succeeded.
+	StateReady ServerState = "ready"
+
+	// StateDegraded is a connected server whose recent calls timed out
+	// (HealthConfig.DegradedAfter in a row). It recovers on the next
+	// successful call.
+	StateDegraded ServerState = "degraded"
+
+	// StateDown is a server whose connection broke or could not be opened.
+	// Its tool calls fail until a reconnect succeeds.
+	StateDown ServerState = "down"
+)
+
+// HealthConfig controls how an MCPServer tracks and recovers its
+// connection. Zero values select the defaults.
+type HealthConfig struct {
+	// MinBackoff is the delay before the first reconnect attempt (default
+	// 1s). Each failed attempt doubles it, up to MaxBackoff (default 1m).
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// DegradedAfter is the number of timed-out calls in a row that marks a
+	// connected server degraded (default 3).
+	DegradedAfter int
+}
+
+func (c HealthConfig) withDefaults() HealthConfig {
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = defaultMinBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultMaxBackoff
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		c.MaxBackoff = c.MinBackoff
+	}
+	if c.DegradedAfter <= 0 {
+		c.DegradedAfter = defaultDegradedAfter
+	}
+	return c
+}
+
+// ServerStatus is a snapshot of an MCP server's health.
+type ServerStatus struct {
+	Name  string      `json:"name"`
+	State ServerState `json:"state"`
+
+	// Tools is the number of tools the server offers.
+	Tools int `json:"tools"`
+
+	// LastError is why the server is down or degraded.
+	LastError string `json:"last_error,omitempty"`
+
+	// Reconnects counts the successful reconnects after the first
+	// connection.
+	Reconnects  int       `json:"reconnects"`
+	ConnectedAt time.Time `json:"connected_at,omitzero"`
+
+	// NextRetry is when a down server is reconnected next.
+	NextRetry time.Time `json:"next_retry,omitzero"`
+
+	// ToolCalls counts the calls of each tool by outcome.
+	ToolCalls map[string]ToolCallStats `json:"tool_calls,omitempty"`
+}
+
+// ToolCallStats counts the calls of one MCP tool.
+type ToolCallStats struct {
+	// OK calls returned a result; Errors returned an error result or a
+	// JSON-RPC error from the server.
+	OK     int `json:"ok"`
+	Errors int `json:"errors"`
+
+	// Failed calls got no answer: the server was down, the connection
+	// broke, or the call timed out.
+	Failed int `json:"failed"`
+}
+
+// WithHealth sets how the server tracks and recovers its connection.
+func (s *MCPServer) WithHealth(cfg HealthConfig) *MCPServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health = cfg
+	return s
+}
+
+// Status returns the server's current health.
+func (s *MCPServer) Status() ServerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := ServerStatus{
+		Name:        s.name,
+		State:       s.state,
+		Tools:       len(s.tools),
+		Reconnects:  s.reconnects,
+		ConnectedAt: s.connectedAt,
+	}
+	if status.State == "" {
+		status.State = StateConnecting
+	}
+	if s.lastErr != nil {
+		status.LastError = s.lastErr.Error()
+	}
+	if s.state == StateDown && !s.closed {
+		status.NextRetry = s.retryAt
+	}
+	if len(s.calls) > 0 {
+		status.ToolCalls = make(map[string]ToolCallStats, len(s.calls))
+		for name, stats := range s.calls {
+			status.ToolCalls[name] = *stats
+		}
+	}
+	return status
+}
+
+// Monitor checks the server every interval until ctx is done (see Check).
+func (s *MCPServer) Monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			if err := s.Check(checkCtx); err != nil {
+				log.Printf("[mcp] server %s check failed: %v", s.name, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Check reconnects a down server once its backoff has passed, and
+// refreshes the tools of a connected one, which also detects a dead
+// connection.
+func (s *MCPServer) Check(ctx context.Context) error {
+	s.mu.Lock()
+	closed, down := s.closed, s.state == StateDown
+	due := !s.clock().Before(s.retryAt)
+	s.mu.Unlock()
+
+	switch {
+	case closed:
+		return nil
+	case down && !due:
+		return nil
+	case down:
+		return s.reconnect(ctx)
+	}
+	return s.Refresh(ctx)
+}
+
+// Refresh lists the server's tools again and updates the registered ones:
+// new tools are registered, dropped ones unregistered. A broken connection
+// marks the server down.
+func (s *MCPServer) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	client := s.client
+	s.mu.Unlock()
+
+	infos, err := client.ListTools(ctx)
+	s.mu.Lock()
+	if err != nil {
+		s.observe(ctx, err)
+		s.mu.Unlock()
+		return fmt.Errorf("failed to refresh tools from MCP server %s: %w", s.name, err)
+	}
+	s.markReady()
+	s.mu.Unlock()
+	return s.setTools(infos)
+}
+
+// callTool calls a tool over the current connection, reconnecting a down
+// server whose backoff has passed, and records the outcome.
+func (s *MCPServer) callTool(ctx context.Context, name string, input map[string]any) (CallToolResult, error) {
+	client, err := s.connection(ctx)
+	if err != nil {
+		s.mu.Lock()
+		s.stats(name).Failed++
+		s.mu.Unlock()
+		return CallToolResult{}, err
+	}
+
+	result, err := client.CallTool(ctx, name, input)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats(name)
+	var rpcErr *Error
+	switch {
+	case err == nil && result.IsError, errors.As(err, &rpcErr):
+		stats.Errors++
+	case err == nil:
+		stats.OK++
+	default:
+		stats.Failed++
+	}
+	s.observe(ctx, err)
+	return result, err
+}
+
+// connection returns the client of a connected server.
+func (s *MCPServer) connection(ctx context.Context) (*Client, error) {
+	s.mu.Lock()
+	client, state, closed, retryAt, lastErr := s.client, s.state, s.closed, s.retryAt, s.lastErr
+	now := s.clock()
+	s.mu.Unlock()
+
+	switch {
+	case closed:
+		return nil, fmt.Errorf("MCP server %s is closed", s.name)
+	case state != StateDown:
+		return client, nil
+	case now.Before(retryAt):
+		return nil, fmt.Errorf("MCP server %s is down, reconnecting in %s: %w",
+			s.name, retryAt.Sub(now).Round(time.Millisecond), lastErr)
+	}
+	if err := s.reconnect(ctx); err != nil {
+		return nil, fmt.Errorf("MCP server %s is down: %w", s.name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client, nil
+}
+
+// reconnect replaces the connection of a down server.
+func (s *MCPServer) reconnect(ctx context.Context) error {
+	s.dialMu.Lock()
+	defer s.dialMu.Unlock()
+
+	s.mu.Lock()
+	if s.state != StateDown || s.closed {
+		// Another caller reconnected, or the server was closed.
+		s.mu.Unlock()
+		return nil
+	}
+	old := s.transport
+	s.transport = nil
+	s.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+
+	err := errors.New("no dialer to reconnect with")
+	if s.dial != nil {
+		var transport Transport
+		transport, err = s.dial()
+		if err == nil {
+			client := NewClient(transport)
+			var infos []ToolInfo
+			infos, err = connectClient(ctx, client, s.name)
+			if err == nil {
+				s.mu.Lock()
+				s.client, s.transport = client, transport
+				s.reconnects++
+				s.markReady()
+				s.mu.Unlock()
+				log.Printf("[mcp] reconnected to server %s", s.name)
+				return s.setTools(infos)
+			}
+			transport.Close()
+		}
+	}
+
+	s.mu.Lock()
+	s.markDown(err)
+	s.mu.Unlock()
+	return err
+}
+
+// observe updates the health after a request to the server. A JSON-RPC
+// error is an answer, so only missing answers count against the server.
+// The caller holds s.mu.
+func (s *MCPServer) observe(ctx context.Context, err error) {
+	var rpcErr *Error
+	switch {
+	case err == nil || errors.As(err, &rpcErr):
+		s.markReady()
+	case ctx.Err() != nil:
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// Cancelled by the caller; says nothing about the server.
+			return
+		}
+		s.failures++
+		s.lastErr = err
+		if s.state == StateReady && s.failures >= s.health.withDefaults().DegradedAfter {
+			log.Printf("[mcp] server %s degraded after %d timed-out calls", s.name, s.failures)
+			s.state = StateDegraded
+		}
+	default:
+		s.markDown(err)
+	}
+}
+
+// markReady records a working connection. The caller holds s.mu.
+func (s *MCPServer) markReady() {
+	if s.state == StateDown || s.state == StateConnecting || s.state == "" {
+		s.connectedAt = s.clock()
+	}
+	s.state = StateReady
+	s.lastErr = nil
+	s.failures = 0
+	s.attempts = 0
+}
+
+// markDown records a broken connection and schedules the next reconnect
+// with exponential backoff. The caller holds s.mu.
+func (s *MCPServer) markDown(err error) {
+	if s.state != StateDown {
+		log.Printf("[mcp] server %s is down: %v", s.name, err)
+	}
+	cfg := s.health.withDefaults()
+	delay := cfg.MinBackoff << min(s.attempts, 30)
+	if delay <= 0 || delay > cfg.MaxBackoff {
+		delay = cfg.MaxBackoff
+	}
+	s.state = StateDown
+	s.lastErr = err
+	s.attempts++
+	s.retryAt = s.clock().Add(delay)
+}
+
+// stats returns the call counts of a tool. The caller holds s.mu.
+func (s *MCPServer) stats(name string) *ToolCallStats {
+	if s.calls == nil {
+		s.calls = map[string]*ToolCallStats{}
+	}
+	stats, ok := s.calls[name]
+	if !ok {
+		stats = &ToolCallStats{}
+		s.calls[name] = stats
+	}
+	return stats
+}
+
+func (s *MCPServer) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,166 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// fakeTransport answers like an MCP server offering tools until it breaks.
+type fakeTransport struct {
+	tools  []string
+	broken bool
+}
+
+func (f *fakeTransport) Send(_ context.Context, req Request) (Response, error) {
+	if f.broken {
+		return Response{}, errors.New("write |1: broken pipe")
+	}
+	var result any
+	switch req.Method {
+	case MethodInitialize:
+		result = InitializeResult{ProtocolVersion: ProtocolVersion, ServerInfo: Implementation{Name: "fake"}}
+	case MethodToolsList:
+		list := ListToolsResult{}
+		for _, name := range f.tools {
+			list.Tools = append(list.Tools, ToolInfo{Name: name})
+		}
+		result = list
+	case MethodToolsCall:
+		var params CallToolParams
+		json.Unmarshal(req.Params, &params)
+		if params.Name == "reject" {
+			return Response{ID: req.ID, Error: &Error{Code: -32602, Message: "invalid arguments"}}, nil
+		}
+		result = CallToolResult{Content: []ContentItem{{Type: "text", Text: "ok"}}}
+	}
+	data, _ := json.Marshal(result)
+	return Response{ID: req.ID, Result: data}, nil
+}
+
+func (f *fakeTransport) Notify(context.Context, Notification) error {
+	if f.broken {
+		return errors.New("write |1: broken pipe")
+	}
+	return nil
+}
+
+func (f *fakeTransport) Close() error { return nil }
+
+func TestMCPServerReconnectsWithBackoffAndRefreshesTools(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := &fakeTransport{tools: []string{"search", "reject"}}
+	dials := []*fakeTransport{first}
+	server, err := NewMCPServerWithDialer("docs", func() (Transport, error) {
+		if len(dials) == 0 {
+			return nil, errors.New("connection refused")
+		}
+		next := dials[0]
+		dials = dials[1:]
+		return next, nil
+	})
+	if err != nil {
+		t.Fatalf("NewMCPServerWithDialer: %v", err)
+	}
+	server.now = func() time.Time { return now }
+	server.WithHealth(HealthConfig{MinBackoff: time.Second, MaxBackoff: 4 * time.Second})
+
+	ctx := context.Background()
+	if err := server.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	registry := tools.NewRegistry()
+	if err := server.RegisterTools(registry); err != nil {
+		t.Fatalf("RegisterTools: %v", err)
+	}
+	search := registry.Get("mcp__docs__search")
+	if res, _ := search.Execute(ctx, nil, nil); res.IsError {
+		t.Fatalf("search failed: %s", res.Content)
+	}
+	if res, _ := registry.Get("mcp__docs__reject").Execute(ctx, nil, nil); !res.IsError {
+		t.Fatal("expected the JSON-RPC error as an error result")
+	}
+	if s := server.Status(); s.State != StateReady {
+		t.Fatalf("a JSON-RPC error should not mark the server unhealthy: %+v", s)
+	}
+
+	// The connection breaks; the failed reconnect backs off 1s, then 2s.
+	first.broken = true
+	search.Execute(ctx, nil, nil)
+	if s := server.Status(); s.State != StateDown || !s.NextRetry.Equal(now.Add(time.Second)) {
+		t.Fatalf("expected down with a 1s backoff: %+v", s)
+	}
+	if res, _ := search.Execute(ctx, nil, nil); !strings.Contains(res.Content, "reconnecting in") {
+		t.Fatalf("expected a fast failure during backoff, got %q", res.Content)
+	}
+	now = now.Add(time.Second)
+	if err := server.Check(ctx); err == nil {
+		t.Fatal("expected the reconnect to fail without a server")
+	}
+	if s := server.Status(); !s.NextRetry.Equal(now.Add(2 * time.Second)) {
+		t.Fatalf("expected the backoff to double: %+v", s)
+	}
+
+	// The server comes back with a different tool set.
+	dials = append(dials, &fakeTransport{tools: []string{"search", "summarize"}})
+	now = now.Add(2 * time.Second)
+	if err := server.Check(ctx); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if registry.Get("mcp__docs__search") != search || !registry.Has("mcp__docs__summarize") || registry.Has("mcp__docs__reject") {
+		t.Fatalf("registered tools not refreshed: %v", registry.Names())
+	}
+	if res, _ := search.Execute(ctx, nil, nil); res.IsError {
+		t.Fatalf("search after reconnect failed: %s", res.Content)
+	}
+
+	status := server.Status()
+	want := ToolCallStats{OK: 2, Failed: 2}
+	if status.State != StateReady || status.Reconnects != 1 || status.Tools != 2 || status.ToolCalls["search"] != want {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf, []ServerStatus{status}); err != nil {
+		t.Fatalf("WriteMetrics: %v", err)
+	}
+	for _, line := range []string{
+		`mcp_server_up{server="docs"} 1`,
+		`mcp_server_state{server="docs",state="down"} 0`,
+		`mcp_server_reconnects_total{server="docs"} 1`,
+		`mcp_tool_calls_total{server="docs",tool="search",outcome="failed"} 2`,
+		`mcp_tool_calls_total{server="docs",tool="reject",outcome="error"} 1`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("metrics missing %q:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestMCPServerDegradesAfterTimeouts(t *testing.T) {
+	server := &MCPServer{name: "slow", state: StateReady}
+	server.WithHealth(HealthConfig{DegradedAfter: 2})
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	for range 2 {
+		server.mu.Lock()
+		server.observe(ctx, ctx.Err())
+		server.mu.Unlock()
+	}
+	if s := server.Status(); s.State != StateDegraded || s.LastError == "" {
+		t.Fatalf("expected degraded after two timeouts: %+v", s)
+	}
+	server.mu.Lock()
+	server.observe(context.Background(), nil)
+	server.mu.Unlock()
+	if s := server.Status(); s.State != StateReady {
+		t.Fatalf("expected a successful call to recover the server: %+v", s)
+	}
+}
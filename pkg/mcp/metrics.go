@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// serverStates lists every state for the mcp_server_state gauge.
+var serverStates = []ServerState{StateConnecting, StateReady, StateDegraded, StateDown}
+
+// WriteMetrics writes the statuses in the Prometheus text exposition format:
+//
+//	mcp_server_up{server}                    1 when ready or degraded
+//	mcp_server_state{server,state}           1 for the current state
+//	mcp_server_tools{server}                 tools offered
+//	mcp_server_reconnects_total{server}      successful reconnects
+//	mcp_tool_calls_total{server,tool,outcome} calls by outcome (ok, error, failed)
+func WriteMetrics(w io.Writer, statuses []ServerStatus) error {
+	bw := bufio.NewWriter(w)
+	metric := func(name, typ, help string, write func()) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		write()
+	}
+
+	metric("mcp_server_up", "gauge", "Whether the MCP server is connected (ready or degraded).", func() {
+		for _, s := range statuses {
+			up := 0
+			if s.State == StateReady || s.State == StateDegraded {
+				up = 1
+			}
+			fmt.Fprintf(bw, "mcp_server_up{server=%s} %d\n", labelValue(s.Name), up)
+		}
+	})
+	metric("mcp_server_state", "gauge", "The MCP server's connection state.", func() {
+		for _, s := range statuses {
+			for _, state := range serverStates {
+				value := 0
+				if s.State == state {
+					value = 1
+				}
+				fmt.Fprintf(bw, "mcp_server_state{server=%s,state=%s} %d\n", labelValue(s.Name), labelValue(string(state)), value)
+			}
+		}
+	})
+	metric("mcp_server_tools", "gauge", "Number of tools the MCP server offers.", func() {
+		for _, s := range statuses {
+			fmt.Fprintf(bw, "mcp_server_tools{server=%s} %d\n", labelValue(s.Name), s.Tools)
+		}
+	})
+	metric("mcp_server_reconnects_total", "counter", "Successful reconnects to the MCP server.", func() {
+		for _, s := range statuses {
+			fmt.Fprintf(bw, "mcp_server_reconnects_total{server=%s} %d\n", labelValue(s.Name), s.Reconnects)
+		}
+	})
+	metric("mcp_tool_calls_total", "counter", "MCP tool calls by outcome: ok, error (error result), or failed (no answer).", func() {
+		for _, s := range statuses {
+			tools := make([]string, 0, len(s.ToolCalls))
+			for name := range s.ToolCalls {
+				tools = append(tools, name)
+			}
+			sort.Strings(tools)
+			for _, tool := range tools {
+				stats := s.ToolCalls[tool]
+				for _, o := range []struct {
+					outcome string
+					n       int
+				}{{"ok", stats.OK}, {"error", stats.Errors}, {"failed", stats.Failed}} {
+					fmt.Fprintf(bw, "mcp_tool_calls_total{server=%s,tool=%s,outcome=%q} %d\n",
+						labelValue(s.Name), labelValue(tool), o.outcome, o.n)
+				}
+			}
+		}
+	})
+	return bw.Flush()
+}
+
+// labelValue quotes a Prometheus label value.
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -3,16 +3,80 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
 )
 
+// ToolNamePrefix starts the namespaced name of every MCP tool.
+const ToolNamePrefix = "mcp__"
+
+// ToolName returns the namespaced name of an MCP server's tool,
+// mcp__<server>__<tool>. The same names are used by Claude Code, so
+// allowlists carry over between API and CLI agents.
+func ToolName(server, tool string) string {
+	return ToolNamePrefix + server + "__" + tool
+}
+
+// ParseToolName splits a namespaced MCP tool name into its server and
+// tool names.
+func ParseToolName(name string) (server, tool string, ok bool) {
+	rest, ok := strings.CutPrefix(name, ToolNamePrefix)
+	if !ok {
+		return "", "", false
+	}
+	server, tool, ok = strings.Cut(rest, "__")
+	if !ok || server == "" || tool == "" {
+		return "", "", false
+	}
+	return server, tool, true
+}
+
+// CollisionPolicy decides what RegisterToolsWithOptions does when a tool
+// name is already registered.
+type CollisionPolicy string
+
+const (
+	// CollisionError fails the registration. This is the default.
+	CollisionError CollisionPolicy = "error"
+
+	// CollisionPrefix registers the tool under its namespaced name
+	// instead. With namespaced names it behaves like CollisionError.
+	CollisionPrefix CollisionPolicy = "prefix"
+
+	// CollisionOverride replaces the registered tool, e.g. to let an MCP
+	// server's read_file shadow the builtin one.
+	CollisionOverride CollisionPolicy = "override"
+)
+
+// RegisterOptions configures RegisterToolsWithOptions.
+type RegisterOptions struct {
+	// BareNames registers tools under their MCP names (e.g. "search")
+	// instead of mcp__<server>__<tool>. These can collide with builtins
+	// and other servers, so pair it with OnCollision.
+	BareNames bool
+
+	// OnCollision is applied when a name is taken (default CollisionError).
+	OnCollision CollisionPolicy
+}
+
 // MCPTool wraps an MCP tool to implement the tools.Tool interface.
 type MCPTool struct {
 	client     *Client
-	info       ToolInfo
 	serverName string
+
+	// server routes calls through the server's current connection and
+	// health tracking. Nil for tools made with NewMCPTool.
+	server *MCPServer
+
+	mu   sync.RWMutex
+	info ToolInfo
+
+	// name overrides the namespaced name once registered under another.
+	name string
 }
 
 // NewMCPTool creates a new MCP tool wrapper.
@@ -24,24 +88,62 @@ func NewMCPTool(client *Client, info ToolInfo, serverName string) *MCPTool {
 	}
 }
 
-// Name returns the tool name prefixed with the server name.
+// Name returns the registered tool name, mcp__<server>__<tool> unless
+// registered with bare names.
 func (t *MCPTool) Name() string {
-	return fmt.Sprintf("mcp_%s_%s", t.serverName, t.info.Name)
+	if t.name != "" {
+		return t.name
+	}
+	return ToolName(t.serverName, t.info.Name)
+}
+
+// ServerName returns the name of the MCP server providing the tool.
+func (t *MCPTool) ServerName() string {
+	return t.serverName
+}
+
+// Server returns the MCPServer providing the tool, or nil for tools made
+// with NewMCPTool.
+func (t *MCPTool) Server() *MCPServer {
+	return t.server
+}
+
+// BareName returns the tool name reported by the MCP server.
+func (t *MCPTool) BareName() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.info.Name
 }
 
 // Description returns the tool description.
 func (t *MCPTool) Description() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.info.Description
 }
 
 // InputSchema returns the tool's input schema.
 func (t *MCPTool) InputSchema() map[string]any {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.info.InputSchema
 }
 
+func (t *MCPTool) setInfo(info ToolInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.info = info
+}
+
 // Execute calls the MCP tool and returns the result.
 func (t *MCPTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
-	result, err := t.client.CallTool(ctx, t.info.Name, input)
+	var result CallToolResult
+	var err error
+	if t.server != nil {
+		result, err = t.server.callTool(ctx, t.BareName(), input)
+	} else {
+		result, err = t.client.CallTool(ctx, t.BareName(), input)
+	}
 	if err != nil {
 		return tools.NewErrorResult(err), nil
 	}
@@ -63,15 +165,46 @@ func (t *MCPTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input
 	}, nil
 }
 
-// MCPServer manages an MCP server connection and its tools.
+// Dialer opens a new transport to an MCP server. MCPServer calls it again
+// to reconnect after the connection breaks.
+type Dialer func() (Transport, error)
+
+// MCPServer manages an MCP server connection and its tools. A broken
+// connection is reopened with backoff (see HealthConfig and Monitor), and
+// Status reports the connection's health.
 type MCPServer struct {
 	name      string
 	client    *Client
 	transport Transport
 	tools     []*MCPTool
+
+	dial   Dialer
+	health HealthConfig
+	now    func() time.Time
+
+	// dialMu serializes reconnects; mu guards everything below and the
+	// fields above that reconnects and refreshes replace.
+	dialMu sync.Mutex
+	mu     sync.Mutex
+
+	state       ServerState
+	lastErr     error
+	failures    int
+	attempts    int
+	retryAt     time.Time
+	reconnects  int
+	connectedAt time.Time
+	closed      bool
+	calls       map[string]*ToolCallStats
+
+	// registry and registerOpts are remembered from RegisterToolsWithOptions
+	// so tool refreshes can update the registered tools.
+	registry     *tools.Registry
+	registerOpts RegisterOptions
 }
 
-// NewMCPServer creates a new MCP server connection.
+// NewMCPServer creates a new MCP server connection that runs command over
+// stdio.
 func NewMCPServer(name, command string, args []string, env map[string]string, workDir string) (*MCPServer, error) {
 	// Build environment slice
 	var envSlice []string
@@ -79,41 +212,64 @@ func NewMCPServer(name, command string, args []string, env map[string]string, wo
 		envSlice = append(envSlice, k+"="+v)
 	}
 
-	transport, err := NewStdioTransport(command, args, envSlice, workDir)
+	return NewMCPServerWithDialer(name, func() (Transport, error) {
+		return NewStdioTransport(command, args, envSlice, workDir)
+	})
+}
+
+// NewMCPServerWithDialer creates a new MCP server connection over the
+// transport dial opens.
+func NewMCPServerWithDialer(name string, dial Dialer) (*MCPServer, error) {
+	transport, err := dial()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transport: %w", err)
 	}
 
-	client := NewClient(transport)
-
 	return &MCPServer{
 		name:      name,
-		client:    client,
+		client:    NewClient(transport),
 		transport: transport,
+		dial:      dial,
+		state:     StateConnecting,
 	}, nil
 }
 
-// Initialize initializes the server connection and loads tools.
+// Initialize initializes the server connection and loads tools. On failure
+// the server is down and Monitor retries the connection.
 func (s *MCPServer) Initialize(ctx context.Context) error {
-	if err := s.client.Initialize(ctx); err != nil {
-		return fmt.Errorf("failed to initialize MCP server %s: %w", s.name, err)
-	}
+	s.mu.Lock()
+	client := s.client
+	s.mu.Unlock()
 
-	toolInfos, err := s.client.ListTools(ctx)
+	infos, err := connectClient(ctx, client, s.name)
+	s.mu.Lock()
 	if err != nil {
-		return fmt.Errorf("failed to list tools from MCP server %s: %w", s.name, err)
+		s.markDown(err)
+		s.mu.Unlock()
+		return err
 	}
+	s.markReady()
+	s.mu.Unlock()
+	return s.setTools(infos)
+}
 
-	s.tools = make([]*MCPTool, len(toolInfos))
-	for i, info := range toolInfos {
-		s.tools[i] = NewMCPTool(s.client, info, s.name)
+// connectClient performs the initialization handshake and lists the tools.
+func connectClient(ctx context.Context, client *Client, name string) ([]ToolInfo, error) {
+	if err := client.Initialize(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize MCP server %s: %w", name, err)
 	}
 
-	return nil
+	toolInfos, err := client.ListTools(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools from MCP server %s: %w", name, err)
+	}
+	return toolInfos, nil
 }
 
 // Tools returns the MCP tools as tools.Tool interfaces.
 func (s *MCPServer) Tools() []tools.Tool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	result := make([]tools.Tool, len(s.tools))
 	for i, t := range s.tools {
 		result[i] = t
@@ -121,19 +277,115 @@ func (s *MCPServer) Tools() []tools.Tool {
 	return result
 }
 
-// RegisterTools registers all MCP tools with the given registry.
-func (s *MCPServer) RegisterTools(registry *tools.Registry) error {
+// setTools replaces the server's tools with infos, keeping the MCPTool of
+// every tool the server still offers, and updates the registry the tools
+// were registered with.
+func (s *MCPServer) setTools(infos []ToolInfo) error {
+	s.mu.Lock()
+	removed := make(map[string]*MCPTool, len(s.tools))
 	for _, t := range s.tools {
+		removed[t.BareName()] = t
+	}
+	next := make([]*MCPTool, 0, len(infos))
+	var added []*MCPTool
+	for _, info := range infos {
+		if t, ok := removed[info.Name]; ok {
+			delete(removed, info.Name)
+			t.setInfo(info)
+			next = append(next, t)
+			continue
+		}
+		t := NewMCPTool(s.client, info, s.name)
+		t.server = s
+		next = append(next, t)
+		added = append(added, t)
+	}
+	s.tools = next
+	registry, opts := s.registry, s.registerOpts
+	s.mu.Unlock()
+
+	if registry == nil {
+		return nil
+	}
+	for _, t := range removed {
+		if registry.Get(t.Name()) == t {
+			log.Printf("[mcp] tool %s is no longer offered by server %s", t.Name(), s.name)
+			registry.Unregister(t.Name())
+		}
+	}
+	return s.register(registry, opts, added)
+}
+
+// RegisterTools registers all MCP tools with the given registry under
+// their namespaced names.
+func (s *MCPServer) RegisterTools(registry *tools.Registry) error {
+	return s.RegisterToolsWithOptions(registry, RegisterOptions{})
+}
+
+// RegisterToolsWithOptions registers all MCP tools with the given registry,
+// resolving name collisions with opts.OnCollision. Tools the server adds or
+// drops later (see Refresh) are registered or unregistered the same way.
+func (s *MCPServer) RegisterToolsWithOptions(registry *tools.Registry, opts RegisterOptions) error {
+	if opts.OnCollision == "" {
+		opts.OnCollision = CollisionError
+	}
+	switch opts.OnCollision {
+	case CollisionError, CollisionPrefix, CollisionOverride:
+	default:
+		return fmt.Errorf("unknown MCP tool collision policy %q", opts.OnCollision)
+	}
+
+	s.mu.Lock()
+	s.registry, s.registerOpts = registry, opts
+	current := append([]*MCPTool(nil), s.tools...)
+	s.mu.Unlock()
+	return s.register(registry, opts, current)
+}
+
+// register adds toolList to registry under opts.
+func (s *MCPServer) register(registry *tools.Registry, opts RegisterOptions, toolList []*MCPTool) error {
+	policy := opts.OnCollision
+	for _, t := range toolList {
+		bare := t.BareName()
+		namespaced := ToolName(s.name, bare)
+		name := namespaced
+		if opts.BareNames {
+			name = bare
+		}
+		if registry.Has(name) {
+			switch {
+			case policy == CollisionOverride:
+				log.Printf("[mcp] tool %s from server %s overrides the registered tool", name, s.name)
+				registry.Unregister(name)
+			case policy == CollisionPrefix && name != namespaced:
+				log.Printf("[mcp] tool %s from server %s collides, registering as %s", name, s.name, namespaced)
+				name = namespaced
+			}
+		}
+		if name == namespaced {
+			t.name = ""
+		} else {
+			t.name = name
+		}
 		if err := registry.Register(t); err != nil {
-			return fmt.Errorf("failed to register MCP tool %s: %w", t.Name(), err)
+			return fmt.Errorf("failed to register MCP tool %s: %w", name, err)
 		}
 	}
 	return nil
 }
 
-// Close closes the server connection.
+// Close closes the server connection. A closed server is not reconnected.
 func (s *MCPServer) Close() error {
-	return s.client.Close()
+	s.mu.Lock()
+	s.closed = true
+	s.markDown(errServerClosed)
+	transport := s.transport
+	s.transport = nil
+	s.mu.Unlock()
+	if transport == nil {
+		return nil
+	}
+	return transport.Close()
 }
 
 // Name returns the server name.
@@ -143,5 +395,7 @@ func (s *MCPServer) Name() string {
 
 // ServerInfo returns the MCP server implementation info.
 func (s *MCPServer) ServerInfo() Implementation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.client.ServerInfo()
 }
@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+type builtinTool struct{ name string }
+
+func (t builtinTool) Name() string                { return t.name }
+func (t builtinTool) Description() string         { return "builtin" }
+func (t builtinTool) InputSchema() map[string]any { return map[string]any{} }
+func (t builtinTool) Execute(context.Context, *tools.ToolContext, map[string]any) (tools.ToolResult, error) {
+	return tools.NewToolResult("builtin"), nil
+}
+
+func testServer(name string, toolNames ...string) *MCPServer {
+	s := &MCPServer{name: name}
+	for _, toolName := range toolNames {
+		s.tools = append(s.tools, NewMCPTool(nil, ToolInfo{Name: toolName}, name))
+	}
+	return s
+}
+
+func TestToolNameRoundTrip(t *testing.T) {
+	name := ToolName("github", "search_issues")
+	if name != "mcp__github__search_issues" {
+		t.Fatalf("unexpected name %q", name)
+	}
+	server, tool, ok := ParseToolName(name)
+	if !ok || server != "github" || tool != "search_issues" {
+		t.Fatalf("ParseToolName(%q) = %q, %q, %v", name, server, tool, ok)
+	}
+	for _, bad := range []string{"read_file", "mcp__github", "mcp____x"} {
+		if _, _, ok := ParseToolName(bad); ok {
+			t.Fatalf("expected %q not to parse", bad)
+		}
+	}
+}
+
+func TestRegisterToolsUsesNamespacedNames(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(builtinTool{name: "read_file"})
+
+	if err := testServer("fs", "read_file").RegisterTools(registry); err != nil {
+		t.Fatalf("RegisterTools: %v", err)
+	}
+	if !registry.Has("mcp__fs__read_file") || registry.Get("read_file").Description() != "builtin" {
+		t.Fatalf("unexpected tools: %v", registry.Names())
+	}
+	if err := testServer("fs", "read_file").RegisterTools(registry); err == nil {
+		t.Fatal("expected duplicate server tools to fail")
+	}
+}
+
+func TestRegisterToolsWithOptionsCollisionPolicies(t *testing.T) {
+	tests := []struct {
+		policy    CollisionPolicy
+		wantErr   bool
+		wantNames string
+		wantMCP   string
+	}{
+		{policy: CollisionError, wantErr: true},
+		{policy: CollisionPrefix, wantNames: "mcp__fs__read_file,read_file,search", wantMCP: "mcp__fs__read_file"},
+		{policy: CollisionOverride, wantNames: "read_file,search", wantMCP: "read_file"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			registry := tools.NewRegistry()
+			registry.MustRegister(builtinTool{name: "read_file"})
+			server := testServer("fs", "search", "read_file")
+
+			err := server.RegisterToolsWithOptions(registry, RegisterOptions{BareNames: true, OnCollision: tt.policy})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected collision error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RegisterToolsWithOptions: %v", err)
+			}
+			if got := strings.Join(registry.Names(), ","); got != tt.wantNames {
+				t.Fatalf("unexpected tools %s, want %s", got, tt.wantNames)
+			}
+			if got := server.tools[1].Name(); got != tt.wantMCP {
+				t.Fatalf("MCP read_file registered as %q, want %q", got, tt.wantMCP)
+			}
+			if _, ok := registry.Get(tt.wantMCP).(*MCPTool); !ok {
+				t.Fatalf("expected %s to be the MCP tool", tt.wantMCP)
+			}
+		})
+	}
+}
+
+func TestRegisterToolsWithOptionsRejectsUnknownPolicy(t *testing.T) {
+	err := testServer("fs", "search").RegisterToolsWithOptions(tools.NewRegistry(), RegisterOptions{OnCollision: "merge"})
+	if err == nil {
+		t.Fatal("expected unknown policy error")
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+// builtinModels seeds the Default registry. Prices are list prices in USD
+// per million tokens.
+var builtinModels = []Model{
+	// Anthropic
+	{Name: "claude-opus-4-1", ContextWindow: 200_000, MaxOutputTokens: 32_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 15, OutputPerMillion: 75},
+	{Name: "claude-opus-4", ContextWindow: 200_000, MaxOutputTokens: 32_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 15, OutputPerMillion: 75},
+	{Name: "claude-sonnet-4-5", ContextWindow: 200_000, MaxOutputTokens: 64_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 3, OutputPerMillion: 15},
+	{Name: "claude-sonnet-4", ContextWindow: 200_000, MaxOutputTokens: 64_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 3, OutputPerMillion: 15},
+	{Name: "claude-haiku-4-5", ContextWindow: 200_000, MaxOutputTokens: 64_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 1, OutputPerMillion: 5},
+	{Name: "claude-3-7-sonnet", ContextWindow: 200_000, MaxOutputTokens: 64_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 3, OutputPerMillion: 15},
+	{Name: "claude-3-5-sonnet", ContextWindow: 200_000, MaxOutputTokens: 8_192, SupportsVision: true, SupportsTools: true, InputPerMillion: 3, OutputPerMillion: 15},
+	{Name: "claude-3-5-haiku", ContextWindow: 200_000, MaxOutputTokens: 8_192, SupportsTools: true, InputPerMillion: 0.8, OutputPerMillion: 4},
+	{Name: "claude-3-opus", ContextWindow: 200_000, MaxOutputTokens: 4_096, SupportsVision: true, SupportsTools: true, InputPerMillion: 15, OutputPerMillion: 75},
+	{Name: "claude-3-haiku", ContextWindow: 200_000, MaxOutputTokens: 4_096, SupportsVision: true, SupportsTools: true, InputPerMillion: 0.25, OutputPerMillion: 1.25},
+
+	// OpenAI
+	{Name: "gpt-5", ContextWindow: 400_000, MaxOutputTokens: 128_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 1.25, OutputPerMillion: 10},
+	{Name: "gpt-5-mini", ContextWindow: 400_000, MaxOutputTokens: 128_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 0.25, OutputPerMillion: 2},
+	{Name: "gpt-5-nano", ContextWindow: 400_000, MaxOutputTokens: 128_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 0.05, OutputPerMillion: 0.4},
+	{Name: "gpt-4.1", ContextWindow: 1_047_576, MaxOutputTokens: 32_768, SupportsVision: true, SupportsTools: true, InputPerMillion: 2, OutputPerMillion: 8},
+	{Name: "gpt-4.1-mini", ContextWindow: 1_047_576, MaxOutputTokens: 32_768, SupportsVision: true, SupportsTools: true, InputPerMillion: 0.4, OutputPerMillion: 1.6},
+	{Name: "gpt-4.1-nano", ContextWindow: 1_047_576, MaxOutputTokens: 32_768, SupportsVision: true, SupportsTools: true, InputPerMillion: 0.1, OutputPerMillion: 0.4},
+	{Name: "gpt-4o", ContextWindow: 128_000, MaxOutputTokens: 16_384, SupportsVision: true, SupportsTools: true, InputPerMillion: 2.5, OutputPerMillion: 10},
+	{Name: "gpt-4o-mini", ContextWindow: 128_000, MaxOutputTokens: 16_384, SupportsVision: true, SupportsTools: true, InputPerMillion: 0.15, OutputPerMillion: 0.6},
+	{Name: "gpt-4-turbo", ContextWindow: 128_000, MaxOutputTokens: 4_096, SupportsVision: true, SupportsTools: true, InputPerMillion: 10, OutputPerMillion: 30},
+	{Name: "gpt-4", ContextWindow: 8_192, MaxOutputTokens: 8_192, SupportsTools: true, InputPerMillion: 30, OutputPerMillion: 60},
+	{Name: "gpt-3.5-turbo", ContextWindow: 16_385, MaxOutputTokens: 4_096, SupportsTools: true, InputPerMillion: 0.5, OutputPerMillion: 1.5},
+	{Name: "o1", ContextWindow: 200_000, MaxOutputTokens: 100_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 15, OutputPerMillion: 60},
+	{Name: "o3", ContextWindow: 200_000, MaxOutputTokens: 100_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 2, OutputPerMillion: 8},
+	{Name: "o3-mini", ContextWindow: 200_000, MaxOutputTokens: 100_000, SupportsTools: true, InputPerMillion: 1.1, OutputPerMillion: 4.4},
+	{Name: "o4-mini", ContextWindow: 200_000, MaxOutputTokens: 100_000, SupportsVision: true, SupportsTools: true, InputPerMillion: 1.1, OutputPerMillion: 4.4},
+
+	// DeepSeek
+	{Name: "deepseek-chat", ContextWindow: 128_000, MaxOutputTokens: 8_192, SupportsTools: true, InputPerMillion: 0.27, OutputPerMillion: 1.1},
+	{Name: "deepseek-reasoner", ContextWindow: 128_000, MaxOutputTokens: 64_000, SupportsTools: true, InputPerMillion: 0.55, OutputPerMillion: 2.19},
+
+	// Moonshot
+	{Name: "kimi-k2", ContextWindow: 131_072, MaxOutputTokens: 16_384, SupportsTools: true},
+}
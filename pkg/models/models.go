@@ -0,0 +1,121 @@
+// Package models is a registry of known LLM models and their limits,
+// capabilities, and pricing, used to default configuration that would
+// otherwise have to be set by hand.
+package models
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Model describes a known model.
+type Model struct {
+	// Name is the canonical model name, e.g. "claude-sonnet-4".
+	Name string
+
+	// ContextWindow is the maximum number of input plus output tokens.
+	ContextWindow int
+
+	// MaxOutputTokens is the largest max_tokens the model accepts.
+	MaxOutputTokens int
+
+	SupportsVision bool
+	SupportsTools  bool
+
+	// InputPerMillion and OutputPerMillion are USD prices per million tokens.
+	// Zero means unknown.
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Cost returns the USD cost of the given token counts.
+func (m Model) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*m.InputPerMillion + float64(outputTokens)*m.OutputPerMillion) / 1_000_000
+}
+
+// Registry maps model names to models. It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	models map[string]Model
+}
+
+// NewRegistry creates a registry containing models.
+func NewRegistry(models ...Model) *Registry {
+	r := &Registry{models: make(map[string]Model)}
+	for _, m := range models {
+		r.Register(m)
+	}
+	return r
+}
+
+// Register adds or replaces a model.
+func (r *Registry) Register(m Model) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.models[normalize(m.Name)] = m
+}
+
+// Lookup finds the model for name. Matching is case-insensitive, ignores a
+// provider prefix ("anthropic/", "openai/"), and falls back to the longest
+// registered name that prefixes name at a "-", ":", or "@" boundary, so
+// dated snapshots such as "claude-sonnet-4-20250514" resolve to their family.
+func (r *Registry) Lookup(name string) (Model, bool) {
+	key := normalize(name)
+	if key == "" {
+		return Model{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if m, ok := r.models[key]; ok {
+		return m, true
+	}
+
+	var best string
+	for candidate := range r.models {
+		if len(candidate) <= len(best) || !strings.HasPrefix(key, candidate) {
+			continue
+		}
+		if strings.ContainsRune("-:@", rune(key[len(candidate)])) {
+			best = candidate
+		}
+	}
+	if best == "" {
+		return Model{}, false
+	}
+	return r.models[best], true
+}
+
+// List returns all registered models sorted by name.
+func (r *Registry) List() []Model {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Model, 0, len(r.models))
+	for _, m := range r.models {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// Default is the registry of built-in models used by Lookup and Register.
+var Default = NewRegistry(builtinModels...)
+
+// Lookup finds name in the Default registry.
+func Lookup(name string) (Model, bool) {
+	return Default.Lookup(name)
+}
+
+// Register adds or replaces a model in the Default registry.
+func Register(m Model) {
+	Default.Register(m)
+}
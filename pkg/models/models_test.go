@@ -0,0 +1,58 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		want   string
+		wantOK bool
+	}{
+		{"exact", "gpt-4o", "gpt-4o", true},
+		{"case insensitive", "GPT-4o-Mini", "gpt-4o-mini", true},
+		{"provider prefix", "anthropic/claude-sonnet-4", "claude-sonnet-4", true},
+		{"dated snapshot", "claude-sonnet-4-20250514", "claude-sonnet-4", true},
+		{"longest family wins", "claude-sonnet-4-5-20250929", "claude-sonnet-4-5", true},
+		{"openai snapshot", "gpt-4o-mini-2024-07-18", "gpt-4o-mini", true},
+		{"version suffix", "gpt-4.1-2025-04-14", "gpt-4.1", true},
+		{"tag suffix", "deepseek-chat:latest", "deepseek-chat", true},
+		{"no partial token match", "gpt-4oo", "", false},
+		{"unknown", "my-local-model", "", false},
+		{"empty", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Lookup(tt.query)
+			if ok != tt.wantOK || got.Name != tt.want {
+				t.Fatalf("Lookup(%q) = %q, %v; want %q, %v", tt.query, got.Name, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRegistryRegisterOverrides(t *testing.T) {
+	r := NewRegistry(Model{Name: "local-llm", ContextWindow: 8192})
+	r.Register(Model{Name: "Local-LLM", ContextWindow: 32768})
+
+	m, ok := r.Lookup("local-llm-q4")
+	if !ok || m.ContextWindow != 32768 {
+		t.Fatalf("expected re-registered model, got %+v, %v", m, ok)
+	}
+	if len(r.List()) != 1 {
+		t.Fatalf("expected one model, got %d", len(r.List()))
+	}
+}
+
+func TestModelCost(t *testing.T) {
+	m, ok := Lookup("claude-sonnet-4")
+	if !ok {
+		t.Fatal("expected claude-sonnet-4 to be registered")
+	}
+	if got := m.Cost(1_000_000, 100_000); math.Abs(got-4.5) > 1e-9 {
+		t.Fatalf("Cost() = %v, want 4.5", got)
+	}
+}
@@ -0,0 +1,240 @@
+// Package plugins bundles tools, skills, slash commands, and hooks into
+// installable units that are enabled per agent via AgentConfig.Plugins.
+//
+// A plugin is either built in Go (New with a Spec) or loaded from a
+// directory (Load):
+//
+//	github-automation/
+//	  plugin.json          {"name": "github-automation", "description": "..."}
+//	  skills/<skill>/SKILL.md
+//	  commands/<command>.md
+//
+// Directory plugins contribute skills and commands; Go code can add tools and
+// hooks to the Spec returned by LoadSpec before calling New.
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	agenttypes "github.com/MimeLyc/agent-core-go/pkg/agent/types"
+	"github.com/MimeLyc/agent-core-go/pkg/skills"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+const (
+	// ManifestFileName is the plugin manifest file in a plugin directory.
+	ManifestFileName = "plugin.json"
+
+	// DefaultSkillsDir is the manifest-relative skills directory.
+	DefaultSkillsDir = "skills"
+
+	// DefaultCommandsDir is the manifest-relative commands directory.
+	DefaultCommandsDir = "commands"
+
+	// commandFileExt is the extension of command prompt files.
+	commandFileExt = ".md"
+)
+
+// Plugin is an installable bundle of agent extensions.
+type Plugin interface {
+	// Name uniquely identifies the plugin within an agent.
+	Name() string
+
+	// Tools are registered in the agent's tool registry.
+	Tools() []tools.Tool
+
+	// SkillDirs are searched for SKILL.md files in addition to the defaults.
+	SkillDirs() []string
+
+	// Commands are user-invocable slash commands.
+	Commands() []Command
+
+	// Hooks observe the agent's executions.
+	Hooks() Hooks
+}
+
+// Command is a slash command: "/name args" in the task is replaced with the
+// rendered Prompt.
+type Command struct {
+	// Name is the command name without the leading slash.
+	Name string
+
+	// Description is a short human-readable summary.
+	Description string
+
+	// Prompt is the message template. $ARGUMENTS is replaced with the
+	// command arguments; they are appended when the placeholder is missing.
+	Prompt string
+}
+
+// Hooks are callbacks invoked alongside the request's AgentCallbacks.
+// Nil hooks are skipped.
+type Hooks struct {
+	// OnMessage is called when the agent produces a message.
+	OnMessage func(agenttypes.Message)
+
+	// OnToolCall is called when the agent invokes a tool.
+	OnToolCall func(name string, input map[string]any)
+
+	// OnToolResult is called when a tool returns a result.
+	OnToolResult func(name string, result tools.ToolResult)
+}
+
+// Spec describes a plugin's contributions.
+type Spec struct {
+	Name        string
+	Description string
+	Version     string
+
+	Tools     []tools.Tool
+	SkillDirs []string
+	Commands  []Command
+	Hooks     Hooks
+}
+
+// Manifest is the plugin.json file of a plugin directory.
+type Manifest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+
+	// Skills and Commands are directories relative to the manifest.
+	// Empty uses DefaultSkillsDir and DefaultCommandsDir.
+	Skills   string `json:"skills,omitempty"`
+	Commands string `json:"commands,omitempty"`
+}
+
+// New returns a plugin contributing exactly what spec lists.
+func New(spec Spec) (Plugin, error) {
+	if err := validateName(spec.Name); err != nil {
+		return nil, err
+	}
+	for _, cmd := range spec.Commands {
+		if err := validateName(cmd.Name); err != nil {
+			return nil, fmt.Errorf("plugin %s: command: %w", spec.Name, err)
+		}
+	}
+	return specPlugin{spec: spec}, nil
+}
+
+// Load reads the plugin directory dir. See LoadSpec.
+func Load(dir string) (Plugin, error) {
+	spec, err := LoadSpec(dir)
+	if err != nil {
+		return nil, err
+	}
+	return New(spec)
+}
+
+// LoadSpec reads the plugin directory dir: its manifest, skills directory,
+// and commands directory (one <name>.md prompt file per command, with
+// optional SKILL.md-style front matter). Missing directories are skipped.
+func LoadSpec(dir string) (Spec, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return Spec{}, fmt.Errorf("read plugin manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Spec{}, fmt.Errorf("parse plugin manifest %s: %w", filepath.Join(dir, ManifestFileName), err)
+	}
+	if err := validateName(manifest.Name); err != nil {
+		return Spec{}, fmt.Errorf("plugin manifest %s: %w", filepath.Join(dir, ManifestFileName), err)
+	}
+
+	spec := Spec{
+		Name:        manifest.Name,
+		Description: manifest.Description,
+		Version:     manifest.Version,
+	}
+
+	skillsDir := resolveDir(dir, manifest.Skills, DefaultSkillsDir)
+	if info, err := os.Stat(skillsDir); err == nil && info.IsDir() {
+		spec.SkillDirs = []string{skillsDir}
+	}
+
+	commands, err := loadCommands(resolveDir(dir, manifest.Commands, DefaultCommandsDir))
+	if err != nil {
+		return Spec{}, fmt.Errorf("plugin %s: %w", manifest.Name, err)
+	}
+	spec.Commands = commands
+	return spec, nil
+}
+
+type specPlugin struct {
+	spec Spec
+}
+
+func (p specPlugin) Name() string        { return p.spec.Name }
+func (p specPlugin) Tools() []tools.Tool { return p.spec.Tools }
+func (p specPlugin) SkillDirs() []string { return p.spec.SkillDirs }
+func (p specPlugin) Commands() []Command { return p.spec.Commands }
+func (p specPlugin) Hooks() Hooks        { return p.spec.Hooks }
+
+func resolveDir(base, dir, def string) string {
+	if strings.TrimSpace(dir) == "" {
+		dir = def
+	}
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir)
+	}
+	abs, err := filepath.Abs(filepath.Join(base, dir))
+	if err != nil {
+		return filepath.Join(base, dir)
+	}
+	return abs
+}
+
+func loadCommands(dir string) ([]Command, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read commands: %w", err)
+	}
+
+	var commands []Command
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != commandFileExt {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read command %s: %w", entry.Name(), err)
+		}
+		name, description, body := skills.ParseDocument(data)
+		if name == "" {
+			name = strings.TrimSuffix(entry.Name(), commandFileExt)
+		}
+		if err := validateName(name); err != nil {
+			return nil, fmt.Errorf("command %s: %w", entry.Name(), err)
+		}
+		commands = append(commands, Command{
+			Name:        name,
+			Description: description,
+			Prompt:      strings.TrimSpace(body),
+		})
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands, nil
+}
+
+// validateName accepts the names "/name" slash syntax can address.
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			continue
+		}
+		return fmt.Errorf("invalid name %q: use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
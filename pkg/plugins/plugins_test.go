@@ -0,0 +1,90 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadReadsManifestSkillsAndCommands(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ManifestFileName), `{"name": "github-automation", "version": "1.0.0"}`)
+	writeFile(t, filepath.Join(dir, "skills", "triage", "SKILL.md"), "---\nname: triage\n---\nTriage issues.")
+	writeFile(t, filepath.Join(dir, "commands", "review.md"), "---\ndescription: Review a pull request\n---\nReview PR $ARGUMENTS.")
+	writeFile(t, filepath.Join(dir, "commands", "release.md"), "Cut a release.")
+	writeFile(t, filepath.Join(dir, "commands", "notes.txt"), "ignored")
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if p.Name() != "github-automation" {
+		t.Fatalf("Name() = %q", p.Name())
+	}
+	if dirs := p.SkillDirs(); len(dirs) != 1 || !strings.HasSuffix(dirs[0], "skills") {
+		t.Fatalf("SkillDirs() = %v", dirs)
+	}
+
+	commands := p.Commands()
+	if len(commands) != 2 {
+		t.Fatalf("expected 2 commands, got %+v", commands)
+	}
+	if commands[0].Name != "release" || commands[0].Description != "Cut a release." {
+		t.Fatalf("unexpected first command: %+v", commands[0])
+	}
+	if commands[1].Name != "review" || commands[1].Description != "Review a pull request" || commands[1].Prompt != "Review PR $ARGUMENTS." {
+		t.Fatalf("unexpected second command: %+v", commands[1])
+	}
+}
+
+func TestLoadWithoutOptionalDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ManifestFileName), `{"name": "empty"}`)
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(p.SkillDirs()) != 0 || len(p.Commands()) != 0 || len(p.Tools()) != 0 {
+		t.Fatalf("expected no contributions, got skills=%v commands=%v", p.SkillDirs(), p.Commands())
+	}
+}
+
+func TestLoadRejectsInvalidPlugins(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		command  string
+	}{
+		{name: "missing manifest"},
+		{name: "malformed manifest", manifest: `{`},
+		{name: "missing name", manifest: `{}`},
+		{name: "invalid name", manifest: `{"name": "has space"}`},
+		{name: "invalid command name", manifest: `{"name": "ok"}`, command: "bad name.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.manifest != "" {
+				writeFile(t, filepath.Join(dir, ManifestFileName), tt.manifest)
+			}
+			if tt.command != "" {
+				writeFile(t, filepath.Join(dir, DefaultCommandsDir, tt.command), "prompt")
+			}
+			if _, err := Load(dir); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
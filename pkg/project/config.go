@@ -0,0 +1,221 @@
+// Package project loads the project configuration, .agents/config.yaml,
+// which describes a repository made of several roots, e.g. the services
+// and libraries of a monorepo. Each root can declare its own instruction
+// files, tool policy, and default skills; the orchestrator applies the
+// settings of the root the agent works in and opens every root to its
+// tools.
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the project configuration's path relative to the project
+// directory.
+const ConfigFile = ".agents/config.yaml"
+
+// Config is a project configuration. Project-level fields apply to every
+// root; a root's fields refine them.
+//
+//	instructions: [AGENTS.md]
+//	denied_tools: [web_fetch]
+//	roots:
+//	  - name: api
+//	    path: services/api
+//	    description: Go HTTP API
+//	    instructions: [AGENTS.md, CONTRIBUTING.md]
+//	    skills: [go-review]
+//	  - name: web
+//	    path: apps/web
+//	    allowed_tools: [read_file, write_file, bash]
+type Config struct {
+	// Dir is the directory holding .agents/config.yaml. Root paths are
+	// relative to it. Set by Find and Load.
+	Dir string `yaml:"-"`
+
+	Roots []Root `yaml:"roots"`
+
+	// Instructions are the instruction file names looked for from the
+	// repository root to the working directory (see
+	// instructions.LoadOptions.CandidateFiles). Empty uses the defaults.
+	Instructions []string `yaml:"instructions,omitempty"`
+
+	// AllowedTools and DeniedTools are allowed-tools patterns (see
+	// skills.IsToolAllowed). Empty AllowedTools allows every tool.
+	AllowedTools []string `yaml:"allowed_tools,omitempty"`
+	DeniedTools  []string `yaml:"denied_tools,omitempty"`
+
+	// Skills are skill names whose instructions are added to the system
+	// prompt of every run, instead of waiting for the model to invoke them.
+	Skills []string `yaml:"skills,omitempty"`
+}
+
+// Root is one part of the project, e.g. a service of a monorepo.
+type Root struct {
+	// Name identifies the root. Required and unique.
+	Name string `yaml:"name"`
+
+	// Path is the root's directory relative to the project directory.
+	// Required; it must not leave the project directory.
+	Path string `yaml:"path"`
+
+	// Description tells the model what the root contains.
+	Description string `yaml:"description,omitempty"`
+
+	// Instructions replaces the project's instruction file names while
+	// working in this root.
+	Instructions []string `yaml:"instructions,omitempty"`
+
+	// AllowedTools replaces the project's allowed tools while working in
+	// this root; DeniedTools and Skills add to the project's.
+	AllowedTools []string `yaml:"allowed_tools,omitempty"`
+	DeniedTools  []string `yaml:"denied_tools,omitempty"`
+	Skills       []string `yaml:"skills,omitempty"`
+}
+
+// Settings are the settings for working in one directory of the project.
+type Settings struct {
+	// Root is the root containing the directory, nil when none does.
+	Root *Root
+
+	Instructions []string
+	AllowedTools []string
+	DeniedTools  []string
+	Skills       []string
+}
+
+// Parse reads a project configuration from YAML (or JSON) and validates
+// it. dir is the project directory.
+func Parse(data []byte, dir string) (*Config, error) {
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("project: parse: %w", err)
+	}
+	cfg.Dir = filepath.Clean(dir)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Load reads and parses the project configuration of dir.
+func Load(dir string) (*Config, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ConfigFile)))
+	if err != nil {
+		return nil, fmt.Errorf("project: %w", err)
+	}
+	return Parse(data, dir)
+}
+
+// Find loads the project configuration of the nearest directory holding
+// one, from workDir up to the repository root (the first directory with
+// .git). It returns nil without an error when there is none.
+func Find(workDir string) (*Config, error) {
+	dir := filepath.Clean(workDir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(ConfigFile))); err == nil {
+			return Load(dir)
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return nil, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Validate checks that every root has a unique name and a path inside the
+// project directory.
+func (c *Config) Validate() error {
+	seen := make(map[string]bool, len(c.Roots))
+	for i, r := range c.Roots {
+		if strings.TrimSpace(r.Name) == "" {
+			return fmt.Errorf("project: root %d has no name", i+1)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("project: duplicate root %s", r.Name)
+		}
+		seen[r.Name] = true
+		if strings.TrimSpace(r.Path) == "" {
+			return fmt.Errorf("project: root %s has no path", r.Name)
+		}
+		if !filepath.IsLocal(filepath.FromSlash(r.Path)) {
+			return fmt.Errorf("project: root %s: path %s is outside the project directory", r.Name, r.Path)
+		}
+	}
+	return nil
+}
+
+// RootDir returns the absolute directory of r.
+func (c *Config) RootDir(r Root) string {
+	return filepath.Join(c.Dir, filepath.FromSlash(r.Path))
+}
+
+// RootFor returns the most specific root containing dir.
+func (c *Config) RootFor(dir string) (*Root, bool) {
+	dir = filepath.Clean(dir)
+	var best *Root
+	bestLen := -1
+	for i := range c.Roots {
+		rootDir := c.RootDir(c.Roots[i])
+		if !within(rootDir, dir) || len(rootDir) <= bestLen {
+			continue
+		}
+		best, bestLen = &c.Roots[i], len(rootDir)
+	}
+	return best, best != nil
+}
+
+// SettingsFor merges the project settings with those of the root
+// containing dir.
+func (c *Config) SettingsFor(dir string) Settings {
+	s := Settings{
+		Instructions: slices.Clone(c.Instructions),
+		AllowedTools: slices.Clone(c.AllowedTools),
+		DeniedTools:  slices.Clone(c.DeniedTools),
+		Skills:       slices.Clone(c.Skills),
+	}
+	root, ok := c.RootFor(dir)
+	if !ok {
+		return s
+	}
+	s.Root = root
+	if len(root.Instructions) > 0 {
+		s.Instructions = slices.Clone(root.Instructions)
+	}
+	if len(root.AllowedTools) > 0 {
+		s.AllowedTools = slices.Clone(root.AllowedTools)
+	}
+	s.DeniedTools = appendNew(s.DeniedTools, root.DeniedTools)
+	s.Skills = appendNew(s.Skills, root.Skills)
+	return s
+}
+
+// within reports whether path is dir or inside it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+func appendNew(list, items []string) []string {
+	for _, item := range items {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,104 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const sampleConfig = `
+instructions: [AGENTS.md]
+denied_tools: [web_fetch]
+skills: [style]
+roots:
+  - name: api
+    path: services/api
+    description: Go HTTP API
+    instructions: [AGENTS.md, CONTRIBUTING.md]
+    denied_tools: [bash]
+    skills: [go-review, style]
+  - name: web
+    path: apps/web
+    allowed_tools: [read_file]
+`
+
+func TestSettingsForMergesRootSettings(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Parse([]byte(sampleConfig), dir)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	api := cfg.SettingsFor(filepath.Join(dir, "services", "api", "internal"))
+	if api.Root == nil || api.Root.Name != "api" {
+		t.Fatalf("expected api root, got %#v", api.Root)
+	}
+	if !slices.Equal(api.Instructions, []string{"AGENTS.md", "CONTRIBUTING.md"}) {
+		t.Fatalf("unexpected instructions: %v", api.Instructions)
+	}
+	if !slices.Equal(api.DeniedTools, []string{"web_fetch", "bash"}) {
+		t.Fatalf("unexpected denied tools: %v", api.DeniedTools)
+	}
+	if !slices.Equal(api.Skills, []string{"style", "go-review"}) {
+		t.Fatalf("unexpected skills: %v", api.Skills)
+	}
+
+	web := cfg.SettingsFor(filepath.Join(dir, "apps", "web"))
+	if !slices.Equal(web.AllowedTools, []string{"read_file"}) || !slices.Equal(web.Instructions, []string{"AGENTS.md"}) {
+		t.Fatalf("unexpected web settings: %#v", web)
+	}
+
+	top := cfg.SettingsFor(dir)
+	if top.Root != nil || len(top.AllowedTools) != 0 || !slices.Equal(top.DeniedTools, []string{"web_fetch"}) {
+		t.Fatalf("unexpected project settings: %#v", top)
+	}
+}
+
+func TestParseRejectsInvalidRoots(t *testing.T) {
+	for name, data := range map[string]string{
+		"missing name": "roots:\n  - path: a\n",
+		"missing path": "roots:\n  - name: a\n",
+		"duplicate":    "roots:\n  - {name: a, path: a}\n  - {name: a, path: b}\n",
+		"escapes":      "roots:\n  - {name: a, path: ../a}\n",
+		"unknown key":  "rootz: []\n",
+	} {
+		if _, err := Parse([]byte(data), t.TempDir()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFindStopsAtRepositoryRoot(t *testing.T) {
+	repo := t.TempDir()
+	leaf := filepath.Join(repo, "services", "api")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(leaf, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Find(leaf)
+	if err != nil || cfg != nil {
+		t.Fatalf("expected no config, got %#v, %v", cfg, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(repo, ".agents"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, filepath.FromSlash(ConfigFile)), []byte(sampleConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Find(leaf)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if cfg == nil || cfg.Dir != repo || len(cfg.Roots) != 2 {
+		t.Fatalf("unexpected config: %#v", cfg)
+	}
+	if got := cfg.RootDir(cfg.Roots[0]); !strings.HasSuffix(got, filepath.Join("services", "api")) {
+		t.Fatalf("unexpected root dir: %s", got)
+	}
+}
@@ -0,0 +1,272 @@
+// Package redact masks secrets (API keys, tokens, private keys) in text that
+// flows into model context, logs, and other output.
+package redact
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Rule is a named secret pattern.
+// If Pattern has capture groups, only the first group that matched is
+// masked; otherwise the whole match is masked.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+
+	// Verify, if set, reports whether a matched value is a secret. Values
+	// it rejects are left as they are.
+	Verify func(value string) bool
+}
+
+// Config configures a Redactor.
+type Config struct {
+	// Rules are additional patterns applied after the defaults.
+	Rules []Rule
+
+	// Allowlist contains literal values that must never be masked,
+	// e.g. well-known test keys or placeholder tokens.
+	Allowlist []string
+
+	// DisableDefaults skips DefaultRules and uses only Rules.
+	DisableDefaults bool
+}
+
+// Redactor masks secrets in text. A nil *Redactor is valid and performs no redaction.
+type Redactor struct {
+	rules     []Rule
+	allowlist map[string]bool
+
+	mu      sync.RWMutex
+	secrets map[string]*secretValue // literal value -> registration
+}
+
+type secretValue struct {
+	name string
+	refs int
+}
+
+// minSecretLength is the shortest literal secret that is masked; shorter
+// values would mask unrelated text.
+const minSecretLength = 4
+
+// DefaultRules returns the built-in secret patterns.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "private_key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+		{Name: "anthropic_key", Pattern: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{20,}`)},
+		{Name: "openai_key", Pattern: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_\-]{20,}`)},
+		{Name: "github_token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+		{Name: "aws_access_key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+		{Name: "slack_token", Pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9\-]{10,}`)},
+		{Name: "bearer_token", Pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9_\-\.=]{16,})`)},
+		// Assignments such as DB_PASSWORD=... or apiKey: "...". Quoted
+		// values are literals; unquoted ones must end the value, so calls
+		// such as getenv("API_KEY") in source code are left alone.
+		{Name: "secret_assignment", Pattern: regexp.MustCompile(secretAssignmentName + `(?:"([^\s"]{8,})"|'([^\s']{8,})')`), Verify: isQuotedSecret},
+		{Name: "secret_assignment", Pattern: regexp.MustCompile(secretAssignmentName + `([^\s"'(){}\[\],;]{8,})(?:[\s,;]|$)`), Verify: isUnquotedSecret},
+	}
+}
+
+// secretAssignmentName matches a key naming a secret and the assignment
+// operator that follows it.
+const secretAssignmentName = `(?i)\b[A-Z0-9_]*(?:API_KEY|APIKEY|SECRET|TOKEN|PASSWORD|PASSWD)[A-Z0-9_]*["']?\s*(?::=|[:=])\s*`
+
+var (
+	// envNamePattern matches environment variable names such as
+	// LLM_API_KEY, which configuration refers to by name.
+	envNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+	// selectorPattern matches identifiers and selectors such as
+	// cfg.APIToken.
+	selectorPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)+$`)
+)
+
+// isQuotedSecret rejects quoted values that refer to a secret instead of
+// holding it: variable references and environment variable names.
+func isQuotedSecret(value string) bool {
+	return !strings.ContainsAny(value[:1], "$%") && !strings.Contains(value, "${") && !envNamePattern.MatchString(value)
+}
+
+// isUnquotedSecret accepts unquoted values that are not references,
+// environment variable names, or selectors. The key already names a secret,
+// so the value's characters do not matter: a letters-only password is
+// still a password.
+func isUnquotedSecret(value string) bool {
+	return isQuotedSecret(value) && !selectorPattern.MatchString(value)
+}
+
+// New creates a Redactor from cfg.
+func New(cfg Config) *Redactor {
+	r := &Redactor{allowlist: make(map[string]bool, len(cfg.Allowlist))}
+	if !cfg.DisableDefaults {
+		r.rules = append(r.rules, DefaultRules()...)
+	}
+	for _, rule := range cfg.Rules {
+		if rule.Pattern != nil {
+			r.rules = append(r.rules, rule)
+		}
+	}
+	for _, value := range cfg.Allowlist {
+		if value = strings.TrimSpace(value); value != "" {
+			r.allowlist[value] = true
+		}
+	}
+	return r
+}
+
+// AddSecrets registers literal secret values (e.g. request-scoped tokens)
+// that are masked as [REDACTED:<name>] in addition to the pattern rules.
+// The returned function unregisters them; registrations are reference
+// counted so concurrent runs can share a value.
+func (r *Redactor) AddSecrets(name string, values ...string) (remove func()) {
+	if r == nil {
+		return func() {}
+	}
+	var added []string
+	r.mu.Lock()
+	if r.secrets == nil {
+		r.secrets = make(map[string]*secretValue)
+	}
+	for _, value := range values {
+		if len(value) < minSecretLength || r.allowlist[value] {
+			continue
+		}
+		if sv, ok := r.secrets[value]; ok {
+			sv.refs++
+		} else {
+			r.secrets[value] = &secretValue{name: name, refs: 1}
+		}
+		added = append(added, value)
+	}
+	r.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			for _, value := range added {
+				if sv := r.secrets[value]; sv != nil {
+					if sv.refs--; sv.refs <= 0 {
+						delete(r.secrets, value)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Redact returns s with every detected secret replaced by [REDACTED:<rule>].
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	s = r.redactSecrets(s)
+	for _, rule := range r.rules {
+		s = r.apply(rule, s)
+	}
+	return s
+}
+
+// RedactMap returns a copy of m with every string value (recursively) redacted.
+func (r *Redactor) RedactMap(m map[string]any) map[string]any {
+	if r == nil || m == nil {
+		return m
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = r.redactValue(v)
+	}
+	return out
+}
+
+func (r *Redactor) redactValue(v any) any {
+	switch val := v.(type) {
+	case string:
+		return r.Redact(val)
+	case map[string]any:
+		return r.RedactMap(val)
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = r.redactValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) redactSecrets(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.secrets) == 0 {
+		return s
+	}
+	// Replace longer values first so a secret containing another is masked whole.
+	values := make([]string, 0, len(r.secrets))
+	for value := range r.secrets {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, value := range values {
+		s = strings.ReplaceAll(s, value, "[REDACTED:"+r.secrets[value].name+"]")
+	}
+	return s
+}
+
+func (r *Redactor) apply(rule Rule, s string) string {
+	matches := rule.Pattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		for g := 2; g+1 < len(m); g += 2 {
+			if m[g] >= 0 {
+				start, end = m[g], m[g+1]
+				break
+			}
+		}
+		value := s[start:end]
+		if start < last || r.allowlist[value] || strings.HasPrefix(value, "[REDACTED:") || (rule.Verify != nil && !rule.Verify(value)) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString("[REDACTED:" + rule.Name + "]")
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// NewWriter wraps w so that everything written through it is redacted.
+// It is intended for line-oriented output such as the standard logger.
+func NewWriter(w io.Writer, r *Redactor) io.Writer {
+	if r == nil {
+		return w
+	}
+	return &writer{w: w, r: r}
+}
+
+type writer struct {
+	mu sync.Mutex
+	w  io.Writer
+	r  *Redactor
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := io.WriteString(w.w, w.r.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
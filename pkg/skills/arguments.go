@@ -0,0 +1,327 @@
+package skills
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArgumentType is the type of a declared skill argument.
+type ArgumentType string
+
+const (
+	ArgumentString  ArgumentType = "string"
+	ArgumentInteger ArgumentType = "integer"
+	ArgumentNumber  ArgumentType = "number"
+	ArgumentBoolean ArgumentType = "boolean"
+)
+
+// Argument is one named parameter declared in a skill's arguments
+// frontmatter:
+//
+//	arguments:
+//	  - env: string, required, Environment to deploy to
+//	  - replicas: integer
+//	  - dry-run: boolean, Only print the plan
+//
+// Each item is "name: type", optionally followed by "required" and a
+// description. The type defaults to string.
+type Argument struct {
+	Name        string
+	Type        ArgumentType
+	Required    bool
+	Description string
+}
+
+// Placeholder returns the body placeholder the argument's value replaces,
+// e.g. ${ARG_DRY_RUN} for "dry-run".
+func (a Argument) Placeholder() string {
+	return "${ARG_" + strings.ToUpper(strings.ReplaceAll(a.Name, "-", "_")) + "}"
+}
+
+// parseArgumentItem parses one arguments list item.
+func parseArgumentItem(item string) (Argument, bool) {
+	name, spec, _ := strings.Cut(item, ":")
+	arg := Argument{Name: strings.TrimSpace(name), Type: ArgumentString}
+	if arg.Name == "" {
+		return Argument{}, false
+	}
+	parts := strings.Split(spec, ",")
+	if t := ArgumentType(strings.ToLower(strings.TrimSpace(parts[0]))); t != "" {
+		switch t {
+		case ArgumentString, ArgumentInteger, ArgumentNumber, ArgumentBoolean:
+			arg.Type = t
+		default:
+			return Argument{}, false
+		}
+	}
+	rest := parts[1:]
+	if len(rest) > 0 && strings.EqualFold(strings.TrimSpace(rest[0]), "required") {
+		arg.Required = true
+		rest = rest[1:]
+	}
+	arg.Description = strings.TrimSpace(strings.Join(rest, ","))
+	return arg, true
+}
+
+// FormatArguments renders a schema for prompts and tool output, e.g.
+// "env (string, required), replicas (integer)".
+func FormatArguments(schema []Argument) string {
+	parts := make([]string, len(schema))
+	for i, arg := range schema {
+		attrs := string(arg.Type)
+		if arg.Required {
+			attrs += ", required"
+		}
+		parts[i] = fmt.Sprintf("%s (%s)", arg.Name, attrs)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseArguments validates raw invocation arguments against schema and
+// returns the typed values by argument name. raw is either a JSON object or
+// space-separated name=value pairs; bare values fill the arguments not yet
+// set, in schema order. Quotes group values that contain spaces. A nil
+// schema accepts any text and returns nil.
+func ParseArguments(schema []Argument, raw string) (map[string]any, error) {
+	if len(schema) == 0 {
+		return nil, nil
+	}
+	raw = strings.TrimSpace(raw)
+
+	byName := make(map[string]Argument, len(schema))
+	for _, arg := range schema {
+		byName[arg.Name] = arg
+	}
+	values := make(map[string]any)
+	set := func(name string, value any) error {
+		arg, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("unknown argument %q (expected %s)", name, FormatArguments(schema))
+		}
+		if _, dup := values[name]; dup {
+			return fmt.Errorf("argument %q given more than once", name)
+		}
+		converted, err := convertArgument(arg, value)
+		if err != nil {
+			return err
+		}
+		values[name] = converted
+		return nil
+	}
+
+	if strings.HasPrefix(raw, "{") {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+			return nil, fmt.Errorf("arguments are not a valid JSON object: %w", err)
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := set(name, obj[name]); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		tokens, err := splitArguments(raw)
+		if err != nil {
+			return nil, err
+		}
+		var positional []string
+		for _, token := range tokens {
+			name, value, ok := strings.Cut(token, "=")
+			if !ok || !isArgumentName(name) {
+				positional = append(positional, token)
+				continue
+			}
+			if err := set(name, value); err != nil {
+				return nil, err
+			}
+		}
+		for _, arg := range schema {
+			if len(positional) == 0 {
+				break
+			}
+			if _, ok := values[arg.Name]; ok {
+				continue
+			}
+			if err := set(arg.Name, positional[0]); err != nil {
+				return nil, err
+			}
+			positional = positional[1:]
+		}
+		if len(positional) > 0 {
+			return nil, fmt.Errorf("unexpected argument %q (expected %s)", positional[0], FormatArguments(schema))
+		}
+	}
+
+	for _, arg := range schema {
+		if _, ok := values[arg.Name]; !ok && arg.Required {
+			return nil, fmt.Errorf("missing required argument %q", arg.Name)
+		}
+	}
+	return values, nil
+}
+
+// isArgumentName reports whether s looks like an argument name, so that
+// "name=value" tokens with an unknown name are rejected while values such
+// as URLs that contain "=" are still taken as positional.
+func isArgumentName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func convertArgument(arg Argument, value any) (any, error) {
+	text, isText := value.(string)
+	switch arg.Type {
+	case ArgumentInteger:
+		switch v := value.(type) {
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v), nil
+			}
+		case string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return n, nil
+			}
+		}
+	case ArgumentNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if n, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return n, nil
+			}
+		}
+	case ArgumentBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, ok := parseBool(v); ok {
+				return b, nil
+			}
+		}
+	default:
+		if isText {
+			return text, nil
+		}
+		switch value.(type) {
+		case float64, bool:
+			return fmt.Sprint(value), nil
+		}
+	}
+	return nil, fmt.Errorf("argument %q must be %s, got %v", arg.Name, articleFor(arg.Type), value)
+}
+
+func articleFor(t ArgumentType) string {
+	if t == ArgumentInteger {
+		return "an integer"
+	}
+	return "a " + string(t)
+}
+
+// splitArguments splits raw on whitespace, keeping quoted runs together
+// and removing the quotes.
+func splitArguments(raw string) ([]string, error) {
+	var (
+		tokens  []string
+		current strings.Builder
+		quote   rune
+		inToken bool
+	)
+	for _, r := range raw {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in arguments")
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// substituteArguments replaces each argument's placeholder with its value.
+// Optional arguments that were not given become empty. It reports whether
+// the body had any placeholder.
+func substituteArguments(body string, schema []Argument, values map[string]any) (string, bool) {
+	used := false
+	for _, arg := range schema {
+		used = used || strings.Contains(body, arg.Placeholder())
+		var text string
+		if v, ok := values[arg.Name]; ok {
+			text = fmt.Sprint(v)
+		}
+		body = strings.ReplaceAll(body, arg.Placeholder(), text)
+	}
+	return body, used
+}
+
+// ArgumentsSchema returns a JSON schema for the arguments as a tool input
+// object, with one typed property per argument. A skill without declared
+// arguments gets a single free-text "arguments" property.
+func ArgumentsSchema(schema []Argument) map[string]any {
+	if len(schema) == 0 {
+		return map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"arguments": map[string]any{
+					"type":        "string",
+					"description": "Optional arguments passed to the skill ($ARGUMENTS)",
+				},
+			},
+		}
+	}
+	properties := make(map[string]any, len(schema))
+	required := []string{}
+	for _, arg := range schema {
+		property := map[string]any{"type": string(arg.Type)}
+		if arg.Description != "" {
+			property["description"] = arg.Description
+		}
+		properties[arg.Name] = property
+		if arg.Required {
+			required = append(required, arg.Name)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
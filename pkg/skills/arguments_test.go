@@ -0,0 +1,132 @@
+package skills
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var deploySchema = []Argument{
+	{Name: "env", Type: ArgumentString, Required: true},
+	{Name: "replicas", Type: ArgumentInteger},
+	{Name: "dry-run", Type: ArgumentBoolean},
+}
+
+func TestParseArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]any
+		wantErr string
+	}{
+		{name: "pairs", raw: `env=prod replicas=3 dry-run=yes`, want: map[string]any{"env": "prod", "replicas": int64(3), "dry-run": true}},
+		{name: "json", raw: `{"env":"prod","replicas":2}`, want: map[string]any{"env": "prod", "replicas": int64(2)}},
+		{name: "positional", raw: `staging 4`, want: map[string]any{"env": "staging", "replicas": int64(4)}},
+		{name: "quoted", raw: `env="us east"`, want: map[string]any{"env": "us east"}},
+		{name: "missing required", raw: `replicas=1`, wantErr: `missing required argument "env"`},
+		{name: "unknown", raw: `env=prod region=eu`, wantErr: `unknown argument "region"`},
+		{name: "bad type", raw: `env=prod replicas=many`, wantErr: `"replicas" must be an integer`},
+		{name: "fractional json", raw: `{"env":"prod","replicas":1.5}`, wantErr: `"replicas" must be an integer`},
+		{name: "too many", raw: `prod 2 true extra`, wantErr: `unexpected argument "extra"`},
+		{name: "unterminated", raw: `env="prod`, wantErr: "unterminated quote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseArguments(deploySchema, tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseArguments() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseArguments() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if got, err := ParseArguments(nil, "anything goes"); got != nil || err != nil {
+		t.Fatalf("expected free text to be accepted without a schema, got %v, %v", got, err)
+	}
+}
+
+func TestDiscoverParsesArgumentSchema(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "deploy", "SKILL.md"), `---
+name: deploy
+arguments:
+  - env: string, required, Environment to deploy to, e.g. prod
+  - replicas: integer
+  - dry-run: boolean
+  - broken: duration
+---
+`)
+	skills, err := Discover([]string{root})
+	if err != nil || len(skills) != 1 {
+		t.Fatalf("Discover() = %v, %v", skills, err)
+	}
+	want := append([]Argument(nil), deploySchema...)
+	want[0].Description = "Environment to deploy to, e.g. prod"
+	if !reflect.DeepEqual(skills[0].Arguments, want) {
+		t.Fatalf("Arguments = %+v, want %+v", skills[0].Arguments, want)
+	}
+	block := BuildPromptBlock(skills, 0)
+	if !strings.Contains(block.Content, "arguments: env (string, required), replicas (integer), dry-run (boolean))") {
+		t.Fatalf("expected the argument schema in the prompt block, got %q", block.Content)
+	}
+}
+
+func TestRenderForInvocationSubstitutesNamedArguments(t *testing.T) {
+	root := t.TempDir()
+	skillPath := filepath.Join(root, "deploy", "SKILL.md")
+	mustWrite(t, skillPath, `---
+name: deploy
+---
+Deploy to ${ARG_ENV} with ${ARG_REPLICAS} replicas (dry run: ${ARG_DRY_RUN}).`)
+	skill := Skill{Name: "deploy", Path: skillPath, Arguments: deploySchema}
+
+	content, _, err := RenderForInvocation(skill, "env=prod dry-run=true", "", 4096)
+	if err != nil {
+		t.Fatalf("RenderForInvocation() error = %v", err)
+	}
+	if content != "Deploy to prod with  replicas (dry run: true)." {
+		t.Fatalf("unexpected render: %q", content)
+	}
+
+	if _, _, err := RenderForInvocation(skill, "replicas=2", "", 4096); err == nil {
+		t.Fatal("expected missing required argument to fail")
+	}
+}
+
+func TestArgumentsSchema(t *testing.T) {
+	schema := ArgumentsSchema(deploySchema)
+	properties := schema["properties"].(map[string]any)
+	if len(properties) != 3 || properties["replicas"].(map[string]any)["type"] != "integer" {
+		t.Fatalf("unexpected properties: %v", properties)
+	}
+	if !reflect.DeepEqual(schema["required"], []string{"env"}) {
+		t.Fatalf("required = %v", schema["required"])
+	}
+	if _, ok := ArgumentsSchema(nil)["properties"].(map[string]any)["arguments"]; !ok {
+		t.Fatal("expected a free-text arguments property without a schema")
+	}
+}
+
+func TestToolName(t *testing.T) {
+	for in, want := range map[string]string{
+		"deploy":        "skill__deploy",
+		"release notes": "skill__release_notes",
+		"ops/rollback":  "skill__ops_rollback",
+	} {
+		if got := ToolName(in); got != want {
+			t.Errorf("ToolName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := ToolName(strings.Repeat("x", 100)); len(got) != 64 {
+		t.Errorf("expected names capped at 64 bytes, got %d", len(got))
+	}
+}
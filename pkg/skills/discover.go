@@ -0,0 +1,269 @@
+package skills
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDiscoverMaxDepth is how many directory levels below a search
+	// root are scanned for SKILL.md files.
+	DefaultDiscoverMaxDepth = 6
+	// DefaultDiscoverRootTimeout bounds the scan of one search root.
+	DefaultDiscoverRootTimeout = 5 * time.Second
+	// DefaultDiscoverConcurrency is how many search roots are scanned at once.
+	DefaultDiscoverConcurrency = 4
+)
+
+// statRoot is replaced in tests to simulate an unresponsive mount.
+var statRoot = os.Stat
+
+// DiscoverOptions bounds skill discovery so that a slow or unreachable
+// search root, such as an NFS home directory, cannot hang the caller.
+type DiscoverOptions struct {
+	// MaxDepth limits how many directory levels below a root are scanned.
+	// If <= 0, DefaultDiscoverMaxDepth is used.
+	MaxDepth int
+
+	// RootTimeout bounds the scan of each root. A root that does not finish
+	// in time is skipped. If 0, DefaultDiscoverRootTimeout is used; a
+	// negative value disables the timeout.
+	RootTimeout time.Duration
+
+	// Concurrency is how many roots are scanned in parallel.
+	// If <= 0, DefaultDiscoverConcurrency is used.
+	Concurrency int
+}
+
+func (o DiscoverOptions) maxDepth() int {
+	if o.MaxDepth <= 0 {
+		return DefaultDiscoverMaxDepth
+	}
+	return o.MaxDepth
+}
+
+func (o DiscoverOptions) rootTimeout() time.Duration {
+	if o.RootTimeout == 0 {
+		return DefaultDiscoverRootTimeout
+	}
+	return o.RootTimeout
+}
+
+func (o DiscoverOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return DefaultDiscoverConcurrency
+	}
+	return o.Concurrency
+}
+
+// SkippedRoot is a search root whose skills were not loaded.
+type SkippedRoot struct {
+	Path   string
+	Reason string
+}
+
+// DiscoverResult is the output of DiscoverContext.
+type DiscoverResult struct {
+	// Skills are sorted by name, then path.
+	Skills []Skill
+
+	// Skipped lists roots that timed out or could not be read, in search
+	// order. Roots that do not exist are not listed.
+	Skipped []SkippedRoot
+
+	// Watched lists the roots, the directories scanned below them, and the
+	// SKILL.md files found. Adding or removing a skill changes the
+	// modification time of one of the directories.
+	Watched []string
+}
+
+// FormatSkipped renders skipped roots for logs and tool output, e.g.
+// "/home/me/.claude/skills (timed out after 5s)".
+func FormatSkipped(skipped []SkippedRoot) string {
+	parts := make([]string, len(skipped))
+	for i, s := range skipped {
+		parts[i] = fmt.Sprintf("%s (%s)", filepath.ToSlash(s.Path), s.Reason)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Discover scans search directories recursively and returns discovered skills.
+// It uses the default DiscoverOptions; roots that time out are left out.
+func Discover(searchDirs []string) ([]Skill, error) {
+	return DiscoverContext(context.Background(), searchDirs, DiscoverOptions{}).Skills, nil
+}
+
+// DiscoverContext scans search directories in parallel and returns the
+// discovered skills together with the roots it had to skip. Each root is
+// scanned under its own timeout; when ctx is done, roots still being
+// scanned are skipped. Results are merged in search order, so a skill
+// reachable from two roots is attributed to the first.
+func DiscoverContext(ctx context.Context, searchDirs []string, opts DiscoverOptions) DiscoverResult {
+	dirs := normalizePaths(searchDirs)
+	scans := make([]rootScan, len(dirs))
+	sem := make(chan struct{}, opts.concurrency())
+
+	var wg sync.WaitGroup
+	for idx, root := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				scans[idx] = rootScan{err: ctx.Err()}
+				return
+			}
+			defer func() { <-sem }()
+			scans[idx] = scanRootWithTimeout(ctx, root, idx, opts)
+		}()
+	}
+	wg.Wait()
+
+	result := DiscoverResult{Watched: append([]string(nil), dirs...)}
+	seenPaths := make(map[string]struct{})
+	out := make([]Skill, 0)
+	for idx, scan := range scans {
+		result.Watched = append(result.Watched, scan.watched...)
+		if scan.err != nil {
+			result.Skipped = append(result.Skipped, SkippedRoot{
+				Path:   dirs[idx],
+				Reason: skipReason(ctx, scan.err, opts.rootTimeout()),
+			})
+			continue
+		}
+		for i, skill := range scan.skills {
+			if _, ok := seenPaths[scan.resolved[i]]; ok {
+				continue
+			}
+			seenPaths[scan.resolved[i]] = struct{}{}
+			out = append(out, skill)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name == out[j].Name {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Name < out[j].Name
+	})
+	result.Skills = out
+	return result
+}
+
+// rootScan holds the skills found under one root and their resolved
+// SKILL.md paths, used to dedupe across roots.
+type rootScan struct {
+	skills   []Skill
+	resolved []string
+	watched  []string
+	err      error
+}
+
+// scanRootWithTimeout scans root and stops waiting when the root's timeout
+// expires or ctx is done. A walk that is stuck in a system call cannot be
+// interrupted; it finishes in the background and its result is discarded.
+func scanRootWithTimeout(ctx context.Context, root string, idx int, opts DiscoverOptions) rootScan {
+	if timeout := opts.rootTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan rootScan, 1)
+	go func() { done <- scanRoot(ctx, root, idx, opts.maxDepth()) }()
+	select {
+	case scan := <-done:
+		return scan
+	case <-ctx.Done():
+		return rootScan{err: ctx.Err()}
+	}
+}
+
+func scanRoot(ctx context.Context, root string, idx int, maxDepth int) rootScan {
+	info, err := statRoot(root)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return rootScan{}
+		}
+		return rootScan{err: err}
+	}
+	if !info.IsDir() {
+		return rootScan{}
+	}
+	scope := classifyScope(root)
+
+	var scan rootScan
+	seen := make(map[string]struct{})
+	walkErr := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if walkErr != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && pathDepth(root, path) > maxDepth {
+				return filepath.SkipDir
+			}
+			if path != root {
+				scan.watched = append(scan.watched, path)
+			}
+			return nil
+		}
+		if d.Name() != SkillFileName {
+			return nil
+		}
+		scan.watched = append(scan.watched, path)
+
+		resolved := filepath.Clean(path)
+		if rp, err := filepath.EvalSymlinks(path); err == nil {
+			resolved = filepath.Clean(rp)
+		}
+		if _, ok := seen[resolved]; ok {
+			return nil
+		}
+
+		skill, err := parseSkill(path, root, idx, scope)
+		if err != nil {
+			return nil
+		}
+		seen[resolved] = struct{}{}
+		scan.skills = append(scan.skills, skill)
+		scan.resolved = append(scan.resolved, resolved)
+		return nil
+	})
+	if walkErr != nil {
+		return rootScan{err: walkErr}
+	}
+	return scan
+}
+
+// pathDepth returns how many directory levels path is below root.
+func pathDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+func skipReason(ctx context.Context, err error, timeout time.Duration) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		return fmt.Sprintf("timed out after %s", timeout)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline exceeded"
+	}
+	return err.Error()
+}
@@ -0,0 +1,71 @@
+package skills
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiscoverContextSkipsUnresponsiveRoot(t *testing.T) {
+	fast := t.TempDir()
+	slow := t.TempDir()
+	mustWrite(t, filepath.Join(fast, "alpha", "SKILL.md"), "---\nname: alpha\n---\nAlpha")
+	mustWrite(t, filepath.Join(slow, "beta", "SKILL.md"), "---\nname: beta\n---\nBeta")
+
+	release, released := make(chan struct{}), make(chan struct{})
+	statRoot = func(path string) (os.FileInfo, error) {
+		if path == slow {
+			<-release
+			defer close(released)
+		}
+		return os.Stat(path)
+	}
+	defer func() {
+		close(release)
+		<-released
+		statRoot = os.Stat
+	}()
+
+	result := DiscoverContext(context.Background(), []string{slow, fast}, DiscoverOptions{RootTimeout: 50 * time.Millisecond})
+	if len(result.Skills) != 1 || result.Skills[0].Name != "alpha" {
+		t.Fatalf("expected only alpha, got %+v", result.Skills)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != slow || result.Skipped[0].Reason != "timed out after 50ms" {
+		t.Fatalf("unexpected skipped roots: %+v", result.Skipped)
+	}
+}
+
+func TestDiscoverContextCanceled(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "alpha", "SKILL.md"), "---\nname: alpha\n---\nAlpha")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := DiscoverContext(ctx, []string{root, filepath.Join(root, "missing")}, DiscoverOptions{})
+	if len(result.Skills) != 0 {
+		t.Fatalf("expected no skills, got %+v", result.Skills)
+	}
+	if len(result.Skipped) != 2 || result.Skipped[0].Reason != "canceled" {
+		t.Fatalf("unexpected skipped roots: %+v", result.Skipped)
+	}
+}
+
+func TestDiscoverContextLimitsDepth(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "a", "SKILL.md"), "---\nname: shallow\n---\nShallow")
+	mustWrite(t, filepath.Join(root, "a", "b", "c", "SKILL.md"), "---\nname: deep\n---\nDeep")
+
+	result := DiscoverContext(context.Background(), []string{root, filepath.Join(root, "missing")}, DiscoverOptions{MaxDepth: 2})
+	if len(result.Skills) != 1 || result.Skills[0].Name != "shallow" {
+		t.Fatalf("expected only the shallow skill, got %+v", result.Skills)
+	}
+	if len(result.Skipped) != 0 {
+		t.Fatalf("missing roots should not be reported as skipped: %+v", result.Skipped)
+	}
+
+	if got := DiscoverContext(context.Background(), []string{root}, DiscoverOptions{}); len(got.Skills) != 2 {
+		t.Fatalf("expected both skills at the default depth, got %+v", got.Skills)
+	}
+}
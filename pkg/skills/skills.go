@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/locale"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
 )
 
 const (
@@ -25,7 +27,11 @@ const (
 	EnvActiveSkillPath = "ACTIVE_SKILL_PATH"
 	// EnvActiveSkillAllowedTools stores allowed tool patterns for active skill.
 	EnvActiveSkillAllowedTools = "ACTIVE_SKILL_ALLOWED_TOOLS"
-	// EnvClaudeSessionID is available for template substitution in skill bodies.
+	// ToolNamePrefix prefixes the names of skills exposed as tools, e.g.
+	// skill__deploy.
+	ToolNamePrefix = "skill__"
+	// EnvClaudeSessionID is available for template substitution in skill
+	// bodies (see Variables).
 	EnvClaudeSessionID = "CLAUDE_SESSION_ID"
 
 	// DefaultPromptBlockMaxBytes limits skill metadata injected into prompts.
@@ -56,6 +62,14 @@ type Skill struct {
 	DisableModelInvocation bool
 	AllowedTools           []string
 
+	// Sandbox holds the network, write, and allowed-paths directives
+	// applied to the tool context while the skill is active.
+	Sandbox tools.Sandbox
+
+	// Arguments is the declared parameter schema. Empty means the skill
+	// takes free-text $ARGUMENTS only.
+	Arguments []Argument
+
 	sourceOrder int
 }
 
@@ -66,55 +80,6 @@ type PromptBlock struct {
 	Truncated  bool
 }
 
-// Discover scans search directories recursively and returns discovered skills.
-func Discover(searchDirs []string) ([]Skill, error) {
-	dirs := normalizePaths(searchDirs)
-	seenPaths := make(map[string]struct{})
-	out := make([]Skill, 0)
-
-	for idx, root := range dirs {
-		info, err := os.Stat(root)
-		if err != nil || !info.IsDir() {
-			continue
-		}
-		scope := classifyScope(root)
-
-		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
-			if walkErr != nil {
-				return nil
-			}
-			if d.IsDir() || d.Name() != SkillFileName {
-				return nil
-			}
-
-			resolved := filepath.Clean(path)
-			if rp, err := filepath.EvalSymlinks(path); err == nil {
-				resolved = filepath.Clean(rp)
-			}
-			if _, ok := seenPaths[resolved]; ok {
-				return nil
-			}
-
-			skill, err := parseSkill(path, root, idx, scope)
-			if err != nil {
-				return nil
-			}
-			seenPaths[resolved] = struct{}{}
-			out = append(out, skill)
-			return nil
-		})
-	}
-
-	sort.Slice(out, func(i, j int) bool {
-		if out[i].Name == out[j].Name {
-			return out[i].Path < out[j].Path
-		}
-		return out[i].Name < out[j].Name
-	})
-
-	return out, nil
-}
-
 // DefaultSearchDirs returns built-in skill search directories for a workdir.
 func DefaultSearchDirs(workDir string) []string {
 	if raw := strings.TrimSpace(os.Getenv(SkillDirsEnv)); raw != "" {
@@ -148,8 +113,24 @@ func DefaultSearchDirs(workDir string) []string {
 	return normalizePaths(dirs)
 }
 
+// SearchDirs returns DefaultSearchDirs for workDir followed by extra
+// directories (e.g. skill directories contributed by plugins).
+func SearchDirs(workDir string, extra []string) []string {
+	dirs := DefaultSearchDirs(workDir)
+	if len(extra) == 0 {
+		return dirs
+	}
+	return normalizePaths(append(dirs, extra...))
+}
+
 // BuildPromptBlock renders skill metadata for prompt injection.
 func BuildPromptBlock(skills []Skill, maxBytes int) PromptBlock {
+	return BuildLocalizedPromptBlock(skills, maxBytes, locale.Default)
+}
+
+// BuildLocalizedPromptBlock is BuildPromptBlock with the instructional text
+// taken from the lang catalog.
+func BuildLocalizedPromptBlock(skills []Skill, maxBytes int, lang string) PromptBlock {
 	visible := canonicalSkills(skills, true)
 	if len(visible) == 0 {
 		return PromptBlock{}
@@ -159,12 +140,9 @@ func BuildPromptBlock(skills []Skill, maxBytes int) PromptBlock {
 	}
 
 	header := strings.Join([]string{
-		"## Available Skills",
+		locale.Text(lang, locale.SkillsHeading),
 		"",
-		"Skills use progressive disclosure: surface metadata first, load full content only on invocation.",
-		"When a relevant skill applies, invoke it with `use_skill` before taking action.",
-		"Use `list_skills` to discover available skills; use `read_skill` for direct inspection when needed.",
-		"Skills marked with disable-model-invocation are intentionally excluded from this list.",
+		locale.Text(lang, locale.SkillsIntro),
 		"",
 	}, "\n")
 
@@ -193,7 +171,7 @@ func BuildPromptBlock(skills []Skill, maxBytes int) PromptBlock {
 	for _, skill := range visible {
 		desc := strings.TrimSpace(skill.Description)
 		if desc == "" {
-			desc = "No description."
+			desc = locale.Text(lang, locale.SkillsNoDescription)
 		}
 		if len(desc) > 180 {
 			desc = desc[:180] + "..."
@@ -203,6 +181,9 @@ func BuildPromptBlock(skills []Skill, maxBytes int) PromptBlock {
 			scope = string(ScopeUnknown)
 		}
 		line := fmt.Sprintf("- `%s` [%s]: %s (path: `%s`)\n", skill.Name, scope, desc, filepath.ToSlash(skill.Path))
+		if len(skill.Arguments) > 0 {
+			line = strings.TrimSuffix(line, ")\n") + fmt.Sprintf("; arguments: %s)\n", FormatArguments(skill.Arguments))
+		}
 		if remaining <= 0 {
 			truncated = true
 			break
@@ -265,6 +246,8 @@ func parseSkill(path, root string, sourceOrder int, scope SkillScope) (Skill, er
 		UserInvocable:          meta.UserInvocable,
 		DisableModelInvocation: meta.DisableModelInvocation,
 		AllowedTools:           meta.AllowedTools,
+		Sandbox:                meta.Sandbox,
+		Arguments:              meta.Arguments,
 		sourceOrder:            sourceOrder,
 	}, nil
 }
@@ -276,6 +259,8 @@ type frontMatter struct {
 	UserInvocable          bool
 	DisableModelInvocation bool
 	AllowedTools           []string
+	Sandbox                tools.Sandbox
+	Arguments              []Argument
 }
 
 func parseFrontMatter(data []byte) (meta frontMatter, body string) {
@@ -356,6 +341,43 @@ func setFrontMatterValue(meta *frontMatter, key, raw string, isListItem bool) {
 			}
 			meta.AllowedTools = append(meta.AllowedTools, v)
 		}
+	case "arguments":
+		if arg, ok := parseArgumentItem(clean); ok {
+			meta.Arguments = append(meta.Arguments, arg)
+		}
+	case "network":
+		if on, ok := parseSwitch(clean); ok {
+			meta.Sandbox.DenyNetwork = !on
+		}
+	case "write":
+		if on, ok := parseSwitch(clean); ok {
+			meta.Sandbox.DenyWrite = !on
+		}
+	case "allowed-paths":
+		values := []string{clean}
+		if !isListItem {
+			values = parseAllowedToolsValue(raw)
+		}
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			meta.Sandbox.AllowedPaths = append(meta.Sandbox.AllowedPaths, filepath.Clean(v))
+		}
+	}
+}
+
+// parseSwitch parses a sandbox directive such as "network: off" or
+// "write: disallowed".
+func parseSwitch(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "allowed", "allow", "enabled":
+		return true, true
+	case "off", "disallowed", "deny", "denied", "disabled", "none", "read-only":
+		return false, true
+	default:
+		return parseBool(value)
 	}
 }
 
@@ -575,19 +597,53 @@ func ResolveForInvocation(skills []Skill, name string) (Skill, error) {
 }
 
 // RenderForInvocation loads and renders a skill body with variable substitution.
+// Arguments are validated against skill.Arguments, and each declared
+// argument's ${ARG_NAME} placeholder is replaced with its value.
 func RenderForInvocation(skill Skill, arguments, sessionID string, maxBytes int) (content string, truncated bool, err error) {
+	return RenderForInvocationVars(skill, arguments, Variables{SessionID: sessionID}, maxBytes)
+}
+
+// RenderForInvocationVars is RenderForInvocation with the run context
+// variables of vars. Variables are expanded before the arguments are
+// inserted, so argument values are never expanded.
+func RenderForInvocationVars(skill Skill, arguments string, vars Variables, maxBytes int) (content string, truncated bool, err error) {
+	values, err := ParseArguments(skill.Arguments, arguments)
+	if err != nil {
+		return "", false, fmt.Errorf("skill %q: %w", skill.Name, err)
+	}
 	raw, truncated, err := ReadFile(skill.Path, maxBytes)
 	if err != nil {
 		return "", false, err
 	}
 	_, body := parseFrontMatter([]byte(raw))
+	body, usedPlaceholders := substituteArguments(vars.Expand(body), skill.Arguments, values)
+	if usedPlaceholders && !strings.Contains(body, "$ARGUMENTS") && !strings.Contains(body, "${ARGUMENTS}") {
+		// The arguments are already in the body; do not append them again.
+		arguments = ""
+	}
+	return renderArguments(body, arguments), truncated, nil
+}
+
+// RenderBody substitutes $ARGUMENTS and ${CLAUDE_SESSION_ID} in a skill or
+// command body. Arguments are appended when the body has no placeholder.
+func RenderBody(body, arguments, sessionID string) string {
+	return RenderBodyVars(body, arguments, Variables{SessionID: sessionID})
+}
+
+// RenderBodyVars is RenderBody with the run context variables of vars.
+func RenderBodyVars(body, arguments string, vars Variables) string {
+	return renderArguments(vars.Expand(body), arguments)
+}
+
+// renderArguments substitutes $ARGUMENTS in body, or appends the arguments
+// when the body has no placeholder.
+func renderArguments(body, arguments string) string {
 	rendered := strings.TrimSpace(body)
 
 	argText := strings.TrimSpace(arguments)
 	hasArgPlaceholder := strings.Contains(rendered, "$ARGUMENTS") || strings.Contains(rendered, "${ARGUMENTS}")
 	rendered = strings.ReplaceAll(rendered, "${ARGUMENTS}", argText)
 	rendered = strings.ReplaceAll(rendered, "$ARGUMENTS", argText)
-	rendered = strings.ReplaceAll(rendered, "${CLAUDE_SESSION_ID}", strings.TrimSpace(sessionID))
 
 	if argText != "" && !hasArgPlaceholder {
 		if strings.TrimSpace(rendered) != "" {
@@ -595,8 +651,19 @@ func RenderForInvocation(skill Skill, arguments, sessionID string, maxBytes int)
 		}
 		rendered += "ARGUMENTS:\n" + argText
 	}
+	return rendered
+}
 
-	return rendered, truncated, nil
+// ParseDocument splits a SKILL.md-style markdown document into its front
+// matter name and description and its body. The description falls back to
+// the first non-heading body line.
+func ParseDocument(data []byte) (name, description, body string) {
+	meta, body := parseFrontMatter(data)
+	description = strings.TrimSpace(meta.Description)
+	if description == "" {
+		description = inferDescription(body)
+	}
+	return strings.TrimSpace(meta.Name), description, body
 }
 
 // ParseSlashSkillCommand parses "/skill-name args..." command format.
@@ -677,6 +744,8 @@ func JoinAllowedToolsEnv(allowed []string) string {
 }
 
 // IsToolAllowed checks if a tool is permitted by skill allowed-tools patterns.
+// Patterns may use * anywhere, e.g. "mcp__*__search". A namespaced MCP
+// server pattern such as "mcp__github" allows every tool of that server.
 func IsToolAllowed(toolName string, allowed []string) bool {
 	if len(allowed) == 0 {
 		return true
@@ -697,6 +766,9 @@ func IsToolAllowed(toolName string, allowed []string) bool {
 		if wildcardMatch(pattern, tool) {
 			return true
 		}
+		if isMCPServerPattern(pattern) && strings.HasPrefix(tool, pattern+"__") {
+			return true
+		}
 
 		switch pattern {
 		case "bash":
@@ -749,13 +821,56 @@ func wildcardMatch(pattern, value string) bool {
 	if pattern == value {
 		return true
 	}
-	if strings.Contains(pattern, "*") {
-		prefix := strings.TrimSuffix(pattern, "*")
-		if strings.HasSuffix(pattern, "*") {
-			return strings.HasPrefix(value, prefix)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return false
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
 		}
+		value = value[idx+len(part):]
 	}
-	return false
+	return len(value) >= len(last) && strings.HasSuffix(value, last)
+}
+
+// isMCPServerPattern reports whether pattern names a whole MCP server,
+// mcp__<server>, rather than one of its tools.
+func isMCPServerPattern(pattern string) bool {
+	server, ok := strings.CutPrefix(pattern, "mcp__")
+	return ok && server != "" && !strings.ContainsAny(server, "*") && !strings.Contains(server, "__")
+}
+
+// ModelInvocable returns one skill per name, by precedence, leaving out
+// skills with disable-model-invocation. These are the skills listed in the
+// prompt block.
+func ModelInvocable(skills []Skill) []Skill {
+	return canonicalSkills(skills, true)
+}
+
+// ToolName returns the name of the tool that exposes the skill, e.g.
+// skill__deploy. Characters not allowed in tool names become "_".
+func ToolName(skillName string) string {
+	var b strings.Builder
+	b.WriteString(ToolNamePrefix)
+	for _, r := range strings.TrimSpace(skillName) {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := b.String()
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
 }
 
 func canonicalSkills(skills []Skill, skipModelDisabled bool) []Skill {
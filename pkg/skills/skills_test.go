@@ -3,8 +3,11 @@ package skills
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
 )
 
 func TestSkillEnvConstantsUseNonCodexPrefix(t *testing.T) {
@@ -86,6 +89,41 @@ Use this for beta tasks.`)
 	}
 }
 
+func TestDiscoverParsesSandboxDirectives(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "audit", "SKILL.md"), `---
+name: audit
+network: off
+write: disallowed
+allowed-paths:
+  - docs
+  - ./reports/
+---
+`)
+	mustWrite(t, filepath.Join(root, "fetch", "SKILL.md"), `---
+name: fetch
+network: on
+allowed-paths: [vendor, third_party]
+---
+`)
+
+	skills, err := Discover([]string{root})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if len(skills) != 2 {
+		t.Fatalf("expected 2 skills, got %d", len(skills))
+	}
+	want := tools.Sandbox{DenyNetwork: true, DenyWrite: true, AllowedPaths: []string{"docs", "reports"}}
+	if !reflect.DeepEqual(skills[0].Sandbox, want) {
+		t.Fatalf("audit sandbox = %+v, want %+v", skills[0].Sandbox, want)
+	}
+	want = tools.Sandbox{AllowedPaths: []string{"vendor", "third_party"}}
+	if !reflect.DeepEqual(skills[1].Sandbox, want) {
+		t.Fatalf("fetch sandbox = %+v, want %+v", skills[1].Sandbox, want)
+	}
+}
+
 func TestBuildPromptBlockUsesProgressiveDisclosure(t *testing.T) {
 	block := BuildPromptBlock([]Skill{
 		{
@@ -248,3 +286,41 @@ func mustWrite(t *testing.T, path, content string) {
 		t.Fatalf("write %s: %v", path, err)
 	}
 }
+
+func TestBuildLocalizedPromptBlock(t *testing.T) {
+	block := BuildLocalizedPromptBlock([]Skill{
+		{Name: "alpha", Path: "/tmp/alpha/SKILL.md"},
+	}, 4096, "zh")
+
+	for _, want := range []string{"## 可用技能", "`use_skill`", "无描述。", "alpha"} {
+		if !strings.Contains(block.Content, want) {
+			t.Fatalf("expected %q in block, got: %q", want, block.Content)
+		}
+	}
+	if strings.Contains(block.Content, "Available Skills") {
+		t.Fatalf("expected no English heading, got: %q", block.Content)
+	}
+}
+
+func TestIsToolAllowedMatchesNamespacedMCPTools(t *testing.T) {
+	tests := []struct {
+		tool    string
+		allowed []string
+		want    bool
+	}{
+		{tool: "mcp__github__search", allowed: []string{"mcp__github__search"}, want: true},
+		{tool: "mcp__github__search", allowed: []string{"mcp__github"}, want: true},
+		{tool: "mcp__github_enterprise__search", allowed: []string{"mcp__github"}, want: false},
+		{tool: "mcp__github__search", allowed: []string{"mcp__github__*"}, want: true},
+		{tool: "mcp__jira__search", allowed: []string{"mcp__*__search"}, want: true},
+		{tool: "mcp__jira__create", allowed: []string{"mcp__*__search"}, want: false},
+		{tool: "mcp__jira__search", allowed: []string{"mcp__*"}, want: true},
+		{tool: "bash", allowed: []string{"mcp__github"}, want: false},
+		{tool: "git_status", allowed: []string{"git"}, want: true},
+	}
+	for _, tt := range tests {
+		if got := IsToolAllowed(tt.tool, tt.allowed); got != tt.want {
+			t.Errorf("IsToolAllowed(%q, %q) = %v, want %v", tt.tool, tt.allowed, got, tt.want)
+		}
+	}
+}
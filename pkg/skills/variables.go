@@ -0,0 +1,180 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// envVariablePrefix marks placeholders for allowlisted ToolContext.Env
+// entries, e.g. ${env:DEPLOY_TARGET}.
+const envVariablePrefix = "env:"
+
+// Variables are the run context values skill and command bodies can
+// reference:
+//
+//	${CLAUDE_SESSION_ID}  the session ID
+//	${WORKDIR}            the run's working directory
+//	${REPO_ROOT}          the git repository containing it
+//	${GIT_BRANCH}         the checked-out branch (a commit hash when detached)
+//	${DATE}               today's date, YYYY-MM-DD
+//	${env:NAME}           ToolContext.Env["NAME"], if NAME is allowlisted
+//
+// $${NAME} renders a literal ${NAME}. Placeholders without a value and
+// unknown names are left as they are, so shell snippets such as ${HOME}
+// keep working.
+type Variables struct {
+	SessionID string
+	WorkDir   string
+	RepoRoot  string
+	Branch    string
+	Date      string
+
+	// Env holds the allowlisted environment values by name.
+	Env map[string]string
+}
+
+// VariablesFor collects the variables of a run from toolCtx. Only the
+// ToolContext.Env entries named in ToolContext.SkillEnvVars are exposed.
+// The repository and branch are read from the .git directory; no commands
+// are run.
+func VariablesFor(toolCtx *tools.ToolContext, now time.Time) Variables {
+	vars := Variables{Date: now.Format("2006-01-02")}
+	if toolCtx == nil {
+		return vars
+	}
+	vars.SessionID = strings.TrimSpace(toolCtx.Env[EnvClaudeSessionID])
+	vars.WorkDir = toolCtx.WorkDir
+	if toolCtx.WorkDir != "" {
+		vars.RepoRoot, vars.Branch = gitCheckout(toolCtx.WorkDir)
+	}
+	for _, name := range toolCtx.SkillEnvVars {
+		if value, ok := toolCtx.Env[name]; ok {
+			if vars.Env == nil {
+				vars.Env = make(map[string]string)
+			}
+			vars.Env[name] = value
+		}
+	}
+	return vars
+}
+
+// lookup returns the value of the named variable. Variables other than
+// the session ID count as unset when empty.
+func (v Variables) lookup(name string) (string, bool) {
+	var value string
+	switch name {
+	case EnvClaudeSessionID:
+		// Always replaced, as before the other variables existed.
+		return v.SessionID, true
+	case "WORKDIR":
+		value = v.WorkDir
+	case "REPO_ROOT":
+		value = v.RepoRoot
+	case "GIT_BRANCH":
+		value = v.Branch
+	case "DATE":
+		value = v.Date
+	default:
+		if key, ok := strings.CutPrefix(name, envVariablePrefix); ok {
+			value = v.Env[key]
+		}
+	}
+	return value, value != ""
+}
+
+// Expand replaces the variable placeholders in body in a single pass, so
+// placeholders inside values are not expanded again. Control characters in
+// values, such as newlines, become spaces: a value cannot add lines to
+// the prompt.
+func (v Variables) Expand(body string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(body, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(body[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		name := body[start+2 : end]
+		value, ok := v.lookup(name)
+		switch {
+		case ok && start > 0 && body[start-1] == '$':
+			b.WriteString(body[:start-1])
+			b.WriteString(body[start : end+1])
+		case ok:
+			b.WriteString(body[:start])
+			b.WriteString(sanitizeVariable(value))
+		default:
+			b.WriteString(body[:start+2])
+			body = body[start+2:]
+			continue
+		}
+		body = body[end+1:]
+	}
+	b.WriteString(body)
+	return b.String()
+}
+
+// sanitizeVariable trims value and replaces its control characters with
+// spaces.
+func sanitizeVariable(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, strings.TrimSpace(value))
+}
+
+// gitCheckout finds the git repository containing dir and its checked-out
+// branch. Both are empty outside a repository.
+func gitCheckout(dir string) (root, branch string) {
+	dir = filepath.Clean(dir)
+	for {
+		gitPath := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitPath); err == nil {
+			return dir, gitBranch(gitPath, info.IsDir())
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// gitBranch reads HEAD from a .git directory or, for worktrees and
+// submodules, the directory a .git file points to.
+func gitBranch(gitPath string, isDir bool) string {
+	gitDir := gitPath
+	if !isDir {
+		data, err := os.ReadFile(gitPath)
+		if err != nil {
+			return ""
+		}
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+		if !ok {
+			return ""
+		}
+		gitDir = strings.TrimSpace(target)
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(filepath.Dir(gitPath), gitDir)
+		}
+	}
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	ref := strings.TrimSpace(string(head))
+	if branch, ok := strings.CutPrefix(ref, "ref: refs/heads/"); ok {
+		return branch
+	}
+	return ref
+}
@@ -35,6 +35,10 @@ type LoadResult struct {
 
 	// Truncated indicates the content hit MaxBytes.
 	Truncated bool
+
+	// Watched lists the paths checked, whether or not they exist. The
+	// result changes only when one of them does.
+	Watched []string
 }
 
 // Load reads the SOUL file content.
@@ -47,7 +51,9 @@ func Load(workDir string, opts LoadOptions) LoadResult {
 	}
 
 	if opts.File != "" {
-		return readSoulFile(opts.File, maxBytes)
+		result := readSoulFile(opts.File, maxBytes)
+		result.Watched = []string{opts.File}
+		return result
 	}
 
 	if strings.TrimSpace(workDir) == "" {
@@ -61,21 +67,25 @@ func Load(workDir string, opts LoadOptions) LoadResult {
 	workDir = filepath.Clean(workDir)
 
 	// Try workDir first
-	result := readSoulFile(filepath.Join(workDir, DefaultFileName), maxBytes)
+	watched := []string{filepath.Join(workDir, DefaultFileName)}
+	result := readSoulFile(watched[0], maxBytes)
 	if result.Content != "" {
+		result.Watched = watched
 		return result
 	}
 
 	// Try repo root
 	root := findRepoRoot(workDir)
 	if root != workDir {
-		result = readSoulFile(filepath.Join(root, DefaultFileName), maxBytes)
+		watched = append(watched, filepath.Join(root, DefaultFileName))
+		result = readSoulFile(watched[1], maxBytes)
 		if result.Content != "" {
+			result.Watched = watched
 			return result
 		}
 	}
 
-	return LoadResult{}
+	return LoadResult{Watched: watched}
 }
 
 func readSoulFile(path string, maxBytes int) LoadResult {
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Artifact is an output a tool attached to the run, e.g. a report, a
+// generated image, or a build log, returned with the result instead of in
+// the message text.
+type Artifact struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	// MediaType is the content's MIME type, e.g. "text/plain".
+	MediaType string `json:"media_type"`
+	Size      int    `json:"size"`
+
+	// Description says what the artifact is, for listings.
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	data []byte
+}
+
+// Content returns the artifact's content.
+func (a Artifact) Content() []byte {
+	return a.data
+}
+
+// Open returns a reader over the artifact's content.
+func (a Artifact) Open() io.Reader {
+	return bytes.NewReader(a.data)
+}
+
+// Artifacts collects the artifacts of a run. It is safe for concurrent use.
+type Artifacts struct {
+	mu    sync.Mutex
+	items []Artifact
+}
+
+// NewArtifacts creates an empty collection.
+func NewArtifacts() *Artifacts {
+	return &Artifacts{}
+}
+
+// Add attaches content under name. An empty mediaType is derived from the
+// name's extension, or sniffed from the content.
+func (a *Artifacts) Add(name, mediaType, description string, content []byte) Artifact {
+	if mediaType == "" {
+		mediaType = mime.TypeByExtension(filepath.Ext(name))
+	}
+	if mediaType == "" {
+		mediaType = http.DetectContentType(content)
+	}
+	artifact := Artifact{
+		ID:          newArtifactID(),
+		Name:        name,
+		MediaType:   mediaType,
+		Size:        len(content),
+		Description: strings.TrimSpace(description),
+		CreatedAt:   time.Now(),
+		data:        bytes.Clone(content),
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.items = append(a.items, artifact)
+	return artifact
+}
+
+// List returns the artifacts in the order they were added.
+func (a *Artifacts) List() []Artifact {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.items) == 0 {
+		return nil
+	}
+	return append([]Artifact(nil), a.items...)
+}
+
+// Get returns the artifact with the given ID.
+func (a *Artifacts) Get(id string) (Artifact, bool) {
+	if a == nil {
+		return Artifact{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, artifact := range a.items {
+		if artifact.ID == id {
+			return artifact, true
+		}
+	}
+	return Artifact{}, false
+}
+
+// AddArtifact attaches content to the run's artifacts. It fails when the
+// run does not collect artifacts.
+func (c *ToolContext) AddArtifact(name, mediaType, description string, content []byte) (Artifact, error) {
+	if c.Artifacts == nil {
+		return Artifact{}, fmt.Errorf("this run does not collect artifacts")
+	}
+	return c.Artifacts.Add(name, mediaType, description, content), nil
+}
+
+func newArtifactID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "art_" + hex.EncodeToString(b)
+}
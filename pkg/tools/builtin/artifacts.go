@@ -0,0 +1,118 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// maxArtifactBytes bounds the content of one attached artifact.
+const maxArtifactBytes = 10 << 20
+
+// AttachArtifactTool attaches a file or generated text to the run result
+// as an artifact (see tools.Artifacts).
+type AttachArtifactTool struct{}
+
+func (t AttachArtifactTool) Name() string {
+	return "attach_artifact"
+}
+
+func (t AttachArtifactTool) Description() string {
+	return "Attach a file (e.g. a report, generated image, or build log) or generated text to the result as a downloadable artifact, instead of pasting it into your reply. Give either path or content."
+}
+
+func (t AttachArtifactTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File to attach",
+			},
+			"content": map[string]any{
+				"type":        "string",
+				"description": "Text to attach instead of a file",
+			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Artifact name, e.g. report.md (default: the file name)",
+			},
+			"media_type": map[string]any{
+				"type":        "string",
+				"description": "MIME type (default: derived from the name or content)",
+			},
+			"description": map[string]any{
+				"type":        "string",
+				"description": "What the artifact is",
+			},
+		},
+	}
+}
+
+func (t AttachArtifactTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	if toolCtx.Artifacts == nil {
+		return tools.NewErrorResultf("this run does not collect artifacts; include the output in your reply instead"), nil
+	}
+	path, _ := input["path"].(string)
+	text, hasContent := input["content"].(string)
+	name, _ := input["name"].(string)
+	mediaType, _ := input["media_type"].(string)
+	description, _ := input["description"].(string)
+	name = strings.TrimSpace(name)
+
+	var content []byte
+	switch {
+	case path != "" && hasContent:
+		return tools.NewErrorResultf("give either path or content, not both"), nil
+	case path != "":
+		if err := toolCtx.CheckFileRead(); err != nil {
+			return tools.NewErrorResult(err), nil
+		}
+		absPath, err := toolCtx.ValidatePath(path)
+		if err != nil {
+			return tools.NewErrorResult(err), nil
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return tools.NewErrorResultf("failed to read file: %v", err).WithCause(err).WithDetail("path", path), nil
+		}
+		if info.IsDir() {
+			return tools.NewErrorResultf("%s is a directory; attach its files one by one", path), nil
+		}
+		if info.Size() > maxArtifactBytes {
+			return tools.NewErrorResultf("%s is %d bytes; artifacts are limited to %d bytes", path, info.Size(), maxArtifactBytes), nil
+		}
+		if content, err = os.ReadFile(absPath); err != nil {
+			return tools.NewErrorResultf("failed to read file: %v", err).WithCause(err).WithDetail("path", path), nil
+		}
+		if name == "" {
+			name = filepath.Base(absPath)
+		}
+	case hasContent:
+		if len(text) > maxArtifactBytes {
+			return tools.NewErrorResultf("content is %d bytes; artifacts are limited to %d bytes", len(text), maxArtifactBytes), nil
+		}
+		if name == "" {
+			return tools.NewErrorResultf("name is required with content"), nil
+		}
+		content = []byte(text)
+	default:
+		return tools.NewErrorResultf("path or content is required"), nil
+	}
+
+	artifact := toolCtx.Artifacts.Add(name, mediaType, description, content)
+	return tools.NewToolResult(fmt.Sprintf("attached %s as artifact %s (%s, %d bytes)",
+		artifact.Name, artifact.ID, artifact.MediaType, artifact.Size)), nil
+}
+
+// RegisterArtifactTools registers attach_artifact. It is not part of
+// RegisterAll because its artifacts only reach users whose agent returns
+// them, e.g. through AgentResult.Artifacts or the server's artifact
+// endpoint.
+func RegisterArtifactTools(registry *tools.Registry) {
+	registry.MustRegister(AttachArtifactTool{})
+}
@@ -0,0 +1,65 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestAttachArtifactToolAttachesFilesAndText(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "build.log"), []byte("ok\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	toolCtx := tools.NewToolContext(dir).WithArtifacts(tools.NewArtifacts())
+	tool := AttachArtifactTool{}
+
+	result, _ := tool.Execute(context.Background(), toolCtx, map[string]any{"path": "build.log", "description": "CI output"})
+	if result.IsError {
+		t.Fatalf("attach file: %s", result.Content)
+	}
+	result, _ = tool.Execute(context.Background(), toolCtx, map[string]any{"content": "# Report", "name": "report.md"})
+	if result.IsError {
+		t.Fatalf("attach content: %s", result.Content)
+	}
+
+	artifacts := toolCtx.Artifacts.List()
+	if len(artifacts) != 2 {
+		t.Fatalf("expected 2 artifacts, got %+v", artifacts)
+	}
+	if a := artifacts[0]; a.Name != "build.log" || string(a.Content()) != "ok\n" || a.Description != "CI output" || !strings.HasPrefix(a.MediaType, "text/") {
+		t.Fatalf("unexpected file artifact: %+v", a)
+	}
+	if a := artifacts[1]; a.Name != "report.md" || a.Size != 8 || a.MediaType == "" || a.ID == artifacts[0].ID {
+		t.Fatalf("unexpected text artifact: %+v", a)
+	}
+}
+
+func TestAttachArtifactToolErrors(t *testing.T) {
+	dir := t.TempDir()
+	tool := AttachArtifactTool{}
+	result, _ := tool.Execute(context.Background(), tools.NewToolContext(dir), map[string]any{"content": "x", "name": "x.txt"})
+	if !result.IsError || !strings.Contains(result.Content, "does not collect artifacts") {
+		t.Fatalf("expected an error without Artifacts, got %+v", result)
+	}
+
+	toolCtx := tools.NewToolContext(dir).WithArtifacts(tools.NewArtifacts())
+	for _, input := range []map[string]any{
+		{},
+		{"content": "x"},
+		{"path": "x.txt", "content": "x"},
+		{"path": "../outside.txt"},
+		{"path": "missing.txt"},
+	} {
+		if result, _ := tool.Execute(context.Background(), toolCtx, input); !result.IsError {
+			t.Errorf("expected an error for %v, got %+v", input, result)
+		}
+	}
+	if n := len(toolCtx.Artifacts.List()); n != 0 {
+		t.Fatalf("expected no artifacts, got %d", n)
+	}
+}
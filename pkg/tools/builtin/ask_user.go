@@ -0,0 +1,71 @@
+package builtin
+
+import (
+	"context"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// AskUserTool asks the user a clarifying question and waits for the answer.
+// It requires a run that can reach the user (ToolContext.AskUser).
+type AskUserTool struct{}
+
+func (t AskUserTool) Name() string {
+	return "ask_user"
+}
+
+func (t AskUserTool) Description() string {
+	return "Ask the user a clarifying question and wait for their answer. Use it only when the task is ambiguous and a wrong guess would waste significant work; offer options when the likely answers are known."
+}
+
+func (t AskUserTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"question": map[string]any{
+				"type":        "string",
+				"description": "The question to ask the user",
+			},
+			"options": map[string]any{
+				"type":        "array",
+				"description": "Optional suggested answers for a multiple-choice question",
+				"items":       map[string]any{"type": "string"},
+			},
+		},
+		"required": []string{"question"},
+	}
+}
+
+func (t AskUserTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	question, _ := input["question"].(string)
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return tools.NewErrorResultf("question is required"), nil
+	}
+	if toolCtx.AskUser == nil {
+		return tools.NewErrorResultf("no user is available to answer questions in this run; proceed with your best judgement and state your assumptions"), nil
+	}
+
+	var options []string
+	if raw, ok := input["options"].([]any); ok {
+		for _, v := range raw {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				options = append(options, strings.TrimSpace(s))
+			}
+		}
+	}
+
+	answer, err := toolCtx.AskUser(ctx, tools.UserQuestion{Question: question, Options: options})
+	if err != nil {
+		return tools.NewErrorResultf("no answer from the user: %v", err), nil
+	}
+	return tools.NewToolResult(answer), nil
+}
+
+// RegisterUserTools registers tools that interact with the user during a
+// run. They are not part of RegisterAll because they need an agent that can
+// deliver the user's answers (see AgentOptions.InputQueue).
+func RegisterUserTools(registry *tools.Registry) {
+	registry.MustRegister(AskUserTool{})
+}
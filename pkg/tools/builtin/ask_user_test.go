@@ -0,0 +1,53 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestAskUserToolReturnsTheAnswer(t *testing.T) {
+	var asked tools.UserQuestion
+	toolCtx := tools.NewToolContext(t.TempDir())
+	toolCtx.AskUser = func(_ context.Context, q tools.UserQuestion) (string, error) {
+		asked = q
+		return "use postgres", nil
+	}
+
+	result, err := AskUserTool{}.Execute(context.Background(), toolCtx, map[string]any{
+		"question": " Which database? ",
+		"options":  []any{"postgres", " ", "sqlite"},
+	})
+	if err != nil || result.IsError || result.Content != "use postgres" {
+		t.Fatalf("unexpected result: %+v, %v", result, err)
+	}
+	want := tools.UserQuestion{Question: "Which database?", Options: []string{"postgres", "sqlite"}}
+	if !reflect.DeepEqual(asked, want) {
+		t.Fatalf("asked %+v, want %+v", asked, want)
+	}
+}
+
+func TestAskUserToolErrors(t *testing.T) {
+	toolCtx := tools.NewToolContext(t.TempDir())
+	result, _ := AskUserTool{}.Execute(context.Background(), toolCtx, map[string]any{"question": "Which database?"})
+	if !result.IsError || !strings.Contains(result.Content, "no user is available") {
+		t.Fatalf("expected an error without AskUser, got %+v", result)
+	}
+
+	toolCtx.AskUser = func(context.Context, tools.UserQuestion) (string, error) {
+		return "", errors.New("timed out after 1m0s")
+	}
+	result, _ = AskUserTool{}.Execute(context.Background(), toolCtx, map[string]any{"question": "Which database?"})
+	if !result.IsError || !strings.Contains(result.Content, "timed out") {
+		t.Fatalf("expected the asker error, got %+v", result)
+	}
+
+	result, _ = AskUserTool{}.Execute(context.Background(), toolCtx, map[string]any{})
+	if !result.IsError || result.Content != "question is required" {
+		t.Fatalf("expected a missing question error, got %+v", result)
+	}
+}
@@ -1,9 +1,10 @@
 package builtin
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -20,7 +21,7 @@ func (t BashTool) Name() string {
 }
 
 func (t BashTool) Description() string {
-	return "Execute a bash command. Use this for running tests, building projects, or any shell operations. Commands run in the working directory."
+	return "Execute a bash command. Use this for running tests, building projects, or any shell operations. Commands run in the current directory unless cwd is given."
 }
 
 func (t BashTool) InputSchema() map[string]any {
@@ -35,15 +36,34 @@ func (t BashTool) InputSchema() map[string]any {
 				"type":        "integer",
 				"description": "Timeout in seconds (default: 60, max: 300)",
 			},
+			tools.CwdInputKey: map[string]any{
+				"type":        "string",
+				"description": "Directory to run the command in, relative to the current directory (default: current directory)",
+			},
 		},
 		"required": []string{"command"},
 	}
 }
 
+// WritePaths returns nil: a command may touch any file, so transactional
+// mode snapshots the whole workspace before every call, and refuses the
+// call when the workspace is over the snapshot cap.
+func (t BashTool) WritePaths(toolCtx *tools.ToolContext, input map[string]any) []string {
+	return nil
+}
+
 func (t BashTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	return t.ExecuteStream(ctx, toolCtx, input, io.Discard)
+}
+
+// ExecuteStream runs the command, copying stdout and stderr to w as they are produced.
+func (t BashTool) ExecuteStream(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any, w io.Writer) (tools.ToolResult, error) {
 	if err := toolCtx.CheckBash(); err != nil {
 		return tools.NewErrorResult(err), nil
 	}
+	if err := toolCtx.CheckHostCommand(); err != nil {
+		return tools.NewErrorResult(err), nil
+	}
 
 	command, ok := input["command"].(string)
 	if !ok || command == "" {
@@ -55,6 +75,11 @@ func (t BashTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input
 		return tools.NewErrorResult(err), nil
 	}
 
+	dir, err := toolCtx.ResolveCwd(input)
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
 	// Get timeout
 	timeout := toolCtx.BashTimeout
 	if t, ok := input["timeout"].(float64); ok && t > 0 {
@@ -73,48 +98,96 @@ func (t BashTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input
 
 	// Execute command
 	cmd := exec.CommandContext(ctx, "bash", "-c", command)
-	cmd.Dir = toolCtx.WorkDir
+	cmd.Dir = dir
 
 	// Set up environment
 	cmd.Env = buildEnv(toolCtx)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	stdout, stderr := newOutputBuffer(maxCommandStreamBytes), newOutputBuffer(maxCommandStreamBytes)
+	cmd.Stdout = io.MultiWriter(stdout, w)
+	cmd.Stderr = io.MultiWriter(stderr, w)
 
-	err := cmd.Run()
+	start := time.Now()
+	err = cmd.Run()
 
-	// Build result
-	result := strings.Builder{}
-	if stdout.Len() > 0 {
-		result.WriteString(stdout.String())
-	}
-	if stderr.Len() > 0 {
-		if result.Len() > 0 {
-			result.WriteString("\n")
-		}
-		result.WriteString("STDERR:\n")
-		result.WriteString(stderr.String())
-	}
+	return commandResult(ctx, err, timeout, time.Since(start), stdout, stderr), nil
+}
 
-	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return tools.ToolResult{
-				Content: fmt.Sprintf("Command timed out after %d seconds\n%s", timeout, result.String()),
-				IsError: true,
-			}, nil
-		}
-		return tools.ToolResult{
-			Content: fmt.Sprintf("Command failed: %v\n%s", err, result.String()),
-			IsError: true,
-		}, nil
-	}
+// maxCommandStreamBytes bounds the stdout and stderr each kept in a
+// command result.
+const maxCommandStreamBytes = 64 * 1024
+
+// commandResult builds the result of a finished command run with the
+// given timeout in seconds.
+func commandResult(ctx context.Context, err error, timeout int, elapsed time.Duration, stdout, stderr *outputBuffer) tools.ToolResult {
+	out := tools.CommandOutput{Duration: elapsed}
+	out.Stdout, out.StdoutTruncated = stdout.result()
+	out.Stderr, out.StderrTruncated = stderr.result()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() == context.DeadlineExceeded:
+		out.ExitCode = -1
+		out.TimedOut = true
+	case errors.As(err, &exitErr):
+		// ExitCode is -1 when a signal killed the process.
+		out.ExitCode = exitErr.ExitCode()
+	default:
+		out.ExitCode = -1
+		out.Error = err.Error()
+	}
+
+	result := tools.ToolResult{Content: out.Format(), IsError: err != nil, Command: &out}
+	if out.TimedOut {
+		result = result.WithErrorCode(tools.ErrCodeTimeout, true).WithDetail("timeout_seconds", timeout)
+	}
+	return result
+}
 
-	output := result.String()
-	if output == "" {
-		output = "(no output)"
+// outputBuffer captures a command stream. Output beyond its limit keeps
+// the first and last half of the limit and drops the middle.
+type outputBuffer struct {
+	limit int
+	head  []byte
+	tail  []byte
+	total int
+}
+
+func newOutputBuffer(limit int) *outputBuffer {
+	return &outputBuffer{limit: limit}
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += n
+	if room := b.limit/2 - len(b.head); room > 0 {
+		k := min(room, len(p))
+		b.head = append(b.head, p[:k]...)
+		p = p[k:]
+	}
+	b.tail = append(b.tail, p...)
+	// Trim the tail only once it is twice the size kept, so writes stay
+	// amortized O(n).
+	if keep := b.limit - b.limit/2; len(b.tail) > 2*keep {
+		b.tail = append(b.tail[:0], b.tail[len(b.tail)-keep:]...)
+	}
+	return n, nil
+}
+
+// result returns the captured output and whether its middle was dropped.
+func (b *outputBuffer) result() (string, bool) {
+	tail := b.tail
+	if keep := b.limit - b.limit/2; len(tail) > keep {
+		tail = tail[len(tail)-keep:]
+	}
+	omitted := b.total - len(b.head) - len(tail)
+	if omitted == 0 {
+		return string(b.head) + string(tail), false
 	}
-	return tools.NewToolResult(output), nil
+	// The cuts may split a multi-byte character.
+	head := strings.ToValidUTF8(string(b.head), "")
+	return fmt.Sprintf("%s\n... [%d bytes omitted] ...\n%s", head, omitted, strings.ToValidUTF8(string(tail), "")), true
 }
 
 // validateCommand checks for potentially dangerous commands.
@@ -0,0 +1,69 @@
+package builtin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestBashToolSeparatesStreams(t *testing.T) {
+	toolCtx := tools.NewToolContext(t.TempDir())
+	result, err := BashTool{}.Execute(context.Background(), toolCtx, map[string]any{
+		"command": "echo out; echo err >&2; exit 3",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	out := result.Command
+	if out == nil || !result.IsError {
+		t.Fatalf("expected a failed command result, got %+v", result)
+	}
+	if out.ExitCode != 3 || out.Stdout != "out\n" || out.Stderr != "err\n" || out.TimedOut || out.Duration <= 0 {
+		t.Fatalf("unexpected command output: %+v", out)
+	}
+	for _, want := range []string{"exit_code: 3\n", "\nstdout:\nout\n", "\nstderr:\nerr"} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected %q in content, got %q", want, result.Content)
+		}
+	}
+
+	result, _ = BashTool{}.Execute(context.Background(), toolCtx, map[string]any{"command": "true"})
+	if result.IsError || result.Command.ExitCode != 0 || !strings.HasSuffix(result.Content, "(no output)") {
+		t.Fatalf("unexpected result for a silent command: %+v", result)
+	}
+}
+
+func TestBashToolTimeout(t *testing.T) {
+	result, _ := BashTool{}.Execute(context.Background(), tools.NewToolContext(t.TempDir()), map[string]any{
+		"command": "echo started; sleep 5",
+		"timeout": float64(1),
+	})
+	if !result.IsError || result.ErrorCode != tools.ErrCodeTimeout || !result.Retryable {
+		t.Fatalf("expected a retryable timeout error, got %+v", result)
+	}
+	if out := result.Command; !out.TimedOut || out.ExitCode != -1 || out.Stdout != "started\n" {
+		t.Fatalf("unexpected command output: %+v", out)
+	}
+	if !strings.Contains(result.Content, "timed_out: true") {
+		t.Fatalf("expected the timeout in content, got %q", result.Content)
+	}
+}
+
+func TestOutputBufferKeepsHeadAndTail(t *testing.T) {
+	b := newOutputBuffer(8)
+	for _, chunk := range []string{"ab", "cdef", "ghijkl", "mnop"} {
+		b.Write([]byte(chunk))
+	}
+	got, truncated := b.result()
+	if !truncated || got != "abcd\n... [8 bytes omitted] ...\nmnop" {
+		t.Fatalf("result() = %q, %v", got, truncated)
+	}
+
+	b = newOutputBuffer(8)
+	b.Write([]byte("abcdefgh"))
+	if got, truncated := b.result(); truncated || got != "abcdefgh" {
+		t.Fatalf("result() = %q, %v for output within the limit", got, truncated)
+	}
+}
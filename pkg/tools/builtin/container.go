@@ -0,0 +1,419 @@
+package builtin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// ContainerMount controls how the working directory is mounted into the
+// container.
+type ContainerMount string
+
+const (
+	// ContainerMountReadOnly mounts the working directory read-only.
+	ContainerMountReadOnly ContainerMount = "ro"
+	// ContainerMountReadWrite lets commands request a writable mount.
+	ContainerMountReadWrite ContainerMount = "rw"
+	// ContainerMountNone runs commands without the working directory.
+	ContainerMountNone ContainerMount = "none"
+)
+
+// containerWorkspace is where the working directory appears inside the container.
+const containerWorkspace = "/workspace"
+
+// ContainerPolicy configures the run_in_container tool.
+type ContainerPolicy struct {
+	// Runtime is the container CLI, e.g. "docker" or "podman". Empty
+	// uses the first one found on PATH.
+	Runtime string
+
+	// Image is the default image. Required unless every call names one.
+	Image string
+
+	// AllowedImages restricts the images a call may request. Empty allows
+	// only Image.
+	AllowedImages []string
+
+	// Mount is the most permissive mount a call may use (default: ro).
+	Mount ContainerMount
+
+	// CPUs and Memory are passed as --cpus and --memory when set,
+	// e.g. "1.5" and "512m".
+	CPUs   string
+	Memory string
+
+	// PidsLimit caps processes in the container (default: 256).
+	PidsLimit int
+
+	// AllowNetwork lets calls enable networking. Calls also need the
+	// network permission. Containers run with --network none otherwise.
+	AllowNetwork bool
+
+	// Timeout is the default timeout in seconds (default: 120).
+	// MaxTimeout caps per-call timeouts (default: 600).
+	Timeout    int
+	MaxTimeout int
+
+	// ExtraArgs are appended to the run arguments before the image.
+	ExtraArgs []string
+}
+
+func (p ContainerPolicy) mount() ContainerMount {
+	if p.Mount == "" {
+		return ContainerMountReadOnly
+	}
+	return p.Mount
+}
+
+func (p ContainerPolicy) imageAllowed(image string) bool {
+	return image == p.Image || slices.Contains(p.AllowedImages, image)
+}
+
+func (p ContainerPolicy) timeout(requested int) int {
+	timeout, max := p.Timeout, p.MaxTimeout
+	if timeout < 1 {
+		timeout = 120
+	}
+	if max < 1 {
+		max = 600
+	}
+	if requested > 0 {
+		timeout = requested
+	}
+	if timeout > max {
+		timeout = max
+	}
+	return timeout
+}
+
+func (p ContainerPolicy) runtime() (string, error) {
+	if p.Runtime != "" {
+		return exec.LookPath(p.Runtime)
+	}
+	for _, name := range []string{"docker", "podman"} {
+		if bin, err := exec.LookPath(name); err == nil {
+			return bin, nil
+		}
+	}
+	return "", fmt.Errorf("no container runtime found: install docker or podman")
+}
+
+// RunInContainerTool executes commands in an ephemeral container, a safer
+// alternative to bash for untrusted code.
+type RunInContainerTool struct {
+	Policy ContainerPolicy
+}
+
+func (t RunInContainerTool) Name() string {
+	return "run_in_container"
+}
+
+func (t RunInContainerTool) Description() string {
+	return "Execute a shell command in an ephemeral container with the working directory mounted at " + containerWorkspace +
+		". Networking is disabled and the mount is read-only unless requested and allowed by policy."
+}
+
+func (t RunInContainerTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"command": map[string]any{
+				"type":        "string",
+				"description": "The shell command to execute",
+			},
+			"image": map[string]any{
+				"type":        "string",
+				"description": "Container image to use (default: the configured image)",
+			},
+			"writable": map[string]any{
+				"type":        "boolean",
+				"description": "Mount the working directory read-write, if policy allows",
+			},
+			"network": map[string]any{
+				"type":        "boolean",
+				"description": "Enable networking, if policy allows",
+			},
+			"timeout": map[string]any{
+				"type":        "integer",
+				"description": "Timeout in seconds",
+			},
+			tools.CwdInputKey: map[string]any{
+				"type":        "string",
+				"description": "Directory to run the command in, relative to the current directory (default: current directory)",
+			},
+		},
+		"required": []string{"command"},
+	}
+}
+
+// WritePaths returns nil for writable runs so transactional mode snapshots
+// the whole workspace, the mounted allowed paths when the active skill
+// restricts paths, and an empty list for read-only runs.
+func (t RunInContainerTool) WritePaths(toolCtx *tools.ToolContext, input map[string]any) []string {
+	if !t.writable(input) {
+		return []string{}
+	}
+	if toolCtx != nil {
+		if paths, err := toolCtx.SandboxPaths(); err == nil && paths != nil {
+			return paths
+		}
+	}
+	return nil
+}
+
+func (t RunInContainerTool) writable(input map[string]any) bool {
+	writable, _ := input["writable"].(bool)
+	return writable && t.Policy.mount() == ContainerMountReadWrite
+}
+
+func (t RunInContainerTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	return t.ExecuteStream(ctx, toolCtx, input, io.Discard)
+}
+
+// ExecuteStream runs the command, copying stdout and stderr to w as they are produced.
+func (t RunInContainerTool) ExecuteStream(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any, w io.Writer) (tools.ToolResult, error) {
+	if err := toolCtx.CheckBash(); err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	command, ok := input["command"].(string)
+	if !ok || command == "" {
+		return tools.NewErrorResultf("command is required"), nil
+	}
+	if err := validateCommand(command); err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	image := t.Policy.Image
+	if requested, ok := input["image"].(string); ok && requested != "" {
+		if !t.Policy.imageAllowed(requested) {
+			return tools.NewErrorResultf("image %q is not allowed", requested), nil
+		}
+		image = requested
+	}
+	if image == "" {
+		return tools.NewErrorResultf("image is required: no default image configured"), nil
+	}
+
+	network, _ := input["network"].(bool)
+	if network {
+		if !t.Policy.AllowNetwork {
+			return tools.NewErrorResultf("network access is disabled by container policy"), nil
+		}
+		if err := toolCtx.CheckNetwork(); err != nil {
+			return tools.NewErrorResult(err), nil
+		}
+	}
+	if writable, _ := input["writable"].(bool); writable && !t.writable(input) {
+		return tools.NewErrorResultf("writable mounts are disabled by container policy"), nil
+	}
+	if t.writable(input) {
+		if err := toolCtx.CheckFileWrite(); err != nil {
+			return tools.NewErrorResult(err), nil
+		}
+	}
+
+	dir, err := toolCtx.ResolveCwd(input)
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	var timeout int
+	if v, ok := input["timeout"].(float64); ok {
+		timeout = int(v)
+	}
+	timeout = t.Policy.timeout(timeout)
+
+	runtime, err := t.Policy.runtime()
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	name := containerName()
+	args, err := t.containerArgs(toolCtx, containerRun{
+		name:     name,
+		image:    image,
+		command:  command,
+		dir:      dir,
+		writable: t.writable(input),
+		network:  network,
+	})
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, runtime, args...)
+	// The runtime client needs the host environment (DOCKER_HOST,
+	// XDG_RUNTIME_DIR, ...); tool env values are forwarded by name with -e.
+	cmd.Env = os.Environ()
+	for k, v := range toolCtx.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	stdout, stderr := newOutputBuffer(maxCommandStreamBytes), newOutputBuffer(maxCommandStreamBytes)
+	cmd.Stdout = io.MultiWriter(stdout, w)
+	cmd.Stderr = io.MultiWriter(stderr, w)
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(start)
+	if err != nil && runCtx.Err() != nil {
+		// Killing the client does not stop the container.
+		rmCtx, rmCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_ = exec.CommandContext(rmCtx, runtime, "rm", "-f", name).Run()
+		rmCancel()
+	}
+
+	return commandResult(runCtx, err, timeout, elapsed, stdout, stderr), nil
+}
+
+// containerRun holds the per-call settings for containerArgs.
+type containerRun struct {
+	name     string
+	image    string
+	command  string
+	dir      string // host directory to run in
+	writable bool
+	network  bool
+}
+
+// containerArgs builds the runtime arguments for one call.
+func (t RunInContainerTool) containerArgs(toolCtx *tools.ToolContext, run containerRun) ([]string, error) {
+	args := []string{
+		"run", "--rm",
+		"--name", run.name,
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+	}
+
+	if run.network {
+		args = append(args, "--network", "bridge")
+	} else {
+		args = append(args, "--network", "none")
+	}
+	if t.Policy.CPUs != "" {
+		args = append(args, "--cpus", t.Policy.CPUs)
+	}
+	if t.Policy.Memory != "" {
+		args = append(args, "--memory", t.Policy.Memory)
+	}
+	pids := t.Policy.PidsLimit
+	if pids < 1 {
+		pids = 256
+	}
+	args = append(args, "--pids-limit", strconv.Itoa(pids))
+
+	if uid, gid := os.Getuid(), os.Getgid(); uid > 0 {
+		// Files created in a writable mount stay owned by the host user.
+		args = append(args, "--user", fmt.Sprintf("%d:%d", uid, gid))
+	}
+
+	if t.Policy.mount() != ContainerMountNone {
+		workDir, err := filepath.Abs(toolCtx.WorkDir)
+		if err != nil {
+			return nil, err
+		}
+		rel, ok := relWithin(workDir, run.dir)
+		if !ok {
+			return nil, fmt.Errorf("directory %s is outside the mounted working directory", run.dir)
+		}
+		mounts, err := containerMounts(toolCtx, workDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mounts {
+			volume := m.host + ":" + m.container
+			if !run.writable {
+				volume += ":ro"
+			}
+			args = append(args, "-v", volume)
+		}
+		args = append(args, "-w", path.Join(containerWorkspace, filepath.ToSlash(rel)))
+	}
+
+	keys := make([]string, 0, len(toolCtx.Env))
+	for k := range toolCtx.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// Pass by name so values stay out of the process list.
+		args = append(args, "-e", k)
+	}
+
+	args = append(args, t.Policy.ExtraArgs...)
+	args = append(args, run.image, "sh", "-c", run.command)
+	return args, nil
+}
+
+// containerMount is a host path and where it appears in the container.
+type containerMount struct {
+	host      string
+	container string
+}
+
+// containerMounts returns the working directory mount, or, while the active
+// skill restricts paths, one mount per allowed path that exists below the
+// working directory, at the same place under containerWorkspace.
+func containerMounts(toolCtx *tools.ToolContext, workDir string) ([]containerMount, error) {
+	allowed, err := toolCtx.SandboxPaths()
+	if err != nil {
+		return nil, err
+	}
+	if allowed == nil {
+		return []containerMount{{host: workDir, container: containerWorkspace}}, nil
+	}
+	var mounts []containerMount
+	for _, p := range allowed {
+		rel, ok := relWithin(workDir, p)
+		if !ok {
+			continue
+		}
+		if _, err := os.Stat(p); err != nil {
+			// The runtime would create a missing host path.
+			continue
+		}
+		mounts = append(mounts, containerMount{host: p, container: path.Join(containerWorkspace, filepath.ToSlash(rel))})
+	}
+	return mounts, nil
+}
+
+// relWithin returns target relative to root, and false when target is
+// outside root.
+func relWithin(root, target string) (string, bool) {
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+func containerName() string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	return "agent-run-" + hex.EncodeToString(b[:])
+}
+
+// RegisterContainerTools registers the run_in_container tool with the
+// registry. It is not part of RegisterAll since it needs a policy and a
+// container runtime.
+func RegisterContainerTools(registry *tools.Registry, policy ContainerPolicy) {
+	registry.MustRegister(RunInContainerTool{Policy: policy})
+}
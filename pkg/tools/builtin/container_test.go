@@ -0,0 +1,195 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// fakeContainerRuntime prints its arguments instead of starting a container.
+const fakeContainerRuntime = `#!/bin/sh
+echo "$@"
+`
+
+func newFakeContainerTool(t *testing.T, policy ContainerPolicy) RunInContainerTool {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-docker")
+	if err := os.WriteFile(path, []byte(fakeContainerRuntime), 0o755); err != nil {
+		t.Fatalf("write fake runtime: %v", err)
+	}
+	policy.Runtime = path
+	return RunInContainerTool{Policy: policy}
+}
+
+func TestRunInContainerToolBuildsRunArgs(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "sub", "file.txt"), "x")
+
+	tool := newFakeContainerTool(t, ContainerPolicy{Image: "alpine:3", CPUs: "1", Memory: "256m"})
+	toolCtx := tools.NewToolContext(root).WithEnv("API_TOKEN", "secret")
+	result, err := tool.Execute(context.Background(), toolCtx, map[string]any{
+		"command": "ls",
+		"cwd":     "sub",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %s", result.Content)
+	}
+
+	for _, want := range []string{
+		"run --rm",
+		"--network none",
+		"--cpus 1",
+		"--memory 256m",
+		"-v " + root + ":/workspace:ro",
+		"-w /workspace/sub",
+		"-e API_TOKEN alpine:3 sh -c ls",
+	} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected %q in args, got: %q", want, result.Content)
+		}
+	}
+	if strings.Contains(result.Content, "secret") {
+		t.Errorf("env values must not appear in args: %q", result.Content)
+	}
+}
+
+func TestRunInContainerToolPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ContainerPolicy
+		input   map[string]any
+		wantErr string
+		want    string
+	}{
+		{
+			name:    "network disabled by policy",
+			policy:  ContainerPolicy{Image: "alpine:3"},
+			input:   map[string]any{"command": "ls", "network": true},
+			wantErr: "network access is disabled",
+		},
+		{
+			name:   "network allowed",
+			policy: ContainerPolicy{Image: "alpine:3", AllowNetwork: true},
+			input:  map[string]any{"command": "ls", "network": true},
+			want:   "--network bridge",
+		},
+		{
+			name:    "writable disabled by policy",
+			policy:  ContainerPolicy{Image: "alpine:3"},
+			input:   map[string]any{"command": "ls", "writable": true},
+			wantErr: "writable mounts are disabled",
+		},
+		{
+			name:   "writable allowed",
+			policy: ContainerPolicy{Image: "alpine:3", Mount: ContainerMountReadWrite},
+			input:  map[string]any{"command": "ls", "writable": true},
+			want:   ":/workspace -w /workspace ",
+		},
+		{
+			name:    "image not allowed",
+			policy:  ContainerPolicy{Image: "alpine:3"},
+			input:   map[string]any{"command": "ls", "image": "ubuntu:24.04"},
+			wantErr: `image "ubuntu:24.04" is not allowed`,
+		},
+		{
+			name:   "allowed image",
+			policy: ContainerPolicy{Image: "alpine:3", AllowedImages: []string{"ubuntu:24.04"}},
+			input:  map[string]any{"command": "ls", "image": "ubuntu:24.04"},
+			want:   "ubuntu:24.04 sh -c ls",
+		},
+		{
+			name:    "no image",
+			input:   map[string]any{"command": "ls"},
+			wantErr: "image is required",
+		},
+		{
+			name:   "no mount",
+			policy: ContainerPolicy{Image: "alpine:3", Mount: ContainerMountNone},
+			input:  map[string]any{"command": "ls"},
+			want:   "--pids-limit 256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := newFakeContainerTool(t, tt.policy)
+			result, err := tool.Execute(context.Background(), tools.NewToolContext(t.TempDir()), tt.input)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if tt.wantErr != "" {
+				if !result.IsError || !strings.Contains(result.Content, tt.wantErr) {
+					t.Fatalf("expected error %q, got: %+v", tt.wantErr, result)
+				}
+				return
+			}
+			if result.IsError {
+				t.Fatalf("unexpected tool error: %s", result.Content)
+			}
+			if !strings.Contains(result.Content, tt.want) {
+				t.Fatalf("expected %q in args, got: %q", tt.want, result.Content)
+			}
+			if tt.policy.Mount == ContainerMountNone && strings.Contains(result.Content, "/workspace") {
+				t.Fatalf("expected no workspace mount, got: %q", result.Content)
+			}
+		})
+	}
+}
+
+func TestRunInContainerToolMountsOnlySandboxPaths(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "docs", "guide.md"), "x")
+	mustWrite(t, filepath.Join(root, "CHANGELOG.md"), "x")
+
+	tool := newFakeContainerTool(t, ContainerPolicy{Image: "alpine:3"})
+	toolCtx := tools.NewToolContext(root)
+	toolCtx.Sandbox = tools.Sandbox{AllowedPaths: []string{"docs", "CHANGELOG.md", "missing"}}
+	result, err := tool.Execute(context.Background(), toolCtx, map[string]any{"command": "ls"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %s", result.Content)
+	}
+
+	for _, want := range []string{
+		"-v " + filepath.Join(root, "docs") + ":/workspace/docs:ro",
+		"-v " + filepath.Join(root, "CHANGELOG.md") + ":/workspace/CHANGELOG.md:ro",
+		"-w /workspace ",
+	} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected %q in args, got: %q", want, result.Content)
+		}
+	}
+	for _, unwanted := range []string{root + ":/workspace", "missing"} {
+		if strings.Contains(result.Content, unwanted) {
+			t.Errorf("expected no %q in args, got: %q", unwanted, result.Content)
+		}
+	}
+}
+
+func TestRunInContainerToolWritePaths(t *testing.T) {
+	readOnly := RunInContainerTool{Policy: ContainerPolicy{Mount: ContainerMountReadOnly}}
+	if paths := readOnly.WritePaths(nil, map[string]any{"writable": true}); paths == nil || len(paths) != 0 {
+		t.Fatalf("expected empty write paths for read-only runs, got %v", paths)
+	}
+
+	readWrite := RunInContainerTool{Policy: ContainerPolicy{Mount: ContainerMountReadWrite}}
+	if paths := readWrite.WritePaths(nil, map[string]any{"writable": true}); paths != nil {
+		t.Fatalf("expected nil write paths for writable runs, got %v", paths)
+	}
+
+	toolCtx := tools.NewToolContext(t.TempDir())
+	toolCtx.Sandbox = tools.Sandbox{AllowedPaths: []string{"docs"}}
+	paths := readWrite.WritePaths(toolCtx, map[string]any{"writable": true})
+	if len(paths) != 1 || paths[0] != filepath.Join(toolCtx.WorkDir, "docs") {
+		t.Fatalf("expected the allowed paths for sandboxed writable runs, got %v", paths)
+	}
+}
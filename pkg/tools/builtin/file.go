@@ -1,23 +1,45 @@
 package builtin
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
 )
 
-// ReadFileTool reads file contents.
+// ReadFileTool reads file contents, optionally a range of lines. Output is
+// capped at maxReadFileBytes; partial reads end with a note giving the line
+// range, the file's total line count, and the offset to continue from.
 type ReadFileTool struct{}
 
+const (
+	// maxReadFileBytes caps the content returned by a single read_file call.
+	maxReadFileBytes = 128 * 1024
+
+	// binarySniffLen is how much of a file is inspected to detect binaries.
+	binarySniffLen = 8000
+)
+
 func (t ReadFileTool) Name() string {
 	return "read_file"
 }
 
 func (t ReadFileTool) Description() string {
-	return "Read the contents of a file. Use this to examine source code, configuration files, or any text file in the repository."
+	return fmt.Sprintf("Read the contents of a file. Use this to examine source code, configuration files, or any text file in the repository. Use offset and limit to read a range of lines from large files; output is capped at %dKB and partial reads report the total line count and where to continue. Binary files and images are detected and not returned.", maxReadFileBytes/1024)
 }
 
 func (t ReadFileTool) InputSchema() map[string]any {
@@ -28,6 +50,14 @@ func (t ReadFileTool) InputSchema() map[string]any {
 				"type":        "string",
 				"description": "The path to the file to read, relative to the working directory",
 			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "The 1-based line number to start reading from (default 1)",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "The maximum number of lines to read (default: to the end of the file, subject to the size cap)",
+			},
 		},
 		"required": []string{"path"},
 	}
@@ -48,23 +78,170 @@ func (t ReadFileTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, i
 		return tools.NewErrorResult(err), nil
 	}
 
-	content, err := os.ReadFile(absPath)
+	offset, limit := 1, 0
+	if n, ok := input["offset"].(float64); ok && n >= 1 {
+		offset = int(n)
+	}
+	if n, ok := input["limit"].(float64); ok && n >= 1 {
+		limit = int(n)
+	}
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		return tools.NewErrorResultf("failed to read file: %v", err).WithCause(err).WithDetail("path", path), nil
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
 	if err != nil {
-		return tools.NewErrorResultf("failed to read file: %v", err), nil
+		return tools.NewErrorResultf("failed to read file: %v", err).WithCause(err).WithDetail("path", path), nil
+	}
+	if info.IsDir() {
+		return tools.NewErrorResultf("%s is a directory; use list_files to list it", path), nil
+	}
+
+	// The hash of the whole file is recorded for toolCtx.FileReads.
+	hash := sha256.New()
+	reader := bufio.NewReader(io.TeeReader(f, hash))
+	head, _ := reader.Peek(binarySniffLen)
+	if kind, binary := detectBinary(head); binary {
+		if toolCtx.FileReads != nil {
+			if _, err := io.Copy(io.Discard, reader); err == nil {
+				toolCtx.FileReads.Record(absPath, hex.EncodeToString(hash.Sum(nil)))
+			}
+		}
+		return tools.NewToolResult(fmt.Sprintf("[%s is a binary file (%s, %s); its contents are not shown]", path, kind, humanSize(info.Size()))), nil
+	}
+
+	r, err := readLines(reader, offset, limit, maxReadFileBytes)
+	if err != nil {
+		return tools.NewErrorResultf("failed to read file: %v", err).WithCause(err).WithDetail("path", path), nil
+	}
+	if toolCtx.FileReads != nil {
+		toolCtx.FileReads.Record(absPath, hex.EncodeToString(hash.Sum(nil)))
+	}
+	if offset > 1 && offset > r.total {
+		return tools.NewErrorResultf("offset %d is past the end of %s (%d lines)", offset, path, r.total), nil
+	}
+	if r.first == 1 && r.last == r.total && !r.capped {
+		return tools.NewToolResult(r.content), nil
+	}
+
+	var out strings.Builder
+	out.WriteString(r.content)
+	if r.content != "" && !strings.HasSuffix(r.content, "\n") {
+		out.WriteString("\n")
+	}
+	switch {
+	case r.last < r.first:
+		fmt.Fprintf(&out, "[line %d is longer than %d bytes and was cut off; %d lines total", r.first, maxReadFileBytes, r.total)
+	default:
+		fmt.Fprintf(&out, "[showing lines %d-%d of %d", r.first, r.last, r.total)
+	}
+	if r.capped {
+		fmt.Fprintf(&out, "; output capped at %dKB", maxReadFileBytes/1024)
+	}
+	if next := max(r.last, r.first) + 1; next <= r.total {
+		fmt.Fprintf(&out, "; continue with offset=%d", next)
+	}
+	out.WriteString("]")
+
+	return tools.NewToolResult(out.String()), nil
+}
+
+// lineRange is the result of readLines.
+type lineRange struct {
+	content string
+
+	// first and last are the 1-based lines in content. last is first-1
+	// when the only line returned was cut off at the byte cap.
+	first, last int
+	total       int
+	capped      bool
+}
+
+// readLines returns up to limit lines (0 for no limit) starting at line
+// offset, stopping before maxBytes is exceeded, and counts the total lines.
+func readLines(reader *bufio.Reader, offset, limit, maxBytes int) (lineRange, error) {
+	r := lineRange{first: offset, last: offset - 1}
+	var b strings.Builder
+	done := false
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			r.total++
+			inRange := r.total >= offset && (limit == 0 || r.total < offset+limit)
+			if inRange && !done {
+				if b.Len()+len(line) > maxBytes {
+					if b.Len() == 0 {
+						b.WriteString(strings.ToValidUTF8(line[:maxBytes], ""))
+					}
+					r.capped, done = true, true
+				} else {
+					b.WriteString(line)
+					r.last = r.total
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return lineRange{}, err
+		}
 	}
+	r.content = b.String()
+	return r, nil
+}
 
-	return tools.NewToolResult(string(content)), nil
+// detectBinary reports whether head, the start of a file, looks binary and
+// if so a description of its content type.
+func detectBinary(head []byte) (string, bool) {
+	if len(head) == 0 {
+		return "", false
+	}
+	kind := http.DetectContentType(head)
+	if strings.HasPrefix(kind, "image/") {
+		return strings.TrimSuffix(kind, "; charset=utf-8"), true
+	}
+	if bytes.IndexByte(head, 0) >= 0 || !utf8.Valid(trimPartialRune(head)) {
+		return kind, true
+	}
+	return "", false
+}
+
+// trimPartialRune drops a multi-byte rune cut off at the end of b.
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
 }
 
-// WriteFileTool writes content to a file.
+// WriteFileTool writes content to a file. The mode input chooses between
+// overwriting, creating only new files, and appending; expected_sha256
+// rejects the write when the file changed since the caller last saw it, and
+// dry_run returns the diff without writing.
 type WriteFileTool struct{}
 
+// Write modes accepted by WriteFileTool.
+const (
+	writeModeOverwrite  = "overwrite"
+	writeModeCreateOnly = "create_only"
+	writeModeAppend     = "append"
+)
+
 func (t WriteFileTool) Name() string {
 	return "write_file"
 }
 
 func (t WriteFileTool) Description() string {
-	return "Write content to a file. Creates the file if it doesn't exist, or overwrites if it does. Parent directories are created automatically."
+	return "Write content to a file. By default creates the file or overwrites it; set mode to create_only to refuse overwriting an existing file, or append to add to the end. Parent directories are created automatically unless create_dirs is false. Pass expected_sha256 (reported after each write) to fail instead of clobbering a file changed by someone else, and dry_run to preview the change as a diff."
 }
 
 func (t WriteFileTool) InputSchema() map[string]any {
@@ -79,6 +256,23 @@ func (t WriteFileTool) InputSchema() map[string]any {
 				"type":        "string",
 				"description": "The content to write to the file",
 			},
+			"mode": map[string]any{
+				"type":        "string",
+				"enum":        []string{writeModeOverwrite, writeModeCreateOnly, writeModeAppend},
+				"description": "overwrite (default) replaces the file, create_only fails if it exists, append adds to the end",
+			},
+			"create_dirs": map[string]any{
+				"type":        "boolean",
+				"description": "Create missing parent directories (default true)",
+			},
+			"expected_sha256": map[string]any{
+				"type":        "string",
+				"description": "Hex SHA-256 of the file's current content; the write fails if the file does not match",
+			},
+			"dry_run": map[string]any{
+				"type":        "boolean",
+				"description": "Return the unified diff the write would produce without changing the file (default false)",
+			},
 		},
 		"required": []string{"path", "content"},
 	}
@@ -99,33 +293,170 @@ func (t WriteFileTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 		return tools.NewErrorResultf("content is required"), nil
 	}
 
+	mode, _ := input["mode"].(string)
+	switch mode {
+	case "":
+		mode = writeModeOverwrite
+	case writeModeOverwrite, writeModeCreateOnly, writeModeAppend:
+	default:
+		return tools.NewErrorResultf("invalid mode %q: use overwrite, create_only, or append", mode), nil
+	}
+	createDirs := true
+	if v, ok := input["create_dirs"].(bool); ok {
+		createDirs = v
+	}
+	expectedHash, _ := input["expected_sha256"].(string)
+	dryRun, _ := input["dry_run"].(bool)
+
 	absPath, err := toolCtx.ValidatePath(path)
 	if err != nil {
 		return tools.NewErrorResult(err), nil
 	}
 
-	// Create parent directories if needed
+	// A nil previous content means the file does not exist.
+	previous, err := os.ReadFile(absPath)
+	switch {
+	case err == nil:
+		if previous == nil {
+			previous = []byte{}
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		previous = nil
+	default:
+		return tools.NewErrorResultf("failed to read file: %v", err).WithCause(err).WithDetail("path", path), nil
+	}
+
+	if previous != nil && mode == writeModeCreateOnly {
+		return tools.NewErrorResultf("%s already exists (sha256 %s); use mode overwrite to replace it", path, contentHash(previous)), nil
+	}
+	if expectedHash != "" {
+		if previous == nil {
+			return tools.NewErrorResultf("%s does not exist, but expected_sha256 was given", path), nil
+		}
+		if current := contentHash(previous); !strings.EqualFold(current, expectedHash) {
+			return tools.NewErrorResultf("%s has changed: expected sha256 %s, found %s; read it again before writing", path, expectedHash, current), nil
+		}
+	}
+
 	dir := filepath.Dir(absPath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return tools.NewErrorResultf("failed to create directory: %v", err), nil
+	if !createDirs {
+		if _, err := os.Stat(dir); err != nil {
+			return tools.NewErrorResultf("parent directory of %s does not exist and create_dirs is false", path), nil
+		}
+	}
+
+	next := []byte(content)
+	if mode == writeModeAppend && previous != nil {
+		next = append(append([]byte{}, previous...), content...)
 	}
 
-	if err := os.WriteFile(absPath, []byte(content), 0o644); err != nil {
-		return tools.NewErrorResultf("failed to write file: %v", err), nil
+	if dryRun {
+		diff := workspace.UnifiedDiff(diffPath(toolCtx, absPath, path), previous, next)
+		if diff == "" {
+			return tools.NewToolResult(fmt.Sprintf("[dry run] %s would not change", path)), nil
+		}
+		return tools.NewToolResult("[dry run] no changes written\n" + diff), nil
+	}
+
+	// Overwriting needs an up-to-date read, unless expected_sha256 already
+	// proved the caller knows the content. Appending is always allowed, but
+	// only an up-to-date read lets the result count as seen.
+	seen := previous == nil || expectedHash != ""
+	if !seen && toolCtx.FileReads != nil {
+		err := toolCtx.FileReads.Check(absPath)
+		if err != nil && mode == writeModeOverwrite {
+			return tools.NewErrorResult(fmt.Errorf("%s: %w", path, err)).WithDetail("path", path), nil
+		}
+		seen = err == nil
+	}
+
+	if createDirs {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return tools.NewErrorResultf("failed to create directory: %v", err).WithCause(err).WithDetail("path", path), nil
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch mode {
+	case writeModeCreateOnly:
+		// O_EXCL also catches a file created since the read above.
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	case writeModeAppend:
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	if err := writeFile(absPath, flags, []byte(content)); err != nil {
+		return tools.NewErrorResultf("failed to write file: %v", err).WithCause(err).WithDetail("path", path), nil
+	}
+
+	if toolCtx.FileReads != nil && (seen || mode != writeModeAppend) {
+		toolCtx.FileReads.Record(absPath, contentHash(next))
 	}
 
-	return tools.NewToolResult(fmt.Sprintf("Successfully wrote %d bytes to %s", len(content), path)), nil
+	verb := "wrote"
+	if mode == writeModeAppend {
+		verb = "appended"
+	}
+	return tools.NewToolResult(fmt.Sprintf("Successfully %s %d bytes to %s (sha256 %s)", verb, len(content), path, contentHash(next))), nil
 }
 
-// ListFilesTool lists files in a directory.
+// WritePaths returns the file targeted by input. Dry runs write nothing.
+func (t WriteFileTool) WritePaths(toolCtx *tools.ToolContext, input map[string]any) []string {
+	path, _ := input["path"].(string)
+	if dryRun, _ := input["dry_run"].(bool); path == "" || dryRun {
+		return []string{}
+	}
+	absPath, err := toolCtx.ValidatePath(path)
+	if err != nil {
+		return []string{}
+	}
+	return []string{absPath}
+}
+
+func writeFile(name string, flags int, data []byte) error {
+	f, err := os.OpenFile(name, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// diffPath names absPath in a diff relative to the working directory,
+// falling back to the path the caller gave.
+func diffPath(toolCtx *tools.ToolContext, absPath, path string) string {
+	if root, err := filepath.Abs(toolCtx.WorkDir); err == nil {
+		if rel, err := filepath.Rel(root, absPath); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// ListFilesTool lists files in a directory, optionally as a recursive tree.
+// Entries matched by .gitignore files and the .git directory are skipped
+// unless include_ignored is set.
 type ListFilesTool struct{}
 
+const (
+	defaultListTreeDepth  = 3
+	defaultListMaxEntries = 500
+	maxListEntries        = 5000
+)
+
 func (t ListFilesTool) Name() string {
 	return "list_files"
 }
 
 func (t ListFilesTool) Description() string {
-	return "List files and directories in a path. Returns names of entries in the directory."
+	return "List files and directories in a path. Set recursive for a tree view of subdirectories (depth-limited) and long for size and modification time columns. Entries ignored by .gitignore are skipped. Prefer this over running ls or find with bash."
 }
 
 func (t ListFilesTool) InputSchema() map[string]any {
@@ -136,6 +467,26 @@ func (t ListFilesTool) InputSchema() map[string]any {
 				"type":        "string",
 				"description": "The directory path to list, relative to the working directory. Use '.' for the current directory.",
 			},
+			"recursive": map[string]any{
+				"type":        "boolean",
+				"description": "List subdirectories as a tree (default false)",
+			},
+			"max_depth": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum directory depth in tree mode (default %d)", defaultListTreeDepth),
+			},
+			"long": map[string]any{
+				"type":        "boolean",
+				"description": "Show size and modification time columns (default false)",
+			},
+			"include_ignored": map[string]any{
+				"type":        "boolean",
+				"description": "Include entries ignored by .gitignore and the .git directory (default false)",
+			},
+			"max_entries": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum entries to return before the output is truncated (default %d, max %d)", defaultListMaxEntries, maxListEntries),
+			},
 		},
 		"required": []string{"path"},
 	}
@@ -156,21 +507,207 @@ func (t ListFilesTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 		return tools.NewErrorResult(err), nil
 	}
 
-	entries, err := os.ReadDir(absPath)
+	l := fileLister{ctx: ctx, maxDepth: 1, maxEntries: defaultListMaxEntries}
+	if recursive, _ := input["recursive"].(bool); recursive {
+		l.tree = true
+		l.maxDepth = defaultListTreeDepth
+		if n, ok := input["max_depth"].(float64); ok && n >= 1 {
+			l.maxDepth = int(n)
+		}
+	}
+	l.long, _ = input["long"].(bool)
+	if n, ok := input["max_entries"].(float64); ok && n >= 1 {
+		l.maxEntries = min(int(n), maxListEntries)
+	}
+	if includeIgnored, _ := input["include_ignored"].(bool); !includeIgnored {
+		l.ignore, l.rel = loadGitignores(toolCtx.WorkDir, absPath)
+	}
+
+	if l.tree {
+		l.out.WriteString(strings.TrimSuffix(filepath.ToSlash(path), "/") + "/\n")
+	}
+	if err := l.list(absPath, l.rel, "", 1); err != nil {
+		return tools.NewErrorResultf("failed to list directory: %v", err).WithCause(err), nil
+	}
+	if l.truncated {
+		fmt.Fprintf(&l.out, "[truncated after %d entries; list a narrower path, lower max_depth, or raise max_entries]\n", l.entries)
+	}
+
+	return tools.NewToolResult(l.out.String()), nil
+}
+
+// fileLister renders a directory listing for ListFilesTool.
+type fileLister struct {
+	ctx        context.Context
+	tree       bool
+	long       bool
+	maxDepth   int
+	maxEntries int
+
+	// ignore is nil when ignored entries are included. rel is the listed
+	// directory relative to the ignore root.
+	ignore *gitignore
+	rel    string
+
+	out       strings.Builder
+	entries   int
+	truncated bool
+}
+
+func (l *fileLister) list(dir, rel, prefix string, depth int) error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+	if l.ignore != nil {
+		l.ignore.load(rel)
+	}
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return tools.NewErrorResultf("failed to list directory: %v", err), nil
+		return err
 	}
 
-	var result string
+	visible := entries[:0]
 	for _, entry := range entries {
+		if l.ignore != nil && (entry.Name() == ".git" || l.ignore.ignored(path.Join(rel, entry.Name()), entry.IsDir())) {
+			continue
+		}
+		visible = append(visible, entry)
+	}
+
+	for i, entry := range visible {
+		if l.entries >= l.maxEntries {
+			l.truncated = true
+			return nil
+		}
+		l.entries++
+
 		name := entry.Name()
 		if entry.IsDir() {
 			name += "/"
 		}
-		result += name + "\n"
+		branch, indent := "", ""
+		if l.tree {
+			branch, indent = "├── ", "│   "
+			if i == len(visible)-1 {
+				branch, indent = "└── ", "    "
+			}
+		}
+		if l.long {
+			l.out.WriteString(longColumns(entry))
+		}
+		l.out.WriteString(prefix + branch + name + "\n")
+
+		if l.tree && entry.IsDir() && depth < l.maxDepth {
+			if err := l.list(filepath.Join(dir, entry.Name()), path.Join(rel, entry.Name()), prefix+indent, depth+1); err != nil {
+				return err
+			}
+			if l.truncated {
+				return nil
+			}
+		}
 	}
+	return nil
+}
+
+// longColumns formats the size and modification time of entry.
+func longColumns(entry os.DirEntry) string {
+	info, err := entry.Info()
+	if err != nil {
+		return fmt.Sprintf("%6s  %16s  ", "?", "?")
+	}
+	size := "-"
+	if !entry.IsDir() {
+		size = humanSize(info.Size())
+	}
+	return fmt.Sprintf("%6s  %16s  ", size, info.ModTime().Format("2006-01-02 15:04"))
+}
 
-	return tools.NewToolResult(result), nil
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, suffix := float64(n)/unit, "K"
+	for _, next := range []string{"M", "G", "T"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
+
+// loadGitignores returns a matcher rooted at the working directory with
+// the .gitignore files from there down to dir loaded, and dir relative to
+// that root. Directories outside the working directory are their own root.
+func loadGitignores(workDir, dir string) (*gitignore, string) {
+	root, err := filepath.Abs(workDir)
+	if err != nil {
+		return newGitignore(dir), ""
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return newGitignore(dir), ""
+	}
+	g := newGitignore(root)
+	if rel == "." {
+		return g, ""
+	}
+	rel = filepath.ToSlash(rel)
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		// The listed directory's own .gitignore is loaded by the walk.
+		g.load(strings.Join(parts[:i], "/"))
+	}
+	return g, rel
+}
+
+// ChangeDirTool changes the current directory for subsequent tool calls.
+type ChangeDirTool struct{}
+
+func (t ChangeDirTool) Name() string {
+	return "change_dir"
+}
+
+func (t ChangeDirTool) Description() string {
+	return "Change the current directory for subsequent tool calls. Relative paths in later calls resolve against it. The directory must be inside the working directory."
+}
+
+func (t ChangeDirTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "The directory to change to, relative to the current directory",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t ChangeDirTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	if err := toolCtx.CheckFileRead(); err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	path, ok := input["path"].(string)
+	if !ok || path == "" {
+		return tools.NewErrorResultf("path is required"), nil
+	}
+
+	dir, err := toolCtx.ChangeDir(path)
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	display := dir
+	if root, err := filepath.Abs(toolCtx.WorkDir); err == nil {
+		if rel, err := filepath.Rel(root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			display = rel
+		}
+	}
+	return tools.NewToolResult(fmt.Sprintf("Current directory: %s", display)), nil
 }
 
 // RegisterFileTools registers all file tools with the registry.
@@ -178,4 +715,6 @@ func RegisterFileTools(registry *tools.Registry) {
 	registry.MustRegister(ReadFileTool{})
 	registry.MustRegister(WriteFileTool{})
 	registry.MustRegister(ListFilesTool{})
+	registry.MustRegister(ChangeDirTool{})
+	registry.MustRegister(JSONQueryTool{})
 }
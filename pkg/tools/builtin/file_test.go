@@ -0,0 +1,293 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func runListFiles(t *testing.T, root string, input map[string]any) string {
+	t.Helper()
+	result, err := ListFilesTool{}.Execute(context.Background(), tools.NewToolContext(root), input)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %s", result.Content)
+	}
+	return result.Content
+}
+
+func TestListFilesToolTreeRespectsGitignore(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, ".gitignore"), "# build output\n*.log\n!keep.log\n/build\ncache/\n")
+	mustWrite(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/main\n")
+	mustWrite(t, filepath.Join(root, "main.go"), "package main\n")
+	mustWrite(t, filepath.Join(root, "debug.log"), "noise")
+	mustWrite(t, filepath.Join(root, "keep.log"), "kept")
+	mustWrite(t, filepath.Join(root, "build", "out.bin"), "bin")
+	mustWrite(t, filepath.Join(root, "src", "build", "gen.go"), "package build\n")
+	mustWrite(t, filepath.Join(root, "src", "cache", "entry"), "cached")
+	mustWrite(t, filepath.Join(root, "src", ".gitignore"), "*.tmp\n")
+	mustWrite(t, filepath.Join(root, "src", "a.tmp"), "tmp")
+	mustWrite(t, filepath.Join(root, "src", "lib.go"), "package src\n")
+
+	got := runListFiles(t, root, map[string]any{"path": ".", "recursive": true})
+	want := strings.Join([]string{
+		"./",
+		"├── .gitignore",
+		"├── keep.log",
+		"├── main.go",
+		"└── src/",
+		"    ├── .gitignore",
+		"    ├── build/",
+		"    │   └── gen.go",
+		"    └── lib.go",
+		"",
+	}, "\n")
+	if got != want {
+		t.Fatalf("unexpected tree:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Listing a subdirectory still applies the ancestors' .gitignore files.
+	got = runListFiles(t, root, map[string]any{"path": "src"})
+	if got != ".gitignore\nbuild/\nlib.go\n" {
+		t.Fatalf("unexpected subdirectory listing: %q", got)
+	}
+
+	got = runListFiles(t, root, map[string]any{"path": ".", "include_ignored": true})
+	for _, name := range []string{".git/", "build/", "debug.log"} {
+		if !strings.Contains(got, name+"\n") {
+			t.Fatalf("expected %s with include_ignored, got:\n%s", name, got)
+		}
+	}
+}
+
+func TestListFilesToolDepthLongAndTruncation(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "a", "b", "c", "deep.txt"), "deep")
+	mustWrite(t, filepath.Join(root, "big.txt"), strings.Repeat("x", 1536))
+
+	got := runListFiles(t, root, map[string]any{"path": ".", "recursive": true, "max_depth": float64(2)})
+	if !strings.Contains(got, "b/") || strings.Contains(got, "c/") {
+		t.Fatalf("expected depth limit of 2, got:\n%s", got)
+	}
+
+	got = runListFiles(t, root, map[string]any{"path": ".", "long": true})
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(strings.TrimSpace(lines[0]), "- ") || !strings.HasPrefix(strings.TrimSpace(lines[1]), "1.5K ") {
+		t.Fatalf("unexpected long listing:\n%s", got)
+	}
+
+	got = runListFiles(t, root, map[string]any{"path": ".", "recursive": true, "max_entries": float64(2)})
+	if !strings.Contains(got, "[truncated after 2 entries") || strings.Contains(got, "big.txt") {
+		t.Fatalf("expected truncated output, got:\n%s", got)
+	}
+}
+
+func runReadFile(t *testing.T, root string, input map[string]any) tools.ToolResult {
+	t.Helper()
+	result, err := ReadFileTool{}.Execute(context.Background(), tools.NewToolContext(root), input)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	return result
+}
+
+func TestReadFileToolLineRanges(t *testing.T) {
+	root := t.TempDir()
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	content := strings.Join(lines, "\n") + "\n"
+	mustWrite(t, filepath.Join(root, "f.txt"), content)
+
+	if got := runReadFile(t, root, map[string]any{"path": "f.txt"}); got.Content != content {
+		t.Fatalf("expected the whole file unchanged, got %q", got.Content)
+	}
+
+	got := runReadFile(t, root, map[string]any{"path": "f.txt", "offset": float64(3), "limit": float64(2)})
+	if got.Content != "line 3\nline 4\n[showing lines 3-4 of 10; continue with offset=5]" {
+		t.Fatalf("unexpected range: %q", got.Content)
+	}
+
+	got = runReadFile(t, root, map[string]any{"path": "f.txt", "offset": float64(9)})
+	if got.Content != "line 9\nline 10\n[showing lines 9-10 of 10]" {
+		t.Fatalf("unexpected tail: %q", got.Content)
+	}
+
+	if got := runReadFile(t, root, map[string]any{"path": "f.txt", "offset": float64(11)}); !got.IsError {
+		t.Fatalf("expected an error past the end, got %q", got.Content)
+	}
+}
+
+func TestReadFileToolCapsOutput(t *testing.T) {
+	root := t.TempDir()
+	line := strings.Repeat("x", 1023) + "\n"
+	mustWrite(t, filepath.Join(root, "big.txt"), strings.Repeat(line, 200))
+	mustWrite(t, filepath.Join(root, "long.txt"), strings.Repeat("y", maxReadFileBytes+10)+"\nend\n")
+
+	got := runReadFile(t, root, map[string]any{"path": "big.txt"})
+	if !strings.HasSuffix(got.Content, "[showing lines 1-128 of 200; output capped at 128KB; continue with offset=129]") {
+		t.Fatalf("unexpected capped read: %q", got.Content[len(got.Content)-100:])
+	}
+
+	got = runReadFile(t, root, map[string]any{"path": "long.txt"})
+	if !strings.HasSuffix(got.Content, "[line 1 is longer than 131072 bytes and was cut off; 2 lines total; output capped at 128KB; continue with offset=2]") {
+		t.Fatalf("unexpected long-line read: %q", got.Content[len(got.Content)-100:])
+	}
+}
+
+func TestReadFileToolDetectsBinary(t *testing.T) {
+	root := t.TempDir()
+	mustWrite(t, filepath.Join(root, "img.png"), "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	mustWrite(t, filepath.Join(root, "blob.bin"), "abc\x00def")
+	// A multi-byte rune cut at the sniff window is still text.
+	mustWrite(t, filepath.Join(root, "text.txt"), strings.Repeat("a", binarySniffLen-1)+"é")
+
+	if got := runReadFile(t, root, map[string]any{"path": "img.png"}); !strings.Contains(got.Content, "binary file (image/png") {
+		t.Fatalf("expected image detection, got %q", got.Content)
+	}
+	if got := runReadFile(t, root, map[string]any{"path": "blob.bin"}); !strings.Contains(got.Content, "binary file (application/octet-stream") {
+		t.Fatalf("expected binary detection, got %q", got.Content)
+	}
+	if got := runReadFile(t, root, map[string]any{"path": "text.txt"}); strings.Contains(got.Content, "binary") {
+		t.Fatalf("expected text, got %q", got.Content[:40])
+	}
+}
+
+func runWriteFile(t *testing.T, root string, input map[string]any) tools.ToolResult {
+	t.Helper()
+	result, err := WriteFileTool{}.Execute(context.Background(), tools.NewToolContext(root), input)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	return result
+}
+
+func TestWriteFileToolModes(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "notes.txt")
+
+	if got := runWriteFile(t, root, map[string]any{"path": "notes.txt", "content": "one\n", "mode": "create_only"}); got.IsError {
+		t.Fatalf("create_only on a new file failed: %s", got.Content)
+	}
+	if got := runWriteFile(t, root, map[string]any{"path": "notes.txt", "content": "clobber\n", "mode": "create_only"}); !got.IsError || !strings.Contains(got.Content, "already exists") {
+		t.Fatalf("expected create_only to refuse an existing file, got %q", got.Content)
+	}
+	if got := runWriteFile(t, root, map[string]any{"path": "notes.txt", "content": "two\n", "mode": "append"}); got.IsError || !strings.Contains(got.Content, contentHash([]byte("one\ntwo\n"))) {
+		t.Fatalf("unexpected append result: %q", got.Content)
+	}
+	if got := readTestFile(t, target); got != "one\ntwo\n" {
+		t.Fatalf("unexpected content after append: %q", got)
+	}
+	if got := runWriteFile(t, root, map[string]any{"path": "notes.txt", "content": "x", "mode": "replace"}); !got.IsError {
+		t.Fatalf("expected an invalid mode error, got %q", got.Content)
+	}
+
+	if got := runWriteFile(t, root, map[string]any{"path": "a/b/c.txt", "content": "x", "create_dirs": false}); !got.IsError {
+		t.Fatalf("expected missing parent error, got %q", got.Content)
+	}
+	if got := runWriteFile(t, root, map[string]any{"path": "a/b/c.txt", "content": "x"}); got.IsError {
+		t.Fatalf("expected parent directories to be created: %s", got.Content)
+	}
+}
+
+func TestWriteFileToolExpectedHashAndDryRun(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "main.go")
+	mustWrite(t, target, "package main\n\nfunc main() {}\n")
+	hash := contentHash([]byte("package main\n\nfunc main() {}\n"))
+
+	stale := contentHash([]byte("package main\n"))
+	if got := runWriteFile(t, root, map[string]any{"path": "main.go", "content": "x", "expected_sha256": stale}); !got.IsError || !strings.Contains(got.Content, "has changed") {
+		t.Fatalf("expected a hash mismatch, got %q", got.Content)
+	}
+
+	got := runWriteFile(t, root, map[string]any{
+		"path":            "main.go",
+		"content":         "package main\n\nfunc main() { run() }\n",
+		"expected_sha256": hash,
+		"dry_run":         true,
+	})
+	if got.IsError || !strings.Contains(got.Content, "--- a/main.go\n+++ b/main.go\n") || !strings.Contains(got.Content, "-func main() {}\n+func main() { run() }\n") {
+		t.Fatalf("unexpected dry run diff: %q", got.Content)
+	}
+	if content := readTestFile(t, target); content != "package main\n\nfunc main() {}\n" {
+		t.Fatalf("dry run changed the file: %q", content)
+	}
+	if paths := (WriteFileTool{}).WritePaths(tools.NewToolContext(root), map[string]any{"path": "main.go", "dry_run": true}); len(paths) != 0 {
+		t.Fatalf("expected no write paths for a dry run, got %v", paths)
+	}
+
+	if got := runWriteFile(t, root, map[string]any{"path": "main.go", "content": "package main\n", "expected_sha256": strings.ToUpper(hash)}); got.IsError {
+		t.Fatalf("expected the write to pass the hash check: %s", got.Content)
+	}
+}
+
+func TestWriteFileToolRequiresFreshRead(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "config.yaml")
+	mustWrite(t, target, "a: 1\n")
+	toolCtx := tools.NewToolContext(root).WithFileReads(tools.NewFileReads())
+	run := func(tool tools.Tool, input map[string]any) tools.ToolResult {
+		t.Helper()
+		result, err := tool.Execute(context.Background(), toolCtx, input)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		return result
+	}
+
+	got := run(WriteFileTool{}, map[string]any{"path": "config.yaml", "content": "a: 2\n"})
+	if !got.IsError || got.ErrorCode != tools.ErrCodeStaleRead || !strings.Contains(got.Content, "has not been read") {
+		t.Fatalf("expected a blind overwrite to be refused, got %#v", got)
+	}
+	if got := run(WriteFileTool{}, map[string]any{"path": "new.txt", "content": "x"}); got.IsError {
+		t.Fatalf("expected a new file to be written: %s", got.Content)
+	}
+
+	run(ReadFileTool{}, map[string]any{"path": "config.yaml"})
+	if got := run(WriteFileTool{}, map[string]any{"path": "config.yaml", "content": "a: 2\n"}); got.IsError {
+		t.Fatalf("expected the write after a read to succeed: %s", got.Content)
+	}
+	if got := run(WriteFileTool{}, map[string]any{"path": "config.yaml", "content": "a: 3\n"}); got.IsError {
+		t.Fatalf("expected the run's own write to count as read: %s", got.Content)
+	}
+
+	// Someone else edits the file; a touch alone does not count.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(target, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(WriteFileTool{}, map[string]any{"path": "config.yaml", "content": "a: 4\n"}); got.IsError {
+		t.Fatalf("expected a touched but unchanged file to be writable: %s", got.Content)
+	}
+	mustWrite(t, target, "a: 4\nb: 5\n")
+	got = run(WriteFileTool{}, map[string]any{"path": "config.yaml", "content": "a: 6\n"})
+	if !got.IsError || !strings.Contains(got.Content, "changed since it was last read") {
+		t.Fatalf("expected a stale read to be refused, got %q", got.Content)
+	}
+	if got := run(WriteFileTool{}, map[string]any{"path": "config.yaml", "content": "c: 7\n", "mode": "append"}); got.IsError {
+		t.Fatalf("expected appending to be allowed: %s", got.Content)
+	}
+	if content := readTestFile(t, target); content != "a: 4\nb: 5\nc: 7\n" {
+		t.Fatalf("unexpected content: %q", content)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
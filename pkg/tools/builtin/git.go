@@ -36,7 +36,7 @@ func (t GitStatusTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 		return tools.NewErrorResult(err), nil
 	}
 
-	output, err := runGitCommand(ctx, toolCtx.WorkDir, "status", "--porcelain")
+	output, err := runGitCommand(ctx, toolCtx.Cwd(), "status", "--porcelain")
 	if err != nil {
 		return tools.NewErrorResultf("git status failed: %v", err), nil
 	}
@@ -87,7 +87,7 @@ func (t GitDiffTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, in
 		args = append(args, "--", path)
 	}
 
-	output, err := runGitCommand(ctx, toolCtx.WorkDir, args...)
+	output, err := runGitCommand(ctx, toolCtx.Cwd(), args...)
 	if err != nil {
 		return tools.NewErrorResultf("git diff failed: %v", err), nil
 	}
@@ -109,20 +109,13 @@ func (t GitLogTool) Description() string {
 	return "Show commit history. Returns the last N commits with hash, author, date, and message."
 }
 
+type gitLogInput struct {
+	Count   int  `json:"count,omitempty" jsonschema:"description=Number of commits to show (default: 10, max: 50)"`
+	Oneline bool `json:"oneline,omitempty" jsonschema:"description=Show each commit on a single line"`
+}
+
 func (t GitLogTool) InputSchema() map[string]any {
-	return map[string]any{
-		"type": "object",
-		"properties": map[string]any{
-			"count": map[string]any{
-				"type":        "integer",
-				"description": "Number of commits to show (default: 10, max: 50)",
-			},
-			"oneline": map[string]any{
-				"type":        "boolean",
-				"description": "Show each commit on a single line",
-			},
-		},
-	}
+	return tools.MustSchemaFor[gitLogInput]()
 }
 
 func (t GitLogTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
@@ -130,22 +123,23 @@ func (t GitLogTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, inp
 		return tools.NewErrorResult(err), nil
 	}
 
+	in, err := tools.DecodeInput[gitLogInput](input)
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
 	count := 10
-	if n, ok := input["count"].(float64); ok && n > 0 {
-		count = int(n)
-		if count > 50 {
-			count = 50
-		}
+	if in.Count > 0 {
+		count = min(in.Count, 50)
 	}
 
 	args := []string{"log", fmt.Sprintf("-n%d", count)}
-	if oneline, ok := input["oneline"].(bool); ok && oneline {
+	if in.Oneline {
 		args = append(args, "--oneline")
 	} else {
 		args = append(args, "--format=%h %an <%ae> %ai%n%s%n")
 	}
 
-	output, err := runGitCommand(ctx, toolCtx.WorkDir, args...)
+	output, err := runGitCommand(ctx, toolCtx.Cwd(), args...)
 	if err != nil {
 		return tools.NewErrorResultf("git log failed: %v", err), nil
 	}
@@ -164,18 +158,12 @@ func (t GitAddTool) Description() string {
 	return "Stage files for the next commit. Can stage specific files or all changes."
 }
 
+type gitAddInput struct {
+	Paths []string `json:"paths" jsonschema:"description=Files to stage. Use ['.'] to stage all changes."`
+}
+
 func (t GitAddTool) InputSchema() map[string]any {
-	return map[string]any{
-		"type": "object",
-		"properties": map[string]any{
-			"paths": map[string]any{
-				"type":        "array",
-				"items":       map[string]any{"type": "string"},
-				"description": "Files to stage. Use ['.'] to stage all changes.",
-			},
-		},
-		"required": []string{"paths"},
-	}
+	return tools.MustSchemaFor[gitAddInput]()
 }
 
 func (t GitAddTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
@@ -183,19 +171,13 @@ func (t GitAddTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, inp
 		return tools.NewErrorResult(err), nil
 	}
 
-	pathsRaw, ok := input["paths"].([]any)
-	if !ok || len(pathsRaw) == 0 {
+	in, err := tools.DecodeInput[gitAddInput](input)
+	if err != nil || len(in.Paths) == 0 {
 		return tools.NewErrorResultf("paths is required"), nil
 	}
 
-	args := []string{"add"}
-	for _, p := range pathsRaw {
-		if path, ok := p.(string); ok {
-			args = append(args, path)
-		}
-	}
-
-	_, err := runGitCommand(ctx, toolCtx.WorkDir, args...)
+	args := append([]string{"add"}, in.Paths...)
+	_, err = runGitCommand(ctx, toolCtx.Cwd(), args...)
 	if err != nil {
 		return tools.NewErrorResultf("git add failed: %v", err), nil
 	}
@@ -214,17 +196,12 @@ func (t GitCommitTool) Description() string {
 	return "Create a new commit with staged changes. Requires a commit message."
 }
 
+type gitCommitInput struct {
+	Message string `json:"message" jsonschema:"description=The commit message"`
+}
+
 func (t GitCommitTool) InputSchema() map[string]any {
-	return map[string]any{
-		"type": "object",
-		"properties": map[string]any{
-			"message": map[string]any{
-				"type":        "string",
-				"description": "The commit message",
-			},
-		},
-		"required": []string{"message"},
-	}
+	return tools.MustSchemaFor[gitCommitInput]()
 }
 
 func (t GitCommitTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
@@ -232,12 +209,12 @@ func (t GitCommitTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 		return tools.NewErrorResult(err), nil
 	}
 
-	message, ok := input["message"].(string)
-	if !ok || message == "" {
+	in, err := tools.DecodeInput[gitCommitInput](input)
+	if err != nil || in.Message == "" {
 		return tools.NewErrorResultf("message is required"), nil
 	}
 
-	output, err := runGitCommand(ctx, toolCtx.WorkDir, "commit", "-m", message)
+	output, err := runGitCommand(ctx, toolCtx.Cwd(), "commit", "-m", in.Message)
 	if err != nil {
 		return tools.NewErrorResultf("git commit failed: %v\n%s", err, output), nil
 	}
@@ -256,22 +233,13 @@ func (t GitBranchTool) Description() string {
 	return "List, create, or switch branches."
 }
 
+type gitBranchInput struct {
+	Action string `json:"action" jsonschema:"enum=list|create|switch,description=Action to perform: list, create, or switch"`
+	Name   string `json:"name,omitempty" jsonschema:"description=Branch name (required for create/switch)"`
+}
+
 func (t GitBranchTool) InputSchema() map[string]any {
-	return map[string]any{
-		"type": "object",
-		"properties": map[string]any{
-			"action": map[string]any{
-				"type":        "string",
-				"enum":        []string{"list", "create", "switch"},
-				"description": "Action to perform: list, create, or switch",
-			},
-			"name": map[string]any{
-				"type":        "string",
-				"description": "Branch name (required for create/switch)",
-			},
-		},
-		"required": []string{"action"},
-	}
+	return tools.MustSchemaFor[gitBranchInput]()
 }
 
 func (t GitBranchTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
@@ -279,32 +247,31 @@ func (t GitBranchTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 		return tools.NewErrorResult(err), nil
 	}
 
-	action, ok := input["action"].(string)
-	if !ok {
+	in, err := tools.DecodeInput[gitBranchInput](input)
+	if err != nil || in.Action == "" {
 		return tools.NewErrorResultf("action is required"), nil
 	}
+	action := in.Action
 
 	var args []string
 	switch action {
 	case "list":
 		args = []string{"branch", "-a"}
 	case "create":
-		name, ok := input["name"].(string)
-		if !ok || name == "" {
+		if in.Name == "" {
 			return tools.NewErrorResultf("name is required for create"), nil
 		}
-		args = []string{"branch", name}
+		args = []string{"branch", in.Name}
 	case "switch":
-		name, ok := input["name"].(string)
-		if !ok || name == "" {
+		if in.Name == "" {
 			return tools.NewErrorResultf("name is required for switch"), nil
 		}
-		args = []string{"checkout", name}
+		args = []string{"checkout", in.Name}
 	default:
 		return tools.NewErrorResultf("invalid action: %s", action), nil
 	}
 
-	output, err := runGitCommand(ctx, toolCtx.WorkDir, args...)
+	output, err := runGitCommand(ctx, toolCtx.Cwd(), args...)
 	if err != nil {
 		return tools.NewErrorResultf("git %s failed: %v\n%s", action, err, output), nil
 	}
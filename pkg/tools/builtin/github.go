@@ -3,16 +3,37 @@ package builtin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
 )
 
-const githubAPIBase = "https://api.github.com"
+const (
+	githubAPIBase = "https://api.github.com"
+
+	// GitHubTokenEnv and GitHubCLITokenEnv are ToolContext.Env fallbacks for
+	// ToolContext.GitHubToken.
+	GitHubTokenEnv    = "GITHUB_TOKEN"
+	GitHubCLITokenEnv = "GH_TOKEN"
+
+	// GitHubRepositoryEnv ("owner/repo") is the fallback for
+	// ToolContext.RepoOwner and RepoName.
+	GitHubRepositoryEnv = "GITHUB_REPOSITORY"
+
+	// GitHubAPIURLEnv overrides the API base URL (e.g. GitHub Enterprise).
+	GitHubAPIURLEnv = "GITHUB_API_URL"
+
+	// GitHubDryRunEnv makes write tools report the request they would send
+	// instead of sending it.
+	GitHubDryRunEnv = "GITHUB_DRY_RUN"
+)
 
 // GitHubGetIssueTool retrieves issue details.
 type GitHubGetIssueTool struct{}
@@ -47,34 +68,19 @@ func (t GitHubGetIssueTool) InputSchema() map[string]any {
 }
 
 func (t GitHubGetIssueTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
-	if err := toolCtx.CheckGitHub(); err != nil {
+	gh, err := newGitHubTarget(toolCtx, input)
+	if err != nil {
 		return tools.NewErrorResult(err), nil
 	}
-	if toolCtx.GitHubToken == "" {
-		return tools.NewErrorResultf("GitHub token not configured"), nil
-	}
-
-	owner := toolCtx.RepoOwner
-	if o, ok := input["owner"].(string); ok && o != "" {
-		owner = o
-	}
-	repo := toolCtx.RepoName
-	if r, ok := input["repo"].(string); ok && r != "" {
-		repo = r
-	}
-	if owner == "" || repo == "" {
-		return tools.NewErrorResultf("owner and repo are required"), nil
-	}
 
 	number, ok := input["number"].(float64)
 	if !ok || number <= 0 {
 		return tools.NewErrorResultf("number is required"), nil
 	}
 
-	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d", githubAPIBase, owner, repo, int(number))
-	body, err := githubRequest(ctx, "GET", url, toolCtx.GitHubToken, nil)
+	body, err := gh.request(ctx, http.MethodGet, fmt.Sprintf("issues/%d", int(number)), nil)
 	if err != nil {
-		return tools.NewErrorResultf("failed to get issue: %v", err), nil
+		return githubErrorResult("failed to get issue: %v", err), nil
 	}
 
 	// Parse and format response
@@ -119,30 +125,17 @@ func (t GitHubCreateCommentTool) InputSchema() map[string]any {
 				"type":        "string",
 				"description": "Comment body (supports markdown)",
 			},
+			"dry_run": githubDryRunSchema(),
 		},
 		"required": []string{"number", "body"},
 	}
 }
 
 func (t GitHubCreateCommentTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
-	if err := toolCtx.CheckGitHub(); err != nil {
+	gh, err := newGitHubTarget(toolCtx, input)
+	if err != nil {
 		return tools.NewErrorResult(err), nil
 	}
-	if toolCtx.GitHubToken == "" {
-		return tools.NewErrorResultf("GitHub token not configured"), nil
-	}
-
-	owner := toolCtx.RepoOwner
-	if o, ok := input["owner"].(string); ok && o != "" {
-		owner = o
-	}
-	repo := toolCtx.RepoName
-	if r, ok := input["repo"].(string); ok && r != "" {
-		repo = r
-	}
-	if owner == "" || repo == "" {
-		return tools.NewErrorResultf("owner and repo are required"), nil
-	}
 
 	number, ok := input["number"].(float64)
 	if !ok || number <= 0 {
@@ -154,13 +147,16 @@ func (t GitHubCreateCommentTool) Execute(ctx context.Context, toolCtx *tools.Too
 		return tools.NewErrorResultf("body is required"), nil
 	}
 
-	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", githubAPIBase, owner, repo, int(number))
+	path := fmt.Sprintf("issues/%d/comments", int(number))
 	payload := map[string]string{"body": body}
+	if gh.dryRun(input) {
+		return gh.dryRunResult(http.MethodPost, path, payload), nil
+	}
 	payloadBytes, _ := json.Marshal(payload)
 
-	respBody, err := githubRequest(ctx, "POST", url, toolCtx.GitHubToken, payloadBytes)
+	respBody, err := gh.request(ctx, http.MethodPost, path, payloadBytes)
 	if err != nil {
-		return tools.NewErrorResultf("failed to create comment: %v", err), nil
+		return githubErrorResult("failed to create comment: %v", err), nil
 	}
 
 	var comment map[string]any
@@ -217,24 +213,10 @@ func (t GitHubListIssuesTool) InputSchema() map[string]any {
 }
 
 func (t GitHubListIssuesTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
-	if err := toolCtx.CheckGitHub(); err != nil {
+	gh, err := newGitHubTarget(toolCtx, input)
+	if err != nil {
 		return tools.NewErrorResult(err), nil
 	}
-	if toolCtx.GitHubToken == "" {
-		return tools.NewErrorResultf("GitHub token not configured"), nil
-	}
-
-	owner := toolCtx.RepoOwner
-	if o, ok := input["owner"].(string); ok && o != "" {
-		owner = o
-	}
-	repo := toolCtx.RepoName
-	if r, ok := input["repo"].(string); ok && r != "" {
-		repo = r
-	}
-	if owner == "" || repo == "" {
-		return tools.NewErrorResultf("owner and repo are required"), nil
-	}
 
 	state := "open"
 	if s, ok := input["state"].(string); ok && s != "" {
@@ -249,14 +231,14 @@ func (t GitHubListIssuesTool) Execute(ctx context.Context, toolCtx *tools.ToolCo
 		}
 	}
 
-	url := fmt.Sprintf("%s/repos/%s/%s/issues?state=%s&per_page=%d", githubAPIBase, owner, repo, state, limit)
+	path := fmt.Sprintf("issues?state=%s&per_page=%d", state, limit)
 	if labels, ok := input["labels"].(string); ok && labels != "" {
-		url += "&labels=" + labels
+		path += "&labels=" + labels
 	}
 
-	body, err := githubRequest(ctx, "GET", url, toolCtx.GitHubToken, nil)
+	body, err := gh.request(ctx, http.MethodGet, path, nil)
 	if err != nil {
-		return tools.NewErrorResultf("failed to list issues: %v", err), nil
+		return githubErrorResult("failed to list issues: %v", err), nil
 	}
 
 	var issues []map[string]any
@@ -289,6 +271,291 @@ func (t GitHubListIssuesTool) Execute(ctx context.Context, toolCtx *tools.ToolCo
 	return tools.NewToolResult(output), nil
 }
 
+// GitHubCreatePullRequestTool opens a pull request.
+type GitHubCreatePullRequestTool struct{}
+
+func (t GitHubCreatePullRequestTool) Name() string {
+	return "github_create_pr"
+}
+
+func (t GitHubCreatePullRequestTool) Description() string {
+	return "Open a GitHub pull request from a pushed head branch into a base branch."
+}
+
+func (t GitHubCreatePullRequestTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"owner": map[string]any{
+				"type":        "string",
+				"description": "Repository owner (defaults to current repo owner)",
+			},
+			"repo": map[string]any{
+				"type":        "string",
+				"description": "Repository name (defaults to current repo name)",
+			},
+			"title": map[string]any{
+				"type":        "string",
+				"description": "Pull request title",
+			},
+			"head": map[string]any{
+				"type":        "string",
+				"description": "Branch containing the changes (use owner:branch for forks)",
+			},
+			"base": map[string]any{
+				"type":        "string",
+				"description": "Branch to merge into",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "Pull request description (supports markdown)",
+			},
+			"draft": map[string]any{
+				"type":        "boolean",
+				"description": "Open as a draft pull request (default: false)",
+			},
+			"dry_run": githubDryRunSchema(),
+		},
+		"required": []string{"title", "head", "base"},
+	}
+}
+
+func (t GitHubCreatePullRequestTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	gh, err := newGitHubTarget(toolCtx, input)
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	payload := map[string]any{}
+	for _, field := range []string{"title", "head", "base"} {
+		v, _ := input[field].(string)
+		if strings.TrimSpace(v) == "" {
+			return tools.NewErrorResultf("%s is required", field), nil
+		}
+		payload[field] = v
+	}
+	if body, ok := input["body"].(string); ok && body != "" {
+		payload["body"] = body
+	}
+	if draft, ok := input["draft"].(bool); ok && draft {
+		payload["draft"] = true
+	}
+
+	if gh.dryRun(input) {
+		return gh.dryRunResult(http.MethodPost, "pulls", payload), nil
+	}
+	payloadBytes, _ := json.Marshal(payload)
+
+	respBody, err := gh.request(ctx, http.MethodPost, "pulls", payloadBytes)
+	if err != nil {
+		return githubErrorResult("failed to create pull request: %v", err), nil
+	}
+
+	var pr map[string]any
+	if err := json.Unmarshal(respBody, &pr); err != nil {
+		return tools.NewErrorResultf("failed to parse response: %v", err), nil
+	}
+
+	number := 0
+	if n, ok := pr["number"].(float64); ok {
+		number = int(n)
+	}
+	htmlURL, _ := pr["html_url"].(string)
+	return tools.NewToolResult(fmt.Sprintf("Pull request #%d created: %s", number, htmlURL)), nil
+}
+
+// GitHubListChecksTool lists check runs for a commit or pull request.
+type GitHubListChecksTool struct{}
+
+func (t GitHubListChecksTool) Name() string {
+	return "github_list_checks"
+}
+
+func (t GitHubListChecksTool) Description() string {
+	return "List CI check runs (name, status, conclusion) for a commit ref or pull request head."
+}
+
+func (t GitHubListChecksTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"owner": map[string]any{
+				"type":        "string",
+				"description": "Repository owner (defaults to current repo owner)",
+			},
+			"repo": map[string]any{
+				"type":        "string",
+				"description": "Repository name (defaults to current repo name)",
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Commit SHA, branch, or tag",
+			},
+			"number": map[string]any{
+				"type":        "integer",
+				"description": "Pull request number; checks of its head commit are listed when ref is empty",
+			},
+		},
+	}
+}
+
+func (t GitHubListChecksTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	gh, err := newGitHubTarget(toolCtx, input)
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	ref, _ := input["ref"].(string)
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		number, ok := input["number"].(float64)
+		if !ok || number <= 0 {
+			return tools.NewErrorResultf("ref or number is required"), nil
+		}
+		body, err := gh.request(ctx, http.MethodGet, fmt.Sprintf("pulls/%d", int(number)), nil)
+		if err != nil {
+			return githubErrorResult("failed to get pull request: %v", err), nil
+		}
+		var pr struct {
+			Head struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		}
+		if err := json.Unmarshal(body, &pr); err != nil || pr.Head.SHA == "" {
+			return tools.NewErrorResultf("failed to resolve pull request head commit"), nil
+		}
+		ref = pr.Head.SHA
+	}
+
+	body, err := gh.request(ctx, http.MethodGet, fmt.Sprintf("commits/%s/check-runs?per_page=100", url.PathEscape(ref)), nil)
+	if err != nil {
+		return githubErrorResult("failed to list checks: %v", err), nil
+	}
+
+	var checks struct {
+		TotalCount int `json:"total_count"`
+		CheckRuns  []struct {
+			Name       string `json:"name"`
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+			HTMLURL    string `json:"html_url"`
+		} `json:"check_runs"`
+	}
+	if err := json.Unmarshal(body, &checks); err != nil {
+		return tools.NewErrorResultf("failed to parse response: %v", err), nil
+	}
+	if len(checks.CheckRuns) == 0 {
+		return tools.NewToolResult(fmt.Sprintf("No checks found for %s", ref)), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Checks for %s (%d):\n", ref, checks.TotalCount)
+	for _, run := range checks.CheckRuns {
+		state := run.Status
+		if run.Conclusion != "" {
+			state = run.Conclusion
+		}
+		fmt.Fprintf(&result, "- %s: %s", run.Name, state)
+		if run.HTMLURL != "" {
+			fmt.Fprintf(&result, " (%s)", run.HTMLURL)
+		}
+		result.WriteString("\n")
+	}
+	return tools.NewToolResult(result.String()), nil
+}
+
+// githubTarget is the resolved repository, credentials, and API endpoint
+// for one GitHub tool call.
+type githubTarget struct {
+	owner   string
+	repo    string
+	token   string
+	baseURL string
+	env     map[string]string
+}
+
+// newGitHubTarget resolves the repository from the owner/repo inputs,
+// falling back to ToolContext.RepoOwner/RepoName and GITHUB_REPOSITORY, and
+// the token from ToolContext.GitHubToken, GITHUB_TOKEN, or GH_TOKEN.
+func newGitHubTarget(toolCtx *tools.ToolContext, input map[string]any) (githubTarget, error) {
+	if err := toolCtx.CheckGitHub(); err != nil {
+		return githubTarget{}, err
+	}
+
+	gh := githubTarget{
+		owner:   toolCtx.RepoOwner,
+		repo:    toolCtx.RepoName,
+		token:   toolCtx.GitHubToken,
+		baseURL: githubAPIBase,
+		env:     toolCtx.Env,
+	}
+	if gh.token == "" {
+		gh.token = firstNonEmpty(gh.env[GitHubTokenEnv], gh.env[GitHubCLITokenEnv])
+	}
+	if gh.token == "" {
+		return githubTarget{}, fmt.Errorf("GitHub token not configured")
+	}
+	if gh.owner == "" && gh.repo == "" {
+		gh.owner, gh.repo, _ = strings.Cut(gh.env[GitHubRepositoryEnv], "/")
+	}
+	if o, ok := input["owner"].(string); ok && o != "" {
+		gh.owner = o
+	}
+	if r, ok := input["repo"].(string); ok && r != "" {
+		gh.repo = r
+	}
+	if gh.owner == "" || gh.repo == "" {
+		return githubTarget{}, fmt.Errorf("owner and repo are required")
+	}
+	if u := strings.TrimSpace(gh.env[GitHubAPIURLEnv]); u != "" {
+		gh.baseURL = strings.TrimRight(u, "/")
+	}
+	return gh, nil
+}
+
+// url returns the API URL of path relative to the repository.
+func (gh githubTarget) url(path string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/%s", gh.baseURL, gh.owner, gh.repo, path)
+}
+
+// request makes an authenticated request to path relative to the repository.
+func (gh githubTarget) request(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	return githubRequest(ctx, method, gh.url(path), gh.token, body)
+}
+
+// dryRun reports whether a write should only be described: the dry_run
+// input wins over GITHUB_DRY_RUN.
+func (gh githubTarget) dryRun(input map[string]any) bool {
+	if v, ok := input["dry_run"].(bool); ok {
+		return v
+	}
+	v, _ := strconv.ParseBool(strings.TrimSpace(gh.env[GitHubDryRunEnv]))
+	return v
+}
+
+// dryRunResult describes the request a write tool would have sent.
+func (gh githubTarget) dryRunResult(method, path string, payload any) tools.ToolResult {
+	data, _ := json.MarshalIndent(payload, "", "  ")
+	return tools.NewToolResult(fmt.Sprintf("[dry-run] %s %s\n%s", method, gh.url(path), data)).
+		WithMetadata("dry_run", true)
+}
+
+func githubDryRunSchema() map[string]any {
+	return map[string]any{
+		"type":        "boolean",
+		"description": "Describe the request without sending it (default: GITHUB_DRY_RUN or false)",
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 // githubRequest makes an authenticated request to the GitHub API.
 func githubRequest(ctx context.Context, method, url, token string, body []byte) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -323,12 +590,39 @@ func githubRequest(ctx context.Context, method, url, token string, body []byte)
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, &githubAPIError{status: resp.StatusCode, body: string(respBody)}
 	}
 
 	return respBody, nil
 }
 
+// githubAPIError is a GitHub API response with an error status.
+type githubAPIError struct {
+	status int
+	body   string
+}
+
+func (e *githubAPIError) Error() string {
+	return fmt.Sprintf("GitHub API error %d: %s", e.status, e.body)
+}
+
+// githubErrorResult formats a failed GitHub call. Auth failures are
+// permission_denied; rate limits and server errors are retryable.
+func githubErrorResult(format string, err error) tools.ToolResult {
+	result := tools.NewErrorResultf(format, err).WithCause(err)
+	var apiErr *githubAPIError
+	if errors.As(err, &apiErr) {
+		result = result.WithDetail("status", apiErr.status)
+		switch {
+		case apiErr.status == http.StatusUnauthorized || apiErr.status == http.StatusForbidden:
+			result = result.WithErrorCode(tools.ErrCodePermissionDenied, false)
+		case apiErr.status == http.StatusTooManyRequests || apiErr.status >= 500:
+			result = result.WithErrorCode(tools.ErrCodeUnavailable, true)
+		}
+	}
+	return result
+}
+
 // formatIssue formats an issue for display.
 func formatIssue(issue map[string]any) string {
 	var result strings.Builder
@@ -372,4 +666,6 @@ func RegisterGitHubTools(registry *tools.Registry) {
 	registry.MustRegister(GitHubGetIssueTool{})
 	registry.MustRegister(GitHubCreateCommentTool{})
 	registry.MustRegister(GitHubListIssuesTool{})
+	registry.MustRegister(GitHubCreatePullRequestTool{})
+	registry.MustRegister(GitHubListChecksTool{})
 }
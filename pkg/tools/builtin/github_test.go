@@ -0,0 +1,154 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+type githubRecordedRequest struct {
+	Method string
+	Path   string
+	Auth   string
+	Body   map[string]any
+}
+
+func newGitHubTestServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]githubRecordedRequest) {
+	t.Helper()
+	var requests []githubRecordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := githubRecordedRequest{Method: r.Method, Path: r.URL.Path, Auth: r.Header.Get("Authorization")}
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			_ = json.Unmarshal(data, &rec.Body)
+		}
+		requests = append(requests, rec)
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func newGitHubToolContext(apiURL string) *tools.ToolContext {
+	toolCtx := tools.NewToolContext("")
+	toolCtx.WithEnv(GitHubAPIURLEnv, apiURL)
+	toolCtx.WithEnv(GitHubTokenEnv, "env-token")
+	toolCtx.WithEnv(GitHubRepositoryEnv, "acme/widgets")
+	return toolCtx
+}
+
+func TestGitHubCreatePullRequestTool(t *testing.T) {
+	srv, requests := newGitHubTestServer(t, map[string]string{
+		"POST /repos/acme/widgets/pulls": `{"number": 7, "html_url": "https://github.com/acme/widgets/pull/7"}`,
+	})
+
+	result, err := GitHubCreatePullRequestTool{}.Execute(context.Background(), newGitHubToolContext(srv.URL), map[string]any{
+		"title": "Fix bug",
+		"head":  "fix-bug",
+		"base":  "main",
+		"draft": true,
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("Execute() = %+v, %v", result, err)
+	}
+	if result.Content != "Pull request #7 created: https://github.com/acme/widgets/pull/7" {
+		t.Fatalf("unexpected result: %q", result.Content)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.Auth != "Bearer env-token" {
+		t.Fatalf("expected token from env, got %q", req.Auth)
+	}
+	if req.Body["title"] != "Fix bug" || req.Body["head"] != "fix-bug" || req.Body["base"] != "main" || req.Body["draft"] != true {
+		t.Fatalf("unexpected payload: %v", req.Body)
+	}
+}
+
+func TestGitHubWriteToolsDryRun(t *testing.T) {
+	srv, requests := newGitHubTestServer(t, nil)
+
+	tests := []struct {
+		name  string
+		tool  tools.Tool
+		input map[string]any
+		env   bool
+		want  string
+	}{
+		{
+			name:  "input",
+			tool:  GitHubCreateCommentTool{},
+			input: map[string]any{"number": float64(3), "body": "hi", "dry_run": true},
+			want:  "[dry-run] POST " + srv.URL + "/repos/acme/widgets/issues/3/comments",
+		},
+		{
+			name:  "env",
+			tool:  GitHubCreatePullRequestTool{},
+			input: map[string]any{"title": "t", "head": "h", "base": "main"},
+			env:   true,
+			want:  "[dry-run] POST " + srv.URL + "/repos/acme/widgets/pulls",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toolCtx := newGitHubToolContext(srv.URL)
+			if tt.env {
+				toolCtx.WithEnv(GitHubDryRunEnv, "true")
+			}
+			result, err := tt.tool.Execute(context.Background(), toolCtx, tt.input)
+			if err != nil || result.IsError {
+				t.Fatalf("Execute() = %+v, %v", result, err)
+			}
+			if !strings.HasPrefix(result.Content, tt.want) {
+				t.Fatalf("expected %q prefix, got %q", tt.want, result.Content)
+			}
+			if result.Metadata["dry_run"] != true {
+				t.Fatalf("expected dry_run metadata, got %v", result.Metadata)
+			}
+		})
+	}
+	if len(*requests) != 0 {
+		t.Fatalf("expected no requests in dry-run mode, got %+v", *requests)
+	}
+}
+
+func TestGitHubListChecksToolResolvesPullRequestHead(t *testing.T) {
+	srv, _ := newGitHubTestServer(t, map[string]string{
+		"GET /repos/acme/widgets/pulls/5":                   `{"head": {"sha": "abc123"}}`,
+		"GET /repos/acme/widgets/commits/abc123/check-runs": `{"total_count": 2, "check_runs": [{"name": "build", "status": "completed", "conclusion": "success"}, {"name": "lint", "status": "in_progress"}]}`,
+	})
+
+	result, err := GitHubListChecksTool{}.Execute(context.Background(), newGitHubToolContext(srv.URL), map[string]any{"number": float64(5)})
+	if err != nil || result.IsError {
+		t.Fatalf("Execute() = %+v, %v", result, err)
+	}
+	want := "Checks for abc123 (2):\n- build: success\n- lint: in_progress\n"
+	if result.Content != want {
+		t.Fatalf("expected %q, got %q", want, result.Content)
+	}
+}
+
+func TestGitHubToolsRequireTokenAndRepo(t *testing.T) {
+	toolCtx := tools.NewToolContext("")
+	result, _ := GitHubGetIssueTool{}.Execute(context.Background(), toolCtx, map[string]any{"number": float64(1)})
+	if !result.IsError || !strings.Contains(result.Content, "token") {
+		t.Fatalf("expected missing token error, got %+v", result)
+	}
+
+	toolCtx.WithEnv(GitHubCLITokenEnv, "gh-token")
+	result, _ = GitHubGetIssueTool{}.Execute(context.Background(), toolCtx, map[string]any{"number": float64(1)})
+	if !result.IsError || !strings.Contains(result.Content, "owner and repo") {
+		t.Fatalf("expected missing repo error, got %+v", result)
+	}
+}
@@ -0,0 +1,132 @@
+package builtin
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern from a .gitignore file.
+type ignoreRule struct {
+	// base is the slash-separated directory of the .gitignore file,
+	// relative to the matcher root ("" for the root itself).
+	base     string
+	pattern  []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// gitignore matches paths against the .gitignore files loaded so far. It
+// covers the common syntax: comments, negation, directory-only patterns,
+// anchoring, and *, ?, [...] and ** globs. Later rules win, as in git.
+type gitignore struct {
+	root  string
+	rules []ignoreRule
+}
+
+func newGitignore(root string) *gitignore {
+	return &gitignore{root: root}
+}
+
+// load reads the .gitignore in dir, a slash-separated path relative to the
+// root. A missing file is not an error.
+func (g *gitignore) load(dir string) {
+	content, err := os.ReadFile(filepath.Join(g.root, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if rule, ok := parseIgnoreLine(dir, line); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+}
+
+func parseIgnoreLine(base, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	// A slash anywhere but the end anchors the pattern to the file's
+	// directory; otherwise it matches a name at any depth.
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.pattern = strings.Split(line, "/")
+	return rule, true
+}
+
+// ignored reports whether rel, a slash-separated path relative to the root,
+// is ignored.
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			sub = strings.TrimPrefix(rel, rule.base+"/")
+		}
+		var match bool
+		if rule.anchored {
+			match = matchSegments(rule.pattern, strings.Split(sub, "/"))
+		} else {
+			match, _ = path.Match(rule.pattern[0], path.Base(sub))
+		}
+		if match {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matchSegments matches path segments against pattern segments, where
+// "**" matches any number of segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
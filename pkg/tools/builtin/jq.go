@@ -0,0 +1,52 @@
+package builtin
+
+import (
+	"context"
+
+	"github.com/itchyny/gojq"
+)
+
+// This file adapts gojq for json_query. Queries cannot read the
+// environment ($ENV and env are empty) or further inputs (input, inputs),
+// and they run under the caller's context, so a deadline stops a query
+// that never produces its next result.
+
+// jqQuery is a compiled json_query expression.
+type jqQuery struct {
+	code *gojq.Code
+}
+
+// compileJQ parses and compiles a jq expression.
+func compileJQ(expr string) (*jqQuery, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, err
+	}
+	return &jqQuery{code: code}, nil
+}
+
+// run evaluates the query against v, a value decoded by encoding/json, and
+// passes each result to yield until yield returns false. It returns the
+// first error the query raised; halt ends the query without one.
+func (q *jqQuery) run(ctx context.Context, v any, yield func(any) bool) error {
+	iter := q.code.RunWithContext(ctx, v)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, isErr := result.(error); isErr {
+			if halt, isHalt := err.(*gojq.HaltError); isHalt && halt.Value() == nil {
+				return nil
+			}
+			return err
+		}
+		if !yield(result) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+const (
+	// maxJSONQueryInputBytes bounds the JSON document a query reads.
+	maxJSONQueryInputBytes = 50 << 20
+
+	// maxJSONQueryOutputBytes bounds the results returned to the model.
+	maxJSONQueryOutputBytes = 16 << 10
+
+	// maxJSONQueryDuration bounds how long a query may run.
+	maxJSONQueryDuration = 10 * time.Second
+)
+
+// JSONQueryTool evaluates a jq expression against a JSON file or artifact,
+// e.g. a large API response that JSON output compression stored as an
+// artifact, and returns the results compactly.
+type JSONQueryTool struct{}
+
+func (t JSONQueryTool) Name() string {
+	return "json_query"
+}
+
+func (t JSONQueryTool) Description() string {
+	return fmt.Sprintf("Run a jq query against a JSON file or artifact and return the results, one compact JSON value per line (capped at %dKB). "+
+		"Use it to explore large JSON documents without reading them whole. "+
+		"The full jq language is supported; $ENV and env are empty, and input and inputs are unavailable. Queries stop after %d seconds.",
+		maxJSONQueryOutputBytes/1024, int(maxJSONQueryDuration/time.Second))
+}
+
+func (t JSONQueryTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "The jq expression, e.g. '.items[] | select(.state == \"open\") | {id, title}'",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "JSON file to query, relative to the working directory",
+			},
+			"artifact_id": map[string]any{
+				"type":        "string",
+				"description": "ID of the artifact to query instead of a file",
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t JSONQueryTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	query, _ := input["query"].(string)
+	path, _ := input["path"].(string)
+	artifactID, _ := input["artifact_id"].(string)
+	if strings.TrimSpace(query) == "" {
+		return tools.NewErrorResultf("query is required"), nil
+	}
+
+	var data []byte
+	switch {
+	case path != "" && artifactID != "":
+		return tools.NewErrorResultf("give either path or artifact_id, not both"), nil
+	case artifactID != "":
+		artifact, ok := toolCtx.Artifacts.Get(artifactID)
+		if !ok {
+			return tools.NewErrorResultf("artifact %s not found", artifactID).WithErrorCode(tools.ErrCodeFileNotFound, false), nil
+		}
+		data = artifact.Content()
+	case path != "":
+		if err := toolCtx.CheckFileRead(); err != nil {
+			return tools.NewErrorResult(err), nil
+		}
+		absPath, err := toolCtx.ValidatePath(path)
+		if err != nil {
+			return tools.NewErrorResult(err), nil
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return tools.NewErrorResultf("failed to read file: %v", err).WithCause(err).WithDetail("path", path), nil
+		}
+		if info.Size() > maxJSONQueryInputBytes {
+			return tools.NewErrorResultf("%s is %d bytes; json_query reads at most %d bytes", path, info.Size(), maxJSONQueryInputBytes), nil
+		}
+		if data, err = os.ReadFile(absPath); err != nil {
+			return tools.NewErrorResultf("failed to read file: %v", err).WithCause(err).WithDetail("path", path), nil
+		}
+	default:
+		return tools.NewErrorResultf("path or artifact_id is required"), nil
+	}
+
+	jq, err := compileJQ(query)
+	if err != nil {
+		return tools.NewErrorResultf("invalid query: %v", err).WithErrorCode(tools.ErrCodeInvalidInput, false), nil
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return tools.NewErrorResultf("the input is not valid JSON: %v", err), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, maxJSONQueryDuration)
+	defer cancel()
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	results, truncated := 0, false
+	var encodeErr error
+	err = jq.run(ctx, doc, func(r any) bool {
+		mark := out.Len()
+		if encodeErr = enc.Encode(r); encodeErr != nil {
+			return false
+		}
+		if out.Len() > maxJSONQueryOutputBytes {
+			out.Truncate(mark)
+			truncated = true
+			return false
+		}
+		results++
+		return true
+	})
+	switch {
+	case encodeErr != nil:
+		return tools.NewErrorResultf("failed to encode result: %v", encodeErr), nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return tools.NewErrorResultf("query did not finish within %v; narrow it, e.g. with limit(n; f) or first(f)", maxJSONQueryDuration), nil
+	case err != nil:
+		return tools.NewErrorResultf("query failed: %v", err), nil
+	case results == 0 && !truncated:
+		return tools.NewToolResult("(no results)"), nil
+	}
+	if truncated {
+		fmt.Fprintf(&out, "[output truncated after %d results; narrow the query, e.g. with limit(n; f) or by selecting fields]\n", results)
+	}
+	return tools.NewToolResult(strings.TrimSuffix(out.String(), "\n")), nil
+}
@@ -0,0 +1,173 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+const jqSampleDoc = `{
+	"total": 3,
+	"items": [
+		{"id": 1, "title": "Fix login", "state": "open", "labels": ["bug"], "user": {"login": "ana"}},
+		{"id": 2, "title": "Add search", "state": "closed", "labels": [], "user": {"login": "bo"}},
+		{"id": 3, "title": "Fix logout", "state": "open", "labels": ["bug", "ui"], "user": {"login": "ana"}}
+	]
+}`
+
+func TestCompileJQEvaluatesQueries(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(jqSampleDoc), &doc); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{".", jqSampleDoc},
+		{".total", `3`},
+		{`.["total"]`, `3`},
+		{".items[0].title", `"Fix login"`},
+		{".items[-1].id", `3`},
+		{".items[1:].[].id", `2 3`},
+		{".items | length", `3`},
+		{".items[] | .user.login", `"ana" "bo" "ana"`},
+		{`.items[] | select(.state == "open") | .id`, `1 3`},
+		{`[.items[] | select(.labels | length > 0 and .[0] == "bug") | .id]`, `[1,3]`},
+		{`.items | map(.user.login) | unique`, `["ana","bo"]`},
+		{`.items[0] | {id, who: .user.login}`, `{"id":1,"who":"ana"}`},
+		{`.items[0] | keys`, `["id","labels","state","title","user"]`},
+		{`.items | sort_by(.title) | first | .id`, `2`},
+		{`.items | map(.id) | add`, `6`},
+		{`.items | map(.id) | max`, `3`},
+		{`[limit(2; .items[].id)]`, `[1,2]`},
+		{`.items[] | select(.title | test("^Fix")) | .id`, `1 3`},
+		{`.items[0].title | startswith("Fix")`, `true`},
+		{`.items[0] | has("user"), has("body")`, `true false`},
+		{`.missing.field`, `null`},
+		{`.total.x?`, ``},
+		{`[..] | length`, `27`},
+		{`.items[0].labels, .total | type`, `"array" "number"`},
+		{`$ENV, env | length`, `0 0`},
+	}
+	for _, tt := range tests {
+		jq, err := compileJQ(tt.query)
+		if err != nil {
+			t.Errorf("compile %s: %v", tt.query, err)
+			continue
+		}
+		var results []any
+		if err := jq.run(context.Background(), doc, func(r any) bool {
+			results = append(results, r)
+			return true
+		}); err != nil {
+			t.Errorf("eval %s: %v", tt.query, err)
+			continue
+		}
+		want := tt.want
+		if tt.query == "." {
+			var v any
+			_ = json.Unmarshal([]byte(want), &v)
+			data, _ := json.Marshal(v)
+			want = string(data)
+		}
+		var got []string
+		for _, r := range results {
+			data, _ := json.Marshal(r)
+			got = append(got, string(data))
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("%s = %s, want %s", tt.query, strings.Join(got, " "), want)
+		}
+	}
+}
+
+func TestCompileJQRejectsInvalidQueries(t *testing.T) {
+	for _, query := range []string{".items[", "no_such_function", "map()", `"open`, "select(.a; .b)", "input", `import "m" as m; .`} {
+		if _, err := compileJQ(query); err == nil {
+			t.Errorf("expected %q to be rejected", query)
+		}
+	}
+}
+
+func TestJSONQueryToolQueriesFilesAndArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "issues.json"), []byte(jqSampleDoc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	toolCtx := tools.NewToolContext(dir).WithArtifacts(tools.NewArtifacts())
+	artifact := toolCtx.Artifacts.Add("issues.json", "application/json", "", []byte(jqSampleDoc))
+	tool := JSONQueryTool{}
+
+	result, _ := tool.Execute(context.Background(), toolCtx, map[string]any{"path": "issues.json", "query": ".items[].id"})
+	if result.IsError || result.Content != "1\n2\n3" {
+		t.Fatalf("unexpected file result: %+v", result)
+	}
+	result, _ = tool.Execute(context.Background(), toolCtx, map[string]any{"artifact_id": artifact.ID, "query": `.items[] | select(.id == 2) | {title}`})
+	if result.IsError || result.Content != `{"title":"Add search"}` {
+		t.Fatalf("unexpected artifact result: %+v", result)
+	}
+	result, _ = tool.Execute(context.Background(), toolCtx, map[string]any{"path": "issues.json", "query": ".items[] | select(.id > 5)"})
+	if result.IsError || result.Content != "(no results)" {
+		t.Fatalf("unexpected empty result: %+v", result)
+	}
+
+	for _, input := range []map[string]any{
+		{"path": "issues.json"},
+		{"query": "."},
+		{"path": "issues.json", "artifact_id": artifact.ID, "query": "."},
+		{"artifact_id": "art_missing", "query": "."},
+		{"path": "../outside.json", "query": "."},
+		{"path": "issues.json", "query": ".items["},
+		{"path": "issues.json", "query": ".total[0]"},
+	} {
+		if result, _ := tool.Execute(context.Background(), toolCtx, input); !result.IsError {
+			t.Errorf("expected an error for %v, got %+v", input, result)
+		}
+	}
+}
+
+func TestJSONQueryToolTruncatesLargeOutput(t *testing.T) {
+	dir := t.TempDir()
+	items := make([]string, 2000)
+	for i := range items {
+		items[i] = `"` + strings.Repeat("x", 50) + `"`
+	}
+	if err := os.WriteFile(filepath.Join(dir, "big.json"), []byte("["+strings.Join(items, ",")+"]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := JSONQueryTool{}.Execute(context.Background(), tools.NewToolContext(dir), map[string]any{"path": "big.json", "query": ".[]"})
+	if result.IsError || len(result.Content) > maxJSONQueryOutputBytes+200 {
+		t.Fatalf("expected a capped result, got %d bytes", len(result.Content))
+	}
+	if !strings.Contains(result.Content, "[output truncated after ") {
+		t.Fatalf("expected a truncation note, got tail %q", result.Content[len(result.Content)-120:])
+	}
+}
+
+func TestJSONQueryToolStopsUnboundedQueries(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "doc.json"), []byte(jqSampleDoc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	toolCtx := tools.NewToolContext(dir)
+
+	result, _ := JSONQueryTool{}.Execute(context.Background(), toolCtx, map[string]any{"path": "doc.json", "query": "repeat(.total)"})
+	if result.IsError || len(result.Content) > maxJSONQueryOutputBytes+200 || !strings.Contains(result.Content, "[output truncated after ") {
+		t.Fatalf("expected an infinite generator to stop at the output cap, got %d bytes", len(result.Content))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, _ = JSONQueryTool{}.Execute(ctx, toolCtx, map[string]any{"path": "doc.json", "query": "last(range(infinite))"})
+	if !result.IsError || !strings.Contains(result.Content, "did not finish") {
+		t.Fatalf("expected a query without results to stop at the deadline, got %+v", result)
+	}
+}
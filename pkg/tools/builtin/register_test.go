@@ -10,7 +10,7 @@ func TestRegisterAllSkipsGitHubByDefault(t *testing.T) {
 	registry := tools.NewRegistry()
 	RegisterAll(registry)
 
-	for _, name := range []string{"github_get_issue", "github_create_comment", "github_list_issues"} {
+	for _, name := range []string{"github_get_issue", "github_create_comment", "github_list_issues", "github_create_pr", "github_list_checks"} {
 		if registry.Has(name) {
 			t.Fatalf("expected %s to be excluded from default builtins", name)
 		}
@@ -21,7 +21,7 @@ func TestRegisterAllWithGitHubIncludesGitHubTools(t *testing.T) {
 	registry := tools.NewRegistry()
 	RegisterAllWithGitHub(registry)
 
-	for _, name := range []string{"github_get_issue", "github_create_comment", "github_list_issues"} {
+	for _, name := range []string{"github_get_issue", "github_create_comment", "github_list_issues", "github_create_pr", "github_list_checks"} {
 		if !registry.Has(name) {
 			t.Fatalf("expected %s to be registered", name)
 		}
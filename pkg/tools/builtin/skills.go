@@ -2,6 +2,7 @@ package builtin
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"path/filepath"
@@ -56,16 +57,17 @@ func (t ListSkillsTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 
 	searchPaths := parseSearchPaths(input["search_paths"])
 	if len(searchPaths) == 0 {
-		searchPaths = skills.DefaultSearchDirs(toolCtx.WorkDir)
+		searchPaths = skills.SearchDirs(toolCtx.WorkDir, toolCtx.SkillDirs)
 	}
 
-	discovered, err := skills.Discover(searchPaths)
-	if err != nil {
-		return tools.NewErrorResultf("failed to discover skills: %v", err), nil
-	}
+	discovered := skills.DiscoverContext(ctx, searchPaths, skills.DiscoverOptions{})
 
 	query, _ := input["query"].(string)
-	filtered := skills.FilterByQuery(discovered, query)
+	filtered := skills.FilterByQuery(discovered.Skills, query)
+	var skippedNote string
+	if len(discovered.Skipped) > 0 {
+		skippedNote = "\nSkipped unresponsive directories: " + skills.FormatSkipped(discovered.Skipped)
+	}
 
 	limit := getInt(input["limit"], defaultListSkillsLimit)
 	if limit <= 0 {
@@ -76,7 +78,7 @@ func (t ListSkillsTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 	}
 
 	if len(filtered) == 0 {
-		return tools.NewToolResult("No skills found."), nil
+		return tools.NewToolResult("No skills found." + skippedNote), nil
 	}
 	if len(filtered) > limit {
 		filtered = filtered[:limit]
@@ -91,7 +93,7 @@ func (t ListSkillsTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 		}
 		fmt.Fprintf(&b, "- %s | %s | %s\n", skill.Name, desc, filepath.ToSlash(skill.Path))
 	}
-	return tools.NewToolResult(strings.TrimSpace(b.String())), nil
+	return tools.NewToolResult(strings.TrimSpace(b.String()) + skippedNote), nil
 }
 
 // ReadSkillTool reads full SKILL.md content for a selected skill.
@@ -137,13 +139,10 @@ func (t ReadSkillTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 
 	searchPaths := parseSearchPaths(input["search_paths"])
 	if len(searchPaths) == 0 {
-		searchPaths = skills.DefaultSearchDirs(toolCtx.WorkDir)
+		searchPaths = skills.SearchDirs(toolCtx.WorkDir, toolCtx.SkillDirs)
 	}
 
-	discovered, err := skills.Discover(searchPaths)
-	if err != nil {
-		return tools.NewErrorResultf("failed to discover skills: %v", err), nil
-	}
+	discovered := skills.DiscoverContext(ctx, searchPaths, skills.DiscoverOptions{}).Skills
 	if len(discovered) == 0 {
 		return tools.NewErrorResultf("no skills available"), nil
 	}
@@ -175,7 +174,7 @@ func (t ReadSkillTool) Execute(ctx context.Context, toolCtx *tools.ToolContext,
 	selected := matches[0]
 	content, truncated, err := skills.ReadFile(selected.Path, maxBytes)
 	if err != nil {
-		return tools.NewErrorResultf("failed to read skill file: %v", err), nil
+		return tools.NewErrorResultf("failed to read skill file: %v", err).WithCause(err), nil
 	}
 
 	var b strings.Builder
@@ -209,7 +208,7 @@ func (t UseSkillTool) InputSchema() map[string]any {
 			},
 			"arguments": map[string]any{
 				"type":        "string",
-				"description": "Optional arguments passed to the skill ($ARGUMENTS)",
+				"description": "Optional arguments passed to the skill ($ARGUMENTS). For skills that declare arguments: name=value pairs or a JSON object",
 			},
 			"source": map[string]any{
 				"type":        "string",
@@ -238,12 +237,9 @@ func (t UseSkillTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, i
 
 	searchPaths := parseSearchPaths(input["search_paths"])
 	if len(searchPaths) == 0 {
-		searchPaths = skills.DefaultSearchDirs(toolCtx.WorkDir)
-	}
-	discovered, err := skills.Discover(searchPaths)
-	if err != nil {
-		return tools.NewErrorResultf("failed to discover skills: %v", err), nil
+		searchPaths = skills.SearchDirs(toolCtx.WorkDir, toolCtx.SkillDirs)
 	}
+	discovered := skills.DiscoverContext(ctx, searchPaths, skills.DiscoverOptions{}).Skills
 	if len(discovered) == 0 {
 		return tools.NewErrorResultf("no skills available"), nil
 	}
@@ -271,7 +267,13 @@ func (t UseSkillTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, i
 		return tools.NewErrorResultf("invalid source %q: must be model or user", source), nil
 	}
 
-	args, _ := input["arguments"].(string)
+	args, err := skillArguments(input["arguments"])
+	if err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+	if _, err := skills.ParseArguments(selected.Arguments, args); err != nil {
+		return tools.NewErrorResultf("invalid arguments for skill %q: %v", selected.Name, err), nil
+	}
 	log.Printf(
 		"[skills-tool] use_skill resolved: source=%s skill=%s scope=%s path=%s args=%q",
 		source,
@@ -280,8 +282,8 @@ func (t UseSkillTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, i
 		filepath.ToSlash(selected.Path),
 		strings.TrimSpace(args),
 	)
-	sessionID := strings.TrimSpace(toolCtx.Env[skills.EnvClaudeSessionID])
-	rendered, truncated, err := skills.RenderForInvocation(selected, args, sessionID, skills.DefaultSkillReadMaxBytes)
+	vars := skills.VariablesFor(toolCtx, toolCtx.Now())
+	rendered, truncated, err := skills.RenderForInvocationVars(selected, args, vars, skills.DefaultSkillReadMaxBytes)
 	if err != nil {
 		return tools.NewErrorResultf("failed to render skill: %v", err), nil
 	}
@@ -293,12 +295,19 @@ func (t UseSkillTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, i
 	} else if toolCtx.Env != nil {
 		delete(toolCtx.Env, skills.EnvActiveSkillAllowedTools)
 	}
+	toolCtx.Sandbox = selected.Sandbox
 
 	var b strings.Builder
 	fmt.Fprintf(&b, "Skill: %s\nPath: %s\nSource: %s\n", selected.Name, filepath.ToSlash(selected.Path), source)
 	if len(selected.AllowedTools) > 0 {
 		fmt.Fprintf(&b, "Allowed-Tools: %s\n", strings.Join(selected.AllowedTools, ", "))
 	}
+	if len(selected.Arguments) > 0 {
+		fmt.Fprintf(&b, "Arguments: %s\n", skills.FormatArguments(selected.Arguments))
+	}
+	if !selected.Sandbox.IsZero() {
+		fmt.Fprintf(&b, "Sandbox: %s\n", selected.Sandbox)
+	}
 	b.WriteString("\n")
 	b.WriteString(rendered)
 	if truncated {
@@ -314,6 +323,25 @@ func RegisterSkillTools(registry *tools.Registry) {
 	registry.MustRegister(UseSkillTool{})
 }
 
+// skillArguments returns the use_skill arguments as text. Models sometimes
+// pass an object for skills with declared arguments; it is encoded as JSON.
+func skillArguments(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case map[string]any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("arguments must be a string or an object")
+	}
+}
+
 func parseSearchPaths(value any) []string {
 	switch v := value.(type) {
 	case []string:
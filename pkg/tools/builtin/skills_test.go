@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/MimeLyc/agent-core-go/pkg/skills"
 	"github.com/MimeLyc/agent-core-go/pkg/tools"
@@ -163,6 +164,130 @@ Deploy target: $ARGUMENTS`)
 	}
 }
 
+func TestUseSkillToolAppliesSandbox(t *testing.T) {
+	root := t.TempDir()
+	skillsDir := filepath.Join(root, ".agents", "skills")
+	mustWrite(t, filepath.Join(skillsDir, "audit", "SKILL.md"), `---
+name: audit
+description: read-only audit
+network: off
+write: disallowed
+allowed-paths: docs
+---
+
+Audit the docs.`)
+	mustWrite(t, filepath.Join(skillsDir, "plain", "SKILL.md"), "---\nname: plain\n---\n\nNo restrictions.")
+	mustWrite(t, filepath.Join(root, "docs", "guide.md"), "guide")
+	mustWrite(t, filepath.Join(root, "secrets.env"), "TOKEN=1")
+
+	toolCtx := tools.NewToolContext(root)
+	use := func(name string) tools.ToolResult {
+		t.Helper()
+		result, err := UseSkillTool{}.Execute(context.Background(), toolCtx, map[string]any{
+			"name":         name,
+			"search_paths": []any{skillsDir},
+		})
+		if err != nil || result.IsError {
+			t.Fatalf("use_skill %s: %v %s", name, err, result.Content)
+		}
+		return result
+	}
+
+	result := use("audit")
+	if !strings.Contains(result.Content, "Sandbox: network: off, write: disallowed, allowed-paths: docs") {
+		t.Fatalf("expected the sandbox in the output, got %q", result.Content)
+	}
+	if r, _ := (ReadFileTool{}).Execute(context.Background(), toolCtx, map[string]any{"path": "docs/guide.md"}); r.IsError {
+		t.Fatalf("expected reads inside allowed paths to work: %s", r.Content)
+	}
+	if r, _ := (ReadFileTool{}).Execute(context.Background(), toolCtx, map[string]any{"path": "secrets.env"}); !r.IsError || r.ErrorCode != tools.ErrCodePermissionDenied {
+		t.Fatalf("expected reads outside allowed paths to be denied, got %+v", r)
+	}
+	if r, _ := (WriteFileTool{}).Execute(context.Background(), toolCtx, map[string]any{"path": "docs/new.md", "content": "x"}); !r.IsError {
+		t.Fatal("expected writes to be denied")
+	}
+	if r, _ := (BashTool{}).Execute(context.Background(), toolCtx, map[string]any{"command": "true"}); !r.IsError {
+		t.Fatal("expected host commands to be denied")
+	}
+
+	use("plain")
+	if !toolCtx.Sandbox.IsZero() {
+		t.Fatalf("expected switching skills to clear the sandbox, got %+v", toolCtx.Sandbox)
+	}
+	if r, _ := (ReadFileTool{}).Execute(context.Background(), toolCtx, map[string]any{"path": "secrets.env"}); r.IsError {
+		t.Fatalf("expected the run's own permissions back: %s", r.Content)
+	}
+}
+
+// fixedClock is a tools.Clock that always returns the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestUseSkillToolUsesContextClock(t *testing.T) {
+	root := t.TempDir()
+	skillsDir := filepath.Join(root, ".agents", "skills")
+	mustWrite(t, filepath.Join(skillsDir, "report", "SKILL.md"), `---
+name: report
+description: daily report
+---
+
+Report for ${DATE}`)
+
+	tool := UseSkillTool{}
+	toolCtx := tools.NewToolContext(root)
+	toolCtx.Clock = fixedClock(time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC))
+	result, err := tool.Execute(context.Background(), toolCtx, map[string]any{
+		"name":         "report",
+		"source":       "user",
+		"search_paths": []any{skillsDir},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(result.Content, "Report for 2025-03-04") {
+		t.Fatalf("expected the context clock's date, got: %q", result.Content)
+	}
+}
+
+func TestUseSkillToolValidatesArguments(t *testing.T) {
+	root := t.TempDir()
+	skillsDir := filepath.Join(root, ".agents", "skills")
+	mustWrite(t, filepath.Join(skillsDir, "deploy", "SKILL.md"), `---
+name: deploy
+arguments:
+  - env: string, required
+  - replicas: integer
+---
+
+Deploy ${ARG_ENV} x${ARG_REPLICAS}`)
+
+	run := func(args any) tools.ToolResult {
+		t.Helper()
+		result, err := UseSkillTool{}.Execute(context.Background(), tools.NewToolContext(root), map[string]any{
+			"name":         "deploy",
+			"arguments":    args,
+			"search_paths": []any{skillsDir},
+		})
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		return result
+	}
+
+	result := run(map[string]any{"env": "prod", "replicas": float64(3)})
+	if result.IsError || !strings.Contains(result.Content, "Deploy prod x3") {
+		t.Fatalf("expected object arguments to render, got %q", result.Content)
+	}
+	if !strings.Contains(result.Content, "Arguments: env (string, required), replicas (integer)") {
+		t.Fatalf("expected the schema in the output, got %q", result.Content)
+	}
+	result = run("replicas=two")
+	if !result.IsError || !strings.Contains(result.Content, `invalid arguments for skill "deploy"`) {
+		t.Fatalf("expected a validation error, got %q", result.Content)
+	}
+}
+
 func mustWrite(t *testing.T, path, content string) {
 	t.Helper()
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
@@ -0,0 +1,57 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// RollbackLastChangesTool reverts the file changes made by the most recent
+// write-capable tool call. It requires transactional mode (ToolContext.Journal).
+type RollbackLastChangesTool struct{}
+
+func (t RollbackLastChangesTool) Name() string {
+	return "rollback_last_changes"
+}
+
+func (t RollbackLastChangesTool) Description() string {
+	return "Revert the file changes made by the most recent write_file or bash call. Use this to undo an edit that broke the code."
+}
+
+func (t RollbackLastChangesTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+func (t RollbackLastChangesTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	if err := toolCtx.CheckFileWrite(); err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+	if toolCtx.Journal == nil {
+		return tools.NewErrorResultf("transactional mode is not enabled"), nil
+	}
+
+	label, restored, err := toolCtx.Journal.RollbackLast()
+	if err != nil {
+		return tools.NewErrorResultf("rollback failed: %v", err), nil
+	}
+	if label == "" {
+		return tools.NewToolResult("No changes to roll back"), nil
+	}
+	if len(restored) == 0 {
+		return tools.NewToolResult(fmt.Sprintf("Changes from %s were already reverted", label)), nil
+	}
+
+	return tools.NewToolResult(fmt.Sprintf("Rolled back changes from %s:\n%s", label, strings.Join(restored, "\n"))).
+		WithMetadata("restored", restored), nil
+}
+
+// RegisterWorkspaceTools registers transactional workspace tools with the registry.
+// They are not part of RegisterAll because they only work in transactional mode.
+func RegisterWorkspaceTools(registry *tools.Registry) {
+	registry.MustRegister(RollbackLastChangesTool{})
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CommandOutput is the structured outcome of a shell command run by a tool
+// such as bash, so callers can branch on the exit code without parsing
+// ToolResult.Content.
+type CommandOutput struct {
+	// ExitCode is the process exit code, or -1 when the command did not
+	// exit on its own: it timed out, was killed, or failed to start.
+	ExitCode int
+
+	// Stdout and Stderr are the captured streams. Long output keeps its
+	// beginning and end; the Truncated flags report that the middle was
+	// dropped.
+	Stdout          string
+	Stderr          string
+	StdoutTruncated bool
+	StderrTruncated bool
+
+	// Duration is how long the command ran.
+	Duration time.Duration
+
+	// TimedOut reports that the command was killed at its timeout.
+	TimedOut bool
+
+	// Error describes a failure to run the command, e.g. a missing
+	// executable. Empty when the command ran.
+	Error string
+}
+
+// Format renders the output as the tool result content: header lines for
+// the exit code, duration, and any timeout or error, then the stdout and
+// stderr sections that are not empty.
+//
+//	exit_code: 1
+//	duration: 1.52s
+//	stdout:
+//	...
+//	stderr (truncated):
+//	...
+func (o CommandOutput) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "exit_code: %d\n", o.ExitCode)
+	fmt.Fprintf(&b, "duration: %s\n", o.Duration.Round(time.Millisecond))
+	if o.TimedOut {
+		b.WriteString("timed_out: true\n")
+	}
+	if o.Error != "" {
+		fmt.Fprintf(&b, "error: %s\n", o.Error)
+	}
+	writeStream(&b, "stdout", o.Stdout, o.StdoutTruncated)
+	writeStream(&b, "stderr", o.Stderr, o.StderrTruncated)
+	if o.Stdout == "" && o.Stderr == "" {
+		b.WriteString("(no output)\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeStream(b *strings.Builder, name, content string, truncated bool) {
+	if content == "" {
+		return
+	}
+	b.WriteString(name)
+	if truncated {
+		b.WriteString(" (truncated)")
+	}
+	b.WriteString(":\n")
+	b.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+}
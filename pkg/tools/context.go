@@ -4,6 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/workspace"
 )
 
 // Permissions defines what operations a tool is allowed to perform.
@@ -69,8 +72,60 @@ type ToolContext struct {
 
 	// BashTimeout is the timeout for bash command execution in seconds.
 	BashTimeout int
+
+	// Journal records file changes in transactional mode. Nil when
+	// transactional mode is off.
+	Journal *workspace.Journal
+
+	// CurrentDir is the directory relative paths resolve against. It must be
+	// inside a sandbox root; empty means WorkDir. Set by the change_dir tool.
+	CurrentDir string
+
+	// AllowedRoots are additional sandbox roots, besides WorkDir, that paths
+	// may resolve into (e.g. sibling packages of a monorepo checkout).
+	AllowedRoots []string
+
+	// SkillDirs are additional skill search directories appended to the
+	// defaults (e.g. skill directories bundled with plugins).
+	SkillDirs []string
+
+	// SkillEnvVars names the Env entries that skill and command bodies may
+	// reference as ${env:NAME}. Other entries, such as secrets, are never
+	// substituted.
+	SkillEnvVars []string
+
+	// AskUser asks the user a question and waits for the answer. Nil when
+	// nobody can answer during the run.
+	AskUser UserAsker
+
+	// FileReads tracks the files the run has read. When set, write tools
+	// refuse to overwrite a file that was not read or changed since it was
+	// read. Nil disables the check.
+	FileReads *FileReads
+
+	// Sandbox holds the active skill's restrictions. It is replaced
+	// whenever a skill is activated.
+	Sandbox Sandbox
+
+	// Artifacts collects the outputs tools attach to the run (see
+	// AddArtifact). Nil when the run does not collect artifacts.
+	Artifacts *Artifacts
+
+	// Clock tells tools the time they show the model, e.g. the ${DATE}
+	// skill variable. Nil uses the system clock.
+	Clock Clock
 }
 
+// Clock tells the time. The agent loop sets ToolContext.Clock to its own
+// clock, so a fixed clock also fixes what tools see.
+type Clock interface {
+	Now() time.Time
+}
+
+// CwdInputKey is the optional tool input field that overrides the working
+// directory for a single tool call.
+const CwdInputKey = "cwd"
+
 // NewToolContext creates a new tool context with the given working directory.
 func NewToolContext(workDir string) *ToolContext {
 	return &ToolContext{
@@ -110,50 +165,125 @@ func (c *ToolContext) WithBashTimeout(seconds int) *ToolContext {
 	return c
 }
 
-// ValidatePath checks if the given path is within the working directory.
-// Returns the cleaned absolute path if valid, or an error if the path
-// is outside the working directory.
-func (c *ToolContext) ValidatePath(path string) (string, error) {
-	if c.WorkDir == "" {
-		return "", ErrNoWorkDir
+// WithJournal enables transactional mode and returns the context for chaining.
+func (c *ToolContext) WithJournal(j *workspace.Journal) *ToolContext {
+	c.Journal = j
+	return c
+}
+
+// WithFileReads enables read tracking and returns the context for chaining.
+func (c *ToolContext) WithFileReads(r *FileReads) *ToolContext {
+	c.FileReads = r
+	return c
+}
+
+// WithArtifacts collects tool artifacts and returns the context for chaining.
+func (c *ToolContext) WithArtifacts(a *Artifacts) *ToolContext {
+	c.Artifacts = a
+	return c
+}
+
+// Now returns the time from Clock, or the system time when Clock is nil.
+func (c *ToolContext) Now() time.Time {
+	if c == nil || c.Clock == nil {
+		return time.Now()
 	}
+	return c.Clock.Now()
+}
 
-	// Handle relative paths
-	var absPath string
-	if filepath.IsAbs(path) {
-		absPath = filepath.Clean(path)
-	} else {
-		absPath = filepath.Clean(filepath.Join(c.WorkDir, path))
+// Cwd returns the directory relative paths resolve against.
+func (c *ToolContext) Cwd() string {
+	if c.CurrentDir != "" {
+		return c.CurrentDir
 	}
+	return c.WorkDir
+}
 
-	// Get the absolute work directory
-	absWorkDir, err := filepath.Abs(c.WorkDir)
+// ChangeDir validates path against the sandbox roots and makes it the
+// current directory. It returns the new absolute current directory.
+func (c *ToolContext) ChangeDir(path string) (string, error) {
+	dir, err := c.validateDir(path)
 	if err != nil {
 		return "", err
 	}
-	absWorkDir = filepath.Clean(absWorkDir)
+	c.CurrentDir = dir
+	return dir, nil
+}
+
+// ResolveCwd returns the working directory for a single tool call: the
+// validated CwdInputKey value from input when present, otherwise Cwd().
+func (c *ToolContext) ResolveCwd(input map[string]any) (string, error) {
+	if dir, ok := input[CwdInputKey].(string); ok && dir != "" {
+		return c.validateDir(dir)
+	}
+	return c.Cwd(), nil
+}
 
-	// Check if the path is within the working directory
-	rel, err := filepath.Rel(absWorkDir, absPath)
+func (c *ToolContext) validateDir(path string) (string, error) {
+	absPath, err := c.ValidatePath(path)
 	if err != nil {
-		return "", ErrPathOutsideWorkDir
+		return "", err
 	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", ErrNotADirectory
+	}
+	return absPath, nil
+}
 
-	// If the relative path starts with "..", it's outside the work directory
-	if strings.HasPrefix(rel, "..") {
-		return "", ErrPathOutsideWorkDir
+// ValidatePath checks if the given path is within the working directory
+// (or one of AllowedRoots) and the active skill's Sandbox.AllowedPaths.
+// Relative paths resolve against Cwd().
+// Returns the cleaned absolute path if valid, or an error if the path
+// is outside the sandbox roots.
+func (c *ToolContext) ValidatePath(path string) (string, error) {
+	if c.WorkDir == "" {
+		return "", ErrNoWorkDir
 	}
 
-	return absPath, nil
+	absPath, err := filepath.Abs(c.ResolvePath(path))
+	if err != nil {
+		return "", err
+	}
+
+	for _, root := range append([]string{c.WorkDir}, c.AllowedRoots...) {
+		if root == "" {
+			continue
+		}
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return "", err
+		}
+		if isWithin(filepath.Clean(absRoot), absPath) {
+			if err := c.checkSandboxPath(absPath); err != nil {
+				return "", err
+			}
+			return absPath, nil
+		}
+	}
+	return "", ErrPathOutsideWorkDir
+}
+
+// isWithin reports whether path is root or below it.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	// If the relative path starts with "..", it's outside the root
+	return !strings.HasPrefix(rel, "..")
 }
 
-// ResolvePath resolves a path relative to the working directory.
+// ResolvePath resolves a path relative to the current directory.
 // Unlike ValidatePath, this does not check if the path exists.
 func (c *ToolContext) ResolvePath(path string) string {
 	if filepath.IsAbs(path) {
 		return filepath.Clean(path)
 	}
-	return filepath.Clean(filepath.Join(c.WorkDir, path))
+	return filepath.Clean(filepath.Join(c.Cwd(), path))
 }
 
 // FileExists checks if a file exists at the given path.
@@ -194,6 +324,11 @@ const (
 	ErrGitNotAllowed    toolError = "git operations not allowed"
 	ErrGitHubNotAllowed toolError = "github operations not allowed"
 	ErrNetworkNotAllowed toolError = "network operations not allowed"
+	ErrNotADirectory    toolError = "path is not a directory"
+	ErrFileNotRead      toolError = "file has not been read in this run; read it before overwriting it"
+	ErrFileChangedSinceRead toolError = "file changed since it was last read; read it again before overwriting it"
+	ErrPathNotInSandbox toolError = "path is outside the active skill's allowed paths"
+	ErrHostCommandSandboxed toolError = "host commands are not allowed while the active skill restricts network, writes, or paths"
 )
 
 // CheckBash checks if bash execution is allowed.
@@ -214,7 +349,7 @@ func (c *ToolContext) CheckFileRead() error {
 
 // CheckFileWrite checks if file write operations are allowed.
 func (c *ToolContext) CheckFileWrite() error {
-	if !c.Permissions.AllowFileWrite {
+	if !c.Permissions.AllowFileWrite || c.Sandbox.DenyWrite {
 		return ErrFileWriteNotAllowed
 	}
 	return nil
@@ -230,7 +365,7 @@ func (c *ToolContext) CheckGit() error {
 
 // CheckGitHub checks if GitHub operations are allowed.
 func (c *ToolContext) CheckGitHub() error {
-	if !c.Permissions.AllowGitHub {
+	if !c.Permissions.AllowGitHub || c.Sandbox.DenyNetwork {
 		return ErrGitHubNotAllowed
 	}
 	return nil
@@ -238,7 +373,7 @@ func (c *ToolContext) CheckGitHub() error {
 
 // CheckNetwork checks if network operations are allowed.
 func (c *ToolContext) CheckNetwork() error {
-	if !c.Permissions.AllowNetwork {
+	if !c.Permissions.AllowNetwork || c.Sandbox.DenyNetwork {
 		return ErrNetworkNotAllowed
 	}
 	return nil
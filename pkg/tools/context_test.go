@@ -143,3 +143,115 @@ func TestToolContextChaining(t *testing.T) {
 		t.Errorf("BashTimeout = %d, want 120", ctx.BashTimeout)
 	}
 }
+
+func TestToolContextChangeDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkgDir := filepath.Join(tmpDir, "packages", "api")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatalf("failed to create package dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("test"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	ctx := NewToolContext(tmpDir)
+	dir, err := ctx.ChangeDir("packages/api")
+	if err != nil {
+		t.Fatalf("ChangeDir() error = %v", err)
+	}
+	if dir != pkgDir || ctx.Cwd() != pkgDir {
+		t.Fatalf("expected cwd %s, got %s (returned %s)", pkgDir, ctx.Cwd(), dir)
+	}
+
+	// Relative paths now resolve against the new current directory.
+	got, err := ctx.ValidatePath("main.go")
+	if err != nil || got != filepath.Join(pkgDir, "main.go") {
+		t.Fatalf("ValidatePath() = %q, %v", got, err)
+	}
+	// Moving up stays allowed while inside the sandbox root.
+	if _, err := ctx.ValidatePath("../../file.txt"); err != nil {
+		t.Fatalf("expected path inside workdir to be valid: %v", err)
+	}
+
+	for _, bad := range []string{"../../..", "missing", filepath.Join("..", "..", "file.txt")} {
+		if _, err := ctx.ChangeDir(bad); err == nil {
+			t.Errorf("ChangeDir(%q) expected error", bad)
+		}
+	}
+	if ctx.Cwd() != pkgDir {
+		t.Fatalf("failed ChangeDir must not move cwd, got %s", ctx.Cwd())
+	}
+}
+
+func TestToolContextResolveCwd(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, "web"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	ctx := NewToolContext(tmpDir)
+
+	if dir, err := ctx.ResolveCwd(map[string]any{}); err != nil || dir != tmpDir {
+		t.Fatalf("ResolveCwd() without override = %q, %v", dir, err)
+	}
+	if dir, err := ctx.ResolveCwd(map[string]any{CwdInputKey: "web"}); err != nil || dir != filepath.Join(tmpDir, "web") {
+		t.Fatalf("ResolveCwd() with override = %q, %v", dir, err)
+	}
+	if _, err := ctx.ResolveCwd(map[string]any{CwdInputKey: "/etc"}); err != ErrPathOutsideWorkDir {
+		t.Fatalf("expected ErrPathOutsideWorkDir, got %v", err)
+	}
+}
+
+func TestToolContextAllowedRoots(t *testing.T) {
+	workDir := t.TempDir()
+	extra := t.TempDir()
+	ctx := NewToolContext(workDir)
+
+	if _, err := ctx.ValidatePath(filepath.Join(extra, "a.txt")); err != ErrPathOutsideWorkDir {
+		t.Fatalf("expected path outside roots to be rejected, got %v", err)
+	}
+	ctx.AllowedRoots = []string{extra}
+	if _, err := ctx.ValidatePath(filepath.Join(extra, "a.txt")); err != nil {
+		t.Fatalf("expected path in allowed root to be valid: %v", err)
+	}
+}
+
+func TestToolContextSandbox(t *testing.T) {
+	workDir := t.TempDir()
+	ctx := NewToolContext(workDir)
+	ctx.Sandbox = Sandbox{DenyNetwork: true, DenyWrite: true, AllowedPaths: []string{"docs"}}
+
+	if _, err := ctx.ValidatePath("docs/guide.md"); err != nil {
+		t.Fatalf("expected path in allowed paths to be valid: %v", err)
+	}
+	if _, err := ctx.ValidatePath("src/main.go"); err != ErrPathNotInSandbox {
+		t.Fatalf("expected ErrPathNotInSandbox, got %v", err)
+	}
+	if err := ctx.CheckNetwork(); err != ErrNetworkNotAllowed {
+		t.Errorf("CheckNetwork() = %v", err)
+	}
+	if err := ctx.CheckFileWrite(); err != ErrFileWriteNotAllowed {
+		t.Errorf("CheckFileWrite() = %v", err)
+	}
+	if err := ctx.CheckHostCommand(); err != ErrHostCommandSandboxed {
+		t.Errorf("CheckHostCommand() = %v", err)
+	}
+	if got := ctx.Sandbox.String(); got != "network: off, write: disallowed, allowed-paths: docs" {
+		t.Errorf("String() = %q", got)
+	}
+
+	ctx.Sandbox = Sandbox{AllowedPaths: []string{"docs"}}
+	if err := ctx.CheckHostCommand(); err != ErrHostCommandSandboxed {
+		t.Errorf("CheckHostCommand() with only allowed paths = %v", err)
+	}
+	if paths, err := ctx.SandboxPaths(); err != nil || len(paths) != 1 || paths[0] != filepath.Join(workDir, "docs") {
+		t.Errorf("SandboxPaths() = %v, %v", paths, err)
+	}
+
+	ctx.Sandbox = Sandbox{}
+	if _, err := ctx.ValidatePath("src/main.go"); err != nil {
+		t.Fatalf("expected clearing the sandbox to restore access: %v", err)
+	}
+	if ctx.CheckNetwork() != nil || ctx.CheckFileWrite() != nil || ctx.CheckHostCommand() != nil {
+		t.Fatal("expected clearing the sandbox to restore the run's permissions")
+	}
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+)
+
+// Error codes reported in ToolResult.ErrorCode.
+const (
+	ErrCodeFileNotFound     = "file_not_found"
+	ErrCodePermissionDenied = "permission_denied"
+	ErrCodeTimeout          = "timeout"
+	ErrCodeUnavailable      = "unavailable"
+	ErrCodeInvalidInput     = "invalid_input"
+	ErrCodeUnknownTool      = "unknown_tool"
+	ErrCodeStaleRead        = "stale_read"
+)
+
+// ClassifyError returns the error code for err and whether the failed call
+// is worth retrying. It returns "" for errors it does not recognize.
+func ClassifyError(err error) (code string, retryable bool) {
+	switch {
+	case err == nil:
+		return "", false
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded), isTimeout(err):
+		return ErrCodeTimeout, true
+	case errors.Is(err, os.ErrNotExist):
+		return ErrCodeFileNotFound, false
+	case errors.Is(err, os.ErrPermission), isPermissionError(err):
+		return ErrCodePermissionDenied, false
+	case errors.Is(err, ErrFileNotRead), errors.Is(err, ErrFileChangedSinceRead):
+		return ErrCodeStaleRead, false
+	default:
+		return "", false
+	}
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// isPermissionError reports whether err is one of the ToolContext
+// permission and sandbox errors.
+func isPermissionError(err error) bool {
+	var te toolError
+	if !errors.As(err, &te) {
+		return false
+	}
+	switch te {
+	case ErrPathOutsideWorkDir, ErrPermissionDenied, ErrBashNotAllowed, ErrFileReadNotAllowed,
+		ErrFileWriteNotAllowed, ErrGitNotAllowed, ErrGitHubNotAllowed, ErrNetworkNotAllowed,
+		ErrPathNotInSandbox, ErrHostCommandSandboxed:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	_, statErr := os.Stat("/definitely/not/here")
+
+	tests := []struct {
+		name      string
+		err       error
+		code      string
+		retryable bool
+	}{
+		{"nil", nil, "", false},
+		{"not exist", statErr, ErrCodeFileNotFound, false},
+		{"wrapped permission", fmt.Errorf("open: %w", os.ErrPermission), ErrCodePermissionDenied, false},
+		{"sandbox", ErrBashNotAllowed, ErrCodePermissionDenied, false},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), ErrCodeTimeout, true},
+		{"other", fmt.Errorf("boom"), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, retryable := ClassifyError(tt.err)
+			if code != tt.code || retryable != tt.retryable {
+				t.Fatalf("ClassifyError(%v) = (%q, %v), want (%q, %v)", tt.err, code, retryable, tt.code, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestNewErrorResultSetsErrorCode(t *testing.T) {
+	_, err := os.ReadFile("/definitely/not/here")
+	result := NewErrorResult(err).WithDetail("path", "/definitely/not/here")
+
+	if !result.IsError || result.ErrorCode != ErrCodeFileNotFound || result.Retryable {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+	if result.Details["path"] != "/definitely/not/here" {
+		t.Fatalf("expected path detail, got %#v", result.Details)
+	}
+}
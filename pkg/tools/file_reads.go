@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileReads tracks which files a run has seen, and in what state, so that
+// write tools can refuse to overwrite a file the agent never read or that
+// changed since it was read. Reading a file, or writing it, records it.
+// A FileReads is safe for concurrent use.
+type FileReads struct {
+	mu    sync.Mutex
+	files map[string]fileState
+}
+
+// fileState is a file's state when the run last saw it.
+type fileState struct {
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+// NewFileReads creates an empty tracker.
+func NewFileReads() *FileReads {
+	return &FileReads{files: make(map[string]fileState)}
+}
+
+// Record notes that the run has seen the content of absPath, whose SHA-256
+// is hash (hex encoded).
+func (r *FileReads) Record(absPath, hash string) {
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files[absPath] = fileState{modTime: info.ModTime(), size: info.Size(), hash: hash}
+}
+
+// Check returns an error when absPath exists and the run has not read it,
+// or it changed since the run last saw it. Missing files pass.
+func (r *FileReads) Check(absPath string) error {
+	info, err := os.Stat(absPath)
+	if err != nil || info.IsDir() {
+		return nil
+	}
+	r.mu.Lock()
+	seen, ok := r.files[absPath]
+	r.mu.Unlock()
+	if !ok {
+		return ErrFileNotRead
+	}
+	if info.ModTime().Equal(seen.modTime) && info.Size() == seen.size {
+		return nil
+	}
+	// The file was touched; only a content change makes the read stale.
+	hash, err := hashFile(absPath)
+	if err != nil || hash != seen.hash {
+		return ErrFileChangedSinceRead
+	}
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
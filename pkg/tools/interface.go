@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"io"
 )
 
 // Tool defines the interface for all tools available to the agent.
@@ -20,6 +21,29 @@ type Tool interface {
 	Execute(ctx context.Context, toolCtx *ToolContext, input map[string]any) (ToolResult, error)
 }
 
+// StreamingTool is an optional interface for tools that produce output
+// incrementally (e.g. long-running commands). Output written to w is forwarded
+// to stream consumers as it is produced. The returned ToolResult is the final
+// result; if its Content is empty, the accumulated output is used instead.
+type StreamingTool interface {
+	Tool
+
+	// ExecuteStream runs the tool, writing incremental output to w.
+	ExecuteStream(ctx context.Context, toolCtx *ToolContext, input map[string]any, w io.Writer) (ToolResult, error)
+}
+
+// WorkspaceWriter is an optional interface for tools that modify files in
+// the working directory. In transactional mode the loop snapshots the paths
+// returned by WritePaths before the tool runs so its changes can be rolled
+// back. A nil result means the affected files are unknown and the whole
+// workspace is snapshotted; an empty non-nil result skips the snapshot.
+type WorkspaceWriter interface {
+	Tool
+
+	// WritePaths returns the files the call with input may modify.
+	WritePaths(toolCtx *ToolContext, input map[string]any) []string
+}
+
 // ToolResult represents the result of a tool execution.
 type ToolResult struct {
 	// Content is the output of the tool execution.
@@ -30,6 +54,23 @@ type ToolResult struct {
 
 	// Metadata contains additional information about the execution.
 	Metadata map[string]any
+
+	// ErrorCode classifies a failed execution, e.g. ErrCodeFileNotFound.
+	// Empty for successful results and unclassified errors.
+	ErrorCode string
+
+	// Retryable reports that running the same call again may succeed, e.g.
+	// after a timeout. The orchestrator can retry such failures before the
+	// model sees them.
+	Retryable bool
+
+	// Details carries structured error context such as the path or the
+	// timeout that was hit.
+	Details map[string]any
+
+	// Command is the structured outcome of a shell command, set by tools
+	// that run one (bash, run_in_container). Nil for other tools.
+	Command *CommandOutput
 }
 
 // NewToolResult creates a successful tool result.
@@ -37,12 +78,13 @@ func NewToolResult(content string) ToolResult {
 	return ToolResult{Content: content}
 }
 
-// NewErrorResult creates an error tool result.
+// NewErrorResult creates an error tool result, classified by err
+// (see WithCause).
 func NewErrorResult(err error) ToolResult {
 	return ToolResult{
 		Content: err.Error(),
 		IsError: true,
-	}
+	}.WithCause(err)
 }
 
 // NewErrorResultf creates an error tool result with a formatted message.
@@ -62,6 +104,31 @@ func (r ToolResult) WithMetadata(key string, value any) ToolResult {
 	return r
 }
 
+// WithErrorCode sets the result's error code and retryability.
+func (r ToolResult) WithErrorCode(code string, retryable bool) ToolResult {
+	r.ErrorCode = code
+	r.Retryable = retryable
+	return r
+}
+
+// WithCause classifies the result by err (see ClassifyError). Unclassified
+// errors leave the result unchanged.
+func (r ToolResult) WithCause(err error) ToolResult {
+	if code, retryable := ClassifyError(err); code != "" {
+		return r.WithErrorCode(code, retryable)
+	}
+	return r
+}
+
+// WithDetail adds structured error context to a tool result.
+func (r ToolResult) WithDetail(key string, value any) ToolResult {
+	if r.Details == nil {
+		r.Details = make(map[string]any)
+	}
+	r.Details[key] = value
+	return r
+}
+
 func formatMessage(format string, args ...any) string {
 	if len(args) == 0 {
 		return format
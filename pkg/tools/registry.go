@@ -40,6 +40,18 @@ func (r *Registry) MustRegister(tool Tool) {
 	}
 }
 
+// Unregister removes a tool by name and reports whether it was registered.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; !exists {
+		return false
+	}
+	delete(r.tools, name)
+	return true
+}
+
 // Get retrieves a tool by name.
 // Returns nil if the tool is not found.
 func (r *Registry) Get(name string) Tool {
@@ -113,3 +113,18 @@ func TestRegistryClear(t *testing.T) {
 		t.Fatalf("expected 0 tools, got %d", r.Count())
 	}
 }
+
+func TestRegistryUnregister(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(mockTool{name: "tool1"})
+
+	if !r.Unregister("tool1") {
+		t.Fatal("expected tool1 to be unregistered")
+	}
+	if r.Has("tool1") {
+		t.Fatal("expected tool1 to be removed")
+	}
+	if r.Unregister("tool1") {
+		t.Fatal("expected second unregister to report false")
+	}
+}
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Sandbox narrows what tools may do while a skill is active. It comes from
+// the skill's SKILL.md frontmatter and can only take permissions away,
+// never grant ones the run does not have.
+type Sandbox struct {
+	// DenyNetwork blocks network operations (network: off).
+	DenyNetwork bool
+
+	// DenyWrite blocks file writes (write: disallowed).
+	DenyWrite bool
+
+	// AllowedPaths limits the files and directories tools may access
+	// (allowed-paths). Relative entries resolve against WorkDir. Empty
+	// leaves the sandbox roots as they are.
+	AllowedPaths []string
+}
+
+// IsZero reports whether the sandbox restricts nothing.
+func (s Sandbox) IsZero() bool {
+	return !s.DenyNetwork && !s.DenyWrite && len(s.AllowedPaths) == 0
+}
+
+// String renders the directives in frontmatter form, e.g.
+// "network: off, write: disallowed, allowed-paths: docs".
+func (s Sandbox) String() string {
+	var parts []string
+	if s.DenyNetwork {
+		parts = append(parts, "network: off")
+	}
+	if s.DenyWrite {
+		parts = append(parts, "write: disallowed")
+	}
+	if len(s.AllowedPaths) > 0 {
+		parts = append(parts, "allowed-paths: "+strings.Join(s.AllowedPaths, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CheckHostCommand checks if commands may run directly on the host. A host
+// command can reach the network and read or write anywhere, so it is
+// refused while the sandbox restricts any of them; run_in_container can
+// still be used.
+func (c *ToolContext) CheckHostCommand() error {
+	if !c.Sandbox.IsZero() {
+		return ErrHostCommandSandboxed
+	}
+	return nil
+}
+
+// SandboxPaths returns Sandbox.AllowedPaths as cleaned absolute paths, or
+// nil when the sandbox does not restrict paths.
+func (c *ToolContext) SandboxPaths() ([]string, error) {
+	if len(c.Sandbox.AllowedPaths) == 0 {
+		return nil, nil
+	}
+	paths := make([]string, 0, len(c.Sandbox.AllowedPaths))
+	for _, allowed := range c.Sandbox.AllowedPaths {
+		if !filepath.IsAbs(allowed) {
+			allowed = filepath.Join(c.WorkDir, allowed)
+		}
+		absAllowed, err := filepath.Abs(allowed)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, absAllowed)
+	}
+	return paths, nil
+}
+
+// checkSandboxPath checks an absolute path against Sandbox.AllowedPaths.
+func (c *ToolContext) checkSandboxPath(absPath string) error {
+	paths, err := c.SandboxPaths()
+	if err != nil || paths == nil {
+		return err
+	}
+	for _, allowed := range paths {
+		if isWithin(allowed, absPath) {
+			return nil
+		}
+	}
+	return ErrPathNotInSandbox
+}
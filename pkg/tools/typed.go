@@ -0,0 +1,296 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TypedTool is a Tool whose input is decoded into a Go struct. Create it
+// with NewTool; the input schema is derived from T by SchemaFor.
+type TypedTool[T any] struct {
+	name        string
+	description string
+	schema      map[string]any
+	execute     func(ctx context.Context, toolCtx *ToolContext, input T) (ToolResult, error)
+}
+
+// NewTool creates a tool from a typed handler. The JSON Schema of the
+// input is derived from T (see SchemaFor), and each call's input is
+// decoded into a T before execute runs; input that does not decode
+// returns an invalid_input error result. NewTool panics if T has no
+// schema, since that is a programming error.
+//
+//	type greetInput struct {
+//		Name  string `json:"name" jsonschema:"description=Who to greet"`
+//		Times int    `json:"times,omitempty" jsonschema:"minimum=1,maximum=5"`
+//	}
+//
+//	greet := tools.NewTool("greet", "Greet someone.",
+//		func(ctx context.Context, toolCtx *tools.ToolContext, in greetInput) (tools.ToolResult, error) {
+//			return tools.NewToolResult(strings.Repeat("Hello, "+in.Name+"! ", max(in.Times, 1))), nil
+//		})
+func NewTool[T any](name, description string, execute func(ctx context.Context, toolCtx *ToolContext, input T) (ToolResult, error)) *TypedTool[T] {
+	schema, err := SchemaFor[T]()
+	if err != nil {
+		panic(fmt.Sprintf("tools.NewTool(%q): %v", name, err))
+	}
+	return &TypedTool[T]{name: name, description: description, schema: schema, execute: execute}
+}
+
+func (t *TypedTool[T]) Name() string {
+	return t.name
+}
+
+func (t *TypedTool[T]) Description() string {
+	return t.description
+}
+
+// InputSchema returns the schema derived from T. Callers must not modify it.
+func (t *TypedTool[T]) InputSchema() map[string]any {
+	return t.schema
+}
+
+func (t *TypedTool[T]) Execute(ctx context.Context, toolCtx *ToolContext, input map[string]any) (ToolResult, error) {
+	in, err := DecodeInput[T](input)
+	if err != nil {
+		return NewErrorResultf("invalid input for %s: %v", t.name, err).WithErrorCode(ErrCodeInvalidInput, false), nil
+	}
+	return t.execute(ctx, toolCtx, in)
+}
+
+// DecodeInput converts a tool's input map into T through its JSON
+// encoding. Fields missing from input keep their zero values, and input
+// fields T does not declare are ignored.
+func DecodeInput[T any](input map[string]any) (T, error) {
+	var out T
+	data, err := json.Marshal(input)
+	if err != nil {
+		return out, err
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// SchemaFor derives the JSON Schema of a struct type's input from its
+// fields:
+//
+//   - The property name comes from the json tag; fields tagged "-" and
+//     unexported fields are skipped, and embedded structs are flattened.
+//   - A field is required unless its json tag has omitempty or it is a
+//     pointer. The jsonschema options required and optional override that.
+//   - Strings, booleans, integers, floats, slices, arrays, string-keyed
+//     maps, nested structs, and time.Time (a date-time string) are
+//     supported. Interface fields accept any value.
+//
+// The jsonschema tag holds comma-separated options: required, optional,
+// enum=a|b|c, default=v, minimum=n, maximum=n, minLength=n, maxLength=n,
+// pattern=re, format=f, and description=text. description takes the rest
+// of the tag, so it must come last and may contain commas.
+func SchemaFor[T any]() (map[string]any, error) {
+	typ := reflect.TypeFor[T]()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("input type %s is not a struct", typ)
+	}
+	return schemaOf(typ, nil)
+}
+
+// MustSchemaFor is like SchemaFor but panics on error. It suits tools
+// whose InputSchema returns the schema of a fixed input struct.
+func MustSchemaFor[T any]() map[string]any {
+	schema, err := SchemaFor[T]()
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// schemaOf returns the schema of typ. seen holds the struct types being
+// expanded, to reject recursive types.
+func schemaOf(typ reflect.Type, seen []reflect.Type) (map[string]any, error) {
+	if typ == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	}
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return schemaOf(typ.Elem(), seen)
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes []byte as a base64 string.
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := schemaOf(typ.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map key type %s is not a string", typ.Key())
+		}
+		values, err := schemaOf(typ.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		for _, s := range seen {
+			if s == typ {
+				return nil, fmt.Errorf("recursive type %s", typ)
+			}
+		}
+		return structSchema(typ, append(seen, typ))
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+}
+
+func structSchema(typ reflect.Type, seen []reflect.Type) (map[string]any, error) {
+	properties := map[string]any{}
+	var required []string
+	if err := addFields(typ, seen, properties, &required); err != nil {
+		return nil, err
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+func addFields(typ reflect.Type, seen []reflect.Type, properties map[string]any, required *[]string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addFields(embedded, seen, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop, err := schemaOf(field.Type, seen)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		isRequired := !strings.Contains(","+opts+",", ",omitempty,") && field.Type.Kind() != reflect.Pointer
+		if tag, ok := field.Tag.Lookup("jsonschema"); ok {
+			if isRequired, err = applySchemaTag(prop, tag, isRequired); err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+		properties[name] = prop
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// applySchemaTag adds the options of a jsonschema tag to prop and returns
+// whether the field is required.
+func applySchemaTag(prop map[string]any, tag string, isRequired bool) (bool, error) {
+	typ, _ := prop["type"].(string)
+	for tag != "" {
+		var opt string
+		if strings.HasPrefix(tag, "description=") {
+			opt, tag = tag, ""
+		} else {
+			opt, tag, _ = strings.Cut(tag, ",")
+		}
+		key, value, hasValue := strings.Cut(strings.TrimSpace(opt), "=")
+		switch key {
+		case "":
+		case "required":
+			isRequired = true
+		case "optional":
+			isRequired = false
+		case "description", "pattern", "format":
+			prop[key] = value
+		case "enum":
+			values := strings.Split(value, "|")
+			if typ == "string" {
+				prop["enum"] = values
+				continue
+			}
+			enum := make([]any, len(values))
+			for i, v := range values {
+				parsed, err := parseSchemaValue(typ, v)
+				if err != nil {
+					return false, fmt.Errorf("enum: %w", err)
+				}
+				enum[i] = parsed
+			}
+			prop["enum"] = enum
+		case "default":
+			parsed, err := parseSchemaValue(typ, value)
+			if err != nil {
+				return false, fmt.Errorf("default: %w", err)
+			}
+			prop["default"] = parsed
+		case "minimum", "maximum", "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.ParseFloat(value, 64)
+			if !hasValue || err != nil {
+				return false, fmt.Errorf("%s must be a number, got %q", key, value)
+			}
+			prop[key] = n
+		default:
+			return false, fmt.Errorf("unknown jsonschema option %q", key)
+		}
+	}
+	return isRequired, nil
+}
+
+// parseSchemaValue parses a tag value as the given JSON Schema type.
+func parseSchemaValue(typ, value string) (any, error) {
+	switch typ {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return n, nil
+	case "boolean":
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
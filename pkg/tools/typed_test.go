@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type typedAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty" jsonschema:"pattern=^[0-9]{5}$"`
+}
+
+type typedCommon struct {
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+type typedInput struct {
+	typedCommon
+	Name    string         `json:"name" jsonschema:"description=Who to greet, by name"`
+	Times   int            `json:"times,omitempty" jsonschema:"minimum=1,maximum=5,default=1"`
+	Mode    string         `json:"mode" jsonschema:"optional,enum=short|long"`
+	Ratio   *float64       `json:"ratio"`
+	Tags    []string       `json:"tags,omitempty"`
+	Labels  map[string]int `json:"labels,omitempty"`
+	Address typedAddress   `json:"address,omitempty"`
+	At      time.Time      `json:"at,omitempty"`
+	Extra   any            `json:"extra,omitempty"`
+	Ignored string         `json:"-"`
+	hidden  string
+	Meta    map[string]string `json:"meta,omitempty" jsonschema:"required"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema, err := SchemaFor[typedInput]()
+	if err != nil {
+		t.Fatalf("SchemaFor() error = %v", err)
+	}
+	if got, want := schema["required"], []string{"name", "meta"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("required = %v, want %v", got, want)
+	}
+
+	props := schema["properties"].(map[string]any)
+	want := map[string]any{
+		"dry_run": map[string]any{"type": "boolean"},
+		"name":    map[string]any{"type": "string", "description": "Who to greet, by name"},
+		"times":   map[string]any{"type": "integer", "minimum": 1.0, "maximum": 5.0, "default": 1.0},
+		"mode":    map[string]any{"type": "string", "enum": []string{"short", "long"}},
+		"ratio":   map[string]any{"type": "number"},
+		"tags":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"labels":  map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "integer"}},
+		"address": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city": map[string]any{"type": "string"},
+				"zip":  map[string]any{"type": "string", "pattern": "^[0-9]{5}$"},
+			},
+			"required": []string{"city"},
+		},
+		"at":    map[string]any{"type": "string", "format": "date-time"},
+		"extra": map[string]any{},
+		"meta":  map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+	}
+	if !reflect.DeepEqual(props, want) {
+		t.Fatalf("properties = %#v\nwant %#v", props, want)
+	}
+}
+
+func TestSchemaForRejectsUnsupportedTypes(t *testing.T) {
+	type badTag struct {
+		N int `json:"n" jsonschema:"minimum=low"`
+	}
+	type recursive struct {
+		Child *recursive `json:"child"`
+	}
+	type badMap struct {
+		M map[int]string `json:"m"`
+	}
+	type badKind struct {
+		C chan int `json:"c"`
+	}
+	for name, fn := range map[string]func() (map[string]any, error){
+		"tag":       SchemaFor[badTag],
+		"recursive": SchemaFor[recursive],
+		"map":       SchemaFor[badMap],
+		"kind":      SchemaFor[badKind],
+		"scalar":    SchemaFor[string],
+	} {
+		if _, err := fn(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNewTool(t *testing.T) {
+	type greetInput struct {
+		Name  string `json:"name"`
+		Times int    `json:"times,omitempty"`
+	}
+	tool := NewTool("greet", "Greet someone.", func(_ context.Context, _ *ToolContext, in greetInput) (ToolResult, error) {
+		return NewToolResult(strings.Repeat("hi "+in.Name+" ", in.Times)), nil
+	})
+	var _ Tool = tool
+
+	registry := NewRegistry()
+	registry.MustRegister(tool)
+
+	input, err := ValidateInput("greet", tool.InputSchema(), map[string]any{"name": "Ada", "times": "2"})
+	if err != nil {
+		t.Fatalf("ValidateInput() error = %v", err)
+	}
+	result, err := tool.Execute(context.Background(), NewToolContext(t.TempDir()), input)
+	if err != nil || result.IsError || result.Content != "hi Ada hi Ada " {
+		t.Fatalf("Execute() = %#v, %v", result, err)
+	}
+
+	result, _ = tool.Execute(context.Background(), NewToolContext(t.TempDir()), map[string]any{"name": 7})
+	if !result.IsError || result.ErrorCode != ErrCodeInvalidInput {
+		t.Fatalf("expected an invalid input error, got %#v", result)
+	}
+}
+
+func TestNewToolPanicsOnUnsupportedInput(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	NewTool("bad", "", func(context.Context, *ToolContext, string) (ToolResult, error) {
+		return ToolResult{}, nil
+	})
+}
@@ -0,0 +1,18 @@
+package tools
+
+import "context"
+
+// UserQuestion is a clarifying question for the user, asked by a tool such
+// as ask_user.
+type UserQuestion struct {
+	// Question is the text shown to the user.
+	Question string
+
+	// Options are suggested answers for a multiple-choice question. The
+	// user may still answer freely.
+	Options []string
+}
+
+// UserAsker shows a question to the user and blocks until they answer or
+// ctx ends.
+type UserAsker func(ctx context.Context, q UserQuestion) (string, error)
@@ -0,0 +1,310 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationIssue describes a single schema violation in tool input.
+type ValidationIssue struct {
+	// Path is the dotted location of the offending value (e.g. "paths[0]").
+	Path string
+
+	// Message explains what is wrong with the value.
+	Message string
+}
+
+// ValidationError is returned when tool input does not match the tool's InputSchema.
+// Its message is written for the model so it can correct the call and retry.
+type ValidationError struct {
+	Tool   string
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid input for tool %q:", e.Tool)
+	for _, issue := range e.Issues {
+		b.WriteString("\n- ")
+		if issue.Path != "" {
+			b.WriteString(issue.Path)
+			b.WriteString(": ")
+		}
+		b.WriteString(issue.Message)
+	}
+	b.WriteString("\nFix the arguments to match the tool's input schema and call it again.")
+	return b.String()
+}
+
+// ValidateInput checks input against a JSON Schema subset (type, required,
+// enum, properties, items) and returns a coerced copy of the input.
+//
+// Coercion rules for common model mistakes:
+//   - numeric strings are converted for integer/number fields ("5" -> 5)
+//   - "true"/"false" strings are converted for boolean fields
+//   - Go integer values are normalized to float64, matching JSON decoding
+//   - a single value is wrapped when an array is expected
+//
+// A nil or empty schema accepts any input unchanged.
+func ValidateInput(toolName string, schema map[string]any, input map[string]any) (map[string]any, error) {
+	if input == nil {
+		input = map[string]any{}
+	}
+	if len(schema) == 0 {
+		return input, nil
+	}
+
+	v := &schemaValidator{}
+	coerced := v.validate("", schema, input)
+	if len(v.issues) > 0 {
+		return input, &ValidationError{Tool: toolName, Issues: v.issues}
+	}
+	obj, ok := coerced.(map[string]any)
+	if !ok {
+		return input, nil
+	}
+	return obj, nil
+}
+
+type schemaValidator struct {
+	issues []ValidationIssue
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	v.issues = append(v.issues, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(path string, schema map[string]any, value any) any {
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			v.fail(path, "expected object, got %s", describeType(value))
+			return value
+		}
+		value = v.validateObject(path, schema, obj)
+	case "array":
+		value = v.validateArray(path, schema, value)
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			v.fail(path, "expected string, got %s", describeType(value))
+			return value
+		}
+		value = s
+	case "integer":
+		n, ok := coerceNumber(value)
+		if !ok {
+			v.fail(path, "expected integer, got %s", describeType(value))
+			return value
+		}
+		if n != math.Trunc(n) {
+			v.fail(path, "expected integer, got fractional number %v", n)
+			return value
+		}
+		value = n
+	case "number":
+		n, ok := coerceNumber(value)
+		if !ok {
+			v.fail(path, "expected number, got %s", describeType(value))
+			return value
+		}
+		value = n
+	case "boolean":
+		b, ok := coerceBool(value)
+		if !ok {
+			v.fail(path, "expected boolean, got %s", describeType(value))
+			return value
+		}
+		value = b
+	}
+
+	if enum := toAnySlice(schema["enum"]); len(enum) > 0 && !enumContains(enum, value) {
+		v.fail(path, "must be one of %s", formatEnum(enum))
+	}
+	return value
+}
+
+func (v *schemaValidator) validateObject(path string, schema map[string]any, obj map[string]any) map[string]any {
+	out := make(map[string]any, len(obj))
+	for k, val := range obj {
+		out[k] = val
+	}
+
+	for _, name := range toStringSlice(schema["required"]) {
+		if val, ok := out[name]; !ok || val == nil {
+			v.fail(joinPath(path, name), "is required")
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		val, ok := out[name]
+		if !ok || val == nil {
+			continue
+		}
+		propSchema, ok := props[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		out[name] = v.validate(joinPath(path, name), propSchema, val)
+	}
+	return out
+}
+
+func (v *schemaValidator) validateArray(path string, schema map[string]any, value any) any {
+	items := toAnySlice(value)
+	if items == nil {
+		if value == nil {
+			v.fail(path, "expected array, got null")
+			return value
+		}
+		if _, isMap := value.(map[string]any); isMap {
+			v.fail(path, "expected array, got object")
+			return value
+		}
+		// Wrap a scalar the model passed where a single-element array was expected.
+		items = []any{value}
+	}
+
+	itemSchema, _ := schema["items"].(map[string]any)
+	out := make([]any, len(items))
+	for i, item := range items {
+		if itemSchema == nil {
+			out[i] = item
+			continue
+		}
+		out[i] = v.validate(fmt.Sprintf("%s[%d]", path, i), itemSchema, item)
+	}
+	return out
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// coerceNumber returns value as a finite number. JSON has no infinities or
+// NaN, but ParseFloat accepts "Inf" and "NaN", and Inf would even pass as
+// an integer.
+func coerceNumber(value any) (float64, bool) {
+	var f float64
+	switch n := value.(type) {
+	case float64:
+		f = n
+	case float32:
+		f = float64(n)
+	case int:
+		f = float64(n)
+	case int32:
+		f = float64(n)
+	case int64:
+		f = float64(n)
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, false
+		}
+		f = parsed
+	default:
+		return 0, false
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, false
+	}
+	return f, true
+}
+
+func coerceBool(value any) (bool, bool) {
+	switch b := value.(type) {
+	case bool:
+		return b, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(b)) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func toAnySlice(value any) []any {
+	switch s := value.(type) {
+	case []any:
+		return s
+	case []string:
+		out := make([]any, len(s))
+		for i, item := range s {
+			out[i] = item
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func toStringSlice(value any) []string {
+	switch s := value.(type) {
+	case []string:
+		return s
+	case []any:
+		out := make([]string, 0, len(s))
+		for _, item := range s {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func enumContains(enum []any, value any) bool {
+	for _, candidate := range enum {
+		if fmt.Sprint(candidate) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []any) string {
+	parts := make([]string, len(enum))
+	for i, item := range enum {
+		parts[i] = fmt.Sprintf("%q", fmt.Sprint(item))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func describeType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int32, int64:
+		return "number"
+	case []any, []string:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
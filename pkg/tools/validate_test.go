@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func validateTestSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{"type": "string"},
+			"count": map[string]any{
+				"type": "integer",
+			},
+			"staged": map[string]any{"type": "boolean"},
+			"action": map[string]any{
+				"type": "string",
+				"enum": []string{"list", "create"},
+			},
+			"paths": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string"},
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func TestValidateInputAcceptsValidInput(t *testing.T) {
+	input := map[string]any{"path": "a.txt", "count": float64(3), "action": "list"}
+	got, err := ValidateInput("t", validateTestSchema(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["path"] != "a.txt" || got["count"] != float64(3) {
+		t.Fatalf("unexpected output: %#v", got)
+	}
+}
+
+func TestValidateInputReportsMissingRequired(t *testing.T) {
+	_, err := ValidateInput("read_file", validateTestSchema(), map[string]any{})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(vErr.Issues) != 1 || vErr.Issues[0].Path != "path" {
+		t.Fatalf("unexpected issues: %#v", vErr.Issues)
+	}
+	if !strings.Contains(err.Error(), `invalid input for tool "read_file"`) {
+		t.Fatalf("unexpected message: %s", err.Error())
+	}
+}
+
+func TestValidateInputCoercesCommonMismatches(t *testing.T) {
+	input := map[string]any{
+		"path":   "a.txt",
+		"count":  "5",
+		"staged": "true",
+		"paths":  "single.txt",
+	}
+	got, err := ValidateInput("t", validateTestSchema(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["count"] != float64(5) {
+		t.Errorf("expected count coerced to 5, got %#v", got["count"])
+	}
+	if got["staged"] != true {
+		t.Errorf("expected staged coerced to true, got %#v", got["staged"])
+	}
+	paths, ok := got["paths"].([]any)
+	if !ok || len(paths) != 1 || paths[0] != "single.txt" {
+		t.Errorf("expected paths wrapped into array, got %#v", got["paths"])
+	}
+	if input["count"] != "5" {
+		t.Errorf("expected original input to be left untouched")
+	}
+}
+
+func TestValidateInputRejectsTypeAndEnumViolations(t *testing.T) {
+	input := map[string]any{
+		"path":   float64(1),
+		"count":  2.5,
+		"action": "delete",
+		"paths":  []any{"ok", true},
+	}
+	_, err := ValidateInput("t", validateTestSchema(), input)
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	got := map[string]bool{}
+	for _, issue := range vErr.Issues {
+		got[issue.Path] = true
+	}
+	for _, path := range []string{"path", "count", "action", "paths[1]"} {
+		if !got[path] {
+			t.Errorf("expected issue for %s, got %#v", path, vErr.Issues)
+		}
+	}
+}
+
+func TestValidateInputRejectsNonFiniteNumbers(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"count": map[string]any{"type": "integer"},
+			"ratio": map[string]any{"type": "number"},
+		},
+	}
+	tests := []struct {
+		name  string
+		field string
+		value any
+	}{
+		{"integer Inf", "count", "Inf"},
+		{"integer -Inf", "count", "-Inf"},
+		{"integer NaN", "count", "NaN"},
+		{"integer Infinity", "count", "Infinity"},
+		{"integer float Inf", "count", math.Inf(1)},
+		{"number Inf", "ratio", "Inf"},
+		{"number -Inf", "ratio", "-inf"},
+		{"number NaN", "ratio", "nan"},
+		{"number float NaN", "ratio", math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateInput("t", schema, map[string]any{tt.field: tt.value})
+			var vErr *ValidationError
+			if !errors.As(err, &vErr) || len(vErr.Issues) != 1 || vErr.Issues[0].Path != tt.field {
+				t.Fatalf("expected an issue for %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestValidateInputEmptySchemaAcceptsAnything(t *testing.T) {
+	input := map[string]any{"anything": 1}
+	got, err := ValidateInput("t", nil, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["anything"] != 1 {
+		t.Fatalf("unexpected output: %#v", got)
+	}
+}
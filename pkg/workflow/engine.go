@@ -0,0 +1,468 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// DefaultMaxSteps bounds step executions per run, so that workflows that
+// jump back (e.g. review → implement) cannot loop forever.
+const DefaultMaxSteps = 50
+
+var (
+	// ErrRejected is returned when an approval step without OnReject is
+	// rejected.
+	ErrRejected = errors.New("workflow: approval rejected")
+
+	// ErrMaxSteps is returned when a run executes Config.MaxSteps steps
+	// without finishing.
+	ErrMaxSteps = errors.New("workflow: max steps reached")
+)
+
+// ApprovalRequest asks a human to approve a step.
+type ApprovalRequest struct {
+	Workflow string
+	Step     string
+
+	// Prompt is the step's rendered Approval text.
+	Prompt string
+
+	// Steps are the results of the steps run so far.
+	Steps []StepResult
+}
+
+// Approval is an approver's decision. Comment becomes the step's output.
+type Approval struct {
+	Approved bool
+	Comment  string
+}
+
+// Approver decides approval steps, e.g. by asking in a chat or ticket. It
+// may block until a human answers; ctx is canceled when the run is.
+type Approver func(ctx context.Context, req ApprovalRequest) (Approval, error)
+
+// Config configures an Engine.
+type Config struct {
+	// Agent runs agent steps without an Agent name.
+	Agent agent.Agent
+
+	// Agents are the named agents steps select with Step.Agent.
+	Agents map[string]agent.Agent
+
+	// Tools provides the tools of tool steps.
+	Tools *tools.Registry
+
+	// Approver decides approval steps. Workflows with approval steps fail
+	// without one.
+	Approver Approver
+
+	// Request is the template for agent step requests (WorkDir, Env,
+	// Options, Callbacks, ...). Task is replaced per step; WorkDir is also
+	// the working directory of tool steps.
+	Request agent.AgentRequest
+
+	// MaxSteps bounds step executions per run (default DefaultMaxSteps).
+	MaxSteps int
+}
+
+// StepResult is the outcome of one step execution.
+type StepResult struct {
+	ID   string
+	Kind StepKind
+
+	// Output is the agent's reply, the tool output, or the approval
+	// comment.
+	Output string
+
+	// Data is the parsed output of a structured step.
+	Data map[string]any
+
+	// Agent is the agent result of an agent step.
+	Agent *agent.AgentResult
+
+	// Approved reports the decision of an approval step.
+	Approved bool
+
+	// Next is the step the run continued with, or "end".
+	Next string
+
+	Duration time.Duration
+	Err      error
+}
+
+// Result is the combined outcome of a run.
+type Result struct {
+	// Success is true when the run finished without a failed step.
+	Success bool
+
+	// Message is the output of the last successful agent or tool step.
+	Message string
+
+	// Steps lists step executions in order. A step that ran more than once
+	// appears once per execution.
+	Steps []StepResult
+
+	// Usage sums the usage of all agent steps.
+	Usage agent.ExecutionUsage
+}
+
+// Step returns the latest execution of the step with id.
+func (r Result) Step(id string) (StepResult, bool) {
+	for i := len(r.Steps) - 1; i >= 0; i-- {
+		if r.Steps[i].ID == id {
+			return r.Steps[i], true
+		}
+	}
+	return StepResult{}, false
+}
+
+// Engine runs workflows. It is safe for concurrent Run calls as long as
+// its agents are.
+type Engine struct {
+	cfg Config
+}
+
+// New returns an Engine for cfg.
+func New(cfg Config) (*Engine, error) {
+	if cfg.Agent == nil && len(cfg.Agents) == 0 && cfg.Tools == nil {
+		return nil, fmt.Errorf("workflow: an agent or tools registry is required")
+	}
+	if cfg.MaxSteps <= 0 {
+		cfg.MaxSteps = DefaultMaxSteps
+	}
+	return &Engine{cfg: cfg}, nil
+}
+
+// Run validates wf and runs it with input from the first step until a step
+// jumps to "end" or the last step finishes. A failed step stops the run
+// unless it has ContinueOnError; the returned error then names the step.
+func (e *Engine) Run(ctx context.Context, wf Workflow, input string) (Result, error) {
+	if err := wf.Validate(); err != nil {
+		return Result{}, err
+	}
+	index := make(map[string]int, len(wf.Steps))
+	for i, s := range wf.Steps {
+		index[s.ID] = i
+	}
+
+	run := &runState{input: input, steps: make(map[string]StepResult)}
+	var result Result
+	var runErr error
+	pos := 0
+	for executed := 0; pos < len(wf.Steps); executed++ {
+		if err := ctx.Err(); err != nil {
+			runErr = err
+			break
+		}
+		if executed == e.cfg.MaxSteps {
+			runErr = ErrMaxSteps
+			break
+		}
+
+		step := wf.Steps[pos]
+		log.Printf("[workflow] %s: step %s (%s)", wf.Name, step.ID, step.Kind())
+		start := time.Now()
+		sr, err := e.runStep(ctx, wf, step, run, result.Steps)
+		sr.ID, sr.Kind, sr.Err = step.ID, step.Kind(), err
+		sr.Duration = time.Since(start)
+
+		next := sr.Next
+		if next == "" {
+			next = step.Next
+		}
+		switch {
+		case errors.Is(err, ErrRejected) && step.OnReject != "":
+			sr.Err = nil
+			next = step.OnReject
+		case err != nil:
+			log.Printf("[workflow] ERROR: %s: step %s failed: %v", wf.Name, step.ID, err)
+			if !step.ContinueOnError {
+				runErr = fmt.Errorf("workflow %s: step %s: %w", wf.Name, step.ID, err)
+			}
+		}
+		if next == "" {
+			if pos+1 < len(wf.Steps) {
+				next = wf.Steps[pos+1].ID
+			} else {
+				next = End
+			}
+		}
+		sr.Next = next
+		if runErr != nil {
+			sr.Next = ""
+		}
+
+		result.Steps = append(result.Steps, sr)
+		run.steps[step.ID] = sr
+		if sr.Agent != nil {
+			addUsage(&result.Usage, sr.Agent.Usage)
+		}
+		if sr.Err == nil && (sr.Kind == StepAgent || sr.Kind == StepTool) {
+			result.Message = sr.Output
+		}
+		if runErr != nil || next == End {
+			break
+		}
+		pos = index[next]
+	}
+
+	result.Success = runErr == nil
+	for _, sr := range result.Steps {
+		if sr.Err != nil {
+			result.Success = false
+		}
+	}
+	log.Printf("[workflow] %s finished: steps=%d success=%v", wf.Name, len(result.Steps), result.Success)
+	return result, runErr
+}
+
+// runState holds the data templates and conditions see.
+type runState struct {
+	input string
+	steps map[string]StepResult
+}
+
+func (e *Engine) runStep(ctx context.Context, wf Workflow, step Step, run *runState, done []StepResult) (StepResult, error) {
+	if step.Budget.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Budget.Timeout)
+		defer cancel()
+	}
+	switch step.Kind() {
+	case StepAgent:
+		return e.runAgentStep(ctx, step, run)
+	case StepTool:
+		return e.runToolStep(ctx, step, run)
+	case StepBranch:
+		return runBranchStep(step, run)
+	case StepApproval:
+		return e.runApprovalStep(ctx, wf, step, run, done)
+	}
+	return StepResult{}, fmt.Errorf("step defines no task, tool, branch, or approval")
+}
+
+func (e *Engine) runAgentStep(ctx context.Context, step Step, run *runState) (StepResult, error) {
+	a := e.cfg.Agent
+	if step.Agent != "" {
+		a = e.cfg.Agents[step.Agent]
+	}
+	if a == nil {
+		return StepResult{}, fmt.Errorf("unknown agent %q", step.Agent)
+	}
+
+	req := e.cfg.Request
+	task, err := run.render(step.Task)
+	if err != nil {
+		return StepResult{}, err
+	}
+	req.Task = task
+	if step.SystemPrompt != "" {
+		if req.SystemPrompt, err = run.render(step.SystemPrompt); err != nil {
+			return StepResult{}, err
+		}
+	}
+	if step.Budget.MaxIterations > 0 {
+		req.Options.MaxIterations = step.Budget.MaxIterations
+	}
+	if len(step.Budget.ToolBudgets) > 0 {
+		req.Options.ToolBudgets = step.Budget.ToolBudgets
+	}
+	req.Options.StructuredOutput = req.Options.StructuredOutput || step.Structured
+
+	res, err := a.Execute(ctx, req)
+	sr := StepResult{Output: res.Message, Agent: &res}
+	if err == nil && !res.Success {
+		err = errors.New(res.Message)
+	}
+	if err == nil && step.Structured {
+		sr.Data, err = parseData(res.Message)
+	}
+	return sr, err
+}
+
+func (e *Engine) runToolStep(ctx context.Context, step Step, run *runState) (StepResult, error) {
+	if e.cfg.Tools == nil || !e.cfg.Tools.Has(step.Tool) {
+		return StepResult{}, fmt.Errorf("unknown tool %q", step.Tool)
+	}
+	rendered, err := run.renderValue(step.Input)
+	if err != nil {
+		return StepResult{}, err
+	}
+	input, _ := rendered.(map[string]any)
+
+	toolCtx := tools.NewToolContext(e.cfg.Request.WorkDir)
+	for k, v := range e.cfg.Request.Env {
+		toolCtx.Env[k] = v
+	}
+	res, err := e.cfg.Tools.Get(step.Tool).Execute(ctx, toolCtx, input)
+	sr := StepResult{Output: res.Content}
+	switch {
+	case err != nil:
+		return sr, err
+	case res.IsError:
+		return sr, errors.New(res.Content)
+	}
+	if step.Structured {
+		sr.Data, err = parseData(res.Content)
+	}
+	return sr, err
+}
+
+func runBranchStep(step Step, run *runState) (StepResult, error) {
+	for _, c := range step.Branch {
+		ok, err := run.holds(c.When)
+		if err != nil {
+			return StepResult{}, err
+		}
+		if ok {
+			return StepResult{Output: c.Goto, Next: c.Goto}, nil
+		}
+	}
+	return StepResult{Output: step.Default, Next: step.Default}, nil
+}
+
+func (e *Engine) runApprovalStep(ctx context.Context, wf Workflow, step Step, run *runState, done []StepResult) (StepResult, error) {
+	if e.cfg.Approver == nil {
+		return StepResult{}, fmt.Errorf("no approver configured")
+	}
+	prompt, err := run.render(step.Approval)
+	if err != nil {
+		return StepResult{}, err
+	}
+	approval, err := e.cfg.Approver(ctx, ApprovalRequest{
+		Workflow: wf.Name,
+		Step:     step.ID,
+		Prompt:   prompt,
+		Steps:    append([]StepResult(nil), done...),
+	})
+	if err != nil {
+		return StepResult{}, err
+	}
+	sr := StepResult{Output: approval.Comment, Approved: approval.Approved}
+	if !approval.Approved {
+		return sr, ErrRejected
+	}
+	return sr, nil
+}
+
+// render executes text as a template over the input and earlier steps.
+func (r *runState) render(text string) (string, error) {
+	tmpl, err := template.New("step").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, map[string]any{"Input": r.input, "Steps": r.steps}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// renderValue renders the strings in a tool input.
+func (r *runState) renderValue(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return r.render(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			rendered, err := r.renderValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = rendered
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			rendered, err := r.renderValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// holds evaluates a branch condition.
+func (r *runState) holds(c Condition) (bool, error) {
+	if c.If != nil {
+		return c.If(r.steps), nil
+	}
+	stepID, path, _ := strings.Cut(c.Field, ".")
+	sr, ok := r.steps[stepID]
+	if !ok {
+		return false, fmt.Errorf("condition on step %s, which has not run", stepID)
+	}
+	var value any = sr.Output
+	if path != "" {
+		value = lookup(sr.Data, strings.Split(path, "."))
+	}
+	text := ""
+	if value != nil {
+		text = fmt.Sprint(value)
+	}
+
+	switch {
+	case c.Equals != nil:
+		return text == fmt.Sprint(c.Equals), nil
+	case c.NotEquals != nil:
+		return text != fmt.Sprint(c.NotEquals), nil
+	case c.Contains != "":
+		return strings.Contains(text, c.Contains), nil
+	}
+	return value != nil && text != "", nil
+}
+
+// lookup follows path through nested objects; it returns nil when a key
+// is missing.
+func lookup(data map[string]any, path []string) any {
+	var cur any = data
+	for _, key := range path {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = obj[key]
+	}
+	return cur
+}
+
+// parseData parses a structured step's output as a JSON object, allowing a
+// Markdown code fence around it.
+func parseData(output string) (map[string]any, error) {
+	text := strings.TrimSpace(output)
+	if strings.HasPrefix(text, "```") {
+		if nl := strings.IndexByte(text, '\n'); nl >= 0 {
+			text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text[nl+1:]), "```"))
+		}
+	}
+	var data map[string]any
+	if err := json.Unmarshal([]byte(text), &data); err != nil {
+		return nil, fmt.Errorf("structured output is not a JSON object: %w", err)
+	}
+	return data, nil
+}
+
+func addUsage(total *agent.ExecutionUsage, u agent.ExecutionUsage) {
+	total.TotalIterations += u.TotalIterations
+	total.TotalInputTokens += u.TotalInputTokens
+	total.TotalOutputTokens += u.TotalOutputTokens
+	total.TotalCachedInputTokens += u.TotalCachedInputTokens
+	total.EstimatedCost += u.EstimatedCost
+	total.TotalDuration += u.TotalDuration
+	total.ToolPairRepairs += u.ToolPairRepairs
+}
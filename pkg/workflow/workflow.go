@@ -0,0 +1,233 @@
+// Package workflow runs declarative multi-step pipelines, such as
+// "triage → plan → implement → review", on top of agents. A Workflow is a
+// list of steps defined in Go or YAML: agent tasks, tool invocations,
+// branches on a step's structured output, and human approval gates. Each
+// step has its own budget, and the Engine collects every step into one
+// Result.
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// End is the Next or Goto target that finishes a run.
+const End = "end"
+
+// StepKind classifies a step by the field that defines it.
+type StepKind string
+
+const (
+	// StepAgent runs an agent with Task.
+	StepAgent StepKind = "agent"
+
+	// StepTool invokes Tool with Input, without a model.
+	StepTool StepKind = "tool"
+
+	// StepBranch jumps to the first case whose condition holds.
+	StepBranch StepKind = "branch"
+
+	// StepApproval asks the Approver to approve Approval before continuing.
+	StepApproval StepKind = "approval"
+)
+
+// Workflow is a named list of steps. Steps run in order unless a step's
+// Next, a branch, or a rejected approval jumps elsewhere.
+type Workflow struct {
+	Name  string `yaml:"name"`
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is one step of a workflow. Exactly one of Task, Tool, Branch, or
+// Approval defines what it does.
+//
+// Task, Approval, SystemPrompt, and string values in Input are Go
+// templates rendered with the workflow input and earlier steps:
+//
+//	{{.Input}}                      the input passed to Engine.Run
+//	{{.Steps.triage.Output}}        a step's reply, tool output, or approval comment
+//	{{.Steps.triage.Data.severity}} a field of a structured step's output
+type Step struct {
+	// ID names the step in templates, conditions, and jumps. Required and
+	// unique; "end" is reserved.
+	ID string `yaml:"id"`
+
+	// Task is the agent task of an agent step.
+	Task string `yaml:"task,omitempty"`
+
+	// Agent selects one of Config.Agents; empty uses Config.Agent.
+	Agent string `yaml:"agent,omitempty"`
+
+	// SystemPrompt overrides the request's system prompt for this step.
+	SystemPrompt string `yaml:"system_prompt,omitempty"`
+
+	// Structured asks for a JSON object reply (AgentOptions.StructuredOutput)
+	// and parses it into StepResult.Data. For tool steps it parses the tool
+	// output. A reply that is not a JSON object fails the step.
+	Structured bool `yaml:"structured,omitempty"`
+
+	// Tool and Input define a tool step. The tool must be in Config.Tools.
+	Tool  string         `yaml:"tool,omitempty"`
+	Input map[string]any `yaml:"input,omitempty"`
+
+	// Branch and Default define a branch step: the first case whose
+	// condition holds selects the next step, otherwise Default does. An
+	// empty Default continues with the following step.
+	Branch  []Case `yaml:"branch,omitempty"`
+	Default string `yaml:"default,omitempty"`
+
+	// Approval is the prompt of an approval step. When the approver
+	// rejects, the run jumps to OnReject, or fails with ErrRejected if it
+	// is empty.
+	Approval string `yaml:"approval,omitempty"`
+	OnReject string `yaml:"on_reject,omitempty"`
+
+	// Budget bounds the step. Timeout applies to every kind; the other
+	// limits apply to agent steps.
+	Budget Budget `yaml:"budget,omitempty"`
+
+	// ContinueOnError records a failure and moves on instead of failing
+	// the run.
+	ContinueOnError bool `yaml:"continue_on_error,omitempty"`
+
+	// Next is the step to run afterwards; empty continues with the
+	// following step and "end" finishes the run.
+	Next string `yaml:"next,omitempty"`
+}
+
+// Kind reports which kind of step s is, or "" when it defines none.
+func (s Step) Kind() StepKind {
+	switch {
+	case s.Tool != "":
+		return StepTool
+	case len(s.Branch) > 0:
+		return StepBranch
+	case s.Approval != "":
+		return StepApproval
+	case s.Task != "":
+		return StepAgent
+	}
+	return ""
+}
+
+// Budget bounds one step. Zero fields leave the request's settings.
+type Budget struct {
+	// MaxIterations caps the agent loop iterations.
+	MaxIterations int `yaml:"max_iterations,omitempty"`
+
+	// Timeout cancels the step after the duration, e.g. "10m" in YAML.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// ToolBudgets caps calls per tool name (AgentOptions.ToolBudgets).
+	ToolBudgets map[string]int `yaml:"tool_budgets,omitempty"`
+}
+
+// Case is one branch of a branch step.
+type Case struct {
+	When Condition `yaml:"when"`
+	Goto string    `yaml:"goto"`
+}
+
+// Condition tests the output of an earlier step.
+type Condition struct {
+	// Field is "<step>" for a step's output, or "<step>.<path>" for a
+	// field of its structured data, e.g. "triage.severity" or
+	// "review.issues.count".
+	Field string `yaml:"field"`
+
+	// Equals, NotEquals, and Contains compare the field's value, rendered
+	// as text. Set one of them.
+	Equals    any    `yaml:"equals,omitempty"`
+	NotEquals any    `yaml:"not_equals,omitempty"`
+	Contains  string `yaml:"contains,omitempty"`
+
+	// If, when set, decides instead of the fields above. It is only
+	// available in Go.
+	If func(steps map[string]StepResult) bool `yaml:"-"`
+}
+
+// Parse reads a workflow from YAML (or JSON) and validates it.
+func Parse(data []byte) (Workflow, error) {
+	var wf Workflow
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&wf); err != nil {
+		return Workflow{}, fmt.Errorf("workflow: parse: %w", err)
+	}
+	if err := wf.Validate(); err != nil {
+		return Workflow{}, err
+	}
+	return wf, nil
+}
+
+// Load reads and parses a workflow file.
+func Load(path string) (Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Workflow{}, fmt.Errorf("workflow: %w", err)
+	}
+	return Parse(data)
+}
+
+// Validate checks that every step has a unique ID, defines exactly one
+// kind, and jumps only to existing steps.
+func (wf Workflow) Validate() error {
+	if len(wf.Steps) == 0 {
+		return fmt.Errorf("workflow %s: at least one step is required", wf.Name)
+	}
+	ids := make(map[string]bool, len(wf.Steps))
+	for i, s := range wf.Steps {
+		switch {
+		case strings.TrimSpace(s.ID) == "":
+			return fmt.Errorf("workflow %s: step %d has no id", wf.Name, i+1)
+		case s.ID == End:
+			return fmt.Errorf("workflow %s: step id %q is reserved", wf.Name, End)
+		case ids[s.ID]:
+			return fmt.Errorf("workflow %s: duplicate step %s", wf.Name, s.ID)
+		}
+		ids[s.ID] = true
+
+		defined := 0
+		for _, set := range []bool{s.Task != "", s.Tool != "", len(s.Branch) > 0, s.Approval != ""} {
+			if set {
+				defined++
+			}
+		}
+		if defined != 1 {
+			return fmt.Errorf("workflow %s: step %s must set exactly one of task, tool, branch, or approval", wf.Name, s.ID)
+		}
+	}
+
+	checkTarget := func(step, target string) error {
+		if target != "" && target != End && !ids[target] {
+			return fmt.Errorf("workflow %s: step %s jumps to unknown step %s", wf.Name, step, target)
+		}
+		return nil
+	}
+	for _, s := range wf.Steps {
+		targets := []string{s.Next, s.Default, s.OnReject}
+		for _, c := range s.Branch {
+			if c.Goto == "" {
+				return fmt.Errorf("workflow %s: step %s has a case without goto", wf.Name, s.ID)
+			}
+			if c.When.If == nil && c.When.Field == "" {
+				return fmt.Errorf("workflow %s: step %s has a case without a condition", wf.Name, s.ID)
+			}
+			if ref, _, _ := strings.Cut(c.When.Field, "."); c.When.If == nil && !ids[ref] {
+				return fmt.Errorf("workflow %s: step %s tests unknown step %s", wf.Name, s.ID, ref)
+			}
+			targets = append(targets, c.Goto)
+		}
+		for _, target := range targets {
+			if err := checkTarget(s.ID, target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
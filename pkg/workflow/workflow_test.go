@@ -0,0 +1,197 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/agent"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+// funcAgent is an agent.Agent backed by a function.
+type funcAgent struct {
+	execute func(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error)
+}
+
+func (a funcAgent) Execute(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+	return a.execute(ctx, req)
+}
+
+func (a funcAgent) ExecuteStream(ctx context.Context, req agent.AgentRequest) (<-chan agent.AgentStreamEvent, <-chan error) {
+	events := make(chan agent.AgentStreamEvent)
+	errs := make(chan error, 1)
+	close(events)
+	close(errs)
+	return events, errs
+}
+
+func (a funcAgent) Capabilities() agent.AgentCapabilities { return agent.AgentCapabilities{} }
+func (a funcAgent) Close() error                          { return nil }
+
+// echoTool returns its "text" input.
+type echoTool struct{}
+
+func (echoTool) Name() string                { return "echo" }
+func (echoTool) Description() string         { return "echo" }
+func (echoTool) InputSchema() map[string]any { return map[string]any{"type": "object"} }
+func (echoTool) Execute(_ context.Context, _ *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	return tools.NewToolResult(input["text"].(string)), nil
+}
+
+const pipelineYAML = `
+name: triage-to-review
+steps:
+  - id: triage
+    agent: triager
+    task: "Triage: {{.Input}}"
+    structured: true
+    budget:
+      max_iterations: 3
+      timeout: 1m
+  - id: route
+    branch:
+      - when: {field: triage.severity, equals: low}
+        goto: end
+    default: implement
+  - id: implement
+    task: "Fix {{.Steps.triage.Data.summary}}"
+  - id: approve
+    approval: "Ship {{.Steps.implement.Output}}?"
+    on_reject: implement
+  - id: notify
+    tool: echo
+    input: {text: "shipped {{.Steps.approve.Output}}"}
+`
+
+func TestEngineRunsParsedPipeline(t *testing.T) {
+	wf, err := Parse([]byte(pipelineYAML))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if wf.Steps[0].Budget.Timeout != time.Minute || wf.Steps[1].Kind() != StepBranch {
+		t.Fatalf("unexpected parsed workflow: %+v", wf)
+	}
+
+	var triageReq agent.AgentRequest
+	triager := funcAgent{execute: func(_ context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+		triageReq = req
+		return agent.AgentResult{Success: true, Message: "```json\n{\"severity\":\"high\",\"summary\":\"the crash\"}\n```",
+			Usage: agent.ExecutionUsage{TotalIterations: 1, TotalInputTokens: 100}}, nil
+	}}
+	attempts := 0
+	coder := funcAgent{execute: func(_ context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+		attempts++
+		if req.Task != "Fix the crash" {
+			t.Errorf("unexpected implement task %q", req.Task)
+		}
+		return agent.AgentResult{Success: true, Message: "patch " + string(rune('0'+attempts)),
+			Usage: agent.ExecutionUsage{TotalIterations: 2, TotalInputTokens: 50}}, nil
+	}}
+	var prompts []string
+	approver := func(_ context.Context, req ApprovalRequest) (Approval, error) {
+		prompts = append(prompts, req.Prompt)
+		if len(prompts) == 1 {
+			return Approval{Approved: false, Comment: "add a test"}, nil
+		}
+		return Approval{Approved: true, Comment: "lgtm"}, nil
+	}
+	registry := tools.NewRegistry()
+	registry.MustRegister(echoTool{})
+
+	engine, err := New(Config{
+		Agent:    coder,
+		Agents:   map[string]agent.Agent{"triager": triager},
+		Tools:    registry,
+		Approver: approver,
+		Request:  agent.AgentRequest{WorkDir: t.TempDir()},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	result, err := engine.Run(context.Background(), wf, "app crashes on start")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if triageReq.Task != "Triage: app crashes on start" || triageReq.Options.MaxIterations != 3 || !triageReq.Options.StructuredOutput {
+		t.Fatalf("unexpected triage request: %+v", triageReq)
+	}
+	var ids []string
+	for _, s := range result.Steps {
+		ids = append(ids, s.ID+">"+s.Next)
+	}
+	want := "triage>route route>implement implement>approve approve>implement implement>approve approve>notify notify>end"
+	if got := strings.Join(ids, " "); got != want {
+		t.Fatalf("steps = %s, want %s", got, want)
+	}
+	if strings.Join(prompts, "|") != "Ship patch 1?|Ship patch 2?" {
+		t.Fatalf("unexpected approval prompts: %q", prompts)
+	}
+	if !result.Success || result.Message != "shipped lgtm" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Usage.TotalIterations != 5 || result.Usage.TotalInputTokens != 200 {
+		t.Fatalf("unexpected usage: %+v", result.Usage)
+	}
+	if triage, ok := result.Step("triage"); !ok || triage.Data["severity"] != "high" {
+		t.Fatalf("unexpected triage step: %+v", triage)
+	}
+}
+
+func TestEngineStopsOnFailure(t *testing.T) {
+	failing := funcAgent{execute: func(ctx context.Context, req agent.AgentRequest) (agent.AgentResult, error) {
+		if req.Task == "slow" {
+			<-ctx.Done()
+			return agent.AgentResult{}, ctx.Err()
+		}
+		return agent.AgentResult{Success: false, Message: "tests fail"}, nil
+	}}
+	engine, err := New(Config{Agent: failing})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	wf := Workflow{Name: "wf", Steps: []Step{
+		{ID: "slow", Task: "slow", Budget: Budget{Timeout: 20 * time.Millisecond}, ContinueOnError: true},
+		{ID: "fix", Task: "fix"},
+		{ID: "never", Task: "never"},
+	}}
+	result, err := engine.Run(context.Background(), wf, "")
+	if err == nil || !strings.Contains(err.Error(), "step fix: tests fail") {
+		t.Fatalf("expected the fix step to fail the run, got %v", err)
+	}
+	if len(result.Steps) != 2 || !errors.Is(result.Steps[0].Err, context.DeadlineExceeded) || result.Success {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	rejecting := func(context.Context, ApprovalRequest) (Approval, error) { return Approval{}, nil }
+	engine, _ = New(Config{Agent: failing, Approver: rejecting})
+	_, err = engine.Run(context.Background(), Workflow{Name: "wf", Steps: []Step{{ID: "gate", Approval: "ok?"}}}, "")
+	if !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+
+	loop := Workflow{Name: "loop", Steps: []Step{{ID: "again", Approval: "ok?", OnReject: "again"}}}
+	engine, _ = New(Config{Agent: failing, Approver: rejecting, MaxSteps: 3})
+	if result, err := engine.Run(context.Background(), loop, ""); !errors.Is(err, ErrMaxSteps) || len(result.Steps) != 3 {
+		t.Fatalf("expected ErrMaxSteps after 3 steps, got %v (%d steps)", err, len(result.Steps))
+	}
+}
+
+func TestParseRejectsInvalidWorkflows(t *testing.T) {
+	for name, src := range map[string]string{
+		"no steps":           "name: x\nsteps: []\n",
+		"unknown field":      "steps:\n  - id: a\n    task: t\n    retries: 2\n",
+		"two kinds":          "steps:\n  - id: a\n    task: t\n    tool: bash\n",
+		"duplicate id":       "steps:\n  - id: a\n    task: t\n  - id: a\n    task: t\n",
+		"unknown jump":       "steps:\n  - id: a\n    task: t\n    next: b\n",
+		"unknown field step": "steps:\n  - id: a\n    branch:\n      - when: {field: b.x, equals: 1}\n        goto: end\n",
+	} {
+		if _, err := Parse([]byte(src)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGitTimeout bounds each git command run by a Manager.
+const DefaultGitTimeout = 5 * time.Minute
+
+// ErrCheckoutConflict is returned when a session already has a checkout of a
+// different repository or ref.
+var ErrCheckoutConflict = errors.New("session already has a checkout of a different repo or ref")
+
+// ManagerConfig configures a Manager.
+type ManagerConfig struct {
+	// Root is the cache directory. Mirrors live in Root/mirrors and
+	// per-session worktrees in Root/sessions. Required.
+	Root string
+
+	// AllowedHosts lists the repository hosts that may be cloned (e.g.
+	// "github.com"). Empty allows no remote host, so requests cannot make
+	// the server fetch from internal hosts; "*" allows any host.
+	AllowedHosts []string
+
+	// AllowLocal permits file:// URLs and local paths as repositories.
+	AllowLocal bool
+
+	// IdleTTL removes checkouts unused for this long on the next Acquire.
+	// Zero keeps them until Release.
+	IdleTTL time.Duration
+
+	// GitTimeout bounds each git command. Defaults to DefaultGitTimeout.
+	GitTimeout time.Duration
+}
+
+// Checkout is a session's worktree of a repository at a fixed commit.
+type Checkout struct {
+	SessionID string
+	Repo      string
+	Ref       string
+	Commit    string
+	Dir       string
+	LastUsed  time.Time
+}
+
+// Manager provisions isolated git worktrees per session from a shared
+// cache of bare mirrors, so repeated clones of one repository only fetch.
+// It is safe for concurrent use.
+type Manager struct {
+	cfg ManagerConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	checkouts map[string]*Checkout
+	locks     map[string]*keyLock // per session and per mirror, while in use
+	seq       uint64              // numbers worktree directories
+}
+
+// keyLock is a lock of Manager.locks and the number of its holders and
+// waiters.
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewManager creates a Manager rooted at cfg.Root. Worktrees left over from
+// a previous process are removed; mirrors are kept.
+func NewManager(cfg ManagerConfig) (*Manager, error) {
+	if cfg.Root == "" {
+		return nil, errors.New("workspace manager root is required")
+	}
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Root = root
+	if cfg.GitTimeout <= 0 {
+		cfg.GitTimeout = DefaultGitTimeout
+	}
+	if err := os.RemoveAll(filepath.Join(root, "sessions")); err != nil {
+		return nil, fmt.Errorf("clean session worktrees: %w", err)
+	}
+	for _, dir := range []string{"mirrors", "sessions"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	m := &Manager{
+		cfg:       cfg,
+		now:       time.Now,
+		checkouts: make(map[string]*Checkout),
+		locks:     make(map[string]*keyLock),
+	}
+	m.pruneMirrors(context.Background())
+	return m, nil
+}
+
+// Acquire returns the session's worktree of repo at ref, cloning or
+// fetching the mirror and creating the worktree on first use. Later calls
+// with the same repo and ref reuse the worktree, keeping earlier edits.
+// An empty ref checks out the remote's default branch.
+func (m *Manager) Acquire(ctx context.Context, sessionID, repo, ref string) (Checkout, error) {
+	if err := m.validate(repo, ref); err != nil {
+		return Checkout{}, err
+	}
+	m.sweep(ctx)
+
+	unlockSession := m.lock("session:" + sessionID)
+	defer unlockSession()
+
+	m.mu.Lock()
+	if co, ok := m.checkouts[sessionID]; ok {
+		defer m.mu.Unlock()
+		if co.Repo != repo || co.Ref != ref {
+			return Checkout{}, fmt.Errorf("%w (%s@%s)", ErrCheckoutConflict, co.Repo, co.Ref)
+		}
+		co.LastUsed = m.now()
+		return *co, nil
+	}
+	m.mu.Unlock()
+
+	mirror, err := m.syncMirror(ctx, repo)
+	if err != nil {
+		return Checkout{}, err
+	}
+	rev := "HEAD"
+	if ref != "" {
+		rev = ref
+	}
+	commit, err := m.git(ctx, mirror, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return Checkout{}, fmt.Errorf("resolve ref %q: %w", rev, err)
+	}
+
+	// Each checkout gets its own directory, so a detached worktree of the
+	// session still being removed in the background is never reused.
+	m.mu.Lock()
+	m.seq++
+	dir := filepath.Join(m.cfg.Root, "sessions", fmt.Sprintf("%s-%d", hashKey(sessionID), m.seq))
+	m.mu.Unlock()
+	unlockMirror := m.lock(mirror)
+	_, err = m.git(ctx, mirror, "worktree", "add", "--detach", dir, commit)
+	unlockMirror()
+	if err != nil {
+		return Checkout{}, fmt.Errorf("create worktree: %w", err)
+	}
+
+	co := &Checkout{SessionID: sessionID, Repo: repo, Ref: ref, Commit: commit, Dir: dir, LastUsed: m.now()}
+	m.mu.Lock()
+	m.checkouts[sessionID] = co
+	m.mu.Unlock()
+	log.Printf("[workspace] checked out %s@%s (%s) for session %s", repo, rev, shortCommit(commit), sessionID)
+	return *co, nil
+}
+
+// Release removes the session's worktree, if any.
+func (m *Manager) Release(ctx context.Context, sessionID string) error {
+	unlockSession := m.lock("session:" + sessionID)
+	defer unlockSession()
+	return m.Detach(sessionID)(ctx)
+}
+
+// Detach forgets the session's checkout without running git, and returns
+// a function that removes its worktree. A later Acquire for the session
+// creates a new worktree, so the removal can run in the background without
+// touching a checkout in use. Detach does not block on git work.
+func (m *Manager) Detach(sessionID string) func(context.Context) error {
+	m.mu.Lock()
+	co, ok := m.checkouts[sessionID]
+	delete(m.checkouts, sessionID)
+	m.mu.Unlock()
+	if !ok {
+		return func(context.Context) error { return nil }
+	}
+	return func(ctx context.Context) error {
+		return m.removeWorktree(ctx, m.mirrorDir(co.Repo), co.Dir)
+	}
+}
+
+// Checkouts returns the current checkouts.
+func (m *Manager) Checkouts() []Checkout {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Checkout, 0, len(m.checkouts))
+	for _, co := range m.checkouts {
+		out = append(out, *co)
+	}
+	return out
+}
+
+// sweep releases checkouts idle longer than IdleTTL.
+func (m *Manager) sweep(ctx context.Context) {
+	if m.cfg.IdleTTL <= 0 {
+		return
+	}
+	now := m.now()
+	var expired []string
+	m.mu.Lock()
+	for id, co := range m.checkouts {
+		if now.Sub(co.LastUsed) > m.cfg.IdleTTL {
+			expired = append(expired, id)
+		}
+	}
+	m.mu.Unlock()
+	for _, id := range expired {
+		if err := m.Release(ctx, id); err != nil {
+			log.Printf("[workspace] WARNING: failed to remove expired checkout for session %s: %v", id, err)
+		}
+	}
+}
+
+// syncMirror clones repo into its mirror or fetches updates.
+func (m *Manager) syncMirror(ctx context.Context, repo string) (string, error) {
+	mirror := m.mirrorDir(repo)
+	unlock := m.lock(mirror)
+	defer unlock()
+
+	if _, err := os.Stat(mirror); err == nil {
+		if _, err := m.git(ctx, mirror, "fetch", "--prune", "origin"); err != nil {
+			return "", fmt.Errorf("fetch %s: %w", repo, err)
+		}
+		return mirror, nil
+	}
+	if _, err := m.git(ctx, m.cfg.Root, "clone", "--mirror", "--", repo, mirror); err != nil {
+		_ = os.RemoveAll(mirror)
+		return "", fmt.Errorf("clone %s: %w", repo, err)
+	}
+	return mirror, nil
+}
+
+func (m *Manager) removeWorktree(ctx context.Context, mirror, dir string) error {
+	unlock := m.lock(mirror)
+	defer unlock()
+	if _, err := m.git(ctx, mirror, "worktree", "remove", "--force", dir); err == nil {
+		return nil
+	}
+	// Fall back to deleting the directory and dropping git's bookkeeping.
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	_, err := m.git(ctx, mirror, "worktree", "prune")
+	return err
+}
+
+// pruneMirrors drops worktree records for directories removed by NewManager.
+func (m *Manager) pruneMirrors(ctx context.Context) {
+	entries, err := os.ReadDir(filepath.Join(m.cfg.Root, "mirrors"))
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			_, _ = m.git(ctx, filepath.Join(m.cfg.Root, "mirrors", e.Name()), "worktree", "prune")
+		}
+	}
+}
+
+func (m *Manager) mirrorDir(repo string) string {
+	return filepath.Join(m.cfg.Root, "mirrors", hashKey(repo)+".git")
+}
+
+// lock serializes work on one session or mirror. Sessions are locked
+// before mirrors. The lock is dropped from m.locks when its last holder
+// unlocks.
+func (m *Manager) lock(key string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		m.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// validate rejects repository URLs and refs that could make git run
+// commands, read local files, or parse them as options.
+func (m *Manager) validate(repo, ref string) error {
+	if repo == "" {
+		return errors.New("repo is required")
+	}
+	if strings.HasPrefix(repo, "-") || strings.HasPrefix(ref, "-") {
+		return errors.New("repo and ref must not start with '-'")
+	}
+	if strings.ContainsAny(ref, " \t\n~^:?*[\\") || strings.Contains(ref, "..") {
+		return fmt.Errorf("invalid ref %q", ref)
+	}
+
+	host, local, err := repoHost(repo)
+	if err != nil {
+		return err
+	}
+	if local {
+		if !m.cfg.AllowLocal {
+			return errors.New("local repositories are not allowed")
+		}
+		return nil
+	}
+	for _, allowed := range m.cfg.AllowedHosts {
+		if allowed == "*" || strings.EqualFold(host, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("repository host %q is not allowed", host)
+}
+
+// repoHost returns the host of an https, ssh, or scp-style ("git@host:path")
+// URL, or local=true for file URLs and paths.
+func repoHost(repo string) (host string, local bool, err error) {
+	if u, err := url.Parse(repo); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		switch u.Scheme {
+		case "https", "http", "ssh", "git":
+			if u.Hostname() == "" {
+				return "", false, fmt.Errorf("repository URL %q has no host", repo)
+			}
+			return u.Hostname(), false, nil
+		case "file":
+			return "", true, nil
+		default:
+			return "", false, fmt.Errorf("unsupported repository scheme %q", u.Scheme)
+		}
+	}
+	if strings.Contains(repo, "::") {
+		return "", false, fmt.Errorf("unsupported repository transport in %q", repo)
+	}
+	if at, rest, ok := strings.Cut(repo, "@"); ok && !strings.Contains(at, "/") {
+		if h, _, ok := strings.Cut(rest, ":"); ok && h != "" {
+			return h, false, nil
+		}
+	}
+	return "", true, nil
+}
+
+func (m *Manager) git(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.GitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
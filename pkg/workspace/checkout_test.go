@@ -0,0 +1,221 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "init.defaultBranch=main"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newOriginRepo creates a repository with a commit on main and a tag v1,
+// followed by a second commit on main.
+func newOriginRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+	writeFile(t, dir, "README.md", "v1")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "first")
+	runGit(t, dir, "tag", "v1")
+	writeFile(t, dir, "README.md", "v2")
+	runGit(t, dir, "commit", "-am", "second")
+	return dir
+}
+
+func TestManagerAcquireProvisionsIsolatedWorktrees(t *testing.T) {
+	origin := newOriginRepo(t)
+	m, err := NewManager(ManagerConfig{Root: t.TempDir(), AllowLocal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	head, err := m.Acquire(ctx, "s1", origin, "")
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	tagged, err := m.Acquire(ctx, "s2", origin, "v1")
+	if err != nil {
+		t.Fatalf("Acquire v1: %v", err)
+	}
+	if head.Dir == tagged.Dir {
+		t.Fatal("expected separate worktrees per session")
+	}
+	if got := readFile(t, head.Dir, "README.md"); got != "v2" {
+		t.Fatalf("default branch README = %q, want v2", got)
+	}
+	if got := readFile(t, tagged.Dir, "README.md"); got != "v1" {
+		t.Fatalf("v1 README = %q, want v1", got)
+	}
+
+	// Later requests of the session reuse the worktree and keep edits.
+	writeFile(t, head.Dir, "notes.txt", "draft")
+	again, err := m.Acquire(ctx, "s1", origin, "")
+	if err != nil {
+		t.Fatalf("re-Acquire: %v", err)
+	}
+	if again.Dir != head.Dir || readFile(t, again.Dir, "notes.txt") != "draft" {
+		t.Fatal("expected the session's worktree to be reused")
+	}
+
+	if _, err := m.Acquire(ctx, "s1", origin, "v1"); !errors.Is(err, ErrCheckoutConflict) {
+		t.Fatalf("expected ErrCheckoutConflict, got %v", err)
+	}
+
+	if err := m.Release(ctx, "s1"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, err := os.Stat(head.Dir); !os.IsNotExist(err) {
+		t.Fatalf("expected worktree to be removed, stat err=%v", err)
+	}
+	if len(m.Checkouts()) != 1 {
+		t.Fatalf("expected one checkout left, got %v", m.Checkouts())
+	}
+}
+
+func TestManagerDetachThenReuseKeepsNewWorktree(t *testing.T) {
+	origin := newOriginRepo(t)
+	m, err := NewManager(ManagerConfig{Root: t.TempDir(), AllowLocal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	old, err := m.Acquire(ctx, "s1", origin, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The session is evicted and immediately reused before the removal
+	// of its old worktree runs.
+	remove := m.Detach("s1")
+	reused, err := m.Acquire(ctx, "s1", origin, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reused.Dir == old.Dir {
+		t.Fatal("expected the reused session to get a new worktree")
+	}
+	if err := remove(ctx); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := os.Stat(old.Dir); !os.IsNotExist(err) {
+		t.Fatalf("expected the detached worktree to be removed, stat err=%v", err)
+	}
+	if got := readFile(t, reused.Dir, "README.md"); got != "v2" {
+		t.Fatalf("expected the new worktree to survive, README = %q", got)
+	}
+	if len(m.locks) != 0 {
+		t.Fatalf("expected no locks left after use, got %d", len(m.locks))
+	}
+}
+
+func TestManagerFetchesNewCommits(t *testing.T) {
+	origin := newOriginRepo(t)
+	m, err := NewManager(ManagerConfig{Root: t.TempDir(), AllowLocal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := m.Acquire(ctx, "s1", origin, "main"); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, origin, "README.md", "v3")
+	runGit(t, origin, "commit", "-am", "third")
+
+	co, err := m.Acquire(ctx, "s2", origin, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, co.Dir, "README.md"); got != "v3" {
+		t.Fatalf("README = %q, want v3 after fetch", got)
+	}
+}
+
+func TestManagerExpiresIdleCheckouts(t *testing.T) {
+	origin := newOriginRepo(t)
+	m, err := NewManager(ManagerConfig{Root: t.TempDir(), AllowLocal: true, IdleTTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	old, err := m.Acquire(ctx, "old", origin, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := m.Acquire(ctx, "new", origin, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(old.Dir); !os.IsNotExist(err) {
+		t.Fatalf("expected idle worktree to be removed, stat err=%v", err)
+	}
+}
+
+func TestManagerValidatesRepositories(t *testing.T) {
+	root := t.TempDir()
+	m, err := NewManager(ManagerConfig{Root: root, AllowedHosts: []string{"github.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		repo, ref, wantErr string
+	}{
+		{"/etc", "", "local repositories are not allowed"},
+		{"file:///etc", "", "local repositories are not allowed"},
+		{"ext::sh -c touch% /tmp/pwned", "", "unsupported repository scheme"},
+		{"https://gitlab.com/o/r.git", "", `host "gitlab.com" is not allowed`},
+		{"git@gitlab.com:o/r.git", "", `host "gitlab.com" is not allowed`},
+		{"--upload-pack=touch", "", "must not start with '-'"},
+		{"https://github.com/o/r.git", "--output=/tmp/x", "must not start with '-'"},
+		{"https://github.com/o/r.git", "main..dev", "invalid ref"},
+	} {
+		_, err := m.Acquire(context.Background(), "s", tc.repo, tc.ref)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("Acquire(%q, %q) error = %v, want %q", tc.repo, tc.ref, err, tc.wantErr)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, "mirrors")); len(entries) != 0 {
+		t.Fatalf("expected no clones, found %d", len(entries))
+	}
+}
+
+func TestManagerDeniesRemoteHostsByDefault(t *testing.T) {
+	m, err := NewManager(ManagerConfig{Root: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, repo := range []string{"https://github.com/o/r.git", "http://169.254.169.254/r.git", "git@internal:o/r.git"} {
+		if err := m.validate(repo, ""); err == nil || !strings.Contains(err.Error(), "is not allowed") {
+			t.Errorf("validate(%q) = %v, want the host denied", repo, err)
+		}
+	}
+
+	m.cfg.AllowedHosts = []string{"*"}
+	if err := m.validate("https://example.com/o/r.git", ""); err != nil {
+		t.Fatalf("expected \"*\" to allow any host, got %v", err)
+	}
+}
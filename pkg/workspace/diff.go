@@ -0,0 +1,306 @@
+package workspace
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around each hunk.
+const diffContext = 3
+
+// maxDiffEdits bounds the edit distance the line diff searches before it
+// falls back to replacing the whole differing region.
+const maxDiffEdits = 1000
+
+// FileDiff is the net change of one file across a journal's checkpoints.
+type FileDiff struct {
+	Change
+
+	// Before and After are the file contents before the first checkpoint
+	// and now. Before is nil for created files, After for deleted ones.
+	Before []byte
+	After  []byte
+
+	// Binary is set when either side contains a NUL byte. Diff then only
+	// notes that the file differs.
+	Binary bool
+
+	// Diff is the change in git's unified diff format.
+	Diff string
+}
+
+// Diffs returns the net file changes across all recorded checkpoints with
+// their contents and unified diffs, sorted by path.
+func (j *Journal) Diffs() []FileDiff {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	changes := j.netChangesLocked()
+	out := make([]FileDiff, 0, len(changes))
+	for _, c := range changes {
+		d := FileDiff{Change: c.Change}
+		if c.before.existed {
+			d.Before = c.before.content
+		}
+		if c.after.existed {
+			d.After = c.after.content
+		}
+		d.Binary = bytes.IndexByte(d.Before, 0) >= 0 || bytes.IndexByte(d.After, 0) >= 0
+		d.Diff = unifiedDiff(c.Change, c.before, c.after, d.Binary)
+		out = append(out, d)
+	}
+	return out
+}
+
+// Patch returns a single git-style patch of all net changes, suitable for
+// git apply. It is empty when nothing changed.
+func (j *Journal) Patch() string {
+	var b strings.Builder
+	for _, d := range j.Diffs() {
+		b.WriteString(d.Diff)
+	}
+	return b.String()
+}
+
+// UnifiedDiff returns the change from before to after of the file at path,
+// a slash-separated path relative to the repository root, in git's unified
+// diff format. A nil before marks a created file and a nil after a deleted
+// one. It is empty when the contents are equal.
+func UnifiedDiff(path string, before, after []byte) string {
+	if before != nil && after != nil && bytes.Equal(before, after) {
+		return ""
+	}
+	c := Change{Path: path, Kind: ChangeModify}
+	switch {
+	case before == nil:
+		c.Kind = ChangeCreate
+	case after == nil:
+		c.Kind = ChangeDelete
+	}
+	binary := bytes.IndexByte(before, 0) >= 0 || bytes.IndexByte(after, 0) >= 0
+	return unifiedDiff(c, fileState{existed: before != nil, content: before, mode: 0o644}, fileState{existed: after != nil, content: after, mode: 0o644}, binary)
+}
+
+func unifiedDiff(c Change, before, after fileState, binary bool) string {
+	path := filepath.ToSlash(c.Path)
+	oldName, newName := "a/"+path, "b/"+path
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git %s %s\n", oldName, newName)
+	switch c.Kind {
+	case ChangeCreate:
+		fmt.Fprintf(&b, "new file mode %s\n", gitMode(after))
+		oldName = "/dev/null"
+	case ChangeDelete:
+		fmt.Fprintf(&b, "deleted file mode %s\n", gitMode(before))
+		newName = "/dev/null"
+	}
+	if binary {
+		fmt.Fprintf(&b, "Binary files %s and %s differ\n", oldName, newName)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	writeHunks(&b, splitLines(before.content), splitLines(after.content))
+	return b.String()
+}
+
+func gitMode(s fileState) string {
+	if s.mode&0o111 != 0 {
+		return "100755"
+	}
+	return "100644"
+}
+
+// splitLines splits content into lines that keep their "\n", so a missing
+// final newline shows up as a changed last line.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editOp is one line of a line diff: ' ' keeps a[ai], '-' deletes a[ai],
+// '+' inserts b[bi].
+type editOp struct {
+	kind   byte
+	ai, bi int
+}
+
+func writeHunks(b *strings.Builder, a, bLines []string) {
+	ops := diffLines(a, bLines)
+
+	// Group changes whose unchanged gap fits in the surrounding context.
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext+1, len(ops))
+		writeHunk(b, a, bLines, ops[start:end])
+		i = end
+	}
+}
+
+func writeHunk(b *strings.Builder, a, bLines []string, ops []editOp) {
+	aStart, bStart := ops[0].ai, ops[0].bi
+	var aLen, bLen int
+	for _, op := range ops {
+		if op.kind != '+' {
+			aLen++
+		}
+		if op.kind != '-' {
+			bLen++
+		}
+	}
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+	for _, op := range ops {
+		var line string
+		if op.kind == '+' {
+			line = bLines[op.bi]
+		} else {
+			line = a[op.ai]
+		}
+		b.WriteByte(op.kind)
+		b.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, length)
+	}
+}
+
+// diffLines returns a shortest edit script from a to b (Myers' algorithm)
+// after trimming the common prefix and suffix. Each op records the a and b
+// positions it applies at.
+func diffLines(a, b []string) []editOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]editOp, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, editOp{kind: ' ', ai: i, bi: i})
+	}
+	middle := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, op := range middle {
+		op.ai += prefix
+		op.bi += prefix
+		ops = append(ops, op)
+	}
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, editOp{kind: ' ', ai: len(a) - suffix + i, bi: len(b) - suffix + i})
+	}
+	return ops
+}
+
+func myers(a, b []string) []editOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m)
+	}
+	// v[offset+k] is the furthest x reached on diagonal k. trace keeps the
+	// window of v each round reads, v[offset-d-1 : offset+d+2].
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= n+m && d <= maxDiffEdits; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	return replaceAll(n, m)
+}
+
+func backtrack(trace [][]int, x, y int) []editOp {
+	var ops []editOp
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, editOp{kind: ' ', ai: x, bi: y})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, editOp{kind: '+', ai: x, bi: y})
+		} else {
+			x--
+			ops = append(ops, editOp{kind: '-', ai: x, bi: y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, editOp{kind: ' ', ai: x, bi: y})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// replaceAll deletes all n lines of a and inserts all m lines of b.
+func replaceAll(n, m int) []editOp {
+	ops := make([]editOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, editOp{kind: '-', ai: i})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, editOp{kind: '+', ai: n, bi: j})
+	}
+	return ops
+}
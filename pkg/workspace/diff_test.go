@@ -0,0 +1,156 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournalPatchFormat(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.txt", "one\ntwo\nthree\n")
+	writeFile(t, root, "gone.txt", "bye\n")
+	j := NewJournal(root)
+
+	j.Begin("edit")
+	if err := j.SnapshotFiles("a.txt", "gone.txt", "new.txt"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "a.txt", "one\n2\nthree\n")
+	writeFile(t, root, "new.txt", "hello")
+	if err := os.Remove(filepath.Join(root, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	j.End()
+
+	want := strings.Join([]string{
+		"diff --git a/a.txt b/a.txt",
+		"--- a/a.txt",
+		"+++ b/a.txt",
+		"@@ -1,3 +1,3 @@",
+		" one",
+		"-two",
+		"+2",
+		" three",
+		"diff --git a/gone.txt b/gone.txt",
+		"deleted file mode 100644",
+		"--- a/gone.txt",
+		"+++ /dev/null",
+		"@@ -1 +0,0 @@",
+		"-bye",
+		"diff --git a/new.txt b/new.txt",
+		"new file mode 100644",
+		"--- /dev/null",
+		"+++ b/new.txt",
+		"@@ -0,0 +1 @@",
+		"+hello",
+		`\ No newline at end of file`,
+		"",
+	}, "\n")
+	if got := j.Patch(); got != want {
+		t.Fatalf("unexpected patch:\n%s\nwant:\n%s", got, want)
+	}
+
+	diffs := j.Diffs()
+	if len(diffs) != 3 || string(diffs[0].Before) != "one\ntwo\nthree\n" || string(diffs[0].After) != "one\n2\nthree\n" {
+		t.Fatalf("unexpected diffs: %+v", diffs)
+	}
+	if diffs[1].After != nil || diffs[2].Before != nil {
+		t.Fatal("expected nil contents for the missing side of deletes and creates")
+	}
+}
+
+func TestJournalPatchBinary(t *testing.T) {
+	root := t.TempDir()
+	j := NewJournal(root)
+	j.Begin("write")
+	if err := j.SnapshotFiles("blob.bin"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "blob.bin", "\x00\x01")
+	j.End()
+
+	diffs := j.Diffs()
+	if len(diffs) != 1 || !diffs[0].Binary {
+		t.Fatalf("expected one binary diff, got %+v", diffs)
+	}
+	if !strings.Contains(diffs[0].Diff, "Binary files /dev/null and b/blob.bin differ") {
+		t.Fatalf("unexpected binary diff: %q", diffs[0].Diff)
+	}
+}
+
+// TestJournalPatchAppliesWithGit checks that patches for assorted edits
+// turn the original tree into the current one under git apply.
+func TestJournalPatchAppliesWithGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	var long, edited []string
+	for i := 0; i < 60; i++ {
+		long = append(long, fmt.Sprintf("line %d", i))
+	}
+	edited = append(edited, long...)
+	edited[2] = "changed 2"
+	edited = append(edited[:20], append([]string{"inserted a", "inserted b"}, edited[20:]...)...)
+	edited = append(edited[:40], edited[45:]...)
+
+	cases := map[string][2]string{
+		"hunks.txt":      {strings.Join(long, "\n") + "\n", strings.Join(edited, "\n") + "\n"},
+		"eol.txt":        {"a\nb", "a\nb\n"},
+		"drop-eol.txt":   {"a\nb\n", "a\nc"},
+		"rewrite.txt":    {"x\ny\nz\n", "1\n2\n"},
+		"emptied.txt":    {"x\n", ""},
+		"nested/new.txt": {"", "fresh\n"},
+	}
+
+	root, original := t.TempDir(), t.TempDir()
+	for path, c := range cases {
+		if path != "nested/new.txt" {
+			writeFile(t, root, path, c[0])
+			writeFile(t, original, path, c[0])
+		}
+	}
+
+	j := NewJournal(root)
+	j.Begin("edit")
+	if err := j.SnapshotTree(); err != nil {
+		t.Fatal(err)
+	}
+	for path, c := range cases {
+		writeFile(t, root, path, c[1])
+	}
+	j.End()
+
+	patchFile := filepath.Join(t.TempDir(), "changes.patch")
+	if err := os.WriteFile(patchFile, []byte(j.Patch()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", "apply", patchFile)
+	cmd.Dir = original
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git apply: %v\n%s\npatch:\n%s", err, out, j.Patch())
+	}
+	for path, c := range cases {
+		if got := readFile(t, original, path); got != c[1] {
+			t.Errorf("%s after apply = %q, want %q", path, got, c[1])
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if got := UnifiedDiff("a.txt", []byte("same\n"), []byte("same\n")); got != "" {
+		t.Fatalf("expected no diff for equal content, got %q", got)
+	}
+	want := "diff --git a/new.txt b/new.txt\nnew file mode 100644\n--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+hello\n"
+	if got := UnifiedDiff("new.txt", nil, []byte("hello\n")); got != want {
+		t.Fatalf("unexpected create diff:\n%s\nwant:\n%s", got, want)
+	}
+	want = "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-old\n+new\n"
+	if got := UnifiedDiff("a.txt", []byte("old\n"), []byte("new\n")); got != want {
+		t.Fatalf("unexpected modify diff:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,389 @@
+// Package workspace records the original state of workspace files before
+// tools modify them so agent edits can be rolled back.
+package workspace
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxSnapshotBytes bounds the total size of a full-tree snapshot.
+const DefaultMaxSnapshotBytes int64 = 64 << 20
+
+// ErrSnapshotTooLarge is returned when a tree snapshot exceeds MaxSnapshotBytes.
+var ErrSnapshotTooLarge = errors.New("workspace snapshot exceeds size limit")
+
+// skippedDirs are never snapshotted, compared, or cleaned up.
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+}
+
+// Journal is a stack of checkpoints, one per write-capable tool call.
+// It is safe for concurrent use.
+type Journal struct {
+	// MaxSnapshotBytes bounds full-tree snapshots. Defaults to DefaultMaxSnapshotBytes.
+	MaxSnapshotBytes int64
+
+	mu          sync.Mutex
+	root        string
+	open        *checkpoint
+	checkpoints []*checkpoint
+}
+
+type checkpoint struct {
+	label   string
+	tree    bool
+	files   map[string]fileState // relative path -> original state
+	changed []string
+}
+
+type fileState struct {
+	existed bool
+	content []byte
+	mode    fs.FileMode
+}
+
+// NewJournal creates a journal for the workspace rooted at root.
+func NewJournal(root string) *Journal {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+	return &Journal{root: abs, MaxSnapshotBytes: DefaultMaxSnapshotBytes}
+}
+
+// Root returns the workspace root.
+func (j *Journal) Root() string {
+	return j.root
+}
+
+// Begin opens a checkpoint. Snapshots taken until End belong to it.
+func (j *Journal) Begin(label string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.open = &checkpoint{label: label, files: make(map[string]fileState)}
+}
+
+// SnapshotFiles records the current state of paths (relative to the root or
+// absolute inside it) in the open checkpoint.
+func (j *Journal) SnapshotFiles(paths ...string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.open == nil {
+		return errors.New("no open checkpoint")
+	}
+	for _, p := range paths {
+		rel, err := j.rel(p)
+		if err != nil {
+			return err
+		}
+		if _, ok := j.open.files[rel]; ok {
+			continue
+		}
+		state, err := readState(filepath.Join(j.root, rel))
+		if err != nil {
+			return err
+		}
+		j.open.files[rel] = state
+	}
+	return nil
+}
+
+// SnapshotTree records every file under the root in the open checkpoint.
+// Files created after the snapshot are removed on rollback.
+func (j *Journal) SnapshotTree() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.open == nil {
+		return errors.New("no open checkpoint")
+	}
+
+	limit := j.MaxSnapshotBytes
+	if limit <= 0 {
+		limit = DefaultMaxSnapshotBytes
+	}
+	files := make(map[string]fileState)
+	var total int64
+	err := j.walk(func(rel string, info fs.FileInfo) error {
+		total += info.Size()
+		if total > limit {
+			return ErrSnapshotTooLarge
+		}
+		state, err := readState(filepath.Join(j.root, rel))
+		if err != nil {
+			return err
+		}
+		files[rel] = state
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	j.open.tree = true
+	j.open.files = files
+	return nil
+}
+
+// End closes the open checkpoint and returns the paths it changed.
+// Checkpoints without changes are discarded.
+func (j *Journal) End() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	cp := j.open
+	j.open = nil
+	if cp == nil {
+		return nil
+	}
+	cp.changed = j.diff(cp)
+	if len(cp.changed) == 0 {
+		return nil
+	}
+	j.checkpoints = append(j.checkpoints, cp)
+	return append([]string(nil), cp.changed...)
+}
+
+// Len returns the number of checkpoints that can be rolled back.
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.checkpoints)
+}
+
+// ChangeKind describes how a file differs from its original state.
+type ChangeKind string
+
+const (
+	ChangeCreate ChangeKind = "create"
+	ChangeModify ChangeKind = "modify"
+	ChangeDelete ChangeKind = "delete"
+)
+
+// Change is a file whose current state differs from its state before the
+// first recorded checkpoint.
+type Change struct {
+	Path string
+	Kind ChangeKind
+}
+
+// Changes returns the net file changes across all recorded checkpoints,
+// sorted by path.
+func (j *Journal) Changes() []Change {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	net := j.netChangesLocked()
+	changes := make([]Change, 0, len(net))
+	for _, c := range net {
+		changes = append(changes, c.Change)
+	}
+	return changes
+}
+
+// netChange is a Change with the file's original and current state.
+type netChange struct {
+	Change
+	before, after fileState
+}
+
+func (j *Journal) netChangesLocked() []netChange {
+	original := make(map[string]fileState)
+	for _, cp := range j.checkpoints {
+		for _, rel := range cp.changed {
+			if _, ok := original[rel]; !ok {
+				original[rel] = cp.files[rel] // zero value: did not exist
+			}
+		}
+	}
+
+	changes := make([]netChange, 0, len(original))
+	for rel, before := range original {
+		after, err := readState(filepath.Join(j.root, rel))
+		if err != nil || sameState(before, after) {
+			continue
+		}
+		kind := ChangeModify
+		switch {
+		case !before.existed:
+			kind = ChangeCreate
+		case !after.existed:
+			kind = ChangeDelete
+		}
+		changes = append(changes, netChange{Change: Change{Path: rel, Kind: kind}, before: before, after: after})
+	}
+	sort.Slice(changes, func(a, b int) bool { return changes[a].Path < changes[b].Path })
+	return changes
+}
+
+// RollbackLast reverts the most recent checkpoint and returns the restored paths.
+func (j *Journal) RollbackLast() (string, []string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.checkpoints) == 0 {
+		return "", nil, nil
+	}
+	cp := j.checkpoints[len(j.checkpoints)-1]
+	j.checkpoints = j.checkpoints[:len(j.checkpoints)-1]
+	restored, err := j.restore(cp)
+	return cp.label, restored, err
+}
+
+// RollbackAll reverts every checkpoint, newest first, and returns the restored paths.
+func (j *Journal) RollbackAll() ([]string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	seen := make(map[string]bool)
+	var out []string
+	var errs []error
+	for i := len(j.checkpoints) - 1; i >= 0; i-- {
+		restored, err := j.restore(j.checkpoints[i])
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, p := range restored {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	j.checkpoints = nil
+	sort.Strings(out)
+	return out, errors.Join(errs...)
+}
+
+func (j *Journal) restore(cp *checkpoint) ([]string, error) {
+	var restored []string
+	var errs []error
+
+	if cp.tree {
+		// Remove files created after the snapshot.
+		_ = j.walk(func(rel string, _ fs.FileInfo) error {
+			if _, ok := cp.files[rel]; !ok {
+				if err := os.Remove(filepath.Join(j.root, rel)); err != nil {
+					errs = append(errs, err)
+				} else {
+					restored = append(restored, rel)
+				}
+			}
+			return nil
+		})
+	}
+
+	for rel, state := range cp.files {
+		abs := filepath.Join(j.root, rel)
+		current, err := readState(abs)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if sameState(current, state) {
+			continue
+		}
+		if !state.existed {
+			if err := os.Remove(abs); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+				continue
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := os.WriteFile(abs, state.content, state.mode.Perm()); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		restored = append(restored, rel)
+	}
+	sort.Strings(restored)
+	return restored, errors.Join(errs...)
+}
+
+// diff returns the paths whose current state differs from the checkpoint.
+func (j *Journal) diff(cp *checkpoint) []string {
+	var changed []string
+	for rel, state := range cp.files {
+		current, err := readState(filepath.Join(j.root, rel))
+		if err != nil || !sameState(current, state) {
+			changed = append(changed, rel)
+		}
+	}
+	if cp.tree {
+		_ = j.walk(func(rel string, _ fs.FileInfo) error {
+			if _, ok := cp.files[rel]; !ok {
+				changed = append(changed, rel)
+			}
+			return nil
+		})
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func (j *Journal) walk(fn func(rel string, info fs.FileInfo) error) error {
+	return filepath.Walk(j.root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != j.root && skippedDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(j.root, path)
+		if err != nil {
+			return err
+		}
+		return fn(rel, info)
+	})
+}
+
+func (j *Journal) rel(p string) (string, error) {
+	abs := p
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(j.root, p)
+	}
+	rel, err := filepath.Rel(j.root, filepath.Clean(abs))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path outside workspace: %s", p)
+	}
+	return rel, nil
+}
+
+func readState(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, err
+	}
+	if info.IsDir() {
+		return fileState{}, fmt.Errorf("cannot snapshot directory: %s", path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{existed: true, content: content, mode: info.Mode()}, nil
+}
+
+func sameState(a, b fileState) bool {
+	if a.existed != b.existed {
+		return false
+	}
+	return !a.existed || bytes.Equal(a.content, b.content)
+}
@@ -0,0 +1,154 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, root, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, rel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestJournalRollbackLastRestoresFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.txt", "original")
+	j := NewJournal(root)
+
+	j.Begin("write_file (1)")
+	if err := j.SnapshotFiles("a.txt", "new.txt"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "a.txt", "changed")
+	writeFile(t, root, "new.txt", "created")
+	if changed := j.End(); !reflect.DeepEqual(changed, []string{"a.txt", "new.txt"}) {
+		t.Fatalf("unexpected changed paths: %v", changed)
+	}
+
+	label, restored, err := j.RollbackLast()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label != "write_file (1)" || !reflect.DeepEqual(restored, []string{"a.txt", "new.txt"}) {
+		t.Fatalf("unexpected rollback: label=%q restored=%v", label, restored)
+	}
+	if got := readFile(t, root, "a.txt"); got != "original" {
+		t.Fatalf("expected original content, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected created file to be removed, stat err=%v", err)
+	}
+	if j.Len() != 0 {
+		t.Fatalf("expected no checkpoints left, got %d", j.Len())
+	}
+}
+
+func TestJournalDiscardsUnchangedCheckpoints(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.txt", "same")
+	j := NewJournal(root)
+
+	j.Begin("noop")
+	if err := j.SnapshotFiles("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if changed := j.End(); changed != nil {
+		t.Fatalf("expected no changes, got %v", changed)
+	}
+	if j.Len() != 0 {
+		t.Fatalf("expected unchanged checkpoint to be discarded")
+	}
+}
+
+func TestJournalTreeSnapshotRemovesCreatedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "src/main.go", "package main")
+	writeFile(t, root, ".git/HEAD", "ref: refs/heads/main")
+	j := NewJournal(root)
+
+	j.Begin("bash (1)")
+	if err := j.SnapshotTree(); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "src/main.go", "broken")
+	writeFile(t, root, "src/extra.go", "package main")
+	writeFile(t, root, ".git/index", "ignored")
+	j.End()
+
+	restored, err := j.RollbackAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored, []string{"src/extra.go", "src/main.go"}) {
+		t.Fatalf("unexpected restored paths: %v", restored)
+	}
+	if got := readFile(t, root, "src/main.go"); got != "package main" {
+		t.Fatalf("expected original content, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".git/index")); err != nil {
+		t.Fatalf("expected .git to be left alone: %v", err)
+	}
+}
+
+func TestJournalTreeSnapshotSizeLimit(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "big.txt", "0123456789")
+	j := NewJournal(root)
+	j.MaxSnapshotBytes = 5
+
+	j.Begin("bash (1)")
+	if err := j.SnapshotTree(); err != ErrSnapshotTooLarge {
+		t.Fatalf("expected ErrSnapshotTooLarge, got %v", err)
+	}
+}
+
+func TestJournalRollbackAllRestoresOldestState(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.txt", "v1")
+	j := NewJournal(root)
+
+	for _, content := range []string{"v2", "v3"} {
+		j.Begin("write_file")
+		if err := j.SnapshotFiles("a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, root, "a.txt", content)
+		j.End()
+	}
+
+	changes := j.Changes()
+	if !reflect.DeepEqual(changes, []Change{{Path: "a.txt", Kind: ChangeModify}}) {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+	if _, err := j.RollbackAll(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, root, "a.txt"); got != "v1" {
+		t.Fatalf("expected v1, got %q", got)
+	}
+}
+
+func TestJournalRejectsPathsOutsideRoot(t *testing.T) {
+	j := NewJournal(t.TempDir())
+	j.Begin("write_file")
+	if err := j.SnapshotFiles("../escape.txt"); err == nil {
+		t.Fatal("expected error for path outside workspace")
+	}
+}
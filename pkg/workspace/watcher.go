@@ -0,0 +1,260 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	defaultWatchGrace    = 250 * time.Millisecond
+	defaultWatchMaxDirs  = 2000
+	defaultWatchMaxFiles = 50
+)
+
+// DefaultWatchIgnore lists the directory names a Watcher skips when
+// WatchConfig.Ignore is empty.
+var DefaultWatchIgnore = []string{".git", "node_modules", "vendor", ".venv", "__pycache__"}
+
+// WatchConfig configures a Watcher.
+type WatchConfig struct {
+	// Ignore lists directory names that are not watched, at any depth.
+	// Empty uses DefaultWatchIgnore.
+	Ignore []string
+
+	// Grace is how long after Resume events are still attributed to the
+	// agent, since they are delivered asynchronously (default 250ms).
+	Grace time.Duration
+
+	// MaxDirs caps the number of watched directories (default 2000).
+	// Directories beyond it are not watched.
+	MaxDirs int
+
+	// MaxFiles caps the files listed per report (default 50); the rest
+	// are counted.
+	MaxFiles int
+}
+
+// FileChangeOp is the kind of a FileChange.
+type FileChangeOp string
+
+const (
+	FileCreated  FileChangeOp = "created"
+	FileModified FileChangeOp = "modified"
+	FileRemoved  FileChangeOp = "removed"
+)
+
+// FileChange is a file changed outside the agent.
+type FileChange struct {
+	// Path is relative to the watched root.
+	Path string
+	Op   FileChangeOp
+}
+
+// Watcher reports files under a directory that change while the agent is
+// not running tools, e.g. because a human edits alongside the agent.
+// Changes made between Pause and Resume (plus WatchConfig.Grace) are
+// attributed to the agent and dropped. It is safe for concurrent use.
+type Watcher struct {
+	root    string
+	cfg     WatchConfig
+	ignore  map[string]bool
+	watcher *fsnotify.Watcher
+
+	mu       sync.Mutex
+	dirs     int
+	paused   bool
+	quietTil time.Time
+	changes  map[string]FileChangeOp
+	done     chan struct{}
+}
+
+// NewWatcher starts watching root and its subdirectories.
+func NewWatcher(root string, cfg WatchConfig) (*Watcher, error) {
+	if cfg.Grace <= 0 {
+		cfg.Grace = defaultWatchGrace
+	}
+	if cfg.MaxDirs <= 0 {
+		cfg.MaxDirs = defaultWatchMaxDirs
+	}
+	if cfg.MaxFiles <= 0 {
+		cfg.MaxFiles = defaultWatchMaxFiles
+	}
+	if len(cfg.Ignore) == 0 {
+		cfg.Ignore = DefaultWatchIgnore
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolve watch root: %w", err)
+	}
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
+	w := &Watcher{
+		root:    root,
+		cfg:     cfg,
+		ignore:  make(map[string]bool, len(cfg.Ignore)),
+		watcher: fw,
+		changes: make(map[string]FileChangeOp),
+		done:    make(chan struct{}),
+	}
+	for _, name := range cfg.Ignore {
+		w.ignore[name] = true
+	}
+	if err := w.addTree(root); err != nil {
+		fw.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Pause starts a stretch of agent activity, e.g. a batch of tool calls.
+func (w *Watcher) Pause() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.paused = true
+}
+
+// Resume ends a stretch of agent activity.
+func (w *Watcher) Resume() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.paused = false
+	w.quietTil = time.Now().Add(w.cfg.Grace)
+}
+
+// Changes returns the files changed outside the agent since the last call,
+// sorted by path.
+func (w *Watcher) Changes() []FileChange {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.changes) == 0 {
+		return nil
+	}
+	out := make([]FileChange, 0, len(w.changes))
+	for path, op := range w.changes {
+		out = append(out, FileChange{Path: path, Op: op})
+	}
+	w.changes = make(map[string]FileChangeOp)
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// MaxFiles is the number of files a report should list.
+func (w *Watcher) MaxFiles() int {
+	return w.cfg.MaxFiles
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	for {
+		select {
+		case evt, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handle(evt)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[workspace] WARNING: watcher error: %v", err)
+		}
+	}
+}
+
+func (w *Watcher) handle(evt fsnotify.Event) {
+	if evt.Has(fsnotify.Create) {
+		if info, err := os.Stat(evt.Name); err == nil && info.IsDir() {
+			if !w.ignore[filepath.Base(evt.Name)] {
+				if err := w.addTree(evt.Name); err != nil {
+					log.Printf("[workspace] WARNING: watch %s: %v", evt.Name, err)
+				}
+			}
+			return
+		}
+	}
+
+	var op FileChangeOp
+	switch {
+	case evt.Has(fsnotify.Create):
+		op = FileCreated
+	case evt.Has(fsnotify.Write):
+		op = FileModified
+	case evt.Has(fsnotify.Remove), evt.Has(fsnotify.Rename):
+		op = FileRemoved
+	default:
+		return
+	}
+	rel, err := filepath.Rel(w.root, evt.Name)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.paused || time.Now().Before(w.quietTil) {
+		return
+	}
+	// A file created and then written is still new; one removed and
+	// recreated was modified.
+	switch prev, seen := w.changes[rel]; {
+	case seen && prev == FileCreated && op == FileModified:
+	case seen && prev == FileRemoved && op == FileCreated:
+		w.changes[rel] = FileModified
+	default:
+		w.changes[rel] = op
+	}
+}
+
+// addTree watches dir and its subdirectories, skipping ignored names.
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != w.root && w.ignore[d.Name()] {
+			return filepath.SkipDir
+		}
+		w.mu.Lock()
+		full := w.dirs >= w.cfg.MaxDirs
+		if !full {
+			w.dirs++
+		}
+		w.mu.Unlock()
+		if full {
+			log.Printf("[workspace] WARNING: watching at most %d directories; %s and later directories are not watched", w.cfg.MaxDirs, path)
+			return fs.SkipAll
+		}
+		if err := w.watcher.Add(path); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return fmt.Errorf("watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
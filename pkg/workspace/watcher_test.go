@@ -0,0 +1,99 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForChanges polls w until it reports changes or a second passes.
+func waitForChanges(t *testing.T, w *Watcher) []FileChange {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		// Let related events (e.g. create then write) arrive together.
+		time.Sleep(50 * time.Millisecond)
+		if changes := w.Changes(); len(changes) > 0 {
+			return changes
+		}
+	}
+	return nil
+}
+
+func TestWatcherReportsExternalChanges(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWatcher(dir, WatchConfig{Grace: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	defer w.Close()
+
+	if err := os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changes := waitForChanges(t, w)
+	if len(changes) != 1 || changes[0] != (FileChange{Path: "a.txt", Op: FileModified}) {
+		t.Fatalf("changes = %+v, want a.txt modified", changes)
+	}
+
+	// Files in directories created after the watcher started are reported.
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	changes = waitForChanges(t, w)
+	time.Sleep(50 * time.Millisecond)
+	changes = append(changes, w.Changes()...)
+	want := map[string]FileChangeOp{"a.txt": FileRemoved, filepath.Join("sub", "b.txt"): FileCreated}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v, want %v", changes, want)
+	}
+	for _, change := range changes {
+		if want[change.Path] != change.Op {
+			t.Fatalf("changes = %+v, want %v", changes, want)
+		}
+	}
+}
+
+func TestWatcherIgnoresChangesWhilePaused(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWatcher(dir, WatchConfig{Grace: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+	defer w.Close()
+
+	w.Pause()
+	if err := os.WriteFile(filepath.Join(dir, "agent.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w.Resume()
+	time.Sleep(150 * time.Millisecond)
+	if changes := w.Changes(); len(changes) != 0 {
+		t.Fatalf("expected the agent's changes to be ignored, got %+v", changes)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "human.txt"), []byte("h"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changes := waitForChanges(t, w)
+	if len(changes) != 1 || changes[0] != (FileChange{Path: "human.txt", Op: FileCreated}) {
+		t.Fatalf("changes = %+v, want human.txt created", changes)
+	}
+}